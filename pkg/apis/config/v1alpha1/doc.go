@@ -0,0 +1,4 @@
+// Package v1alpha1 contains the v1alpha1 configuration API of the registration hub controller and agent, which is
+// loaded from the file of the --config flag, so the registration can be configured declaratively.
+// +groupName=config.registration.open-cluster-management.io
+package v1alpha1
@@ -0,0 +1,17 @@
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// GroupName is the group of the configuration API
+	GroupName = "config.registration.open-cluster-management.io"
+
+	// RegistrationHubConfigurationKind and RegistrationAgentConfigurationKind are the kinds of the configurations
+	RegistrationHubConfigurationKind   = "RegistrationHubConfiguration"
+	RegistrationAgentConfigurationKind = "RegistrationAgentConfiguration"
+)
+
+// GroupVersion is the group version of the configuration API
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
@@ -0,0 +1,619 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RegistrationHubConfiguration is the configuration of the registration hub controller. Each field mirrors a flag of
+// the controller, the fields which are not set keep the defaults of the flags, and the flags set on the command line
+// take precedence over the fields.
+type RegistrationHubConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// AddOnCSRPruneAge is the --addon-csr-prune-age flag. The age after which the approved, denied or expired
+	// certificate signing requests of the addons are pruned from the hub. Set it to 0 to disable the pruning.
+	AddOnCSRPruneAge *metav1.Duration `json:"addOnCSRPruneAge,omitempty" flag:"addon-csr-prune-age"`
+
+	// AddOnLabelSelector is the --addon-label-selector flag. The label selector of the managed cluster addons handled
+	// by the hub. If it is not set, all of the addons are handled.
+	AddOnLabelSelector *string `json:"addOnLabelSelector,omitempty" flag:"addon-label-selector"`
+
+	// AddOnResyncInterval is the --addon-resync-interval flag. The resync interval of the informers for managed
+	// cluster addons.
+	AddOnResyncInterval *metav1.Duration `json:"addOnResyncInterval,omitempty" flag:"addon-resync-interval"`
+
+	// AddOnSignerCASecrets is the --addon-signer-ca-secrets flag. The custom signers of the addons and the
+	// namespace/name of the secrets holding their CAs in the keys tls.crt and tls.key, e.g.
+	// example.com/signer=open-cluster-management-hub/example-signer-ca. The approved certificate signing requests of
+	// these signers are signed by the hub.
+	AddOnSignerCASecrets map[string]string `json:"addOnSignerCASecrets,omitempty" flag:"addon-signer-ca-secrets"`
+
+	// AddOnSignerCertDuration is the --addon-signer-cert-duration flag. The max duration of the certificates signed
+	// for the custom signers of the addons and the cluster serving signer.
+	AddOnSignerCertDuration *metav1.Duration `json:"addOnSignerCertDuration,omitempty" flag:"addon-signer-cert-duration"`
+
+	// AddOnStatusConditionSuffixes is the --addon-status-condition-suffixes flag. The addon condition types and the
+	// suffixes appended to the feature.open-cluster-management.io/addon-<addon name> labels of the clusters when the
+	// conditions are true, e.g. Degraded=degraded,Progressing=progressing. The suffixes are appended in the order of
+	// the condition types, e.g. available-degraded-progressing.
+	AddOnStatusConditionSuffixes map[string]string `json:"addOnStatusConditionSuffixes,omitempty" flag:"addon-status-condition-suffixes"`
+
+	// AgentlessRegistrationBindAddress is the --agentless-registration-bind-address flag. The address to serve the
+	// agent-less registration endpoint on.
+	AgentlessRegistrationBindAddress *string `json:"agentlessRegistrationBindAddress,omitempty" flag:"agentless-registration-bind-address"`
+
+	// AgentlessRegistrationCertFile is the --agentless-registration-cert-file flag. The serving certificate file of
+	// the agent-less registration endpoint.
+	AgentlessRegistrationCertFile *string `json:"agentlessRegistrationCertFile,omitempty" flag:"agentless-registration-cert-file"`
+
+	// AgentlessRegistrationClientCAFile is the --agentless-registration-client-ca-file flag. The CA bundle file to
+	// verify the client certificates of the heartbeats, it is the CA bundle of the kube-apiserver-client signer on the
+	// hub.
+	AgentlessRegistrationClientCAFile *string `json:"agentlessRegistrationClientCAFile,omitempty" flag:"agentless-registration-client-ca-file"`
+
+	// AgentlessRegistrationKeyFile is the --agentless-registration-key-file flag. The serving key file of the agent-
+	// less registration endpoint.
+	AgentlessRegistrationKeyFile *string `json:"agentlessRegistrationKeyFile,omitempty" flag:"agentless-registration-key-file"`
+
+	// AvailabilityFlapHoldDown is the --availability-flap-hold-down flag. The initial hold-down of a flapping managed
+	// cluster, it is doubled each time the cluster flaps while it is held down. The cluster is released once it is
+	// stable for the whole hold-down.
+	AvailabilityFlapHoldDown *metav1.Duration `json:"availabilityFlapHoldDown,omitempty" flag:"availability-flap-hold-down"`
+
+	// AvailabilityFlapThreshold is the --availability-flap-threshold flag. The number of the transitions of the
+	// available condition of a managed cluster within the flap window, above which the cluster is held down with the
+	// Flapping condition and tainted as unavailable. Set it to 0 to disable the flap detection. It requires the
+	// ClusterTaint feature.
+	AvailabilityFlapThreshold *int `json:"availabilityFlapThreshold,omitempty" flag:"availability-flap-threshold"`
+
+	// AvailabilityFlapWindow is the --availability-flap-window flag. The window in which the transitions of the
+	// available condition of a managed cluster are counted.
+	AvailabilityFlapWindow *metav1.Duration `json:"availabilityFlapWindow,omitempty" flag:"availability-flap-window"`
+
+	// AvailabilityHistoryLength is the --availability-history-length flag. The number of the last transitions of the
+	// available condition of each managed cluster recorded with their timestamps in the configmap availability-history
+	// of its cluster namespace for flap analysis. Set it to 0 to disable the history.
+	AvailabilityHistoryLength *int `json:"availabilityHistoryLength,omitempty" flag:"availability-history-length"`
+
+	// AWSIAMRolePatterns is the --aws-iam-role-patterns flag. A list of the AWS IAM role ARN patterns allowed for the
+	// managed clusters registered with the awsirsa driver, e.g. arn:aws:iam::123456789012:role/ocm_{cluster}_*. The
+	// {cluster} in the patterns is replaced with the cluster name, it must be delimited by any of :/_+=,@ which the
+	// cluster names cannot contain. The matched roles are mapped to the clusters in the aws-auth configmap of the EKS
+	// hub. It requires the AWSIAMRegistrationDriver feature.
+	AWSIAMRolePatterns []string `json:"awsIAMRolePatterns,omitempty" flag:"aws-iam-role-patterns"`
+
+	// BootstrapKubeconfigSecret is the --bootstrap-kubeconfig-secret flag. The namespace/name of the secret holding
+	// the bootstrap kubeconfig on hub. If it is set, the bootstrap kubeconfig is published to the managed clusters to
+	// refresh their local bootstrap kubeconfig.
+	BootstrapKubeconfigSecret *string `json:"bootstrapKubeconfigSecret,omitempty" flag:"bootstrap-kubeconfig-secret"`
+
+	// ClockSkewTolerance is the --clock-skew-tolerance flag. The tolerated skew of the clocks of the managed clusters
+	// from the clock of the hub, measured with the renewals of their leases. The clusters beyond it get the
+	// ClockOutOfSync condition, and their leases are evaluated with the time the hub receives the renewals. Set it to
+	// 0 to disable the check.
+	ClockSkewTolerance *metav1.Duration `json:"clockSkewTolerance,omitempty" flag:"clock-skew-tolerance"`
+
+	// ClusterAcceptanceReview is the --cluster-acceptance-review flag. Accept the new clusters only after a reviewer
+	// sets the annotation cluster.open-cluster-management.io/acceptance-review of the clusters to approved, which
+	// requires the approve verb on the managedclusters/accept subresource of the register.open-cluster-management.io
+	// group. The clusters accepted by the auto approval are not reviewed. Enable the --require-acceptance-review of
+	// the webhook to prevent the other users from accepting the clusters without the review.
+	ClusterAcceptanceReview *bool `json:"clusterAcceptanceReview,omitempty" flag:"cluster-acceptance-review"`
+
+	// ClusterAcceptanceReviewTimeout is the --cluster-acceptance-review-timeout flag. The period after which the
+	// clusters not reviewed are denied. If it is not set, the review does not time out.
+	ClusterAcceptanceReviewTimeout *metav1.Duration `json:"clusterAcceptanceReviewTimeout,omitempty" flag:"cluster-acceptance-review-timeout"`
+
+	// ClusterAutoApprovalDeniedClusters is the --cluster-auto-approval-denied-clusters flag. A list of cluster names
+	// or name patterns (e.g. dev-*) whose registration requests cannot be automatically approved.
+	ClusterAutoApprovalDeniedClusters []string `json:"clusterAutoApprovalDeniedClusters,omitempty" flag:"cluster-auto-approval-denied-clusters"`
+
+	// ClusterAutoApprovalDeniedUsers is the --cluster-auto-approval-denied-users flag. A bootstrap user list whose
+	// cluster registration requests cannot be automatically approved, even if the users are in the auto approval user
+	// list.
+	ClusterAutoApprovalDeniedUsers []string `json:"clusterAutoApprovalDeniedUsers,omitempty" flag:"cluster-auto-approval-denied-users"`
+
+	// ClusterAutoApprovalUsers is the --cluster-auto-approval-users flag. A bootstrap user list whose cluster
+	// registration requests can be automatically approved.
+	ClusterAutoApprovalUsers []string `json:"clusterAutoApprovalUsers,omitempty" flag:"cluster-auto-approval-users"`
+
+	// ClusterCertExpirationWarningPeriod is the --cluster-cert-expiration-warning-period flag. The period before the
+	// expiration of the client certificate of a managed cluster when the hub starts warning about it. It should be
+	// shorter than the renewal window of the client certificates. Set it to 0 to disable the warning.
+	ClusterCertExpirationWarningPeriod *metav1.Duration `json:"clusterCertExpirationWarningPeriod,omitempty" flag:"cluster-cert-expiration-warning-period"`
+
+	// ClusterCSRAllowedDNSNames is the --cluster-csr-allowed-dns-names flag. A list of DNS names or name patterns
+	// (e.g. *.example.com) allowed in the subject alternative names of the automatically approved cluster csrs.
+	ClusterCSRAllowedDNSNames []string `json:"clusterCSRAllowedDNSNames,omitempty" flag:"cluster-csr-allowed-dns-names"`
+
+	// ClusterCSRAllowedIPRanges is the --cluster-csr-allowed-ip-ranges flag. A list of IPv4 or IPv6 ranges in CIDR
+	// notation allowed in the subject alternative names of the automatically approved cluster csrs.
+	ClusterCSRAllowedIPRanges []string `json:"clusterCSRAllowedIPRanges,omitempty" flag:"cluster-csr-allowed-ip-ranges"`
+
+	// ClusterIdentitySecretNamespace is the --cluster-identity-secret-namespace flag. The namespace of the secrets
+	// holding the pre-shared keys of the clusters in the key identity.key, the secrets are named after the clusters.
+	// If it is set, the cluster registration requests are automatically approved only if they are annotated with the
+	// identity proofs made with the keys.
+	ClusterIdentitySecretNamespace *string `json:"clusterIdentitySecretNamespace,omitempty" flag:"cluster-identity-secret-namespace"`
+
+	// ClusterLabelSelector is the --cluster-label-selector flag. The label selector of the managed clusters handled by
+	// the per cluster controllers of the hub, e.g. cluster.open-cluster-management.io/clusterset=prod. The controllers
+	// which are not per cluster handle all of the managed clusters. If it is not set, all of the managed clusters are
+	// handled.
+	ClusterLabelSelector *string `json:"clusterLabelSelector,omitempty" flag:"cluster-label-selector"`
+
+	// ClusterNamespacePolicyConfigMap is the --cluster-namespace-policy-configmap flag. The namespace/name of the
+	// ConfigMap holding the policy to provision the namespaces of the accepted clusters with the labels, annotations,
+	// resource quota and limit range. The policy is read from the key policy.yaml.
+	ClusterNamespacePolicyConfigMap *string `json:"clusterNamespacePolicyConfigMap,omitempty" flag:"cluster-namespace-policy-configmap"`
+
+	// ClusterResyncInterval is the --cluster-resync-interval flag. The resync interval of the informers for managed
+	// clusters and managed cluster sets.
+	ClusterResyncInterval *metav1.Duration `json:"clusterResyncInterval,omitempty" flag:"cluster-resync-interval"`
+
+	// ClusterServingSignerCASecret is the --cluster-serving-signer-ca-secret flag. The namespace/name of the secret
+	// holding the CA of the open-cluster-management.io/cluster-serving signer in the keys tls.crt and tls.key. The
+	// serving certificate signing requests of the agents are approved if their subject alternative names are allowed
+	// by --cluster-csr-allowed-dns-names and --cluster-csr-allowed-ip-ranges, and are signed by the hub with the CA.
+	ClusterServingSignerCASecret *string `json:"clusterServingSignerCASecret,omitempty" flag:"cluster-serving-signer-ca-secret"`
+
+	// ControllerLogLevels is the --controller-log-levels flag. The verbosities of the syncs of the controllers
+	// overriding -v, e.g. CSRApprovingController=4,ManagedClusterLeaseController=0. The log lines out of the
+	// controller syncs are still filtered by -v.
+	ControllerLogLevels map[string]int `json:"controllerLogLevels,omitempty" flag:"controller-log-levels"`
+
+	// CSRAuditSinks is the --csr-audit-sinks flag. The sinks to record the auto approval decisions of certificate
+	// signing requests, the supported sinks are event, log and webhook.
+	CSRAuditSinks []string `json:"csrAuditSinks,omitempty" flag:"csr-audit-sinks"`
+
+	// CSRAuditWebhookURL is the --csr-audit-webhook-url flag. The url of the external webhook endpoint to post the
+	// approval records, it is required by the webhook audit sink.
+	CSRAuditWebhookURL *string `json:"csrAuditWebhookURL,omitempty" flag:"csr-audit-webhook-url"`
+
+	// CSRPendingCondition is the --csr-pending-condition flag. If true, the PendingCSR condition is reported on the
+	// managed clusters whose registration certificate signing requests are awaiting the approval.
+	CSRPendingCondition *bool `json:"csrPendingCondition,omitempty" flag:"csr-pending-condition"`
+
+	// CSRResyncInterval is the --csr-resync-interval flag. The resync interval of the informers for certificate
+	// signing requests.
+	CSRResyncInterval *metav1.Duration `json:"csrResyncInterval,omitempty" flag:"csr-resync-interval"`
+
+	// DryRun is the --dry-run flag. If true, the writes of the hub controllers, e.g. csr approvals, label patches,
+	// taints and namespace deletions, are sent as server-side dry-run requests and logged, so they are not persisted.
+	DryRun *bool `json:"dryRun,omitempty" flag:"dry-run"`
+
+	// FeatureGates is the --feature-gates flag. A set of key=value pairs that describe feature gates for
+	// alpha/experimental features. Options are: AddOnInstallLabels=true|false (ALPHA - default=false)
+	// AgentlessRegistration=true|false (ALPHA - default=false) AllAlpha=true|false (ALPHA - default=false)
+	// AllBeta=true|false (BETA - default=false) ClusterTaint=true|false (BETA - default=true)
+	// DefaultClusterSet=true|false (ALPHA - default=false) MQTTTransport=true|false (ALPHA - default=false)
+	// ManagedClusterAutoApproval=true|false (ALPHA - default=false) V1beta1CSRAPICompatibility=true|false (ALPHA -
+	// default=false)
+	FeatureGates map[string]bool `json:"featureGates,omitempty" flag:"feature-gates"`
+
+	// FeatureGatesFile is the --feature-gates-file flag. The file holding a map of the feature gates to true or false,
+	// it takes precedence over --feature-gates. The feature gates are not reloaded in-process, the controller manager
+	// exits to be restarted once any feature gate in the file is toggled.
+	FeatureGatesFile *string `json:"featureGatesFile,omitempty" flag:"feature-gates-file"`
+
+	// HubCABundleConfigMap is the --hub-ca-bundle-configmap flag. The namespace/name of the configmap holding the CA
+	// bundle of the hub apiserver in the key ca.crt. If it is set, the CA bundle is published to the managed clusters
+	// to refresh the CA of their hub kubeconfig once it is rotated.
+	HubCABundleConfigMap *string `json:"hubCABundleConfigMap,omitempty" flag:"hub-ca-bundle-configmap"`
+
+	// KubeAPIBurst is the --kube-api-burst flag. The burst of the clients of the hub controllers, it defaults to 200.
+	// If it is 0, the burst of the kubeconfig is used.
+	KubeAPIBurst *int `json:"kubeAPIBurst,omitempty" flag:"kube-api-burst"`
+
+	// KubeAPIQPS is the --kube-api-qps flag. The QPS of the clients of the hub controllers, it defaults to 100. If it
+	// is 0, the QPS of the kubeconfig is used.
+	KubeAPIQPS *float32 `json:"kubeAPIQPS,omitempty" flag:"kube-api-qps"`
+
+	// LeaseLabelSelector is the --lease-label-selector flag. The label selector of the cluster leases watched by the
+	// hub, e.g. open-cluster-management.io/cluster-name. If it is not set, all of the leases are watched. The addon
+	// leases are not filtered by it.
+	LeaseLabelSelector *string `json:"leaseLabelSelector,omitempty" flag:"lease-label-selector"`
+
+	// LoggingFormat is the --logging-format flag. The format of the log lines, text or json. The log lines of the
+	// controller syncs carry the controller, queueKey, cluster and addon fields in both formats.
+	LoggingFormat *string `json:"loggingFormat,omitempty" flag:"logging-format"`
+
+	// MQTTBrokerURL is the --mqtt-broker-url flag. The URL of the MQTT broker for the mqtt registration transport,
+	// e.g. tls://broker:8883.
+	MQTTBrokerURL *string `json:"mqttBrokerURL,omitempty" flag:"mqtt-broker-url"`
+
+	// MQTTCAFile is the --mqtt-ca-file flag. The CA file to verify the MQTT broker. If it is not set, the system CAs
+	// are used.
+	MQTTCAFile *string `json:"mqttCAFile,omitempty" flag:"mqtt-ca-file"`
+
+	// MQTTClientCertFile is the --mqtt-client-cert-file flag. The client certificate file to authenticate to the MQTT
+	// broker.
+	MQTTClientCertFile *string `json:"mqttClientCertFile,omitempty" flag:"mqtt-client-cert-file"`
+
+	// MQTTClientKeyFile is the --mqtt-client-key-file flag. The client key file to authenticate to the MQTT broker.
+	MQTTClientKeyFile *string `json:"mqttClientKeyFile,omitempty" flag:"mqtt-client-key-file"`
+
+	// OIDCUsernamePrefix is the --oidc-username-prefix flag. The username prefix of the tokens of the managed clusters
+	// registered with the oidc driver, the same as the one of the hub kube-apiserver. It is used only if the
+	// OIDCRegistrationDriver feature is enabled.
+	OIDCUsernamePrefix *string `json:"oidcUsernamePrefix,omitempty" flag:"oidc-username-prefix"`
+
+	// RegistrationTransport is the --registration-transport flag. The transport of the heartbeats and status of the
+	// managed clusters, it must be one of kube and mqtt. With mqtt, the hub consumes them from the MQTT broker. The
+	// mqtt transport requires the MQTTTransport feature.
+	RegistrationTransport *string `json:"registrationTransport,omitempty" flag:"registration-transport"`
+
+	// ShardCount is the --shard-count flag. The number of the shards of the managed clusters. Each replica of the hub
+	// runs with a distinct shard id and only handles the managed clusters with the label registration.open-cluster-
+	// management.io/shard of its shard id. The label is set with the hash of the cluster name by the shard 0 if it is
+	// not set.
+	ShardCount *int `json:"shardCount,omitempty" flag:"shard-count"`
+
+	// ShardID is the --shard-id flag. The id of the shard handled by this replica, it must be in the range of [0,
+	// shard-count). The controllers which are not per cluster run in the shard 0 only.
+	ShardID *int `json:"shardID,omitempty" flag:"shard-id"`
+
+	// SyncClusterEndpoints is the --sync-cluster-endpoints flag. Create the secret cluster-endpoints in the cluster
+	// namespaces for the agents to publish the api server urls and CA bundle of the managed clusters, and copy them
+	// into the client configs of the managed clusters. The client configs applied by the agents themselves are left
+	// alone.
+	SyncClusterEndpoints *bool `json:"syncClusterEndpoints,omitempty" flag:"sync-cluster-endpoints"`
+
+	// TracingEndpoint is the --tracing-endpoint flag. The OTLP gRPC endpoint to export the traces of the controller
+	// syncs to, e.g. otel-collector:4317. The exporter can be further configured with the OTEL_EXPORTER_OTLP_*
+	// environment variables. If it is not set, the tracing is disabled.
+	TracingEndpoint *string `json:"tracingEndpoint,omitempty" flag:"tracing-endpoint"`
+
+	// TracingSamplingRatio is the --tracing-sampling-ratio flag. The ratio of the traces to sample, it must be in the
+	// range of [0, 1].
+	TracingSamplingRatio *float64 `json:"tracingSamplingRatio,omitempty" flag:"tracing-sampling-ratio"`
+}
+
+// RegistrationAgentConfiguration is the configuration of the registration agent. Each field mirrors a flag of the
+// agent, the fields which are not set keep the defaults of the flags, and the flags set on the command line take
+// precedence over the fields.
+type RegistrationAgentConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// AgentUpgradePolicy is the --agent-upgrade-policy flag. The policy to require the upgrade of the agent once its
+	// version is behind the desired version in the annotation cluster.open-cluster-management.io/desired-agent-version
+	// of the managed cluster, it must be one of none, major, minor and patch. The condition UpgradeRequired of the
+	// managed cluster is true once the agent is behind by a release of the policy or above.
+	AgentUpgradePolicy *string `json:"agentUpgradePolicy,omitempty" flag:"agent-upgrade-policy"`
+
+	// AvailabilityProbes is the --availability-probe flag. The probe of a critical API of the managed cluster in the
+	// format of <name>=<type>:<target>, the result is reported with the condition probe.open-cluster-
+	// management.io/<name> on the managed cluster. The type is one of readyz (e.g. etcd=readyz:etcd), path (e.g.
+	// livez=path:/livez), apigroup (e.g. metrics=apigroup:metrics.k8s.io/v1beta1) and resource (e.g.
+	// works=resource:work.open-cluster-management.io/v1/appliedmanifestworks).
+	AvailabilityProbes []string `json:"availabilityProbes,omitempty" flag:"availability-probe"`
+
+	// BootstrapKubeconfig is the --bootstrap-kubeconfig flag. The path of the kubeconfig file for agent bootstrap.
+	BootstrapKubeconfig *string `json:"bootstrapKubeconfig,omitempty" flag:"bootstrap-kubeconfig"`
+
+	// BootstrapKubeconfigSecret is the --bootstrap-kubeconfig-secret flag. The name of secret in component namespace
+	// storing the bootstrap kubeconfig. If it is set, the secret will be updated with the bootstrap kubeconfig
+	// published by hub.
+	BootstrapKubeconfigSecret *string `json:"bootstrapKubeconfigSecret,omitempty" flag:"bootstrap-kubeconfig-secret"`
+
+	// BootstrapToken is the --bootstrap-token flag. The bootstrap token to bootstrap the agent instead of the
+	// bootstrap kubeconfig, it requires --hub-apiserver and --hub-ca-cert-hash.
+	BootstrapToken *string `json:"bootstrapToken,omitempty" flag:"bootstrap-token"`
+
+	// CapacityNodeSelector is the --capacity-node-selector flag. The alias of --cluster-resource-node-selector, e.g.
+	// 'node-pool.example.com/multicluster=true' to count a dedicated worker pool only.
+	CapacityNodeSelector *string `json:"capacityNodeSelector,omitempty" flag:"capacity-node-selector"`
+
+	// ClientCertECDSACurve is the --client-cert-ecdsa-curve flag. The curve of the ECDSA private keys of the client
+	// certificates, it must be one of P256, P384 and P521.
+	ClientCertECDSACurve *string `json:"clientCertECDSACurve,omitempty" flag:"client-cert-ecdsa-curve"`
+
+	// ClientCertKeyProvider is the --client-cert-key-provider flag. The provider generating and holding the private
+	// keys of the client certificates for the cluster and addons, it must be one of the registered key providers,
+	// e.g. software. The software provider generates the keys with the key type options and stores them in the
+	// client certificate secrets.
+	ClientCertKeyProvider *string `json:"clientCertKeyProvider,omitempty" flag:"client-cert-key-provider"`
+
+	// ClientCertExpirationSeconds is the --client-cert-expiration-seconds flag. The requested duration in seconds of
+	// validity of the issued client certificate. If this is not set, the value of --cluster-signing-duration command-
+	// line flag of the kube-controller-manager will be used.
+	ClientCertExpirationSeconds *int32 `json:"clientCertExpirationSeconds,omitempty" flag:"client-cert-expiration-seconds"`
+
+	// ClientCertKeyType is the --client-cert-key-type flag. The type of the private keys of the client certificates
+	// for the cluster and addons, it must be one of ecdsa and rsa.
+	ClientCertKeyType *string `json:"clientCertKeyType,omitempty" flag:"client-cert-key-type"`
+
+	// ClientCertRenewalJitter is the --client-cert-renewal-jitter flag. The max factor of the renewal threshold which
+	// is randomly added to the threshold, so that the client certificates issued at the same time are not rotated
+	// simultaneously.
+	ClientCertRenewalJitter *float64 `json:"clientCertRenewalJitter,omitempty" flag:"client-cert-renewal-jitter"`
+
+	// ClientCertRenewalThreshold is the --client-cert-renewal-threshold flag. The fraction of the client certificate
+	// lifetime remaining when the certificate rotation starts, it must be in the range of (0, 1).
+	ClientCertRenewalThreshold *float64 `json:"clientCertRenewalThreshold,omitempty" flag:"client-cert-renewal-threshold"`
+
+	// ClientCertRSAKeySize is the --client-cert-rsa-key-size flag. The size of the RSA private keys of the client
+	// certificates, it must be one of 2048, 3072 and 4096.
+	ClientCertRSAKeySize *int `json:"clientCertRSAKeySize,omitempty" flag:"client-cert-rsa-key-size"`
+
+	// ClusterAnnotations is the --cluster-annotations flag. The annotations set on the managed cluster when it is
+	// created by the agent, e.g. example.com/owner=team-a. The hub webhook may restrict them to the allowed prefixes.
+	ClusterAnnotations map[string]string `json:"clusterAnnotations,omitempty" flag:"cluster-annotations"`
+
+	// ClusterHealthCheckPeriod is the --cluster-healthcheck-period flag. The period to check managed cluster kube-
+	// apiserver health
+	ClusterHealthCheckPeriod *metav1.Duration `json:"clusterHealthCheckPeriod,omitempty" flag:"cluster-healthcheck-period"`
+
+	// ClusterIdentityKeyFile is the --cluster-identity-key-file flag. The file of the pre-shared key of the cluster.
+	// If it is set, the csrs of the cluster are annotated with the proofs of the cluster identity made with the key,
+	// which are verified by the hub before approving the csrs.
+	ClusterIdentityKeyFile *string `json:"clusterIdentityKeyFile,omitempty" flag:"cluster-identity-key-file"`
+
+	// ClusterLabels is the --cluster-labels flag. The labels set on the managed cluster when it is created by the
+	// agent, e.g. environment=production. The hub webhook may restrict them to the allowed prefixes.
+	ClusterLabels map[string]string `json:"clusterLabels,omitempty" flag:"cluster-labels"`
+
+	// ClusterLeaseDurationSeconds is the --cluster-lease-duration-seconds flag. The lease duration seconds of the
+	// managed cluster, it is required if leaseDurationSeconds is reconciled.
+	ClusterLeaseDurationSeconds *int32 `json:"clusterLeaseDurationSeconds,omitempty" flag:"cluster-lease-duration-seconds"`
+
+	// ClusterName is the --cluster-name flag. If non-empty, will use as cluster name instead of generated random name.
+	ClusterName *string `json:"clusterName,omitempty" flag:"cluster-name"`
+
+	// ClusterResourceExcludeCordonedNodes is the --cluster-resource-exclude-cordoned-nodes flag. Exclude the cordoned
+	// nodes from the capacity of the managed cluster, they are always excluded from the allocatable.
+	ClusterResourceExcludeCordonedNodes *bool `json:"clusterResourceExcludeCordonedNodes,omitempty" flag:"cluster-resource-exclude-cordoned-nodes"`
+
+	// ClusterResourceExcludeNotReadyNodes is the --cluster-resource-exclude-not-ready-nodes flag. Exclude the nodes
+	// which are not ready from the capacity and allocatable of the managed cluster.
+	ClusterResourceExcludeNotReadyNodes *bool `json:"clusterResourceExcludeNotReadyNodes,omitempty" flag:"cluster-resource-exclude-not-ready-nodes"`
+
+	// ClusterResourceExcludedNodeTaints is the --cluster-resource-excluded-node-taints flag. The keys of the taints,
+	// the nodes with any of them are not aggregated into the managed cluster status.
+	ClusterResourceExcludedNodeTaints []string `json:"clusterResourceExcludedNodeTaints,omitempty" flag:"cluster-resource-excluded-node-taints"`
+
+	// ClusterResourceExtendedResources is the --cluster-resource-extended-resources flag. The allow-list of the
+	// extended resources of the nodes, e.g. GPUs, aggregated into the capacity and allocatable of the managed cluster.
+	// The items are the resource names or wildcard patterns, e.g. nvidia.com/gpu or amd.com/*. If it is not set, all
+	// of the extended resources are aggregated. The resources without a domain, e.g. cpu, memory and ephemeral-
+	// storage, are always aggregated.
+	ClusterResourceExtendedResources []string `json:"clusterResourceExtendedResources,omitempty" flag:"cluster-resource-extended-resources"`
+
+	// ClusterResourceNodeSelector is the --cluster-resource-node-selector flag. The label selector of the nodes whose
+	// capacity and allocatable are aggregated into the managed cluster status, e.g. '!node-role.kubernetes.io/control-
+	// plane'. If it is not set, all of the nodes are aggregated.
+	ClusterResourceNodeSelector *string `json:"clusterResourceNodeSelector,omitempty" flag:"cluster-resource-node-selector"`
+
+	// ClusterResourcePlatformBreakdown is the --cluster-resource-platform-breakdown flag. Add the capacity and
+	// allocatable of the nodes of each architecture and OS as the extended resources
+	// node-arch.open-cluster-management.io/<arch>.<resource> and node-os.open-cluster-management.io/<os>.<resource>,
+	// e.g. node-arch.open-cluster-management.io/arm64.cpu and node-os.open-cluster-management.io/windows.memory.
+	ClusterResourcePlatformBreakdown *bool `json:"clusterResourcePlatformBreakdown,omitempty" flag:"cluster-resource-platform-breakdown"`
+
+	// ClusterResourceRoleBreakdown is the --cluster-resource-role-breakdown flag. Add the capacity and allocatable of
+	// the nodes of each role as the extended resources node-role.open-cluster-management.io/<role>.<resource>, e.g.
+	// node-role.open-cluster-management.io/worker.cpu.
+	ClusterResourceRoleBreakdown *bool `json:"clusterResourceRoleBreakdown,omitempty" flag:"cluster-resource-role-breakdown"`
+
+	// ClusterResourceUpdateMinInterval is the --cluster-resource-update-min-interval flag. The min interval between
+	// the updates of the capacity and allocatable of the managed cluster, the changes in the interval are delayed to
+	// its end. If it is not set, the changes are updated immediately.
+	ClusterResourceUpdateMinInterval *metav1.Duration `json:"clusterResourceUpdateMinInterval,omitempty" flag:"cluster-resource-update-min-interval"`
+
+	// ClusterResourceUpdateThresholdPercent is the --cluster-resource-update-threshold-percent flag. The percentage of
+	// the change of a resource of the managed cluster, the change larger than it is updated immediately even in the
+	// min update interval. If it is not set, all of the changes in the interval are delayed.
+	ClusterResourceUpdateThresholdPercent *float64 `json:"clusterResourceUpdateThresholdPercent,omitempty" flag:"cluster-resource-update-threshold-percent"`
+
+	// ControllerLogLevels is the --controller-log-levels flag. The verbosities of the syncs of the controllers
+	// overriding -v, e.g. ManagedClusterLeaseController=4,ClusterClaimController=0. The log lines out of the
+	// controller syncs are still filtered by -v.
+	ControllerLogLevels map[string]int `json:"controllerLogLevels,omitempty" flag:"controller-log-levels"`
+
+	// CSRBackoffInitial is the --csr-backoff-initial flag. The interval before creating another csr of the cluster
+	// once a csr is created without a client certificate issued, e.g. the csr is denied or expires before the manual
+	// approval. The interval grows exponentially with the following csrs. If it is not set, the csrs are created
+	// without any interval.
+	CSRBackoffInitial *metav1.Duration `json:"csrBackoffInitial,omitempty" flag:"csr-backoff-initial"`
+
+	// CSRBackoffMax is the --csr-backoff-max flag. The max interval between the csrs of the cluster.
+	CSRBackoffMax *metav1.Duration `json:"csrBackoffMax,omitempty" flag:"csr-backoff-max"`
+
+	// CSRBackoffMultiplier is the --csr-backoff-multiplier flag. The factor by which the interval between the csrs of
+	// the cluster is multiplied after each csr, it must not be less than 1.
+	CSRBackoffMultiplier *float64 `json:"csrBackoffMultiplier,omitempty" flag:"csr-backoff-multiplier"`
+
+	// CSRMaxAttempts is the --csr-max-attempts flag. The number of the csrs of the cluster created without a client
+	// certificate issued after which the agent is reported as degraded by the health probes. If it is not set, the
+	// agent is not degraded.
+	CSRMaxAttempts *int `json:"csrMaxAttempts,omitempty" flag:"csr-max-attempts"`
+
+	// DeployMode is the --deploy-mode flag. The deploy mode of the agent, it must be one of Default and Detached. In
+	// Detached mode, the agent runs on a management cluster and --spoke-kubeconfig is required.
+	DeployMode *string `json:"deployMode,omitempty" flag:"deploy-mode"`
+
+	// FeatureGates is the --feature-gates flag. A set of key=value pairs that describe feature gates for
+	// alpha/experimental features. Options are: AddonManagement=true|false (ALPHA - default=false) AllAlpha=true|false
+	// (ALPHA - default=false) AllBeta=true|false (BETA - default=false) ClusterClaim=true|false (BETA - default=true)
+	// MQTTTransport=true|false (ALPHA - default=false) V1beta1CSRAPICompatibility=true|false (ALPHA - default=false)
+	FeatureGates map[string]bool `json:"featureGates,omitempty" flag:"feature-gates"`
+
+	// FeatureGatesFile is the --feature-gates-file flag. The file holding a map of the feature gates to true or false,
+	// it takes precedence over --feature-gates. The feature gates are not reloaded in-process, the agent exits to be
+	// restarted once any feature gate in the file is toggled.
+	FeatureGatesFile *string `json:"featureGatesFile,omitempty" flag:"feature-gates-file"`
+
+	// HealthCheckStrategies is the --health-check-strategy flag. The strategies to check the health of the
+	// kube-apiserver of the managed cluster in the format of <type>[:<target>][,timeout=<duration>]. The type is one
+	// of livez, readyz, healthz, list (e.g. list:v1/namespaces lists one namespace) and url (e.g.
+	// url:https://lb.example.com/healthz, requested without credentials). The strategies are tried in order, the next
+	// one is tried if a strategy is not found or forbidden. If it is not set, livez is checked with the fallback to
+	// healthz.
+	HealthCheckStrategies []string `json:"healthCheckStrategies,omitempty" flag:"health-check-strategy"`
+
+	// HealthCheckCAFile is the --health-check-ca-file flag. The CA file to verify the servers of the url health check
+	// strategies. If it is not set, the system CAs are used.
+	HealthCheckCAFile *string `json:"healthCheckCAFile,omitempty" flag:"health-check-ca-file"`
+
+	// HealthProbeBindAddress is the --health-probe-bind-address flag. The address the /healthz and /readyz endpoints
+	// bind to, e.g. :8000. The endpoints report the last sync of each controller of the agent. If it is not set, the
+	// endpoints are not served.
+	HealthProbeBindAddress *string `json:"healthProbeBindAddress,omitempty" flag:"health-probe-bind-address"`
+
+	// HealthProbeFailureThreshold is the --health-probe-failure-threshold flag. The duration a controller keeps
+	// failing before it is reported as stuck by the /healthz endpoint. The failures caused by the connectivity to the
+	// hub or the managed cluster are only reported by the /readyz endpoint.
+	HealthProbeFailureThreshold *metav1.Duration `json:"healthProbeFailureThreshold,omitempty" flag:"health-probe-failure-threshold"`
+
+	// HubAPIServer is the --hub-apiserver flag. The URL of the hub kube-apiserver to bootstrap the agent with the
+	// bootstrap token.
+	HubAPIServer *string `json:"hubAPIServer,omitempty" flag:"hub-apiserver"`
+
+	// HubCACertHashes is the --hub-ca-cert-hash flag. The hashes of the hub CA certs to verify the hub when
+	// bootstrapping with the bootstrap token, in the format of sha256:<hex>.
+	HubCACertHashes []string `json:"hubCACertHashes,omitempty" flag:"hub-ca-cert-hash"`
+
+	// HubClusterARN is the --hub-cluster-arn flag. The ARN of the EKS hub cluster of the awsirsa registration driver,
+	// e.g. arn:aws:eks:us-west-2:123456789012:cluster/hub.
+	HubClusterARN *string `json:"hubClusterARN,omitempty" flag:"hub-cluster-arn"`
+
+	// HubKubeAPIBurst is the --hub-kube-api-burst flag. The burst of the clients of the hub. If it is 0, the
+	// burst of the hub kubeconfig is used.
+	HubKubeAPIBurst *int `json:"hubKubeAPIBurst,omitempty" flag:"hub-kube-api-burst"`
+
+	// HubKubeAPIQPS is the --hub-kube-api-qps flag. The QPS of the clients of the hub. If it is 0, the QPS of
+	// the hub kubeconfig is used.
+	HubKubeAPIQPS *float32 `json:"hubKubeAPIQPS,omitempty" flag:"hub-kube-api-qps"`
+
+	// HubKubeconfigDir is the --hub-kubeconfig-dir flag. The mount path of hub-kubeconfig-secret in the container.
+	HubKubeconfigDir *string `json:"hubKubeconfigDir,omitempty" flag:"hub-kubeconfig-dir"`
+
+	// HubKubeconfigSecret is the --hub-kubeconfig-secret flag. The name of secret in component namespace storing
+	// kubeconfig for hub.
+	HubKubeconfigSecret *string `json:"hubKubeconfigSecret,omitempty" flag:"hub-kubeconfig-secret"`
+
+	// HubTokenFile is the --hub-token-file flag. The service account token projected with the audience of the hub,
+	// which the oidc registration driver authenticates to the hub with.
+	HubTokenFile *string `json:"hubTokenFile,omitempty" flag:"hub-token-file"`
+
+	// LeaseClientBurst is the --lease-client-burst flag. The burst of the hub client dedicated to the lease renewals
+	// of the managed cluster, it defaults to 5. If it is 0, the burst of the hub kubeconfig is used.
+	LeaseClientBurst *int `json:"leaseClientBurst,omitempty" flag:"lease-client-burst"`
+
+	// LeaseClientQPS is the --lease-client-qps flag. The QPS of the hub client dedicated to the lease renewals of the
+	// managed cluster, it defaults to 5. If it is 0, the QPS of the hub kubeconfig is used.
+	LeaseClientQPS *float32 `json:"leaseClientQPS,omitempty" flag:"lease-client-qps"`
+
+	// LeaseRequestTimeout is the --lease-request-timeout flag. The timeout of each request of the lease renewals of
+	// the managed cluster to the hub. If it is not set, the timeout of the hub kubeconfig is used.
+	LeaseRequestTimeout *metav1.Duration `json:"leaseRequestTimeout,omitempty" flag:"lease-request-timeout"`
+
+	// LoggingFormat is the --logging-format flag. The format of the log lines, text or json. The log lines of the
+	// controller syncs carry the controller, queueKey, cluster and addon fields in both formats.
+	LoggingFormat *string `json:"loggingFormat,omitempty" flag:"logging-format"`
+
+	// ManagedClusterIAMRoleARN is the --managed-cluster-iam-role-arn flag. The ARN of the IAM role the agent
+	// authenticates to the EKS hub with by the awsirsa registration driver. If it is not set, the role of the service
+	// account of the agent in the AWS_ROLE_ARN environment variable is used.
+	ManagedClusterIAMRoleARN *string `json:"managedClusterIAMRoleARN,omitempty" flag:"managed-cluster-iam-role-arn"`
+
+	// MaxConcurrentAddOnCSRs is the --max-concurrent-addon-csrs flag. The max number of pending csrs the agent creates
+	// for all addons at the same time. If it is not set, the number is not limited.
+	MaxConcurrentAddOnCSRs *int `json:"maxConcurrentAddOnCSRs,omitempty" flag:"max-concurrent-addon-csrs"`
+
+	// MaxCustomClusterClaims is the --max-custom-cluster-claims flag. The max number of custom cluster claims to
+	// expose.
+	MaxCustomClusterClaims *int `json:"maxCustomClusterClaims,omitempty" flag:"max-custom-cluster-claims"`
+
+	// MQTTBrokerURL is the --mqtt-broker-url flag. The URL of the MQTT broker for the mqtt registration transport,
+	// e.g. tls://broker:8883. The agent authenticates to the broker with its hub client certificate.
+	MQTTBrokerURL *string `json:"mqttBrokerURL,omitempty" flag:"mqtt-broker-url"`
+
+	// MQTTCAFile is the --mqtt-ca-file flag. The CA file to verify the MQTT broker. If it is not set, the system CAs
+	// are used.
+	MQTTCAFile *string `json:"mqttCAFile,omitempty" flag:"mqtt-ca-file"`
+
+	// NodeSummaryClaims is the --node-summary-claims flag. Report the counts of the nodes by the OS, architecture and
+	// kubelet minor version with the cluster claims nodes.open-cluster-management.io/os.<os>, arch.<arch> and
+	// kubelet.<minor version>, e.g. nodes.open-cluster-management.io/kubelet.v1.26, in the managed cluster status.
+	NodeSummaryClaims *bool `json:"nodeSummaryClaims,omitempty" flag:"node-summary-claims"`
+
+	// PublishClusterEndpoints is the --publish-cluster-endpoints flag. If true, the api server urls and CA bundle of
+	// the managed cluster are published to hub. The urls are discovered from the configmap kube-public/cluster-info
+	// unless the spoke external server urls are specified.
+	PublishClusterEndpoints *bool `json:"publishClusterEndpoints,omitempty" flag:"publish-cluster-endpoints"`
+
+	// ReconciledClusterSpecFields is the --reconciled-cluster-spec-fields flag. The spec fields of the managed
+	// cluster kept reconciled by the agent with server-side apply after the cluster is created, they must be of
+	// managedClusterClientConfigs and leaseDurationSeconds. The other fields, e.g. hubAcceptsClient and taints, are
+	// owned by the hub and never overwritten. If it is not set, the spec is only set when the cluster is created. The
+	// managedClusterClientConfigs are not reconciled if the cluster endpoints are published.
+	ReconciledClusterSpecFields []string `json:"reconciledClusterSpecFields,omitempty" flag:"reconciled-cluster-spec-fields"`
+
+	// RegistrationDriver is the --registration-driver flag. The driver to request the client certificates of the
+	// agent from the hub, it must be one of csr, grpc, awsirsa and oidc. The grpc driver requires the
+	// GRPCRegistrationDriver feature and the registration service address. The awsirsa driver authenticates to an EKS
+	// hub with the IAM role of the agent instead of a client certificate, it requires the AWSIAMRegistrationDriver
+	// feature and the hub cluster arn. The oidc driver authenticates with the hub token file, it requires the
+	// OIDCRegistrationDriver feature.
+	RegistrationDriver *string `json:"registrationDriver,omitempty" flag:"registration-driver"`
+
+	// RegistrationServiceAddress is the --registration-service-address flag. The address of the hub registration
+	// service of the grpc registration driver, e.g. registration.example.com:8443.
+	RegistrationServiceAddress *string `json:"registrationServiceAddress,omitempty" flag:"registration-service-address"`
+
+	// RegistrationTransport is the --registration-transport flag. The transport to report the heartbeats and status of
+	// the managed cluster, it must be one of kube and mqtt. The mqtt transport requires the MQTTTransport feature.
+	RegistrationTransport *string `json:"registrationTransport,omitempty" flag:"registration-transport"`
+
+	// ReloadHubKubeconfig is the --reload-hub-kubeconfig flag. If true, the hub clients and the controllers talking
+	// to hub are rebuilt in-process once the hub kubeconfig or the client certificate files are changed.
+	ReloadHubKubeconfig *bool `json:"reloadHubKubeconfig,omitempty" flag:"reload-hub-kubeconfig"`
+
+	// ServingCertDNSNames is the --serving-cert-dns-names flag. The DNS names requested as the subject alternative
+	// names of the serving certificate of the agent, which is signed by the cluster serving signer of the hub for the
+	// mTLS callbacks to the cluster. They must be allowed by the hub, otherwise the csrs are not approved.
+	ServingCertDNSNames []string `json:"servingCertDNSNames,omitempty" flag:"serving-cert-dns-names"`
+
+	// ServingCertIPAddresses is the --serving-cert-ip-addresses flag. The IPv4 or IPv6 addresses requested as the
+	// subject alternative names of the serving certificate of the agent. They must be allowed by the hub, otherwise
+	// the csrs are not approved.
+	ServingCertIPAddresses []string `json:"servingCertIPAddresses,omitempty" flag:"serving-cert-ip-addresses"`
+
+	// ServingCertSecret is the --serving-cert-secret flag. The name of the secret in the agent namespace the serving
+	// certificate of the agent is stored in with the keys tls.crt and tls.key.
+	ServingCertSecret *string `json:"servingCertSecret,omitempty" flag:"serving-cert-secret"`
+
+	// SpokeExternalServerURLs is the --spoke-external-server-urls flag. A list of reachable spoke cluster api server
+	// URLs for hub cluster.
+	SpokeExternalServerURLs []string `json:"spokeExternalServerURLs,omitempty" flag:"spoke-external-server-urls"`
+
+	// SpokeKubeAPIBurst is the --spoke-kube-api-burst flag. The burst of the clients of the managed cluster. If it is
+	// 0, the burst of the spoke kubeconfig is used.
+	SpokeKubeAPIBurst *int `json:"spokeKubeAPIBurst,omitempty" flag:"spoke-kube-api-burst"`
+
+	// SpokeKubeAPIQPS is the --spoke-kube-api-qps flag. The QPS of the clients of the managed cluster. If it is 0,
+	// the QPS of the spoke kubeconfig is used.
+	SpokeKubeAPIQPS *float32 `json:"spokeKubeAPIQPS,omitempty" flag:"spoke-kube-api-qps"`
+
+	// SpokeKubeconfig is the --spoke-kubeconfig flag. The path of the kubeconfig file for managed/spoke cluster. If
+	// this is not set, will use '--kubeconfig' to build client to connect to the managed cluster. In Detached mode,
+	// the agent exits once the kubeconfig or the files referenced by it are changed, so it is restarted with the
+	// rotated kubeconfig.
+	SpokeKubeconfig *string `json:"spokeKubeconfig,omitempty" flag:"spoke-kubeconfig"`
+
+	// SyncHubCABundle is the --sync-hub-ca-bundle flag. If true, the CA of the hub kubeconfig is updated with the hub
+	// CA bundle published by hub, and the agent restarts to trust the rotated CA.
+	SyncHubCABundle *bool `json:"syncHubCABundle,omitempty" flag:"sync-hub-ca-bundle"`
+
+	// TunnelEndpointMetadata is the --tunnel-endpoint-metadata flag. The metadata of the tunnels the hub components
+	// reach the managed cluster through, e.g. konnectivity-agent-identifiers=host=cluster1. It is published to hub
+	// with the tunnel endpoint publisher.
+	TunnelEndpointMetadata map[string]string `json:"tunnelEndpointMetadata,omitempty" flag:"tunnel-endpoint-metadata"`
+
+	// TunnelEndpointPublisher is the --tunnel-endpoint-publisher flag. The publisher of the tunnel endpoint metadata,
+	// it must be one of secret and claims. The secret publisher sets the keys tunnel.<name> of the secret
+	// cluster-endpoints created by hub in the cluster namespace, and the claims publisher reports the cluster claims
+	// tunnel.open-cluster-management.io/<name> of the managed cluster.
+	TunnelEndpointPublisher *string `json:"tunnelEndpointPublisher,omitempty" flag:"tunnel-endpoint-publisher"`
+}
@@ -0,0 +1,74 @@
+package clientcert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// DefaultCSRBackoffMax is the default max interval between the csrs created by a client certificate controller
+	DefaultCSRBackoffMax = 10 * time.Minute
+	// DefaultCSRBackoffMultiplier is the default factor by which the interval between the csrs is multiplied
+	DefaultCSRBackoffMultiplier = 2.0
+)
+
+// CSRBackoffOption includes options that is used to throttle the creation of the csrs. Once a csr is created
+// without a client certificate issued, e.g. the csr is denied, deleted or expired on the hub, the next csr is created
+// after an exponentially growing interval, so the hubs enforcing the manual approval are not flooded with duplicate
+// csrs. The csrs are created without any interval if the option is not set.
+type CSRBackoffOption struct {
+	// Initial is the interval after the first csr, the backoff is disabled if it is 0
+	Initial time.Duration
+	// Max is the max interval between the csrs, DefaultCSRBackoffMax is used if it is not set
+	Max time.Duration
+	// Multiplier is the factor by which the interval is multiplied after each csr, DefaultCSRBackoffMultiplier is
+	// used if it is not set
+	Multiplier float64
+	// MaxAttempts is the number of the csrs created without a client certificate issued after which the controller
+	// is reported as degraded, it is not limited if it is 0
+	MaxAttempts int
+}
+
+// Validate verifies the csr backoff option.
+func (o CSRBackoffOption) Validate() error {
+	if o.Initial < 0 {
+		return errors.New("csr backoff initial interval must not be negative")
+	}
+	if o.Max < 0 {
+		return errors.New("csr backoff max interval must not be negative")
+	}
+	if o.Max > 0 && o.Max < o.Initial {
+		return fmt.Errorf("csr backoff max interval %v must not be less than the initial interval %v", o.Max, o.Initial)
+	}
+	if o.Multiplier != 0 && o.Multiplier < 1 {
+		return fmt.Errorf("csr backoff multiplier %v must not be less than 1", o.Multiplier)
+	}
+	if o.MaxAttempts < 0 {
+		return errors.New("csr max attempts must not be negative")
+	}
+	return nil
+}
+
+// interval returns the interval before the next csr after the given number of csrs are created
+func (o CSRBackoffOption) interval(attempts int) time.Duration {
+	if o.Initial <= 0 || attempts <= 0 {
+		return 0
+	}
+
+	max := o.Max
+	if max == 0 {
+		max = DefaultCSRBackoffMax
+	}
+	multiplier := o.Multiplier
+	if multiplier == 0 {
+		multiplier = DefaultCSRBackoffMultiplier
+	}
+
+	interval := float64(o.Initial) * math.Pow(multiplier, float64(attempts-1))
+	if interval > float64(max) {
+		return max
+	}
+	return time.Duration(interval)
+}
@@ -0,0 +1,97 @@
+package clientcert
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCSRBackoffInterval(t *testing.T) {
+	cases := []struct {
+		name     string
+		option   CSRBackoffOption
+		attempts int
+		expected time.Duration
+	}{
+		{
+			name:     "backoff is disabled",
+			attempts: 3,
+		},
+		{
+			name:     "first csr",
+			option:   CSRBackoffOption{Initial: time.Minute},
+			attempts: 1,
+			expected: time.Minute,
+		},
+		{
+			name:     "default multiplier",
+			option:   CSRBackoffOption{Initial: time.Minute},
+			attempts: 3,
+			expected: 4 * time.Minute,
+		},
+		{
+			name:     "customized multiplier",
+			option:   CSRBackoffOption{Initial: time.Minute, Multiplier: 3},
+			attempts: 3,
+			expected: 9 * time.Minute,
+		},
+		{
+			name:     "default max",
+			option:   CSRBackoffOption{Initial: time.Minute},
+			attempts: 10,
+			expected: DefaultCSRBackoffMax,
+		},
+		{
+			name:     "customized max",
+			option:   CSRBackoffOption{Initial: time.Minute, Max: 3 * time.Minute},
+			attempts: 3,
+			expected: 3 * time.Minute,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := c.option.interval(c.attempts); actual != c.expected {
+				t.Errorf("expected interval %v, but got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCSRBackoffValidate(t *testing.T) {
+	cases := []struct {
+		name        string
+		option      CSRBackoffOption
+		expectedErr bool
+	}{
+		{
+			name: "empty option",
+		},
+		{
+			name:   "valid option",
+			option: CSRBackoffOption{Initial: time.Minute, Max: time.Hour, Multiplier: 1.5, MaxAttempts: 5},
+		},
+		{
+			name:        "max is less than initial",
+			option:      CSRBackoffOption{Initial: time.Hour, Max: time.Minute},
+			expectedErr: true,
+		},
+		{
+			name:        "multiplier is less than 1",
+			option:      CSRBackoffOption{Initial: time.Minute, Multiplier: 0.5},
+			expectedErr: true,
+		},
+		{
+			name:        "negative max attempts",
+			option:      CSRBackoffOption{MaxAttempts: -1},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.option.Validate(); c.expectedErr != (err != nil) {
+				t.Errorf("expected error %v, but got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
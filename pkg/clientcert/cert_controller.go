@@ -2,16 +2,17 @@ package clientcert
 
 import (
 	"context"
-	"crypto/tls"
 	"crypto/x509/pkix"
 	"fmt"
 	"math/rand"
+	"net"
 	"reflect"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 
+	certificates "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -20,8 +21,10 @@ import (
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	certutil "k8s.io/client-go/util/cert"
-	"k8s.io/client-go/util/keyutil"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	"open-cluster-management.io/registration/pkg/health"
 )
 
 const (
@@ -45,11 +48,49 @@ const (
 	// ClientCertificateUpdatedReason is a reason of condition ClusterCertificateRotatedCondition that
 	// the the client certificate succeeds
 	ClientCertificateUpdatedReason = "ClientCertificateUpdated"
+
+	// ClusterCertificateExpiringCondition is a condition type that client certificate is within the renewal
+	// threshold of its lifetime.
+	ClusterCertificateExpiringCondition = "ClusterCertificateExpiring"
+
+	// ClientCertificateExpiringReason is a reason of condition ClusterCertificateExpiringCondition that the
+	// client certificate is within the renewal threshold of its lifetime and is being rotated.
+	ClientCertificateExpiringReason = "ClientCertificateExpiring"
+
+	// ClientCertificateValidReason is a reason of condition ClusterCertificateExpiringCondition that the
+	// client certificate is not close to its expiration.
+	ClientCertificateValidReason = "ClientCertificateValid"
+
+	// certificateExpirationMessagePrefix is the prefix of the message of condition ClusterCertificateExpiringCondition,
+	// it is followed by the expiration time of the client certificate in RFC3339 format.
+	certificateExpirationMessagePrefix = "Client certificate expires at "
+
+	// DefaultRenewalThreshold is the default fraction of the client certificate lifetime remaining when
+	// the certificate rotation starts.
+	DefaultRenewalThreshold = 0.2
+	// DefaultRenewalJitter is the default max factor of the renewal threshold which is randomly added to
+	// the threshold, so that the client certificates issued at the same time are not rotated simultaneously.
+	DefaultRenewalJitter = 0.25
 )
 
 // ControllerResyncInterval is exposed so that integration tests can crank up the constroller sync speed.
 var ControllerResyncInterval = 5 * time.Minute
 
+var (
+	// ClientCertUsages are the key usages of the client certificates
+	ClientCertUsages = []certificates.KeyUsage{
+		certificates.UsageDigitalSignature,
+		certificates.UsageKeyEncipherment,
+		certificates.UsageClientAuth,
+	}
+	// ServingCertUsages are the key usages of the serving certificates
+	ServingCertUsages = []certificates.KeyUsage{
+		certificates.UsageDigitalSignature,
+		certificates.UsageKeyEncipherment,
+		certificates.UsageServerAuth,
+	}
+)
+
 // CSROption includes options that is used to create and monitor csrs
 type CSROption struct {
 	// ObjectMeta is the ObjectMeta shared by all created csrs. It should use GenerateName instead of Name
@@ -59,8 +100,12 @@ type CSROption struct {
 	Subject *pkix.Name
 	// DNSNames represents DNS names used to create the client certificate
 	DNSNames []string
+	// IPAddresses represents IP addresses used to create the client certificate
+	IPAddresses []net.IP
 	// SignerName is the name of the signer specified in the created csrs
 	SignerName string
+	// Usages are the key usages requested in the created csrs, ClientCertUsages are requested if it is not set
+	Usages []certificates.KeyUsage
 
 	// ExpirationSeconds is the requested duration of validity of the issued
 	// certificate.
@@ -79,6 +124,20 @@ type CSROption struct {
 
 	// HaltCSRCreation halt the csr creation
 	HaltCSRCreation func() bool
+
+	// PrivateKey is the option to generate the private key used to create csrs
+	PrivateKey PrivateKeyOption
+
+	// KeyProvider generates and holds the private keys used to create csrs. If it is not set, the private keys
+	// are generated in memory with the PrivateKey option and stored in the client certificate secret.
+	KeyProvider KeyProvider
+
+	// Backoff throttles the creation of the csrs which are not issued with client certificates
+	Backoff CSRBackoffOption
+
+	// IdentityKey is the pre-shared key of the cluster. If it is set, the created csrs are annotated with the
+	// proof of the cluster identity, see IdentityProofAnnotation.
+	IdentityKey []byte
 }
 
 // ClientCertOption includes options that is used to create client certificate
@@ -88,11 +147,32 @@ type ClientCertOption struct {
 	// SecretName is the name of the secret containing client certificate. The secret will be created if
 	// it does not exist.
 	SecretName string
+	// SecretLabels are the labels of the secret containing client certificate, they are set once the secret is
+	// created by the controller.
+	SecretLabels map[string]string
 	// AdditonalSecretData contains data that will be added into client certificate secret besides tls.key/tls.crt
 	AdditionalSecretData map[string][]byte
 	// AdditonalSecretDataSensitive is true indicates the client cert is sensitive to the AdditonalSecretData.
 	// That means once AdditonalSecretData changes, the client cert will be recreated.
 	AdditionalSecretDataSensitive bool
+	// MergeAdditionalSecretData returns the additional secret data merged with the data of the current secret, it is
+	// called before a new client certificate is saved into the secret, so the changes made to the additional secret
+	// data in the secret by the other controllers are kept. The AdditionalSecretData are saved as they are if it is nil.
+	MergeAdditionalSecretData func(additionalSecretData, currentSecretData map[string][]byte) (map[string][]byte, error)
+	// RenewalThreshold is the fraction of the client certificate lifetime remaining when the certificate
+	// rotation starts, it should be in the range of (0, 1). DefaultRenewalThreshold is used if it is not set.
+	RenewalThreshold float64
+	// RenewalJitter is the max factor of the RenewalThreshold which is randomly added to the threshold. The
+	// rotation starts once the remaining lifetime is less than a random fraction in the range of
+	// [RenewalThreshold, RenewalThreshold*(1+RenewalJitter)). DefaultRenewalJitter is used if it is not set.
+	RenewalJitter float64
+	// ExpirationReporter reports the expiration time of the client certificate each time the certificate is
+	// validated, so the hub does not parse it from the message of ClusterCertificateExpiringCondition. Nothing is
+	// reported if it is nil.
+	ExpirationReporter func(ctx context.Context, notAfter time.Time) error
+	// Clock is the clock the client certificate is validated and rotated with, the real clock is used if it is
+	// not set. Tests set a fake clock to step through the lifetime of the certificate.
+	Clock clock.Clock
 }
 
 type StatusUpdateFunc func(ctx context.Context, cond metav1.Condition) error
@@ -105,7 +185,7 @@ type StatusUpdateFunc func(ctx context.Context, cond metav1.Condition) error
 type clientCertificateController struct {
 	ClientCertOption
 	CSROption
-	csrControl CSRControl
+	registrationDriver RegistrationDriver
 	// managementCoreClient is used to create/delete hub kubeconfig secret on the management cluster
 	managementCoreClient corev1client.CoreV1Interface
 	controllerName       string
@@ -122,6 +202,11 @@ type clientCertificateController struct {
 	//   4. csrName empty, keydata set: the CSR failed to create, this shouldn't happen, it's a bug.
 	keyData []byte
 
+	// csrAttempts is the number of the csrs created since the last client certificate was issued, and nextCSRTime
+	// is the time after which the next csr can be created according to the backoff.
+	csrAttempts int
+	nextCSRTime time.Time
+
 	statusUpdater StatusUpdateFunc
 }
 
@@ -129,7 +214,7 @@ type clientCertificateController struct {
 func NewClientCertificateController(
 	clientCertOption ClientCertOption,
 	csrOption CSROption,
-	csrControl CSRControl,
+	registrationDriver RegistrationDriver,
 	managementSecretInformer corev1informers.SecretInformer,
 	managementCoreClient corev1client.CoreV1Interface,
 	statusUpdater StatusUpdateFunc,
@@ -139,7 +224,7 @@ func NewClientCertificateController(
 	c := clientCertificateController{
 		ClientCertOption:     clientCertOption,
 		CSROption:            csrOption,
-		csrControl:           csrControl,
+		registrationDriver:   registrationDriver,
 		managementCoreClient: managementCoreClient,
 		controllerName:       controllerName,
 		statusUpdater:        statusUpdater,
@@ -161,13 +246,15 @@ func NewClientCertificateController(
 		}, managementSecretInformer.Informer()).
 		WithFilteredEventsInformersQueueKeyFunc(func(obj runtime.Object) string {
 			return factory.DefaultQueueKey
-		}, c.EventFilterFunc, csrControl.Informer()).
-		WithSync(c.sync).
+		}, c.EventFilterFunc, registrationDriver.Informer()).
+		WithSync(health.DefaultRegistry.TrackSync(controllerName, c.sync)).
 		ResyncEvery(ControllerResyncInterval).
 		ToController(controllerName, recorder)
 }
 
 func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	logger := klog.FromContext(ctx)
+
 	// get secret containing client certificate
 	secret, err := c.managementCoreClient.Secrets(c.SecretNamespace).Get(ctx, c.SecretName, metav1.GetOptions{})
 	switch {
@@ -176,6 +263,7 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: c.SecretNamespace,
 				Name:      c.SecretName,
+				Labels:    c.SecretLabels,
 			},
 		}
 	case err != nil:
@@ -192,7 +280,7 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 			}
 
 			// skip if csr is not approved yet
-			isApproved, err := c.csrControl.isApproved(c.csrName)
+			isApproved, err := c.registrationDriver.IsApproved(c.csrName)
 			if err != nil {
 				return nil, err
 			}
@@ -201,7 +289,7 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 			}
 
 			// skip if csr is not issued
-			certData, err := c.csrControl.getIssuedCertificate(c.csrName)
+			certData, err := c.registrationDriver.GetIssuedCertificate(c.csrName)
 			if err != nil {
 				return nil, err
 			}
@@ -209,13 +297,17 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 				return nil, nil
 			}
 
-			klog.V(4).Infof("Sync csr %v", c.csrName)
+			logger.V(4).Info("Sync csr", "csr", c.csrName)
 			// check if cert in csr status matches with the corresponding private key
 			if c.keyData == nil {
 				return nil, fmt.Errorf("no private key found for certificate in csr: %s", c.csrName)
 			}
-			_, err = tls.X509KeyPair(certData, c.keyData)
+			signer, err := c.keyProvider().Signer(ctx, c.keyData)
 			if err != nil {
+				return nil, fmt.Errorf("unable to load the private key for certificate in csr %s: %w", c.csrName, err)
+			}
+			matched, err := certificateMatchesKey(certData, signer)
+			if err != nil || !matched {
 				return nil, fmt.Errorf("private key does not match with the certificate in csr: %s", c.csrName)
 			}
 
@@ -240,10 +332,17 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 			return err
 		}
 		if len(newSecretConfig) == 0 {
-			return nil
+			return c.csrAttemptsError()
 		}
 		// append additional data into client certificate secret
-		for k, v := range c.AdditionalSecretData {
+		additionalSecretData := c.AdditionalSecretData
+		if c.MergeAdditionalSecretData != nil {
+			additionalSecretData, err = c.MergeAdditionalSecretData(c.AdditionalSecretData, secret.Data)
+			if err != nil {
+				return fmt.Errorf("unable to merge the additional data of secret %q: %w", c.SecretNamespace+"/"+c.SecretName, err)
+			}
+		}
+		for k, v := range additionalSecretData {
 			newSecretConfig[k] = v
 		}
 		secret.Data = newSecretConfig
@@ -288,20 +387,35 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 
 		syncCtx.Recorder().Eventf("ClientCertificateCreated", "A new client certificate for %s is available", c.controllerName)
 		c.reset()
+		c.csrAttempts = 0
+		c.nextCSRTime = time.Time{}
 		return nil
 	}
 
+	// report the expiration of the current client certificate
+	now := c.clock().Now()
+	if hasValidClientCertificate(c.Subject, secret, now) {
+		if err := c.updateExpiringCondition(ctx, secret, now); err != nil {
+			return err
+		}
+	}
+
 	// create a csr to request new client certificate if
 	// a. there is no valid client certificate issued for the current cluster/agent;
 	// b. client certificate is sensitive to the additional secret data and the data changes;
-	// c. client certificate exists and has less than a random percentage range from 20% to 25% of its life remaining;
+	// c. client certificate exists and has less than a random percentage of its life remaining, the percentage
+	//    is in the range from the renewal threshold to the threshold with the jitter;
 	shouldCreate, err := shouldCreateCSR(
+		logger,
 		c.controllerName,
 		secret,
 		syncCtx.Recorder(),
 		c.Subject,
 		c.AdditionalSecretDataSensitive,
-		c.AdditionalSecretData)
+		c.AdditionalSecretData,
+		c.RenewalThreshold,
+		c.RenewalJitter,
+		now)
 	if err != nil {
 		return err
 	}
@@ -322,27 +436,92 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 		return nil
 	}
 
+	// throttle the csr creation with the backoff
+	if wait := c.nextCSRTime.Sub(now); wait > 0 {
+		logger.V(4).Info("Wait to create the next csr", "wait", wait)
+		syncCtx.Queue().AddAfter(syncCtx.QueueKey(), wait)
+		return c.csrAttemptsError()
+	}
+
 	// create a new private key
-	keyData, err := keyutil.MakeEllipticPrivateKeyPEM()
+	keyData, err := c.keyProvider().GenerateKey(ctx)
 	if err != nil {
 		return err
 	}
 
-	privateKey, err := keyutil.ParsePrivateKeyPEM(keyData)
+	signer, err := c.keyProvider().Signer(ctx, keyData)
 	if err != nil {
 		return fmt.Errorf("invalid private key for certificate request: %w", err)
 	}
-	csrData, err := certutil.MakeCSR(privateKey, c.Subject, c.DNSNames, nil)
+	csrData, err := certutil.MakeCSR(signer, c.Subject, c.DNSNames, c.IPAddresses)
 	if err != nil {
 		return fmt.Errorf("unable to generate certificate request: %w", err)
 	}
-	createdCSRName, err := c.csrControl.create(ctx, syncCtx.Recorder(), c.ObjectMeta, csrData, c.SignerName, c.ExpirationSeconds)
+	objectMeta := c.ObjectMeta
+	if len(c.IdentityKey) > 0 {
+		objectMeta = *c.ObjectMeta.DeepCopy()
+		if objectMeta.Annotations == nil {
+			objectMeta.Annotations = map[string]string{}
+		}
+		objectMeta.Annotations[IdentityProofAnnotation] = IdentityProof(c.IdentityKey, csrData)
+	}
+	createdCSRName, err := c.registrationDriver.Create(ctx, syncCtx.Recorder(), objectMeta, csrData, c.SignerName, c.usages(),
+		c.ExpirationSeconds)
 	if err != nil {
 		return err
 	}
 	c.keyData = keyData
 	c.csrName = createdCSRName
-	return nil
+
+	c.csrAttempts++
+	c.nextCSRTime = now.Add(c.Backoff.interval(c.csrAttempts))
+	if c.csrAttempts == c.Backoff.MaxAttempts {
+		syncCtx.Recorder().Warningf("ClientCertificateRequestsExceeded",
+			"%d csrs are created for %s without a client certificate issued", c.csrAttempts, c.controllerName)
+	}
+	return c.csrAttemptsError()
+}
+
+// csrAttemptsError returns an error if the csrs created without a client certificate issued reach the max attempts
+// of the backoff, the error is recorded in the health registry so the agent is reported as degraded.
+func (c *clientCertificateController) csrAttemptsError() error {
+	if c.Backoff.MaxAttempts == 0 || c.csrAttempts < c.Backoff.MaxAttempts {
+		return nil
+	}
+	return fmt.Errorf("%d csrs are created for %s without a client certificate issued", c.csrAttempts, c.controllerName)
+}
+
+// updateExpiringCondition reports whether the client certificate is within the renewal threshold of its
+// lifetime at the time now, together with its expiration time.
+func (c *clientCertificateController) updateExpiringCondition(ctx context.Context, secret *corev1.Secret, now time.Time) error {
+	notBefore, notAfter, err := getCertValidityPeriod(secret)
+	if err != nil {
+		return err
+	}
+
+	threshold := c.RenewalThreshold
+	if threshold <= 0 {
+		threshold = DefaultRenewalThreshold
+	}
+
+	cond := metav1.Condition{
+		Type:    ClusterCertificateExpiringCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  ClientCertificateValidReason,
+		Message: certificateExpirationMessagePrefix + notAfter.UTC().Format(time.RFC3339),
+	}
+	total := notAfter.Sub(*notBefore)
+	if notAfter.Sub(now).Seconds() <= total.Seconds()*threshold {
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = ClientCertificateExpiringReason
+	}
+	if err := c.statusUpdater(ctx, cond); err != nil {
+		return err
+	}
+	if c.ExpirationReporter == nil {
+		return nil
+	}
+	return c.ExpirationReporter(ctx, *notAfter)
 }
 
 func saveSecret(spokeCoreClient corev1client.CoreV1Interface, secretNamespace string, secret *corev1.Secret) error {
@@ -355,20 +534,44 @@ func saveSecret(spokeCoreClient corev1client.CoreV1Interface, secretNamespace st
 	return err
 }
 
+func (c *clientCertificateController) keyProvider() KeyProvider {
+	if c.KeyProvider != nil {
+		return c.KeyProvider
+	}
+	return NewSoftwareKeyProvider(c.PrivateKey)
+}
+
+func (c *clientCertificateController) usages() []certificates.KeyUsage {
+	if len(c.Usages) == 0 {
+		return ClientCertUsages
+	}
+	return c.Usages
+}
+
+func (c *clientCertificateController) clock() clock.Clock {
+	if c.Clock != nil {
+		return c.Clock
+	}
+	return clock.RealClock{}
+}
+
 func (c *clientCertificateController) reset() {
 	c.csrName = ""
 	c.keyData = nil
 }
 
 func shouldCreateCSR(
+	logger klog.Logger,
 	controllerName string,
 	secret *corev1.Secret,
 	recorder events.Recorder,
 	subject *pkix.Name,
 	additionalSecretDataSensitive bool,
-	additionalSecretData map[string][]byte) (bool, error) {
+	additionalSecretData map[string][]byte,
+	renewalThreshold, renewalJitter float64,
+	now time.Time) (bool, error) {
 	switch {
-	case !hasValidClientCertificate(subject, secret):
+	case !hasValidClientCertificate(subject, secret, now):
 		recorder.Eventf("NoValidCertificateFound", "No valid client certificate for %s is found. Bootstrap is required", controllerName)
 	case additionalSecretDataSensitive && !hasAdditionalSecretData(additionalSecretData, secret):
 		recorder.Eventf("AdditonalSecretDataChanged", "The additonal secret data is changed. Re-create the client certificate for %s", controllerName)
@@ -379,12 +582,18 @@ func shouldCreateCSR(
 		}
 
 		total := notAfter.Sub(*notBefore)
-		remaining := time.Until(*notAfter)
-		klog.V(4).Infof("Client certificate for %s: time total=%v, remaining=%v, remaining/total=%v", controllerName, total, remaining, remaining.Seconds()/total.Seconds())
-		threshold := jitter(0.2, 0.25)
+		remaining := notAfter.Sub(now)
+		logger.V(4).Info("Client certificate validity", "total", total, "remaining", remaining, "remainingRatio", remaining.Seconds()/total.Seconds())
+		if renewalThreshold <= 0 {
+			renewalThreshold = DefaultRenewalThreshold
+		}
+		if renewalJitter <= 0 {
+			renewalJitter = DefaultRenewalJitter
+		}
+		threshold := jitter(renewalThreshold, renewalJitter)
 		if remaining.Seconds()/total.Seconds() > threshold {
-			// Do nothing if the client certificate is valid and has more than a random percentage range from 20% to 25% of its life remaining
-			klog.V(4).Infof("Client certificate for %s is valid and has more than %.2f%% of its life remaining", controllerName, threshold*100)
+			// Do nothing if the client certificate is valid and has more than a random percentage of its life remaining
+			logger.V(4).Info("Client certificate is valid and has enough of its life remaining", "thresholdPercent", threshold*100)
 			return false, nil
 		}
 		recorder.Eventf("CertificateRotationStarted", "The current client certificate for %s expires in %v. Start certificate rotation", controllerName, remaining.Round(time.Second))
@@ -415,8 +624,8 @@ func jitter(percentage float64, maxFactor float64) float64 {
 	return newPercentage
 }
 
-func hasValidClientCertificate(subject *pkix.Name, secret *corev1.Secret) bool {
-	if valid, err := IsCertificateValid(secret.Data[TLSCertFile], subject); err == nil {
+func hasValidClientCertificate(subject *pkix.Name, secret *corev1.Secret, now time.Time) bool {
+	if valid, err := isCertificateValidAt(secret.Data[TLSCertFile], subject, now); err == nil {
 		return valid
 	}
 	return false
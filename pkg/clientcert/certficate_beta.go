@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/openshift/library-go/pkg/operator/events"
+	certificatesv1 "k8s.io/api/certificates/v1"
 	certificates "k8s.io/api/certificates/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,7 +15,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 )
 
-var _ CSRControl = &v1beta1CSRControl{}
+var _ RegistrationDriver = &v1beta1CSRControl{}
 
 type v1beta1CSRControl struct {
 	hubCSRInformer certificatesinformers.CertificateSigningRequestInformer
@@ -22,7 +23,7 @@ type v1beta1CSRControl struct {
 	hubCSRClient   csrclient.CertificateSigningRequestInterface
 }
 
-func (v *v1beta1CSRControl) isApproved(name string) (bool, error) {
+func (v *v1beta1CSRControl) IsApproved(name string) (bool, error) {
 	csr, err := v.get(name)
 	if err != nil {
 		return false, err
@@ -39,7 +40,7 @@ func (v *v1beta1CSRControl) isApproved(name string) (bool, error) {
 	return approved, nil
 }
 
-func (v *v1beta1CSRControl) getIssuedCertificate(name string) ([]byte, error) {
+func (v *v1beta1CSRControl) GetIssuedCertificate(name string) ([]byte, error) {
 	csr, err := v.get(name)
 	if err != nil {
 		return nil, err
@@ -52,16 +53,18 @@ func (v *v1beta1CSRControl) getIssuedCertificate(name string) ([]byte, error) {
 	return v1beta1CSR.Status.Certificate, nil
 }
 
-func (v *v1beta1CSRControl) create(ctx context.Context, recorder events.Recorder, objMeta metav1.ObjectMeta, csrData []byte, signerName string, expirationSeconds *int32) (string, error) {
+func (v *v1beta1CSRControl) Create(ctx context.Context, recorder events.Recorder, objMeta metav1.ObjectMeta, csrData []byte, signerName string,
+	usages []certificatesv1.KeyUsage, expirationSeconds *int32) (string, error) {
+	v1beta1Usages := []certificates.KeyUsage{}
+	for _, usage := range usages {
+		v1beta1Usages = append(v1beta1Usages, certificates.KeyUsage(usage))
+	}
+
 	csr := &certificates.CertificateSigningRequest{
 		ObjectMeta: objMeta,
 		Spec: certificates.CertificateSigningRequestSpec{
-			Request: csrData,
-			Usages: []certificates.KeyUsage{
-				certificates.UsageDigitalSignature,
-				certificates.UsageKeyEncipherment,
-				certificates.UsageClientAuth,
-			},
+			Request:           csrData,
+			Usages:            v1beta1Usages,
 			SignerName:        &signerName,
 			ExpirationSeconds: expirationSeconds,
 		},
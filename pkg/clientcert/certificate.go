@@ -35,29 +35,29 @@ import (
 //  4. If subject is specified, it matches the subject in the certificate stored in TLSCertFile
 func HasValidHubKubeconfig(secret *corev1.Secret, subject *pkix.Name) bool {
 	if len(secret.Data) == 0 {
-		klog.V(4).Infof("No data found in secret %q", secret.Namespace+"/"+secret.Name)
+		klog.V(4).InfoS("No data found in secret", "secret", secret.Namespace+"/"+secret.Name)
 		return false
 	}
 
 	if _, ok := secret.Data[KubeconfigFile]; !ok {
-		klog.V(4).Infof("No %q found in secret %q", KubeconfigFile, secret.Namespace+"/"+secret.Name)
+		klog.V(4).InfoS("No key found in secret", "key", KubeconfigFile, "secret", secret.Namespace+"/"+secret.Name)
 		return false
 	}
 
 	if _, ok := secret.Data[TLSKeyFile]; !ok {
-		klog.V(4).Infof("No %q found in secret %q", TLSKeyFile, secret.Namespace+"/"+secret.Name)
+		klog.V(4).InfoS("No key found in secret", "key", TLSKeyFile, "secret", secret.Namespace+"/"+secret.Name)
 		return false
 	}
 
 	certData, ok := secret.Data[TLSCertFile]
 	if !ok {
-		klog.V(4).Infof("No %q found in secret %q", TLSCertFile, secret.Namespace+"/"+secret.Name)
+		klog.V(4).InfoS("No key found in secret", "key", TLSCertFile, "secret", secret.Namespace+"/"+secret.Name)
 		return false
 	}
 
 	valid, err := IsCertificateValid(certData, subject)
 	if err != nil {
-		klog.V(4).Infof("Unable to validate certificate in secret %s: %v", secret.Namespace+"/"+secret.Name, err)
+		klog.V(4).InfoS("Unable to validate certificate in secret", "secret", secret.Namespace+"/"+secret.Name, "err", err)
 		return false
 	}
 
@@ -68,6 +68,11 @@ func HasValidHubKubeconfig(secret *corev1.Secret, subject *pkix.Name) bool {
 // 1) All certs in client certificate are not expired.
 // 2) At least one cert matches the given subject if specified
 func IsCertificateValid(certData []byte, subject *pkix.Name) (bool, error) {
+	return isCertificateValidAt(certData, subject, time.Now())
+}
+
+// isCertificateValidAt is IsCertificateValid at the time now
+func isCertificateValidAt(certData []byte, subject *pkix.Name, now time.Time) (bool, error) {
 	certs, err := certutil.ParseCertsPEM(certData)
 	if err != nil {
 		return false, errors.New("unable to parse certificate")
@@ -77,11 +82,10 @@ func IsCertificateValid(certData []byte, subject *pkix.Name) (bool, error) {
 		return false, errors.New("no cert found in certificate")
 	}
 
-	now := time.Now()
 	// make sure no cert in the certificate chain expired
 	for _, cert := range certs {
 		if now.After(cert.NotAfter) {
-			klog.V(4).Infof("Part of the certificate is expired: %v", cert.NotAfter)
+			klog.V(4).InfoS("Part of the certificate is expired", "notAfter", cert.NotAfter)
 			return false, nil
 		}
 	}
@@ -98,7 +102,7 @@ func IsCertificateValid(certData []byte, subject *pkix.Name) (bool, error) {
 		return true, nil
 	}
 
-	klog.V(4).Infof("Certificate is not issued for subject (cn=%s)", subject.CommonName)
+	klog.V(4).InfoS("Certificate is not issued for subject", "commonName", subject.CommonName)
 	return false, nil
 }
 
@@ -170,16 +174,7 @@ func BuildKubeconfig(clientConfig *restclient.Config, certPath, keyPath string)
 	return kubeconfig
 }
 
-type CSRControl interface {
-	create(ctx context.Context, recorder events.Recorder, objMeta metav1.ObjectMeta, csrData []byte, signerName string, expirationSeconds *int32) (string, error)
-	isApproved(name string) (bool, error)
-	getIssuedCertificate(name string) ([]byte, error)
-
-	// public so we can add indexer outside
-	Informer() cache.SharedIndexInformer
-}
-
-var _ CSRControl = &v1CSRControl{}
+var _ RegistrationDriver = &v1CSRControl{}
 
 type v1CSRControl struct {
 	hubCSRInformer certificatesv1informers.CertificateSigningRequestInformer
@@ -187,7 +182,7 @@ type v1CSRControl struct {
 	hubCSRClient   csrclient.CertificateSigningRequestInterface
 }
 
-func (v *v1CSRControl) isApproved(name string) (bool, error) {
+func (v *v1CSRControl) IsApproved(name string) (bool, error) {
 	csr, err := v.get(name)
 	if err != nil {
 		return false, err
@@ -204,7 +199,7 @@ func (v *v1CSRControl) isApproved(name string) (bool, error) {
 	return approved, nil
 }
 
-func (v *v1CSRControl) getIssuedCertificate(name string) ([]byte, error) {
+func (v *v1CSRControl) GetIssuedCertificate(name string) ([]byte, error) {
 	csr, err := v.get(name)
 	if err != nil {
 		return nil, err
@@ -213,16 +208,13 @@ func (v *v1CSRControl) getIssuedCertificate(name string) ([]byte, error) {
 	return v1CSR.Status.Certificate, nil
 }
 
-func (v *v1CSRControl) create(ctx context.Context, recorder events.Recorder, objMeta metav1.ObjectMeta, csrData []byte, signerName string, expirationSeconds *int32) (string, error) {
+func (v *v1CSRControl) Create(ctx context.Context, recorder events.Recorder, objMeta metav1.ObjectMeta, csrData []byte, signerName string,
+	usages []certificates.KeyUsage, expirationSeconds *int32) (string, error) {
 	csr := &certificates.CertificateSigningRequest{
 		ObjectMeta: objMeta,
 		Spec: certificates.CertificateSigningRequestSpec{
-			Request: csrData,
-			Usages: []certificates.KeyUsage{
-				certificates.UsageDigitalSignature,
-				certificates.UsageKeyEncipherment,
-				certificates.UsageClientAuth,
-			},
+			Request:           csrData,
+			Usages:            usages,
 			SignerName:        signerName,
 			ExpirationSeconds: expirationSeconds,
 		},
@@ -255,7 +247,10 @@ func (v *v1CSRControl) get(name string) (metav1.Object, error) {
 	return csr, nil
 }
 
-func NewCSRControl(hubCSRInformer certificatesinformers.Interface, hubKubeClient kubernetes.Interface) (CSRControl, error) {
+// NewCSRDriver returns the registration driver over the certificate signing requests of the hub kube-apiserver, the
+// v1beta1 csr api is used if the hub does not support the v1 csr api and the V1beta1CSRAPICompatibility feature is
+// enabled.
+func NewCSRDriver(hubCSRInformer certificatesinformers.Interface, hubKubeClient kubernetes.Interface) (RegistrationDriver, error) {
 	if features.DefaultSpokeMutableFeatureGate.Enabled(ocmfeature.V1beta1CSRAPICompatibility) {
 		v1CSRSupported, v1beta1CSRSupported, err := helpers.IsCSRSupported(hubKubeClient)
 		if err != nil {
@@ -17,6 +17,7 @@ import (
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 	"open-cluster-management.io/registration/pkg/hub/user"
@@ -101,6 +102,10 @@ func TestSync(t *testing.T) {
 					KubeconfigFile:  testinghelpers.NewKubeconfig(nil, nil),
 				}),
 			},
+			expectedCondition: &metav1.Condition{
+				Type:   ClusterCertificateExpiringCondition,
+				Status: metav1.ConditionFalse,
+			},
 			validateActions: func(t *testing.T, hubActions, agentActions []clienttesting.Action) {
 				testinghelpers.AssertNoActions(t, hubActions)
 				testinghelpers.AssertActions(t, agentActions, "get")
@@ -139,6 +144,10 @@ func TestSync(t *testing.T) {
 			keyDataExpected:              true,
 			csrNameExpected:              true,
 			additonalSecretDataSensitive: true,
+			expectedCondition: &metav1.Condition{
+				Type:   ClusterCertificateExpiringCondition,
+				Status: metav1.ConditionFalse,
+			},
 			validateActions: func(t *testing.T, hubActions, agentActions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, hubActions, "create")
 				actual := hubActions[0].(clienttesting.CreateActionImpl).Object
@@ -196,7 +205,7 @@ func TestSync(t *testing.T) {
 			controller := &clientCertificateController{
 				ClientCertOption:     clientCertOption,
 				CSROption:            csrOption,
-				csrControl:           ctrl,
+				registrationDriver:   ctrl,
 				managementCoreClient: agentKubeClient.CoreV1(),
 				controllerName:       "test-agent",
 				statusUpdater:        updater.update,
@@ -231,7 +240,7 @@ func TestSync(t *testing.T) {
 	}
 }
 
-var _ CSRControl = &mockCSRControl{}
+var _ RegistrationDriver = &mockCSRControl{}
 
 func conditionEqual(expected, actual *metav1.Condition) bool {
 	if expected == nil && actual == nil {
@@ -266,9 +275,16 @@ type mockCSRControl struct {
 	approved       bool
 	issuedCertData []byte
 	csrClient      *clienttesting.Fake
+
+	// the ObjectMeta and request of the last created csr
+	createdObjMeta metav1.ObjectMeta
+	createdCSRData []byte
 }
 
-func (m *mockCSRControl) create(ctx context.Context, recorder events.Recorder, objMeta metav1.ObjectMeta, csrData []byte, signerName string, expirationSeconds *int32) (string, error) {
+func (m *mockCSRControl) Create(ctx context.Context, recorder events.Recorder, objMeta metav1.ObjectMeta, csrData []byte, signerName string,
+	usages []certificates.KeyUsage, expirationSeconds *int32) (string, error) {
+	m.createdObjMeta = objMeta
+	m.createdCSRData = csrData
 	mockCSR := &unstructured.Unstructured{}
 	_, err := m.csrClient.Invokes(clienttesting.CreateActionImpl{
 		ActionImpl: clienttesting.ActionImpl{
@@ -279,7 +295,7 @@ func (m *mockCSRControl) create(ctx context.Context, recorder events.Recorder, o
 	return objMeta.Name + rand.String(4), err
 }
 
-func (m *mockCSRControl) isApproved(name string) (bool, error) {
+func (m *mockCSRControl) IsApproved(name string) (bool, error) {
 	_, err := m.csrClient.Invokes(clienttesting.GetActionImpl{
 		ActionImpl: clienttesting.ActionImpl{
 			Verb:     "get",
@@ -291,7 +307,7 @@ func (m *mockCSRControl) isApproved(name string) (bool, error) {
 	return m.approved, err
 }
 
-func (m *mockCSRControl) getIssuedCertificate(name string) ([]byte, error) {
+func (m *mockCSRControl) GetIssuedCertificate(name string) ([]byte, error) {
 	_, err := m.csrClient.Invokes(clienttesting.GetActionImpl{
 		ActionImpl: clienttesting.ActionImpl{
 			Verb:     "get",
@@ -305,3 +321,248 @@ func (m *mockCSRControl) getIssuedCertificate(name string) ([]byte, error) {
 func (m *mockCSRControl) Informer() cache.SharedIndexInformer {
 	panic("implement me")
 }
+
+func TestJitter(t *testing.T) {
+	cases := []struct {
+		name       string
+		percentage float64
+		maxFactor  float64
+		expectedLo float64
+		expectedHi float64
+	}{
+		{
+			name:       "default renewal threshold",
+			percentage: DefaultRenewalThreshold,
+			maxFactor:  DefaultRenewalJitter,
+			expectedLo: 0.2,
+			expectedHi: 0.25,
+		},
+		{
+			name:       "customized renewal threshold",
+			percentage: 0.5,
+			maxFactor:  0.1,
+			expectedLo: 0.5,
+			expectedHi: 0.55,
+		},
+		{
+			name:       "no max factor",
+			percentage: 0.3,
+			expectedLo: 0.3,
+			expectedHi: 0.6,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				actual := jitter(c.percentage, c.maxFactor)
+				if actual < c.expectedLo || actual > c.expectedHi {
+					t.Errorf("expected jitter in range [%v, %v], but got %v", c.expectedLo, c.expectedHi, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncWithCSRBackoff(t *testing.T) {
+	cases := []struct {
+		name            string
+		csrAttempts     int
+		nextCSRTime     time.Time
+		expectedErr     bool
+		expectedCreated bool
+	}{
+		{
+			name:            "first csr",
+			expectedCreated: true,
+		},
+		{
+			name:        "wait for the backoff",
+			csrAttempts: 1,
+			nextCSRTime: time.Now().Add(time.Hour),
+		},
+		{
+			name:            "backoff is over",
+			csrAttempts:     1,
+			nextCSRTime:     time.Now().Add(-time.Second),
+			expectedCreated: true,
+		},
+		{
+			name:            "max attempts is reached",
+			csrAttempts:     2,
+			nextCSRTime:     time.Now().Add(-time.Second),
+			expectedErr:     true,
+			expectedCreated: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			hubKubeClient := kubefake.NewSimpleClientset()
+			ctrl := &mockCSRControl{csrClient: &hubKubeClient.Fake}
+
+			controller := &clientCertificateController{
+				ClientCertOption: ClientCertOption{
+					SecretNamespace: testNamespace,
+					SecretName:      testSecretName,
+				},
+				CSROption: CSROption{
+					ObjectMeta:      metav1.ObjectMeta{GenerateName: "test-"},
+					Subject:         &pkix.Name{CommonName: commonName},
+					SignerName:      certificates.KubeAPIServerClientSignerName,
+					HaltCSRCreation: func() bool { return false },
+					Backoff:         CSRBackoffOption{Initial: time.Minute, MaxAttempts: 3},
+				},
+				registrationDriver:   ctrl,
+				managementCoreClient: kubefake.NewSimpleClientset().CoreV1(),
+				controllerName:       "test-agent",
+				statusUpdater:        (&fakeStatusUpdater{}).update,
+				csrAttempts:          c.csrAttempts,
+				nextCSRTime:          c.nextCSRTime,
+			}
+
+			err := controller.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "key"))
+			if c.expectedErr != (err != nil) {
+				t.Errorf("expected error %v, but got %v", c.expectedErr, err)
+			}
+
+			if !c.expectedCreated {
+				testinghelpers.AssertNoActions(t, hubKubeClient.Actions())
+				return
+			}
+			testinghelpers.AssertActions(t, hubKubeClient.Actions(), "create")
+			if controller.csrAttempts != c.csrAttempts+1 {
+				t.Errorf("expected %d csr attempts, but got %d", c.csrAttempts+1, controller.csrAttempts)
+			}
+			if !controller.nextCSRTime.After(time.Now()) {
+				t.Errorf("expected the next csr is delayed, but got %v", controller.nextCSRTime)
+			}
+		})
+	}
+}
+
+func TestSyncWithFakeClock(t *testing.T) {
+	cert := testinghelpers.NewTestCert(commonName, 100*time.Hour)
+	notBefore, notAfter, err := getCertValidityPeriod(testinghelpers.NewHubKubeconfigSecret(
+		testNamespace, testSecretName, "", cert, map[string][]byte{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name             string
+		elapsed          time.Duration
+		expectedCreated  bool
+		expectedExpiring metav1.ConditionStatus
+	}{
+		{
+			name:             "certificate has enough of its life remaining",
+			elapsed:          10 * time.Hour,
+			expectedExpiring: metav1.ConditionFalse,
+		},
+		{
+			name:             "certificate is within the renewal threshold",
+			elapsed:          85 * time.Hour,
+			expectedCreated:  true,
+			expectedExpiring: metav1.ConditionTrue,
+		},
+		{
+			name:            "certificate is expired",
+			elapsed:         101 * time.Hour,
+			expectedCreated: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			hubKubeClient := kubefake.NewSimpleClientset()
+			secret := testinghelpers.NewHubKubeconfigSecret(testNamespace, testSecretName, "1", cert, map[string][]byte{})
+			statusUpdater := &fakeStatusUpdater{}
+			var reportedExpiration *time.Time
+
+			controller := &clientCertificateController{
+				ClientCertOption: ClientCertOption{
+					SecretNamespace:  testNamespace,
+					SecretName:       testSecretName,
+					RenewalThreshold: 0.2,
+					RenewalJitter:    0.01,
+					ExpirationReporter: func(_ context.Context, notAfter time.Time) error {
+						reportedExpiration = &notAfter
+						return nil
+					},
+					Clock: clocktesting.NewFakeClock(notBefore.Add(c.elapsed)),
+				},
+				CSROption: CSROption{
+					ObjectMeta:      metav1.ObjectMeta{GenerateName: "test-"},
+					Subject:         &pkix.Name{CommonName: commonName},
+					SignerName:      certificates.KubeAPIServerClientSignerName,
+					HaltCSRCreation: func() bool { return false },
+				},
+				registrationDriver:   &mockCSRControl{csrClient: &hubKubeClient.Fake},
+				managementCoreClient: kubefake.NewSimpleClientset(secret).CoreV1(),
+				controllerName:       "test-agent",
+				statusUpdater:        statusUpdater.update,
+			}
+
+			if err := controller.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "key")); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if c.expectedCreated {
+				testinghelpers.AssertActions(t, hubKubeClient.Actions(), "create")
+			} else {
+				testinghelpers.AssertNoActions(t, hubKubeClient.Actions())
+			}
+
+			switch {
+			case len(c.expectedExpiring) == 0 && statusUpdater.cond != nil:
+				t.Errorf("expected no condition is reported, but got %v", statusUpdater.cond)
+			case len(c.expectedExpiring) > 0 && (statusUpdater.cond == nil || statusUpdater.cond.Status != c.expectedExpiring):
+				t.Errorf("expected condition %s is %s, but got %v", ClusterCertificateExpiringCondition, c.expectedExpiring, statusUpdater.cond)
+			}
+
+			switch {
+			case len(c.expectedExpiring) == 0 && reportedExpiration != nil:
+				t.Errorf("expected no expiration is reported, but got %v", reportedExpiration)
+			case len(c.expectedExpiring) > 0 && (reportedExpiration == nil || !reportedExpiration.Equal(*notAfter)):
+				t.Errorf("expected expiration %v is reported, but got %v", notAfter, reportedExpiration)
+			}
+		})
+	}
+}
+
+func TestSyncWithIdentityKey(t *testing.T) {
+	identityKey := []byte("pre-shared-key")
+	hubKubeClient := kubefake.NewSimpleClientset()
+	ctrl := &mockCSRControl{csrClient: &hubKubeClient.Fake}
+
+	controller := &clientCertificateController{
+		ClientCertOption: ClientCertOption{
+			SecretNamespace: testNamespace,
+			SecretName:      testSecretName,
+		},
+		CSROption: CSROption{
+			ObjectMeta:      metav1.ObjectMeta{GenerateName: "test-"},
+			Subject:         &pkix.Name{CommonName: commonName},
+			SignerName:      certificates.KubeAPIServerClientSignerName,
+			HaltCSRCreation: func() bool { return false },
+			IdentityKey:     identityKey,
+		},
+		registrationDriver:   ctrl,
+		managementCoreClient: kubefake.NewSimpleClientset().CoreV1(),
+		controllerName:       "test-agent",
+		statusUpdater:        (&fakeStatusUpdater{}).update,
+	}
+
+	if err := controller.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "key")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	testinghelpers.AssertActions(t, hubKubeClient.Actions(), "create")
+
+	proof := ctrl.createdObjMeta.Annotations[IdentityProofAnnotation]
+	if !VerifyIdentityProof(identityKey, ctrl.createdCSRData, proof) {
+		t.Errorf("expected a valid identity proof, but got %q", proof)
+	}
+	if len(controller.ObjectMeta.Annotations) != 0 {
+		t.Errorf("expected the csr option is not changed, but got %v", controller.ObjectMeta.Annotations)
+	}
+}
@@ -0,0 +1,47 @@
+package clientcert
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	certificates "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// RegistrationDriverCSR requests the client certificates with the certificate signing requests of the hub
+	// kube-apiserver
+	RegistrationDriverCSR = "csr"
+	// RegistrationDriverGRPC requests the client certificates from a registration service of the hub over gRPC, so
+	// the agent can register to a hub which does not serve the Kubernetes API
+	RegistrationDriverGRPC = "grpc"
+	// RegistrationDriverAWSIRSA authenticates the agent to an EKS hub with the AWS IAM role of its service account
+	// instead of a client certificate, the role is mapped to the identity of the cluster by the hub
+	RegistrationDriverAWSIRSA = "awsirsa"
+	// RegistrationDriverOIDC authenticates the agent to the hub with a service account token projected with the
+	// audience of the hub instead of a client certificate, the hub trusts the issuer of the managed cluster
+	RegistrationDriverOIDC = "oidc"
+)
+
+// RegistrationDrivers are the supported registration drivers
+var RegistrationDrivers = []string{RegistrationDriverCSR, RegistrationDriverGRPC, RegistrationDriverAWSIRSA,
+	RegistrationDriverOIDC}
+
+// RegistrationDriver requests the client certificates of the agent from the hub. Each request is identified by the
+// name returned by Create, the client certificate controller polls the request with the name until the certificate
+// is issued.
+type RegistrationDriver interface {
+	// Create requests a certificate of the signer with the certificate request data and the key usages, and returns
+	// the name of the request
+	Create(ctx context.Context, recorder events.Recorder, objMeta metav1.ObjectMeta, csrData []byte, signerName string,
+		usages []certificates.KeyUsage, expirationSeconds *int32) (string, error)
+	// IsApproved returns true if the request is approved, a denied request is not approved
+	IsApproved(name string) (bool, error)
+	// GetIssuedCertificate returns the certificate issued for the request, it is empty until the certificate is issued
+	GetIssuedCertificate(name string) ([]byte, error)
+
+	// Informer returns the informer of the requests, the controllers are triggered by its events and add indexers to it
+	Informer() cache.SharedIndexInformer
+}
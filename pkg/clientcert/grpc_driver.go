@@ -0,0 +1,85 @@
+package clientcert
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	certificates "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// errRegistrationServiceNotImplemented is returned by the requests of the grpc driver until the registration service
+// of the hub is defined
+var errRegistrationServiceNotImplemented = errors.New("the hub registration service of the grpc registration driver is not implemented yet")
+
+var _ RegistrationDriver = &grpcDriver{}
+
+// grpcDriver is the registration driver talking to a registration service of the hub over gRPC instead of the hub
+// kube-apiserver. It is a stub, the connection to the service is established but the requests are not implemented.
+type grpcDriver struct {
+	address  string
+	conn     *grpc.ClientConn
+	informer cache.SharedIndexInformer
+}
+
+// NewGRPCDriver returns the grpc registration driver connecting to the registration service at the address with the
+// tls config. The connection is closed once the context is done.
+func NewGRPCDriver(ctx context.Context, address string, tlsConfig *tls.Config) (RegistrationDriver, error) {
+	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to the registration service %q: %w", address, err)
+	}
+
+	// the requests are not watched from a kube-apiserver, the informer is kept empty so the controllers and their
+	// indexers work the same as the csr driver
+	informer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return &certificates.CertificateSigningRequestList{}, nil
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return watch.NewFake(), nil
+			},
+		},
+		&certificates.CertificateSigningRequest{},
+		0,
+		cache.Indexers{},
+	)
+	go informer.Run(ctx.Done())
+	go func() {
+		<-ctx.Done()
+		if err := conn.Close(); err != nil {
+			klog.Warningf("failed to close the connection to the registration service %q: %v", address, err)
+		}
+	}()
+
+	klog.Infof("Using the grpc registration driver with the registration service %q", address)
+	return &grpcDriver{address: address, conn: conn, informer: informer}, nil
+}
+
+func (g *grpcDriver) Create(ctx context.Context, recorder events.Recorder, objMeta metav1.ObjectMeta, csrData []byte, signerName string,
+	usages []certificates.KeyUsage, expirationSeconds *int32) (string, error) {
+	return "", errRegistrationServiceNotImplemented
+}
+
+func (g *grpcDriver) IsApproved(name string) (bool, error) {
+	return false, errRegistrationServiceNotImplemented
+}
+
+func (g *grpcDriver) GetIssuedCertificate(name string) ([]byte, error) {
+	return nil, errRegistrationServiceNotImplemented
+}
+
+func (g *grpcDriver) Informer() cache.SharedIndexInformer {
+	return g.informer
+}
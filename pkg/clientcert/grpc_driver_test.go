@@ -0,0 +1,40 @@
+package clientcert
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestGRPCDriver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	driver, err := NewGRPCDriver(ctx, "127.0.0.1:8443", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the informer is synced without any request, so the controllers using it are started
+	if !cache.WaitForCacheSync(ctx.Done(), driver.Informer().HasSynced) {
+		t.Fatalf("expected the informer is synced")
+	}
+	if len(driver.Informer().GetStore().List()) != 0 {
+		t.Errorf("expected no request in the informer")
+	}
+
+	_, err = driver.Create(ctx, eventstesting.NewTestingEventRecorder(t), metav1.ObjectMeta{GenerateName: "cluster1-"}, []byte("csr"), "signer", ClientCertUsages, nil)
+	if err != errRegistrationServiceNotImplemented {
+		t.Errorf("expected the request is not implemented, but got %v", err)
+	}
+	if _, err := driver.IsApproved("cluster1-abcde"); err != errRegistrationServiceNotImplemented {
+		t.Errorf("expected the request is not implemented, but got %v", err)
+	}
+	if _, err := driver.GetIssuedCertificate("cluster1-abcde"); err != errRegistrationServiceNotImplemented {
+		t.Errorf("expected the request is not implemented, but got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package clientcert
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// IdentityProofAnnotation is the annotation on a csr holding the proof of the cluster identity. It is the base64
+// encoded HMAC-SHA256 of the certificate request with the pre-shared key of the cluster, so a leaked bootstrap
+// kubeconfig is not sufficient to register a cluster whose key is provisioned on the hub.
+const IdentityProofAnnotation = "open-cluster-management.io/cluster-identity-proof"
+
+const (
+	// ClusterIDClaimName is the name of the cluster claim holding the unique identifier of the cluster
+	ClusterIDClaimName = "id.k8s.io"
+	// ClusterIDAnnotation is the annotation on a csr holding the value of the id.k8s.io claim of the cluster. It is
+	// asserted by the requester, so the hub only reports it and does not regard it as the identity of the cluster.
+	ClusterIDAnnotation = "open-cluster-management.io/cluster-id"
+)
+
+// IdentityProof returns the proof of the cluster identity for the certificate request with the pre-shared key.
+func IdentityProof(key, request []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(request)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyIdentityProof returns true if the proof of the certificate request is made with the pre-shared key.
+func VerifyIdentityProof(key, request []byte, proof string) bool {
+	return hmac.Equal([]byte(IdentityProof(key, request)), []byte(proof))
+}
@@ -0,0 +1,110 @@
+package clientcert
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"sort"
+
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+)
+
+// KeyProviderSoftware is the name of the default key provider, which generates the private keys in memory
+const KeyProviderSoftware = "software"
+
+// KeyProviderFactory creates a KeyProvider with the option to generate the private keys
+type KeyProviderFactory func(option PrivateKeyOption) KeyProvider
+
+// keyProviderFactories are the key providers which are able to be selected by their names
+var keyProviderFactories = map[string]KeyProviderFactory{
+	KeyProviderSoftware: NewSoftwareKeyProvider,
+}
+
+// RegisterKeyProvider registers a key provider with the name, so it is able to be selected with NewKeyProvider, e.g.
+// a provider backed by a TPM in the builds of the agent supporting it.
+func RegisterKeyProvider(name string, factory KeyProviderFactory) {
+	keyProviderFactories[name] = factory
+}
+
+// KeyProviders returns the names of the registered key providers
+func KeyProviders() []string {
+	names := []string{}
+	for name := range keyProviderFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewKeyProvider returns the registered key provider with the name, the software key provider is returned if the
+// name is empty.
+func NewKeyProvider(name string, option PrivateKeyOption) (KeyProvider, error) {
+	if len(name) == 0 {
+		name = KeyProviderSoftware
+	}
+	factory, ok := keyProviderFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported key provider %q, it must be one of %v", name, KeyProviders())
+	}
+	return factory(option), nil
+}
+
+// KeyProvider generates and holds the private keys of the client certificates. The default provider generates
+// the keys in memory and stores them in the client certificate secrets in PEM format. A provider backed by a
+// TPM, PKCS#11 device or KMS may keep the keys non-exportable, and returns a reference of the key instead, which
+// is stored in the client certificate secret as the tls.key. The consumers of such secrets are expected to load
+// the key from the same provider.
+type KeyProvider interface {
+	// GenerateKey creates a new private key. It returns the data stored in the client certificate secret to
+	// identify the key, e.g. the key in PEM format or a reference of the key in the provider.
+	GenerateKey(ctx context.Context) ([]byte, error)
+	// Signer returns the signer of the private key identified by the key data, it is used to create the csrs.
+	Signer(ctx context.Context, keyData []byte) (crypto.Signer, error)
+}
+
+// softwareKeyProvider generates the private keys in memory, the keys are exported in PEM format.
+type softwareKeyProvider struct {
+	option PrivateKeyOption
+}
+
+// NewSoftwareKeyProvider returns a KeyProvider which generates the private keys in memory with the option.
+func NewSoftwareKeyProvider(option PrivateKeyOption) KeyProvider {
+	return &softwareKeyProvider{option: option}
+}
+
+func (p *softwareKeyProvider) GenerateKey(_ context.Context) ([]byte, error) {
+	return p.option.generate()
+}
+
+func (p *softwareKeyProvider) Signer(_ context.Context, keyData []byte) (crypto.Signer, error) {
+	privateKey, err := keyutil.ParsePrivateKeyPEM(keyData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+	return signer, nil
+}
+
+// certificateMatchesKey returns true if the public key of the first certificate in the cert data is the public
+// key of the signer.
+func certificateMatchesKey(certData []byte, signer crypto.Signer) (bool, error) {
+	certs, err := certutil.ParseCertsPEM(certData)
+	if err != nil {
+		return false, err
+	}
+	certPublicKey, err := x509.MarshalPKIXPublicKey(certs[0].PublicKey)
+	if err != nil {
+		return false, err
+	}
+	signerPublicKey, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(certPublicKey, signerPublicKey), nil
+}
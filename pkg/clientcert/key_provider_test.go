@@ -0,0 +1,116 @@
+package clientcert
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509/pkix"
+	"fmt"
+	"testing"
+	"time"
+
+	certificates "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+// fakeKeyProvider holds the private keys in memory and only exports the references of the keys
+type fakeKeyProvider struct {
+	signers map[string]crypto.Signer
+}
+
+func (p *fakeKeyProvider) GenerateKey(ctx context.Context) ([]byte, error) {
+	keyData, err := NewSoftwareKeyProvider(PrivateKeyOption{}).GenerateKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := NewSoftwareKeyProvider(PrivateKeyOption{}).Signer(ctx, keyData)
+	if err != nil {
+		return nil, err
+	}
+	ref := fmt.Sprintf("fake:key-%d", len(p.signers))
+	p.signers[ref] = signer
+	return []byte(ref), nil
+}
+
+func (p *fakeKeyProvider) Signer(_ context.Context, keyData []byte) (crypto.Signer, error) {
+	signer, ok := p.signers[string(keyData)]
+	if !ok {
+		return nil, fmt.Errorf("key %q is not found", keyData)
+	}
+	return signer, nil
+}
+
+func TestNewKeyProvider(t *testing.T) {
+	RegisterKeyProvider("fake", func(option PrivateKeyOption) KeyProvider {
+		return &fakeKeyProvider{signers: map[string]crypto.Signer{}}
+	})
+	defer delete(keyProviderFactories, "fake")
+
+	if provider, err := NewKeyProvider("", PrivateKeyOption{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if _, ok := provider.(*softwareKeyProvider); !ok {
+		t.Errorf("expected the software key provider by default, but got %T", provider)
+	}
+	if provider, err := NewKeyProvider("fake", PrivateKeyOption{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if _, ok := provider.(*fakeKeyProvider); !ok {
+		t.Errorf("expected the registered key provider, but got %T", provider)
+	}
+	_, err := NewKeyProvider("tpm", PrivateKeyOption{})
+	testinghelpers.AssertError(t, err, "unsupported key provider \"tpm\", it must be one of [fake software]")
+}
+
+func TestCertificateMatchesKey(t *testing.T) {
+	cert1 := testinghelpers.NewTestCert("test1", 60*time.Second)
+	cert2 := testinghelpers.NewTestCert("test2", 60*time.Second)
+
+	signer, err := NewSoftwareKeyProvider(PrivateKeyOption{}).Signer(context.TODO(), cert1.Key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	matched, err := certificateMatchesKey(cert1.Cert, signer)
+	if err != nil || !matched {
+		t.Errorf("expected the cert matches the key, but got %v, %v", matched, err)
+	}
+	matched, err = certificateMatchesKey(cert2.Cert, signer)
+	if err != nil || matched {
+		t.Errorf("expected the cert does not match the key, but got %v, %v", matched, err)
+	}
+}
+
+func TestSyncWithKeyProvider(t *testing.T) {
+	provider := &fakeKeyProvider{signers: map[string]crypto.Signer{}}
+	hubKubeClient := kubefake.NewSimpleClientset()
+	agentKubeClient := kubefake.NewSimpleClientset()
+
+	controller := &clientCertificateController{
+		ClientCertOption: ClientCertOption{
+			SecretNamespace: testNamespace,
+			SecretName:      testSecretName,
+		},
+		CSROption: CSROption{
+			ObjectMeta:      metav1.ObjectMeta{GenerateName: "test-"},
+			Subject:         &pkix.Name{CommonName: commonName},
+			SignerName:      certificates.KubeAPIServerClientSignerName,
+			HaltCSRCreation: func() bool { return false },
+			KeyProvider:     provider,
+		},
+		registrationDriver:   &mockCSRControl{csrClient: &hubKubeClient.Fake},
+		managementCoreClient: agentKubeClient.CoreV1(),
+		controllerName:       "test-agent",
+		statusUpdater:        (&fakeStatusUpdater{}).update,
+	}
+
+	if err := controller.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "key")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// only the reference of the key is kept by the controller
+	if string(controller.keyData) != "fake:key-0" {
+		t.Errorf("expected the key reference, but got %q", controller.keyData)
+	}
+	testinghelpers.AssertActions(t, hubKubeClient.Actions(), "create")
+}
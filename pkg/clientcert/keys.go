@@ -0,0 +1,112 @@
+package clientcert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	cryptorand "crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"k8s.io/client-go/util/keyutil"
+)
+
+const (
+	// KeyTypeRSA and KeyTypeECDSA are the supported types of the private keys of the client certificates
+	KeyTypeRSA   = "rsa"
+	KeyTypeECDSA = "ecdsa"
+
+	// DefaultRSAKeySize is the default size of the RSA private keys
+	DefaultRSAKeySize = 2048
+	// DefaultECDSACurve is the default curve of the ECDSA private keys
+	DefaultECDSACurve = "P256"
+)
+
+var (
+	// KeyTypes are the supported types of the private keys
+	KeyTypes = []string{KeyTypeECDSA, KeyTypeRSA}
+	// RSAKeySizes are the supported sizes of the RSA private keys
+	RSAKeySizes = []int{2048, 3072, 4096}
+	// ECDSACurves are the supported curves of the ECDSA private keys
+	ECDSACurves = []string{"P256", "P384", "P521"}
+
+	curves = map[string]elliptic.Curve{
+		"P256": elliptic.P256(),
+		"P384": elliptic.P384(),
+		"P521": elliptic.P521(),
+	}
+)
+
+// PrivateKeyOption includes options that is used to generate the private keys of the client certificates. An
+// ECDSA private key with the P256 curve is generated if the option is not set.
+type PrivateKeyOption struct {
+	// Type is the type of the private key, it is one of KeyTypes
+	Type string
+	// RSAKeySize is the size of the RSA private key, it is one of RSAKeySizes
+	RSAKeySize int
+	// ECDSACurve is the curve of the ECDSA private key, it is one of ECDSACurves
+	ECDSACurve string
+}
+
+// Validate verifies the private key option.
+func (o PrivateKeyOption) Validate() error {
+	switch o.Type {
+	case "", KeyTypeECDSA:
+		if _, ok := curves[o.ecdsaCurve()]; !ok {
+			return fmt.Errorf("unsupported ECDSA curve %q, it must be one of %v", o.ECDSACurve, ECDSACurves)
+		}
+	case KeyTypeRSA:
+		for _, size := range RSAKeySizes {
+			if size == o.rsaKeySize() {
+				return nil
+			}
+		}
+		return fmt.Errorf("unsupported RSA key size %d, it must be one of %v", o.RSAKeySize, RSAKeySizes)
+	default:
+		return fmt.Errorf("unsupported key type %q, it must be one of %v", o.Type, KeyTypes)
+	}
+	return nil
+}
+
+// generate creates a new private key in PEM format.
+func (o PrivateKeyOption) generate() ([]byte, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	if o.Type == KeyTypeRSA {
+		privateKey, err := rsa.GenerateKey(cryptorand.Reader, o.rsaKeySize())
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  keyutil.RSAPrivateKeyBlockType,
+			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+		}), nil
+	}
+
+	privateKey, err := ecdsa.GenerateKey(curves[o.ecdsaCurve()], cryptorand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	derBytes, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal ECDSA private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: keyutil.ECPrivateKeyBlockType, Bytes: derBytes}), nil
+}
+
+func (o PrivateKeyOption) rsaKeySize() int {
+	if o.RSAKeySize == 0 {
+		return DefaultRSAKeySize
+	}
+	return o.RSAKeySize
+}
+
+func (o PrivateKeyOption) ecdsaCurve() string {
+	if len(o.ECDSACurve) == 0 {
+		return DefaultECDSACurve
+	}
+	return o.ECDSACurve
+}
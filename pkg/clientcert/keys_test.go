@@ -0,0 +1,98 @@
+package clientcert
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509/pkix"
+	"testing"
+
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestPrivateKeyOption(t *testing.T) {
+	cases := []struct {
+		name          string
+		option        PrivateKeyOption
+		expectedErr   string
+		validateKeyFn func(t *testing.T, key interface{})
+	}{
+		{
+			name:   "default option",
+			option: PrivateKeyOption{},
+			validateKeyFn: func(t *testing.T, key interface{}) {
+				ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+				if !ok {
+					t.Fatalf("expected ECDSA key, but got %T", key)
+				}
+				if ecdsaKey.Curve.Params().Name != "P-256" {
+					t.Errorf("expected curve P-256, but got %s", ecdsaKey.Curve.Params().Name)
+				}
+			},
+		},
+		{
+			name:   "ecdsa P384",
+			option: PrivateKeyOption{Type: KeyTypeECDSA, ECDSACurve: "P384"},
+			validateKeyFn: func(t *testing.T, key interface{}) {
+				ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+				if !ok {
+					t.Fatalf("expected ECDSA key, but got %T", key)
+				}
+				if ecdsaKey.Curve.Params().Name != "P-384" {
+					t.Errorf("expected curve P-384, but got %s", ecdsaKey.Curve.Params().Name)
+				}
+			},
+		},
+		{
+			name:   "rsa 3072",
+			option: PrivateKeyOption{Type: KeyTypeRSA, RSAKeySize: 3072},
+			validateKeyFn: func(t *testing.T, key interface{}) {
+				rsaKey, ok := key.(*rsa.PrivateKey)
+				if !ok {
+					t.Fatalf("expected RSA key, but got %T", key)
+				}
+				if rsaKey.N.BitLen() != 3072 {
+					t.Errorf("expected key size 3072, but got %d", rsaKey.N.BitLen())
+				}
+			},
+		},
+		{
+			name:        "unsupported key type",
+			option:      PrivateKeyOption{Type: "dsa"},
+			expectedErr: "unsupported key type \"dsa\", it must be one of [ecdsa rsa]",
+		},
+		{
+			name:        "unsupported rsa key size",
+			option:      PrivateKeyOption{Type: KeyTypeRSA, RSAKeySize: 1024},
+			expectedErr: "unsupported RSA key size 1024, it must be one of [2048 3072 4096]",
+		},
+		{
+			name:        "unsupported ecdsa curve",
+			option:      PrivateKeyOption{Type: KeyTypeECDSA, ECDSACurve: "P224"},
+			expectedErr: "unsupported ECDSA curve \"P224\", it must be one of [P256 P384 P521]",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			keyData, err := c.option.generate()
+			testinghelpers.AssertError(t, err, c.expectedErr)
+			if err != nil {
+				return
+			}
+
+			key, err := keyutil.ParsePrivateKeyPEM(keyData)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			c.validateKeyFn(t, key)
+
+			// the csr is able to be created with the key
+			if _, err := certutil.MakeCSR(key, &pkix.Name{CommonName: "test"}, nil, nil); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package componentconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	configv1alpha1 "open-cluster-management.io/registration/pkg/apis/config/v1alpha1"
+)
+
+// genericOperatorConfigFields are the fields of the GenericOperatorConfig of library-go, which reads the same file
+// of the --config flag, so they are allowed in the configuration file
+var genericOperatorConfigFields = []string{"servingInfo", "leaderElection", "authentication", "authorization"}
+
+// AddConfigFile makes the command load the configuration of the kind from the file of the --config flag before it
+// runs, and set the flags of the command which are not set on the command line with the fields of the configuration.
+// The config is a pointer to RegistrationHubConfiguration or RegistrationAgentConfiguration. The file is ignored if
+// it is not a configuration of the configuration API, e.g. a GenericOperatorConfig of library-go. It should be called
+// after the other PreRunE of the command is set, so the other PreRunE sees the flags set by the configuration.
+func AddConfigFile(cmd *cobra.Command, kind string, config interface{}) {
+	preRunE := cmd.PreRunE
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		if len(configFile) > 0 {
+			loaded, err := loadConfigFile(configFile, kind, config)
+			if err != nil {
+				return err
+			}
+			if loaded {
+				if err := applyToFlags(config, cmd.Flags()); err != nil {
+					return fmt.Errorf("invalid config file %q: %w", configFile, err)
+				}
+			}
+		}
+		if preRunE == nil {
+			return nil
+		}
+		return preRunE(cmd, args)
+	}
+}
+
+// loadConfigFile decodes the configuration of the kind in the file strictly, and returns false if the file is not
+// a configuration of the configuration API
+func loadConfigFile(configFile, kind string, config interface{}) (bool, error) {
+	content, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return false, err
+	}
+
+	typeMeta := metav1.TypeMeta{}
+	if err := yaml.Unmarshal(content, &typeMeta); err != nil {
+		return false, fmt.Errorf("unable to decode config file %q: %w", configFile, err)
+	}
+	if typeMeta.APIVersion != configv1alpha1.GroupVersion.String() {
+		return false, nil
+	}
+	if typeMeta.Kind != kind {
+		return false, fmt.Errorf("config file %q must be a %s, but got %q", configFile, kind, typeMeta.Kind)
+	}
+
+	fields := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &fields); err != nil {
+		return false, fmt.Errorf("unable to decode config file %q: %w", configFile, err)
+	}
+	for _, field := range genericOperatorConfigFields {
+		delete(fields, field)
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return false, err
+	}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return false, fmt.Errorf("unable to decode config file %q: %w", configFile, err)
+	}
+	return true, nil
+}
+
+// applyToFlags sets the flags with the fields of the configuration which are set, the flag of each field is named
+// in its flag tag. The flags which are already set on the command line are kept.
+func applyToFlags(config interface{}, fs *pflag.FlagSet) error {
+	value := reflect.ValueOf(config).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := field.Tag.Get("flag")
+		if len(name) == 0 {
+			continue
+		}
+		flag := fs.Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("flag %q of field %s is not found", name, field.Name)
+		}
+		if flag.Changed {
+			continue
+		}
+		for _, flagValue := range flagValues(value.Field(i)) {
+			if err := fs.Set(name, flagValue); err != nil {
+				return fmt.Errorf("invalid value %q of field %s: %w", flagValue, field.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// flagValues returns the values to set to the flag of the field, the elements of the lists and maps are set one by
+// one, since the first value replaces the default of the flag and the others are appended.
+func flagValues(field reflect.Value) []string {
+	switch field.Kind() {
+	case reflect.Ptr:
+		if field.IsNil() {
+			return nil
+		}
+		if duration, ok := field.Interface().(*metav1.Duration); ok {
+			return []string{duration.Duration.String()}
+		}
+		return []string{fmt.Sprint(field.Elem().Interface())}
+	case reflect.Slice:
+		values := []string{}
+		for i := 0; i < field.Len(); i++ {
+			values = append(values, fmt.Sprint(field.Index(i).Interface()))
+		}
+		return values
+	case reflect.Map:
+		values := []string{}
+		for _, key := range field.MapKeys() {
+			values = append(values, fmt.Sprintf("%v=%v", key.Interface(), field.MapIndex(key).Interface()))
+		}
+		sort.Strings(values)
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package componentconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	configv1alpha1 "open-cluster-management.io/registration/pkg/apis/config/v1alpha1"
+	"open-cluster-management.io/registration/pkg/hub"
+	"open-cluster-management.io/registration/pkg/spoke"
+)
+
+func TestConfigurationCoversFlags(t *testing.T) {
+	cases := []struct {
+		name     string
+		addFlags func(fs *pflag.FlagSet)
+		config   interface{}
+	}{
+		{
+			name:     "hub",
+			addFlags: hub.NewHubManagerOptions().AddFlags,
+			config:   configv1alpha1.RegistrationHubConfiguration{},
+		},
+		{
+			name:     "agent",
+			addFlags: spoke.NewSpokeAgentOptions().AddFlags,
+			config:   configv1alpha1.RegistrationAgentConfiguration{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fs := pflag.NewFlagSet(c.name, pflag.ContinueOnError)
+			c.addFlags(fs)
+
+			fields := map[string]bool{}
+			configType := reflect.TypeOf(c.config)
+			for i := 0; i < configType.NumField(); i++ {
+				if name := configType.Field(i).Tag.Get("flag"); len(name) > 0 {
+					fields[name] = true
+					if fs.Lookup(name) == nil {
+						t.Errorf("flag %q of field %s is not found", name, configType.Field(i).Name)
+					}
+				}
+			}
+			fs.VisitAll(func(flag *pflag.Flag) {
+				if !fields[flag.Name] {
+					t.Errorf("flag %q is not covered by %s", flag.Name, configType.Name())
+				}
+			})
+		})
+	}
+}
+
+func TestAddConfigFile(t *testing.T) {
+	cases := []struct {
+		name        string
+		content     string
+		args        []string
+		expectedErr string
+		validate    func(t *testing.T, options *hub.HubManagerOptions)
+	}{
+		{
+			name: "generic operator config",
+			content: `apiVersion: operator.openshift.io/v1alpha1
+kind: GenericOperatorConfig
+clusterAutoApprovalUsers: ["user1"]
+`,
+			validate: func(t *testing.T, options *hub.HubManagerOptions) {
+				if len(options.ClusterAutoApprovalUsers) != 0 {
+					t.Errorf("expected the generic operator config is ignored, but got %v", options.ClusterAutoApprovalUsers)
+				}
+			},
+		},
+		{
+			name: "wrong kind",
+			content: `apiVersion: config.registration.open-cluster-management.io/v1alpha1
+kind: RegistrationAgentConfiguration
+`,
+			expectedErr: "must be a RegistrationHubConfiguration",
+		},
+		{
+			name: "unknown field",
+			content: `apiVersion: config.registration.open-cluster-management.io/v1alpha1
+kind: RegistrationHubConfiguration
+unknownField: true
+`,
+			expectedErr: "unknown field",
+		},
+		{
+			name: "invalid value",
+			content: `apiVersion: config.registration.open-cluster-management.io/v1alpha1
+kind: RegistrationHubConfiguration
+featureGates:
+  UnknownFeature: true
+`,
+			expectedErr: "invalid value",
+		},
+		{
+			name: "set flags",
+			content: `apiVersion: config.registration.open-cluster-management.io/v1alpha1
+kind: RegistrationHubConfiguration
+leaderElection:
+  namespace: open-cluster-management-hub
+clusterAutoApprovalUsers: ["user1", "user2"]
+addOnSignerCASecrets:
+  example.com/signer1: ns/ca1
+  example.com/signer2: ns/ca2
+csrResyncInterval: 1m
+shardCount: 3
+syncClusterEndpoints: true
+tracingSamplingRatio: 0.5
+`,
+			validate: func(t *testing.T, options *hub.HubManagerOptions) {
+				if !reflect.DeepEqual(options.ClusterAutoApprovalUsers, []string{"user1", "user2"}) {
+					t.Errorf("unexpected cluster auto approval users %v", options.ClusterAutoApprovalUsers)
+				}
+				if !reflect.DeepEqual(options.AddOnSignerCASecrets, map[string]string{
+					"example.com/signer1": "ns/ca1",
+					"example.com/signer2": "ns/ca2",
+				}) {
+					t.Errorf("unexpected addon signer ca secrets %v", options.AddOnSignerCASecrets)
+				}
+				if options.CSRResyncInterval != time.Minute {
+					t.Errorf("unexpected csr resync interval %v", options.CSRResyncInterval)
+				}
+				if options.ShardCount != 3 || !options.SyncClusterEndpoints || options.TracingSamplingRatio != 0.5 {
+					t.Errorf("unexpected options %v", options)
+				}
+			},
+		},
+		{
+			name: "flags on command line take precedence",
+			content: `apiVersion: config.registration.open-cluster-management.io/v1alpha1
+kind: RegistrationHubConfiguration
+clusterAutoApprovalUsers: ["user1"]
+shardCount: 3
+`,
+			args: []string{"--cluster-auto-approval-users=user2"},
+			validate: func(t *testing.T, options *hub.HubManagerOptions) {
+				if !reflect.DeepEqual(options.ClusterAutoApprovalUsers, []string{"user2"}) {
+					t.Errorf("unexpected cluster auto approval users %v", options.ClusterAutoApprovalUsers)
+				}
+				if options.ShardCount != 3 {
+					t.Errorf("unexpected shard count %d", options.ShardCount)
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tempDir, err := ioutil.TempDir("", "testcomponentconfig")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tempDir)
+
+			configFile := path.Join(tempDir, "config.yaml")
+			if err := ioutil.WriteFile(configFile, []byte(c.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			options := hub.NewHubManagerOptions()
+			preRun := false
+			cmd := &cobra.Command{
+				PreRunE: func(cmd *cobra.Command, args []string) error {
+					preRun = true
+					return nil
+				},
+			}
+			cmd.Flags().String("config", "", "")
+			options.AddFlags(cmd.Flags())
+			AddConfigFile(cmd, configv1alpha1.RegistrationHubConfigurationKind, &configv1alpha1.RegistrationHubConfiguration{})
+
+			if err := cmd.ParseFlags(append(c.args, "--config="+configFile)); err != nil {
+				t.Fatal(err)
+			}
+			err = cmd.PreRunE(cmd, nil)
+			if len(c.expectedErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), c.expectedErr) {
+					t.Errorf("expected error %q, but got %v", c.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !preRun {
+				t.Errorf("expected the PreRunE of the command is called")
+			}
+			c.validate(t, options)
+		})
+	}
+}
@@ -1,12 +1,17 @@
 package hub
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 
+	configv1alpha1 "open-cluster-management.io/registration/pkg/apis/config/v1alpha1"
+	"open-cluster-management.io/registration/pkg/cmd/componentconfig"
+	"open-cluster-management.io/registration/pkg/cmd/leaderelection"
+	"open-cluster-management.io/registration/pkg/features"
 	"open-cluster-management.io/registration/pkg/hub"
 	"open-cluster-management.io/registration/pkg/version"
 )
@@ -19,23 +24,26 @@ func NewController() *cobra.Command {
 	cmd.Use = "controller"
 	cmd.Short = "Start the Cluster Registration Controller"
 
-	flags := cmd.Flags()
-
-	flags.DurationVar(&cmdConfig.LeaseDuration.Duration, "leader-election-lease-duration", 137*time.Second, ""+
-		"The duration that non-leader candidates will wait after observing a leadership "+
-		"renewal until attempting to acquire leadership of a led but unrenewed leader "+
-		"slot. This is effectively the maximum duration that a leader can be stopped "+
-		"before it is replaced by another candidate. This is only applicable if leader "+
-		"election is enabled.")
-	flags.DurationVar(&cmdConfig.RenewDeadline.Duration, "leader-election-renew-deadline", 107*time.Second, ""+
-		"The interval between attempts by the acting master to renew a leadership slot "+
-		"before it stops leading. This must be less than or equal to the lease duration. "+
-		"This is only applicable if leader election is enabled.")
-	flags.DurationVar(&cmdConfig.RetryPeriod.Duration, "leader-election-retry-period", 26*time.Second, ""+
-		"The duration the clients should wait between attempting acquisition and renewal "+
-		"of a leadership. This is only applicable if leader election is enabled.")
+	leaderElectionOptions := &leaderelection.Options{}
+	leaderElectionOptions.AddFlags(cmd, cmdConfig, 137*time.Second, 107*time.Second, 26*time.Second)
 
 	manager.AddFlags(cmd.Flags())
 
+	// the replicas of different shards and label selectors are elected separately, and the feature gates file is
+	// loaded before the election
+	preRunE := cmd.PreRunE
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := features.LoadFeatureGatesFile(features.DefaultHubMutableFeatureGate, manager.FeatureGatesFile); err != nil {
+			return fmt.Errorf("failed to load the feature gates file: %w", err)
+		}
+		if name := manager.LeaderElectionLockName(); len(name) > 0 {
+			leaderElectionOptions.Name = name
+		}
+		return preRunE(cmd, args)
+	}
+
+	// the configuration is applied to the flags before the shard and selectors are read
+	componentconfig.AddConfigFile(cmd, configv1alpha1.RegistrationHubConfigurationKind, &configv1alpha1.RegistrationHubConfiguration{})
+
 	return cmd
 }
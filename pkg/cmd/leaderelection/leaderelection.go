@@ -0,0 +1,154 @@
+package leaderelection
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Options are the leader election options of a controller command
+type Options struct {
+	// Namespace is the namespace of the leader election lock. If it is empty, the lock is in the namespace of the
+	// controller.
+	Namespace string
+	// Name is the name of the leader election lock. If it is empty, the lock is named after the controller. It is
+	// not exposed as a flag, the controllers set it when the replicas are elected separately, e.g. with sharding.
+	Name string
+
+	// configFile is the temporary config file with the namespace and name of the lock, which is removed once the
+	// command exits
+	configFile string
+}
+
+// AddFlags registers the leader election flags of the controller command with the default durations. The zero
+// durations fall back to the defaults of library-go, which are tuned for the single node clusters.
+func (o *Options) AddFlags(cmd *cobra.Command, cmdConfig *controllercmd.ControllerCommandConfig,
+	leaseDuration, renewDeadline, retryPeriod time.Duration) {
+	flags := cmd.Flags()
+	flags.BoolVar(&cmdConfig.DisableLeaderElection, "disable-leader-election", false,
+		"Disable leader election, it is only safe for the single replica installs.")
+	flags.DurationVar(&cmdConfig.LeaseDuration.Duration, "leader-election-lease-duration", leaseDuration, ""+
+		"The duration that non-leader candidates will wait after observing a leadership "+
+		"renewal until attempting to acquire leadership of a led but unrenewed leader "+
+		"slot. This is effectively the maximum duration that a leader can be stopped "+
+		"before it is replaced by another candidate. This is only applicable if leader "+
+		"election is enabled.")
+	flags.DurationVar(&cmdConfig.RenewDeadline.Duration, "leader-election-renew-deadline", renewDeadline, ""+
+		"The interval between attempts by the acting master to renew a leadership slot "+
+		"before it stops leading. This must be less than or equal to the lease duration. "+
+		"This is only applicable if leader election is enabled.")
+	flags.DurationVar(&cmdConfig.RetryPeriod.Duration, "leader-election-retry-period", retryPeriod, ""+
+		"The duration the clients should wait between attempting acquisition and renewal "+
+		"of a leadership. This is only applicable if leader election is enabled.")
+	flags.StringVar(&o.Namespace, "leader-election-namespace", o.Namespace,
+		"The namespace of the leader election lock. If it is not set, the lock is in the namespace of the controller.")
+
+	// library-go only reads the namespace and name of the lock from the config file, so they are set in a copy of
+	// the config file before the command runs. The copy is observed by library-go to restart the controller on
+	// change, so it is only removed after the command runs.
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := validate(cmdConfig); err != nil {
+			return err
+		}
+		if len(o.Namespace) == 0 && len(o.Name) == 0 {
+			return nil
+		}
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		o.configFile, err = o.writeConfigFile(configFile)
+		if err != nil {
+			return err
+		}
+		return cmd.Flags().Set("config", o.configFile)
+	}
+	cmd.PostRunE = func(cmd *cobra.Command, args []string) error {
+		return o.removeConfigFile()
+	}
+}
+
+// removeConfigFile removes the temporary config file written before the command runs
+func (o *Options) removeConfigFile() error {
+	if len(o.configFile) == 0 {
+		return nil
+	}
+	if err := os.Remove(o.configFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	o.configFile = ""
+	return nil
+}
+
+// validate verifies the leader election durations of the controller command
+func validate(cmdConfig *controllercmd.ControllerCommandConfig) error {
+	if cmdConfig.DisableLeaderElection {
+		return nil
+	}
+	if cmdConfig.LeaseDuration.Duration < 0 || cmdConfig.RenewDeadline.Duration < 0 || cmdConfig.RetryPeriod.Duration < 0 {
+		return fmt.Errorf("leader election durations must not be negative")
+	}
+	if cmdConfig.LeaseDuration.Duration > 0 && cmdConfig.RenewDeadline.Duration > cmdConfig.LeaseDuration.Duration {
+		return fmt.Errorf("leader election renew deadline %s must be less than or equal to the lease duration %s",
+			cmdConfig.RenewDeadline.Duration, cmdConfig.LeaseDuration.Duration)
+	}
+	return nil
+}
+
+// writeConfigFile writes the content of the config file with the namespace and name of the lock to a temporary file
+func (o *Options) writeConfigFile(configFile string) (string, error) {
+	config := map[string]interface{}{
+		"apiVersion": "operator.openshift.io/v1alpha1",
+		"kind":       "GenericOperatorConfig",
+	}
+	if len(configFile) > 0 {
+		content, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			return "", err
+		}
+		data, err := yaml.ToJSON(content)
+		if err != nil {
+			return "", err
+		}
+		if len(content) > 0 {
+			if err := json.Unmarshal(data, &config); err != nil {
+				return "", fmt.Errorf("unable to decode config file %q: %w", configFile, err)
+			}
+		}
+	}
+
+	leaderElection, _ := config["leaderElection"].(map[string]interface{})
+	if leaderElection == nil {
+		leaderElection = map[string]interface{}{}
+	}
+	if len(o.Namespace) > 0 {
+		leaderElection["namespace"] = o.Namespace
+	}
+	if len(o.Name) > 0 {
+		leaderElection["name"] = o.Name
+	}
+	config["leaderElection"] = leaderElection
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	file, err := ioutil.TempFile("", "config-*.json")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
@@ -0,0 +1,160 @@
+package leaderelection
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+	"github.com/spf13/cobra"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestWriteConfigFile(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "testleaderelection")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	configFile := path.Join(tempDir, "config.yaml")
+	if err := ioutil.WriteFile(configFile, []byte(`apiVersion: operator.openshift.io/v1alpha1
+kind: GenericOperatorConfig
+servingInfo:
+  bindAddress: 0.0.0.0:8443
+leaderElection:
+  name: registration-lock
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name                  string
+		configFile            string
+		expectedBindAddress   string
+		expectedLockName      string
+		lockName              string
+		expectedLockNamespace string
+	}{
+		{
+			name:                  "no config file",
+			expectedLockNamespace: "leader-election",
+		},
+		{
+			name:                  "lock name",
+			lockName:              "registration-controller-shard-1-lock",
+			expectedLockName:      "registration-controller-shard-1-lock",
+			expectedLockNamespace: "leader-election",
+		},
+		{
+			name:                  "merge config file",
+			configFile:            configFile,
+			expectedBindAddress:   "0.0.0.0:8443",
+			expectedLockName:      "registration-lock",
+			expectedLockNamespace: "leader-election",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			o := &Options{Namespace: "leader-election", Name: c.lockName}
+			file, err := o.writeConfigFile(c.configFile)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer os.Remove(file)
+
+			content, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config := &operatorv1alpha1.GenericOperatorConfig{}
+			if err := yaml.Unmarshal(content, config); err != nil {
+				t.Fatal(err)
+			}
+			if config.Kind != "GenericOperatorConfig" {
+				t.Errorf("unexpected kind %q", config.Kind)
+			}
+			if config.ServingInfo.BindAddress != c.expectedBindAddress {
+				t.Errorf("expected bind address %q, but got %q", c.expectedBindAddress, config.ServingInfo.BindAddress)
+			}
+			if config.LeaderElection.Name != c.expectedLockName {
+				t.Errorf("expected lock name %q, but got %q", c.expectedLockName, config.LeaderElection.Name)
+			}
+			if config.LeaderElection.Namespace != c.expectedLockNamespace {
+				t.Errorf("expected lock namespace %q, but got %q", c.expectedLockNamespace, config.LeaderElection.Namespace)
+			}
+		})
+	}
+}
+
+func TestRemoveConfigFile(t *testing.T) {
+	cmd := &cobra.Command{Run: func(cmd *cobra.Command, args []string) {}}
+	cmd.Flags().String("config", "", "")
+	o := &Options{Namespace: "leader-election"}
+	o.AddFlags(cmd, &controllercmd.ControllerCommandConfig{}, 0, 0, 0)
+
+	if err := cmd.PreRunE(cmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	configFile, err := cmd.Flags().GetString("config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(configFile); err != nil {
+		t.Fatalf("expected the config file %q is written, but got %v", configFile, err)
+	}
+
+	if err := cmd.PostRunE(cmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(configFile); !os.IsNotExist(err) {
+		t.Errorf("expected the config file %q is removed, but got %v", configFile, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name          string
+		disable       bool
+		leaseDuration time.Duration
+		renewDeadline time.Duration
+		expectedErr   string
+	}{
+		{
+			name:          "default durations",
+			leaseDuration: 137 * time.Second,
+			renewDeadline: 107 * time.Second,
+		},
+		{
+			name:          "renew deadline is larger than lease duration",
+			leaseDuration: 30 * time.Second,
+			renewDeadline: 60 * time.Second,
+			expectedErr:   "leader election renew deadline 1m0s must be less than or equal to the lease duration 30s",
+		},
+		{
+			name:          "negative duration",
+			renewDeadline: -time.Second,
+			expectedErr:   "leader election durations must not be negative",
+		},
+		{
+			name:          "leader election is disabled",
+			disable:       true,
+			renewDeadline: -time.Second,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cmdConfig := &controllercmd.ControllerCommandConfig{DisableLeaderElection: c.disable}
+			cmdConfig.LeaseDuration.Duration = c.leaseDuration
+			cmdConfig.RenewDeadline.Duration = c.renewDeadline
+
+			testinghelpers.AssertError(t, validate(cmdConfig), c.expectedErr)
+		})
+	}
+}
@@ -1,10 +1,16 @@
 package spoke
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 
+	configv1alpha1 "open-cluster-management.io/registration/pkg/apis/config/v1alpha1"
+	"open-cluster-management.io/registration/pkg/cmd/componentconfig"
+	"open-cluster-management.io/registration/pkg/cmd/leaderelection"
+	"open-cluster-management.io/registration/pkg/features"
 	"open-cluster-management.io/registration/pkg/spoke"
 	"open-cluster-management.io/registration/pkg/version"
 )
@@ -21,6 +27,18 @@ func NewAgent() *cobra.Command {
 	flags := cmd.Flags()
 	agentOptions.AddFlags(flags)
 
-	flags.BoolVar(&cmdConfig.DisableLeaderElection, "disable-leader-election", false, "Disable leader election for the agent.")
+	leaderElectionOptions := &leaderelection.Options{}
+	leaderElectionOptions.AddFlags(cmd, cmdConfig, 0, 0, 0)
+
+	// the feature gates file is loaded before the leader election
+	preRunE := cmd.PreRunE
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := features.LoadFeatureGatesFile(features.DefaultSpokeMutableFeatureGate, agentOptions.FeatureGatesFile); err != nil {
+			return fmt.Errorf("unable to load the feature gates file: %w", err)
+		}
+		return preRunE(cmd, args)
+	}
+
+	componentconfig.AddConfigFile(cmd, configv1alpha1.RegistrationAgentConfigurationKind, &configv1alpha1.RegistrationAgentConfiguration{})
 	return cmd
 }
@@ -1,11 +1,37 @@
 package webhook
 
-import "github.com/spf13/pflag"
+import (
+	"github.com/spf13/pflag"
+
+	"open-cluster-management.io/registration/pkg/hub/acceptancereview"
+)
 
 // Config contains the server (the webhook) cert and key.
 type Options struct {
 	Port    int
 	CertDir string
+	// DenyClusterSetDeletionInUse denies the deletion of the ManagedClusterSets which still have member clusters or
+	// bindings. Otherwise the deletion is allowed, the bindings of the ManagedClusterSets are reported by the hub in
+	// their conditions as a warning.
+	DenyClusterSetDeletionInUse bool
+	// ClusterMetadataAllowedPrefixes are the prefixes of the labels and annotations the agents are allowed to set or
+	// change on their ManagedClusters, the users allowed to accept the clusters are not restricted
+	ClusterMetadataAllowedPrefixes []string
+	// ClusterNamePatterns are the regular expressions the names of the new ManagedClusters must match entirely,
+	// any name in the format of the namespace names is allowed if it is empty
+	ClusterNamePatterns []string
+	// ClusterNameMaxLength is the max length of the names of the new ManagedClusters, 0 means no limit beyond the
+	// format of the namespace names
+	ClusterNameMaxLength int
+	// ClusterNameReservedPrefixes are the prefixes the names of the new ManagedClusters must not start with
+	ClusterNameReservedPrefixes []string
+	// RequireAcceptanceReview requires the ManagedClusters to be approved with the acceptance review annotation
+	// before they are accepted, unless they are accepted by the reviewers
+	RequireAcceptanceReview bool
+	// ClusterCSRAllowedDNSNames and ClusterCSRAllowedIPRanges are the subject alternative names allowed in the serving
+	// certificate signing requests of the agents, they are expected to be the same as the allow list of the hub
+	ClusterCSRAllowedDNSNames []string
+	ClusterCSRAllowedIPRanges []string
 }
 
 // NewOptions constructs a new set of default options for webhook.
@@ -20,4 +46,30 @@ func (c *Options) AddFlags(fs *pflag.FlagSet) {
 		"Port is the port that the webhook server serves at.")
 	fs.StringVar(&c.CertDir, "certdir", c.CertDir,
 		"CertDir is the directory that contains the server key and certificate. If not set, webhook server would look up the server key and certificate in {TempDir}/k8s-webhook-server/serving-certs")
+	fs.BoolVar(&c.DenyClusterSetDeletionInUse, "deny-clusterset-deletion-in-use", c.DenyClusterSetDeletionInUse,
+		"Deny the deletion of the ManagedClusterSets which still have member clusters or bindings, to prevent the "+
+			"placements of the bound namespaces from losing their clusters by accident.")
+	fs.StringSliceVar(&c.ClusterMetadataAllowedPrefixes, "cluster-metadata-allowed-prefixes", c.ClusterMetadataAllowedPrefixes,
+		"The prefixes of the labels and annotations the agents are allowed to set or change on their ManagedClusters, "+
+			"e.g. example.com/. The users allowed to accept the ManagedClusters are not restricted. If it is not set, "+
+			"the labels and annotations are not restricted.")
+	fs.StringSliceVar(&c.ClusterNamePatterns, "cluster-name-patterns", c.ClusterNamePatterns,
+		"The regular expressions the names of the new ManagedClusters must match entirely, e.g. prod-[a-z0-9-]+. "+
+			"If it is not set, any name in the format of the namespace names is allowed.")
+	fs.IntVar(&c.ClusterNameMaxLength, "cluster-name-max-length", c.ClusterNameMaxLength,
+		"The max length of the names of the new ManagedClusters, up to 63. If it is 0, the length is only limited "+
+			"by the format of the namespace names.")
+	fs.StringSliceVar(&c.ClusterNameReservedPrefixes, "cluster-name-reserved-prefixes", c.ClusterNameReservedPrefixes,
+		"The prefixes the names of the new ManagedClusters must not start with, e.g. local-cluster.")
+	fs.BoolVar(&c.RequireAcceptanceReview, "require-acceptance-review", c.RequireAcceptanceReview,
+		"Require the ManagedClusters to be approved with the annotation "+acceptancereview.AcceptanceReviewAnnotation+
+			"=approved before they are accepted. The users with the approve verb on the managedclusters/accept subresource "+
+			"of the register.open-cluster-management.io group are allowed to accept the ManagedClusters directly. It is "+
+			"expected to be enabled with the --cluster-acceptance-review of the hub.")
+	fs.StringSliceVar(&c.ClusterCSRAllowedDNSNames, "cluster-csr-allowed-dns-names", c.ClusterCSRAllowedDNSNames,
+		"A list of DNS names or name patterns (e.g. *.example.com) allowed in the subject alternative names of the serving "+
+			"certificate signing requests of the agents. It is expected to be the same as the flag of the hub.")
+	fs.StringSliceVar(&c.ClusterCSRAllowedIPRanges, "cluster-csr-allowed-ip-ranges", c.ClusterCSRAllowedIPRanges,
+		"A list of IPv4 or IPv6 ranges in CIDR notation allowed in the subject alternative names of the serving "+
+			"certificate signing requests of the agents. It is expected to be the same as the flag of the hub.")
 }
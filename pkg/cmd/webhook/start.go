@@ -9,6 +9,8 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/hub/csr"
+	internalcertificates "open-cluster-management.io/registration/pkg/webhook/certificates"
 	internalv1 "open-cluster-management.io/registration/pkg/webhook/v1"
 	internalv1beta1 "open-cluster-management.io/registration/pkg/webhook/v1beta1"
 	internalv1beta2 "open-cluster-management.io/registration/pkg/webhook/v1beta2"
@@ -57,10 +59,29 @@ func (c *Options) RunWebhookServer() error {
 		return err
 	}
 
-	if err = (&internalv1.ManagedClusterWebhook{}).Init(mgr); err != nil {
+	namePolicy, err := internalv1.NewClusterNamePolicy(
+		c.ClusterNamePatterns, c.ClusterNameMaxLength, c.ClusterNameReservedPrefixes)
+	if err != nil {
+		klog.Error(err, "invalid cluster name policy")
+		return err
+	}
+	if err = (&internalv1.ManagedClusterWebhook{
+		MetadataAllowedPrefixes: c.ClusterMetadataAllowedPrefixes,
+		NamePolicy:              namePolicy,
+		RequireAcceptanceReview: c.RequireAcceptanceReview,
+	}).Init(mgr); err != nil {
 		klog.Error(err, "unable to create ManagedCluster webhook")
 		return err
 	}
+	sanAllowList, err := csr.NewSANAllowList(c.ClusterCSRAllowedDNSNames, c.ClusterCSRAllowedIPRanges)
+	if err != nil {
+		klog.Error(err, "invalid subject alternative name allow list")
+		return err
+	}
+	if err = (&internalcertificates.CSRWebhook{SANAllowList: sanAllowList}).Init(mgr); err != nil {
+		klog.Error(err, "unable to create CertificateSigningRequest webhook")
+		return err
+	}
 	if err = (&internalv1beta1.ManagedClusterSetBindingWebhook{}).Init(mgr); err != nil {
 		klog.Error(err, "unable to create ManagedClusterSetBinding webhook", "v1beta1")
 		return err
@@ -73,7 +94,7 @@ func (c *Options) RunWebhookServer() error {
 		klog.Error(err, "unable to create ManagedClusterSet webhook", "v1beta1")
 		return err
 	}
-	if err = (&internalv1beta2.ManagedClusterSet{}).SetupWebhookWithManager(mgr); err != nil {
+	if err = (&internalv1beta2.ManagedClusterSetWebhook{DenyDeletionInUse: c.DenyClusterSetDeletionInUse}).Init(mgr); err != nil {
 		klog.Error(err, "unable to create ManagedClusterSet webhook", "v1beta2")
 		return err
 	}
@@ -7,6 +7,69 @@ import (
 	ocmfeature "open-cluster-management.io/api/feature"
 )
 
+const (
+	// Every feature gate which is not defined in the open-cluster-management.io/api should add
+	// method here following this template:
+	//
+	// // owner: @username
+	// // alpha: v1.X
+	// MyFeature featuregate.Feature = "MyFeature"
+
+	// ClusterTaint will make registration hub controller to taint the managed clusters with the
+	// "cluster.open-cluster-management.io/unavailable" and "cluster.open-cluster-management.io/unreachable"
+	// taints according to their available condition, and remove the taints once the clusters recover.
+	ClusterTaint featuregate.Feature = "ClusterTaint"
+
+	// AgentlessRegistration will make registration hub controller to serve the agent-less registration endpoint,
+	// so the edge devices which do not run Kubernetes can register to the hub and report their heartbeats.
+	AgentlessRegistration featuregate.Feature = "AgentlessRegistration"
+
+	// MQTTTransport allows the registration agent to publish the heartbeats and status of the managed cluster to
+	// an MQTT broker, and the registration hub controller to consume them from the broker, instead of using the
+	// hub kube-apiserver.
+	MQTTTransport featuregate.Feature = "MQTTTransport"
+
+	// GRPCRegistrationDriver allows the registration agent to request its client certificates from a registration
+	// service of the hub over gRPC instead of the certificate signing requests of the hub kube-apiserver.
+	GRPCRegistrationDriver featuregate.Feature = "GRPCRegistrationDriver"
+
+	// AWSIAMRegistrationDriver allows the registration agent to authenticate to an EKS hub with the AWS IAM role of
+	// its service account instead of a client certificate, and the registration hub controller to map the roles of
+	// the managed clusters to their identities in the aws-auth configmap of the hub.
+	AWSIAMRegistrationDriver featuregate.Feature = "AWSIAMRegistrationDriver"
+
+	// OIDCRegistrationDriver allows the registration agent to authenticate to the hub with a service account token
+	// projected with the audience of the hub instead of a client certificate, and the registration hub controller to
+	// bind the users of the tokens with the roles of the managed clusters.
+	OIDCRegistrationDriver featuregate.Feature = "OIDCRegistrationDriver"
+
+	// AddOnInstallLabels will make registration hub controller to label the managed clusters selected by the
+	// placements in the install strategy of the ClusterManagementAddOns with addon.open-cluster-management.io/<addon
+	// name>=enabled. It requires the ClusterManagementAddOn and PlacementDecision APIs on the hub.
+	AddOnInstallLabels featuregate.Feature = "AddOnInstallLabels"
+)
+
+// DefaultHubRegistrationFeatureGates consists of the feature keys for registration hub controller
+// which are not defined in the open-cluster-management.io/api. To add a new feature, define a key
+// for it above and add it here.
+var DefaultHubRegistrationFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	ClusterTaint:             {Default: true, PreRelease: featuregate.Beta},
+	AgentlessRegistration:    {Default: false, PreRelease: featuregate.Alpha},
+	MQTTTransport:            {Default: false, PreRelease: featuregate.Alpha},
+	AddOnInstallLabels:       {Default: false, PreRelease: featuregate.Alpha},
+	AWSIAMRegistrationDriver: {Default: false, PreRelease: featuregate.Alpha},
+	OIDCRegistrationDriver:   {Default: false, PreRelease: featuregate.Alpha},
+}
+
+// DefaultSpokeRegistrationFeatureGates consists of the feature keys for registration agent which are not
+// defined in the open-cluster-management.io/api. To add a new feature, define a key for it above and add it here.
+var DefaultSpokeRegistrationFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	MQTTTransport:            {Default: false, PreRelease: featuregate.Alpha},
+	GRPCRegistrationDriver:   {Default: false, PreRelease: featuregate.Alpha},
+	AWSIAMRegistrationDriver: {Default: false, PreRelease: featuregate.Alpha},
+	OIDCRegistrationDriver:   {Default: false, PreRelease: featuregate.Alpha},
+}
+
 var (
 	// DefaultSpokeMutableFeatureGate is made up of multiple mutable feature-gates for registration agent.
 	DefaultSpokeMutableFeatureGate featuregate.MutableFeatureGate = featuregate.NewFeatureGate()
@@ -17,6 +80,8 @@ var (
 
 func init() {
 	runtime.Must(DefaultSpokeMutableFeatureGate.Add(ocmfeature.DefaultSpokeRegistrationFeatureGates))
+	runtime.Must(DefaultSpokeMutableFeatureGate.Add(DefaultSpokeRegistrationFeatureGates))
 	runtime.Must(DefaultHubMutableFeatureGate.Add(ocmfeature.DefaultHubRegistrationFeatureGates))
+	runtime.Must(DefaultHubMutableFeatureGate.Add(DefaultHubRegistrationFeatureGates))
 	runtime.Must(utilfeature.DefaultMutableFeatureGate.Add(ocmfeature.DefaultHubRegistrationFeatureGates))
 }
@@ -0,0 +1,114 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/fileobserver"
+	"k8s.io/component-base/featuregate"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// featureGatesFileObserveInterval is the interval to check the changes of the feature gates file
+var featureGatesFileObserveInterval = 10 * time.Second
+
+// LoadFeatureGatesFile sets the feature gates in the file to the feature gate. The file is usually mounted from a
+// ConfigMap and holds a map of the feature names to the bool values in yaml or json, e.g. "ClusterClaim: false".
+// The feature gates in the file take precedence over the ones set with the --feature-gates flag. It is called before
+// the leader election, so the standby replicas run with the same feature gates as the leader.
+func LoadFeatureGatesFile(featureGate featuregate.MutableFeatureGate, file string) error {
+	if file == "" {
+		return nil
+	}
+
+	gates, err := readFeatureGatesFile(file)
+	if err != nil {
+		return err
+	}
+	return featureGate.SetFromMap(gates)
+}
+
+// WatchFeatureGatesFile observes the feature gates file loaded by LoadFeatureGatesFile. The feature gates are not
+// reloaded in-process: most of the controllers guarded by the feature gates are only started with the process, so
+// once any feature gate in the file is toggled, the returned context is cancelled, the process exits and is restarted
+// by its deployment with the toggled feature gates. The invalid changes of the file are ignored with a warning.
+//
+// The file is loaded again before it is observed, so the changes while the replica waits for the leader election
+// are picked up without a restart.
+func WatchFeatureGatesFile(ctx context.Context, featureGate featuregate.MutableFeatureGate, file string) (context.Context, error) {
+	if file == "" {
+		return ctx, nil
+	}
+	if err := LoadFeatureGatesFile(featureGate, file); err != nil {
+		return nil, err
+	}
+
+	observer, err := fileobserver.NewObserver(featureGatesFileObserveInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	startingContent, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	watchCtx, terminate := context.WithCancel(ctx)
+	observer.AddReactor(func(filename string, action fileobserver.ActionType) error {
+		toggled, err := toggledFeatureGates(featureGate, filename)
+		if err != nil {
+			klog.Warningf("Unable to read the changed feature gates file %q: %v", filename, err)
+			return nil
+		}
+		if len(toggled) == 0 {
+			return nil
+		}
+		klog.Infof("Exiting to restart with the feature gates %v toggled in file %q", toggled, filename)
+		terminate()
+		return nil
+	}, map[string][]byte{file: startingContent}, file)
+
+	go observer.Run(watchCtx.Done())
+	return watchCtx, nil
+}
+
+// toggledFeatureGates returns the feature gates in the file which are different from the feature gate
+func toggledFeatureGates(featureGate featuregate.MutableFeatureGate, file string) ([]string, error) {
+	gates, err := readFeatureGatesFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	known := featureGate.GetAll()
+	toggled := []string{}
+	for name, enabled := range gates {
+		if _, ok := known[featuregate.Feature(name)]; !ok {
+			return nil, fmt.Errorf("unrecognized feature gate: %s", name)
+		}
+		if featureGate.Enabled(featuregate.Feature(name)) != enabled {
+			toggled = append(toggled, fmt.Sprintf("%s=%t", name, enabled))
+		}
+	}
+	if len(toggled) == 0 {
+		return nil, nil
+	}
+	sort.Strings(toggled)
+	return toggled, nil
+}
+
+func readFeatureGatesFile(file string) (map[string]bool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	gates := map[string]bool{}
+	if err := yaml.Unmarshal(data, &gates); err != nil {
+		return nil, fmt.Errorf("unable to parse the feature gates file %q: %v", file, err)
+	}
+	return gates, nil
+}
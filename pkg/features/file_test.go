@@ -0,0 +1,128 @@
+package features
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"k8s.io/component-base/featuregate"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+const (
+	featureA featuregate.Feature = "FeatureA"
+	featureB featuregate.Feature = "FeatureB"
+)
+
+func TestLoadFeatureGatesFile(t *testing.T) {
+	cases := []struct {
+		name        string
+		content     string
+		expectedA   bool
+		expectedB   bool
+		expectedErr string
+	}{
+		{
+			name:      "empty file",
+			expectedB: true,
+		},
+		{
+			name:      "toggle feature gates",
+			content:   "FeatureA: true\nFeatureB: false\n",
+			expectedA: true,
+		},
+		{
+			name:        "unknown feature gate",
+			content:     "FeatureC: true\n",
+			expectedB:   true,
+			expectedErr: "unrecognized feature gate: FeatureC",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			featureGate := newFeatureGate(t)
+			file := writeFeatureGatesFile(t, c.content)
+
+			err := LoadFeatureGatesFile(featureGate, file)
+			testinghelpers.AssertError(t, err, c.expectedErr)
+
+			if featureGate.Enabled(featureA) != c.expectedA || featureGate.Enabled(featureB) != c.expectedB {
+				t.Errorf("expected %s=%t and %s=%t, but got %s", featureA, c.expectedA, featureB, c.expectedB, featureGate)
+			}
+		})
+	}
+}
+
+func TestToggledFeatureGates(t *testing.T) {
+	cases := []struct {
+		name            string
+		content         string
+		expectedToggled []string
+		expectedErr     string
+	}{
+		{
+			name:    "feature gates are not toggled",
+			content: "FeatureA: false\nFeatureB: true\n",
+		},
+		{
+			name:            "feature gates are toggled",
+			content:         "FeatureB: false\nFeatureA: true\n",
+			expectedToggled: []string{"FeatureA=true", "FeatureB=false"},
+		},
+		{
+			name:        "invalid file",
+			content:     "FeatureA: invalid\n",
+			expectedErr: "unable to parse the feature gates file",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			featureGate := newFeatureGate(t)
+			file := writeFeatureGatesFile(t, c.content)
+
+			toggled, err := toggledFeatureGates(featureGate, file)
+			if len(c.expectedErr) > 0 {
+				if err == nil {
+					t.Fatalf("expected error %q, but got nil", c.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(toggled, c.expectedToggled) {
+				t.Errorf("expected toggled %v, but got %v", c.expectedToggled, toggled)
+			}
+		})
+	}
+}
+
+func newFeatureGate(t *testing.T) featuregate.MutableFeatureGate {
+	featureGate := featuregate.NewFeatureGate()
+	if err := featureGate.Add(map[featuregate.Feature]featuregate.FeatureSpec{
+		featureA: {Default: false, PreRelease: featuregate.Alpha},
+		featureB: {Default: true, PreRelease: featuregate.Beta},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return featureGate
+}
+
+func writeFeatureGatesFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "feature-gates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	file := path.Join(dir, "feature-gates.yaml")
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
@@ -0,0 +1,3 @@
+// package health tracks the sync states of the controllers of the registration agent and serves them with the
+// healthz and readyz endpoints, so the probes and support tooling can pinpoint which controller is stuck.
+package health
@@ -0,0 +1,111 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// NewHandler returns a handler serving the states of the components in the registry.
+//   - /healthz fails if any component keeps failing for longer than the failure threshold, the component is
+//     considered stuck. The failures caused by the connectivity to the apiservers, e.g. the hub is unreachable, are
+//     only reported by /readyz, since restarting the agent does not fix them.
+//   - /readyz fails if any component failed in the last sync, or any readiness gate has not succeeded yet.
+//
+// Each component is reported in a line with its last successful sync time and the last error.
+func NewHandler(registry *Registry, failureThreshold time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		now := registry.now()
+		serveChecks(w, registry.States(), func(state ComponentState) bool {
+			if state.LastError == nil || isConnectivityError(state.LastError) {
+				return true
+			}
+			return now.Sub(state.FailingSince) <= failureThreshold
+		})
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, req *http.Request) {
+		serveChecks(w, registry.States(), func(state ComponentState) bool {
+			if state.ReadinessGate && state.LastSuccessTime.IsZero() {
+				return false
+			}
+			return state.LastError == nil
+		})
+	})
+	return mux
+}
+
+func serveChecks(w http.ResponseWriter, states []ComponentState, check func(state ComponentState) bool) {
+	output := &bytes.Buffer{}
+	failed := false
+	for _, state := range states {
+		if check(state) {
+			fmt.Fprintf(output, "[+]%s ok: %s\n", state.Name, describe(state))
+			continue
+		}
+		failed = true
+		fmt.Fprintf(output, "[-]%s failed: %s\n", state.Name, describe(state))
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if failed {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(output, "check failed\n")
+	} else {
+		fmt.Fprint(output, "check passed\n")
+	}
+	_, _ = output.WriteTo(w)
+}
+
+func describe(state ComponentState) string {
+	if state.LastSyncTime.IsZero() {
+		return "not synced yet"
+	}
+	lastSuccess := "never"
+	if !state.LastSuccessTime.IsZero() {
+		lastSuccess = state.LastSuccessTime.UTC().Format(time.RFC3339)
+	}
+	if state.LastError == nil {
+		return fmt.Sprintf("last successful sync at %s", lastSuccess)
+	}
+	return fmt.Sprintf("failing since %s, last successful sync at %s: %v",
+		state.FailingSince.UTC().Format(time.RFC3339), lastSuccess, state.LastError)
+}
+
+// isConnectivityError returns true if the error is caused by the connectivity to an apiserver, e.g. the connection
+// is refused or times out, or the apiserver is unavailable. An aggregated error is a connectivity error only if all
+// of its errors are.
+func isConnectivityError(err error) bool {
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) && len(agg.Errors()) > 0 {
+		for _, e := range agg.Errors() {
+			if !isConnectivityError(e) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr):
+		return true
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	case utilnet.IsConnectionRefused(err), utilnet.IsConnectionReset(err), utilnet.IsProbableEOF(err):
+		return true
+	case apierrors.IsServerTimeout(err), apierrors.IsTimeout(err), apierrors.IsServiceUnavailable(err),
+		apierrors.IsTooManyRequests(err):
+		return true
+	}
+	return false
+}
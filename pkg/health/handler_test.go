@@ -0,0 +1,165 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestHandler(t *testing.T) {
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	hubUnreachable := &url.Error{Op: "Put", URL: "https://hub:6443", Err: syscall.ECONNREFUSED}
+
+	cases := []struct {
+		name                string
+		record              func(r *Registry)
+		expectedHealthzCode int
+		expectedReadyzCode  int
+		expectedLines       []string
+	}{
+		{
+			name: "bootstrap is not completed",
+			record: func(r *Registry) {
+				r.AddReadinessGate("Bootstrap")
+			},
+			expectedHealthzCode: http.StatusOK,
+			expectedReadyzCode:  http.StatusServiceUnavailable,
+			expectedLines:       []string{"Bootstrap failed: not synced yet"},
+		},
+		{
+			name: "all succeeded",
+			record: func(r *Registry) {
+				r.AddReadinessGate("Bootstrap")
+				r.Record("Bootstrap", nil)
+				r.Record("ManagedClusterLeaseController", nil)
+			},
+			expectedHealthzCode: http.StatusOK,
+			expectedReadyzCode:  http.StatusOK,
+			expectedLines: []string{
+				"[+]Bootstrap ok: last successful sync at 2022-10-01T00:00:00Z",
+				"[+]ManagedClusterLeaseController ok: last successful sync at 2022-10-01T00:00:00Z",
+			},
+		},
+		{
+			name: "controller failed recently",
+			record: func(r *Registry) {
+				r.Record("ClusterClaimController", nil)
+				r.now = func() time.Time { return now.Add(time.Minute) }
+				r.Record("ClusterClaimController", errors.New("forbidden"))
+			},
+			expectedHealthzCode: http.StatusOK,
+			expectedReadyzCode:  http.StatusServiceUnavailable,
+			expectedLines: []string{
+				"[-]ClusterClaimController failed: failing since 2022-10-01T00:01:00Z, last successful sync at 2022-10-01T00:00:00Z: forbidden",
+			},
+		},
+		{
+			name: "controller is stuck",
+			record: func(r *Registry) {
+				r.Record("ClusterClaimController", errors.New("forbidden"))
+				r.now = func() time.Time { return now.Add(10 * time.Minute) }
+				r.Record("ClusterClaimController", errors.New("forbidden"))
+			},
+			expectedHealthzCode: http.StatusServiceUnavailable,
+			expectedReadyzCode:  http.StatusServiceUnavailable,
+			expectedLines: []string{
+				"[-]ClusterClaimController failed: failing since 2022-10-01T00:00:00Z, last successful sync at never: forbidden",
+			},
+		},
+		{
+			name: "hub is unreachable",
+			record: func(r *Registry) {
+				r.Record("ManagedClusterLeaseController", hubUnreachable)
+				r.now = func() time.Time { return now.Add(10 * time.Minute) }
+				r.Record("ManagedClusterLeaseController", fmt.Errorf("unable to update lease: %w", hubUnreachable))
+			},
+			expectedHealthzCode: http.StatusOK,
+			expectedReadyzCode:  http.StatusServiceUnavailable,
+			expectedLines: []string{
+				"[-]ManagedClusterLeaseController failed: failing since 2022-10-01T00:00:00Z, last successful sync at never",
+			},
+		},
+		{
+			name: "controller is stuck with aggregated errors",
+			record: func(r *Registry) {
+				r.Record("ClusterClaimController", errors.New("forbidden"))
+				r.now = func() time.Time { return now.Add(10 * time.Minute) }
+				r.Record("ClusterClaimController", utilerrors.NewAggregate([]error{hubUnreachable, errors.New("forbidden")}))
+			},
+			expectedHealthzCode: http.StatusServiceUnavailable,
+			expectedReadyzCode:  http.StatusServiceUnavailable,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			registry := NewRegistry()
+			registry.now = func() time.Time { return now }
+			c.record(registry)
+			handler := NewHandler(registry, 5*time.Minute)
+
+			healthz := httptest.NewRecorder()
+			handler.ServeHTTP(healthz, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if healthz.Code != c.expectedHealthzCode {
+				t.Errorf("expected healthz code %d, but got %d: %s", c.expectedHealthzCode, healthz.Code, healthz.Body.String())
+			}
+
+			readyz := httptest.NewRecorder()
+			handler.ServeHTTP(readyz, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if readyz.Code != c.expectedReadyzCode {
+				t.Errorf("expected readyz code %d, but got %d: %s", c.expectedReadyzCode, readyz.Code, readyz.Body.String())
+			}
+			for _, line := range c.expectedLines {
+				if !strings.Contains(readyz.Body.String(), line) {
+					t.Errorf("expected line %q, but got %s", line, readyz.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestTrackSync(t *testing.T) {
+	registry := NewRegistry()
+	var syncErr error
+	sync := registry.TrackSync("ManagedClusterLeaseController", func(ctx context.Context, syncCtx factory.SyncContext) error {
+		return syncErr
+	})
+
+	states := registry.States()
+	if len(states) != 1 || !states[0].LastSyncTime.IsZero() {
+		t.Errorf("expected the controller is registered without sync, but got %v", states)
+	}
+
+	syncCtx := testinghelpers.NewFakeSyncContext(t, "key")
+	syncErr = errors.New("timeout")
+	if err := sync(context.TODO(), syncCtx); err != syncErr {
+		t.Errorf("expected the sync error is returned, but got %v", err)
+	}
+	if state := registry.States()[0]; state.LastError != syncErr || state.FailingSince.IsZero() {
+		t.Errorf("expected the failed sync is recorded, but got %v", state)
+	}
+
+	syncErr = nil
+	if err := sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if state := registry.States()[0]; state.LastError != nil || !state.FailingSince.IsZero() || state.LastSuccessTime.IsZero() {
+		t.Errorf("expected the successful sync is recorded, but got %v", state)
+	}
+
+	registry.Remove("ManagedClusterLeaseController")
+	if states := registry.States(); len(states) != 0 {
+		t.Errorf("expected the controller is removed, but got %v", states)
+	}
+}
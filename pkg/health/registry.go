@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+// DefaultRegistry is the registry of the controllers of the registration agent
+var DefaultRegistry = NewRegistry()
+
+// ComponentState is the sync state of a controller or a stage of the agent, e.g. the bootstrap
+type ComponentState struct {
+	Name string
+	// ReadinessGate is true if the agent is not ready until the component succeeds
+	ReadinessGate bool
+	// LastSyncTime and LastSuccessTime are the times of the last sync and the last successful sync
+	LastSyncTime    time.Time
+	LastSuccessTime time.Time
+	// FailingSince is the time of the first failed sync after the last successful sync
+	FailingSince time.Time
+	// LastError is the error of the last sync, it is nil if the last sync succeeded
+	LastError error
+}
+
+// Registry records the sync states of the components
+type Registry struct {
+	lock       sync.RWMutex
+	components map[string]*ComponentState
+	now        func() time.Time
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		components: map[string]*ComponentState{},
+		now:        time.Now,
+	}
+}
+
+// AddReadinessGate registers a component, the agent is not ready until the component succeeds
+func (r *Registry) AddReadinessGate(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.component(name).ReadinessGate = true
+}
+
+// Remove removes a component which is stopped, e.g. the controllers which only run in the bootstrap
+func (r *Registry) Remove(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.components, name)
+}
+
+// Record records the result of a sync of the component
+func (r *Registry) Record(name string, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	state := r.component(name)
+	state.LastSyncTime = now
+	state.LastError = err
+	if err == nil {
+		state.LastSuccessTime = now
+		state.FailingSince = time.Time{}
+		return
+	}
+	if state.FailingSince.IsZero() {
+		state.FailingSince = now
+	}
+}
+
+// TrackSync returns a sync func which records the result of each sync of the controller with the registry, the
+// context of the sync func carries the logger of the controller
+func (r *Registry) TrackSync(name string, sync factory.SyncFunc) factory.SyncFunc {
+	sync = logging.SyncWithLogger(name, sync)
+
+	r.lock.Lock()
+	r.component(name)
+	r.lock.Unlock()
+
+	return func(ctx context.Context, syncCtx factory.SyncContext) error {
+		err := sync(ctx, syncCtx)
+		r.Record(name, err)
+		return err
+	}
+}
+
+// States returns the copies of the states of the components sorted by their names
+func (r *Registry) States() []ComponentState {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	states := []ComponentState{}
+	for _, state := range r.components {
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+	return states
+}
+
+func (r *Registry) component(name string) *ComponentState {
+	state, ok := r.components[name]
+	if !ok {
+		state = &ComponentState{Name: name}
+		r.components[name] = state
+	}
+	return state
+}
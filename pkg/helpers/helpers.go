@@ -2,17 +2,20 @@ package helpers
 
 import (
 	"context"
-	"embed"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"net/url"
+	"strconv"
+	"strings"
 
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	addonv1alpha1client "open-cluster-management.io/api/client/addon/clientset/versioned"
 	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"open-cluster-management.io/registration/pkg/logging"
 
-	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/openshift/api"
 	"github.com/openshift/library-go/pkg/assets"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -24,7 +27,6 @@ import (
 	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
-	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -35,10 +37,134 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
-	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
 )
 
+const (
+	// BootstrapHubKubeconfigSecretName is the name of the secret in the cluster namespace on the hub, which holds
+	// the latest bootstrap kubeconfig published by the hub for the agent of the managed cluster.
+	BootstrapHubKubeconfigSecretName = "bootstrap-hub-kubeconfig"
+
+	// HubCABundleConfigMapName is the name of the configmap in the cluster namespace on the hub, which holds the
+	// CA bundle of the hub apiserver in the HubCABundleKey, the agent refreshes its hub kubeconfig with it.
+	HubCABundleConfigMapName = "hub-ca-bundle"
+	HubCABundleKey           = "ca.crt"
+
+	// ClusterEndpointsSecretName is the name of the secret in the cluster namespace on the hub, which holds the api
+	// server urls of the managed cluster in the ClusterEndpointsURLsKey, one per line, and its CA bundle in the
+	// ClusterEndpointsCABundleKey. The secret is created by the hub and updated by the agent.
+	ClusterEndpointsSecretName  = "cluster-endpoints"
+	ClusterEndpointsURLsKey     = "urls"
+	ClusterEndpointsCABundleKey = "ca.crt"
+	// ClusterEndpointsTunnelKeyPrefix is the prefix of the keys of the secret holding the metadata of the tunnels the
+	// hub components reach the managed cluster through, e.g. the identifiers of the konnectivity agent.
+	ClusterEndpointsTunnelKeyPrefix = "tunnel."
+
+	// ClusterSpecFieldManager is the field manager of the spec fields of the managed cluster applied by the agent.
+	// The agent is the sole owner of the fields it applies, so the hub controllers leave them alone.
+	ClusterSpecFieldManager = "registration-agent"
+
+	// ManagedClusterConditionProbePrefix is the prefix of the condition types reported by the availability probes
+	// of the registration agent, the probe name follows the prefix.
+	ManagedClusterConditionProbePrefix = "probe.open-cluster-management.io/"
+
+	// NodeRoleResourcePrefix is the prefix of the resources broken down by the node roles in the capacity and
+	// allocatable of the managed cluster, e.g. node-role.open-cluster-management.io/worker.cpu
+	NodeRoleResourcePrefix = "node-role.open-cluster-management.io/"
+
+	// NodeArchResourcePrefix and NodeOSResourcePrefix are the prefixes of the resources broken down by the node
+	// architectures and OSes in the capacity and allocatable of the managed cluster, e.g.
+	// node-arch.open-cluster-management.io/arm64.cpu and node-os.open-cluster-management.io/windows.memory
+	NodeArchResourcePrefix = "node-arch.open-cluster-management.io/"
+	NodeOSResourcePrefix   = "node-os.open-cluster-management.io/"
+
+	// ManagedClusterConditionFlapping is the condition type set by the hub on the managed clusters whose available
+	// condition flaps, the clusters are tainted as unavailable while the condition is true.
+	ManagedClusterConditionFlapping = "Flapping"
+
+	// ManagedClusterConditionUpgradeRequired is the condition type set by the agent on its managed cluster, it is
+	// true once the version of the agent is behind the desired agent version beyond the upgrade policy of the agent.
+	ManagedClusterConditionUpgradeRequired = "UpgradeRequired"
+
+	// ManagedClusterConditionPendingCSR is the condition type set by the hub on the managed clusters, it is true
+	// while any registration csr of the cluster is awaiting the approval.
+	ManagedClusterConditionPendingCSR = "PendingCSR"
+
+	// ClusterSetBindingBinderAnnotation is the annotation set by the webhook on the ManagedClusterSetBindings with the
+	// username and groups of the users who create them, so the hub can verify the binders are still allowed to bind the
+	// ManagedClusterSets after the bindings are created.
+	ClusterSetBindingBinderAnnotation = "cluster.open-cluster-management.io/binder"
+
+	// ManagedClusterIAMRoleARNAnnotation is the annotation set by the hub admins on a managed cluster with the ARN of
+	// the AWS IAM role its agent authenticates to the hub with, when it is registered with the awsirsa registration
+	// driver. It is an identity annotation, see ManagedClusterIdentityAnnotations.
+	ManagedClusterIAMRoleARNAnnotation = "agent.open-cluster-management.io/iam-role-arn"
+
+	// ManagedClusterOIDCIssuerAnnotation and ManagedClusterOIDCSubjectAnnotation are the annotations set by the hub
+	// admins on a managed cluster with the iss and sub claims of the token its agent authenticates to the hub with,
+	// when it is registered with the oidc registration driver. They are identity annotations, see
+	// ManagedClusterIdentityAnnotations.
+	ManagedClusterOIDCIssuerAnnotation  = "agent.open-cluster-management.io/oidc-issuer"
+	ManagedClusterOIDCSubjectAnnotation = "agent.open-cluster-management.io/oidc-subject"
+
+	// ManagedClusterAgentVersionAnnotation is the annotation set by the agent on its managed cluster with the version
+	// of the agent, and ManagedClusterDesiredAgentVersionAnnotation is the annotation set on the hub with the version
+	// the agent of the managed cluster is expected to run, e.g. by the tools rolling out the agent upgrades.
+	ManagedClusterAgentVersionAnnotation        = "agent.open-cluster-management.io/version"
+	ManagedClusterDesiredAgentVersionAnnotation = "cluster.open-cluster-management.io/desired-agent-version"
+
+	// ManagedClusterClientCertExpirationAnnotation is the annotation set by the agent on its managed cluster with the
+	// expiration time of its client certificate in RFC3339 format, the hub warns about the clusters whose client
+	// certificates are about to expire with it.
+	ManagedClusterClientCertExpirationAnnotation = "agent.open-cluster-management.io/client-cert-expiration"
+
+	// ManagedClusterMaintenanceWindowAnnotation is the annotation set by the operators on the managed clusters to
+	// schedule their maintenance, the hub taints the clusters with the maintenance taint during the window. The value
+	// is either an RFC3339 interval, e.g. 2023-01-02T01:00:00Z/2023-01-02T03:00:00Z, or a cron schedule with the
+	// duration of the windows, e.g. "0 1 * * SAT;2h".
+	ManagedClusterMaintenanceWindowAnnotation = "cluster.open-cluster-management.io/maintenance-window"
+
+	// ClusterServingSignerName is the signer of the serving certificates of the agents, which carry the subject
+	// alternative names the hub calls back to the managed clusters with. The certificates are signed by the hub with
+	// the CA configured for the signer, and only the names in the allow list of the hub are approved.
+	ClusterServingSignerName = "open-cluster-management.io/cluster-serving"
+
+	// AddOnLeaseDurationSecondsAnnotation is the annotation for indicating the lease duration seconds of the addon,
+	// the addon which updates its lease slowly by design can set it to extend its grace period.
+	AddOnLeaseDurationSecondsAnnotation = "addon.open-cluster-management.io/lease-duration-seconds"
+
+	// AddOnSignerCAHashAnnotation is the annotation set by the hub on the addons with the hash of the CAs of the
+	// custom signers of their registrations once their client certificates are issued. The agent revokes the client
+	// certificates of the addon and requests them again once it is changed.
+	AddOnSignerCAHashAnnotation = "addon.open-cluster-management.io/signer-ca-hash"
+
+	// AddOnFeatureLabelPrefix is the prefix of the addon feature labels which are maintained by the addon feature
+	// discovery controller on hub to reflect the status of the addons, e.g.
+	// feature.open-cluster-management.io/addon-<addon name>: available
+	AddOnFeatureLabelPrefix = "feature.open-cluster-management.io/addon-"
+	// AddOnStatusAvailable is the status of an available addon in its feature label value
+	AddOnStatusAvailable = "available"
+)
+
+// ManagedClusterAgentAnnotations are the annotations the agents report on their managed clusters, the webhook allows
+// the agents to set them besides the annotations with the allowed prefixes.
+var ManagedClusterAgentAnnotations = []string{
+	ManagedClusterAgentVersionAnnotation,
+	ManagedClusterClientCertExpirationAnnotation,
+}
+
+// ManagedClusterIdentityAnnotations are the annotations the hub derives the identity of the agent of a managed
+// cluster from, e.g. to bind the roles of the cluster to the user of its token. They are trusted because the webhook
+// only allows the users who can accept the cluster to set them, the agents cannot set them for themselves.
+var ManagedClusterIdentityAnnotations = []string{
+	ManagedClusterIAMRoleARNAnnotation,
+	ManagedClusterOIDCIssuerAnnotation,
+	ManagedClusterOIDCSubjectAnnotation,
+}
+
 var (
 	genericScheme = runtime.NewScheme()
 	genericCodecs = serializer.NewCodecFactory(genericScheme)
@@ -49,67 +175,38 @@ func init() {
 	utilruntime.Must(api.InstallKube(genericScheme))
 }
 
-type UpdateManagedClusterStatusFunc func(status *clusterv1.ManagedClusterStatus) error
+type UpdateManagedClusterStatusFunc = UpdateStatusFunc[clusterv1.ManagedClusterStatus]
 
+// UpdateManagedClusterStatus updates the status of the managed cluster with the update funcs, it is retried on
+// conflict with the latest managed cluster.
 func UpdateManagedClusterStatus(
 	ctx context.Context,
 	client clusterclientset.Interface,
 	spokeClusterName string,
 	updateFuncs ...UpdateManagedClusterStatusFunc) (*clusterv1.ManagedClusterStatus, bool, error) {
-	updated := false
-	var updatedManagedClusterStatus *clusterv1.ManagedClusterStatus
-
-	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
-		managedCluster, err := client.ClusterV1().ManagedClusters().Get(ctx, spokeClusterName, metav1.GetOptions{})
-		if err != nil {
-			return err
-		}
-		oldStatus := &managedCluster.Status
-
-		newStatus := oldStatus.DeepCopy()
-		for _, update := range updateFuncs {
-			if err := update(newStatus); err != nil {
-				return err
-			}
-		}
-		if equality.Semantic.DeepEqual(oldStatus, newStatus) {
-			// We return the newStatus which is a deep copy of oldStatus but with all update funcs applied.
-			updatedManagedClusterStatus = newStatus
-			return nil
-		}
-
-		oldData, err := json.Marshal(clusterv1.ManagedCluster{
-			Status: *oldStatus,
-		})
-
-		if err != nil {
-			return fmt.Errorf("failed to Marshal old data for cluster status %s: %w", managedCluster.Name, err)
-		}
-
-		newData, err := json.Marshal(clusterv1.ManagedCluster{
-			ObjectMeta: metav1.ObjectMeta{
-				UID:             managedCluster.UID,
-				ResourceVersion: managedCluster.ResourceVersion,
-			}, // to ensure they appear in the patch as preconditions
-			Status: *newStatus,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to Marshal new data for cluster status %s: %w", managedCluster.Name, err)
-		}
-
-		patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
-		if err != nil {
-			return fmt.Errorf("failed to create patch for cluster %s: %w", managedCluster.Name, err)
-		}
-
-		updatedManagedCluster, err := client.ClusterV1().ManagedClusters().Patch(ctx, managedCluster.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
-
-		updatedManagedClusterStatus = &updatedManagedCluster.Status
-		updated = err == nil
-		return err
-	})
-
-	return updatedManagedClusterStatus, updated, err
+	updater := statusUpdater[*clusterv1.ManagedCluster, clusterv1.ManagedClusterStatus]{
+		kind: "cluster",
+		get: func(ctx context.Context) (*clusterv1.ManagedCluster, error) {
+			return client.ClusterV1().ManagedClusters().Get(ctx, spokeClusterName, metav1.GetOptions{})
+		},
+		patch: func(ctx context.Context, patchBytes []byte) (*clusterv1.ManagedCluster, error) {
+			return client.ClusterV1().ManagedClusters().Patch(
+				ctx, spokeClusterName, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+		},
+		status: func(cluster *clusterv1.ManagedCluster) *clusterv1.ManagedClusterStatus {
+			return &cluster.Status
+		},
+		deepCopy: func(status *clusterv1.ManagedClusterStatus) *clusterv1.ManagedClusterStatus {
+			return status.DeepCopy()
+		},
+		conditions: func(status *clusterv1.ManagedClusterStatus) *[]metav1.Condition {
+			return &status.Conditions
+		},
+		object: func(objectMeta metav1.ObjectMeta, status clusterv1.ManagedClusterStatus) interface{} {
+			return clusterv1.ManagedCluster{ObjectMeta: objectMeta, Status: status}
+		},
+	}
+	return updater.update(ctx, updateFuncs...)
 }
 
 func UpdateManagedClusterConditionFn(cond metav1.Condition) UpdateManagedClusterStatusFunc {
@@ -119,76 +216,256 @@ func UpdateManagedClusterConditionFn(cond metav1.Condition) UpdateManagedCluster
 	}
 }
 
-type UpdateManagedClusterAddOnStatusFunc func(status *addonv1alpha1.ManagedClusterAddOnStatus) error
+// UpdateManagedClusterResourcesFn sets the capacity, allocatable and version of the managed cluster. The capacity
+// and allocatable are replaced as a whole, so the resources which are no longer aggregated, e.g. the resources of
+// the excluded or removed nodes, are removed from the status.
+func UpdateManagedClusterResourcesFn(capacity, allocatable clusterv1.ResourceList,
+	version clusterv1.ManagedClusterVersion) UpdateManagedClusterStatusFunc {
+	return func(oldStatus *clusterv1.ManagedClusterStatus) error {
+		oldStatus.Capacity = capacity
+		oldStatus.Allocatable = allocatable
+		oldStatus.Version = version
+		return nil
+	}
+}
+
+type UpdateManagedClusterSetStatusFunc = UpdateStatusFunc[clusterv1beta2.ManagedClusterSetStatus]
 
+// UpdateManagedClusterSetStatus updates the status of the managed cluster set with the update funcs, it is retried
+// on conflict with the latest managed cluster set.
+func UpdateManagedClusterSetStatus(
+	ctx context.Context,
+	client clusterclientset.Interface,
+	clusterSetName string,
+	updateFuncs ...UpdateManagedClusterSetStatusFunc) (*clusterv1beta2.ManagedClusterSetStatus, bool, error) {
+	updater := statusUpdater[*clusterv1beta2.ManagedClusterSet, clusterv1beta2.ManagedClusterSetStatus]{
+		kind: "clusterset",
+		get: func(ctx context.Context) (*clusterv1beta2.ManagedClusterSet, error) {
+			return client.ClusterV1beta2().ManagedClusterSets().Get(ctx, clusterSetName, metav1.GetOptions{})
+		},
+		patch: func(ctx context.Context, patchBytes []byte) (*clusterv1beta2.ManagedClusterSet, error) {
+			return client.ClusterV1beta2().ManagedClusterSets().Patch(
+				ctx, clusterSetName, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+		},
+		status: func(clusterSet *clusterv1beta2.ManagedClusterSet) *clusterv1beta2.ManagedClusterSetStatus {
+			return &clusterSet.Status
+		},
+		deepCopy: func(status *clusterv1beta2.ManagedClusterSetStatus) *clusterv1beta2.ManagedClusterSetStatus {
+			return status.DeepCopy()
+		},
+		conditions: func(status *clusterv1beta2.ManagedClusterSetStatus) *[]metav1.Condition {
+			return &status.Conditions
+		},
+		object: func(objectMeta metav1.ObjectMeta, status clusterv1beta2.ManagedClusterSetStatus) interface{} {
+			return clusterv1beta2.ManagedClusterSet{ObjectMeta: objectMeta, Status: status}
+		},
+	}
+	return updater.update(ctx, updateFuncs...)
+}
+
+type UpdateManagedClusterSetBindingStatusFunc = UpdateStatusFunc[clusterv1beta2.ManagedClusterSetBindingStatus]
+
+// UpdateManagedClusterSetBindingStatus updates the status of the managed cluster set binding with the update funcs,
+// it is retried on conflict with the latest managed cluster set binding.
+func UpdateManagedClusterSetBindingStatus(
+	ctx context.Context,
+	client clusterclientset.Interface,
+	bindingNamespace, bindingName string,
+	updateFuncs ...UpdateManagedClusterSetBindingStatusFunc) (*clusterv1beta2.ManagedClusterSetBindingStatus, bool, error) {
+	updater := statusUpdater[*clusterv1beta2.ManagedClusterSetBinding, clusterv1beta2.ManagedClusterSetBindingStatus]{
+		kind: "clustersetbinding",
+		get: func(ctx context.Context) (*clusterv1beta2.ManagedClusterSetBinding, error) {
+			return client.ClusterV1beta2().ManagedClusterSetBindings(bindingNamespace).Get(ctx, bindingName, metav1.GetOptions{})
+		},
+		patch: func(ctx context.Context, patchBytes []byte) (*clusterv1beta2.ManagedClusterSetBinding, error) {
+			return client.ClusterV1beta2().ManagedClusterSetBindings(bindingNamespace).Patch(
+				ctx, bindingName, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+		},
+		status: func(binding *clusterv1beta2.ManagedClusterSetBinding) *clusterv1beta2.ManagedClusterSetBindingStatus {
+			return &binding.Status
+		},
+		deepCopy: func(status *clusterv1beta2.ManagedClusterSetBindingStatus) *clusterv1beta2.ManagedClusterSetBindingStatus {
+			return status.DeepCopy()
+		},
+		conditions: func(status *clusterv1beta2.ManagedClusterSetBindingStatus) *[]metav1.Condition {
+			return &status.Conditions
+		},
+		object: func(objectMeta metav1.ObjectMeta, status clusterv1beta2.ManagedClusterSetBindingStatus) interface{} {
+			return clusterv1beta2.ManagedClusterSetBinding{ObjectMeta: objectMeta, Status: status}
+		},
+	}
+	return updater.update(ctx, updateFuncs...)
+}
+
+type UpdateManagedClusterAddOnStatusFunc = UpdateStatusFunc[addonv1alpha1.ManagedClusterAddOnStatus]
+
+// UpdateManagedClusterAddOnStatus updates the status of the managed cluster addon with the update funcs, it is
+// retried on conflict with the latest managed cluster addon.
 func UpdateManagedClusterAddOnStatus(
 	ctx context.Context,
 	client addonv1alpha1client.Interface,
 	addOnNamespace, addOnName string,
 	updateFuncs ...UpdateManagedClusterAddOnStatusFunc) (*addonv1alpha1.ManagedClusterAddOnStatus, bool, error) {
-	updated := false
-	var updatedAddOnStatus *addonv1alpha1.ManagedClusterAddOnStatus
+	updater := statusUpdater[*addonv1alpha1.ManagedClusterAddOn, addonv1alpha1.ManagedClusterAddOnStatus]{
+		kind: "addon",
+		get: func(ctx context.Context) (*addonv1alpha1.ManagedClusterAddOn, error) {
+			return client.AddonV1alpha1().ManagedClusterAddOns(addOnNamespace).Get(ctx, addOnName, metav1.GetOptions{})
+		},
+		patch: func(ctx context.Context, patchBytes []byte) (*addonv1alpha1.ManagedClusterAddOn, error) {
+			return client.AddonV1alpha1().ManagedClusterAddOns(addOnNamespace).Patch(
+				ctx, addOnName, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+		},
+		status: func(addOn *addonv1alpha1.ManagedClusterAddOn) *addonv1alpha1.ManagedClusterAddOnStatus {
+			return &addOn.Status
+		},
+		deepCopy: func(status *addonv1alpha1.ManagedClusterAddOnStatus) *addonv1alpha1.ManagedClusterAddOnStatus {
+			return status.DeepCopy()
+		},
+		conditions: func(status *addonv1alpha1.ManagedClusterAddOnStatus) *[]metav1.Condition {
+			return &status.Conditions
+		},
+		object: func(objectMeta metav1.ObjectMeta, status addonv1alpha1.ManagedClusterAddOnStatus) interface{} {
+			return addonv1alpha1.ManagedClusterAddOn{ObjectMeta: objectMeta, Status: status}
+		},
+	}
+	return updater.update(ctx, updateFuncs...)
+}
 
-	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
-		addOn, err := client.AddonV1alpha1().ManagedClusterAddOns(addOnNamespace).Get(ctx, addOnName, metav1.GetOptions{})
-		if err != nil {
-			return err
-		}
-		oldStatus := &addOn.Status
+func UpdateManagedClusterAddOnStatusFn(cond metav1.Condition) UpdateManagedClusterAddOnStatusFunc {
+	return func(oldStatus *addonv1alpha1.ManagedClusterAddOnStatus) error {
+		meta.SetStatusCondition(&oldStatus.Conditions, cond)
+		return nil
+	}
+}
 
-		newStatus := oldStatus.DeepCopy()
-		for _, update := range updateFuncs {
-			if err := update(newStatus); err != nil {
-				return err
-			}
-		}
-		if equality.Semantic.DeepEqual(oldStatus, newStatus) {
-			// We return the newStatus which is a deep copy of oldStatus but with all update funcs applied.
-			updatedAddOnStatus = newStatus
-			return nil
+// ApplyManagedClusterLabels applies the labels of the managed cluster with server-side apply as the field manager.
+// The labels are the whole set owned by the field manager, so the labels it applied before are removed once they
+// are not in the set, while the labels owned by the other managers are never touched. The stale keys are removed
+// with a merge patch if they are still on the cluster after the apply, e.g. they were set with an update before
+// the field manager owns them.
+func ApplyManagedClusterLabels(
+	ctx context.Context,
+	client clusterclientset.Interface,
+	cluster *clusterv1.ManagedCluster,
+	fieldManager string,
+	labels map[string]string,
+	staleKeys ...string) (bool, error) {
+	modified := false
+	for key, value := range labels {
+		if current, ok := cluster.Labels[key]; !ok || current != value {
+			modified = true
 		}
-
-		oldData, err := json.Marshal(addonv1alpha1.ManagedClusterAddOn{
-			Status: *oldStatus,
-		})
-
-		if err != nil {
-			return fmt.Errorf("failed to Marshal old data for addon status %s: %w", addOn.Name, err)
+	}
+	for _, key := range staleKeys {
+		if _, ok := cluster.Labels[key]; ok {
+			modified = true
 		}
+	}
+	if !modified {
+		return false, nil
+	}
 
-		newData, err := json.Marshal(addonv1alpha1.ManagedClusterAddOn{
-			ObjectMeta: metav1.ObjectMeta{
-				UID:             addOn.UID,
-				ResourceVersion: addOn.ResourceVersion,
-			}, // to ensure they appear in the patch as preconditions
-			Status: *newStatus,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to Marshal new data for addon status %s: %w", addOn.Name, err)
-		}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"apiVersion": clusterv1.GroupVersion.String(),
+		"kind":       "ManagedCluster",
+		"metadata": map[string]interface{}{
+			"name":   cluster.Name,
+			"labels": labels,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	applied, err := client.ClusterV1().ManagedClusters().Patch(ctx, cluster.Name, types.ApplyPatchType, patch,
+		metav1.PatchOptions{FieldManager: fieldManager, Force: pointer.Bool(true)})
+	if err != nil {
+		return false, fmt.Errorf("unable to apply the labels of managed cluster %q: %w", cluster.Name, err)
+	}
 
-		patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
-		if err != nil {
-			return fmt.Errorf("failed to create patch for cluster %s: %w", addOn.Name, err)
+	// a nil value removes the label
+	removed := map[string]interface{}{}
+	for _, key := range staleKeys {
+		if _, ok := applied.Labels[key]; ok {
+			removed[key] = nil
 		}
+	}
+	if len(removed) == 0 {
+		return true, nil
+	}
+	patch, err = json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": removed,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	_, err = client.ClusterV1().ManagedClusters().Patch(ctx, cluster.Name, types.MergePatchType, patch,
+		metav1.PatchOptions{FieldManager: fieldManager})
+	if err != nil {
+		return false, fmt.Errorf("unable to remove the stale labels of managed cluster %q: %w", cluster.Name, err)
+	}
+	return true, nil
+}
 
-		updatedAddOn, err := client.AddonV1alpha1().ManagedClusterAddOns(addOnNamespace).Patch(ctx, addOn.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
-		if err != nil {
-			return err
-		}
-		updatedAddOnStatus = &updatedAddOn.Status
-		updated = err == nil
-		return err
+// ApplyManagedClusterTaints applies the taints of the managed cluster with server-side apply as the field manager.
+// The taints are an atomic list of the ManagedCluster, so the whole list is applied with the resource version of
+// the cluster as the precondition, and a conflict is returned if the taints were changed by the others meanwhile.
+func ApplyManagedClusterTaints(
+	ctx context.Context,
+	client clusterclientset.Interface,
+	cluster *clusterv1.ManagedCluster,
+	fieldManager string,
+	taints []clusterv1.Taint) error {
+	if taints == nil {
+		taints = []clusterv1.Taint{}
+	}
+	metadata := map[string]interface{}{
+		"name": cluster.Name,
+	}
+	if len(cluster.ResourceVersion) > 0 {
+		metadata["resourceVersion"] = cluster.ResourceVersion
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"apiVersion": clusterv1.GroupVersion.String(),
+		"kind":       "ManagedCluster",
+		"metadata":   metadata,
+		"spec": map[string]interface{}{
+			"taints": taints,
+		},
 	})
+	if err != nil {
+		return err
+	}
+	_, err = client.ClusterV1().ManagedClusters().Patch(ctx, cluster.Name, types.ApplyPatchType, patch,
+		metav1.PatchOptions{FieldManager: fieldManager, Force: pointer.Bool(true)})
+	return err
+}
 
-	return updatedAddOnStatus, updated, err
+// GetAddOnLabelStatus returns the status of an addon feature label value, i.e. the value without the status suffixes
+// appended by the addon feature discovery controller, e.g. available for available-degraded.
+func GetAddOnLabelStatus(value string) string {
+	return strings.SplitN(value, "-", 2)[0]
 }
 
-func UpdateManagedClusterAddOnStatusFn(cond metav1.Condition) UpdateManagedClusterAddOnStatusFunc {
-	return func(oldStatus *addonv1alpha1.ManagedClusterAddOnStatus) error {
-		meta.SetStatusCondition(&oldStatus.Conditions, cond)
-		return nil
+// GetAddOnLeaseDurationSeconds returns the lease duration seconds of the addon, it is read from the
+// AddOnLeaseDurationSecondsAnnotation of the addon, and the default seconds are returned if the annotation is not set
+// or invalid.
+func GetAddOnLeaseDurationSeconds(addOn *addonv1alpha1.ManagedClusterAddOn, defaultSeconds int) int {
+	value, ok := addOn.Annotations[AddOnLeaseDurationSecondsAnnotation]
+	if !ok {
+		return defaultSeconds
 	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		klog.InfoS("The lease duration seconds of addon is invalid, use the default", logging.AddOnKey, addOn.Name,
+			"leaseDurationSeconds", value, "default", defaultSeconds)
+		return defaultSeconds
+	}
+	return seconds
 }
 
 // Check whether a CSR is in terminal state
@@ -217,6 +494,19 @@ func Isv1beta1CSRInTerminalState(status *certificatesv1beta1.CertificateSigningR
 	return false
 }
 
+// WithRateLimit returns a copy of the client config with the qps and burst, the qps or burst of the config is kept
+// if the given one is 0
+func WithRateLimit(config *rest.Config, qps float32, burst int) *rest.Config {
+	config = rest.CopyConfig(config)
+	if qps > 0 {
+		config.QPS = qps
+	}
+	if burst > 0 {
+		config.Burst = burst
+	}
+	return config
+}
+
 // IsValidHTTPSURL validate whether a URL is https URL
 func IsValidHTTPSURL(serverURL string) bool {
 	if serverURL == "" {
@@ -281,15 +571,30 @@ func CleanUpManagedClusterManifests(
 	return errorhelpers.NewMultiLineAggregate(errs)
 }
 
-func ManagedClusterAssetFn(fs embed.FS, managedClusterName string) resourceapply.AssetFunc {
+func ManagedClusterAssetFn(fsys fs.ReadFileFS, managedClusterName string) resourceapply.AssetFunc {
+	return ManagedClusterAssetFnWithUser(fsys, managedClusterName, "")
+}
+
+// ManagedClusterAssetFnWithUser returns the asset func of the manifests of the managed cluster, the manifests bind
+// the roles of the cluster with the user besides the group of the cluster if the user is not empty. The user is
+// passed to the manifests as a quoted string, so it does not break the manifests whatever it contains.
+func ManagedClusterAssetFnWithUser(fsys fs.ReadFileFS, managedClusterName, user string) resourceapply.AssetFunc {
 	return func(name string) ([]byte, error) {
 		config := struct {
 			ManagedClusterName string
+			ManagedClusterUser string
 		}{
 			ManagedClusterName: managedClusterName,
 		}
+		if len(user) > 0 {
+			quotedUser, err := json.Marshal(user)
+			if err != nil {
+				return nil, err
+			}
+			config.ManagedClusterUser = string(quotedUser)
+		}
 
-		template, err := fs.ReadFile(name)
+		template, err := fsys.ReadFile(name)
 		if err != nil {
 			return nil, err
 		}
@@ -21,8 +21,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/diff"
 	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
 	clienttesting "k8s.io/client-go/testing"
 )
 
@@ -214,6 +216,110 @@ func TestUpdateManagedClusterAddOnStatus(t *testing.T) {
 	}
 }
 
+func TestApplyManagedClusterLabels(t *testing.T) {
+	cases := []struct {
+		name            string
+		clusterLabels   map[string]string
+		labels          map[string]string
+		staleKeys       []string
+		expectedUpdated bool
+		expectedLabels  map[string]string
+		expectedPatches []types.PatchType
+	}{
+		{
+			name:           "labels are not changed",
+			clusterLabels:  map[string]string{"env": "dev", "owned": "true"},
+			labels:         map[string]string{"owned": "true"},
+			staleKeys:      []string{"stale"},
+			expectedLabels: map[string]string{"env": "dev", "owned": "true"},
+		},
+		{
+			name:            "apply labels",
+			clusterLabels:   map[string]string{"env": "dev"},
+			labels:          map[string]string{"owned": "true"},
+			expectedUpdated: true,
+			expectedLabels:  map[string]string{"env": "dev", "owned": "true"},
+			expectedPatches: []types.PatchType{types.ApplyPatchType},
+		},
+		{
+			name:            "remove stale labels",
+			clusterLabels:   map[string]string{"env": "dev", "owned": "true", "stale": "true"},
+			labels:          map[string]string{"owned": "true"},
+			staleKeys:       []string{"stale"},
+			expectedUpdated: true,
+			expectedLabels:  map[string]string{"env": "dev", "owned": "true"},
+			expectedPatches: []types.PatchType{types.ApplyPatchType, types.MergePatchType},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewManagedCluster()
+			cluster.Labels = c.clusterLabels
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+
+			updated, err := ApplyManagedClusterLabels(context.TODO(), clusterClient, cluster, "test", c.labels, c.staleKeys...)
+			if err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if updated != c.expectedUpdated {
+				t.Errorf("expected updated %v, but got %v", c.expectedUpdated, updated)
+			}
+
+			var patches []types.PatchType
+			for _, action := range clusterClient.Actions() {
+				patches = append(patches, action.(clienttesting.PatchAction).GetPatchType())
+			}
+			if !reflect.DeepEqual(patches, c.expectedPatches) {
+				t.Errorf("expected patches %v, but got %v", c.expectedPatches, patches)
+			}
+
+			actual, err := clusterClient.ClusterV1().ManagedClusters().Get(context.TODO(), cluster.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(actual.Labels, c.expectedLabels) {
+				t.Errorf("expected labels %v, but got %v", c.expectedLabels, actual.Labels)
+			}
+		})
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	cases := []struct {
+		name          string
+		qps           float32
+		burst         int
+		expectedQPS   float32
+		expectedBurst int
+	}{
+		{
+			name:          "rate limit is not set",
+			expectedQPS:   5,
+			expectedBurst: 10,
+		},
+		{
+			name:          "rate limit is set",
+			qps:           100,
+			burst:         200,
+			expectedQPS:   100,
+			expectedBurst: 200,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config := &rest.Config{Host: "https://127.0.0.1:6443", QPS: 5, Burst: 10}
+			limited := WithRateLimit(config, c.qps, c.burst)
+			if limited.QPS != c.expectedQPS || limited.Burst != c.expectedBurst {
+				t.Errorf("expected qps %v and burst %d, but got %v and %d", c.expectedQPS, c.expectedBurst, limited.QPS, limited.Burst)
+			}
+			if config.QPS != 5 || config.Burst != 10 {
+				t.Errorf("expected the config is not changed, but got qps %v and burst %d", config.QPS, config.Burst)
+			}
+		})
+	}
+}
+
 func TestIsValidHTTPSURL(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -0,0 +1,130 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+)
+
+// UpdateStatusFunc updates the status of an object in place
+type UpdateStatusFunc[S any] func(status *S) error
+
+// statusUpdater gets the objects of type T and patches their status of type S. Unlike the labels and taints of the
+// managed clusters, the status is not written with server-side apply: the conditions are an atomic list in the CRDs,
+// so an apply of the conditions owned by one field manager would replace the conditions of all the others. The status
+// is written with a merge patch guarded by the resource version instead.
+type statusUpdater[T metav1.Object, S any] struct {
+	kind       string
+	get        func(ctx context.Context) (T, error)
+	patch      func(ctx context.Context, patchBytes []byte) (T, error)
+	status     func(obj T) *S
+	deepCopy   func(status *S) *S
+	conditions func(status *S) *[]metav1.Condition
+	// object returns the object of the kind with the object meta and the status, it is marshaled into the patch
+	object func(objectMeta metav1.ObjectMeta, status S) interface{}
+}
+
+// update gets the latest object, updates its status with the update funcs and patches the status with the uid and
+// resource version of the object as the preconditions, and it is retried on conflict. The conditions are deduplicated
+// by type, the first one of each type is kept, and the conditions changed by the update funcs without an observed
+// generation are set with the generation of the object.
+func (u statusUpdater[T, S]) update(ctx context.Context, updateFuncs ...UpdateStatusFunc[S]) (*S, bool, error) {
+	updated := false
+	var updatedStatus *S
+
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		obj, err := u.get(ctx)
+		if err != nil {
+			return err
+		}
+		oldStatus := u.status(obj)
+
+		newStatus := u.deepCopy(oldStatus)
+		for _, update := range updateFuncs {
+			if err := update(newStatus); err != nil {
+				return err
+			}
+		}
+		conditions := u.conditions(newStatus)
+		*conditions = dedupConditions(*conditions)
+		setObservedGeneration(*conditions, *u.conditions(oldStatus), obj.GetGeneration())
+
+		if equality.Semantic.DeepEqual(oldStatus, newStatus) {
+			// We return the newStatus which is a deep copy of oldStatus but with all update funcs applied.
+			updatedStatus = newStatus
+			return nil
+		}
+
+		oldData, err := json.Marshal(u.object(metav1.ObjectMeta{}, *oldStatus))
+		if err != nil {
+			return fmt.Errorf("failed to Marshal old data for %s status %s: %w", u.kind, obj.GetName(), err)
+		}
+
+		newData, err := json.Marshal(u.object(metav1.ObjectMeta{
+			UID:             obj.GetUID(),
+			ResourceVersion: obj.GetResourceVersion(),
+		}, *newStatus)) // to ensure they appear in the patch as preconditions
+		if err != nil {
+			return fmt.Errorf("failed to Marshal new data for %s status %s: %w", u.kind, obj.GetName(), err)
+		}
+
+		patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+		if err != nil {
+			return fmt.Errorf("failed to create patch for %s %s: %w", u.kind, obj.GetName(), err)
+		}
+
+		updatedObj, err := u.patch(ctx, patchBytes)
+		if err != nil {
+			return err
+		}
+		updatedStatus = u.status(updatedObj)
+		updated = true
+		return nil
+	})
+
+	return updatedStatus, updated, err
+}
+
+// dedupConditions removes the conditions whose type is duplicated, the first one of each type is kept as it is the
+// one found and set by the condition helpers.
+func dedupConditions(conditions []metav1.Condition) []metav1.Condition {
+	conditionTypes := sets.New[string]()
+	deduped := make([]metav1.Condition, 0, len(conditions))
+	for _, cond := range conditions {
+		if conditionTypes.Has(cond.Type) {
+			continue
+		}
+		conditionTypes.Insert(cond.Type)
+		deduped = append(deduped, cond)
+	}
+	if len(deduped) == len(conditions) {
+		return conditions
+	}
+	return deduped
+}
+
+// setObservedGeneration sets the observed generation of the conditions which are added or changed without an
+// observed generation to the generation, the unchanged conditions are left as they are.
+func setObservedGeneration(conditions, oldConditions []metav1.Condition, generation int64) {
+	if generation == 0 {
+		return
+	}
+	for i := range conditions {
+		if conditions[i].ObservedGeneration != 0 {
+			continue
+		}
+		oldCond := meta.FindStatusCondition(oldConditions, conditions[i].Type)
+		if oldCond != nil && equality.Semantic.DeepEqual(*oldCond, conditions[i]) {
+			continue
+		}
+		conditions[i].ObservedGeneration = generation
+	}
+}
@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestUpdateManagedClusterStatusOnConflict(t *testing.T) {
+	cluster := testinghelpers.NewManagedCluster()
+	cluster.Generation = 2
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	conflicted := false
+	clusterClient.PrependReactor("patch", "managedclusters", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if conflicted {
+			return false, nil, nil
+		}
+		conflicted = true
+		return true, nil, errors.NewConflict(
+			schema.GroupResource{Group: clusterv1.GroupName, Resource: "managedclusters"}, cluster.Name, nil)
+	})
+
+	cond := metav1.Condition{Type: "Test", Status: metav1.ConditionTrue, Reason: "Test", Message: "test"}
+	status, updated, err := UpdateManagedClusterStatus(
+		context.TODO(), clusterClient, cluster.Name, UpdateManagedClusterConditionFn(cond))
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if !updated {
+		t.Errorf("expected the status is updated")
+	}
+	testinghelpers.AssertActions(t, clusterClient.Actions(), "get", "patch", "get", "patch")
+
+	actual := meta.FindStatusCondition(status.Conditions, cond.Type)
+	if actual == nil || actual.ObservedGeneration != cluster.Generation {
+		t.Errorf("expected the condition observes generation %d, but got %v", cluster.Generation, actual)
+	}
+}
+
+func TestDedupConditions(t *testing.T) {
+	cases := []struct {
+		name       string
+		conditions []metav1.Condition
+		expected   []metav1.Condition
+	}{
+		{
+			name: "no duplicated condition",
+			conditions: []metav1.Condition{
+				{Type: "Available", Status: metav1.ConditionTrue},
+				{Type: "Joined", Status: metav1.ConditionTrue},
+			},
+			expected: []metav1.Condition{
+				{Type: "Available", Status: metav1.ConditionTrue},
+				{Type: "Joined", Status: metav1.ConditionTrue},
+			},
+		},
+		{
+			name: "the first condition of the type is kept",
+			conditions: []metav1.Condition{
+				{Type: "Available", Status: metav1.ConditionTrue},
+				{Type: "Joined", Status: metav1.ConditionTrue},
+				{Type: "Available", Status: metav1.ConditionFalse},
+			},
+			expected: []metav1.Condition{
+				{Type: "Available", Status: metav1.ConditionTrue},
+				{Type: "Joined", Status: metav1.ConditionTrue},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := dedupConditions(c.conditions)
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("expected conditions %v, but got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSetObservedGeneration(t *testing.T) {
+	oldConditions := []metav1.Condition{
+		{Type: "Unchanged", Status: metav1.ConditionTrue},
+		{Type: "Changed", Status: metav1.ConditionTrue, ObservedGeneration: 1},
+		{Type: "Observed", Status: metav1.ConditionTrue, ObservedGeneration: 1},
+	}
+	conditions := []metav1.Condition{
+		{Type: "Unchanged", Status: metav1.ConditionTrue},
+		{Type: "Changed", Status: metav1.ConditionFalse},
+		{Type: "Observed", Status: metav1.ConditionFalse, ObservedGeneration: 1},
+		{Type: "Added", Status: metav1.ConditionTrue},
+	}
+
+	setObservedGeneration(conditions, oldConditions, 2)
+
+	expected := map[string]int64{"Unchanged": 0, "Changed": 2, "Observed": 1, "Added": 2}
+	actual := map[string]int64{}
+	for _, cond := range conditions {
+		actual[cond.Type] = cond.ObservedGeneration
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected observed generations %v, but got %v", expected, actual)
+	}
+}
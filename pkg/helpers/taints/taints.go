@@ -0,0 +1,135 @@
+// Package taints implements the matching semantics of the taints of the managed clusters and the tolerations of
+// the placements, so the webhook, the hub controllers and the placement consumers share one implementation.
+package taints
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+// Matches returns true if the toleration matches the taint by the key, value and effect. An empty key with the
+// Exists operator matches all taints, an empty effect matches all effects and an empty operator means Equal.
+func Matches(toleration clusterv1beta1.Toleration, taint clusterv1.Taint) bool {
+	if len(toleration.Effect) > 0 && toleration.Effect != taint.Effect {
+		return false
+	}
+	if len(toleration.Key) > 0 && toleration.Key != taint.Key {
+		return false
+	}
+
+	switch toleration.Operator {
+	case clusterv1beta1.TolerationOpExists:
+		return true
+	case "", clusterv1beta1.TolerationOpEqual:
+		return len(toleration.Key) > 0 && toleration.Value == taint.Value
+	default:
+		return false
+	}
+}
+
+// Tolerates returns true if the taint is tolerated by the tolerations at the time, and the time the tolerance
+// expires, which is nil if the taint is tolerated forever or is not tolerated.
+//   - The PreferNoSelect taints are always tolerated since they only affect the score of the cluster;
+//   - The NoSelectIfNew taints are tolerated if the cluster is already decided or any toleration matches, the
+//     TolerationSeconds of the tolerations are ignored;
+//   - The NoSelect taints are tolerated if any toleration matches, until the TolerationSeconds counted from the
+//     TimeAdded of the taint elapse. The longest tolerance of the matched tolerations wins.
+func Tolerates(taint clusterv1.Taint, tolerations []clusterv1beta1.Toleration, decided bool, now time.Time) (bool, *time.Time) {
+	switch taint.Effect {
+	case clusterv1.TaintEffectPreferNoSelect:
+		return true, nil
+	case clusterv1.TaintEffectNoSelectIfNew:
+		if decided {
+			return true, nil
+		}
+		for _, toleration := range tolerations {
+			if Matches(toleration, taint) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	var expiresAt *time.Time
+	tolerated := false
+	for _, toleration := range tolerations {
+		if !Matches(toleration, taint) {
+			continue
+		}
+		if toleration.TolerationSeconds == nil {
+			return true, nil
+		}
+
+		expiry := taint.TimeAdded.Add(time.Duration(*toleration.TolerationSeconds) * time.Second)
+		if !now.Before(expiry) {
+			continue
+		}
+		tolerated = true
+		if expiresAt == nil || expiry.After(*expiresAt) {
+			expiresAt = &expiry
+		}
+	}
+	return tolerated, expiresAt
+}
+
+// IsClusterTolerated returns true if all the taints of the cluster are tolerated by the tolerations at the time,
+// and the earliest time one of the tolerances expires, which is nil if the cluster is tolerated forever or is not
+// tolerated. The caller should evaluate the cluster again at the returned time.
+func IsClusterTolerated(cluster *clusterv1.ManagedCluster, tolerations []clusterv1beta1.Toleration,
+	decided bool, now time.Time) (bool, *time.Time) {
+	var expiresAt *time.Time
+	for _, taint := range cluster.Spec.Taints {
+		tolerated, expiry := Tolerates(taint, tolerations, decided, now)
+		if !tolerated {
+			return false, nil
+		}
+		if expiry != nil && (expiresAt == nil || expiry.Before(*expiresAt)) {
+			expiresAt = expiry
+		}
+	}
+	return true, expiresAt
+}
+
+// SetTimeAdded sets the TimeAdded of the taints against the original taints with the same keys, and returns the keys
+// of the taints whose TimeAdded is set against the rules. The TimeAdded is owned by the hub, so
+//   - The TimeAdded of the taints is ignored and set to now if the taints are created with the cluster;
+//   - The TimeAdded of a new taint, or a taint whose value or effect is changed, must not be set and is set to now;
+//   - The TimeAdded of an unchanged taint is preserved if it is not set, and must be the original one if it is set.
+func SetTimeAdded(taints, originalTaints []clusterv1.Taint, creating bool, now metav1.Time) []string {
+	var invalidKeys []string
+	for index, taint := range taints {
+		originalTaint := findTaintByKey(originalTaints, taint.Key)
+		switch {
+		case creating:
+			taints[index].TimeAdded = now
+		case originalTaint != nil && originalTaint.Value == taint.Value && originalTaint.Effect == taint.Effect:
+			if taint.TimeAdded.IsZero() {
+				taints[index].TimeAdded = originalTaint.TimeAdded
+				continue
+			}
+			if !originalTaint.TimeAdded.Equal(&taint.TimeAdded) {
+				invalidKeys = append(invalidKeys, taint.Key)
+			}
+		default:
+			if !taint.TimeAdded.IsZero() {
+				invalidKeys = append(invalidKeys, taint.Key)
+				continue
+			}
+			taints[index].TimeAdded = now
+		}
+	}
+	return invalidKeys
+}
+
+func findTaintByKey(taints []clusterv1.Taint, key string) *clusterv1.Taint {
+	for i := range taints {
+		if taints[i].Key == key {
+			return &taints[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package taints
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+func TestMatches(t *testing.T) {
+	taint := clusterv1.Taint{Key: "gpu", Value: "true", Effect: clusterv1.TaintEffectNoSelect}
+
+	cases := []struct {
+		name       string
+		toleration clusterv1beta1.Toleration
+		expected   bool
+	}{
+		{
+			name:       "equal key and value",
+			toleration: clusterv1beta1.Toleration{Key: "gpu", Operator: clusterv1beta1.TolerationOpEqual, Value: "true"},
+			expected:   true,
+		},
+		{
+			name:       "empty operator means equal",
+			toleration: clusterv1beta1.Toleration{Key: "gpu", Value: "true"},
+			expected:   true,
+		},
+		{
+			name:       "different value",
+			toleration: clusterv1beta1.Toleration{Key: "gpu", Value: "false"},
+		},
+		{
+			name:       "different key",
+			toleration: clusterv1beta1.Toleration{Key: "cpu", Operator: clusterv1beta1.TolerationOpExists},
+		},
+		{
+			name:       "key exists",
+			toleration: clusterv1beta1.Toleration{Key: "gpu", Operator: clusterv1beta1.TolerationOpExists},
+			expected:   true,
+		},
+		{
+			name:       "empty key with exists matches all",
+			toleration: clusterv1beta1.Toleration{Operator: clusterv1beta1.TolerationOpExists},
+			expected:   true,
+		},
+		{
+			name:       "empty key with equal matches nothing",
+			toleration: clusterv1beta1.Toleration{Value: "true"},
+		},
+		{
+			name: "same effect",
+			toleration: clusterv1beta1.Toleration{
+				Key: "gpu", Operator: clusterv1beta1.TolerationOpExists, Effect: clusterv1.TaintEffectNoSelect},
+			expected: true,
+		},
+		{
+			name: "different effect",
+			toleration: clusterv1beta1.Toleration{
+				Key: "gpu", Operator: clusterv1beta1.TolerationOpExists, Effect: clusterv1.TaintEffectPreferNoSelect},
+		},
+		{
+			name:       "unknown operator",
+			toleration: clusterv1beta1.Toleration{Key: "gpu", Operator: "In", Value: "true"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := Matches(c.toleration, taint); actual != c.expected {
+				t.Errorf("expected %v, but got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTolerates(t *testing.T) {
+	now := time.Now()
+	added := metav1.NewTime(now.Add(-10 * time.Second))
+
+	cases := []struct {
+		name              string
+		taint             clusterv1.Taint
+		tolerations       []clusterv1beta1.Toleration
+		decided           bool
+		expectedTolerated bool
+		expectedExpiry    *time.Time
+	}{
+		{
+			name:              "prefer no select is always tolerated",
+			taint:             clusterv1.Taint{Key: "gpu", Effect: clusterv1.TaintEffectPreferNoSelect, TimeAdded: added},
+			expectedTolerated: true,
+		},
+		{
+			name:  "no select is not tolerated",
+			taint: clusterv1.Taint{Key: "gpu", Effect: clusterv1.TaintEffectNoSelect, TimeAdded: added},
+		},
+		{
+			name:              "no select is tolerated forever",
+			taint:             clusterv1.Taint{Key: "gpu", Effect: clusterv1.TaintEffectNoSelect, TimeAdded: added},
+			tolerations:       []clusterv1beta1.Toleration{{Key: "gpu", Operator: clusterv1beta1.TolerationOpExists}},
+			expectedTolerated: true,
+		},
+		{
+			name:  "no select is tolerated until the toleration seconds elapse",
+			taint: clusterv1.Taint{Key: "gpu", Effect: clusterv1.TaintEffectNoSelect, TimeAdded: added},
+			tolerations: []clusterv1beta1.Toleration{
+				{Key: "gpu", Operator: clusterv1beta1.TolerationOpExists, TolerationSeconds: int64Ptr(20)},
+				{Key: "gpu", Operator: clusterv1beta1.TolerationOpExists, TolerationSeconds: int64Ptr(30)},
+			},
+			expectedTolerated: true,
+			expectedExpiry:    timePtr(added.Add(30 * time.Second)),
+		},
+		{
+			name:  "no select is not tolerated after the toleration seconds elapse",
+			taint: clusterv1.Taint{Key: "gpu", Effect: clusterv1.TaintEffectNoSelect, TimeAdded: added},
+			tolerations: []clusterv1beta1.Toleration{
+				{Key: "gpu", Operator: clusterv1beta1.TolerationOpExists, TolerationSeconds: int64Ptr(5)},
+			},
+		},
+		{
+			name:  "toleration without seconds wins",
+			taint: clusterv1.Taint{Key: "gpu", Effect: clusterv1.TaintEffectNoSelect, TimeAdded: added},
+			tolerations: []clusterv1beta1.Toleration{
+				{Key: "gpu", Operator: clusterv1beta1.TolerationOpExists, TolerationSeconds: int64Ptr(20)},
+				{Operator: clusterv1beta1.TolerationOpExists},
+			},
+			expectedTolerated: true,
+		},
+		{
+			name:              "no select if new is tolerated by the decided cluster",
+			taint:             clusterv1.Taint{Key: "gpu", Effect: clusterv1.TaintEffectNoSelectIfNew, TimeAdded: added},
+			decided:           true,
+			expectedTolerated: true,
+		},
+		{
+			name:  "no select if new is not tolerated by the new cluster",
+			taint: clusterv1.Taint{Key: "gpu", Effect: clusterv1.TaintEffectNoSelectIfNew, TimeAdded: added},
+		},
+		{
+			name:  "no select if new ignores the toleration seconds",
+			taint: clusterv1.Taint{Key: "gpu", Effect: clusterv1.TaintEffectNoSelectIfNew, TimeAdded: added},
+			tolerations: []clusterv1beta1.Toleration{
+				{Key: "gpu", Operator: clusterv1beta1.TolerationOpExists, TolerationSeconds: int64Ptr(5)},
+			},
+			expectedTolerated: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tolerated, expiry := Tolerates(c.taint, c.tolerations, c.decided, now)
+			if tolerated != c.expectedTolerated {
+				t.Errorf("expected tolerated %v, but got %v", c.expectedTolerated, tolerated)
+			}
+			assertExpiry(t, c.expectedExpiry, expiry)
+		})
+	}
+}
+
+func TestIsClusterTolerated(t *testing.T) {
+	now := time.Now()
+	added := metav1.NewTime(now.Add(-10 * time.Second))
+	cluster := &clusterv1.ManagedCluster{
+		Spec: clusterv1.ManagedClusterSpec{
+			Taints: []clusterv1.Taint{
+				{Key: "gpu", Effect: clusterv1.TaintEffectNoSelect, TimeAdded: added},
+				{Key: clusterv1.ManagedClusterTaintUnreachable, Effect: clusterv1.TaintEffectNoSelect, TimeAdded: added},
+				{Key: "maintenance", Effect: clusterv1.TaintEffectPreferNoSelect, TimeAdded: added},
+			},
+		},
+	}
+
+	cases := []struct {
+		name              string
+		tolerations       []clusterv1beta1.Toleration
+		expectedTolerated bool
+		expectedExpiry    *time.Time
+	}{
+		{
+			name:        "one taint is not tolerated",
+			tolerations: []clusterv1beta1.Toleration{{Key: "gpu", Operator: clusterv1beta1.TolerationOpExists}},
+		},
+		{
+			name: "the earliest expiry is returned",
+			tolerations: []clusterv1beta1.Toleration{
+				{Key: "gpu", Operator: clusterv1beta1.TolerationOpExists, TolerationSeconds: int64Ptr(60)},
+				{Key: clusterv1.ManagedClusterTaintUnreachable, Operator: clusterv1beta1.TolerationOpExists,
+					TolerationSeconds: int64Ptr(20)},
+			},
+			expectedTolerated: true,
+			expectedExpiry:    timePtr(added.Add(20 * time.Second)),
+		},
+		{
+			name:              "all taints are tolerated forever",
+			tolerations:       []clusterv1beta1.Toleration{{Operator: clusterv1beta1.TolerationOpExists}},
+			expectedTolerated: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tolerated, expiry := IsClusterTolerated(cluster, c.tolerations, false, now)
+			if tolerated != c.expectedTolerated {
+				t.Errorf("expected tolerated %v, but got %v", c.expectedTolerated, tolerated)
+			}
+			assertExpiry(t, c.expectedExpiry, expiry)
+		})
+	}
+}
+
+func TestSetTimeAdded(t *testing.T) {
+	now := metav1.NewTime(time.Now())
+	added := metav1.NewTime(now.Add(-10 * time.Second))
+	originalTaints := []clusterv1.Taint{
+		{Key: "a", Value: "b", Effect: clusterv1.TaintEffectNoSelect, TimeAdded: added},
+	}
+
+	cases := []struct {
+		name                string
+		taints              []clusterv1.Taint
+		originalTaints      []clusterv1.Taint
+		creating            bool
+		expectedTimeAdded   []metav1.Time
+		expectedInvalidKeys []string
+	}{
+		{
+			name:              "TimeAdded is ignored on creation",
+			taints:            []clusterv1.Taint{{Key: "a", Effect: clusterv1.TaintEffectNoSelect, TimeAdded: added}},
+			creating:          true,
+			expectedTimeAdded: []metav1.Time{now},
+		},
+		{
+			name: "new taint",
+			taints: []clusterv1.Taint{
+				{Key: "a", Value: "b", Effect: clusterv1.TaintEffectNoSelect},
+				{Key: "c", Effect: clusterv1.TaintEffectNoSelect},
+			},
+			originalTaints:    originalTaints,
+			expectedTimeAdded: []metav1.Time{added, now},
+		},
+		{
+			name:                "new taint with TimeAdded",
+			taints:              []clusterv1.Taint{{Key: "c", Effect: clusterv1.TaintEffectNoSelect, TimeAdded: added}},
+			originalTaints:      originalTaints,
+			expectedTimeAdded:   []metav1.Time{added},
+			expectedInvalidKeys: []string{"c"},
+		},
+		{
+			name:              "unchanged taint with the original TimeAdded",
+			taints:            []clusterv1.Taint{{Key: "a", Value: "b", Effect: clusterv1.TaintEffectNoSelect, TimeAdded: added}},
+			originalTaints:    originalTaints,
+			expectedTimeAdded: []metav1.Time{added},
+		},
+		{
+			name:                "unchanged taint with a modified TimeAdded",
+			taints:              []clusterv1.Taint{{Key: "a", Value: "b", Effect: clusterv1.TaintEffectNoSelect, TimeAdded: now}},
+			originalTaints:      originalTaints,
+			expectedTimeAdded:   []metav1.Time{now},
+			expectedInvalidKeys: []string{"a"},
+		},
+		{
+			name:              "changed taint",
+			taints:            []clusterv1.Taint{{Key: "a", Value: "c", Effect: clusterv1.TaintEffectNoSelect}},
+			originalTaints:    originalTaints,
+			expectedTimeAdded: []metav1.Time{now},
+		},
+		{
+			name:                "changed taint with the original TimeAdded",
+			taints:              []clusterv1.Taint{{Key: "a", Value: "b", Effect: clusterv1.TaintEffectPreferNoSelect, TimeAdded: added}},
+			originalTaints:      originalTaints,
+			expectedTimeAdded:   []metav1.Time{added},
+			expectedInvalidKeys: []string{"a"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			invalidKeys := SetTimeAdded(c.taints, c.originalTaints, c.creating, now)
+			if !reflect.DeepEqual(invalidKeys, c.expectedInvalidKeys) {
+				t.Errorf("expected invalid keys %v, but got %v", c.expectedInvalidKeys, invalidKeys)
+			}
+			for i, taint := range c.taints {
+				if !taint.TimeAdded.Equal(&c.expectedTimeAdded[i]) {
+					t.Errorf("expected TimeAdded %v of taint %q, but got %v", c.expectedTimeAdded[i], taint.Key, taint.TimeAdded)
+				}
+			}
+		})
+	}
+}
+
+func assertExpiry(t *testing.T, expected, actual *time.Time) {
+	switch {
+	case expected == nil && actual == nil:
+	case expected == nil || actual == nil || !expected.Equal(*actual):
+		t.Errorf("expected expiry %v, but got %v", expected, actual)
+	}
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
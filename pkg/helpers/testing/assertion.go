@@ -2,6 +2,7 @@ package testing
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"reflect"
@@ -16,7 +17,9 @@ import (
 	coordinationv1 "k8s.io/api/coordination/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/utils/diff"
 )
@@ -117,7 +120,7 @@ func AssertCondition(
 	expectedCondition metav1.Condition) {
 	cond := meta.FindStatusCondition(actualConditions, expectedCondition.Type)
 	if cond == nil {
-		t.Errorf("expected condition %s but got: %s", expectedCondition.Type, cond.Type)
+		t.Fatalf("expected condition %s but it is not found in %v", expectedCondition.Type, actualConditions)
 	}
 	if cond.Status != expectedCondition.Status {
 		t.Errorf("expected status %s but got: %s", expectedCondition.Status, cond.Status)
@@ -246,3 +249,86 @@ func AssertFileContent(t *testing.T, filePath string, expectedContent []byte) {
 		t.Errorf("expect %v, but got %v", expectedContent, content)
 	}
 }
+
+// AssertPatch asserts the action is a patch of the patch type on the subresource, the subresource is empty if the
+// object itself is patched, and returns the patch action
+func AssertPatch(t *testing.T, action clienttesting.Action, patchType types.PatchType, subresource string) clienttesting.PatchAction {
+	patchAction, ok := action.(clienttesting.PatchAction)
+	if !ok || action.GetVerb() != "patch" {
+		t.Fatalf("expected patch action, but got %v", action)
+	}
+	if patchAction.GetPatchType() != patchType {
+		t.Errorf("expected %s patch, but got %s", patchType, patchAction.GetPatchType())
+	}
+	if patchAction.GetSubresource() != subresource {
+		t.Errorf("expected the patch of subresource %q, but got %q", subresource, patchAction.GetSubresource())
+	}
+	return patchAction
+}
+
+// AssertApplyPatch asserts the action is a server side apply of the object and returns the patch action
+func AssertApplyPatch(t *testing.T, action clienttesting.Action) clienttesting.PatchAction {
+	return AssertPatch(t, action, types.ApplyPatchType, "")
+}
+
+// UnmarshalPatch unmarshals the patch of the patch action into the obj, e.g. a ManagedCluster to check the fields
+// in the patch with the typed object
+func UnmarshalPatch(t *testing.T, action clienttesting.Action, obj interface{}) {
+	patchAction, ok := action.(clienttesting.PatchAction)
+	if !ok {
+		t.Fatalf("expected patch action, but got %v", action)
+	}
+	if err := json.Unmarshal(patchAction.GetPatch(), obj); err != nil {
+		t.Fatalf("unable to unmarshal the patch %q: %v", string(patchAction.GetPatch()), err)
+	}
+}
+
+// AssertPatchField asserts the field at the path of the fields in the patch of the patch action is the expected
+// value, e.g. AssertPatchField(t, action, "value", "metadata", "labels", "key"). The expected value is compared with
+// its json representation, so it can be a typed value like a []metav1.Condition, and it is nil if the patch removes
+// the field with a null value.
+func AssertPatchField(t *testing.T, action clienttesting.Action, expected interface{}, fields ...string) {
+	patch := map[string]interface{}{}
+	UnmarshalPatch(t, action, &patch)
+
+	path := strings.Join(fields, ".")
+	actual, found, err := unstructured.NestedFieldNoCopy(patch, fields...)
+	if err != nil {
+		t.Fatalf("unable to get the field %s in the patch %v: %v", path, patch, err)
+	}
+	if !found {
+		t.Errorf("expected the field %s in the patch, but got %v", path, patch)
+		return
+	}
+
+	expectedData, err := json.Marshal(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedValue interface{}
+	if err := json.Unmarshal(expectedData, &expectedValue); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expectedValue) {
+		t.Errorf("expected the field %s is %v in the patch, but got %v", path, expectedValue, actual)
+	}
+}
+
+// AssertPatchLabel asserts the patch of the patch action sets the label to the expected value, the expected value is
+// nil if the patch removes the label
+func AssertPatchLabel(t *testing.T, action clienttesting.Action, key string, expected interface{}) {
+	AssertPatchField(t, action, expected, "metadata", "labels", key)
+}
+
+// AssertConditionPatched asserts the action is a merge patch of the status subresource with the expected condition,
+// e.g. the status patches of the managed clusters and addons
+func AssertConditionPatched(t *testing.T, action clienttesting.Action, expectedCondition metav1.Condition) {
+	AssertPatch(t, action, types.MergePatchType, "status")
+	patched := &struct {
+		Status struct {
+			Conditions []metav1.Condition `json:"conditions"`
+		} `json:"status"`
+	}{}
+	UnmarshalPatch(t, action, patched)
+	AssertCondition(t, patched.Status.Conditions, expectedCondition)
+}
@@ -0,0 +1,132 @@
+package testing
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/clock"
+)
+
+// NetworkPartition simulates the network partition between a component and the api server behind the fake
+// clientsets, e.g. the agent losing the connection to the hub. While it is partitioned, the requests and watches of
+// the partitioned resources fail with the connection refused error a real client gets from an unreachable server.
+type NetworkPartition struct {
+	lock        sync.Mutex
+	partitioned bool
+	resources   sets.Set[string]
+	rejected    int
+}
+
+// NewNetworkPartition returns a healed NetworkPartition on the fake clientsets, e.g. &hubKubeClient.Fake
+func NewNetworkPartition(fakes ...*clienttesting.Fake) *NetworkPartition {
+	p := &NetworkPartition{resources: sets.New[string]()}
+	for _, fake := range fakes {
+		fake.PrependReactor("*", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			if !p.reject(action.GetResource().Resource) {
+				return false, nil, nil
+			}
+			return true, nil, NewUnreachableError(action.GetResource().Resource)
+		})
+		fake.PrependWatchReactor("*", func(action clienttesting.Action) (bool, watch.Interface, error) {
+			if !p.reject(action.GetResource().Resource) {
+				return false, nil, nil
+			}
+			return true, nil, NewUnreachableError(action.GetResource().Resource)
+		})
+	}
+	return p
+}
+
+// Partition partitions the requests of the resources, or all the requests if no resource is specified
+func (p *NetworkPartition) Partition(resources ...string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.partitioned = true
+	p.resources = sets.New(resources...)
+}
+
+// Heal heals the partition, the requests are served by the fake clientsets again
+func (p *NetworkPartition) Heal() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.partitioned = false
+	p.resources = sets.New[string]()
+}
+
+// Rejected returns the number of the requests rejected by the partition
+func (p *NetworkPartition) Rejected() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.rejected
+}
+
+func (p *NetworkPartition) reject(resource string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.partitioned {
+		return false
+	}
+	if p.resources.Len() > 0 && !p.resources.Has(resource) {
+		return false
+	}
+	p.rejected++
+	return true
+}
+
+// NewUnreachableError returns the error of a request of the resource to an unreachable api server
+func NewUnreachableError(resource string) error {
+	return &url.Error{
+		Op:  "Get",
+		URL: fmt.Sprintf("https://hub.example.com:6443/%s", resource),
+		Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+	}
+}
+
+// DelayCSRApproval delays the approvals of the csrs on the fake clientset, e.g. a hub with a slow or manual approver.
+// The first approval of a csr starts its delay on the clock, and the approvals of the csr fail with the
+// TooManyRequests error until the clock passes the delay, so a fake clock steps through the delay deterministically.
+func DelayCSRApproval(fake *clienttesting.Fake, clock clock.PassiveClock, delay time.Duration) {
+	var lock sync.Mutex
+	firstApprovals := map[string]time.Time{}
+	fake.PrependReactor("update", "certificatesigningrequests", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "approval" {
+			return false, nil, nil
+		}
+		csr, ok := action.(clienttesting.UpdateAction).GetObject().(metav1.Object)
+		if !ok {
+			return false, nil, nil
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		now := clock.Now()
+		firstApproval, ok := firstApprovals[csr.GetName()]
+		if !ok {
+			firstApproval = now
+			firstApprovals[csr.GetName()] = now
+		}
+		if remaining := firstApproval.Add(delay).Sub(now); remaining > 0 {
+			return true, nil, errors.NewTooManyRequests(
+				fmt.Sprintf("the approval of csr %q is delayed", csr.GetName()), int(remaining.Seconds()))
+		}
+		return false, nil, nil
+	})
+}
+
+// NewStarvedManagedClusterLease returns the lease of the managed cluster which is not renewed for the number of the
+// test lease durations until the time of the clock, e.g. the agent is starved of renewing its lease by a slow hub
+func NewStarvedManagedClusterLease(name string, clock clock.PassiveClock, leaseDurations int) *coordv1.Lease {
+	starvation := time.Duration(leaseDurations) * time.Duration(TestLeaseDurationSeconds) * time.Second
+	return NewManagedClusterLease(name, clock.Now().Add(-starvation))
+}
@@ -297,6 +297,8 @@ type CSRHolder struct {
 	Orgs         []string
 	Username     string
 	ReqBlockType string
+	DNSNames     []string
+	IPAddresses  []net.IP
 }
 
 func NewCSR(holder CSRHolder) *certv1.CertificateSigningRequest {
@@ -310,9 +312,9 @@ func NewCSR(holder CSRHolder) *certv1.CertificateSigningRequest {
 			CommonName:   holder.CN,
 			Organization: holder.Orgs,
 		},
-		DNSNames:       []string{},
+		DNSNames:       holder.DNSNames,
 		EmailAddresses: []string{},
-		IPAddresses:    []net.IP{},
+		IPAddresses:    holder.IPAddresses,
 	}, pk)
 	if err != nil {
 		panic(err)
@@ -343,9 +345,9 @@ func NewV1beta1CSR(holder CSRHolder) *certv1beta1.CertificateSigningRequest {
 			CommonName:   holder.CN,
 			Organization: holder.Orgs,
 		},
-		DNSNames:       []string{},
+		DNSNames:       holder.DNSNames,
 		EmailAddresses: []string{},
-		IPAddresses:    []net.IP{},
+		IPAddresses:    holder.IPAddresses,
 	}, pk)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,151 @@
+package acceptancereview
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+	"open-cluster-management.io/registration/pkg/tracing"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// AcceptanceReviewAnnotation is the annotation on a managed cluster holding the decision of the reviewer, its
+	// value is either approved or denied. Setting the annotation requires the approve verb on the
+	// managedclusters/accept subresource of the register.open-cluster-management.io group.
+	AcceptanceReviewAnnotation = "cluster.open-cluster-management.io/acceptance-review"
+	AcceptanceReviewApproved   = "approved"
+	AcceptanceReviewDenied     = "denied"
+
+	// ManagedClusterConditionPendingApproval is the condition type set on a new managed cluster, it is true while the
+	// cluster is waiting for the review, and false once the cluster is approved, denied or the review times out.
+	ManagedClusterConditionPendingApproval = "PendingApproval"
+)
+
+// acceptanceReviewController accepts the new managed clusters once they are approved by a reviewer. The clusters
+// which are not reviewed within the timeout are denied automatically.
+type acceptanceReviewController struct {
+	clusterClient clientset.Interface
+	clusterLister clusterv1listers.ManagedClusterLister
+	timeout       time.Duration
+	eventRecorder events.Recorder
+}
+
+// NewAcceptanceReviewController creates a new acceptance review controller on hub cluster. The review does not
+// time out if the timeout is 0.
+func NewAcceptanceReviewController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	timeout time.Duration,
+	recorder events.Recorder) factory.Controller {
+	c := &acceptanceReviewController{
+		clusterClient: clusterClient,
+		clusterLister: clusterInformer.Lister(),
+		timeout:       timeout,
+		eventRecorder: recorder.WithComponentSuffix("acceptance-review-controller"),
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithSync(tracing.TraceSync("ManagedClusterAcceptanceReviewController", c.sync)).
+		ToController("ManagedClusterAcceptanceReviewController", recorder)
+}
+
+func (c *acceptanceReviewController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling acceptance review of ManagedCluster")
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// the cluster is not found, do nothing
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	pendingCondition := meta.FindStatusCondition(cluster.Status.Conditions, ManagedClusterConditionPendingApproval)
+	if cluster.Spec.HubAcceptsClient {
+		// the cluster which is accepted without a review, e.g. before the review is enabled, is left as it is
+		if pendingCondition == nil || pendingCondition.Status == metav1.ConditionFalse {
+			return nil
+		}
+		return c.updatePendingCondition(ctx, cluster, metav1.ConditionFalse, "Accepted", "The cluster is accepted")
+	}
+
+	switch cluster.Annotations[AcceptanceReviewAnnotation] {
+	case AcceptanceReviewApproved:
+		patch := []byte("{\"spec\": {\"hubAcceptsClient\": true}}")
+		if _, err := c.clusterClient.ClusterV1().ManagedClusters().Patch(
+			ctx, clusterName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return err
+		}
+		c.eventRecorder.Eventf("ManagedClusterReviewApproved", "managed cluster %s is approved by the reviewer", clusterName)
+		return c.updatePendingCondition(ctx, cluster, metav1.ConditionFalse, "Approved", "The cluster is approved by the reviewer")
+	case AcceptanceReviewDenied:
+		return c.updatePendingCondition(ctx, cluster, metav1.ConditionFalse, "Denied", "The cluster is denied by the reviewer")
+	}
+
+	// the cluster which is denied by the timeout stays denied until the reviewer approves it
+	if pendingCondition != nil && pendingCondition.Status == metav1.ConditionFalse {
+		return nil
+	}
+
+	if c.timeout > 0 {
+		deadline := cluster.CreationTimestamp.Add(c.timeout)
+		now := time.Now()
+		if !now.Before(deadline) {
+			return c.updatePendingCondition(ctx, cluster, metav1.ConditionFalse, "ReviewTimeout",
+				fmt.Sprintf("The cluster is not reviewed within %v", c.timeout))
+		}
+		// deny the cluster once the review times out
+		syncCtx.Queue().AddAfter(clusterName, deadline.Sub(now))
+	}
+
+	return c.updatePendingCondition(ctx, cluster, metav1.ConditionTrue, "WaitingForReview",
+		fmt.Sprintf("The cluster is waiting for a reviewer to set the annotation %s to %s or %s",
+			AcceptanceReviewAnnotation, AcceptanceReviewApproved, AcceptanceReviewDenied))
+}
+
+// updatePendingCondition sets the pending approval condition of the cluster, an event is recorded once the cluster
+// is denied.
+func (c *acceptanceReviewController) updatePendingCondition(ctx context.Context, cluster *clusterv1.ManagedCluster,
+	status metav1.ConditionStatus, reason, message string) error {
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, ManagedClusterConditionPendingApproval)
+	if cond != nil && cond.Status == status && cond.Reason == reason {
+		return nil
+	}
+
+	_, updated, err := helpers.UpdateManagedClusterStatus(
+		ctx, c.clusterClient, cluster.Name, helpers.UpdateManagedClusterConditionFn(metav1.Condition{
+			Type:    ManagedClusterConditionPendingApproval,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		}))
+	if updated && (reason == "Denied" || reason == "ReviewTimeout") {
+		c.eventRecorder.Warningf("ManagedClusterReviewDenied", "managed cluster %s is not accepted: %s", cluster.Name, message)
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package acceptancereview
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestSync(t *testing.T) {
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		timeout         time.Duration
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "accepted cluster without review",
+			cluster:         testinghelpers.NewAcceptedManagedCluster(),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:    "new cluster",
+			cluster: newManagedCluster(time.Now(), ""),
+			timeout: time.Hour,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				assertPendingCondition(t, actions[1], metav1.ConditionTrue, "WaitingForReview")
+			},
+		},
+		{
+			name: "cluster waiting for review",
+			cluster: newManagedCluster(time.Now(), "", metav1.Condition{
+				Type: ManagedClusterConditionPendingApproval, Status: metav1.ConditionTrue, Reason: "WaitingForReview"}),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:    "review timeout",
+			cluster: newManagedCluster(time.Now().Add(-2*time.Hour), ""),
+			timeout: time.Hour,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				assertPendingCondition(t, actions[1], metav1.ConditionFalse, "ReviewTimeout")
+			},
+		},
+		{
+			name:    "review does not time out without timeout",
+			cluster: newManagedCluster(time.Now().Add(-2*time.Hour), ""),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				assertPendingCondition(t, actions[1], metav1.ConditionTrue, "WaitingForReview")
+			},
+		},
+		{
+			name:    "approved by reviewer",
+			cluster: newManagedCluster(time.Now().Add(-2*time.Hour), AcceptanceReviewApproved),
+			timeout: time.Hour,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch", "get", "patch")
+				patch := &clusterv1.ManagedCluster{}
+				testinghelpers.UnmarshalPatch(t, actions[0], patch)
+				if !patch.Spec.HubAcceptsClient {
+					t.Errorf("expected the cluster is accepted")
+				}
+				assertPendingCondition(t, actions[2], metav1.ConditionFalse, "Approved")
+			},
+		},
+		{
+			name:    "denied by reviewer",
+			cluster: newManagedCluster(time.Now(), AcceptanceReviewDenied),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				assertPendingCondition(t, actions[1], metav1.ConditionFalse, "Denied")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset([]runtime.Object{c.cluster}...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := &acceptanceReviewController{
+				clusterClient: clusterClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				timeout:       c.timeout,
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			testinghelpers.AssertError(t, syncErr, "")
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func newManagedCluster(creation time.Time, review string, conds ...metav1.Condition) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewManagedCluster()
+	cluster.CreationTimestamp = metav1.NewTime(creation)
+	if len(review) > 0 {
+		cluster.Annotations = map[string]string{AcceptanceReviewAnnotation: review}
+	}
+	cluster.Status.Conditions = conds
+	return cluster
+}
+
+func assertPendingCondition(t *testing.T, action clienttesting.Action, status metav1.ConditionStatus, reason string) {
+	patch := action.(clienttesting.PatchAction).GetPatch()
+	managedCluster := &clusterv1.ManagedCluster{}
+	if err := json.Unmarshal(patch, managedCluster); err != nil {
+		t.Fatal(err)
+	}
+	for _, cond := range managedCluster.Status.Conditions {
+		if cond.Type == ManagedClusterConditionPendingApproval {
+			if cond.Status != status || cond.Reason != reason {
+				t.Errorf("expected pending approval condition %s/%s, but got %s/%s", status, reason, cond.Status, cond.Reason)
+			}
+			return
+		}
+	}
+	t.Errorf("expected pending approval condition, but not found")
+}
@@ -0,0 +1,3 @@
+// package acceptancereview contains the hub-side controller which gates the acceptance of the new managed
+// clusters on the approval of a reviewer
+package acceptancereview
@@ -8,15 +8,15 @@ import (
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
-	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/klog/v2"
+	"k8s.io/client-go/util/workqueue"
 
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
@@ -24,15 +24,25 @@ import (
 	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+	"open-cluster-management.io/registration/pkg/tracing"
 )
 
 const (
-	addOnFeaturePrefix     = "feature.open-cluster-management.io/addon-"
-	addOnStatusAvailable   = "available"
+	addOnStatusAvailable   = helpers.AddOnStatusAvailable
 	addOnStatusUnhealthy   = "unhealthy"
 	addOnStatusUnreachable = "unreachable"
+
+	// addOnFeatureLabelFieldManager is the field manager of the addon labels applied on the clusters
+	addOnFeatureLabelFieldManager = "addon-feature-discovery-controller"
 )
 
+// FeatureLabelDisabledAnnotation is the annotation of the ManagedClusterAddOn to opt out of the addon feature label
+// of the cluster, the existing label is removed if the annotation is set to "true"
+const FeatureLabelDisabledAnnotation = "addon.open-cluster-management.io/disable-feature-label"
+
 // addOnFeatureDiscoveryController monitors ManagedCluster and its ManagedClusterAddOns on hub and
 // create/update/delete labels of the ManagedCluster to reflect the status of addons.
 type addOnFeatureDiscoveryController struct {
@@ -40,6 +50,8 @@ type addOnFeatureDiscoveryController struct {
 	clusterLister clusterv1listers.ManagedClusterLister
 	addOnLister   addonlisterv1alpha1.ManagedClusterAddOnLister
 	recorder      events.Recorder
+	// statusSuffixes maps the addon condition types to the suffixes appended to the addon label values
+	statusSuffixes map[string]string
 }
 
 // NewAddOnFeatureDiscoveryController returns an instance of addOnFeatureDiscoveryController
@@ -47,33 +59,142 @@ func NewAddOnFeatureDiscoveryController(
 	clusterClient clientset.Interface,
 	clusterInformer clusterv1informer.ManagedClusterInformer,
 	addOnInformers addoninformerv1alpha1.ManagedClusterAddOnInformer,
+	statusSuffixes map[string]string,
 	recorder events.Recorder,
 ) factory.Controller {
 	c := &addOnFeatureDiscoveryController{
-		clusterClient: clusterClient,
-		clusterLister: clusterInformer.Lister(),
-		addOnLister:   addOnInformers.Lister(),
-		recorder:      recorder,
+		clusterClient:  clusterClient,
+		clusterLister:  clusterInformer.Lister(),
+		addOnLister:    addOnInformers.Lister(),
+		recorder:       recorder,
+		statusSuffixes: statusSuffixes,
+	}
+
+	syncCtx := factory.NewSyncContext("addon-feature-discovery-controller", recorder)
+	queue := syncCtx.Queue()
+
+	// the clusters and addons are updated frequently, e.g. with the status of the clusters and the heartbeats of
+	// the addons, only the changes which affect the addon labels of the clusters are handled, the others are
+	// reconciled by the resync.
+	_, err := clusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				utilruntime.HandleError(err)
+				return
+			}
+			queue.Add(accessor.GetName())
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldCluster, ok := oldObj.(*clusterv1.ManagedCluster)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("error to get object: %v", oldObj))
+				return
+			}
+			newCluster, ok := newObj.(*clusterv1.ManagedCluster)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("error to get object: %v", newObj))
+				return
+			}
+			if !labelsChanged(oldCluster.Labels, newCluster.Labels, helpers.AddOnFeatureLabelPrefix) {
+				return
+			}
+			queue.Add(newCluster.Name)
+		},
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+
+	_, err = addOnInformers.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			enqueueAddOn(queue, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldAddOn, ok := oldObj.(*addonv1alpha1.ManagedClusterAddOn)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("error to get object: %v", oldObj))
+				return
+			}
+			newAddOn, ok := newObj.(*addonv1alpha1.ManagedClusterAddOn)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("error to get object: %v", newObj))
+				return
+			}
+			if c.addOnLabelValue(oldAddOn) == c.addOnLabelValue(newAddOn) {
+				return
+			}
+			enqueueAddOn(queue, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			enqueueAddOn(queue, obj)
+		},
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
 	}
 
 	return factory.New().
-		WithInformersQueueKeyFunc(
-			func(obj runtime.Object) string {
-				accessor, _ := meta.Accessor(obj)
-				return accessor.GetName()
-			},
-			clusterInformer.Informer()).
-		WithInformersQueueKeyFunc(
-			func(obj runtime.Object) string {
-				key, _ := cache.MetaNamespaceKeyFunc(obj)
-				return key
-			},
-			addOnInformers.Informer()).
-		WithSync(c.sync).
+		WithSyncContext(syncCtx).
+		WithBareInformers(clusterInformer.Informer(), addOnInformers.Informer()).
+		WithSync(tracing.TraceSync("AddOnFeatureDiscoveryController", c.sync)).
 		ResyncEvery(10*time.Minute).
 		ToController("AddOnFeatureDiscoveryController", recorder)
 }
 
+// enqueueAddOn adds the namespace/name of the addon into the queue
+func enqueueAddOn(queue workqueue.RateLimitingInterface, obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	queue.Add(key)
+}
+
+// addOnLabelKey returns the key of the cluster label reflecting the status of the addon
+func addOnLabelKey(addOnName string) string {
+	return fmt.Sprintf("%s%s", helpers.AddOnFeatureLabelPrefix, addOnName)
+}
+
+// addOnLabelValue returns the value of the cluster label reflecting the status of the addon, it is empty if the
+// addon is deleting or opts out of the label
+func (c *addOnFeatureDiscoveryController) addOnLabelValue(addOn *addonv1alpha1.ManagedClusterAddOn) string {
+	if !addOn.DeletionTimestamp.IsZero() || featureLabelDisabled(addOn) {
+		return ""
+	}
+	return getAddOnLabelValue(addOn, c.statusSuffixes)
+}
+
+// featureLabelDisabled returns true if the addon opts out of the feature label
+func featureLabelDisabled(addOn *addonv1alpha1.ManagedClusterAddOn) bool {
+	return addOn.Annotations[FeatureLabelDisabledAnnotation] == "true"
+}
+
+// labelsChanged returns true if the labels with the prefix are changed, the other labels are ignored
+func labelsChanged(oldLabels, newLabels map[string]string, prefix string) bool {
+	for key, value := range oldLabels {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if newValue, ok := newLabels[key]; !ok || newValue != value {
+			return true
+		}
+	}
+	for key := range newLabels {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if _, ok := oldLabels[key]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *addOnFeatureDiscoveryController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
 	// The value of queueKey might be
 	// 1) equal to the default queuekey. It is triggered by resync every 10 minutes;
@@ -108,51 +229,12 @@ func (c *addOnFeatureDiscoveryController) sync(ctx context.Context, syncCtx fact
 }
 
 func (c *addOnFeatureDiscoveryController) syncAddOn(ctx context.Context, clusterName, addOnName string) error {
-	klog.V(4).Infof("Reconciling addOn %q", addOnName)
+	ctx, logger := logging.WithAddOn(ctx, clusterName, addOnName)
+	logger.V(4).Info("Reconciling addOn")
 
-	labels := map[string]string{}
-	addOn, err := c.addOnLister.ManagedClusterAddOns(clusterName).Get(addOnName)
-	switch {
-	case errors.IsNotFound(err):
-		// addon is deleted
-		key := fmt.Sprintf("%s%s-", addOnFeaturePrefix, addOnName)
-		labels[key] = ""
-	case err != nil:
-		return err
-	case !addOn.DeletionTimestamp.IsZero():
-		key := fmt.Sprintf("%s%s-", addOnFeaturePrefix, addOnName)
-		labels[key] = ""
-	default:
-		key := fmt.Sprintf("%s%s", addOnFeaturePrefix, addOn.Name)
-		labels[key] = getAddOnLabelValue(addOn)
-	}
-
-	cluster, err := c.clusterLister.Get(clusterName)
-	if errors.IsNotFound(err) {
-		// no cluster, it could be deleted
-		return nil
-	}
-	if err != nil {
-		return fmt.Errorf("unable to find cluster with name %q: %w", clusterName, err)
-	}
-	// no work if cluster is deleting
-	if !cluster.DeletionTimestamp.IsZero() {
-		return nil
-	}
-
-	// merge labels
-	modified := false
-	cluster = cluster.DeepCopy()
-	resourcemerge.MergeMap(&modified, &cluster.Labels, labels)
-
-	// no work if the cluster labels have no change
-	if !modified {
-		return nil
-	}
-
-	// otherwise, update cluster
-	_, err = c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, cluster, metav1.UpdateOptions{})
-	return err
+	// the labels of all the addons of the cluster are applied together, since the addon labels which are not in
+	// the applied labels are removed
+	return c.syncCluster(ctx, clusterName)
 }
 
 func (c *addOnFeatureDiscoveryController) syncCluster(ctx context.Context, clusterName string) error {
@@ -165,6 +247,7 @@ func (c *addOnFeatureDiscoveryController) syncCluster(ctx context.Context, clust
 	if err != nil {
 		return err
 	}
+	tracing.SetCluster(ctx, clusterName, cluster.CreationTimestamp)
 
 	// Do not update addon label if cluster is deleting
 	if !cluster.DeletionTimestamp.IsZero() {
@@ -178,52 +261,70 @@ func (c *addOnFeatureDiscoveryController) syncCluster(ctx context.Context, clust
 		return fmt.Errorf("unable to list addOns of cluster %q: %w", clusterName, err)
 	}
 	for _, addOn := range addOns {
-		// addon is deleting
-		if !addOn.DeletionTimestamp.IsZero() {
+		// addon is deleting or opts out of the feature label
+		if !addOn.DeletionTimestamp.IsZero() || featureLabelDisabled(addOn) {
 			continue
 		}
-		key := fmt.Sprintf("%s%s", addOnFeaturePrefix, addOn.Name)
-		addOnLabels[key] = getAddOnLabelValue(addOn)
+		addOnLabels[addOnLabelKey(addOn.Name)] = getAddOnLabelValue(addOn, c.statusSuffixes)
 	}
 
 	// remove addon lable if its corresponding addon no longer exists
+	staleKeys := []string{}
 	for key := range cluster.Labels {
-		if !strings.HasPrefix(key, addOnFeaturePrefix) {
+		if !strings.HasPrefix(key, helpers.AddOnFeatureLabelPrefix) {
 			continue
 		}
 
 		if _, ok := addOnLabels[key]; !ok {
-			addOnLabels[fmt.Sprintf("%s-", key)] = ""
+			staleKeys = append(staleKeys, key)
 		}
 	}
 
-	// merge labels
-	modified := false
-	cluster = cluster.DeepCopy()
-	resourcemerge.MergeMap(&modified, &cluster.Labels, addOnLabels)
-
-	// no work if the cluster labels have no change
-	if !modified {
-		return nil
-	}
-
-	// otherwise, update cluster
-	_, err = c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, cluster, metav1.UpdateOptions{})
+	_, err = helpers.ApplyManagedClusterLabels(ctx, c.clusterClient, cluster, addOnFeatureLabelFieldManager,
+		addOnLabels, staleKeys...)
 	return err
 }
 
-func getAddOnLabelValue(addOn *addonv1alpha1.ManagedClusterAddOn) string {
+// getAddOnLabelValue returns the label value of the addon status. It is derived from the Available condition, and
+// the suffixes of the other true conditions in the status suffixes are appended to the value of a reachable addon
+// in the order of the condition types, e.g. available-degraded.
+func getAddOnLabelValue(addOn *addonv1alpha1.ManagedClusterAddOn, statusSuffixes map[string]string) string {
 	availableCondition := meta.FindStatusCondition(addOn.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable)
 	if availableCondition == nil {
 		return addOnStatusUnreachable
 	}
 
+	var value string
 	switch availableCondition.Status {
 	case metav1.ConditionTrue:
-		return addOnStatusAvailable
+		value = addOnStatusAvailable
 	case metav1.ConditionFalse:
-		return addOnStatusUnhealthy
+		value = addOnStatusUnhealthy
 	default:
 		return addOnStatusUnreachable
 	}
+
+	for _, conditionType := range sets.List(sets.KeySet(statusSuffixes)) {
+		if meta.IsStatusConditionTrue(addOn.Status.Conditions, conditionType) {
+			value = fmt.Sprintf("%s-%s", value, statusSuffixes[conditionType])
+		}
+	}
+	return value
+}
+
+// ValidateStatusSuffixes verifies the suffixes of the addon label values, the label value with all of the suffixes
+// must be a valid label value
+func ValidateStatusSuffixes(statusSuffixes map[string]string) error {
+	value := addOnStatusUnhealthy
+	for _, conditionType := range sets.List(sets.KeySet(statusSuffixes)) {
+		suffix := statusSuffixes[conditionType]
+		if len(conditionType) == 0 || len(suffix) == 0 {
+			return fmt.Errorf("the condition type and suffix of the addon status must not be empty")
+		}
+		value = fmt.Sprintf("%s-%s", value, suffix)
+	}
+	if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+		return fmt.Errorf("the addon label value %q with the status suffixes is invalid: %s", value, strings.Join(errs, "; "))
+	}
+	return nil
 }
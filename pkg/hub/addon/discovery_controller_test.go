@@ -16,6 +16,7 @@ import (
 	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 )
 
@@ -23,6 +24,7 @@ func TestGetAddOnLabelValue(t *testing.T) {
 	cases := []struct {
 		name            string
 		addOnConditions []metav1.Condition
+		statusSuffixes  map[string]string
 		expectedValue   string
 	}{
 		{
@@ -59,6 +61,75 @@ func TestGetAddOnLabelValue(t *testing.T) {
 			},
 			expectedValue: addOnStatusUnreachable,
 		},
+		{
+			name: "degraded is ignored without status suffixes",
+			addOnConditions: []metav1.Condition{
+				{
+					Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+					Status: metav1.ConditionTrue,
+				},
+				{
+					Type:   addonv1alpha1.ManagedClusterAddOnConditionDegraded,
+					Status: metav1.ConditionTrue,
+				},
+			},
+			expectedValue: addOnStatusAvailable,
+		},
+		{
+			name: "available and degraded",
+			addOnConditions: []metav1.Condition{
+				{
+					Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+					Status: metav1.ConditionTrue,
+				},
+				{
+					Type:   addonv1alpha1.ManagedClusterAddOnConditionDegraded,
+					Status: metav1.ConditionTrue,
+				},
+				{
+					Type:   addonv1alpha1.ManagedClusterAddOnConditionProgressing,
+					Status: metav1.ConditionFalse,
+				},
+			},
+			statusSuffixes: map[string]string{
+				addonv1alpha1.ManagedClusterAddOnConditionDegraded:    "degraded",
+				addonv1alpha1.ManagedClusterAddOnConditionProgressing: "progressing",
+			},
+			expectedValue: "available-degraded",
+		},
+		{
+			name: "unhealthy, degraded and progressing",
+			addOnConditions: []metav1.Condition{
+				{
+					Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+					Status: metav1.ConditionFalse,
+				},
+				{
+					Type:   addonv1alpha1.ManagedClusterAddOnConditionProgressing,
+					Status: metav1.ConditionTrue,
+				},
+				{
+					Type:   addonv1alpha1.ManagedClusterAddOnConditionDegraded,
+					Status: metav1.ConditionTrue,
+				},
+			},
+			statusSuffixes: map[string]string{
+				addonv1alpha1.ManagedClusterAddOnConditionDegraded:    "degraded",
+				addonv1alpha1.ManagedClusterAddOnConditionProgressing: "progressing",
+			},
+			expectedValue: "unhealthy-degraded-progressing",
+		},
+		{
+			name: "unreachable has no suffix",
+			addOnConditions: []metav1.Condition{
+				{
+					Type:   addonv1alpha1.ManagedClusterAddOnConditionDegraded,
+					Status: metav1.ConditionTrue,
+				},
+			},
+			statusSuffixes: map[string]string{addonv1alpha1.ManagedClusterAddOnConditionDegraded: "degraded"},
+			expectedValue:  addOnStatusUnreachable,
+		},
 	}
 
 	for _, c := range cases {
@@ -69,7 +140,7 @@ func TestGetAddOnLabelValue(t *testing.T) {
 				},
 			}
 
-			value := getAddOnLabelValue(addOn)
+			value := getAddOnLabelValue(addOn, c.statusSuffixes)
 			if c.expectedValue != value {
 				t.Errorf("expected %q but get %q", c.expectedValue, value)
 			}
@@ -77,6 +148,40 @@ func TestGetAddOnLabelValue(t *testing.T) {
 	}
 }
 
+func TestValidateStatusSuffixes(t *testing.T) {
+	cases := []struct {
+		name           string
+		statusSuffixes map[string]string
+		expectedErr    string
+	}{
+		{
+			name: "no suffix",
+		},
+		{
+			name:           "valid suffixes",
+			statusSuffixes: map[string]string{"Degraded": "degraded", "Progressing": "progressing"},
+		},
+		{
+			name:           "empty suffix",
+			statusSuffixes: map[string]string{"Degraded": ""},
+			expectedErr:    "the condition type and suffix of the addon status must not be empty",
+		},
+		{
+			name:           "invalid suffix",
+			statusSuffixes: map[string]string{"Degraded": "degraded/"},
+			expectedErr: "the addon label value \"unhealthy-degraded/\" with the status suffixes is invalid: " +
+				"a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', " +
+				"and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', " +
+				"regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testinghelpers.AssertError(t, ValidateStatusSuffixes(c.statusSuffixes), c.expectedErr)
+		})
+	}
+}
+
 func TestDiscoveryController_SyncAddOn(t *testing.T) {
 	clusterName := "cluster1"
 	deleteTime := metav1.Now()
@@ -87,6 +192,7 @@ func TestDiscoveryController_SyncAddOn(t *testing.T) {
 		cluster         *clusterv1.ManagedCluster
 		addOn           *addonv1alpha1.ManagedClusterAddOn
 		validateActions func(t *testing.T, actions []clienttesting.Action)
+		validateCluster func(t *testing.T, cluster *clusterv1.ManagedCluster)
 	}{
 		{
 			name:      "addon is deleted",
@@ -100,9 +206,10 @@ func TestDiscoveryController_SyncAddOn(t *testing.T) {
 				},
 			},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testinghelpers.AssertActions(t, actions, "update")
-				actual := actions[0].(clienttesting.UpdateActionImpl).Object
-				assertNoAddonLabel(t, actual.(*clusterv1.ManagedCluster), "addon1")
+				testinghelpers.AssertActions(t, actions, "patch", "patch")
+			},
+			validateCluster: func(t *testing.T, cluster *clusterv1.ManagedCluster) {
+				assertNoAddonLabel(t, cluster, "addon1")
 			},
 		},
 		{
@@ -136,9 +243,10 @@ func TestDiscoveryController_SyncAddOn(t *testing.T) {
 				},
 			},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testinghelpers.AssertActions(t, actions, "update")
-				actual := actions[0].(clienttesting.UpdateActionImpl).Object
-				assertAddonLabel(t, actual.(*clusterv1.ManagedCluster), "addon1", addOnStatusUnreachable)
+				testinghelpers.AssertActions(t, actions, "patch")
+			},
+			validateCluster: func(t *testing.T, cluster *clusterv1.ManagedCluster) {
+				assertAddonLabel(t, cluster, "addon1", addOnStatusUnreachable)
 			},
 		},
 		{
@@ -159,9 +267,35 @@ func TestDiscoveryController_SyncAddOn(t *testing.T) {
 				},
 			},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testinghelpers.AssertActions(t, actions, "update")
-				actual := actions[0].(clienttesting.UpdateActionImpl).Object
-				assertAddonLabel(t, actual.(*clusterv1.ManagedCluster), "addon1", addOnStatusUnreachable)
+				testinghelpers.AssertActions(t, actions, "patch")
+			},
+			validateCluster: func(t *testing.T, cluster *clusterv1.ManagedCluster) {
+				assertAddonLabel(t, cluster, "addon1", addOnStatusUnreachable)
+			},
+		},
+		{
+			name:      "addon opts out of the feature label",
+			addOnName: "addon1",
+			cluster: &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: clusterName,
+					Labels: map[string]string{
+						"feature.open-cluster-management.io/addon-addon1": addOnStatusAvailable,
+					},
+				},
+			},
+			addOn: &addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "addon1",
+					Namespace:   clusterName,
+					Annotations: map[string]string{FeatureLabelDisabledAnnotation: "true"},
+				},
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch", "patch")
+			},
+			validateCluster: func(t *testing.T, cluster *clusterv1.ManagedCluster) {
+				assertNoAddonLabel(t, cluster, "addon1")
 			},
 		},
 		{
@@ -225,6 +359,13 @@ func TestDiscoveryController_SyncAddOn(t *testing.T) {
 			}
 
 			c.validateActions(t, clusterClient.Actions())
+			if c.validateCluster != nil {
+				cluster, err := clusterClient.ClusterV1().ManagedClusters().Get(context.Background(), clusterName, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				c.validateCluster(t, cluster)
+			}
 		})
 	}
 }
@@ -239,6 +380,7 @@ func TestDiscoveryController_Sync(t *testing.T) {
 		cluster         *clusterv1.ManagedCluster
 		addOns          []*addonv1alpha1.ManagedClusterAddOn
 		validateActions func(t *testing.T, actions []clienttesting.Action)
+		validateCluster func(t *testing.T, cluster *clusterv1.ManagedCluster)
 	}{
 		{
 			name:     "addon synced",
@@ -257,9 +399,10 @@ func TestDiscoveryController_Sync(t *testing.T) {
 				},
 			},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testinghelpers.AssertActions(t, actions, "update")
-				actual := actions[0].(clienttesting.UpdateActionImpl).Object
-				assertAddonLabel(t, actual.(*clusterv1.ManagedCluster), "addon1", addOnStatusUnreachable)
+				testinghelpers.AssertActions(t, actions, "patch")
+			},
+			validateCluster: func(t *testing.T, cluster *clusterv1.ManagedCluster) {
+				assertAddonLabel(t, cluster, "addon1", addOnStatusUnreachable)
 			},
 		},
 		{
@@ -296,6 +439,7 @@ func TestDiscoveryController_Sync(t *testing.T) {
 					Name: clusterName,
 					Labels: map[string]string{
 						"feature.open-cluster-management.io/addon-addon4": "available",
+						"feature.open-cluster-management.io/addon-addon5": "available",
 					},
 				},
 			},
@@ -327,13 +471,22 @@ func TestDiscoveryController_Sync(t *testing.T) {
 						},
 					},
 				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "addon5",
+						Namespace:   clusterName,
+						Annotations: map[string]string{FeatureLabelDisabledAnnotation: "true"},
+					},
+				},
 			},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testinghelpers.AssertActions(t, actions, "update")
-				actual := actions[0].(clienttesting.UpdateActionImpl).Object
-				assertAddonLabel(t, actual.(*clusterv1.ManagedCluster), "addon1", addOnStatusUnreachable)
-				assertAddonLabel(t, actual.(*clusterv1.ManagedCluster), "addon3", addOnStatusAvailable)
-				assertNoAddonLabel(t, actual.(*clusterv1.ManagedCluster), "addon4")
+				testinghelpers.AssertActions(t, actions, "patch", "patch")
+			},
+			validateCluster: func(t *testing.T, cluster *clusterv1.ManagedCluster) {
+				assertAddonLabel(t, cluster, "addon1", addOnStatusUnreachable)
+				assertAddonLabel(t, cluster, "addon3", addOnStatusAvailable)
+				assertNoAddonLabel(t, cluster, "addon4")
+				assertNoAddonLabel(t, cluster, "addon5")
 			},
 		},
 	}
@@ -380,12 +533,19 @@ func TestDiscoveryController_Sync(t *testing.T) {
 			}
 
 			c.validateActions(t, clusterClient.Actions())
+			if c.validateCluster != nil {
+				cluster, err := clusterClient.ClusterV1().ManagedClusters().Get(context.Background(), clusterName, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				c.validateCluster(t, cluster)
+			}
 		})
 	}
 }
 
 func assertAddonLabel(t *testing.T, cluster *clusterv1.ManagedCluster, addOnName, addOnStatus string) {
-	key := fmt.Sprintf("%s%s", addOnFeaturePrefix, addOnName)
+	key := fmt.Sprintf("%s%s", helpers.AddOnFeatureLabelPrefix, addOnName)
 	value, ok := cluster.Labels[key]
 	if !ok {
 		t.Errorf("label %q not found", key)
@@ -397,8 +557,49 @@ func assertAddonLabel(t *testing.T, cluster *clusterv1.ManagedCluster, addOnName
 }
 
 func assertNoAddonLabel(t *testing.T, cluster *clusterv1.ManagedCluster, addOnName string) {
-	key := fmt.Sprintf("%s%s", addOnFeaturePrefix, addOnName)
+	key := fmt.Sprintf("%s%s", helpers.AddOnFeatureLabelPrefix, addOnName)
 	if _, ok := cluster.Labels[key]; ok {
 		t.Errorf("label %q found", key)
 	}
 }
+
+func TestLabelsChanged(t *testing.T) {
+	cases := []struct {
+		name            string
+		oldLabels       map[string]string
+		newLabels       map[string]string
+		expectedChanged bool
+	}{
+		{
+			name:      "other labels are changed",
+			oldLabels: map[string]string{"env": "dev", helpers.AddOnFeatureLabelPrefix + "foo": addOnStatusAvailable},
+			newLabels: map[string]string{"env": "prod", helpers.AddOnFeatureLabelPrefix + "foo": addOnStatusAvailable},
+		},
+		{
+			name:            "addon label is added",
+			oldLabels:       map[string]string{"env": "dev"},
+			newLabels:       map[string]string{"env": "dev", helpers.AddOnFeatureLabelPrefix + "foo": addOnStatusAvailable},
+			expectedChanged: true,
+		},
+		{
+			name:            "addon label is removed",
+			oldLabels:       map[string]string{helpers.AddOnFeatureLabelPrefix + "foo": addOnStatusAvailable},
+			newLabels:       map[string]string{},
+			expectedChanged: true,
+		},
+		{
+			name:            "addon label is updated",
+			oldLabels:       map[string]string{helpers.AddOnFeatureLabelPrefix + "foo": addOnStatusAvailable},
+			newLabels:       map[string]string{helpers.AddOnFeatureLabelPrefix + "foo": addOnStatusUnhealthy},
+			expectedChanged: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			changed := labelsChanged(c.oldLabels, c.newLabels, helpers.AddOnFeatureLabelPrefix)
+			if changed != c.expectedChanged {
+				t.Errorf("expected %v, but got %v", c.expectedChanged, changed)
+			}
+		})
+	}
+}
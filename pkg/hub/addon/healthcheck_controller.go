@@ -12,6 +12,7 @@ import (
 	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -47,7 +48,7 @@ func NewManagedClusterAddOnHealthCheckController(addOnClient addonclient.Interfa
 			accessor, _ := meta.Accessor(obj)
 			return accessor.GetName()
 		}, clusterInformer.Informer()).
-		WithSync(c.sync).
+		WithSync(logging.SyncWithLogger("ManagedClusterAddonHealthCheckController", c.sync)).
 		ToController("ManagedClusterAddonHealthCheckController", recorder)
 }
 
@@ -0,0 +1,252 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
+	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1beta1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1beta1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+const (
+	// InstallLabelPrefix is the prefix of the cluster labels of the addons which should be installed on the
+	// cluster, the label key is the prefix followed by the name of the ClusterManagementAddOn
+	InstallLabelPrefix = "addon.open-cluster-management.io/"
+	// InstallLabelValue is the value of the install labels
+	InstallLabelValue = "enabled"
+
+	// installLabelFieldManager is the field manager of the install labels applied on the clusters
+	installLabelFieldManager = "addon-install-label-controller"
+)
+
+// addOnInstallLabelController labels the ManagedClusters selected by the placements in the install strategy of the
+// ClusterManagementAddOns with addon.open-cluster-management.io/<addon name>=enabled, and removes the label once the
+// cluster is no longer selected, so the addon frameworks know which clusters should run the addons.
+type addOnInstallLabelController struct {
+	clusterClient  clientset.Interface
+	clusterLister  clusterv1listers.ManagedClusterLister
+	cmaLister      addonlisterv1alpha1.ClusterManagementAddOnLister
+	decisionLister clusterv1beta1listers.PlacementDecisionLister
+	recorder       events.Recorder
+}
+
+// NewAddOnInstallLabelController returns an instance of addOnInstallLabelController
+func NewAddOnInstallLabelController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	cmaInformer addoninformerv1alpha1.ClusterManagementAddOnInformer,
+	decisionInformer clusterv1beta1informer.PlacementDecisionInformer,
+	recorder events.Recorder,
+) factory.Controller {
+	c := &addOnInstallLabelController{
+		clusterClient:  clusterClient,
+		clusterLister:  clusterInformer.Lister(),
+		cmaLister:      cmaInformer.Lister(),
+		decisionLister: decisionInformer.Lister(),
+		recorder:       recorder,
+	}
+
+	syncCtx := factory.NewSyncContext("addon-install-label-controller", recorder)
+	queue := syncCtx.Queue()
+
+	_, err := clusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				utilruntime.HandleError(err)
+				return
+			}
+			queue.Add(accessor.GetName())
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldCluster, ok := oldObj.(*clusterv1.ManagedCluster)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("error to get object: %v", oldObj))
+				return
+			}
+			newCluster, ok := newObj.(*clusterv1.ManagedCluster)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("error to get object: %v", newObj))
+				return
+			}
+			// only the changes of the install labels are handled
+			if !labelsChanged(oldCluster.Labels, newCluster.Labels, InstallLabelPrefix) {
+				return
+			}
+			queue.Add(newCluster.Name)
+		},
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+
+	// the clusters which are added into or removed from a placement decision are synced
+	_, err = decisionInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			enqueueDecisionClusters(queue, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			enqueueDecisionClusters(queue, oldObj)
+			enqueueDecisionClusters(queue, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			enqueueDecisionClusters(queue, obj)
+		},
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+
+	return factory.New().
+		WithSyncContext(syncCtx).
+		WithBareInformers(clusterInformer.Informer(), decisionInformer.Informer()).
+		// the change of a ClusterManagementAddOn might affect all of the clusters
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			return factory.DefaultQueueKey
+		}, cmaInformer.Informer()).
+		WithSync(logging.SyncWithLogger("AddOnInstallLabelController", c.sync)).
+		ResyncEvery(10*time.Minute).
+		ToController("AddOnInstallLabelController", recorder)
+}
+
+// enqueueDecisionClusters adds the clusters in the placement decision into the queue
+func enqueueDecisionClusters(queue workqueue.RateLimitingInterface, obj interface{}) {
+	decision, ok := obj.(*clusterv1beta1.PlacementDecision)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("error to get object: %v", obj))
+		return
+	}
+	for _, d := range decision.Status.Decisions {
+		queue.Add(d.ClusterName)
+	}
+}
+
+func (c *addOnInstallLabelController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	if clusterName == factory.DefaultQueueKey {
+		clusters, err := c.clusterLister.List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		for _, cluster := range clusters {
+			syncCtx.Queue().Add(cluster.Name)
+		}
+		return nil
+	}
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling addon install labels of ManagedCluster")
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// cluster is deleted
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	addOnNames, err := c.installedAddOns(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+
+	installLabels := map[string]string{}
+	for addOnName := range addOnNames {
+		installLabels[InstallLabelPrefix+addOnName] = InstallLabelValue
+	}
+	// remove the install labels of the addons which should not be installed on the cluster any more
+	staleKeys := []string{}
+	for key, value := range cluster.Labels {
+		if !strings.HasPrefix(key, InstallLabelPrefix) || value != InstallLabelValue {
+			continue
+		}
+		if !addOnNames.Has(strings.TrimPrefix(key, InstallLabelPrefix)) {
+			staleKeys = append(staleKeys, key)
+		}
+	}
+
+	_, err = helpers.ApplyManagedClusterLabels(ctx, c.clusterClient, cluster, installLabelFieldManager,
+		installLabels, staleKeys...)
+	return err
+}
+
+// installedAddOns returns the names of the ClusterManagementAddOns whose placements select the cluster
+func (c *addOnInstallLabelController) installedAddOns(ctx context.Context, clusterName string) (sets.Set[string], error) {
+	addOnNames := sets.New[string]()
+
+	cmas, err := c.cmaLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, cma := range cmas {
+		if cma.Spec.InstallStrategy.Type != addonv1alpha1.AddonInstallStrategyPlacements {
+			continue
+		}
+		// the name of the addon must be a valid label name
+		if errs := validation.IsQualifiedName(InstallLabelPrefix + cma.Name); len(errs) > 0 {
+			klog.FromContext(ctx).Info("ClusterManagementAddOn cannot be labeled on the clusters",
+				"clusterManagementAddOn", cma.Name, "reason", strings.Join(errs, "; "))
+			continue
+		}
+
+		for _, placement := range cma.Spec.InstallStrategy.Placements {
+			selected, err := c.placementSelects(placement.PlacementRef, clusterName)
+			if err != nil {
+				return nil, err
+			}
+			if selected {
+				addOnNames.Insert(cma.Name)
+				break
+			}
+		}
+	}
+	return addOnNames, nil
+}
+
+// placementSelects returns true if the cluster is in the decisions of the placement
+func (c *addOnInstallLabelController) placementSelects(placement addonv1alpha1.PlacementRef, clusterName string) (bool, error) {
+	selector := labels.SelectorFromSet(labels.Set{clusterv1beta1.PlacementLabel: placement.Name})
+	decisions, err := c.decisionLister.PlacementDecisions(placement.Namespace).List(selector)
+	if err != nil {
+		return false, err
+	}
+	for _, decision := range decisions {
+		for _, d := range decision.Status.Decisions {
+			if d.ClusterName == clusterName {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,172 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clienttesting "k8s.io/client-go/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func newClusterManagementAddOn(name string, placements ...addonv1alpha1.PlacementRef) *addonv1alpha1.ClusterManagementAddOn {
+	cma := &addonv1alpha1.ClusterManagementAddOn{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: addonv1alpha1.ClusterManagementAddOnSpec{
+			InstallStrategy: addonv1alpha1.InstallStrategy{Type: addonv1alpha1.AddonInstallStrategyManual},
+		},
+	}
+	if len(placements) > 0 {
+		cma.Spec.InstallStrategy.Type = addonv1alpha1.AddonInstallStrategyPlacements
+	}
+	for _, placement := range placements {
+		cma.Spec.InstallStrategy.Placements = append(cma.Spec.InstallStrategy.Placements,
+			addonv1alpha1.PlacementStrategy{PlacementRef: placement})
+	}
+	return cma
+}
+
+func newPlacementDecision(namespace, placement string, clusterNames ...string) *clusterv1beta1.PlacementDecision {
+	decision := &clusterv1beta1.PlacementDecision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      placement + "-decision-1",
+			Namespace: namespace,
+			Labels:    map[string]string{clusterv1beta1.PlacementLabel: placement},
+		},
+	}
+	for _, clusterName := range clusterNames {
+		decision.Status.Decisions = append(decision.Status.Decisions, clusterv1beta1.ClusterDecision{ClusterName: clusterName})
+	}
+	return decision
+}
+
+func TestAddOnInstallLabelController_Sync(t *testing.T) {
+	clusterName := testinghelpers.TestManagedClusterName
+	placement := addonv1alpha1.PlacementRef{Namespace: "default", Name: "all"}
+
+	cases := []struct {
+		name            string
+		clusterLabels   map[string]string
+		cmas            []*addonv1alpha1.ClusterManagementAddOn
+		decisions       []*clusterv1beta1.PlacementDecision
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+		validateCluster func(t *testing.T, cluster *clusterv1.ManagedCluster)
+	}{
+		{
+			name: "cluster is selected",
+			cmas: []*addonv1alpha1.ClusterManagementAddOn{newClusterManagementAddOn("addon1", placement)},
+			decisions: []*clusterv1beta1.PlacementDecision{
+				newPlacementDecision(placement.Namespace, placement.Name, "cluster0", clusterName),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch")
+			},
+			validateCluster: func(t *testing.T, cluster *clusterv1.ManagedCluster) {
+				if cluster.Labels[InstallLabelPrefix+"addon1"] != InstallLabelValue {
+					t.Errorf("expected the install label, but got %v", cluster.Labels)
+				}
+			},
+		},
+		{
+			name:          "cluster is already labeled",
+			clusterLabels: map[string]string{InstallLabelPrefix + "addon1": InstallLabelValue},
+			cmas:          []*addonv1alpha1.ClusterManagementAddOn{newClusterManagementAddOn("addon1", placement)},
+			decisions: []*clusterv1beta1.PlacementDecision{
+				newPlacementDecision(placement.Namespace, placement.Name, clusterName),
+			},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:          "cluster is not selected any more",
+			clusterLabels: map[string]string{InstallLabelPrefix + "addon1": InstallLabelValue, "env": "dev"},
+			cmas:          []*addonv1alpha1.ClusterManagementAddOn{newClusterManagementAddOn("addon1", placement)},
+			decisions: []*clusterv1beta1.PlacementDecision{
+				newPlacementDecision(placement.Namespace, placement.Name, "cluster0"),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				// the label set before the install label controller owns it is removed with a merge patch
+				testinghelpers.AssertActions(t, actions, "patch", "patch")
+			},
+			validateCluster: func(t *testing.T, cluster *clusterv1.ManagedCluster) {
+				if _, ok := cluster.Labels[InstallLabelPrefix+"addon1"]; ok || cluster.Labels["env"] != "dev" {
+					t.Errorf("expected the install label is removed, but got %v", cluster.Labels)
+				}
+			},
+		},
+		{
+			name:          "manual install strategy",
+			clusterLabels: map[string]string{InstallLabelPrefix + "addon1": InstallLabelValue},
+			cmas:          []*addonv1alpha1.ClusterManagementAddOn{newClusterManagementAddOn("addon1")},
+			decisions: []*clusterv1beta1.PlacementDecision{
+				newPlacementDecision(placement.Namespace, placement.Name, clusterName),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch", "patch")
+			},
+			validateCluster: func(t *testing.T, cluster *clusterv1.ManagedCluster) {
+				if _, ok := cluster.Labels[InstallLabelPrefix+"addon1"]; ok {
+					t.Errorf("expected the install label is removed, but got %v", cluster.Labels)
+				}
+			},
+		},
+		{
+			name:            "labels with other values are kept",
+			clusterLabels:   map[string]string{InstallLabelPrefix + "addon2": "custom"},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewManagedCluster()
+			cluster.Labels = c.clusterLabels
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+				t.Fatal(err)
+			}
+			decisionStore := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore()
+			for _, decision := range c.decisions {
+				if err := decisionStore.Add(decision); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			addOnInformerFactory := addoninformers.NewSharedInformerFactory(addonfake.NewSimpleClientset(), 10*time.Minute)
+			cmaStore := addOnInformerFactory.Addon().V1alpha1().ClusterManagementAddOns().Informer().GetStore()
+			for _, cma := range c.cmas {
+				if err := cmaStore.Add(cma); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			controller := addOnInstallLabelController{
+				clusterClient:  clusterClient,
+				clusterLister:  clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				cmaLister:      addOnInformerFactory.Addon().V1alpha1().ClusterManagementAddOns().Lister(),
+				decisionLister: clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister(),
+			}
+
+			if err := controller.sync(context.Background(), testinghelpers.NewFakeSyncContext(t, clusterName)); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			c.validateActions(t, clusterClient.Actions())
+			if c.validateCluster != nil {
+				cluster, err := clusterClient.ClusterV1().ManagedClusters().Get(context.Background(), clusterName, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				c.validateCluster(t, cluster)
+			}
+		})
+	}
+}
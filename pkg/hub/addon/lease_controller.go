@@ -0,0 +1,159 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
+	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
+	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coordinformers "k8s.io/client-go/informers/coordination/v1"
+	coordlisters "k8s.io/client-go/listers/coordination/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// addOnLeaseDurationTimes is the multiple of the lease duration seconds of an addon which is its grace period
+	addOnLeaseDurationTimes = 5
+	// addOnLeaseExpiredReason is the reason of the Available condition set to Unknown by the addon lease controller,
+	// only the condition with this reason is set back to True once the lease is renewed.
+	addOnLeaseExpiredReason = "ManagedClusterAddOnLeaseExpired"
+)
+
+// AddOnLeaseDurationSeconds is the default lease duration seconds of the addons renewing their leases on the hub, an
+// addon can adjust it with the addon.open-cluster-management.io/lease-duration-seconds annotation. It is exposed so
+// that integration tests can crank up the lease update speed.
+var AddOnLeaseDurationSeconds = 60
+
+// addOnLeaseController watches the leases renewed by the addon agents in the cluster namespaces on the hub, the name
+// of a lease is the name of its addon. The registration agent reports the availability of the addons with their
+// leases, so it goes stale once the agents die abruptly. The hub sets the Available condition of an available addon
+// to Unknown once its lease is not renewed within the grace period of the addon, and sets it back to True once the
+// lease is renewed again. The addons are requeued at the time their leases expire.
+type addOnLeaseController struct {
+	addOnClient   addonclient.Interface
+	addOnLister   addonlisterv1alpha1.ManagedClusterAddOnLister
+	leaseLister   coordlisters.LeaseLister
+	clock         clock.Clock
+	eventRecorder events.Recorder
+}
+
+// NewAddOnLeaseController returns an instance of addOnLeaseController
+func NewAddOnLeaseController(
+	addOnClient addonclient.Interface,
+	addOnInformer addoninformerv1alpha1.ManagedClusterAddOnInformer,
+	leaseInformer coordinformers.LeaseInformer,
+	clock clock.Clock,
+	recorder events.Recorder) factory.Controller {
+	c := &addOnLeaseController{
+		addOnClient:   addOnClient,
+		addOnLister:   addOnInformer.Lister(),
+		leaseLister:   leaseInformer.Lister(),
+		clock:         clock,
+		eventRecorder: recorder.WithComponentSuffix("addon-lease-controller"),
+	}
+
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			key, _ := cache.MetaNamespaceKeyFunc(obj)
+			return key
+		}, addOnInformer.Informer(), leaseInformer.Informer()).
+		WithSync(logging.SyncWithLogger("ManagedClusterAddOnLeaseController", c.sync)).
+		ToController("ManagedClusterAddOnLeaseController", recorder)
+}
+
+func (c *addOnLeaseController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	queueKey := syncCtx.QueueKey()
+	clusterName, addOnName, err := cache.SplitMetaNamespaceKey(queueKey)
+	if err != nil || len(clusterName) == 0 {
+		// the queue key is not an addon, ignore it.
+		return nil
+	}
+	ctx, logger := logging.WithAddOn(ctx, clusterName, addOnName)
+	logger.V(4).Info("Reconciling addOn lease")
+
+	addOn, err := c.addOnLister.ManagedClusterAddOns(clusterName).Get(addOnName)
+	if errors.IsNotFound(err) {
+		// the lease is not of an addon, or the addon is deleted
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// the health of the addons in the Customized mode is checked by the addon managers
+	if !addOn.DeletionTimestamp.IsZero() || addOn.Status.HealthCheck.Mode == addonv1alpha1.HealthCheckModeCustomized {
+		return nil
+	}
+
+	lease, err := c.leaseLister.Leases(clusterName).Get(addOnName)
+	if errors.IsNotFound(err) {
+		// the addon does not renew its lease on the hub
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if lease.Spec.RenewTime == nil {
+		return nil
+	}
+
+	gracePeriod := time.Duration(addOnLeaseDurationTimes*
+		helpers.GetAddOnLeaseDurationSeconds(addOn, AddOnLeaseDurationSeconds)) * time.Second
+	expireTime := lease.Spec.RenewTime.Add(gracePeriod)
+	now := c.clock.Now()
+	availableCondition := meta.FindStatusCondition(addOn.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable)
+
+	var condition metav1.Condition
+	if now.Before(expireTime) {
+		syncCtx.Queue().AddAfter(queueKey, expireTime.Sub(now))
+
+		// only the addon which is set to Unknown by this controller is recovered, the others are left to the agent
+		if availableCondition == nil || availableCondition.Status != metav1.ConditionUnknown ||
+			availableCondition.Reason != addOnLeaseExpiredReason {
+			return nil
+		}
+		condition = metav1.Condition{
+			Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ManagedClusterAddOnLeaseUpdated",
+			Message: fmt.Sprintf("%s add-on is available.", addOn.Name),
+		}
+	} else {
+		// the addon which is not available is reported by the agent already
+		if availableCondition == nil || availableCondition.Status != metav1.ConditionTrue {
+			return nil
+		}
+		condition = metav1.Condition{
+			Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+			Status: metav1.ConditionUnknown,
+			Reason: addOnLeaseExpiredReason,
+			Message: fmt.Sprintf("%s add-on stopped renewing its lease on the hub for more than %s.",
+				addOn.Name, gracePeriod),
+		}
+	}
+
+	_, updated, err := helpers.UpdateManagedClusterAddOnStatus(
+		ctx, c.addOnClient, clusterName, addOnName, helpers.UpdateManagedClusterAddOnStatusFn(condition))
+	if err != nil {
+		return err
+	}
+	if updated {
+		c.eventRecorder.Eventf("ManagedClusterAddOnStatusUpdated",
+			"update addon %q available condition to %q on managed cluster %q with its lease on the hub",
+			addOnName, condition.Status, clusterName)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func newAddOnWithAvailableCondition(status metav1.ConditionStatus, reason string) *addonv1alpha1.ManagedClusterAddOn {
+	addOn := &addonv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: "test"},
+	}
+	if len(status) > 0 {
+		addOn.Status.Conditions = []metav1.Condition{{
+			Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+			Status: status,
+			Reason: reason,
+		}}
+	}
+	return addOn
+}
+
+func TestAddOnLeaseSync(t *testing.T) {
+	now := time.Now()
+
+	cases := []struct {
+		name              string
+		addOn             *addonv1alpha1.ManagedClusterAddOn
+		lease             *coordv1.Lease
+		expectedCondition metav1.ConditionStatus
+	}{
+		{
+			name:  "addon lease is not found",
+			addOn: newAddOnWithAvailableCondition(metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated"),
+		},
+		{
+			name:              "addon lease expired",
+			addOn:             newAddOnWithAvailableCondition(metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated"),
+			lease:             testinghelpers.NewAddOnLease(testinghelpers.TestManagedClusterName, "test", now.Add(-6*time.Minute)),
+			expectedCondition: metav1.ConditionUnknown,
+		},
+		{
+			name: "addon lease expired within the grace period of the addon",
+			addOn: func() *addonv1alpha1.ManagedClusterAddOn {
+				addOn := newAddOnWithAvailableCondition(metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+				addOn.Annotations = map[string]string{helpers.AddOnLeaseDurationSecondsAnnotation: "30"}
+				return addOn
+			}(),
+			lease:             testinghelpers.NewAddOnLease(testinghelpers.TestManagedClusterName, "test", now.Add(-3*time.Minute)),
+			expectedCondition: metav1.ConditionUnknown,
+		},
+		{
+			name:  "unavailable addon is left to the agent",
+			addOn: newAddOnWithAvailableCondition(metav1.ConditionFalse, "ManagedClusterAddOnLeaseUpdateStopped"),
+			lease: testinghelpers.NewAddOnLease(testinghelpers.TestManagedClusterName, "test", now.Add(-6*time.Minute)),
+		},
+		{
+			name:  "addon lease is renewed",
+			addOn: newAddOnWithAvailableCondition(metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated"),
+			lease: testinghelpers.NewAddOnLease(testinghelpers.TestManagedClusterName, "test", now),
+		},
+		{
+			name:              "addon recovers once its lease is renewed",
+			addOn:             newAddOnWithAvailableCondition(metav1.ConditionUnknown, addOnLeaseExpiredReason),
+			lease:             testinghelpers.NewAddOnLease(testinghelpers.TestManagedClusterName, "test", now),
+			expectedCondition: metav1.ConditionTrue,
+		},
+		{
+			name:  "addon unknown with its cluster is left to the agent",
+			addOn: newAddOnWithAvailableCondition(metav1.ConditionUnknown, "ManagedClusterLeaseUpdateStopped"),
+			lease: testinghelpers.NewAddOnLease(testinghelpers.TestManagedClusterName, "test", now),
+		},
+		{
+			name: "addon in customized health check mode",
+			addOn: func() *addonv1alpha1.ManagedClusterAddOn {
+				addOn := newAddOnWithAvailableCondition(metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+				addOn.Status.HealthCheck.Mode = addonv1alpha1.HealthCheckModeCustomized
+				return addOn
+			}(),
+			lease: testinghelpers.NewAddOnLease(testinghelpers.TestManagedClusterName, "test", now.Add(-6*time.Minute)),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOnClient := addonfake.NewSimpleClientset(c.addOn)
+			addOnInformerFactory := addoninformers.NewSharedInformerFactory(addOnClient, 10*time.Minute)
+			if err := addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore().Add(c.addOn); err != nil {
+				t.Fatal(err)
+			}
+
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 10*time.Minute)
+			if c.lease != nil {
+				if err := kubeInformerFactory.Coordination().V1().Leases().Informer().GetStore().Add(c.lease); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &addOnLeaseController{
+				addOnClient:   addOnClient,
+				addOnLister:   addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				leaseLister:   kubeInformerFactory.Coordination().V1().Leases().Lister(),
+				clock:         clocktesting.NewFakeClock(now),
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+			}
+			if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName+"/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			actions := addOnClient.Actions()
+			if len(c.expectedCondition) == 0 {
+				testinghelpers.AssertNoActions(t, actions)
+			} else {
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				addOn := &addonv1alpha1.ManagedClusterAddOn{}
+				testinghelpers.UnmarshalPatch(t, actions[1], addOn)
+				cond := meta.FindStatusCondition(addOn.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable)
+				if cond == nil || cond.Status != c.expectedCondition {
+					t.Errorf("expected available condition %s, but got %v", c.expectedCondition, cond)
+				}
+			}
+		})
+	}
+}
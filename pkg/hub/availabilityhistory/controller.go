@@ -0,0 +1,184 @@
+package availabilityhistory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+const (
+	// ConfigMapName is the name of the configmap in the cluster namespace holding the availability history of the
+	// managed cluster
+	ConfigMapName = "availability-history"
+	// HistoryKey is the key of the configmap holding the json array of the transitions, the oldest first
+	HistoryKey = "history"
+	// MaxHistoryLength is the max number of the transitions in the history, which keeps the configmap small
+	MaxHistoryLength = 100
+)
+
+// Transition is a transition of the available condition of a managed cluster
+type Transition struct {
+	Status             metav1.ConditionStatus `json:"status"`
+	Reason             string                 `json:"reason,omitempty"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime"`
+}
+
+// availabilityHistoryController records the last transitions of the available condition of each accepted managed
+// cluster in the availability-history configmap of its cluster namespace. The conditions only carry the most recent
+// transition, the history keeps the last historyLength of them, so the flapping clusters can be found.
+type availabilityHistoryController struct {
+	kubeClient      kubernetes.Interface
+	clusterLister   listerv1.ManagedClusterLister
+	configMapLister corev1listers.ConfigMapLister
+	historyLength   int
+}
+
+// NewAvailabilityHistoryController creates a new availability history controller. The configmap informer is
+// expected to watch the availability history configmaps only.
+func NewAvailabilityHistoryController(
+	kubeClient kubernetes.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	historyLength int,
+	recorder events.Recorder) factory.Controller {
+	c := &availabilityHistoryController{
+		kubeClient:      kubeClient,
+		clusterLister:   clusterInformer.Lister(),
+		configMapLister: configMapInformer.Lister(),
+		historyLength:   historyLength,
+	}
+
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				// the configmap is in the cluster namespace
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetNamespace()
+			},
+			func(obj interface{}) bool {
+				accessor, err := meta.Accessor(obj)
+				if err != nil {
+					return false
+				}
+				return accessor.GetName() == ConfigMapName
+			},
+			configMapInformer.Informer()).
+		WithSync(logging.SyncWithLogger("AvailabilityHistoryController", c.sync)).
+		ToController("AvailabilityHistoryController", recorder)
+}
+
+func (c *availabilityHistoryController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling availability history of ManagedCluster")
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// the cluster namespace is cleaned up with the cluster
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// the cluster namespace only exists after the cluster is accepted
+	if !cluster.Spec.HubAcceptsClient || !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable)
+	if cond == nil {
+		return nil
+	}
+	transition := Transition{
+		Status:             cond.Status,
+		Reason:             cond.Reason,
+		LastTransitionTime: cond.LastTransitionTime,
+	}
+
+	configMap, err := c.configMapLister.ConfigMaps(clusterName).Get(ConfigMapName)
+	switch {
+	case errors.IsNotFound(err):
+		data, err := marshalHistory([]Transition{transition})
+		if err != nil {
+			return err
+		}
+		_, err = c.kubeClient.CoreV1().ConfigMaps(clusterName).Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: clusterName,
+				Name:      ConfigMapName,
+			},
+			Data: map[string]string{HistoryKey: data},
+		}, metav1.CreateOptions{})
+		if errors.IsNotFound(err) || errors.IsAlreadyExists(err) {
+			// the cluster namespace is not created yet, or the configmap is not synced yet, wait for the next event
+			return nil
+		}
+		return err
+	case err != nil:
+		return err
+	}
+
+	history := []Transition{}
+	if len(configMap.Data[HistoryKey]) > 0 {
+		if err := json.Unmarshal([]byte(configMap.Data[HistoryKey]), &history); err != nil {
+			// the history is corrupted, start a new one
+			logger.Error(err, "Failed to parse the availability history, it is reset")
+			history = []Transition{}
+		}
+	}
+	if len(history) > 0 && isSameTransition(history[len(history)-1], transition) {
+		return nil
+	}
+
+	history = append(history, transition)
+	if len(history) > c.historyLength {
+		history = history[len(history)-c.historyLength:]
+	}
+	data, err := marshalHistory(history)
+	if err != nil {
+		return err
+	}
+
+	configMapCopy := configMap.DeepCopy()
+	if configMapCopy.Data == nil {
+		configMapCopy.Data = map[string]string{}
+	}
+	configMapCopy.Data[HistoryKey] = data
+	_, err = c.kubeClient.CoreV1().ConfigMaps(clusterName).Update(ctx, configMapCopy, metav1.UpdateOptions{})
+	return err
+}
+
+// isSameTransition returns true if the transitions have the same status and transition time, the reason of the
+// condition may be changed without a transition
+func isSameTransition(last, current Transition) bool {
+	return last.Status == current.Status && last.LastTransitionTime.Equal(&current.LastTransitionTime)
+}
+
+func marshalHistory(history []Transition) (string, error) {
+	data, err := json.Marshal(history)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the availability history: %v", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,160 @@
+package availabilityhistory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+var (
+	t1 = metav1.NewTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	t2 = metav1.NewTime(time.Date(2026, 1, 1, 0, 5, 0, 0, time.UTC))
+	t3 = metav1.NewTime(time.Date(2026, 1, 1, 0, 10, 0, 0, time.UTC))
+)
+
+func TestSync(t *testing.T) {
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		configMaps      []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "cluster is not accepted",
+			cluster:         testinghelpers.NewManagedCluster(),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "cluster without available condition",
+			cluster:         testinghelpers.NewAcceptedManagedCluster(),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:    "create history",
+			cluster: newManagedCluster(metav1.ConditionTrue, "ManagedClusterAvailable", t1),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "create")
+				configMap := actions[0].(clienttesting.CreateAction).GetObject().(*corev1.ConfigMap)
+				if configMap.Namespace != testinghelpers.TestManagedClusterName || configMap.Name != ConfigMapName {
+					t.Errorf("unexpected configmap %s/%s", configMap.Namespace, configMap.Name)
+				}
+				assertHistory(t, configMap, []Transition{
+					{Status: metav1.ConditionTrue, Reason: "ManagedClusterAvailable", LastTransitionTime: t1},
+				})
+			},
+		},
+		{
+			name:    "transition is recorded",
+			cluster: newManagedCluster(metav1.ConditionTrue, "ManagedClusterAvailable", t1),
+			configMaps: []runtime.Object{newHistoryConfigMap(t, []Transition{
+				{Status: metav1.ConditionTrue, Reason: "ManagedClusterAvailable", LastTransitionTime: t1},
+			})},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:    "append transition",
+			cluster: newManagedCluster(metav1.ConditionUnknown, "ManagedClusterLeaseUpdateStopped", t3),
+			configMaps: []runtime.Object{newHistoryConfigMap(t, []Transition{
+				{Status: metav1.ConditionTrue, Reason: "ManagedClusterAvailable", LastTransitionTime: t1},
+				{Status: metav1.ConditionFalse, Reason: "ManagedClusterKubeAPIServerUnavailable", LastTransitionTime: t2},
+			})},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				assertHistory(t, actions[0].(clienttesting.UpdateAction).GetObject().(*corev1.ConfigMap), []Transition{
+					{Status: metav1.ConditionFalse, Reason: "ManagedClusterKubeAPIServerUnavailable", LastTransitionTime: t2},
+					{Status: metav1.ConditionUnknown, Reason: "ManagedClusterLeaseUpdateStopped", LastTransitionTime: t3},
+				})
+			},
+		},
+		{
+			name:       "reset corrupted history",
+			cluster:    newManagedCluster(metav1.ConditionTrue, "ManagedClusterAvailable", t1),
+			configMaps: []runtime.Object{newConfigMap("[")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				assertHistory(t, actions[0].(clienttesting.UpdateAction).GetObject().(*corev1.ConfigMap), []Transition{
+					{Status: metav1.ConditionTrue, Reason: "ManagedClusterAvailable", LastTransitionTime: t1},
+				})
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			kubeClient := kubefake.NewSimpleClientset(c.configMaps...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+			for _, configMap := range c.configMaps {
+				if err := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(configMap); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &availabilityHistoryController{
+				kubeClient:      kubeClient,
+				clusterLister:   clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				configMapLister: kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+				historyLength:   2,
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			testinghelpers.AssertError(t, syncErr, "")
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func newManagedCluster(status metav1.ConditionStatus, reason string, lastTransitionTime metav1.Time) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	cluster.Status.Conditions = append(cluster.Status.Conditions, testinghelpers.NewManagedClusterCondition(
+		clusterv1.ManagedClusterConditionAvailable, string(status), reason, "", &lastTransitionTime))
+	return cluster
+}
+
+func newConfigMap(history string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testinghelpers.TestManagedClusterName,
+			Name:      ConfigMapName,
+		},
+		Data: map[string]string{HistoryKey: history},
+	}
+}
+
+func newHistoryConfigMap(t *testing.T, history []Transition) *corev1.ConfigMap {
+	data, err := json.Marshal(history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newConfigMap(string(data))
+}
+
+func assertHistory(t *testing.T, configMap *corev1.ConfigMap, expected []Transition) {
+	history := []Transition{}
+	if err := json.Unmarshal([]byte(configMap.Data[HistoryKey]), &history); err != nil {
+		t.Fatal(err)
+	}
+	if !equality.Semantic.DeepEqual(history, expected) {
+		t.Errorf("expected history %v, but got %v", expected, history)
+	}
+}
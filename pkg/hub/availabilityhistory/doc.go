@@ -0,0 +1,3 @@
+// package availabilityhistory contains the hub-side controller which records the recent transitions of the available
+// condition of the managed clusters in their cluster namespaces for flap analysis.
+package availabilityhistory
@@ -0,0 +1,334 @@
+package awsiam
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"sigs.k8s.io/yaml"
+
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/hub/user"
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+const (
+	// AWSAuthConfigMapNamespace and AWSAuthConfigMapName are the configmap of an EKS cluster mapping the IAM roles
+	// to the Kubernetes users and groups
+	AWSAuthConfigMapNamespace = "kube-system"
+	AWSAuthConfigMapName      = "aws-auth"
+	// MapRolesKey is the key of the configmap holding the yaml list of the role mappings
+	MapRolesKey = "mapRoles"
+
+	// ClusterNamePlaceholder is replaced with the name of the managed cluster in the role patterns
+	ClusterNamePlaceholder = "{cluster}"
+
+	// sessionNameVariable is replaced by EKS with the session name of the assumed role in the user name
+	sessionNameVariable = "{{SessionName}}"
+
+	// RoleSeparators are the characters allowed in the IAM role ARNs but not in the cluster names, the cluster name
+	// placeholder must be delimited by them in the role patterns, so a role matches the patterns of one cluster only
+	RoleSeparators = ":/_+=,@"
+)
+
+// roleMapping is the mapping of an IAM role to a Kubernetes user and groups in the aws-auth configmap
+type roleMapping struct {
+	RoleARN  string   `json:"rolearn"`
+	Username string   `json:"username"`
+	Groups   []string `json:"groups"`
+}
+
+// ValidateRolePatterns verifies the role patterns, each of them must contain the cluster name placeholder, which is
+// followed by one of the role separators or the end of the pattern, and is not preceded by any wildcard since the last
+// role separator. Otherwise the pattern of a cluster may match the roles of the clusters whose names share its prefix
+// or suffix, e.g. ocm-{cluster}-* of cluster a matches the role ocm-a-b-agent of cluster a-b.
+func ValidateRolePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if !strings.Contains(pattern, ClusterNamePlaceholder) {
+			return fmt.Errorf("the aws iam role pattern %q must contain %s", pattern, ClusterNamePlaceholder)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("the aws iam role pattern %q is invalid: %v", pattern, err)
+		}
+		segments := strings.Split(pattern, ClusterNamePlaceholder)
+		for i := 0; i < len(segments)-1; i++ {
+			prefix := segments[i][strings.LastIndexAny(segments[i], RoleSeparators)+1:]
+			suffix := segments[i+1]
+			if strings.ContainsAny(prefix, "*?[") || (len(suffix) > 0 && !strings.ContainsAny(suffix[:1], RoleSeparators)) {
+				return fmt.Errorf("the %s in the aws iam role pattern %q must be delimited by any of %q without wildcards",
+					ClusterNamePlaceholder, pattern, RoleSeparators)
+			}
+		}
+	}
+	return nil
+}
+
+// awsIAMController maps the IAM role of each accepted managed cluster registered with the awsirsa driver to the
+// identity of the cluster in the aws-auth configmap, so the agent authenticates to the EKS hub with the role instead
+// of a client certificate. The role is taken from the ManagedClusterIAMRoleARNAnnotation of the cluster, which is
+// set by the hub admins, and is mapped only if it matches any of the role patterns of the cluster and it is not mapped
+// to another identity.
+type awsIAMController struct {
+	kubeClient      kubernetes.Interface
+	clusterLister   listerv1.ManagedClusterLister
+	configMapLister corev1listers.ConfigMapLister
+	rolePatterns    []string
+	eventRecorder   events.Recorder
+
+	lock sync.Mutex
+	// rejected is the role of each cluster which is reported as rejected, so the rejection is reported once
+	rejected map[string]string
+}
+
+// NewAWSIAMController creates a new aws iam controller. The configmap informer is expected to watch the aws-auth
+// configmap only.
+func NewAWSIAMController(
+	kubeClient kubernetes.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	rolePatterns []string,
+	recorder events.Recorder) factory.Controller {
+	c := &awsIAMController{
+		kubeClient:      kubeClient,
+		clusterLister:   clusterInformer.Lister(),
+		configMapLister: configMapInformer.Lister(),
+		rolePatterns:    rolePatterns,
+		eventRecorder:   recorder.WithComponentSuffix("aws-iam-controller"),
+		rejected:        map[string]string{},
+	}
+
+	// the role mappings of all of the clusters are in the same configmap, they are reconciled together
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			return factory.DefaultQueueKey
+		}, clusterInformer.Informer()).
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				return factory.DefaultQueueKey
+			},
+			func(obj interface{}) bool {
+				accessor, err := meta.Accessor(obj)
+				if err != nil {
+					return false
+				}
+				return accessor.GetNamespace() == AWSAuthConfigMapNamespace && accessor.GetName() == AWSAuthConfigMapName
+			},
+			configMapInformer.Informer()).
+		WithSync(logging.SyncWithLogger("AWSIAMController", c.sync)).
+		ToController("AWSIAMController", recorder)
+}
+
+func (c *awsIAMController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	logger := logging.FromContext(ctx)
+	logger.V(4).Info("Reconciling the IAM role mappings of ManagedClusters")
+
+	clusters, err := c.clusterLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	configMap, err := c.configMapLister.ConfigMaps(AWSAuthConfigMapNamespace).Get(AWSAuthConfigMapName)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	mapRoles := ""
+	if configMap != nil {
+		mapRoles = configMap.Data[MapRolesKey]
+	}
+	owners, err := roleOwners(mapRoles)
+	if err != nil {
+		return fmt.Errorf("unable to parse the role mappings of configmap %s/%s: %w",
+			AWSAuthConfigMapNamespace, AWSAuthConfigMapName, err)
+	}
+	desired := c.desiredRoleMappings(clusters, owners)
+
+	if configMap == nil {
+		if len(desired) == 0 {
+			return nil
+		}
+		mapRoles, err = mergeRoleMappings("", desired)
+		if err != nil {
+			return err
+		}
+		_, err = c.kubeClient.CoreV1().ConfigMaps(AWSAuthConfigMapNamespace).Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: AWSAuthConfigMapNamespace,
+				Name:      AWSAuthConfigMapName,
+			},
+			Data: map[string]string{MapRolesKey: mapRoles},
+		}, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			// the configmap is not synced yet, wait for the next event
+			return nil
+		}
+		return err
+	}
+
+	mapRoles, err = mergeRoleMappings(configMap.Data[MapRolesKey], desired)
+	if err != nil {
+		return fmt.Errorf("unable to merge the role mappings of configmap %s/%s: %w",
+			AWSAuthConfigMapNamespace, AWSAuthConfigMapName, err)
+	}
+	if mapRoles == configMap.Data[MapRolesKey] {
+		return nil
+	}
+
+	configMap = configMap.DeepCopy()
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[MapRolesKey] = mapRoles
+	_, err = c.kubeClient.CoreV1().ConfigMaps(AWSAuthConfigMapNamespace).Update(ctx, configMap, metav1.UpdateOptions{})
+	return err
+}
+
+// desiredRoleMappings returns the role mappings of the clusters ordered by their user names. A role which is mapped
+// to another identity in the configmap, or is claimed by more than one cluster without being mapped yet, is rejected.
+func (c *awsIAMController) desiredRoleMappings(clusters []*clusterv1.ManagedCluster, owners map[string]string) []roleMapping {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	candidates := map[string]roleMapping{}
+	claims := map[string]int{}
+	for _, cluster := range clusters {
+		if mapping, ok := c.clusterRoleMapping(cluster); ok {
+			candidates[cluster.Name] = mapping
+			claims[mapping.RoleARN]++
+		}
+	}
+
+	desired := []roleMapping{}
+	for clusterName, mapping := range candidates {
+		owner, mapped := owners[mapping.RoleARN]
+		switch {
+		case mapped && owner != mapping.Username:
+			c.reject(clusterName, mapping.RoleARN, fmt.Sprintf("is already mapped to %q", owner))
+		case !mapped && claims[mapping.RoleARN] > 1:
+			c.reject(clusterName, mapping.RoleARN, "is claimed by other managed clusters")
+		default:
+			delete(c.rejected, clusterName)
+			desired = append(desired, mapping)
+		}
+	}
+	sort.Slice(desired, func(i, j int) bool {
+		return desired[i].Username < desired[j].Username
+	})
+	return desired
+}
+
+// reject reports the rejected role of the cluster once, the caller must hold the lock
+func (c *awsIAMController) reject(clusterName, roleARN, reason string) {
+	if c.rejected[clusterName] == roleARN {
+		return
+	}
+	c.rejected[clusterName] = roleARN
+	c.eventRecorder.Warningf("ManagedClusterIAMRoleRejected",
+		"The IAM role %q of managed cluster %s %s", roleARN, clusterName, reason)
+}
+
+// clusterRoleMapping returns the role mapping of the cluster if its role is allowed by the role patterns, the caller
+// must hold the lock
+func (c *awsIAMController) clusterRoleMapping(cluster *clusterv1.ManagedCluster) (roleMapping, bool) {
+	roleARN := cluster.Annotations[helpers.ManagedClusterIAMRoleARNAnnotation]
+	if len(roleARN) == 0 || !cluster.Spec.HubAcceptsClient || !cluster.DeletionTimestamp.IsZero() {
+		return roleMapping{}, false
+	}
+
+	if !matchRolePatterns(c.rolePatterns, cluster.Name, roleARN) {
+		c.reject(cluster.Name, roleARN, "does not match any of the role patterns")
+		return roleMapping{}, false
+	}
+
+	return roleMapping{
+		RoleARN: roleARN,
+		// the same identity as the subject of the client certificates of the cluster, so the existing permissions of
+		// the cluster apply to the role
+		Username: fmt.Sprintf("%s%s:%s", user.SubjectPrefix, cluster.Name, sessionNameVariable),
+		Groups:   []string{user.SubjectPrefix + cluster.Name, user.ManagedClustersGroup},
+	}, true
+}
+
+// matchRolePatterns returns true if the role matches any of the patterns with the name of the cluster
+func matchRolePatterns(patterns []string, clusterName, roleARN string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ReplaceAll(pattern, ClusterNamePlaceholder, clusterName), roleARN); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// roleOwners returns the user names of the roles in the yaml list of the role mappings
+func roleOwners(mapRoles string) (map[string]string, error) {
+	mappings := []roleMapping{}
+	if err := yaml.Unmarshal([]byte(mapRoles), &mappings); err != nil {
+		return nil, err
+	}
+	owners := map[string]string{}
+	for _, mapping := range mappings {
+		owners[mapping.RoleARN] = mapping.Username
+	}
+	return owners, nil
+}
+
+// mergeRoleMappings replaces the role mappings of the managed clusters in the yaml list of the role mappings with the
+// desired ones, the other role mappings, e.g. the ones of the node groups, are kept as they are.
+func mergeRoleMappings(mapRoles string, desired []roleMapping) (string, error) {
+	existing := []map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(mapRoles), &existing); err != nil {
+		return "", err
+	}
+
+	merged := []interface{}{}
+	current := []roleMapping{}
+	for _, mapping := range existing {
+		username, _ := mapping["username"].(string)
+		if !strings.HasPrefix(username, user.SubjectPrefix) {
+			merged = append(merged, mapping)
+			continue
+		}
+		data, err := yaml.Marshal(mapping)
+		if err != nil {
+			return "", err
+		}
+		clusterMapping := roleMapping{}
+		if err := yaml.Unmarshal(data, &clusterMapping); err != nil {
+			return "", err
+		}
+		current = append(current, clusterMapping)
+	}
+
+	// keep the configmap as it is if the role mappings of the clusters are not changed
+	if reflect.DeepEqual(current, desired) {
+		return mapRoles, nil
+	}
+
+	for _, mapping := range desired {
+		merged = append(merged, mapping)
+	}
+	if len(merged) == 0 {
+		return "", nil
+	}
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,249 @@
+package awsiam
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+const (
+	testRolePattern = "arn:aws:iam::123456789012:role/ocm_{cluster}_*"
+	testRoleARN     = "arn:aws:iam::123456789012:role/ocm_testmanagedcluster_agent"
+
+	nodeGroupMapRoles = `- groups:
+  - system:bootstrappers
+  - system:nodes
+  rolearn: arn:aws:iam::123456789012:role/node-group
+  username: system:node:{{EC2PrivateDNSName}}
+`
+)
+
+var testRoleMapping = map[string]interface{}{
+	"rolearn":  testRoleARN,
+	"username": "system:open-cluster-management:testmanagedcluster:{{SessionName}}",
+	"groups": []interface{}{
+		"system:open-cluster-management:testmanagedcluster",
+		"system:open-cluster-management:managed-clusters",
+	},
+}
+
+var nodeGroupRoleMapping = map[string]interface{}{
+	"rolearn":  "arn:aws:iam::123456789012:role/node-group",
+	"username": "system:node:{{EC2PrivateDNSName}}",
+	"groups":   []interface{}{"system:bootstrappers", "system:nodes"},
+}
+
+func TestSync(t *testing.T) {
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		clusters        []*clusterv1.ManagedCluster
+		configMaps      []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "cluster without role",
+			cluster:         testinghelpers.NewAcceptedManagedCluster(),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "cluster is not accepted",
+			cluster:         newManagedCluster(false, testRoleARN),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "role does not match the patterns",
+			cluster:         newManagedCluster(true, "arn:aws:iam::123456789012:role/ocm_cluster2_agent"),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "role is mapped to another identity",
+			cluster:         newManagedCluster(true, "arn:aws:iam::123456789012:role/ocm_testmanagedcluster_node"),
+			configMaps:      []runtime.Object{newConfigMap(strings.ReplaceAll(nodeGroupMapRoles, "role/node-group", "role/ocm_testmanagedcluster_node"))},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "role is claimed by another cluster",
+			cluster:         newManagedCluster(true, testRoleARN),
+			clusters:        []*clusterv1.ManagedCluster{newNamedManagedCluster("ocm", testRoleARN)},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "role is kept for the cluster it is mapped to",
+			cluster:         newManagedCluster(true, testRoleARN),
+			clusters:        []*clusterv1.ManagedCluster{newNamedManagedCluster("ocm", testRoleARN)},
+			configMaps:      []runtime.Object{newConfigMap(nodeGroupMapRoles + mustMarshal(t, testRoleMapping))},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:    "create configmap",
+			cluster: newManagedCluster(true, testRoleARN),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "create")
+				configMap := actions[0].(clienttesting.CreateAction).GetObject().(*corev1.ConfigMap)
+				if configMap.Namespace != AWSAuthConfigMapNamespace || configMap.Name != AWSAuthConfigMapName {
+					t.Errorf("unexpected configmap %s/%s", configMap.Namespace, configMap.Name)
+				}
+				assertRoleMappings(t, configMap, testRoleMapping)
+			},
+		},
+		{
+			name:       "add role mapping",
+			cluster:    newManagedCluster(true, testRoleARN),
+			configMaps: []runtime.Object{newConfigMap(nodeGroupMapRoles)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				assertRoleMappings(t, actions[0].(clienttesting.UpdateAction).GetObject().(*corev1.ConfigMap),
+					nodeGroupRoleMapping, testRoleMapping)
+			},
+		},
+		{
+			name:            "role mapping is not changed",
+			cluster:         newManagedCluster(true, testRoleARN),
+			configMaps:      []runtime.Object{newConfigMap(nodeGroupMapRoles + mustMarshal(t, testRoleMapping))},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:       "remove role mapping of the denied cluster",
+			cluster:    newManagedCluster(false, testRoleARN),
+			configMaps: []runtime.Object{newConfigMap(nodeGroupMapRoles + mustMarshal(t, testRoleMapping))},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				assertRoleMappings(t, actions[0].(clienttesting.UpdateAction).GetObject().(*corev1.ConfigMap),
+					nodeGroupRoleMapping)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+			for _, cluster := range append(c.clusters, c.cluster) {
+				if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kubeClient := kubefake.NewSimpleClientset(c.configMaps...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+			for _, configMap := range c.configMaps {
+				if err := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(configMap); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &awsIAMController{
+				kubeClient:      kubeClient,
+				clusterLister:   clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				configMapLister: kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+				// the role of cluster testmanagedcluster matches the second pattern of cluster ocm as well
+				rolePatterns:  []string{testRolePattern, "arn:aws:iam::123456789012:role/{cluster}_*"},
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+				rejected:      map[string]string{},
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "key"))
+			testinghelpers.AssertError(t, syncErr, "")
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func TestValidateRolePatterns(t *testing.T) {
+	cases := []struct {
+		name        string
+		patterns    []string
+		expectedErr string
+	}{
+		{
+			name:     "valid patterns",
+			patterns: []string{testRolePattern, "arn:aws:iam::*:role/{cluster}", "arn:aws:iam::*:role/ocm-{cluster}"},
+		},
+		{
+			name:        "cluster is not delimited",
+			patterns:    []string{"arn:aws:iam::123456789012:role/ocm-{cluster}-*"},
+			expectedErr: "the {cluster} in the aws iam role pattern \"arn:aws:iam::123456789012:role/ocm-{cluster}-*\" must be delimited by any of \":/_+=,@\" without wildcards",
+		},
+		{
+			name:        "cluster is preceded by wildcards",
+			patterns:    []string{"arn:aws:iam::123456789012:role/*{cluster}"},
+			expectedErr: "the {cluster} in the aws iam role pattern \"arn:aws:iam::123456789012:role/*{cluster}\" must be delimited by any of \":/_+=,@\" without wildcards",
+		},
+		{
+			name:        "pattern without cluster",
+			patterns:    []string{"arn:aws:iam::123456789012:role/ocm-*"},
+			expectedErr: "the aws iam role pattern \"arn:aws:iam::123456789012:role/ocm-*\" must contain {cluster}",
+		},
+		{
+			name:        "invalid pattern",
+			patterns:    []string{"arn:aws:iam::123456789012:role/{cluster}["},
+			expectedErr: "the aws iam role pattern \"arn:aws:iam::123456789012:role/{cluster}[\" is invalid: syntax error in pattern",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testinghelpers.AssertError(t, ValidateRolePatterns(c.patterns), c.expectedErr)
+		})
+	}
+}
+
+func newManagedCluster(accepted bool, roleARN string) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewManagedCluster()
+	cluster.Spec.HubAcceptsClient = accepted
+	cluster.Annotations = map[string]string{helpers.ManagedClusterIAMRoleARNAnnotation: roleARN}
+	return cluster
+}
+
+func newNamedManagedCluster(name, roleARN string) *clusterv1.ManagedCluster {
+	cluster := newManagedCluster(true, roleARN)
+	cluster.Name = name
+	return cluster
+}
+
+func newConfigMap(mapRoles string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: AWSAuthConfigMapNamespace,
+			Name:      AWSAuthConfigMapName,
+		},
+		Data: map[string]string{MapRolesKey: mapRoles},
+	}
+}
+
+func mustMarshal(t *testing.T, mappings ...map[string]interface{}) string {
+	data, err := yaml.Marshal(mappings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func assertRoleMappings(t *testing.T, configMap *corev1.ConfigMap, expected ...map[string]interface{}) {
+	actual := []map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(configMap.Data[MapRolesKey]), &actual); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected role mappings %v, but got %v", expected, actual)
+	}
+}
@@ -0,0 +1,3 @@
+// package awsiam contains the hub-side controller which maps the AWS IAM roles of the managed clusters registered
+// with the awsirsa driver to their identities on an EKS hub
+package awsiam
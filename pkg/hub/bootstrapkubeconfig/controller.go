@@ -0,0 +1,136 @@
+package bootstrapkubeconfig
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+// bootstrapKubeconfigController publishes the bootstrap kubeconfig in the source secret to the cluster namespace
+// of each accepted managed cluster, so that the agents are able to refresh their local bootstrap kubeconfig once
+// the hub endpoint or CA bundle is changed.
+type bootstrapKubeconfigController struct {
+	kubeClient      kubernetes.Interface
+	clusterLister   listerv1.ManagedClusterLister
+	secretLister    corev1listers.SecretLister
+	sourceNamespace string
+	sourceName      string
+	eventRecorder   events.Recorder
+}
+
+// NewBootstrapKubeconfigController creates a new bootstrap kubeconfig controller. The secret informer is
+// expected to watch the namespace of the source secret.
+func NewBootstrapKubeconfigController(
+	kubeClient kubernetes.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	secretInformer corev1informers.SecretInformer,
+	sourceNamespace, sourceName string,
+	recorder events.Recorder) factory.Controller {
+	c := &bootstrapKubeconfigController{
+		kubeClient:      kubeClient,
+		clusterLister:   clusterInformer.Lister(),
+		secretLister:    secretInformer.Lister(),
+		sourceNamespace: sourceNamespace,
+		sourceName:      sourceName,
+		eventRecorder:   recorder.WithComponentSuffix("bootstrap-kubeconfig-controller"),
+	}
+
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				// publish to all clusters once the source secret is changed
+				return factory.DefaultQueueKey
+			},
+			func(obj interface{}) bool {
+				accessor, err := meta.Accessor(obj)
+				if err != nil {
+					return false
+				}
+				return accessor.GetNamespace() == sourceNamespace && accessor.GetName() == sourceName
+			},
+			secretInformer.Informer()).
+		WithSync(logging.SyncWithLogger("BootstrapKubeconfigController", c.sync)).
+		ToController("BootstrapKubeconfigController", recorder)
+}
+
+func (c *bootstrapKubeconfigController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	if clusterName == factory.DefaultQueueKey {
+		clusters, err := c.clusterLister.List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		for _, cluster := range clusters {
+			syncCtx.Queue().Add(cluster.Name)
+		}
+		return nil
+	}
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling bootstrap kubeconfig of ManagedCluster")
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// the cluster namespace is cleaned up with the cluster
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// the cluster namespace only exists after the cluster is accepted
+	if !cluster.Spec.HubAcceptsClient || !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	source, err := c.secretLister.Secrets(c.sourceNamespace).Get(c.sourceName)
+	if errors.IsNotFound(err) {
+		logger.V(4).Info("Bootstrap kubeconfig secret is not found", "secret", c.sourceNamespace+"/"+c.sourceName)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	kubeconfig, ok := source.Data[clientcert.KubeconfigFile]
+	if !ok {
+		logger.Info("Bootstrap kubeconfig secret has no kubeconfig", "secret", c.sourceNamespace+"/"+c.sourceName, "key", clientcert.KubeconfigFile)
+		return nil
+	}
+
+	_, _, err = resourceapply.ApplySecret(ctx, c.kubeClient.CoreV1(), c.eventRecorder, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: clusterName,
+			Name:      helpers.BootstrapHubKubeconfigSecretName,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			clientcert.KubeconfigFile: kubeconfig,
+		},
+	})
+	if errors.IsNotFound(err) {
+		// the cluster namespace is not created yet, wait for the next cluster event
+		return nil
+	}
+	return err
+}
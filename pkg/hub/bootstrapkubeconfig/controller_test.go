@@ -0,0 +1,133 @@
+package bootstrapkubeconfig
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+const (
+	sourceNamespace = "open-cluster-management-hub"
+	sourceName      = "bootstrap-hub-kubeconfig"
+)
+
+func TestSync(t *testing.T) {
+	kubeconfig := testinghelpers.NewKubeconfig(nil, nil)
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: sourceNamespace, Name: sourceName},
+		Data:       map[string][]byte{clientcert.KubeconfigFile: kubeconfig},
+	}
+
+	cases := []struct {
+		name            string
+		queueKey        string
+		clusters        []runtime.Object
+		secrets         []runtime.Object
+		expectedQueued  int
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "enqueue all clusters",
+			queueKey:        factory.DefaultQueueKey,
+			clusters:        []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			expectedQueued:  1,
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "cluster is not found",
+			queueKey:        testinghelpers.TestManagedClusterName,
+			secrets:         []runtime.Object{source},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "cluster is not accepted",
+			queueKey:        testinghelpers.TestManagedClusterName,
+			clusters:        []runtime.Object{testinghelpers.NewManagedCluster()},
+			secrets:         []runtime.Object{source},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "cluster is deleting",
+			queueKey:        testinghelpers.TestManagedClusterName,
+			clusters:        []runtime.Object{testinghelpers.NewDeletingManagedCluster()},
+			secrets:         []runtime.Object{source},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "source secret is not found",
+			queueKey:        testinghelpers.TestManagedClusterName,
+			clusters:        []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:     "publish bootstrap kubeconfig",
+			queueKey: testinghelpers.TestManagedClusterName,
+			clusters: []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			secrets:  []runtime.Object{source},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "create")
+				secret := actions[1].(clienttesting.CreateAction).GetObject().(*corev1.Secret)
+				if secret.Namespace != testinghelpers.TestManagedClusterName || secret.Name != helpers.BootstrapHubKubeconfigSecretName {
+					t.Errorf("unexpected secret %s/%s", secret.Namespace, secret.Name)
+				}
+				if string(secret.Data[clientcert.KubeconfigFile]) != string(kubeconfig) {
+					t.Errorf("unexpected kubeconfig %q", secret.Data[clientcert.KubeconfigFile])
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.clusters...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+			for _, cluster := range c.clusters {
+				if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kubeClient := kubefake.NewSimpleClientset(c.secrets...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+			for _, secret := range c.secrets {
+				if err := kubeInformerFactory.Core().V1().Secrets().Informer().GetStore().Add(secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient.ClearActions()
+
+			ctrl := &bootstrapKubeconfigController{
+				kubeClient:      kubeClient,
+				clusterLister:   clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				secretLister:    kubeInformerFactory.Core().V1().Secrets().Lister(),
+				sourceNamespace: sourceNamespace,
+				sourceName:      sourceName,
+				eventRecorder:   eventstesting.NewTestingEventRecorder(t),
+			}
+
+			syncCtx := testinghelpers.NewFakeSyncContext(t, c.queueKey)
+			syncErr := ctrl.sync(context.TODO(), syncCtx)
+			testinghelpers.AssertError(t, syncErr, "")
+
+			if actual := syncCtx.Queue().Len(); actual != c.expectedQueued {
+				t.Errorf("expected %d queued clusters, but got %d", c.expectedQueued, actual)
+			}
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
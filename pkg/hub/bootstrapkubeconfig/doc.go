@@ -0,0 +1,3 @@
+// package bootstrapkubeconfig contains the hub-side controller which publishes the bootstrap kubeconfig to the
+// cluster namespaces of the managed clusters.
+package bootstrapkubeconfig
@@ -0,0 +1,135 @@
+package certexpiration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ManagedClusterConditionCertificateExpirationWarning is the condition type set on a managed cluster whose
+// client certificate is within the warning period of its expiration, it usually means the certificate rotation
+// on the managed cluster is stuck.
+const ManagedClusterConditionCertificateExpirationWarning = "ClusterCertificateExpirationWarning"
+
+// certExpirationController checks the client certificate expiration reported by the registration agent and
+// warns about the managed clusters whose client certificates are about to expire.
+type certExpirationController struct {
+	clusterClient clientset.Interface
+	clusterLister clusterv1listers.ManagedClusterLister
+	warningPeriod time.Duration
+	eventRecorder events.Recorder
+}
+
+// NewCertExpirationController creates a new cert expiration controller on hub cluster.
+func NewCertExpirationController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	warningPeriod time.Duration,
+	recorder events.Recorder) factory.Controller {
+	c := &certExpirationController{
+		clusterClient: clusterClient,
+		clusterLister: clusterInformer.Lister(),
+		warningPeriod: warningPeriod,
+		eventRecorder: recorder.WithComponentSuffix("cert-expiration-controller"),
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithSync(logging.SyncWithLogger("ManagedClusterCertExpirationController", c.sync)).
+		ToController("ManagedClusterCertExpirationController", recorder)
+}
+
+func (c *certExpirationController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling client certificate expiration of ManagedCluster")
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// the cluster is not found, do nothing
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	expiration, ok := cluster.Annotations[helpers.ManagedClusterClientCertExpirationAnnotation]
+	if !ok {
+		// the agent does not report the expiration of its client certificate
+		return nil
+	}
+	notAfter, err := time.Parse(time.RFC3339, expiration)
+	if err != nil {
+		logger.Error(err, "Unable to get the client certificate expiration of ManagedCluster")
+		return nil
+	}
+
+	warningTime := notAfter.Add(-c.warningPeriod)
+	now := time.Now()
+	if now.Before(warningTime) {
+		if err := c.updateWarningCondition(ctx, cluster, notAfter, metav1.ConditionFalse); err != nil {
+			return err
+		}
+		// check the cluster again once the certificate is within the warning period
+		syncCtx.Queue().AddAfter(clusterName, warningTime.Sub(now))
+		return nil
+	}
+
+	return c.updateWarningCondition(ctx, cluster, notAfter, metav1.ConditionTrue)
+}
+
+// updateWarningCondition sets the expiration warning condition of the cluster to the given status. A cluster that
+// has never been warned does not get the condition, so the status of healthy clusters is not changed.
+func (c *certExpirationController) updateWarningCondition(
+	ctx context.Context, cluster *clusterv1.ManagedCluster, notAfter time.Time, status metav1.ConditionStatus) error {
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, ManagedClusterConditionCertificateExpirationWarning)
+	if cond == nil && status == metav1.ConditionFalse {
+		return nil
+	}
+
+	warningCondition := metav1.Condition{
+		Type:    ManagedClusterConditionCertificateExpirationWarning,
+		Status:  status,
+		Reason:  "ClientCertificateRotated",
+		Message: fmt.Sprintf("The client certificate expires at %s.", notAfter.UTC().Format(time.RFC3339)),
+	}
+	if status == metav1.ConditionTrue {
+		warningCondition.Reason = "ClientCertificateNearExpiration"
+		warningCondition.Message = fmt.Sprintf(
+			"The client certificate expires at %s, within %v. The certificate rotation may be stuck.",
+			notAfter.UTC().Format(time.RFC3339), c.warningPeriod)
+	}
+	if cond != nil && cond.Status == warningCondition.Status && cond.Message == warningCondition.Message {
+		return nil
+	}
+
+	_, updated, err := helpers.UpdateManagedClusterStatus(
+		ctx, c.clusterClient, cluster.Name, helpers.UpdateManagedClusterConditionFn(warningCondition))
+	if updated && status == metav1.ConditionTrue {
+		c.eventRecorder.Warningf("ManagedClusterCertificateNearExpiration",
+			"the client certificate of managed cluster %q expires at %s", cluster.Name, notAfter.UTC().Format(time.RFC3339))
+	}
+
+	return err
+}
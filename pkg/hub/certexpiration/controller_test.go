@@ -0,0 +1,110 @@
+package certexpiration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestSync(t *testing.T) {
+	now := time.Now()
+
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "no expiration annotation",
+			cluster:         testinghelpers.NewAcceptedManagedCluster(),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "invalid expiration annotation",
+			cluster:         newManagedClusterWithExpiration("invalid"),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "client certificate is not near expiration",
+			cluster:         newManagedClusterWithExpiration(now.Add(30 * 24 * time.Hour).UTC().Format(time.RFC3339)),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:    "client certificate is near expiration",
+			cluster: newManagedClusterWithExpiration(now.Add(24 * time.Hour).UTC().Format(time.RFC3339)),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				assertWarningCondition(t, actions[1], metav1.ConditionTrue)
+			},
+		},
+		{
+			name: "client certificate is rotated",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := newManagedClusterWithExpiration(now.Add(30 * 24 * time.Hour).UTC().Format(time.RFC3339))
+				cluster.Status.Conditions = append(cluster.Status.Conditions,
+					metav1.Condition{Type: ManagedClusterConditionCertificateExpirationWarning, Status: metav1.ConditionTrue})
+				return cluster
+			}(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				assertWarningCondition(t, actions[1], metav1.ConditionFalse)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset([]runtime.Object{c.cluster}...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := &certExpirationController{
+				clusterClient: clusterClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				warningPeriod: 7 * 24 * time.Hour,
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			testinghelpers.AssertError(t, syncErr, "")
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func newManagedClusterWithExpiration(expiration string) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	cluster.Annotations = map[string]string{helpers.ManagedClusterClientCertExpirationAnnotation: expiration}
+	return cluster
+}
+
+func assertWarningCondition(t *testing.T, action clienttesting.Action, status metav1.ConditionStatus) {
+	patch := action.(clienttesting.PatchAction).GetPatch()
+	managedCluster := &clusterv1.ManagedCluster{}
+	if err := json.Unmarshal(patch, managedCluster); err != nil {
+		t.Fatal(err)
+	}
+	for _, cond := range managedCluster.Status.Conditions {
+		if cond.Type == ManagedClusterConditionCertificateExpirationWarning {
+			if cond.Status != status {
+				t.Errorf("expected warning condition %s, but got %s", status, cond.Status)
+			}
+			return
+		}
+	}
+	t.Errorf("expected warning condition, but not found")
+}
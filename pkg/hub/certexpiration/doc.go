@@ -0,0 +1,3 @@
+// package certexpiration contains the hub-side controller which warns about the managed clusters whose client
+// certificates are about to expire
+package certexpiration
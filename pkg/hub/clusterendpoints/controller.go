@@ -0,0 +1,171 @@
+package clusterendpoints
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+	"open-cluster-management.io/registration/pkg/tracing"
+)
+
+// clusterEndpointsController creates the cluster endpoints secret in the cluster namespace of each accepted managed
+// cluster, and copies the api server urls and CA bundle published in it by the agent into the client configs of the
+// managed cluster, so the hub components reaching the managed clusters get the accurate connection info.
+type clusterEndpointsController struct {
+	kubeClient    kubernetes.Interface
+	clusterClient clientset.Interface
+	clusterLister listerv1.ManagedClusterLister
+	secretLister  corev1listers.SecretLister
+	eventRecorder events.Recorder
+}
+
+// NewClusterEndpointsController creates a new cluster endpoints controller. The secret informer is expected to
+// watch the cluster endpoints secrets only.
+func NewClusterEndpointsController(
+	kubeClient kubernetes.Interface,
+	clusterClient clientset.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	secretInformer corev1informers.SecretInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &clusterEndpointsController{
+		kubeClient:    kubeClient,
+		clusterClient: clusterClient,
+		clusterLister: clusterInformer.Lister(),
+		secretLister:  secretInformer.Lister(),
+		eventRecorder: recorder.WithComponentSuffix("cluster-endpoints-controller"),
+	}
+
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				// the secret is in the cluster namespace
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetNamespace()
+			},
+			func(obj interface{}) bool {
+				accessor, err := meta.Accessor(obj)
+				if err != nil {
+					return false
+				}
+				return accessor.GetName() == helpers.ClusterEndpointsSecretName
+			},
+			secretInformer.Informer()).
+		WithSync(tracing.TraceSync("ClusterEndpointsController", c.sync)).
+		ToController("ClusterEndpointsController", recorder)
+}
+
+func (c *clusterEndpointsController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling endpoints of ManagedCluster")
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// the cluster namespace is cleaned up with the cluster
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// the cluster namespace only exists after the cluster is accepted
+	if !cluster.Spec.HubAcceptsClient || !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	secret, err := c.secretLister.Secrets(clusterName).Get(helpers.ClusterEndpointsSecretName)
+	if errors.IsNotFound(err) {
+		// the agent is only allowed to update the secret, so it is created by the hub
+		_, err := c.kubeClient.CoreV1().Secrets(clusterName).Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: clusterName,
+				Name:      helpers.ClusterEndpointsSecretName,
+			},
+			Type: corev1.SecretTypeOpaque,
+		}, metav1.CreateOptions{})
+		if errors.IsNotFound(err) || errors.IsAlreadyExists(err) {
+			// the cluster namespace is not created yet, or the secret is not synced yet, wait for the next event
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	clientConfigs := []clusterv1.ClientConfig{}
+	for _, url := range strings.Split(string(secret.Data[helpers.ClusterEndpointsURLsKey]), "\n") {
+		url = strings.TrimSpace(url)
+		if len(url) == 0 {
+			continue
+		}
+		clientConfigs = append(clientConfigs, clusterv1.ClientConfig{
+			URL:      url,
+			CABundle: secret.Data[helpers.ClusterEndpointsCABundleKey],
+		})
+	}
+	// the agent does not publish its endpoints
+	if len(clientConfigs) == 0 {
+		return nil
+	}
+
+	if equality.Semantic.DeepEqual(cluster.Spec.ManagedClusterClientConfigs, clientConfigs) {
+		return nil
+	}
+
+	// the agent reconciles the client configs by itself, it is the sole owner of them
+	if appliedByAgent(cluster, "managedClusterClientConfigs") {
+		logger.V(4).Info("Client configs of ManagedCluster are applied by the agent")
+		return nil
+	}
+
+	clusterCopy := cluster.DeepCopy()
+	clusterCopy.Spec.ManagedClusterClientConfigs = clientConfigs
+	if _, err := c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, clusterCopy, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	c.eventRecorder.Eventf("ManagedClusterClientConfigsUpdated",
+		"The client configs of managed cluster %s are updated with the endpoints published by the agent", clusterName)
+	return nil
+}
+
+// appliedByAgent returns true if the spec field of the managed cluster is applied by the agent
+func appliedByAgent(cluster *clusterv1.ManagedCluster, field string) bool {
+	for _, entry := range cluster.ManagedFields {
+		if entry.Manager != helpers.ClusterSpecFieldManager || entry.Operation != metav1.ManagedFieldsOperationApply ||
+			entry.FieldsV1 == nil {
+			continue
+		}
+		fields := map[string]map[string]interface{}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		if _, ok := fields["f:spec"]["f:"+field]; ok {
+			return true
+		}
+	}
+	return false
+}
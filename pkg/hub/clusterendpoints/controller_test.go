@@ -0,0 +1,151 @@
+package clusterendpoints
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSync(t *testing.T) {
+	cases := []struct {
+		name                   string
+		cluster                *clusterv1.ManagedCluster
+		secrets                []runtime.Object
+		validateKubeActions    func(t *testing.T, actions []clienttesting.Action)
+		validateClusterActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:                   "cluster is not accepted",
+			cluster:                testinghelpers.NewManagedCluster(),
+			validateKubeActions:    testinghelpers.AssertNoActions,
+			validateClusterActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:    "create cluster endpoints secret",
+			cluster: testinghelpers.NewAcceptedManagedCluster(),
+			validateKubeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "create")
+				secret := actions[0].(clienttesting.CreateAction).GetObject().(*corev1.Secret)
+				if secret.Namespace != testinghelpers.TestManagedClusterName || secret.Name != helpers.ClusterEndpointsSecretName {
+					t.Errorf("unexpected secret %s/%s", secret.Namespace, secret.Name)
+				}
+			},
+			validateClusterActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:                   "no published endpoints",
+			cluster:                testinghelpers.NewAcceptedManagedCluster(),
+			secrets:                []runtime.Object{newClusterEndpointsSecret("", "")},
+			validateKubeActions:    testinghelpers.AssertNoActions,
+			validateClusterActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:                   "endpoints are not changed",
+			cluster:                newManagedClusterWithClientConfigs("https://api.cluster1.example.com:6443"),
+			secrets:                []runtime.Object{newClusterEndpointsSecret("https://api.cluster1.example.com:6443\n", "ca")},
+			validateKubeActions:    testinghelpers.AssertNoActions,
+			validateClusterActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:    "update client configs",
+			cluster: newManagedClusterWithClientConfigs("https://api.cluster1.example.com:6443"),
+			secrets: []runtime.Object{
+				newClusterEndpointsSecret("https://api.cluster1.example.com:6443\nhttps://10.0.0.1:6443", "ca"),
+			},
+			validateKubeActions: testinghelpers.AssertNoActions,
+			validateClusterActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				cluster := actions[0].(clienttesting.UpdateAction).GetObject().(*clusterv1.ManagedCluster)
+				testinghelpers.AssertManagedClusterClientConfigs(t, cluster.Spec.ManagedClusterClientConfigs, []clusterv1.ClientConfig{
+					{URL: "https://api.cluster1.example.com:6443", CABundle: []byte("ca")},
+					{URL: "https://10.0.0.1:6443", CABundle: []byte("ca")},
+				})
+			},
+		},
+		{
+			name: "client configs are applied by the agent",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := newManagedClusterWithClientConfigs("https://api.cluster1.example.com:6443")
+				cluster.ManagedFields = []metav1.ManagedFieldsEntry{{
+					Manager:   helpers.ClusterSpecFieldManager,
+					Operation: metav1.ManagedFieldsOperationApply,
+					FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:managedClusterClientConfigs":{}}}`)},
+				}}
+				return cluster
+			}(),
+			secrets:                []runtime.Object{newClusterEndpointsSecret("https://10.0.0.1:6443", "ca")},
+			validateKubeActions:    testinghelpers.AssertNoActions,
+			validateClusterActions: testinghelpers.AssertNoActions,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			kubeClient := kubefake.NewSimpleClientset(c.secrets...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+			for _, secret := range c.secrets {
+				if err := kubeInformerFactory.Core().V1().Secrets().Informer().GetStore().Add(secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &clusterEndpointsController{
+				kubeClient:    kubeClient,
+				clusterClient: clusterClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				secretLister:  kubeInformerFactory.Core().V1().Secrets().Lister(),
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			testinghelpers.AssertError(t, syncErr, "")
+
+			c.validateKubeActions(t, kubeClient.Actions())
+			c.validateClusterActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func newClusterEndpointsSecret(urls, caBundle string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testinghelpers.TestManagedClusterName,
+			Name:      helpers.ClusterEndpointsSecretName,
+		},
+		Data: map[string][]byte{
+			helpers.ClusterEndpointsURLsKey:     []byte(urls),
+			helpers.ClusterEndpointsCABundleKey: []byte(caBundle),
+		},
+	}
+}
+
+func newManagedClusterWithClientConfigs(urls ...string) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	for _, url := range urls {
+		cluster.Spec.ManagedClusterClientConfigs = append(cluster.Spec.ManagedClusterClientConfigs, clusterv1.ClientConfig{
+			URL:      url,
+			CABundle: []byte("ca"),
+		})
+	}
+	return cluster
+}
@@ -0,0 +1,3 @@
+// package clusterendpoints contains the hub-side controller which copies the api server urls and CA bundle published
+// by the agents into the client configs of the managed clusters.
+package clusterendpoints
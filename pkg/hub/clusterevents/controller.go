@@ -0,0 +1,228 @@
+package clusterevents
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/logging"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// leaseUpdateStoppedReason is the reason of the unknown available condition set by the lease controller once the
+// registration agent stops updating its lease
+const leaseUpdateStoppedReason = "ManagedClusterLeaseUpdateStopped"
+
+// clusterState is the lifecycle state of a managed cluster last seen by the controller
+type clusterState struct {
+	uid          types.UID
+	accepted     bool
+	joined       bool
+	available    bool
+	leaseExpired bool
+	deleting     bool
+}
+
+// clusterEvent is an event recorded for a lifecycle transition of a managed cluster
+type clusterEvent struct {
+	eventType string
+	reason    string
+	message   string
+}
+
+// clusterLifecycleEventController records the lifecycle transitions of the managed clusters, i.e. accepted, joined,
+// available, lease expired, deleting and deleted, as events in their cluster namespaces, so the history of a cluster
+// can be read with kubectl get events -n <cluster> and fed to the alerting pipelines.
+//
+// The states of the clusters are kept in memory, the first state seen of a cluster is taken as its initial state
+// without recording events, so the transitions are not recorded again once the hub is restarted, and the transitions
+// happening while the hub is down are not recorded. The cluster namespace is removed with the cluster, so the events
+// which cannot be recorded in the cluster namespace, e.g. the deleted event, are recorded in the namespace of the hub
+// controller instead.
+type clusterLifecycleEventController struct {
+	kubeClient    kubernetes.Interface
+	clusterLister clusterv1listers.ManagedClusterLister
+	eventRecorder events.Recorder
+
+	lock   sync.Mutex
+	states map[string]clusterState
+}
+
+// NewClusterLifecycleEventController creates a new cluster lifecycle event controller on hub cluster.
+func NewClusterLifecycleEventController(
+	kubeClient kubernetes.Interface,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &clusterLifecycleEventController{
+		kubeClient:    kubeClient,
+		clusterLister: clusterInformer.Lister(),
+		eventRecorder: recorder.WithComponentSuffix("cluster-lifecycle-event-controller"),
+		states:        map[string]clusterState{},
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithSync(logging.SyncWithLogger("ClusterLifecycleEventController", c.sync)).
+		ToController("ClusterLifecycleEventController", recorder)
+}
+
+func (c *clusterLifecycleEventController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling lifecycle events of ManagedCluster")
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	lastState, seen := c.states[clusterName]
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		if !seen {
+			return nil
+		}
+		deleted := clusterEvent{
+			eventType: corev1.EventTypeNormal,
+			reason:    "ManagedClusterDeleted",
+			message:   "The managed cluster is deleted",
+		}
+		if err := c.recordEvent(ctx, clusterName, lastState.uid, deleted); err != nil {
+			return err
+		}
+		delete(c.states, clusterName)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	state := getClusterState(cluster)
+	// a cluster recreated with the same name starts a new lifecycle
+	if !seen || lastState.uid != state.uid {
+		c.states[clusterName] = state
+		return nil
+	}
+
+	for _, event := range transitionEvents(lastState, state) {
+		if err := c.recordEvent(ctx, clusterName, state.uid, event); err != nil {
+			// the state is not saved, so the events are recorded again in the next reconcile
+			return err
+		}
+	}
+	c.states[clusterName] = state
+	return nil
+}
+
+// recordEvent creates the event in the cluster namespace. If the cluster namespace does not exist or is being
+// deleted, the event is recorded in the namespace of the hub controller instead.
+func (c *clusterLifecycleEventController) recordEvent(
+	ctx context.Context, clusterName string, uid types.UID, event clusterEvent) error {
+	now := metav1.NewTime(time.Now())
+	_, err := c.kubeClient.CoreV1().Events(clusterName).Create(ctx, &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", clusterName, now.UnixNano()),
+			Namespace: clusterName,
+		},
+		// the events of a namespace must refer to the objects in the same namespace, so the managed cluster is
+		// referred in its cluster namespace
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "ManagedCluster",
+			Name:       clusterName,
+			Namespace:  clusterName,
+			UID:        uid,
+		},
+		Reason:         event.reason,
+		Message:        event.message,
+		Type:           event.eventType,
+		Source:         corev1.EventSource{Component: c.eventRecorder.ComponentName()},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}, metav1.CreateOptions{})
+	switch {
+	case errors.IsNotFound(err), errors.IsForbidden(err):
+		klog.FromContext(ctx).V(4).Info("Cluster namespace is not available, record the event on the hub", "reason", event.reason)
+		if event.eventType == corev1.EventTypeWarning {
+			c.eventRecorder.Warningf(event.reason, "managed cluster %s: %s", clusterName, event.message)
+		} else {
+			c.eventRecorder.Eventf(event.reason, "managed cluster %s: %s", clusterName, event.message)
+		}
+		return nil
+	case err != nil:
+		return err
+	}
+	return nil
+}
+
+func getClusterState(cluster *clusterv1.ManagedCluster) clusterState {
+	state := clusterState{
+		uid:      cluster.UID,
+		accepted: meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionHubAccepted),
+		joined:   meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionJoined),
+		deleting: !cluster.DeletionTimestamp.IsZero(),
+	}
+	available := meta.FindStatusCondition(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable)
+	if available != nil {
+		state.available = available.Status == metav1.ConditionTrue
+		state.leaseExpired = available.Status == metav1.ConditionUnknown && available.Reason == leaseUpdateStoppedReason
+	}
+	return state
+}
+
+// transitionEvents returns the events of the transitions from the last state to the current state of the cluster
+func transitionEvents(last, current clusterState) []clusterEvent {
+	clusterEvents := []clusterEvent{}
+	if !last.accepted && current.accepted {
+		clusterEvents = append(clusterEvents, clusterEvent{
+			eventType: corev1.EventTypeNormal,
+			reason:    "ManagedClusterAccepted",
+			message:   "The managed cluster is accepted by the hub",
+		})
+	}
+	if !last.joined && current.joined {
+		clusterEvents = append(clusterEvents, clusterEvent{
+			eventType: corev1.EventTypeNormal,
+			reason:    "ManagedClusterJoined",
+			message:   "The managed cluster joined the hub",
+		})
+	}
+	if !last.available && current.available {
+		clusterEvents = append(clusterEvents, clusterEvent{
+			eventType: corev1.EventTypeNormal,
+			reason:    "ManagedClusterAvailable",
+			message:   "The managed cluster is available",
+		})
+	}
+	if !last.leaseExpired && current.leaseExpired {
+		clusterEvents = append(clusterEvents, clusterEvent{
+			eventType: corev1.EventTypeWarning,
+			reason:    "ManagedClusterLeaseExpired",
+			message:   "The registration agent stopped updating the lease of the managed cluster",
+		})
+	}
+	if !last.deleting && current.deleting {
+		clusterEvents = append(clusterEvents, clusterEvent{
+			eventType: corev1.EventTypeNormal,
+			reason:    "ManagedClusterDeleting",
+			message:   "The managed cluster is being deleted",
+		})
+	}
+	return clusterEvents
+}
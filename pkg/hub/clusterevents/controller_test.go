@@ -0,0 +1,165 @@
+package clusterevents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestSync(t *testing.T) {
+	cases := []struct {
+		name              string
+		lastState         *clusterState
+		cluster           *clusterv1.ManagedCluster
+		namespaceNotFound bool
+		expectedReasons   []string
+		expectedState     *clusterState
+	}{
+		{
+			name:          "first seen cluster",
+			cluster:       testinghelpers.NewAvailableManagedCluster(),
+			expectedState: &clusterState{accepted: true, available: true},
+		},
+		{
+			name:          "unchanged cluster",
+			lastState:     &clusterState{accepted: true, available: true},
+			cluster:       testinghelpers.NewAvailableManagedCluster(),
+			expectedState: &clusterState{accepted: true, available: true},
+		},
+		{
+			name:            "accepted cluster",
+			lastState:       &clusterState{},
+			cluster:         testinghelpers.NewAcceptedManagedCluster(),
+			expectedReasons: []string{"ManagedClusterAccepted"},
+			expectedState:   &clusterState{accepted: true},
+		},
+		{
+			name:            "joined and available cluster",
+			lastState:       &clusterState{accepted: true},
+			cluster:         newJoinedAvailableManagedCluster(),
+			expectedReasons: []string{"ManagedClusterJoined", "ManagedClusterAvailable"},
+			expectedState:   &clusterState{accepted: true, joined: true, available: true},
+		},
+		{
+			name:            "lease expired cluster",
+			lastState:       &clusterState{accepted: true, available: true},
+			cluster:         newLeaseExpiredManagedCluster(),
+			expectedReasons: []string{"ManagedClusterLeaseExpired"},
+			expectedState:   &clusterState{accepted: true, leaseExpired: true},
+		},
+		{
+			name:            "deleting cluster",
+			lastState:       &clusterState{},
+			cluster:         testinghelpers.NewDeletingManagedCluster(),
+			expectedReasons: []string{"ManagedClusterDeleting"},
+			expectedState:   &clusterState{deleting: true},
+		},
+		{
+			name:            "deleted cluster",
+			lastState:       &clusterState{deleting: true},
+			expectedReasons: []string{"ManagedClusterDeleted"},
+		},
+		{
+			name:              "deleted cluster without namespace",
+			lastState:         &clusterState{deleting: true},
+			namespaceNotFound: true,
+			expectedReasons:   []string{"ManagedClusterDeleted"},
+		},
+		{
+			name: "unseen deleted cluster",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterObjs := []runtime.Object{}
+			if c.cluster != nil {
+				clusterObjs = append(clusterObjs, c.cluster)
+			}
+			clusterClient := clusterfake.NewSimpleClientset(clusterObjs...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			for _, obj := range clusterObjs {
+				if err := clusterStore.Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kubeClient := kubefake.NewSimpleClientset()
+			if c.namespaceNotFound {
+				kubeClient.PrependReactor("create", "events", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, testinghelpers.TestManagedClusterName)
+				})
+			}
+
+			ctrl := &clusterLifecycleEventController{
+				kubeClient:    kubeClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+				states:        map[string]clusterState{},
+			}
+			if c.lastState != nil {
+				ctrl.states[testinghelpers.TestManagedClusterName] = *c.lastState
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			testinghelpers.AssertError(t, syncErr, "")
+
+			actions := kubeClient.Actions()
+			if len(actions) != len(c.expectedReasons) {
+				t.Fatalf("expected %d events, but got %d actions", len(c.expectedReasons), len(actions))
+			}
+			for i, action := range actions {
+				if c.namespaceNotFound {
+					continue
+				}
+				event := action.(clienttesting.CreateAction).GetObject().(*corev1.Event)
+				if event.Namespace != testinghelpers.TestManagedClusterName {
+					t.Errorf("expected event in namespace %q, but got %q", testinghelpers.TestManagedClusterName, event.Namespace)
+				}
+				if event.Reason != c.expectedReasons[i] {
+					t.Errorf("expected event %q, but got %q", c.expectedReasons[i], event.Reason)
+				}
+				if event.InvolvedObject.Kind != "ManagedCluster" || event.InvolvedObject.Namespace != event.Namespace {
+					t.Errorf("unexpected involved object %v", event.InvolvedObject)
+				}
+			}
+
+			state, ok := ctrl.states[testinghelpers.TestManagedClusterName]
+			switch {
+			case c.expectedState == nil && ok:
+				t.Errorf("expected the state is removed, but got %v", state)
+			case c.expectedState != nil && (!ok || state != *c.expectedState):
+				t.Errorf("expected state %v, but got %v", *c.expectedState, state)
+			}
+		})
+	}
+}
+
+func newJoinedAvailableManagedCluster() *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAvailableManagedCluster()
+	cluster.Status.Conditions = append(cluster.Status.Conditions, testinghelpers.NewManagedClusterCondition(
+		clusterv1.ManagedClusterConditionJoined, "True", "ManagedClusterJoined", "Managed cluster joined", nil))
+	return cluster
+}
+
+func newLeaseExpiredManagedCluster() *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	cluster.Status.Conditions = append(cluster.Status.Conditions, testinghelpers.NewManagedClusterCondition(
+		clusterv1.ManagedClusterConditionAvailable, "Unknown", leaseUpdateStoppedReason,
+		"Registration agent stopped updating its lease.", nil))
+	return cluster
+}
@@ -0,0 +1,3 @@
+// package clusterevents contains the hub-side controller which records the lifecycle transitions of the managed
+// clusters as events in the cluster namespaces
+package clusterevents
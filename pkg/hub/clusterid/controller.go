@@ -0,0 +1,205 @@
+package clusterid
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+	"open-cluster-management.io/registration/pkg/tracing"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// ManagedClusterConditionDuplicateClusterID is the condition type set on the managed clusters which claim the
+	// same id.k8s.io cluster id, it usually means the clusters are cloned from the same image.
+	ManagedClusterConditionDuplicateClusterID = "DuplicateClusterID"
+
+	byClusterID = "by-cluster-id"
+)
+
+// duplicateClusterIDController indexes the managed clusters by their id.k8s.io claims, and reports the clusters
+// sharing a cluster id with the DuplicateClusterID condition.
+type duplicateClusterIDController struct {
+	clusterClient  clientset.Interface
+	clusterLister  clusterv1listers.ManagedClusterLister
+	clusterIndexer cache.Indexer
+	queue          workqueue.RateLimitingInterface
+	eventRecorder  events.Recorder
+}
+
+// NewDuplicateClusterIDController creates a new duplicate cluster id controller on hub cluster.
+func NewDuplicateClusterIDController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	recorder events.Recorder) factory.Controller {
+	controllerName := "duplicate-cluster-id-controller"
+	syncCtx := factory.NewSyncContext(controllerName, recorder)
+
+	err := clusterInformer.Informer().AddIndexers(cache.Indexers{
+		byClusterID: indexByClusterID,
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+
+	c := &duplicateClusterIDController{
+		clusterClient:  clusterClient,
+		clusterLister:  clusterInformer.Lister(),
+		clusterIndexer: clusterInformer.Informer().GetIndexer(),
+		queue:          syncCtx.Queue(),
+		eventRecorder:  recorder.WithComponentSuffix(controllerName),
+	}
+
+	// once the cluster id of a cluster is changed, the clusters sharing its previous or current id are requeued
+	_, err = clusterInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: c.enqueueClustersByClusterID,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				c.enqueueClustersByClusterID(oldObj)
+				c.enqueueClustersByClusterID(newObj)
+			},
+			DeleteFunc: c.enqueueClustersByClusterID,
+		},
+	)
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+
+	return factory.New().
+		WithSyncContext(syncCtx).
+		WithBareInformers(clusterInformer.Informer()).
+		WithSync(tracing.TraceSync("DuplicateClusterIDController", c.sync)).
+		ToController("DuplicateClusterIDController", recorder)
+}
+
+func indexByClusterID(obj interface{}) ([]string, error) {
+	cluster, ok := obj.(*clusterv1.ManagedCluster)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a ManagedCluster, but is %T", obj)
+	}
+
+	clusterID := getClusterID(cluster)
+	if len(clusterID) == 0 {
+		return []string{}, nil
+	}
+	return []string{clusterID}, nil
+}
+
+// enqueueClustersByClusterID enqueues the cluster and the other clusters sharing its cluster id
+func (c *duplicateClusterIDController) enqueueClustersByClusterID(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	cluster, ok := obj.(*clusterv1.ManagedCluster)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("obj is supposed to be a ManagedCluster, but is %T", obj))
+		return
+	}
+
+	c.queue.Add(cluster.Name)
+
+	clusterID := getClusterID(cluster)
+	if len(clusterID) == 0 {
+		return
+	}
+	objs, err := c.clusterIndexer.ByIndex(byClusterID, clusterID)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("error to get clusters of cluster id %q: %v", clusterID, err))
+		return
+	}
+	for _, obj := range objs {
+		c.queue.Add(obj.(*clusterv1.ManagedCluster).Name)
+	}
+}
+
+func (c *duplicateClusterIDController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling cluster id of ManagedCluster")
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// the cluster is not found, do nothing
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	clusterID := getClusterID(cluster)
+	duplicates := []string{}
+	if len(clusterID) > 0 {
+		objs, err := c.clusterIndexer.ByIndex(byClusterID, clusterID)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			other := obj.(*clusterv1.ManagedCluster)
+			if other.Name == clusterName || !other.DeletionTimestamp.IsZero() {
+				continue
+			}
+			duplicates = append(duplicates, other.Name)
+		}
+	}
+	sort.Strings(duplicates)
+
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, ManagedClusterConditionDuplicateClusterID)
+	duplicateCondition := metav1.Condition{
+		Type:    ManagedClusterConditionDuplicateClusterID,
+		Status:  metav1.ConditionFalse,
+		Reason:  "ClusterIDUnique",
+		Message: "The cluster id is not claimed by other clusters",
+	}
+	if len(duplicates) > 0 {
+		duplicateCondition.Status = metav1.ConditionTrue
+		duplicateCondition.Reason = "ClusterIDDuplicated"
+		duplicateCondition.Message = fmt.Sprintf("The cluster id %q is also claimed by the clusters: %s",
+			clusterID, strings.Join(duplicates, ", "))
+	}
+	// a cluster which has never been duplicated does not get the condition, so the status of the most clusters is
+	// not changed
+	if cond == nil && duplicateCondition.Status == metav1.ConditionFalse {
+		return nil
+	}
+	if cond != nil && cond.Status == duplicateCondition.Status && cond.Message == duplicateCondition.Message {
+		return nil
+	}
+
+	_, updated, err := helpers.UpdateManagedClusterStatus(
+		ctx, c.clusterClient, clusterName, helpers.UpdateManagedClusterConditionFn(duplicateCondition))
+	if updated && duplicateCondition.Status == metav1.ConditionTrue {
+		c.eventRecorder.Warningf("ManagedClusterIDDuplicated", "managed cluster %s: %s", clusterName, duplicateCondition.Message)
+	}
+	return err
+}
+
+// getClusterID returns the id.k8s.io claim of the cluster
+func getClusterID(cluster *clusterv1.ManagedCluster) string {
+	for _, claim := range cluster.Status.ClusterClaims {
+		if claim.Name == clientcert.ClusterIDClaimName {
+			return claim.Value
+		}
+	}
+	return ""
+}
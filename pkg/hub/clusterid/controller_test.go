@@ -0,0 +1,155 @@
+package clusterid
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/clientcert"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestSync(t *testing.T) {
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		otherClusters   []*clusterv1.ManagedCluster
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "cluster without cluster id",
+			cluster:         testinghelpers.NewAcceptedManagedCluster(),
+			otherClusters:   []*clusterv1.ManagedCluster{newManagedCluster("cluster2", "")},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "unique cluster id",
+			cluster:         newManagedCluster(testinghelpers.TestManagedClusterName, "id1"),
+			otherClusters:   []*clusterv1.ManagedCluster{newManagedCluster("cluster2", "id2")},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:    "duplicate cluster id",
+			cluster: newManagedCluster(testinghelpers.TestManagedClusterName, "id1"),
+			otherClusters: []*clusterv1.ManagedCluster{
+				newManagedCluster("cluster3", "id1"),
+				newManagedCluster("cluster2", "id1"),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				assertDuplicateCondition(t, actions[1], metav1.ConditionTrue,
+					"The cluster id \"id1\" is also claimed by the clusters: cluster2, cluster3")
+			},
+		},
+		{
+			name: "duplicate cluster id is resolved",
+			cluster: newManagedCluster(testinghelpers.TestManagedClusterName, "id1", metav1.Condition{
+				Type:    ManagedClusterConditionDuplicateClusterID,
+				Status:  metav1.ConditionTrue,
+				Reason:  "ClusterIDDuplicated",
+				Message: "The cluster id \"id1\" is also claimed by the clusters: cluster2",
+			}),
+			otherClusters: []*clusterv1.ManagedCluster{newManagedCluster("cluster2", "id2")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				assertDuplicateCondition(t, actions[1], metav1.ConditionFalse, "The cluster id is not claimed by other clusters")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset([]runtime.Object{c.cluster}...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterInformer := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer()
+			if err := clusterInformer.AddIndexers(cache.Indexers{byClusterID: indexByClusterID}); err != nil {
+				t.Fatal(err)
+			}
+			for _, cluster := range append(c.otherClusters, c.cluster) {
+				if err := clusterInformer.GetStore().Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			syncCtx := testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)
+			ctrl := &duplicateClusterIDController{
+				clusterClient:  clusterClient,
+				clusterLister:  clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterIndexer: clusterInformer.GetIndexer(),
+				queue:          syncCtx.Queue(),
+				eventRecorder:  eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), syncCtx)
+			testinghelpers.AssertError(t, syncErr, "")
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func TestEnqueueClustersByClusterID(t *testing.T) {
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterfake.NewSimpleClientset(), time.Minute*10)
+	clusterInformer := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer()
+	if err := clusterInformer.AddIndexers(cache.Indexers{byClusterID: indexByClusterID}); err != nil {
+		t.Fatal(err)
+	}
+	for _, cluster := range []*clusterv1.ManagedCluster{
+		newManagedCluster("cluster1", "id1"),
+		newManagedCluster("cluster2", "id1"),
+		newManagedCluster("cluster3", "id2"),
+	} {
+		if err := clusterInformer.GetStore().Add(cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	syncCtx := testinghelpers.NewFakeSyncContext(t, "")
+	ctrl := &duplicateClusterIDController{
+		clusterIndexer: clusterInformer.GetIndexer(),
+		queue:          syncCtx.Queue(),
+	}
+	ctrl.enqueueClustersByClusterID(cache.DeletedFinalStateUnknown{Obj: newManagedCluster("cluster4", "id1")})
+
+	if syncCtx.Queue().Len() != 3 {
+		t.Errorf("expected 3 clusters are enqueued, but got %d", syncCtx.Queue().Len())
+	}
+}
+
+func newManagedCluster(name, clusterID string, conds ...metav1.Condition) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	cluster.Name = name
+	if len(clusterID) > 0 {
+		cluster.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{
+			{Name: clientcert.ClusterIDClaimName, Value: clusterID},
+		}
+	}
+	cluster.Status.Conditions = append(cluster.Status.Conditions, conds...)
+	return cluster
+}
+
+func assertDuplicateCondition(t *testing.T, action clienttesting.Action, status metav1.ConditionStatus, message string) {
+	patch := action.(clienttesting.PatchAction).GetPatch()
+	managedCluster := &clusterv1.ManagedCluster{}
+	if err := json.Unmarshal(patch, managedCluster); err != nil {
+		t.Fatal(err)
+	}
+	for _, cond := range managedCluster.Status.Conditions {
+		if cond.Type == ManagedClusterConditionDuplicateClusterID {
+			if cond.Status != status || cond.Message != message {
+				t.Errorf("expected duplicate condition %s %q, but got %s %q", status, message, cond.Status, cond.Message)
+			}
+			return
+		}
+	}
+	t.Errorf("expected duplicate condition, but not found")
+}
@@ -0,0 +1,3 @@
+// package clusterid contains the hub-side controller which detects the managed clusters claiming the same
+// id.k8s.io cluster id
+package clusterid
@@ -0,0 +1,147 @@
+package clusterrole
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/hub/user"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// AddOnClusterRoleAggregationLabel is the label of the clusterroles contributed by the addons. The rules of the
+	// labeled clusterroles are aggregated by the kube-controller-manager into the addon clusterroles, which is bound
+	// with each accepted cluster in its namespace on the hub.
+	AddOnClusterRoleAggregationLabel = "open-cluster-management.io/aggregate-to-managedcluster-addons"
+
+	// addOnClusterRole is the name of both the aggregated addon clusterrole and its rolebindings in the cluster
+	// namespaces. The hub is allowed to escalate and bind this clusterrole only.
+	addOnClusterRole = "open-cluster-management:managedcluster:addons"
+)
+
+var addOnAggregationRule = &rbacv1.AggregationRule{
+	ClusterRoleSelectors: []metav1.LabelSelector{
+		{MatchLabels: map[string]string{AddOnClusterRoleAggregationLabel: "true"}},
+	},
+}
+
+// isAddOnClusterRole returns true if the clusterrole is an addon fragment or the aggregated addon clusterrole
+func isAddOnClusterRole(obj metav1.Object) bool {
+	return obj.GetLabels()[AddOnClusterRoleAggregationLabel] == "true" || obj.GetName() == addOnClusterRole
+}
+
+// syncAddOnClusterRoles ensures the addon clusterrole, which aggregates the rules of the addon clusterroles, and binds
+// it with the group of each accepted cluster in the cluster namespace. The rolebindings of the deleted or denied
+// clusters are removed, and the addon clusterrole and all of its rolebindings are removed if there is no addon
+// clusterrole.
+func (c *clusterroleController) syncAddOnClusterRoles(
+	ctx context.Context, syncCtx factory.SyncContext, managedClusters []*clusterv1.ManagedCluster) []error {
+	fragments, err := c.clusterRoleLister.List(labels.SelectorFromSet(labels.Set{AddOnClusterRoleAggregationLabel: "true"}))
+	if err != nil {
+		return []error{err}
+	}
+
+	errs := []error{}
+	clusterNames := sets.NewString()
+	if len(fragments) > 0 {
+		if err := c.applyAddOnClusterRole(ctx, syncCtx); err != nil {
+			return []error{err}
+		}
+
+		for _, cluster := range managedClusters {
+			if !cluster.Spec.HubAcceptsClient || !cluster.DeletionTimestamp.IsZero() {
+				continue
+			}
+			clusterNames.Insert(cluster.Name)
+
+			if _, _, err := resourceapply.ApplyRoleBinding(ctx, c.kubeClient.RbacV1(), syncCtx.Recorder(), &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: cluster.Name,
+					Name:      addOnClusterRole,
+				},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: rbacv1.GroupName,
+					Kind:     "ClusterRole",
+					Name:     addOnClusterRole,
+				},
+				Subjects: []rbacv1.Subject{{
+					APIGroup: rbacv1.GroupName,
+					Kind:     rbacv1.GroupKind,
+					Name:     user.SubjectPrefix + cluster.Name,
+				}},
+			}); err != nil {
+				errs = append(errs, fmt.Errorf("unable to apply the addon rolebinding of cluster %q: %w", cluster.Name, err))
+			}
+		}
+	}
+
+	roleBindings, err := c.roleBindingLister.List(labels.Everything())
+	if err != nil {
+		return append(errs, err)
+	}
+	for _, roleBinding := range roleBindings {
+		if roleBinding.Name != addOnClusterRole || clusterNames.Has(roleBinding.Namespace) {
+			continue
+		}
+
+		err := c.kubeClient.RbacV1().RoleBindings(roleBinding.Namespace).Delete(ctx, roleBinding.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("unable to delete the addon rolebinding of cluster %q: %w", roleBinding.Namespace, err))
+			continue
+		}
+		syncCtx.Recorder().Eventf("AddOnRoleBindingDeleted", "The addon rolebinding of cluster %q is deleted", roleBinding.Namespace)
+	}
+	if len(errs) > 0 || len(fragments) > 0 {
+		return errs
+	}
+
+	if _, err := c.clusterRoleLister.Get(addOnClusterRole); apierrors.IsNotFound(err) {
+		return errs
+	}
+	err = c.kubeClient.RbacV1().ClusterRoles().Delete(ctx, addOnClusterRole, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return append(errs, fmt.Errorf("unable to delete the addon clusterrole: %w", err))
+	}
+	syncCtx.Recorder().Eventf("AddOnClusterRoleDeleted", "The addon clusterrole %q is deleted", addOnClusterRole)
+	return errs
+}
+
+// applyAddOnClusterRole creates the addon clusterrole with the aggregation rule, or restores its aggregation rule. Its
+// rules are maintained by the kube-controller-manager, so they are not touched.
+func (c *clusterroleController) applyAddOnClusterRole(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterRole, err := c.clusterRoleLister.Get(addOnClusterRole)
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err := c.kubeClient.RbacV1().ClusterRoles().Create(ctx, &rbacv1.ClusterRole{
+			ObjectMeta:      metav1.ObjectMeta{Name: addOnClusterRole},
+			AggregationRule: addOnAggregationRule,
+		}, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("unable to create the addon clusterrole: %w", err)
+		}
+		syncCtx.Recorder().Eventf("AddOnClusterRoleCreated", "The addon clusterrole %q is created", addOnClusterRole)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if reflect.DeepEqual(clusterRole.AggregationRule, addOnAggregationRule) {
+		return nil
+	}
+	clusterRole = clusterRole.DeepCopy()
+	clusterRole.AggregationRule = addOnAggregationRule
+	if _, err := c.kubeClient.RbacV1().ClusterRoles().Update(ctx, clusterRole, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update the addon clusterrole: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package clusterrole
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var (
+	testAddOnRule = rbacv1.PolicyRule{
+		APIGroups: []string{""},
+		Resources: []string{"configmaps"},
+		Verbs:     []string{"get", "list", "watch"},
+	}
+	testAddOnRule2 = rbacv1.PolicyRule{
+		APIGroups: []string{"example.open-cluster-management.io"},
+		Resources: []string{"reports"},
+		Verbs:     []string{"create"},
+	}
+)
+
+func TestSyncAddOnClusterRoles(t *testing.T) {
+	cases := []struct {
+		name            string
+		clusters        []runtime.Object
+		clusterroles    []runtime.Object
+		rolebindings    []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:     "no addon clusterroles",
+			clusters: []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertAddOnActions(t, actions)
+			},
+		},
+		{
+			name:         "cluster is not accepted",
+			clusters:     []runtime.Object{testinghelpers.NewManagedCluster()},
+			clusterroles: []runtime.Object{newAddOnClusterRole("addon1", testAddOnRule)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertAddOnActions(t, actions, "create")
+			},
+		},
+		{
+			name:     "aggregate addon clusterroles",
+			clusters: []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			clusterroles: []runtime.Object{
+				newAddOnClusterRole("addon2", testAddOnRule2, testAddOnRule),
+				newAddOnClusterRole("addon1", testAddOnRule),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				addOnActions := assertAddOnActions(t, actions, "create", "get", "create")
+				clusterRole := addOnActions[0].(clienttesting.CreateAction).GetObject().(*rbacv1.ClusterRole)
+				if clusterRole.Name != "open-cluster-management:managedcluster:addons" {
+					t.Errorf("unexpected clusterrole %q", clusterRole.Name)
+				}
+				if !reflect.DeepEqual(clusterRole.AggregationRule, addOnAggregationRule) || len(clusterRole.Rules) != 0 {
+					t.Errorf("unexpected clusterrole %v", clusterRole)
+				}
+				roleBinding := addOnActions[2].(clienttesting.CreateAction).GetObject().(*rbacv1.RoleBinding)
+				if roleBinding.Namespace != testinghelpers.TestManagedClusterName || roleBinding.RoleRef.Name != clusterRole.Name {
+					t.Errorf("unexpected rolebinding %v", roleBinding)
+				}
+				if len(roleBinding.Subjects) != 1 || roleBinding.Subjects[0].Name != "system:open-cluster-management:testmanagedcluster" {
+					t.Errorf("unexpected subjects %v", roleBinding.Subjects)
+				}
+			},
+		},
+		{
+			name:     "restore the aggregation rule",
+			clusters: []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			clusterroles: []runtime.Object{
+				newAddOnClusterRole("addon1", testAddOnRule),
+				&rbacv1.ClusterRole{
+					ObjectMeta: metav1.ObjectMeta{Name: "open-cluster-management:managedcluster:addons"},
+					Rules:      []rbacv1.PolicyRule{testAddOnRule2},
+				},
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				addOnActions := assertAddOnActions(t, actions, "update", "get", "create")
+				clusterRole := addOnActions[0].(clienttesting.UpdateAction).GetObject().(*rbacv1.ClusterRole)
+				if !reflect.DeepEqual(clusterRole.AggregationRule, addOnAggregationRule) {
+					t.Errorf("unexpected aggregation rule %v", clusterRole.AggregationRule)
+				}
+			},
+		},
+		{
+			name:         "clean up the addon rolebinding of the deleted cluster",
+			clusterroles: []runtime.Object{newAddOnClusterRole("addon1", testAddOnRule), newAggregatedAddOnClusterRole()},
+			rolebindings: []runtime.Object{newAddOnRoleBinding(testinghelpers.TestManagedClusterName)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				addOnActions := assertAddOnActions(t, actions, "delete")
+				if addOnActions[0].GetResource().Resource != "rolebindings" || addOnActions[0].GetNamespace() != testinghelpers.TestManagedClusterName {
+					t.Errorf("expected the rolebinding to be deleted, but got %v", addOnActions[0])
+				}
+			},
+		},
+		{
+			name:         "clean up the addon clusterrole if there is no addon clusterrole",
+			clusters:     []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			clusterroles: []runtime.Object{newAggregatedAddOnClusterRole()},
+			rolebindings: []runtime.Object{newAddOnRoleBinding(testinghelpers.TestManagedClusterName)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				addOnActions := assertAddOnActions(t, actions, "delete", "delete")
+				if addOnActions[0].GetResource().Resource != "rolebindings" {
+					t.Errorf("expected the rolebinding to be deleted, but got %v", addOnActions[0])
+				}
+				if addOnActions[1].GetResource().Resource != "clusterroles" {
+					t.Errorf("expected the addon clusterrole to be deleted, but got %v", addOnActions[1])
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(append(c.clusterroles, c.rolebindings...)...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+			clusterRoleStore := kubeInformerFactory.Rbac().V1().ClusterRoles().Informer().GetStore()
+			for _, clusterRole := range c.clusterroles {
+				if err := clusterRoleStore.Add(clusterRole); err != nil {
+					t.Fatal(err)
+				}
+			}
+			roleBindingStore := kubeInformerFactory.Rbac().V1().RoleBindings().Informer().GetStore()
+			for _, roleBinding := range c.rolebindings {
+				if err := roleBindingStore.Add(roleBinding); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			clusterClient := clusterfake.NewSimpleClientset(c.clusters...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			for _, cluster := range c.clusters {
+				if err := clusterStore.Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &clusterroleController{
+				kubeClient:        kubeClient,
+				clusterLister:     clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterRoleLister: kubeInformerFactory.Rbac().V1().ClusterRoles().Lister(),
+				roleBindingLister: kubeInformerFactory.Rbac().V1().RoleBindings().Lister(),
+				cache:             resourceapply.NewResourceCache(),
+				eventRecorder:     eventstesting.NewTestingEventRecorder(t),
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "testmangedclsuterclusterrole"))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func newAddOnClusterRole(name string, rules ...rbacv1.PolicyRule) *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{AddOnClusterRoleAggregationLabel: "true"},
+		},
+		Rules: rules,
+	}
+}
+
+func newAggregatedAddOnClusterRole() *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		ObjectMeta:      metav1.ObjectMeta{Name: addOnClusterRole},
+		AggregationRule: addOnAggregationRule,
+	}
+}
+
+func newAddOnRoleBinding(namespace string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: addOnClusterRole},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: addOnClusterRole},
+	}
+}
+
+// assertAddOnActions asserts the verbs of the actions on the addon clusterroles and rolebindings, the actions on the
+// static registration and work clusterroles are ignored
+func assertAddOnActions(t *testing.T, actions []clienttesting.Action, expectedVerbs ...string) []clienttesting.Action {
+	addOnActions := []clienttesting.Action{}
+	for _, action := range actions {
+		name := ""
+		switch a := action.(type) {
+		case clienttesting.GetAction:
+			name = a.GetName()
+		case clienttesting.CreateAction:
+			name = a.GetObject().(metav1.Object).GetName()
+		case clienttesting.UpdateAction:
+			name = a.GetObject().(metav1.Object).GetName()
+		case clienttesting.DeleteAction:
+			name = a.GetName()
+		}
+		if name == registrationClusterRole || name == workClusterRole {
+			continue
+		}
+		addOnActions = append(addOnActions, action)
+	}
+	testinghelpers.AssertActions(t, addOnActions, expectedVerbs...)
+	return addOnActions
+}
@@ -8,6 +8,7 @@ import (
 	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -19,6 +20,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	rbacv1informers "k8s.io/client-go/informers/rbac/v1"
 	"k8s.io/client-go/kubernetes"
+	rbacv1listers "k8s.io/client-go/listers/rbac/v1"
 )
 
 const (
@@ -34,12 +36,15 @@ var clusterRoleFiles = []string{
 //go:embed manifests
 var manifestFiles embed.FS
 
-// clusterroleController maintains the necessary clusterroles for registraion and work agent on hub cluster.
+// clusterroleController maintains the necessary clusterroles for registraion and work agent on hub cluster, and the
+// clusterroles aggregated from the addon clusterroles for each accepted cluster.
 type clusterroleController struct {
-	kubeClient    kubernetes.Interface
-	clusterLister clusterv1listers.ManagedClusterLister
-	cache         resourceapply.ResourceCache
-	eventRecorder events.Recorder
+	kubeClient        kubernetes.Interface
+	clusterLister     clusterv1listers.ManagedClusterLister
+	clusterRoleLister rbacv1listers.ClusterRoleLister
+	roleBindingLister rbacv1listers.RoleBindingLister
+	cache             resourceapply.ResourceCache
+	eventRecorder     events.Recorder
 }
 
 // NewManagedClusterClusterroleController creates a clusterrole controller on hub cluster.
@@ -47,22 +52,29 @@ func NewManagedClusterClusterroleController(
 	kubeClient kubernetes.Interface,
 	clusterInformer clusterv1informer.ManagedClusterInformer,
 	clusterRoleInformer rbacv1informers.ClusterRoleInformer,
+	roleBindingInformer rbacv1informers.RoleBindingInformer,
 	recorder events.Recorder) factory.Controller {
 	c := &clusterroleController{
-		kubeClient:    kubeClient,
-		clusterLister: clusterInformer.Lister(),
-		cache:         resourceapply.NewResourceCache(),
-		eventRecorder: recorder.WithComponentSuffix("managed-cluster-clusterrole-controller"),
+		kubeClient:        kubeClient,
+		clusterLister:     clusterInformer.Lister(),
+		clusterRoleLister: clusterRoleInformer.Lister(),
+		roleBindingLister: roleBindingInformer.Lister(),
+		cache:             resourceapply.NewResourceCache(),
+		eventRecorder:     recorder.WithComponentSuffix("managed-cluster-clusterrole-controller"),
 	}
 	return factory.New().
 		WithFilteredEventsInformers(
 			func(obj interface{}) bool {
 				clusterRoles := sets.NewString(registrationClusterRole, workClusterRole)
 				metaObj := obj.(metav1.Object)
-				return clusterRoles.Has(metaObj.GetName())
+				return clusterRoles.Has(metaObj.GetName()) || isAddOnClusterRole(metaObj)
 			}, clusterRoleInformer.Informer()).
+		WithFilteredEventsInformers(
+			func(obj interface{}) bool {
+				return obj.(metav1.Object).GetName() == addOnClusterRole
+			}, roleBindingInformer.Informer()).
 		WithInformers(clusterInformer.Informer()).
-		WithSync(c.sync).
+		WithSync(logging.SyncWithLogger("ManagedClusterClusterRoleController", c.sync)).
 		ToController("ManagedClusterClusterRoleController", recorder)
 }
 
@@ -72,15 +84,20 @@ func (c *clusterroleController) sync(ctx context.Context, syncCtx factory.SyncCo
 		return err
 	}
 
+	errs := c.syncAddOnClusterRoles(ctx, syncCtx, managedClusters)
+
 	// Clean up managedcluser cluserroles if there are no managed clusters
 	if len(managedClusters) == 0 {
-		return helpers.CleanUpManagedClusterManifests(
+		if err := helpers.CleanUpManagedClusterManifests(
 			ctx,
 			c.kubeClient,
 			c.eventRecorder,
 			manifestFiles.ReadFile,
 			clusterRoleFiles...,
-		)
+		); err != nil {
+			errs = append(errs, err)
+		}
+		return operatorhelpers.NewMultiLineAggregate(errs)
 	}
 
 	// Make sure the managedcluser cluserroles are existed if there are clusters
@@ -93,7 +110,6 @@ func (c *clusterroleController) sync(ctx context.Context, syncCtx factory.SyncCo
 		clusterRoleFiles...,
 	)
 
-	errs := []error{}
 	for _, result := range results {
 		if result.Error != nil {
 			errs = append(errs, fmt.Errorf("%q (%T): %v", result.File, result.Type, result.Error))
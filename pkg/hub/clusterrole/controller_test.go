@@ -15,6 +15,7 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 )
@@ -74,11 +75,14 @@ func TestSyncManagedClusterClusterRole(t *testing.T) {
 				}
 			}
 
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
 			ctrl := &clusterroleController{
-				kubeClient:    kubeClient,
-				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
-				cache:         resourceapply.NewResourceCache(),
-				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+				kubeClient:        kubeClient,
+				clusterLister:     clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterRoleLister: kubeInformerFactory.Rbac().V1().ClusterRoles().Lister(),
+				roleBindingLister: kubeInformerFactory.Rbac().V1().RoleBindings().Lister(),
+				cache:             resourceapply.NewResourceCache(),
+				eventRecorder:     eventstesting.NewTestingEventRecorder(t),
 			}
 
 			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "testmangedclsuterclusterrole"))
@@ -1,2 +1,3 @@
-// package clusterrole contains the hub-side reconciler for the ManagedCluster necessary clusterrole resource.
+// package clusterrole contains the hub-side reconciler for the ManagedCluster necessary clusterrole resource, and the
+// clusterroles of the clusters aggregated from the clusterroles contributed by the addons.
 package clusterrole
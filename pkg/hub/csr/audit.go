@@ -0,0 +1,218 @@
+package csr
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"go.opentelemetry.io/otel/attribute"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/registration/pkg/tracing"
+)
+
+// ApprovalDecision is the decision of the hub on a CSR of a managed cluster
+type ApprovalDecision string
+
+const (
+	// ApprovalDecisionApproved means the CSR is auto approved
+	ApprovalDecisionApproved ApprovalDecision = "Approved"
+	// ApprovalDecisionNotApproved means the CSR qualifies for auto approval but is not approved, it is left to
+	// the cluster admin
+	ApprovalDecisionNotApproved ApprovalDecision = "NotApproved"
+)
+
+const (
+	// AuditSinkEvent writes the approval records to kube events
+	AuditSinkEvent = "event"
+	// AuditSinkLog writes the approval records to the structured log stream
+	AuditSinkLog = "log"
+	// AuditSinkWebhook posts the approval records to an external webhook endpoint
+	AuditSinkWebhook = "webhook"
+)
+
+// AuditSinks is the list of the supported audit sink types
+var AuditSinks = []string{AuditSinkEvent, AuditSinkLog, AuditSinkWebhook}
+
+// ApprovalRecord is the audit record of an approval decision on a CSR
+type ApprovalRecord struct {
+	Timestamp      metav1.Time      `json:"timestamp"`
+	CSRName        string           `json:"csrName"`
+	ClusterName    string           `json:"clusterName"`
+	Requester      string           `json:"requester"`
+	SignerName     string           `json:"signerName"`
+	CommonName     string           `json:"commonName,omitempty"`
+	DNSNames       []string         `json:"dnsNames,omitempty"`
+	IPAddresses    []string         `json:"ipAddresses,omitempty"`
+	EmailAddresses []string         `json:"emailAddresses,omitempty"`
+	URIs           []string         `json:"uris,omitempty"`
+	Decision       ApprovalDecision `json:"decision"`
+	Reason         string           `json:"reason"`
+}
+
+// AuditSink records the approval decisions on CSRs
+type AuditSink interface {
+	Record(ctx context.Context, record ApprovalRecord) error
+}
+
+// NewAuditSink returns an AuditSink which writes the records to all of the given sink types. The webhookURL is
+// required if the webhook sink is enabled. A nil AuditSink is returned if no sink type is given.
+func NewAuditSink(sinkTypes []string, webhookURL string, recorder events.Recorder) (AuditSink, error) {
+	sinks := multiAuditSink{}
+	for _, sinkType := range sinkTypes {
+		switch sinkType {
+		case AuditSinkEvent:
+			sinks = append(sinks, &eventAuditSink{recorder: recorder.WithComponentSuffix("csr-audit")})
+		case AuditSinkLog:
+			sinks = append(sinks, &logAuditSink{})
+		case AuditSinkWebhook:
+			if len(webhookURL) == 0 {
+				return nil, fmt.Errorf("the webhook url is required by the %q audit sink", AuditSinkWebhook)
+			}
+			sinks = append(sinks, &webhookAuditSink{
+				url:    webhookURL,
+				client: &http.Client{Timeout: 10 * time.Second},
+			})
+		default:
+			return nil, fmt.Errorf("unsupported audit sink %q, it must be one of %s", sinkType, strings.Join(AuditSinks, ","))
+		}
+	}
+
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+	return sinks, nil
+}
+
+// multiAuditSink writes the records to each of the sinks
+type multiAuditSink []AuditSink
+
+func (m multiAuditSink) Record(ctx context.Context, record ApprovalRecord) error {
+	errs := []error{}
+	for _, sink := range m {
+		if err := sink.Record(ctx, record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// eventAuditSink writes the records to kube events
+type eventAuditSink struct {
+	recorder events.Recorder
+}
+
+func (e *eventAuditSink) Record(_ context.Context, record ApprovalRecord) error {
+	e.recorder.Eventf("ManagedClusterCSRApprovalAudit",
+		"csr %q of managed cluster %q requested by %q with signer %q is %s: %s",
+		record.CSRName, record.ClusterName, record.Requester, record.SignerName, record.Decision, record.Reason)
+	return nil
+}
+
+// logAuditSink writes the records to the structured log stream
+type logAuditSink struct{}
+
+func (l *logAuditSink) Record(_ context.Context, record ApprovalRecord) error {
+	klog.InfoS("ManagedCluster CSR approval audit",
+		"csr", record.CSRName,
+		"cluster", record.ClusterName,
+		"requester", record.Requester,
+		"signer", record.SignerName,
+		"commonName", record.CommonName,
+		"dnsNames", record.DNSNames,
+		"ipAddresses", record.IPAddresses,
+		"emailAddresses", record.EmailAddresses,
+		"uris", record.URIs,
+		"decision", record.Decision,
+		"reason", record.Reason,
+	)
+	return nil
+}
+
+// webhookAuditSink posts the records in json to an external webhook endpoint
+type webhookAuditSink struct {
+	url    string
+	client *http.Client
+}
+
+func (w *webhookAuditSink) Record(ctx context.Context, record ApprovalRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post the approval record of csr %q: %v", record.CSRName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to post the approval record of csr %q: unexpected status code %d",
+			record.CSRName, resp.StatusCode)
+	}
+	return nil
+}
+
+// newApprovalRecord builds the approval record of a CSR, the subject alternative names are read from the
+// certificate request.
+func newApprovalRecord(csr csrInfo, clusterName string, decision ApprovalDecision, reason string) ApprovalRecord {
+	record := ApprovalRecord{
+		Timestamp:   metav1.Now(),
+		CSRName:     csr.name,
+		ClusterName: clusterName,
+		Requester:   csr.username,
+		SignerName:  csr.signerName,
+		Decision:    decision,
+		Reason:      reason,
+	}
+
+	block, _ := pem.Decode(csr.request)
+	if block == nil {
+		return record
+	}
+	x509cr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return record
+	}
+
+	record.CommonName = x509cr.Subject.CommonName
+	record.DNSNames = x509cr.DNSNames
+	record.EmailAddresses = x509cr.EmailAddresses
+	for _, ip := range x509cr.IPAddresses {
+		record.IPAddresses = append(record.IPAddresses, ip.String())
+	}
+	for _, uri := range x509cr.URIs {
+		record.URIs = append(record.URIs, uri.String())
+	}
+	return record
+}
+
+// recordApprovalDecision writes the approval decision on a CSR to the audit sink. A failure of the audit sink
+// does not block the approval, it is only reported.
+func recordApprovalDecision(ctx context.Context, sink AuditSink,
+	csr csrInfo, clusterName string, decision ApprovalDecision, reason string) {
+	tracing.AddEvent(ctx, "CSRApprovalDecision", attribute.String("decision", string(decision)))
+	if sink == nil {
+		return
+	}
+	if err := sink.Record(ctx, newApprovalRecord(csr, clusterName, decision, reason)); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to record the approval decision of csr %q: %v", csr.name, err))
+	}
+}
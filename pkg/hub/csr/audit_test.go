@@ -0,0 +1,168 @@
+package csr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+type fakeAuditSink struct {
+	records []ApprovalRecord
+}
+
+func (f *fakeAuditSink) Record(_ context.Context, record ApprovalRecord) error {
+	f.records = append(f.records, record)
+	return nil
+}
+
+func TestNewAuditSink(t *testing.T) {
+	cases := []struct {
+		name          string
+		sinkTypes     []string
+		webhookURL    string
+		expectedSinks int
+		expectedErr   string
+	}{
+		{
+			name:          "no sink",
+			expectedSinks: 0,
+		},
+		{
+			name:          "all sinks",
+			sinkTypes:     []string{AuditSinkEvent, AuditSinkLog, AuditSinkWebhook},
+			webhookURL:    "https://audit.example.com",
+			expectedSinks: 3,
+		},
+		{
+			name:        "webhook sink without url",
+			sinkTypes:   []string{AuditSinkWebhook},
+			expectedErr: "the webhook url is required by the \"webhook\" audit sink",
+		},
+		{
+			name:        "unsupported sink",
+			sinkTypes:   []string{"configmap"},
+			expectedErr: "unsupported audit sink \"configmap\", it must be one of event,log,webhook",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, err := NewAuditSink(c.sinkTypes, c.webhookURL, eventstesting.NewTestingEventRecorder(t))
+			testinghelpers.AssertError(t, err, c.expectedErr)
+			if err != nil {
+				return
+			}
+			if c.expectedSinks == 0 {
+				if sink != nil {
+					t.Errorf("expected nil sink, but got %v", sink)
+				}
+				return
+			}
+			if actual := len(sink.(multiAuditSink)); actual != c.expectedSinks {
+				t.Errorf("expected %d sinks, but got %d", c.expectedSinks, actual)
+			}
+		})
+	}
+}
+
+func TestWebhookAuditSink(t *testing.T) {
+	received := []ApprovalRecord{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record := ApprovalRecord{}
+		if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if record.CSRName == "rejected" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		received = append(received, record)
+	}))
+	defer server.Close()
+
+	sink := &webhookAuditSink{url: server.URL, client: server.Client()}
+	record := newApprovalRecord(newCSRInfo(testinghelpers.NewCSR(validCSR)), "managedcluster1",
+		ApprovalDecisionApproved, "approved")
+	if err := sink.Record(context.TODO(), record); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(received) != 1 {
+		t.Fatalf("expected 1 record, but got %d", len(received))
+	}
+	if received[0].CSRName != validCSR.Name || received[0].ClusterName != "managedcluster1" ||
+		received[0].Requester != validCSR.Username || received[0].CommonName != validCSR.CN ||
+		received[0].Decision != ApprovalDecisionApproved {
+		t.Errorf("unexpected record: %v", received[0])
+	}
+
+	record.CSRName = "rejected"
+	if err := sink.Record(context.TODO(), record); err == nil {
+		t.Errorf("expected error, but got nil")
+	}
+}
+
+func TestRenewalReconcilerAudit(t *testing.T) {
+	cases := []struct {
+		name             string
+		allowed          bool
+		expectedDecision ApprovalDecision
+	}{
+		{
+			name:             "approved",
+			allowed:          true,
+			expectedDecision: ApprovalDecisionApproved,
+		},
+		{
+			name:             "not approved",
+			allowed:          false,
+			expectedDecision: ApprovalDecisionNotApproved,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: c.allowed,
+						},
+					}, nil
+				},
+			)
+
+			sink := &fakeAuditSink{}
+			reconciler := NewCSRRenewalReconciler(kubeClient, nil, nil, sink, eventstesting.NewTestingEventRecorder(t))
+			_, err := reconciler.Reconcile(context.TODO(), newCSRInfo(testinghelpers.NewCSR(validCSR)),
+				func(_ kubernetes.Interface) error { return nil })
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if len(sink.records) != 1 {
+				t.Fatalf("expected 1 record, but got %d", len(sink.records))
+			}
+			if sink.records[0].Decision != c.expectedDecision {
+				t.Errorf("expected decision %q, but got %q", c.expectedDecision, sink.records[0].Decision)
+			}
+			if sink.records[0].ClusterName != "managedcluster1" {
+				t.Errorf("expected cluster managedcluster1, but got %q", sink.records[0].ClusterName)
+			}
+		})
+	}
+}
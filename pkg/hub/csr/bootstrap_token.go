@@ -0,0 +1,191 @@
+package csr
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+)
+
+const (
+	// BootstrapTokenClustersAnnotation is the annotation on a bootstrap token secret to scope the token to the
+	// managed clusters. Its value is a comma separated list of cluster names or name patterns (e.g. dev-*). The
+	// registration requests with a bootstrap token are automatically approved only if the cluster is in the
+	// scope of the token.
+	BootstrapTokenClustersAnnotation = "cluster.open-cluster-management.io/bootstrap-token-clusters"
+
+	bootstrapTokenUserPrefix   = "system:bootstrap:"
+	bootstrapTokenSecretPrefix = "bootstrap-token-"
+	bootstrapTokenNamespace    = "kube-system"
+
+	bootstrapTokenExpirationKey          = "expiration"
+	bootstrapTokenUsageAuthenticationKey = "usage-bootstrap-authentication"
+)
+
+// csrBootstrapTokenReconciler approves the registration requests which are submitted with the bootstrap tokens,
+// once the token is verified to be valid and scoped to the cluster.
+type csrBootstrapTokenReconciler struct {
+	kubeClient    kubernetes.Interface
+	clusterClient clusterclientset.Interface
+	clusterLister clusterv1listers.ManagedClusterLister
+	denyList      *ApprovalDenyList
+	sanAllowList  *SANAllowList
+	verifier      *IdentityVerifier
+	auditSink     AuditSink
+	eventRecorder events.Recorder
+}
+
+func NewCSRBootstrapTokenReconciler(kubeClient kubernetes.Interface,
+	clusterClient clusterclientset.Interface,
+	clusterLister clusterv1listers.ManagedClusterLister,
+	denyList *ApprovalDenyList,
+	sanAllowList *SANAllowList,
+	verifier *IdentityVerifier,
+	auditSink AuditSink,
+	recorder events.Recorder) Reconciler {
+	return &csrBootstrapTokenReconciler{
+		kubeClient:    kubeClient,
+		clusterClient: clusterClient,
+		clusterLister: clusterLister,
+		denyList:      denyList,
+		sanAllowList:  sanAllowList,
+		verifier:      verifier,
+		auditSink:     auditSink,
+		eventRecorder: recorder.WithComponentSuffix("csr-approving-controller"),
+	}
+}
+
+func (b *csrBootstrapTokenReconciler) Reconcile(ctx context.Context, csr csrInfo, approveCSR approveCSRFunc) (reconcileState, error) {
+	logger := klog.FromContext(ctx)
+
+	// Check whether current csr is a valid spoker cluster csr.
+	valid, clusterName, _ := validateCSR(csr)
+	if !valid {
+		logger.V(4).Info("CSR was not recognized")
+		return reconcileStop, nil
+	}
+
+	// Check whether current csr is submitted with a bootstrap token.
+	if !strings.HasPrefix(csr.username, bootstrapTokenUserPrefix) {
+		return reconcileContinue, nil
+	}
+
+	tokenID := strings.TrimPrefix(csr.username, bootstrapTokenUserPrefix)
+	inScope, reason, err := b.validateToken(ctx, tokenID, clusterName)
+	if err != nil {
+		return reconcileContinue, err
+	}
+	if !inScope {
+		logger.V(4).Info("Managed cluster csr cannot be auto approved", "reason", reason)
+		recordApprovalDecision(ctx, b.auditSink, csr, clusterName, ApprovalDecisionNotApproved, reason)
+		return reconcileStop, nil
+	}
+
+	// Check whether the cluster or the requester is blocked by the deny list.
+	if denied, reason := b.denyList.denied(clusterName, csr.username); denied {
+		logger.V(4).Info("Managed cluster csr cannot be auto approved", "reason", reason)
+		b.eventRecorder.Warningf("ManagedClusterAutoApprovalDenied", "spoke cluster %q is not auto approved: %s", clusterName, reason)
+		recordApprovalDecision(ctx, b.auditSink, csr, clusterName, ApprovalDecisionNotApproved, reason)
+		return reconcileStop, nil
+	}
+
+	// Check whether the subject alternative names in csr request are allowed.
+	if allowed, reason := b.sanAllowList.Allowed(csr.request); !allowed {
+		logger.V(4).Info("Managed cluster csr cannot be auto approved", "reason", reason)
+		b.eventRecorder.Warningf("ManagedClusterAutoApprovalDenied", "spoke cluster %q is not auto approved: %s", clusterName, reason)
+		recordApprovalDecision(ctx, b.auditSink, csr, clusterName, ApprovalDecisionNotApproved, reason)
+		return reconcileStop, nil
+	}
+
+	// Check whether the identity of the cluster is verified.
+	verified, reason, err := b.verifier.verify(ctx, csr, clusterName)
+	if err != nil {
+		return reconcileContinue, err
+	}
+	if !verified {
+		logger.V(4).Info("Managed cluster csr cannot be auto approved", "reason", reason)
+		b.eventRecorder.Warningf("ManagedClusterAutoApprovalDenied", "spoke cluster %q is not auto approved: %s", clusterName, reason)
+		recordApprovalDecision(ctx, b.auditSink, csr, clusterName, ApprovalDecisionNotApproved, reason)
+		return reconcileStop, nil
+	}
+
+	// Check whether the request is from another cluster than the joined cluster with the same name, the request
+	// verified with the pre-shared key of the cluster is from the cluster.
+	conflicted, reason, err := detectIdentityConflict(b.clusterLister, csr, clusterName, b.verifier != nil)
+	if err != nil {
+		return reconcileContinue, err
+	}
+	if conflicted {
+		logger.Info("Managed cluster csr cannot be auto approved", "reason", reason)
+		b.eventRecorder.Warningf("ManagedClusterIdentityConflict", "spoke cluster %q is not auto approved: %s", clusterName, reason)
+		recordApprovalDecision(ctx, b.auditSink, csr, clusterName, ApprovalDecisionNotApproved, reason)
+		if err := reportIdentityConflict(ctx, b.clusterClient, clusterName, reason); err != nil {
+			return reconcileContinue, err
+		}
+		return reconcileStop, nil
+	}
+
+	err = acceptCluster(ctx, b.clusterClient, b.clusterLister, clusterName)
+	if errors.IsNotFound(err) {
+		// Current spoke cluster not found, could have been deleted, do nothing.
+		return reconcileStop, nil
+	}
+	if err != nil {
+		return reconcileContinue, err
+	}
+
+	if err := approveCSR(b.kubeClient); err != nil {
+		return reconcileContinue, err
+	}
+	recordApprovalDecision(ctx, b.auditSink, csr, clusterName, ApprovalDecisionApproved,
+		fmt.Sprintf("The cluster is in the scope of the bootstrap token %q", tokenID))
+
+	b.eventRecorder.Eventf("ManagedClusterAutoApproved", "spoke cluster %q is auto approved with bootstrap token %q.", clusterName, tokenID)
+	return reconcileStop, nil
+}
+
+// validateToken checks whether the bootstrap token is valid for authentication, not expired, and scoped to
+// the cluster. It returns the reason if the cluster is not in the scope of the token.
+func (b *csrBootstrapTokenReconciler) validateToken(ctx context.Context, tokenID, clusterName string) (bool, string, error) {
+	secret, err := b.kubeClient.CoreV1().Secrets(bootstrapTokenNamespace).Get(ctx, bootstrapTokenSecretPrefix+tokenID, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, fmt.Sprintf("The bootstrap token %q is not found", tokenID), nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	if secret.Type != corev1.SecretTypeBootstrapToken ||
+		string(secret.Data[bootstrapTokenUsageAuthenticationKey]) != "true" {
+		return false, fmt.Sprintf("The bootstrap token %q is not valid for authentication", tokenID), nil
+	}
+
+	if expiration, ok := secret.Data[bootstrapTokenExpirationKey]; ok {
+		expirationTime, err := time.Parse(time.RFC3339, string(expiration))
+		if err != nil || !time.Now().Before(expirationTime) {
+			return false, fmt.Sprintf("The bootstrap token %q is expired", tokenID), nil
+		}
+	}
+
+	for _, pattern := range strings.Split(secret.Annotations[BootstrapTokenClustersAnnotation], ",") {
+		pattern = strings.TrimSpace(pattern)
+		if len(pattern) == 0 {
+			continue
+		}
+		if matched, _ := path.Match(pattern, clusterName); matched {
+			return true, "", nil
+		}
+	}
+	return false, fmt.Sprintf("The cluster %q is not in the scope of the bootstrap token %q", clusterName, tokenID), nil
+}
@@ -0,0 +1,158 @@
+package csr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func newBootstrapTokenSecret(tokenID string, expiration time.Time, clusters string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: bootstrapTokenNamespace,
+			Name:      bootstrapTokenSecretPrefix + tokenID,
+			Annotations: map[string]string{
+				BootstrapTokenClustersAnnotation: clusters,
+			},
+		},
+		Type: corev1.SecretTypeBootstrapToken,
+		Data: map[string][]byte{
+			"token-id":                           []byte(tokenID),
+			"token-secret":                       []byte("0123456789abcdef"),
+			bootstrapTokenExpirationKey:          []byte(expiration.Format(time.RFC3339)),
+			bootstrapTokenUsageAuthenticationKey: []byte("true"),
+		},
+	}
+}
+
+func TestBootstrapTokenReconcile(t *testing.T) {
+	cases := []struct {
+		name             string
+		username         string
+		secrets          []runtime.Object
+		deniedClusters   []string
+		expectedState    reconcileState
+		expectedApproved bool
+		expectedDecision ApprovalDecision
+	}{
+		{
+			name:          "not a bootstrap token user",
+			username:      "test",
+			expectedState: reconcileContinue,
+		},
+		{
+			name:             "token not found",
+			username:         "system:bootstrap:abcdef",
+			expectedState:    reconcileStop,
+			expectedDecision: ApprovalDecisionNotApproved,
+		},
+		{
+			name:             "token expired",
+			username:         "system:bootstrap:abcdef",
+			secrets:          []runtime.Object{newBootstrapTokenSecret("abcdef", time.Now().Add(-time.Hour), "*")},
+			expectedState:    reconcileStop,
+			expectedDecision: ApprovalDecisionNotApproved,
+		},
+		{
+			name:             "cluster not in scope",
+			username:         "system:bootstrap:abcdef",
+			secrets:          []runtime.Object{newBootstrapTokenSecret("abcdef", time.Now().Add(time.Hour), "dev-*,cluster2")},
+			expectedState:    reconcileStop,
+			expectedDecision: ApprovalDecisionNotApproved,
+		},
+		{
+			name:             "cluster in scope but denied",
+			username:         "system:bootstrap:abcdef",
+			secrets:          []runtime.Object{newBootstrapTokenSecret("abcdef", time.Now().Add(time.Hour), "managed*")},
+			deniedClusters:   []string{"managedcluster1"},
+			expectedState:    reconcileStop,
+			expectedDecision: ApprovalDecisionNotApproved,
+		},
+		{
+			name:             "cluster in scope",
+			username:         "system:bootstrap:abcdef",
+			secrets:          []runtime.Object{newBootstrapTokenSecret("abcdef", time.Now().Add(time.Hour), "dev-*, managed*")},
+			expectedState:    reconcileStop,
+			expectedApproved: true,
+			expectedDecision: ApprovalDecisionApproved,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(c.secrets...)
+
+			cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "managedcluster1"}}
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			denyList, err := NewApprovalDenyList(c.deniedClusters, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sink := &fakeAuditSink{}
+			reconciler := NewCSRBootstrapTokenReconciler(
+				kubeClient,
+				clusterClient,
+				clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				denyList,
+				nil,
+				nil,
+				sink,
+				eventstesting.NewTestingEventRecorder(t),
+			)
+
+			csr := testinghelpers.NewCSR(validCSR)
+			csr.Spec.Username = c.username
+			approved := false
+			state, err := reconciler.Reconcile(context.TODO(), newCSRInfo(csr), func(_ kubernetes.Interface) error {
+				approved = true
+				return nil
+			})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if state != c.expectedState {
+				t.Errorf("expected state %v, but got %v", c.expectedState, state)
+			}
+			if approved != c.expectedApproved {
+				t.Errorf("expected approved %v, but got %v", c.expectedApproved, approved)
+			}
+
+			if len(c.expectedDecision) == 0 {
+				if len(sink.records) != 0 {
+					t.Errorf("expected no record, but got %v", sink.records)
+				}
+				return
+			}
+			if len(sink.records) != 1 || sink.records[0].Decision != c.expectedDecision {
+				t.Errorf("expected decision %q, but got %v", c.expectedDecision, sink.records)
+			}
+
+			if c.expectedApproved {
+				testinghelpers.AssertActions(t, clusterClient.Actions(), "patch")
+				patch := clusterClient.Actions()[0].(clienttesting.PatchActionImpl).Patch
+				if string(patch) != `{"spec": {"hubAcceptsClient": true}}` {
+					t.Errorf("unexpected patch %s", string(patch))
+				}
+			}
+		})
+	}
+}
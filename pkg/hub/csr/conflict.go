@@ -0,0 +1,85 @@
+package csr
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/helpers"
+)
+
+// ManagedClusterConditionIdentityConflict is the condition type set on a joined managed cluster once a registration
+// request for its name is submitted from an unverified cluster, it usually means the cluster name is being hijacked.
+// The condition is cleared once no registration request of the cluster is pending.
+const ManagedClusterConditionIdentityConflict = "ClusterIdentityConflict"
+
+// detectIdentityConflict returns true and the reason if the cluster is already joined and available with its
+// id.k8s.io claim reported, and the registration request is not verified to be from the cluster. The cluster id in
+// the request is asserted by the requester, so it is not regarded as the identity of the cluster, and the requests
+// are only verified with the pre-shared keys of the clusters.
+func detectIdentityConflict(clusterLister clusterv1listers.ManagedClusterLister, csr csrInfo, clusterName string,
+	identityVerified bool) (bool, string, error) {
+	if identityVerified {
+		return false, "", nil
+	}
+
+	cluster, err := clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	if !meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionJoined) ||
+		!meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable) {
+		return false, "", nil
+	}
+
+	var claimedID string
+	for _, claim := range cluster.Status.ClusterClaims {
+		if claim.Name == clientcert.ClusterIDClaimName {
+			claimedID = claim.Value
+		}
+	}
+	if len(claimedID) == 0 {
+		return false, "", nil
+	}
+
+	reason := fmt.Sprintf("The joined cluster %q has the cluster id %q, but the registration request %q is not "+
+		"verified to be from the cluster", clusterName, claimedID, csr.name)
+	if requestedID, ok := csr.annotations[clientcert.ClusterIDAnnotation]; ok {
+		reason = fmt.Sprintf("%s, it claims the cluster id %q", reason, requestedID)
+	}
+	return true, reason, nil
+}
+
+// reportIdentityConflict sets the identity conflict condition of the cluster
+func reportIdentityConflict(ctx context.Context, clusterClient clusterclientset.Interface, clusterName, reason string) error {
+	_, _, err := helpers.UpdateManagedClusterStatus(ctx, clusterClient, clusterName,
+		helpers.UpdateManagedClusterConditionFn(metav1.Condition{
+			Type:    ManagedClusterConditionIdentityConflict,
+			Status:  metav1.ConditionTrue,
+			Reason:  "RegistrationRequestFromAnotherCluster",
+			Message: reason,
+		}))
+	return err
+}
+
+// identityConflictResolvedCondition is the condition set on the cluster with the identity conflict once no
+// registration request of the cluster is pending, i.e. the conflicting requests are approved, denied or removed.
+func identityConflictResolvedCondition() metav1.Condition {
+	return metav1.Condition{
+		Type:    ManagedClusterConditionIdentityConflict,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NoPendingRegistrationRequest",
+		Message: "No registration request of the cluster is pending",
+	}
+}
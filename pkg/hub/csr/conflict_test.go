@@ -0,0 +1,126 @@
+package csr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/clientcert"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestDetectIdentityConflict(t *testing.T) {
+	cases := []struct {
+		name               string
+		cluster            *clusterv1.ManagedCluster
+		clusterID          string
+		identityVerified   bool
+		expectedConflicted bool
+	}{
+		{
+			name:      "cluster not found",
+			clusterID: "id1",
+		},
+		{
+			name:      "cluster not joined",
+			cluster:   newClusterWithID(testinghelpers.NewAvailableManagedCluster(), "id1"),
+			clusterID: "id2",
+		},
+		{
+			name:      "available cluster without id claim",
+			cluster:   newJoinedAvailableCluster(),
+			clusterID: "id2",
+		},
+		{
+			name:               "same cluster id is not verified",
+			cluster:            newClusterWithID(newJoinedAvailableCluster(), "id1"),
+			clusterID:          "id1",
+			expectedConflicted: true,
+		},
+		{
+			name:             "identity is verified",
+			cluster:          newClusterWithID(newJoinedAvailableCluster(), "id1"),
+			clusterID:        "id2",
+			identityVerified: true,
+		},
+		{
+			name:               "different cluster id",
+			cluster:            newClusterWithID(newJoinedAvailableCluster(), "id1"),
+			clusterID:          "id2",
+			expectedConflicted: true,
+		},
+		{
+			name:               "no cluster id",
+			cluster:            newClusterWithID(newJoinedAvailableCluster(), "id1"),
+			expectedConflicted: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterfake.NewSimpleClientset(), 10*time.Minute)
+			if c.cluster != nil {
+				if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			csr := testinghelpers.NewCSR(validCSR)
+			if len(c.clusterID) > 0 {
+				csr.Annotations = map[string]string{clientcert.ClusterIDAnnotation: c.clusterID}
+			}
+			conflicted, reason, err := detectIdentityConflict(
+				clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(), newCSRInfo(csr), testinghelpers.TestManagedClusterName,
+				c.identityVerified)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if conflicted != c.expectedConflicted {
+				t.Errorf("expected conflicted %v, but got %v", c.expectedConflicted, conflicted)
+			}
+			if conflicted && len(reason) == 0 {
+				t.Errorf("expected reason, but got empty")
+			}
+		})
+	}
+}
+
+func TestReportIdentityConflict(t *testing.T) {
+	cluster := newClusterWithID(newJoinedAvailableCluster(), "id1")
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+
+	if err := reportIdentityConflict(context.TODO(), clusterClient, cluster.Name, "conflicted"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated, err := clusterClient.ClusterV1().ManagedClusters().Get(context.TODO(), cluster.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !meta.IsStatusConditionTrue(updated.Status.Conditions, ManagedClusterConditionIdentityConflict) {
+		t.Errorf("expected identity conflict condition, but got %v", updated.Status.Conditions)
+	}
+}
+
+func newClusterWithID(cluster *clusterv1.ManagedCluster, clusterID string) *clusterv1.ManagedCluster {
+	cluster.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{
+		{Name: clientcert.ClusterIDClaimName, Value: clusterID},
+	}
+	return cluster
+}
+
+func newJoinedAvailableCluster() *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewJoinedManagedCluster()
+	cluster.Status.Conditions = append(cluster.Status.Conditions, metav1.Condition{
+		Type:   clusterv1.ManagedClusterConditionAvailable,
+		Status: metav1.ConditionTrue,
+		Reason: "ManagedClusterAvailable",
+	})
+	return cluster
+}
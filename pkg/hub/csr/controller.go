@@ -2,6 +2,8 @@ package csr
 
 import (
 	"context"
+	goerrors "errors"
+
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 	certificatesv1 "k8s.io/api/certificates/v1"
@@ -13,8 +15,12 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+	"open-cluster-management.io/registration/pkg/tracing"
 )
 
 type CSR interface {
@@ -25,6 +31,10 @@ type CSRLister[T CSR] interface {
 	Get(name string) (T, error)
 }
 
+// errCSRDecided is returned by the approveCSRFunc if the csr is approved or denied by another approver in the
+// meantime, e.g. another replica of the hub controller before the leader election wins or during a failover
+var errCSRDecided = goerrors.New("the csr is already approved or denied")
+
 type CSRApprover[T CSR] interface {
 	approve(ctx context.Context, csr T) approveCSRFunc
 	isInTerminalState(csr T) bool
@@ -55,13 +65,13 @@ func NewCSRApprovingController[T CSR](
 			accessor, _ := meta.Accessor(obj)
 			return accessor.GetName()
 		}, csrInformer).
-		WithSync(c.sync).
+		WithSync(tracing.TraceSync("CSRApprovingController", c.sync)).
 		ToController("CSRApprovingController", recorder)
 }
 
 func (c *csrApprovingController[T]) sync(ctx context.Context, syncCtx factory.SyncContext) error {
 	csrName := syncCtx.QueueKey()
-	klog.V(4).Infof("Reconciling CertificateSigningRequests %q", csrName)
+	klog.FromContext(ctx).V(4).Info("Reconciling CertificateSigningRequest")
 
 	csr, err := c.lister.Get(csrName)
 	if errors.IsNotFound(err) {
@@ -76,8 +86,17 @@ func (c *csrApprovingController[T]) sync(ctx context.Context, syncCtx factory.Sy
 	}
 
 	csrInfo := newCSRInfo(csr)
+	ctx, _ = logging.WithCluster(ctx, csrInfo.labels[clusterv1.ClusterNameLabelKey])
+	if accessor, err := meta.Accessor(csr); err == nil {
+		tracing.SetCluster(ctx, csrInfo.labels[clusterv1.ClusterNameLabelKey], accessor.GetCreationTimestamp())
+	}
 	for _, r := range c.reconcilers {
 		state, err := r.Reconcile(ctx, csrInfo, c.approver.approve(ctx, csr))
+		if goerrors.Is(err, errCSRDecided) {
+			// the approval is idempotent, the csr decided by another approver is not approved again
+			klog.FromContext(ctx).V(4).Info("CertificateSigningRequest is already decided by another approver")
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -104,16 +123,22 @@ func (c *CSRV1Approver) isInTerminalState(csr *certificatesv1.CertificateSigning
 
 func (c *CSRV1Approver) approve(ctx context.Context, csr *certificatesv1.CertificateSigningRequest) approveCSRFunc {
 	return func(kubeClient kubernetes.Interface) error {
-		csrCopy := csr.DeepCopy()
-		// Auto approve the spoke cluster csr
-		csrCopy.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
-			Type:    certificatesv1.CertificateApproved,
-			Status:  corev1.ConditionTrue,
-			Reason:  "AutoApprovedByHubCSRApprovingController",
-			Message: "Auto approving Managed cluster agent certificate after SubjectAccessReview.",
-		})
-		_, err := kubeClient.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csrCopy.Name, csrCopy, metav1.UpdateOptions{})
-		return err
+		return updateApprovalOnConflict(csr, c.isInTerminalState,
+			func() (*certificatesv1.CertificateSigningRequest, error) {
+				return kubeClient.CertificatesV1().CertificateSigningRequests().Get(ctx, csr.Name, metav1.GetOptions{})
+			},
+			func(csr *certificatesv1.CertificateSigningRequest) error {
+				csrCopy := csr.DeepCopy()
+				// Auto approve the spoke cluster csr
+				csrCopy.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+					Type:    certificatesv1.CertificateApproved,
+					Status:  corev1.ConditionTrue,
+					Reason:  "AutoApprovedByHubCSRApprovingController",
+					Message: "Auto approving Managed cluster agent certificate after SubjectAccessReview.",
+				})
+				_, err := kubeClient.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csrCopy.Name, csrCopy, metav1.UpdateOptions{})
+				return err
+			})
 	}
 }
 
@@ -131,15 +156,45 @@ func (c *CSRV1beta1Approver) isInTerminalState(csr *certificatesv1beta1.Certific
 
 func (c *CSRV1beta1Approver) approve(ctx context.Context, csr *certificatesv1beta1.CertificateSigningRequest) approveCSRFunc {
 	return func(kubeClient kubernetes.Interface) error {
-		csrCopy := csr.DeepCopy()
-		// Auto approve the spoke cluster csr
-		csrCopy.Status.Conditions = append(csr.Status.Conditions, certificatesv1beta1.CertificateSigningRequestCondition{
-			Type:    certificatesv1beta1.CertificateApproved,
-			Status:  corev1.ConditionTrue,
-			Reason:  "AutoApprovedByHubCSRApprovingController",
-			Message: "Auto approving Managed cluster agent certificate after SubjectAccessReview.",
-		})
-		_, err := kubeClient.CertificatesV1beta1().CertificateSigningRequests().UpdateApproval(ctx, csrCopy, metav1.UpdateOptions{})
-		return err
+		return updateApprovalOnConflict(csr, c.isInTerminalState,
+			func() (*certificatesv1beta1.CertificateSigningRequest, error) {
+				return kubeClient.CertificatesV1beta1().CertificateSigningRequests().Get(ctx, csr.Name, metav1.GetOptions{})
+			},
+			func(csr *certificatesv1beta1.CertificateSigningRequest) error {
+				csrCopy := csr.DeepCopy()
+				// Auto approve the spoke cluster csr
+				csrCopy.Status.Conditions = append(csr.Status.Conditions, certificatesv1beta1.CertificateSigningRequestCondition{
+					Type:    certificatesv1beta1.CertificateApproved,
+					Status:  corev1.ConditionTrue,
+					Reason:  "AutoApprovedByHubCSRApprovingController",
+					Message: "Auto approving Managed cluster agent certificate after SubjectAccessReview.",
+				})
+				_, err := kubeClient.CertificatesV1beta1().CertificateSigningRequests().UpdateApproval(ctx, csrCopy, metav1.UpdateOptions{})
+				return err
+			})
+	}
+}
+
+// updateApprovalOnConflict updates the approval of the csr with optimistic concurrency. The csr from the lister may
+// be stale, so the approval is retried with the latest csr once it conflicts, and errCSRDecided is returned if the
+// latest csr is already approved or denied, e.g. by another replica of the hub controller.
+func updateApprovalOnConflict[T CSR](csr T, isInTerminalState func(T) bool, get func() (T, error), updateApproval func(T) error) error {
+	if isInTerminalState(csr) {
+		return errCSRDecided
 	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := updateApproval(csr)
+		if !errors.IsConflict(err) {
+			return err
+		}
+		latest, getErr := get()
+		if getErr != nil {
+			return getErr
+		}
+		if isInTerminalState(latest) {
+			return errCSRDecided
+		}
+		csr = latest
+		return err
+	})
 }
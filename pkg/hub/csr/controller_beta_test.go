@@ -161,3 +161,21 @@ func Test_v1beta1CSRApprovingController_sync(t *testing.T) {
 		})
 	}
 }
+
+func TestNewV1beta1CSRInfo(t *testing.T) {
+	csr := testinghelpers.NewV1beta1CSR(validV1beta1CSR)
+	info := newCSRInfo(csr)
+	if info.signerName != certificatesv1beta1.KubeAPIServerClientSignerName {
+		t.Errorf("expected signer name %q, but got %q", certificatesv1beta1.KubeAPIServerClientSignerName, info.signerName)
+	}
+
+	// the signer name of the v1beta1 csrs is optional
+	csr.Spec.SignerName = nil
+	info = newCSRInfo(csr)
+	if info.signerName != "" {
+		t.Errorf("expected empty signer name, but got %q", info.signerName)
+	}
+	if isRenewal, _, _ := validateCSR(info); isRenewal {
+		t.Errorf("expected the csr without signer name is not a renewal csr")
+	}
+}
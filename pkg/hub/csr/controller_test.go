@@ -2,12 +2,16 @@ package csr
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/helpers"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 	"open-cluster-management.io/registration/pkg/hub/user"
 
@@ -16,12 +20,15 @@ import (
 	authorizationv1 "k8s.io/api/authorization/v1"
 	certificatesv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
 )
 
 var (
@@ -42,6 +49,8 @@ func TestSync(t *testing.T) {
 		startingClusters     []runtime.Object
 		startingCSRs         []runtime.Object
 		approvalUsers        []string
+		deniedClusters       []string
+		deniedUsers          []string
 		autoApprovingAllowed bool
 		validateActions      func(t *testing.T, actions []clienttesting.Action)
 	}{
@@ -136,6 +145,26 @@ func TestSync(t *testing.T) {
 				testinghelpers.AssertCSRCondition(t, actual.(*certificatesv1.CertificateSigningRequest).Status.Conditions, expectedCondition)
 			},
 		},
+		{
+			name:                 "deny a renewal csr of a denied cluster",
+			startingClusters:     []runtime.Object{},
+			startingCSRs:         []runtime.Object{testinghelpers.NewCSR(validCSR)},
+			autoApprovingAllowed: true,
+			deniedClusters:       []string{"managed*"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:                 "deny a renewal csr of a denied user",
+			startingClusters:     []runtime.Object{},
+			startingCSRs:         []runtime.Object{testinghelpers.NewCSR(validCSR)},
+			autoApprovingAllowed: true,
+			deniedUsers:          []string{validCSR.Username},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
 		{
 			name: "auto approve a bootstrap csr request",
 			startingClusters: []runtime.Object{
@@ -164,6 +193,48 @@ func TestSync(t *testing.T) {
 				testinghelpers.AssertCSRCondition(t, actual.(*certificatesv1.CertificateSigningRequest).Status.Conditions, expectedCondition)
 			},
 		},
+		{
+			name: "deny a bootstrap csr request of a denied cluster",
+			startingClusters: []runtime.Object{
+				&clusterv1.ManagedCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "managedcluster1",
+					},
+				},
+			},
+			startingCSRs: []runtime.Object{func() *certificatesv1.CertificateSigningRequest {
+				csr := testinghelpers.NewCSR(validCSR)
+				csr.Spec.Username = "test"
+				return csr
+			}()},
+			autoApprovingAllowed: true,
+			approvalUsers:        []string{"test"},
+			deniedClusters:       []string{"managed*"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "deny a bootstrap csr request of a denied user",
+			startingClusters: []runtime.Object{
+				&clusterv1.ManagedCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "managedcluster1",
+					},
+				},
+			},
+			startingCSRs: []runtime.Object{func() *certificatesv1.CertificateSigningRequest {
+				csr := testinghelpers.NewCSR(validCSR)
+				csr.Spec.Username = "test"
+				return csr
+			}()},
+			autoApprovingAllowed: true,
+			approvalUsers:        []string{"test"},
+			deniedUsers:          []string{"test"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -197,6 +268,11 @@ func TestSync(t *testing.T) {
 				}
 			}
 
+			denyList, err := NewApprovalDenyList(c.deniedClusters, c.deniedUsers)
+			if err != nil {
+				t.Fatal(err)
+			}
+
 			recorder := eventstesting.NewTestingEventRecorder(t)
 			ctrl := &csrApprovingController[*certificatesv1.CertificateSigningRequest]{
 				lister:   informerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
@@ -207,12 +283,16 @@ func TestSync(t *testing.T) {
 						eventRecorder: recorder,
 						approvalUsers: sets.Set[string]{},
 					},
-					NewCSRRenewalReconciler(kubeClient, recorder),
+					NewCSRRenewalReconciler(kubeClient, denyList, nil, nil, recorder),
 					NewCSRBootstrapReconciler(
 						kubeClient,
 						clusterClient,
 						clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
 						c.approvalUsers,
+						denyList,
+						nil,
+						nil,
+						nil,
 						recorder,
 					),
 				},
@@ -325,3 +405,284 @@ func TestIsSpokeClusterClientCertRenewal(t *testing.T) {
 		})
 	}
 }
+
+func TestCSRV1ApproverWithDelayedApproval(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	csr := testinghelpers.NewCSR(validCSR)
+	kubeClient := kubefake.NewSimpleClientset(csr)
+	testinghelpers.DelayCSRApproval(&kubeClient.Fake, fakeClock, time.Minute)
+
+	approve := NewCSRV1Approver(kubeClient).approve(context.TODO(), csr)
+	if err := approve(kubeClient); !errors.IsTooManyRequests(err) {
+		t.Errorf("expected the approval is delayed, but got %v", err)
+	}
+
+	// the csr is approved once the clock passes the delay
+	fakeClock.Step(time.Minute)
+	if err := approve(kubeClient); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	approved, err := kubeClient.CertificatesV1().CertificateSigningRequests().Get(context.TODO(), csr.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !helpers.IsCSRInTerminalState(&approved.Status) {
+		t.Errorf("expected the csr is approved, but got %v", approved.Status)
+	}
+}
+
+func TestCSRV1ApproverOnConflict(t *testing.T) {
+	cases := []struct {
+		name            string
+		csr             *certificatesv1.CertificateSigningRequest
+		decided         *certificatesv1.CertificateSigningRequest
+		conflict        bool
+		expectedErr     error
+		expectedVerbs   []string
+		expectedDecided string
+	}{
+		{
+			name:            "approve the csr after a conflict",
+			csr:             testinghelpers.NewCSR(validCSR),
+			conflict:        true,
+			expectedVerbs:   []string{"update", "get", "update"},
+			expectedDecided: string(certificatesv1.CertificateApproved),
+		},
+		{
+			name:            "the csr is approved by another approver",
+			csr:             testinghelpers.NewCSR(validCSR),
+			decided:         testinghelpers.NewApprovedCSR(validCSR),
+			conflict:        true,
+			expectedErr:     errCSRDecided,
+			expectedVerbs:   []string{"update", "get"},
+			expectedDecided: string(certificatesv1.CertificateApproved),
+		},
+		{
+			name:            "the csr is denied by another approver",
+			csr:             testinghelpers.NewCSR(validCSR),
+			decided:         testinghelpers.NewDeniedCSR(validCSR),
+			conflict:        true,
+			expectedErr:     errCSRDecided,
+			expectedVerbs:   []string{"update", "get"},
+			expectedDecided: string(certificatesv1.CertificateDenied),
+		},
+		{
+			name:            "the csr is already approved",
+			csr:             testinghelpers.NewApprovedCSR(validCSR),
+			expectedErr:     errCSRDecided,
+			expectedDecided: string(certificatesv1.CertificateApproved),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(c.csr)
+			if c.conflict {
+				concurrentApproval(kubeClient, c.decided)
+			}
+
+			err := NewCSRV1Approver(kubeClient).approve(context.TODO(), c.csr)(kubeClient)
+			if err != c.expectedErr {
+				t.Errorf("expected err %v, but got %v", c.expectedErr, err)
+			}
+			testinghelpers.AssertActions(t, kubeClient.Actions(), c.expectedVerbs...)
+
+			actual, err := kubeClient.CertificatesV1().CertificateSigningRequests().Get(context.TODO(), c.csr.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(actual.Status.Conditions) != 1 || string(actual.Status.Conditions[0].Type) != c.expectedDecided {
+				t.Errorf("expected the csr is decided with %s once, but got %v", c.expectedDecided, actual.Status.Conditions)
+			}
+		})
+	}
+}
+
+func TestSyncWithConcurrentApproval(t *testing.T) {
+	csr := testinghelpers.NewCSR(validCSR)
+	kubeClient := kubefake.NewSimpleClientset(csr)
+	kubeClient.PrependReactor(
+		"create",
+		"subjectaccessreviews",
+		func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+			return true, &authorizationv1.SubjectAccessReview{
+				Status: authorizationv1.SubjectAccessReviewStatus{Allowed: true},
+			}, nil
+		},
+	)
+	// another replica of the hub controller approves the csr after it is listed
+	concurrentApproval(kubeClient, testinghelpers.NewApprovedCSR(validCSR))
+
+	informerFactory := informers.NewSharedInformerFactory(kubeClient, 3*time.Minute)
+	if err := informerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore().Add(csr); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := eventstesting.NewTestingEventRecorder(t)
+	ctrl := &csrApprovingController[*certificatesv1.CertificateSigningRequest]{
+		lister:      informerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+		approver:    NewCSRV1Approver(kubeClient),
+		reconcilers: []Reconciler{NewCSRRenewalReconciler(kubeClient, nil, nil, nil, recorder)},
+	}
+	if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, validCSR.Name)); err != nil {
+		t.Errorf("expected the csr approved by another approver is not an error, but got %v", err)
+	}
+	testinghelpers.AssertActions(t, kubeClient.Actions(), "create", "update", "get")
+}
+
+// concurrentApproval simulates another approver which decides the csr right before the first approval of the csr
+// on the fake clientset, so the first approval conflicts. The csr is not decided if decided is nil.
+func concurrentApproval(kubeClient *kubefake.Clientset, decided *certificatesv1.CertificateSigningRequest) {
+	var once sync.Once
+	kubeClient.PrependReactor("update", "certificatesigningrequests", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "approval" {
+			return false, nil, nil
+		}
+		first := false
+		once.Do(func() { first = true })
+		if !first {
+			return false, nil, nil
+		}
+		if decided != nil {
+			if err := kubeClient.Tracker().Update(
+				certificatesv1.SchemeGroupVersion.WithResource("certificatesigningrequests"), decided, ""); err != nil {
+				return true, nil, err
+			}
+		}
+		name := action.(clienttesting.UpdateAction).GetObject().(*certificatesv1.CertificateSigningRequest).Name
+		return true, nil, errors.NewConflict(certificatesv1.Resource("certificatesigningrequests"), name,
+			fmt.Errorf("the object has been modified"))
+	})
+}
+
+func TestServingReconcile(t *testing.T) {
+	servingCSR := validCSR
+	servingCSR.SignerName = helpers.ClusterServingSignerName
+	servingCSR.DNSNames = []string{"cluster1.example.com"}
+
+	cases := []struct {
+		name             string
+		csr              testinghelpers.CSRHolder
+		usages           []certificatesv1.KeyUsage
+		deniedClusters   []string
+		allowed          bool
+		expectedState    reconcileState
+		expectedApproved bool
+		expectedDecision ApprovalDecision
+	}{
+		{
+			name:          "csr of other signers",
+			csr:           validCSR,
+			expectedState: reconcileContinue,
+		},
+		{
+			name: "not requested by the agent",
+			csr: func() testinghelpers.CSRHolder {
+				holder := servingCSR
+				holder.Username = "bootstrap"
+				return holder
+			}(),
+			usages:        clientcert.ServingCertUsages,
+			allowed:       true,
+			expectedState: reconcileStop,
+		},
+		{
+			name:             "cluster is denied",
+			csr:              servingCSR,
+			usages:           clientcert.ServingCertUsages,
+			deniedClusters:   []string{"managedcluster1"},
+			allowed:          true,
+			expectedState:    reconcileStop,
+			expectedDecision: ApprovalDecisionNotApproved,
+		},
+		{
+			name:             "client auth usage",
+			csr:              servingCSR,
+			usages:           clientcert.ClientCertUsages,
+			allowed:          true,
+			expectedState:    reconcileStop,
+			expectedDecision: ApprovalDecisionNotApproved,
+		},
+		{
+			name: "dns name not allowed",
+			csr: func() testinghelpers.CSRHolder {
+				holder := servingCSR
+				holder.DNSNames = []string{"cluster1.other.com"}
+				return holder
+			}(),
+			usages:           clientcert.ServingCertUsages,
+			allowed:          true,
+			expectedState:    reconcileStop,
+			expectedDecision: ApprovalDecisionNotApproved,
+		},
+		{
+			name:             "not authorized",
+			csr:              servingCSR,
+			usages:           clientcert.ServingCertUsages,
+			expectedState:    reconcileStop,
+			expectedDecision: ApprovalDecisionNotApproved,
+		},
+		{
+			name:             "approved",
+			csr:              servingCSR,
+			usages:           clientcert.ServingCertUsages,
+			allowed:          true,
+			expectedState:    reconcileStop,
+			expectedApproved: true,
+			expectedDecision: ApprovalDecisionApproved,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: c.allowed,
+						},
+					}, nil
+				},
+			)
+			denyList, err := NewApprovalDenyList(c.deniedClusters, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sanAllowList, err := NewSANAllowList([]string{"*.example.com"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sink := &fakeAuditSink{}
+			reconciler := NewCSRServingReconciler(kubeClient, denyList, sanAllowList, sink, eventstesting.NewTestingEventRecorder(t))
+			csr := testinghelpers.NewCSR(c.csr)
+			csr.Spec.Usages = c.usages
+			approved := false
+			state, err := reconciler.Reconcile(context.TODO(), newCSRInfo(csr), func(_ kubernetes.Interface) error {
+				approved = true
+				return nil
+			})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if state != c.expectedState {
+				t.Errorf("expected state %v, but got %v", c.expectedState, state)
+			}
+			if approved != c.expectedApproved {
+				t.Errorf("expected approved %v, but got %v", c.expectedApproved, approved)
+			}
+			if len(c.expectedDecision) == 0 {
+				if len(sink.records) != 0 {
+					t.Errorf("expected no record, but got %v", sink.records)
+				}
+				return
+			}
+			if len(sink.records) != 1 || sink.records[0].Decision != c.expectedDecision {
+				t.Errorf("expected decision %q, but got %v", c.expectedDecision, sink.records)
+			}
+		})
+	}
+}
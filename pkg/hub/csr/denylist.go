@@ -0,0 +1,49 @@
+package csr
+
+import (
+	"fmt"
+	"path"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ApprovalDenyList blocks the auto approval of the CSRs from the specific clusters or requesters, even if they
+// otherwise qualify. It is used to quarantine the compromised bootstrap credentials without disabling the auto
+// approval globally.
+type ApprovalDenyList struct {
+	// clusterPatterns are the cluster names or the shell file name patterns of the cluster names, e.g. "dev-*"
+	clusterPatterns []string
+	users           sets.Set[string]
+}
+
+// NewApprovalDenyList returns an ApprovalDenyList with the cluster name patterns and the requester names. An
+// error is returned if any of the patterns is malformed.
+func NewApprovalDenyList(clusterPatterns, users []string) (*ApprovalDenyList, error) {
+	for _, pattern := range clusterPatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("the denied cluster pattern %q is invalid: %v", pattern, err)
+		}
+	}
+	return &ApprovalDenyList{
+		clusterPatterns: clusterPatterns,
+		users:           sets.New(users...),
+	}, nil
+}
+
+// denied returns true and the reason if the CSR of the cluster from the requester is blocked. A nil deny list
+// blocks nothing.
+func (d *ApprovalDenyList) denied(clusterName, username string) (bool, string) {
+	if d == nil {
+		return false, ""
+	}
+	if d.users.Has(username) {
+		return true, fmt.Sprintf("The requester %q is in the auto approval deny list", username)
+	}
+	for _, pattern := range d.clusterPatterns {
+		// the patterns are validated in NewApprovalDenyList
+		if matched, _ := path.Match(pattern, clusterName); matched {
+			return true, fmt.Sprintf("The cluster %q matches %q in the auto approval deny list", clusterName, pattern)
+		}
+	}
+	return false, ""
+}
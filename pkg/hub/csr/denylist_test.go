@@ -0,0 +1,85 @@
+package csr
+
+import (
+	"testing"
+)
+
+func TestApprovalDenyList(t *testing.T) {
+	cases := []struct {
+		name            string
+		clusterPatterns []string
+		users           []string
+		clusterName     string
+		username        string
+		expectedErr     bool
+		expectedDenied  bool
+	}{
+		{
+			name:           "empty deny list",
+			clusterName:    "cluster1",
+			username:       "bootstrap",
+			expectedDenied: false,
+		},
+		{
+			name:            "denied by cluster name",
+			clusterPatterns: []string{"cluster1"},
+			clusterName:     "cluster1",
+			username:        "bootstrap",
+			expectedDenied:  true,
+		},
+		{
+			name:            "denied by cluster name pattern",
+			clusterPatterns: []string{"dev-*"},
+			clusterName:     "dev-cluster1",
+			username:        "bootstrap",
+			expectedDenied:  true,
+		},
+		{
+			name:            "cluster name pattern not matched",
+			clusterPatterns: []string{"dev-*"},
+			clusterName:     "prod-cluster1",
+			username:        "bootstrap",
+			expectedDenied:  false,
+		},
+		{
+			name:           "denied by user",
+			users:          []string{"bootstrap"},
+			clusterName:    "cluster1",
+			username:       "bootstrap",
+			expectedDenied: true,
+		},
+		{
+			name:            "invalid pattern",
+			clusterPatterns: []string{"dev-["},
+			expectedErr:     true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			denyList, err := NewApprovalDenyList(c.clusterPatterns, c.users)
+			if c.expectedErr {
+				if err == nil {
+					t.Errorf("expected error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			denied, reason := denyList.denied(c.clusterName, c.username)
+			if denied != c.expectedDenied {
+				t.Errorf("expected denied %v, but got %v", c.expectedDenied, denied)
+			}
+			if denied && len(reason) == 0 {
+				t.Errorf("expected reason, but got empty")
+			}
+		})
+	}
+
+	var nilDenyList *ApprovalDenyList
+	if denied, _ := nilDenyList.denied("cluster1", "bootstrap"); denied {
+		t.Errorf("expected nil deny list blocks nothing")
+	}
+}
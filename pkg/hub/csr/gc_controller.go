@@ -0,0 +1,135 @@
+package csr
+
+import (
+	"context"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	certificatesv1informers "k8s.io/client-go/informers/certificates/v1"
+	"k8s.io/client-go/kubernetes"
+	certificatesv1listers "k8s.io/client-go/listers/certificates/v1"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+// csrGCController prunes the addon CSRs which are approved, denied, failed or whose certificates are expired,
+// once they are older than the max age. The CSRs of the addons deleted during registration are not cleaned up
+// by the agents, and accumulate on the hub otherwise.
+type csrGCController struct {
+	kubeClient    kubernetes.Interface
+	csrLister     certificatesv1listers.CertificateSigningRequestLister
+	maxAge        time.Duration
+	eventRecorder events.Recorder
+}
+
+// NewCSRGCController creates a new csr garbage collection controller
+func NewCSRGCController(
+	kubeClient kubernetes.Interface,
+	csrInformer certificatesv1informers.CertificateSigningRequestInformer,
+	maxAge time.Duration,
+	recorder events.Recorder) factory.Controller {
+	c := &csrGCController{
+		kubeClient:    kubeClient,
+		csrLister:     csrInformer.Lister(),
+		maxAge:        maxAge,
+		eventRecorder: recorder.WithComponentSuffix("csr-gc-controller"),
+	}
+
+	return factory.New().
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetName()
+			},
+			func(obj interface{}) bool {
+				accessor, err := meta.Accessor(obj)
+				if err != nil {
+					return false
+				}
+				return isAddOnCSR(accessor.GetLabels())
+			},
+			csrInformer.Informer()).
+		WithSync(logging.SyncWithLogger("CSRGCController", c.sync)).
+		ToController("CSRGCController", recorder)
+}
+
+func (c *csrGCController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	csrName := syncCtx.QueueKey()
+	klog.FromContext(ctx).V(4).Info("Reconciling garbage collection of CertificateSigningRequest")
+
+	csr, err := c.csrLister.Get(csrName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !isAddOnCSR(csr.Labels) || !isFinishedCSR(csr) {
+		return nil
+	}
+
+	expirationTime := csr.CreationTimestamp.Add(c.maxAge)
+	if now := time.Now(); now.Before(expirationTime) {
+		// check the csr again once it reaches the max age
+		syncCtx.Queue().AddAfter(csrName, expirationTime.Sub(now))
+		return nil
+	}
+
+	err = c.kubeClient.CertificatesV1().CertificateSigningRequests().Delete(ctx, csrName, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.eventRecorder.Eventf("AddOnCSRPruned", "csr %q of addon %q on cluster %q is pruned",
+		csrName, csr.Labels[addonv1alpha1.AddonLabelKey], csr.Labels[clusterv1.ClusterNameLabelKey])
+	return nil
+}
+
+// isAddOnCSR returns true if the csr is labeled with both the cluster name and the addon name
+func isAddOnCSR(labels map[string]string) bool {
+	if len(labels[clusterv1.ClusterNameLabelKey]) == 0 {
+		return false
+	}
+	return len(labels[addonv1alpha1.AddonLabelKey]) > 0
+}
+
+// isFinishedCSR returns true if the csr is approved, denied or failed, or the issued certificate is expired.
+func isFinishedCSR(csr *certificatesv1.CertificateSigningRequest) bool {
+	if helpers.IsCSRInTerminalState(&csr.Status) {
+		return true
+	}
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type == certificatesv1.CertificateFailed {
+			return true
+		}
+	}
+	if len(csr.Status.Certificate) == 0 {
+		return false
+	}
+
+	certs, err := certutil.ParseCertsPEM(csr.Status.Certificate)
+	if err != nil {
+		return false
+	}
+	for _, cert := range certs {
+		if time.Now().After(cert.NotAfter) {
+			return true
+		}
+	}
+	return false
+}
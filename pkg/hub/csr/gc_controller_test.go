@@ -0,0 +1,131 @@
+package csr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+var addOnCSR = testinghelpers.CSRHolder{
+	Name: "addon-csr",
+	Labels: map[string]string{
+		clusterv1.ClusterNameLabelKey: "managedcluster1",
+		addonv1alpha1.AddonLabelKey:   "addon1",
+	},
+	SignerName: certificatesv1.KubeAPIServerClientSignerName,
+	CN:         "system:open-cluster-management:cluster:managedcluster1:addon:addon1:agent:agent1",
+	Orgs:       []string{"system:open-cluster-management:cluster:managedcluster1:addon:addon1"},
+	Username:   "system:open-cluster-management:managedcluster1:spokeagent1",
+}
+
+func TestCSRGCSync(t *testing.T) {
+	cases := []struct {
+		name            string
+		csr             *certificatesv1.CertificateSigningRequest
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name: "pending csr",
+			csr:  withCreationTimestamp(testinghelpers.NewCSR(addOnCSR), 2*time.Hour),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "approved csr is not old enough",
+			csr:  withCreationTimestamp(testinghelpers.NewApprovedCSR(addOnCSR), 10*time.Minute),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "csr of spoke cluster",
+			csr: withCreationTimestamp(testinghelpers.NewApprovedCSR(testinghelpers.CSRHolder{
+				Name:   "cluster-csr",
+				Labels: map[string]string{clusterv1.ClusterNameLabelKey: "managedcluster1"},
+			}), 2*time.Hour),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "prune approved csr",
+			csr:  withCreationTimestamp(testinghelpers.NewApprovedCSR(addOnCSR), 2*time.Hour),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "delete")
+			},
+		},
+		{
+			name: "prune denied csr",
+			csr:  withCreationTimestamp(testinghelpers.NewDeniedCSR(addOnCSR), 2*time.Hour),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "delete")
+			},
+		},
+		{
+			name: "prune failed csr",
+			csr: func() *certificatesv1.CertificateSigningRequest {
+				csr := withCreationTimestamp(testinghelpers.NewCSR(addOnCSR), 2*time.Hour)
+				csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+					Type:   certificatesv1.CertificateFailed,
+					Status: corev1.ConditionTrue,
+				})
+				return csr
+			}(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "delete")
+			},
+		},
+		{
+			name: "prune csr with expired certificate",
+			csr: func() *certificatesv1.CertificateSigningRequest {
+				csr := withCreationTimestamp(testinghelpers.NewCSR(addOnCSR), 2*time.Hour)
+				csr.Status.Certificate = testinghelpers.NewTestCert("addon1", -1*time.Minute).Cert
+				return csr
+			}(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "delete")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(c.csr)
+			informerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 3*time.Minute)
+			if err := informerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore().Add(c.csr); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := &csrGCController{
+				kubeClient:    kubeClient,
+				csrLister:     informerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+				maxAge:        time.Hour,
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, c.csr.Name))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func withCreationTimestamp(csr *certificatesv1.CertificateSigningRequest, age time.Duration) *certificatesv1.CertificateSigningRequest {
+	csr.CreationTimestamp = metav1.NewTime(time.Now().Add(-age))
+	return csr
+}
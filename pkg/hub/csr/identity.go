@@ -0,0 +1,70 @@
+package csr
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+)
+
+// IdentityKeySecretKey is the key of the pre-shared key in the identity secret of a cluster
+const IdentityKeySecretKey = "identity.key"
+
+// IdentityVerifier verifies the identity proofs in the registration requests with the pre-shared keys of the
+// clusters. The key of a cluster is in the secret named after the cluster in the identity namespace, so a leaked
+// bootstrap kubeconfig is not sufficient to impersonate a cluster.
+type IdentityVerifier struct {
+	secretLister corev1listers.SecretLister
+	secretSynced cache.InformerSynced
+	namespace    string
+}
+
+// NewIdentityVerifier returns an IdentityVerifier reading the pre-shared keys from the secrets in the namespace with
+// the secret informer of the namespace.
+func NewIdentityVerifier(secretInformer corev1informers.SecretInformer, namespace string) *IdentityVerifier {
+	return &IdentityVerifier{
+		secretLister: secretInformer.Lister(),
+		secretSynced: secretInformer.Informer().HasSynced,
+		namespace:    namespace,
+	}
+}
+
+// verify returns false and the reason if the CSR of the cluster does not have a valid identity proof. A nil verifier
+// verifies nothing.
+func (v *IdentityVerifier) verify(_ context.Context, csr csrInfo, clusterName string) (bool, string, error) {
+	if v == nil {
+		return true, "", nil
+	}
+
+	proof, ok := csr.annotations[clientcert.IdentityProofAnnotation]
+	if !ok {
+		return false, "The registration request does not have an identity proof", nil
+	}
+
+	// the request is retried until the secrets are synced, so it is not rejected for a missing secret by mistake
+	if !v.secretSynced() {
+		return false, "", fmt.Errorf("the identity secrets in namespace %q are not synced yet", v.namespace)
+	}
+	secret, err := v.secretLister.Secrets(v.namespace).Get(clusterName)
+	if errors.IsNotFound(err) {
+		return false, fmt.Sprintf("The identity secret of the cluster %q is not found", clusterName), nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	key := secret.Data[IdentityKeySecretKey]
+	if len(key) == 0 {
+		return false, fmt.Sprintf("The identity secret of the cluster %q does not have the key %s",
+			clusterName, IdentityKeySecretKey), nil
+	}
+	if !clientcert.VerifyIdentityProof(key, csr.request, proof) {
+		return false, "The identity proof of the registration request is invalid", nil
+	}
+	return true, "", nil
+}
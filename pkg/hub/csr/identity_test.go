@@ -0,0 +1,94 @@
+package csr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestIdentityVerifier(t *testing.T) {
+	identityKey := []byte("pre-shared-key")
+	csr := testinghelpers.NewCSR(validCSR)
+	validProof := clientcert.IdentityProof(identityKey, csr.Spec.Request)
+	identitySecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-identities", Name: "managedcluster1"},
+		Data:       map[string][]byte{IdentityKeySecretKey: identityKey},
+	}
+
+	cases := []struct {
+		name             string
+		secrets          []runtime.Object
+		proof            string
+		expectedVerified bool
+	}{
+		{
+			name:             "valid proof",
+			secrets:          []runtime.Object{identitySecret},
+			proof:            validProof,
+			expectedVerified: true,
+		},
+		{
+			name:    "no proof",
+			secrets: []runtime.Object{identitySecret},
+		},
+		{
+			name:    "invalid proof",
+			secrets: []runtime.Object{identitySecret},
+			proof:   clientcert.IdentityProof([]byte("other-key"), csr.Spec.Request),
+		},
+		{
+			name:  "no identity secret",
+			proof: validProof,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			informerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(
+				kubefake.NewSimpleClientset(c.secrets...), 10*time.Minute, kubeinformers.WithNamespace("cluster-identities"))
+			verifier := NewIdentityVerifier(informerFactory.Core().V1().Secrets(), "cluster-identities")
+			informerFactory.Start(ctx.Done())
+			informerFactory.WaitForCacheSync(ctx.Done())
+
+			csr := csr.DeepCopy()
+			if len(c.proof) > 0 {
+				csr.Annotations = map[string]string{clientcert.IdentityProofAnnotation: c.proof}
+			}
+			verified, reason, err := verifier.verify(context.TODO(), newCSRInfo(csr), "managedcluster1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if verified != c.expectedVerified {
+				t.Errorf("expected verified %v, but got %v", c.expectedVerified, verified)
+			}
+			if !verified && len(reason) == 0 {
+				t.Errorf("expected reason, but got empty")
+			}
+		})
+	}
+
+	// the secrets are not read before they are synced
+	unsyncedVerifier := NewIdentityVerifier(
+		kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 10*time.Minute).Core().V1().Secrets(),
+		"cluster-identities")
+	csr.Annotations = map[string]string{clientcert.IdentityProofAnnotation: validProof}
+	if _, _, err := unsyncedVerifier.verify(context.TODO(), newCSRInfo(csr), "managedcluster1"); err == nil {
+		t.Errorf("expected error before the secrets are synced, but got nil")
+	}
+
+	var nilVerifier *IdentityVerifier
+	if verified, _, _ := nilVerifier.verify(context.TODO(), newCSRInfo(csr), "managedcluster1"); !verified {
+		t.Errorf("expected nil verifier verifies nothing")
+	}
+}
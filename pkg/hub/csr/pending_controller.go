@@ -0,0 +1,265 @@
+package csr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	certificatesv1informers "k8s.io/client-go/informers/certificates/v1"
+	"k8s.io/client-go/kubernetes"
+	certificatesv1listers "k8s.io/client-go/listers/certificates/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+// PendingRegistrationLabelKey is the label set by the hub with the cluster name on the registration csrs awaiting
+// the approval, so they are selected by the cluster admins and dashboards. It is removed once the csr is approved,
+// denied or failed.
+const PendingRegistrationLabelKey = "registration.open-cluster-management.io/pending-cluster"
+
+// pendingRegistrations is the number of the registration csrs awaiting the approval on the hub
+var pendingRegistrations = metrics.NewGauge(
+	&metrics.GaugeOpts{
+		Subsystem:      "registration",
+		Name:           "pending_registrations",
+		Help:           "The number of the registration csrs of the managed clusters awaiting the approval.",
+		StabilityLevel: metrics.ALPHA,
+	},
+)
+
+func init() {
+	legacyregistry.MustRegister(pendingRegistrations)
+}
+
+// csrPendingController tracks the registration csrs, which are requested by the bootstrap identities rather than
+// renewed by the clusters, until they are approved or denied. The pending ones are labeled with the cluster name
+// and counted in the pendingRegistrations gauge, and the PendingCSR condition of the cluster is reported if
+// reportCondition is true. The ClusterIdentityConflict condition of the cluster is cleared once none of its
+// registration csrs is pending.
+type csrPendingController struct {
+	kubeClient      kubernetes.Interface
+	clusterClient   clusterclientset.Interface
+	csrLister       certificatesv1listers.CertificateSigningRequestLister
+	clusterLister   clusterv1listers.ManagedClusterLister
+	reportCondition bool
+	eventRecorder   events.Recorder
+
+	// pending is the number of the pending registration csrs of each cluster
+	lock    sync.Mutex
+	pending map[string]int
+}
+
+// NewCSRPendingController creates a new csr pending controller
+func NewCSRPendingController(
+	kubeClient kubernetes.Interface,
+	clusterClient clusterclientset.Interface,
+	csrInformer certificatesv1informers.CertificateSigningRequestInformer,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	reportCondition bool,
+	recorder events.Recorder) factory.Controller {
+	c := &csrPendingController{
+		kubeClient:      kubeClient,
+		clusterClient:   clusterClient,
+		csrLister:       csrInformer.Lister(),
+		clusterLister:   clusterInformer.Lister(),
+		reportCondition: reportCondition,
+		eventRecorder:   recorder.WithComponentSuffix("csr-pending-controller"),
+		pending:         map[string]int{},
+	}
+
+	f := factory.New().
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetLabels()[clusterv1.ClusterNameLabelKey]
+			},
+			func(obj interface{}) bool {
+				accessor, err := meta.Accessor(obj)
+				if err != nil {
+					return false
+				}
+				return len(accessor.GetLabels()[clusterv1.ClusterNameLabelKey]) > 0
+			},
+			csrInformer.Informer())
+	if reportCondition {
+		// the condition is reported once the cluster is created after its csr
+		f = f.WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer())
+	}
+	return f.WithSync(logging.SyncWithLogger("CSRPendingController", c.sync)).
+		ToController("CSRPendingController", recorder)
+}
+
+func (c *csrPendingController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling pending registration CertificateSigningRequests")
+
+	csrs, err := c.csrLister.List(labels.SelectorFromSet(labels.Set{clusterv1.ClusterNameLabelKey: clusterName}))
+	if err != nil {
+		return err
+	}
+
+	pending := []string{}
+	for _, csr := range csrs {
+		if !isRegistrationCSR(csr) {
+			continue
+		}
+		isPending := isPendingCSR(csr)
+		if isPending {
+			pending = append(pending, csr.Name)
+		}
+		if err := c.labelCSR(ctx, csr, clusterName, isPending); err != nil {
+			return err
+		}
+	}
+	sort.Strings(pending)
+	c.setPending(clusterName, len(pending))
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	// the identity conflict is resolved once the conflicting registration csrs are not pending
+	if len(pending) == 0 && meta.IsStatusConditionTrue(cluster.Status.Conditions, ManagedClusterConditionIdentityConflict) {
+		if _, _, err := helpers.UpdateManagedClusterStatus(ctx, c.clusterClient, clusterName,
+			helpers.UpdateManagedClusterConditionFn(identityConflictResolvedCondition())); err != nil {
+			return err
+		}
+	}
+
+	if !c.reportCondition {
+		return nil
+	}
+
+	pendingCond := meta.FindStatusCondition(cluster.Status.Conditions, helpers.ManagedClusterConditionPendingCSR)
+	var cond metav1.Condition
+	if len(pending) > 0 {
+		cond = metav1.Condition{
+			Type:    helpers.ManagedClusterConditionPendingCSR,
+			Status:  metav1.ConditionTrue,
+			Reason:  "CSRPendingApproval",
+			Message: fmt.Sprintf("The registration csrs %s are awaiting the approval", strings.Join(pending, ", ")),
+		}
+	} else {
+		// a cluster which has never had a pending csr does not get the condition
+		if pendingCond == nil {
+			return nil
+		}
+		cond = metav1.Condition{
+			Type:    helpers.ManagedClusterConditionPendingCSR,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NoPendingCSR",
+			Message: "No registration csr is awaiting the approval",
+		}
+	}
+	if pendingCond != nil && pendingCond.Status == cond.Status && pendingCond.Message == cond.Message {
+		return nil
+	}
+
+	_, updated, err := helpers.UpdateManagedClusterStatus(
+		ctx, c.clusterClient, clusterName, helpers.UpdateManagedClusterConditionFn(cond))
+	if updated && cond.Status == metav1.ConditionTrue {
+		c.eventRecorder.Eventf("ManagedClusterCSRPending", "managed cluster %s: %s", clusterName, cond.Message)
+	}
+	return err
+}
+
+// labelCSR sets the pending registration label on the csr if it is pending, and removes it otherwise
+func (c *csrPendingController) labelCSR(ctx context.Context,
+	csr *certificatesv1.CertificateSigningRequest, clusterName string, pending bool) error {
+	value, labeled := csr.Labels[PendingRegistrationLabelKey]
+	var label interface{}
+	switch {
+	case pending && value != clusterName:
+		label = clusterName
+	case !pending && labeled:
+		// a nil value removes the label
+		label = nil
+	default:
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{PendingRegistrationLabelKey: label},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.kubeClient.CertificatesV1().CertificateSigningRequests().Patch(
+		ctx, csr.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// setPending records the number of the pending registration csrs of the cluster, and sets the gauge to the
+// number of all the clusters
+func (c *csrPendingController) setPending(clusterName string, count int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if count == 0 {
+		delete(c.pending, clusterName)
+	} else {
+		c.pending[clusterName] = count
+	}
+
+	total := 0
+	for _, n := range c.pending {
+		total += n
+	}
+	pendingRegistrations.Set(float64(total))
+}
+
+// isRegistrationCSR returns true if the csr is a valid managed cluster csr requested by an identity other than the
+// cluster itself, e.g. the bootstrap identity, while the renewal csrs are requested by the cluster.
+func isRegistrationCSR(csr *certificatesv1.CertificateSigningRequest) bool {
+	info := newCSRInfo(csr)
+	valid, _, commonName := validateCSR(info)
+	return valid && info.username != commonName
+}
+
+// isPendingCSR returns true if the csr is neither approved, denied nor failed.
+func isPendingCSR(csr *certificatesv1.CertificateSigningRequest) bool {
+	if helpers.IsCSRInTerminalState(&csr.Status) {
+		return false
+	}
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type == certificatesv1.CertificateFailed {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,215 @@
+package csr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/component-base/metrics/testutil"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+	"open-cluster-management.io/registration/pkg/hub/user"
+)
+
+var registrationCSR = testinghelpers.CSRHolder{
+	Name:         "registration-csr",
+	Labels:       map[string]string{clusterv1.ClusterNameLabelKey: testinghelpers.TestManagedClusterName},
+	SignerName:   certificatesv1.KubeAPIServerClientSignerName,
+	CN:           user.SubjectPrefix + testinghelpers.TestManagedClusterName + ":spokeagent1",
+	Orgs:         []string{user.SubjectPrefix + testinghelpers.TestManagedClusterName, user.ManagedClustersGroup},
+	Username:     "system:serviceaccount:open-cluster-management:cluster-bootstrap",
+	ReqBlockType: "CERTIFICATE REQUEST",
+}
+
+func TestCSRPendingSync(t *testing.T) {
+	renewalCSR := registrationCSR
+	renewalCSR.Name = "renewal-csr"
+	renewalCSR.Username = renewalCSR.CN
+
+	cases := []struct {
+		name                   string
+		csrs                   []runtime.Object
+		clusters               []runtime.Object
+		reportCondition        bool
+		expectedPending        float64
+		validateCSRActions     func(t *testing.T, actions []clienttesting.Action)
+		validateClusterActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:                   "renewal csr is not tracked",
+			csrs:                   []runtime.Object{testinghelpers.NewCSR(renewalCSR)},
+			clusters:               []runtime.Object{testinghelpers.NewManagedCluster()},
+			reportCondition:        true,
+			validateCSRActions:     testinghelpers.AssertNoActions,
+			validateClusterActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "label pending registration csr",
+			csrs:            []runtime.Object{testinghelpers.NewCSR(registrationCSR)},
+			expectedPending: 1,
+			validateCSRActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch")
+				testinghelpers.AssertPatchLabel(t, actions[0], PendingRegistrationLabelKey, testinghelpers.TestManagedClusterName)
+			},
+			validateClusterActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:               "report pending condition",
+			csrs:               []runtime.Object{withPendingLabel(testinghelpers.NewCSR(registrationCSR))},
+			clusters:           []runtime.Object{testinghelpers.NewManagedCluster()},
+			reportCondition:    true,
+			expectedPending:    1,
+			validateCSRActions: testinghelpers.AssertNoActions,
+			validateClusterActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertPendingCondition(t, actions, metav1.ConditionTrue)
+			},
+		},
+		{
+			name:     "unlabel approved registration csr",
+			csrs:     []runtime.Object{withPendingLabel(testinghelpers.NewApprovedCSR(registrationCSR))},
+			clusters: []runtime.Object{testinghelpers.NewManagedCluster()},
+			validateCSRActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch")
+				// a nil value removes the label
+				testinghelpers.AssertPatchLabel(t, actions[0], PendingRegistrationLabelKey, nil)
+			},
+			validateClusterActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:               "clear pending condition",
+			csrs:               []runtime.Object{testinghelpers.NewDeniedCSR(registrationCSR)},
+			clusters:           []runtime.Object{newPendingManagedCluster()},
+			reportCondition:    true,
+			validateCSRActions: testinghelpers.AssertNoActions,
+			validateClusterActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertPendingCondition(t, actions, metav1.ConditionFalse)
+			},
+		},
+		{
+			name:               "clear identity conflict condition",
+			csrs:               []runtime.Object{testinghelpers.NewDeniedCSR(registrationCSR)},
+			clusters:           []runtime.Object{newConflictedManagedCluster()},
+			validateCSRActions: testinghelpers.AssertNoActions,
+			validateClusterActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				cluster := &clusterv1.ManagedCluster{}
+				testinghelpers.UnmarshalPatch(t, actions[1], cluster)
+				cond := meta.FindStatusCondition(cluster.Status.Conditions, ManagedClusterConditionIdentityConflict)
+				if cond == nil || cond.Status != metav1.ConditionFalse {
+					t.Errorf("expected the identity conflict is cleared, but got %v", cond)
+				}
+			},
+		},
+		{
+			name:                   "keep identity conflict condition with pending csr",
+			csrs:                   []runtime.Object{withPendingLabel(testinghelpers.NewCSR(registrationCSR))},
+			clusters:               []runtime.Object{newConflictedManagedCluster()},
+			expectedPending:        1,
+			validateCSRActions:     testinghelpers.AssertNoActions,
+			validateClusterActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:                   "no condition without pending csr",
+			csrs:                   []runtime.Object{testinghelpers.NewApprovedCSR(registrationCSR)},
+			clusters:               []runtime.Object{testinghelpers.NewManagedCluster()},
+			reportCondition:        true,
+			validateCSRActions:     testinghelpers.AssertNoActions,
+			validateClusterActions: testinghelpers.AssertNoActions,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(c.csrs...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 3*time.Minute)
+			for _, csr := range c.csrs {
+				if err := kubeInformerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore().Add(csr); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			clusterClient := clusterfake.NewSimpleClientset(c.clusters...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 3*time.Minute)
+			for _, cluster := range c.clusters {
+				if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &csrPendingController{
+				kubeClient:      kubeClient,
+				clusterClient:   clusterClient,
+				csrLister:       kubeInformerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+				clusterLister:   clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				reportCondition: c.reportCondition,
+				eventRecorder:   eventstesting.NewTestingEventRecorder(t),
+				pending:         map[string]int{},
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			testinghelpers.AssertError(t, syncErr, "")
+
+			c.validateCSRActions(t, kubeClient.Actions())
+			c.validateClusterActions(t, clusterClient.Actions())
+
+			pending, err := testutil.GetGaugeMetricValue(pendingRegistrations)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pending != c.expectedPending {
+				t.Errorf("expected %v pending registrations, but got %v", c.expectedPending, pending)
+			}
+		})
+	}
+}
+
+func withPendingLabel(csr *certificatesv1.CertificateSigningRequest) *certificatesv1.CertificateSigningRequest {
+	csr.Labels = map[string]string{
+		clusterv1.ClusterNameLabelKey: testinghelpers.TestManagedClusterName,
+		PendingRegistrationLabelKey:   testinghelpers.TestManagedClusterName,
+	}
+	return csr
+}
+
+func newPendingManagedCluster() *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewManagedCluster()
+	meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+		Type:    helpers.ManagedClusterConditionPendingCSR,
+		Status:  metav1.ConditionTrue,
+		Reason:  "CSRPendingApproval",
+		Message: "The registration csrs registration-csr are awaiting the approval",
+	})
+	return cluster
+}
+
+func newConflictedManagedCluster() *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewManagedCluster()
+	meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+		Type:    ManagedClusterConditionIdentityConflict,
+		Status:  metav1.ConditionTrue,
+		Reason:  "RegistrationRequestFromAnotherCluster",
+		Message: "conflicted",
+	})
+	return cluster
+}
+
+func assertPendingCondition(t *testing.T, actions []clienttesting.Action, status metav1.ConditionStatus) {
+	testinghelpers.AssertActions(t, actions, "get", "patch")
+	cluster := &clusterv1.ManagedCluster{}
+	testinghelpers.UnmarshalPatch(t, actions[1], cluster)
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, helpers.ManagedClusterConditionPendingCSR)
+	if cond == nil || cond.Status != status {
+		t.Errorf("expected the condition %s, but got %v", status, cond)
+	}
+}
@@ -22,6 +22,8 @@ import (
 	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/helpers"
 	"open-cluster-management.io/registration/pkg/hub/user"
 )
 
@@ -33,14 +35,16 @@ const (
 )
 
 type csrInfo struct {
-	name       string
-	labels     map[string]string
-	signerName string
-	username   string
-	uid        string
-	groups     []string
-	extra      map[string]authorizationv1.ExtraValue
-	request    []byte
+	name        string
+	labels      map[string]string
+	annotations map[string]string
+	signerName  string
+	username    string
+	uid         string
+	groups      []string
+	extra       map[string]authorizationv1.ExtraValue
+	request     []byte
+	usages      []string
 }
 
 type approveCSRFunc func(kubernetes.Interface) error
@@ -51,21 +55,30 @@ type Reconciler interface {
 
 type csrRenewalReconciler struct {
 	kubeClient    kubernetes.Interface
+	denyList      *ApprovalDenyList
+	sanAllowList  *SANAllowList
+	auditSink     AuditSink
 	eventRecorder events.Recorder
 }
 
-func NewCSRRenewalReconciler(kubeClient kubernetes.Interface, recorder events.Recorder) Reconciler {
+func NewCSRRenewalReconciler(kubeClient kubernetes.Interface, denyList *ApprovalDenyList, sanAllowList *SANAllowList,
+	auditSink AuditSink, recorder events.Recorder) Reconciler {
 	return &csrRenewalReconciler{
 		kubeClient:    kubeClient,
+		denyList:      denyList,
+		sanAllowList:  sanAllowList,
+		auditSink:     auditSink,
 		eventRecorder: recorder.WithComponentSuffix("csr-approving-controller"),
 	}
 }
 
 func (r *csrRenewalReconciler) Reconcile(ctx context.Context, csr csrInfo, approveCSR approveCSRFunc) (reconcileState, error) {
+	logger := klog.FromContext(ctx)
+
 	// Check whether current csr is a valid spoker cluster csr.
-	valid, _, commonName := validateCSR(csr)
+	valid, clusterName, commonName := validateCSR(csr)
 	if !valid {
-		klog.V(4).Infof("CSR %q was not recognized", csr.name)
+		logger.V(4).Info("CSR was not recognized")
 		return reconcileStop, nil
 	}
 
@@ -74,29 +87,144 @@ func (r *csrRenewalReconciler) Reconcile(ctx context.Context, csr csrInfo, appro
 		return reconcileContinue, nil
 	}
 
+	// Check whether the cluster or the requester is in the deny list.
+	if denied, reason := r.denyList.denied(clusterName, csr.username); denied {
+		logger.V(4).Info("Managed cluster csr cannot be auto approved", "reason", reason)
+		r.eventRecorder.Warningf("ManagedClusterAutoApprovalDenied", "spoke cluster %q is not auto approved: %s", clusterName, reason)
+		recordApprovalDecision(ctx, r.auditSink, csr, clusterName, ApprovalDecisionNotApproved, reason)
+		return reconcileStop, nil
+	}
+
+	// Check whether the subject alternative names in csr request are allowed.
+	if allowed, reason := r.sanAllowList.Allowed(csr.request); !allowed {
+		logger.V(4).Info("Managed cluster csr cannot be auto approved", "reason", reason)
+		recordApprovalDecision(ctx, r.auditSink, csr, clusterName, ApprovalDecisionNotApproved, reason)
+		return reconcileStop, nil
+	}
+
 	// Authorize whether the current spoke agent has been authorized to renew its csr.
 	allowed, err := authorize(ctx, r.kubeClient, csr)
 	if err != nil {
 		return reconcileContinue, err
 	}
 	if !allowed {
-		klog.V(4).Infof("Managed cluster csr %q cannont be auto approved due to subject access review was not approved", csr.name)
+		logger.V(4).Info("Managed cluster csr cannot be auto approved due to subject access review was not approved")
+		recordApprovalDecision(ctx, r.auditSink, csr, clusterName, ApprovalDecisionNotApproved,
+			"The requester is not authorized to renew the client certificate")
 		return reconcileStop, nil
 	}
 
 	if err := approveCSR(r.kubeClient); err != nil {
 		return reconcileContinue, err
 	}
+	recordApprovalDecision(ctx, r.auditSink, csr, clusterName, ApprovalDecisionApproved,
+		"The requester is authorized to renew the client certificate")
 
 	r.eventRecorder.Eventf("ManagedClusterCSRAutoApproved", "spoke cluster csr %q is auto approved by hub csr controller", csr.name)
 	return reconcileStop, nil
 }
 
+// csrServingReconciler approves the csrs of the serving certificates of the agents, which are requested from the
+// cluster serving signer by the agents with their client certificates. Only the key usages of the serving
+// certificates and the subject alternative names in the allow list are approved. The csrs of the other signers are
+// left to the following reconcilers.
+type csrServingReconciler struct {
+	kubeClient    kubernetes.Interface
+	denyList      *ApprovalDenyList
+	sanAllowList  *SANAllowList
+	auditSink     AuditSink
+	eventRecorder events.Recorder
+}
+
+func NewCSRServingReconciler(kubeClient kubernetes.Interface, denyList *ApprovalDenyList, sanAllowList *SANAllowList,
+	auditSink AuditSink, recorder events.Recorder) Reconciler {
+	return &csrServingReconciler{
+		kubeClient:    kubeClient,
+		denyList:      denyList,
+		sanAllowList:  sanAllowList,
+		auditSink:     auditSink,
+		eventRecorder: recorder.WithComponentSuffix("csr-approving-controller"),
+	}
+}
+
+func (r *csrServingReconciler) Reconcile(ctx context.Context, csr csrInfo, approveCSR approveCSRFunc) (reconcileState, error) {
+	logger := klog.FromContext(ctx)
+
+	if csr.signerName != helpers.ClusterServingSignerName {
+		return reconcileContinue, nil
+	}
+
+	valid, clusterName, commonName := validateClusterCSR(csr, helpers.ClusterServingSignerName)
+	if !valid {
+		logger.V(4).Info("CSR was not recognized")
+		return reconcileStop, nil
+	}
+
+	// The serving certificate is only requested by the agent with its client certificate.
+	if csr.username != commonName {
+		logger.V(4).Info("Managed cluster serving csr is not requested by the agent of the cluster")
+		return reconcileStop, nil
+	}
+
+	reason := ""
+	if denied, deniedReason := r.denyList.denied(clusterName, csr.username); denied {
+		reason = deniedReason
+	} else if !ServingUsagesAllowed(csr.usages) {
+		reason = fmt.Sprintf("The key usages %v are not allowed in the serving certificate", csr.usages)
+	} else if allowed, notAllowedReason := r.sanAllowList.Allowed(csr.request); !allowed {
+		reason = notAllowedReason
+	}
+	if len(reason) > 0 {
+		logger.V(4).Info("Managed cluster serving csr cannot be auto approved", "reason", reason)
+		r.eventRecorder.Warningf("ManagedClusterAutoApprovalDenied", "serving csr %q of spoke cluster %q is not auto approved: %s",
+			csr.name, clusterName, reason)
+		recordApprovalDecision(ctx, r.auditSink, csr, clusterName, ApprovalDecisionNotApproved, reason)
+		return reconcileStop, nil
+	}
+
+	// The agent is authorized to request the serving certificate once the cluster is accepted, as it is to renew
+	// the client certificate.
+	allowed, err := authorize(ctx, r.kubeClient, csr)
+	if err != nil {
+		return reconcileContinue, err
+	}
+	if !allowed {
+		logger.V(4).Info("Managed cluster serving csr cannot be auto approved due to subject access review was not approved")
+		recordApprovalDecision(ctx, r.auditSink, csr, clusterName, ApprovalDecisionNotApproved,
+			"The requester is not authorized to request the serving certificate")
+		return reconcileStop, nil
+	}
+
+	if err := approveCSR(r.kubeClient); err != nil {
+		return reconcileContinue, err
+	}
+	recordApprovalDecision(ctx, r.auditSink, csr, clusterName, ApprovalDecisionApproved,
+		"The requester is authorized to request the serving certificate")
+
+	r.eventRecorder.Eventf("ManagedClusterServingCSRAutoApproved", "serving csr %q of spoke cluster %q is auto approved by hub csr controller",
+		csr.name, clusterName)
+	return reconcileStop, nil
+}
+
+// ServingUsagesAllowed returns true if the key usages are a subset of the usages of the serving certificates of the
+// agents
+func ServingUsagesAllowed(usages []string) bool {
+	allowedUsages := sets.New[string]()
+	for _, usage := range clientcert.ServingCertUsages {
+		allowedUsages.Insert(string(usage))
+	}
+	return allowedUsages.HasAll(usages...)
+}
+
 type csrBootstrapReconciler struct {
 	kubeClient    kubernetes.Interface
 	clusterClient clusterclientset.Interface
 	clusterLister clusterv1listers.ManagedClusterLister
 	approvalUsers sets.Set[string]
+	denyList      *ApprovalDenyList
+	sanAllowList  *SANAllowList
+	verifier      *IdentityVerifier
+	auditSink     AuditSink
 	eventRecorder events.Recorder
 }
 
@@ -104,21 +232,31 @@ func NewCSRBootstrapReconciler(kubeClient kubernetes.Interface,
 	clusterClient clusterclientset.Interface,
 	clusterLister clusterv1listers.ManagedClusterLister,
 	approvalUsers []string,
+	denyList *ApprovalDenyList,
+	sanAllowList *SANAllowList,
+	verifier *IdentityVerifier,
+	auditSink AuditSink,
 	recorder events.Recorder) Reconciler {
 	return &csrBootstrapReconciler{
 		kubeClient:    kubeClient,
 		clusterClient: clusterClient,
 		clusterLister: clusterLister,
 		approvalUsers: sets.New(approvalUsers...),
+		denyList:      denyList,
+		sanAllowList:  sanAllowList,
+		verifier:      verifier,
+		auditSink:     auditSink,
 		eventRecorder: recorder.WithComponentSuffix("csr-approving-controller"),
 	}
 }
 
 func (b *csrBootstrapReconciler) Reconcile(ctx context.Context, csr csrInfo, approveCSR approveCSRFunc) (reconcileState, error) {
+	logger := klog.FromContext(ctx)
+
 	// Check whether current csr is a valid spoker cluster csr.
 	valid, clusterName, _ := validateCSR(csr)
 	if !valid {
-		klog.V(4).Infof("CSR %q was not recognized", csr.name)
+		logger.V(4).Info("CSR was not recognized")
 		return reconcileStop, nil
 	}
 
@@ -127,7 +265,51 @@ func (b *csrBootstrapReconciler) Reconcile(ctx context.Context, csr csrInfo, app
 		return reconcileContinue, nil
 	}
 
-	err := b.accpetCluster(ctx, clusterName)
+	// Check whether the cluster or the requester is blocked by the deny list.
+	if denied, reason := b.denyList.denied(clusterName, csr.username); denied {
+		logger.V(4).Info("Managed cluster csr cannot be auto approved", "reason", reason)
+		b.eventRecorder.Warningf("ManagedClusterAutoApprovalDenied", "spoke cluster %q is not auto approved: %s", clusterName, reason)
+		recordApprovalDecision(ctx, b.auditSink, csr, clusterName, ApprovalDecisionNotApproved, reason)
+		return reconcileStop, nil
+	}
+
+	// Check whether the subject alternative names in csr request are allowed.
+	if allowed, reason := b.sanAllowList.Allowed(csr.request); !allowed {
+		logger.V(4).Info("Managed cluster csr cannot be auto approved", "reason", reason)
+		b.eventRecorder.Warningf("ManagedClusterAutoApprovalDenied", "spoke cluster %q is not auto approved: %s", clusterName, reason)
+		recordApprovalDecision(ctx, b.auditSink, csr, clusterName, ApprovalDecisionNotApproved, reason)
+		return reconcileStop, nil
+	}
+
+	// Check whether the identity of the cluster is verified.
+	verified, reason, err := b.verifier.verify(ctx, csr, clusterName)
+	if err != nil {
+		return reconcileContinue, err
+	}
+	if !verified {
+		logger.V(4).Info("Managed cluster csr cannot be auto approved", "reason", reason)
+		b.eventRecorder.Warningf("ManagedClusterAutoApprovalDenied", "spoke cluster %q is not auto approved: %s", clusterName, reason)
+		recordApprovalDecision(ctx, b.auditSink, csr, clusterName, ApprovalDecisionNotApproved, reason)
+		return reconcileStop, nil
+	}
+
+	// Check whether the request is from another cluster than the joined cluster with the same name, the request
+	// verified with the pre-shared key of the cluster is from the cluster.
+	conflicted, reason, err := detectIdentityConflict(b.clusterLister, csr, clusterName, b.verifier != nil)
+	if err != nil {
+		return reconcileContinue, err
+	}
+	if conflicted {
+		logger.Info("Managed cluster csr cannot be auto approved", "reason", reason)
+		b.eventRecorder.Warningf("ManagedClusterIdentityConflict", "spoke cluster %q is not auto approved: %s", clusterName, reason)
+		recordApprovalDecision(ctx, b.auditSink, csr, clusterName, ApprovalDecisionNotApproved, reason)
+		if err := reportIdentityConflict(ctx, b.clusterClient, clusterName, reason); err != nil {
+			return reconcileContinue, err
+		}
+		return reconcileStop, nil
+	}
+
+	err = acceptCluster(ctx, b.clusterClient, b.clusterLister, clusterName)
 	if errors.IsNotFound(err) {
 		// Current spoke cluster not found, could have been deleted, do nothing.
 		return reconcileStop, nil
@@ -139,13 +321,17 @@ func (b *csrBootstrapReconciler) Reconcile(ctx context.Context, csr csrInfo, app
 	if err := approveCSR(b.kubeClient); err != nil {
 		return reconcileContinue, err
 	}
+	recordApprovalDecision(ctx, b.auditSink, csr, clusterName, ApprovalDecisionApproved,
+		fmt.Sprintf("The requester %q is in the auto approval user list", csr.username))
 
 	b.eventRecorder.Eventf("ManagedClusterAutoApproved", "spoke cluster %q is auto approved.", clusterName)
 	return reconcileStop, nil
 }
 
-func (b *csrBootstrapReconciler) accpetCluster(ctx context.Context, managedClusterName string) error {
-	managedCluster, err := b.clusterLister.Get(managedClusterName)
+// acceptCluster sets the HubAcceptsClient of the managed cluster to true if it is not accepted yet
+func acceptCluster(ctx context.Context, clusterClient clusterclientset.Interface,
+	clusterLister clusterv1listers.ManagedClusterLister, managedClusterName string) error {
+	managedCluster, err := clusterLister.Get(managedClusterName)
 	if err != nil {
 		return err
 	}
@@ -155,7 +341,7 @@ func (b *csrBootstrapReconciler) accpetCluster(ctx context.Context, managedClust
 	}
 
 	patch := []byte("{\"spec\": {\"hubAcceptsClient\": true}}")
-	_, err = b.clusterClient.ClusterV1().ManagedClusters().Patch(
+	_, err = clusterClient.ClusterV1().ManagedClusters().Patch(
 		ctx, managedCluster.Name, types.MergePatchType, patch, metav1.PatchOptions{})
 	return err
 }
@@ -164,24 +350,30 @@ func (b *csrBootstrapReconciler) accpetCluster(ctx context.Context, managedClust
 // 1. if the signer name in csr request is valid.
 // 2. if organization field and commonName field in csr request is valid.
 func validateCSR(csr csrInfo) (bool, string, string) {
+	return validateClusterCSR(csr, certificatesv1.KubeAPIServerClientSignerName)
+}
+
+// validateClusterCSR validates the csr of the managed cluster requested from the signer, and returns the name of
+// the cluster and the common name in the request if it is valid
+func validateClusterCSR(csr csrInfo, signerName string) (bool, string, string) {
 	spokeClusterName, existed := csr.labels[clusterv1.ClusterNameLabelKey]
 	if !existed {
 		return false, "", ""
 	}
 
-	if csr.signerName != certificatesv1.KubeAPIServerClientSignerName {
+	if csr.signerName != signerName {
 		return false, "", ""
 	}
 
 	block, _ := pem.Decode(csr.request)
 	if block == nil || block.Type != "CERTIFICATE REQUEST" {
-		klog.V(4).Infof("csr %q was not recognized: PEM block type is not CERTIFICATE REQUEST", csr.name)
+		klog.V(4).InfoS("CSR was not recognized, the PEM block type is not CERTIFICATE REQUEST", "csr", csr.name)
 		return false, "", ""
 	}
 
 	x509cr, err := x509.ParseCertificateRequest(block.Bytes)
 	if err != nil {
-		klog.V(4).Infof("csr %q was not recognized: %v", csr.name, err)
+		klog.V(4).InfoS("CSR was not recognized", "csr", csr.name, "err", err)
 		return false, "", ""
 	}
 
@@ -238,32 +430,49 @@ func newCSRInfo(csr any) csrInfo {
 		for k, v := range v.Spec.Extra {
 			extra[k] = authorizationv1.ExtraValue(v)
 		}
+		usages := []string{}
+		for _, usage := range v.Spec.Usages {
+			usages = append(usages, string(usage))
+		}
 		return csrInfo{
-			name:       v.Name,
-			labels:     v.Labels,
-			signerName: v.Spec.SignerName,
-			username:   v.Spec.Username,
-			uid:        v.Spec.UID,
-			groups:     v.Spec.Groups,
-			extra:      extra,
-			request:    v.Spec.Request,
+			name:        v.Name,
+			labels:      v.Labels,
+			annotations: v.Annotations,
+			signerName:  v.Spec.SignerName,
+			username:    v.Spec.Username,
+			uid:         v.Spec.UID,
+			groups:      v.Spec.Groups,
+			extra:       extra,
+			request:     v.Spec.Request,
+			usages:      usages,
 		}
 	case *certificatesv1beta1.CertificateSigningRequest:
 		for k, v := range v.Spec.Extra {
 			extra[k] = authorizationv1.ExtraValue(v)
 		}
+		usages := []string{}
+		for _, usage := range v.Spec.Usages {
+			usages = append(usages, string(usage))
+		}
+		// the signer name of the v1beta1 csrs is optional
+		var signerName string
+		if v.Spec.SignerName != nil {
+			signerName = *v.Spec.SignerName
+		}
 		return csrInfo{
-			name:       v.Name,
-			labels:     v.Labels,
-			signerName: *v.Spec.SignerName,
-			username:   v.Spec.Username,
-			uid:        v.Spec.UID,
-			groups:     v.Spec.Groups,
-			extra:      extra,
-			request:    v.Spec.Request,
+			name:        v.Name,
+			labels:      v.Labels,
+			annotations: v.Annotations,
+			signerName:  signerName,
+			username:    v.Spec.Username,
+			uid:         v.Spec.UID,
+			groups:      v.Spec.Groups,
+			extra:       extra,
+			request:     v.Spec.Request,
+			usages:      usages,
 		}
 	default:
-		klog.Errorf("Unsupported type %T", v)
+		klog.ErrorS(nil, "Unsupported csr type", "type", fmt.Sprintf("%T", v))
 		return csrInfo{}
 	}
 }
@@ -0,0 +1,93 @@
+package csr
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"path"
+)
+
+// SANAllowList restricts the subject alternative names in the CSRs of the managed clusters. The CSRs are auto
+// approved only if all of their DNS names and IP addresses are allowed, so the client certificates reused for the
+// mTLS callbacks to the clusters cannot be requested for arbitrary hosts. A nil allow list allows no SANs.
+type SANAllowList struct {
+	// dnsPatterns are the DNS names or the shell file name patterns of the DNS names, e.g. "*.example.com"
+	dnsPatterns []string
+	ipNets      []*net.IPNet
+}
+
+// NewSANAllowList returns a SANAllowList with the DNS name patterns and the IP ranges in CIDR notation. An error
+// is returned if any of the patterns or ranges is malformed.
+func NewSANAllowList(dnsPatterns, cidrs []string) (*SANAllowList, error) {
+	for _, pattern := range dnsPatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("the allowed dns name pattern %q is invalid: %v", pattern, err)
+		}
+	}
+	ipNets := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("the allowed ip range %q is invalid: %v", cidr, err)
+		}
+		ipNets = append(ipNets, ipNet)
+	}
+	return &SANAllowList{
+		dnsPatterns: dnsPatterns,
+		ipNets:      ipNets,
+	}, nil
+}
+
+// Allowed returns false and the reason if the certificate request asks for any subject alternative name out of the
+// allow list. The requests which cannot be parsed are left to the validation of the CSRs.
+func (a *SANAllowList) Allowed(request []byte) (bool, string) {
+	block, _ := pem.Decode(request)
+	if block == nil {
+		return true, ""
+	}
+	x509cr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return true, ""
+	}
+
+	if len(x509cr.EmailAddresses) > 0 || len(x509cr.URIs) > 0 {
+		return false, "The email addresses and URIs are not allowed in the subject alternative names"
+	}
+	for _, dnsName := range x509cr.DNSNames {
+		if !a.dnsNameAllowed(dnsName) {
+			return false, fmt.Sprintf("The dns name %q is not in the subject alternative name allow list", dnsName)
+		}
+	}
+	for _, ip := range x509cr.IPAddresses {
+		if !a.ipAllowed(ip) {
+			return false, fmt.Sprintf("The ip address %q is not in the subject alternative name allow list", ip.String())
+		}
+	}
+	return true, ""
+}
+
+func (a *SANAllowList) dnsNameAllowed(dnsName string) bool {
+	if a == nil {
+		return false
+	}
+	for _, pattern := range a.dnsPatterns {
+		// the patterns are validated in NewSANAllowList
+		if matched, _ := path.Match(pattern, dnsName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *SANAllowList) ipAllowed(ip net.IP) bool {
+	if a == nil {
+		return false
+	}
+	for _, ipNet := range a.ipNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package csr
+
+import (
+	"net"
+	"testing"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSANAllowList(t *testing.T) {
+	cases := []struct {
+		name            string
+		dnsPatterns     []string
+		cidrs           []string
+		dnsNames        []string
+		ipAddresses     []net.IP
+		expectedErr     bool
+		expectedAllowed bool
+	}{
+		{
+			name:            "no sans",
+			expectedAllowed: true,
+		},
+		{
+			name:            "dns name not allowed by empty allow list",
+			dnsNames:        []string{"cluster1.example.com"},
+			expectedAllowed: false,
+		},
+		{
+			name:            "allowed by dns name pattern",
+			dnsPatterns:     []string{"*.example.com"},
+			dnsNames:        []string{"cluster1.example.com"},
+			expectedAllowed: true,
+		},
+		{
+			name:            "dns name pattern not matched",
+			dnsPatterns:     []string{"*.example.com"},
+			dnsNames:        []string{"cluster1.example.com", "cluster1.other.com"},
+			expectedAllowed: false,
+		},
+		{
+			name:            "allowed by dual stack ip ranges",
+			cidrs:           []string{"10.0.0.0/8", "fd00::/8"},
+			ipAddresses:     []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("fd00::1")},
+			expectedAllowed: true,
+		},
+		{
+			name:            "ip address out of range",
+			cidrs:           []string{"10.0.0.0/8"},
+			ipAddresses:     []net.IP{net.ParseIP("192.168.0.1")},
+			expectedAllowed: false,
+		},
+		{
+			name:        "invalid pattern",
+			dnsPatterns: []string{"*.example.["},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid ip range",
+			cidrs:       []string{"10.0.0.1"},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			allowList, err := NewSANAllowList(c.dnsPatterns, c.cidrs)
+			if c.expectedErr {
+				if err == nil {
+					t.Errorf("expected error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			holder := validCSR
+			holder.DNSNames = c.dnsNames
+			holder.IPAddresses = c.ipAddresses
+			allowed, reason := allowList.Allowed(testinghelpers.NewCSR(holder).Spec.Request)
+			if allowed != c.expectedAllowed {
+				t.Errorf("expected allowed %v, but got %v", c.expectedAllowed, allowed)
+			}
+			if !allowed && len(reason) == 0 {
+				t.Errorf("expected reason, but got empty")
+			}
+		})
+	}
+
+	var nilAllowList *SANAllowList
+	if allowed, _ := nilAllowList.Allowed(testinghelpers.NewCSR(validCSR).Spec.Request); !allowed {
+		t.Errorf("expected nil allow list allows the csrs without sans")
+	}
+	holder := validCSR
+	holder.DNSNames = []string{"cluster1.example.com"}
+	if allowed, _ := nilAllowList.Allowed(testinghelpers.NewCSR(holder).Spec.Request); allowed {
+		t.Errorf("expected nil allow list allows no sans")
+	}
+}
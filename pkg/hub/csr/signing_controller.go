@@ -0,0 +1,345 @@
+package csr
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	certificatesv1informers "k8s.io/client-go/informers/certificates/v1"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	certificatesv1listers "k8s.io/client-go/listers/certificates/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonclientset "open-cluster-management.io/api/client/addon/clientset/versioned"
+	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
+	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+// csrSigningController signs the approved CSRs of the custom signers, e.g. the CSRs of the addons which are
+// registered with their own signers, with the CAs loaded from the secrets on the hub. The CSRs of the kubernetes.io
+// signers are signed by the kube-controller-manager and are ignored. Once the CSR of an addon is signed, the hash of
+// the CAs of the custom signers of the addon is recorded with the signer CA hash annotation of the addon, so the
+// agent revokes the client certificates of the addon once the CAs are rotated.
+type csrSigningController struct {
+	kubeClient  kubernetes.Interface
+	addOnClient addonclientset.Interface
+	csrLister   certificatesv1listers.CertificateSigningRequestLister
+	addOnLister addonlisterv1alpha1.ManagedClusterAddOnLister
+	// secretListers are the listers of the secrets in the namespaces of the CA secrets
+	secretListers map[string]corev1listers.SecretLister
+	// caSecrets maps the signer names to the namespace/name of the secrets holding their CAs
+	caSecrets     map[string]string
+	certDuration  time.Duration
+	eventRecorder events.Recorder
+}
+
+// NewCSRSigningController creates a new csr signing controller
+func NewCSRSigningController(
+	kubeClient kubernetes.Interface,
+	addOnClient addonclientset.Interface,
+	csrInformer certificatesv1informers.CertificateSigningRequestInformer,
+	addOnInformer addoninformerv1alpha1.ManagedClusterAddOnInformer,
+	secretInformers map[string]corev1informers.SecretInformer,
+	caSecrets map[string]string,
+	certDuration time.Duration,
+	recorder events.Recorder) factory.Controller {
+	secretListers := map[string]corev1listers.SecretLister{}
+	bareInformers := []factory.Informer{addOnInformer.Informer()}
+	for namespace, secretInformer := range secretInformers {
+		secretListers[namespace] = secretInformer.Lister()
+		bareInformers = append(bareInformers, secretInformer.Informer())
+	}
+	c := &csrSigningController{
+		kubeClient:    kubeClient,
+		addOnClient:   addOnClient,
+		csrLister:     csrInformer.Lister(),
+		addOnLister:   addOnInformer.Lister(),
+		secretListers: secretListers,
+		caSecrets:     caSecrets,
+		certDuration:  certDuration,
+		eventRecorder: recorder.WithComponentSuffix("csr-signing-controller"),
+	}
+
+	return factory.New().
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetName()
+			},
+			func(obj interface{}) bool {
+				csr, ok := obj.(*certificatesv1.CertificateSigningRequest)
+				if !ok {
+					return false
+				}
+				_, ok = caSecrets[csr.Spec.SignerName]
+				return ok
+			},
+			csrInformer.Informer()).
+		WithBareInformers(bareInformers...).
+		WithSync(logging.SyncWithLogger("CSRSigningController", c.sync)).
+		ToController("CSRSigningController", recorder)
+}
+
+// ValidateSignerCASecrets verifies the signer names and the secrets of their CAs
+func ValidateSignerCASecrets(caSecrets map[string]string) error {
+	for signerName, secret := range caSecrets {
+		if len(signerName) == 0 || strings.HasPrefix(signerName, "kubernetes.io/") {
+			return fmt.Errorf("signer %q cannot be signed by the hub, the kubernetes.io signers are not supported", signerName)
+		}
+		if namespace, name, err := cache.SplitMetaNamespaceKey(secret); err != nil || len(namespace) == 0 || len(name) == 0 {
+			return fmt.Errorf("ca secret %q of signer %q must be in the format of namespace/name", secret, signerName)
+		}
+	}
+	return nil
+}
+
+func (c *csrSigningController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	csrName := syncCtx.QueueKey()
+	klog.FromContext(ctx).V(4).Info("Reconciling signing of CertificateSigningRequest")
+
+	csr, err := c.csrLister.Get(csrName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	secret, ok := c.caSecrets[csr.Spec.SignerName]
+	if !ok || !isApprovedCSR(csr) || len(csr.Status.Certificate) > 0 {
+		return nil
+	}
+
+	caCert, caKey, err := c.loadCA(secret)
+	if err != nil {
+		return fmt.Errorf("failed to load the ca of signer %q: %w", csr.Spec.SignerName, err)
+	}
+
+	certData, err := signCSR(csr, caCert, caKey, c.certDuration)
+	if err != nil {
+		// the csr cannot be signed, it is not retried
+		c.eventRecorder.Warningf("CSRSigningFailed", "failed to sign csr %q: %v", csr.Name, err)
+		return nil
+	}
+
+	csr = csr.DeepCopy()
+	csr.Status.Certificate = certData
+	if _, err := c.kubeClient.CertificatesV1().CertificateSigningRequests().UpdateStatus(ctx, csr, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	c.eventRecorder.Eventf("CSRSigned", "csr %q is signed by signer %q", csr.Name, csr.Spec.SignerName)
+
+	return c.recordSignerCAHash(ctx, csr)
+}
+
+// recordSignerCAHash sets the signer CA hash annotation of the addon of the csr with the hash of the CAs of the
+// custom signers in the registrations of the addon
+func (c *csrSigningController) recordSignerCAHash(ctx context.Context, csr *certificatesv1.CertificateSigningRequest) error {
+	clusterName, addOnName := csr.Labels[clusterv1.ClusterNameLabelKey], csr.Labels[addonv1alpha1.AddonLabelKey]
+	if len(clusterName) == 0 || len(addOnName) == 0 {
+		return nil
+	}
+	addOn, err := c.addOnLister.ManagedClusterAddOns(clusterName).Get(addOnName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	signerNames := sets.New[string]()
+	for _, registration := range addOn.Status.Registrations {
+		if _, ok := c.caSecrets[registration.SignerName]; ok {
+			signerNames.Insert(registration.SignerName)
+		}
+	}
+	if signerNames.Len() == 0 {
+		return nil
+	}
+	h := sha256.New()
+	for _, signerName := range sets.List(signerNames) {
+		caCert, _, err := c.loadCA(c.caSecrets[signerName])
+		if err != nil {
+			return fmt.Errorf("failed to load the ca of signer %q: %w", signerName, err)
+		}
+		h.Write([]byte(signerName))
+		h.Write(caCert.Raw)
+	}
+	caHash := fmt.Sprintf("%x", h.Sum(nil))
+	if addOn.Annotations[helpers.AddOnSignerCAHashAnnotation] == caHash {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{helpers.AddOnSignerCAHashAnnotation: caHash},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.addOnClient.AddonV1alpha1().ManagedClusterAddOns(clusterName).Patch(
+		ctx, addOnName, types.MergePatchType, patch, metav1.PatchOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// loadCA returns the certificate and private key of the CA in the secret with the namespace/name, the secret is read
+// from the informer of its namespace
+func (c *csrSigningController) loadCA(secret string) (*x509.Certificate, crypto.Signer, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	secretLister, ok := c.secretListers[namespace]
+	if !ok {
+		return nil, nil, fmt.Errorf("the secrets in namespace %q are not watched", namespace)
+	}
+	caSecret, err := secretLister.Secrets(namespace).Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certs, err := certutil.ParseCertsPEM(caSecret.Data["tls.crt"])
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(caSecret.Data["tls.key"])
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("the private key in secret %q is not a signer", secret)
+	}
+	return certs[0], signer, nil
+}
+
+// isApprovedCSR returns true if the csr is approved and is not denied or failed
+func isApprovedCSR(csr *certificatesv1.CertificateSigningRequest) bool {
+	approved := false
+	for _, condition := range csr.Status.Conditions {
+		switch condition.Type {
+		case certificatesv1.CertificateDenied, certificatesv1.CertificateFailed:
+			return false
+		case certificatesv1.CertificateApproved:
+			approved = true
+		}
+	}
+	return approved
+}
+
+// signCSR issues the certificate of the csr with the ca, the certificate expires after the expiration seconds of
+// the csr or the cert duration, whichever is shorter, and no later than the ca.
+func signCSR(csr *certificatesv1.CertificateSigningRequest, caCert *x509.Certificate, caKey crypto.Signer,
+	certDuration time.Duration) ([]byte, error) {
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("the request is not a PEM encoded certificate request")
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := request.CheckSignature(); err != nil {
+		return nil, err
+	}
+
+	keyUsage, extKeyUsages, err := keyUsagesFromStrings(csr.Spec.Usages)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := certDuration
+	if csr.Spec.ExpirationSeconds != nil {
+		if expiration := time.Duration(*csr.Spec.ExpirationSeconds) * time.Second; expiration < duration {
+			duration = expiration
+		}
+	}
+	now := time.Now()
+	notAfter := now.Add(duration)
+	if notAfter.After(caCert.NotAfter) {
+		notAfter = caCert.NotAfter
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               request.Subject,
+		DNSNames:              request.DNSNames,
+		IPAddresses:           request.IPAddresses,
+		URIs:                  request.URIs,
+		EmailAddresses:        request.EmailAddresses,
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              notAfter,
+		KeyUsage:              keyUsage,
+		ExtKeyUsage:           extKeyUsages,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, request.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: certutil.CertificateBlockType, Bytes: der}), nil
+}
+
+var keyUsages = map[certificatesv1.KeyUsage]x509.KeyUsage{
+	certificatesv1.UsageDigitalSignature: x509.KeyUsageDigitalSignature,
+	certificatesv1.UsageKeyEncipherment:  x509.KeyUsageKeyEncipherment,
+}
+
+var extKeyUsages = map[certificatesv1.KeyUsage]x509.ExtKeyUsage{
+	certificatesv1.UsageClientAuth: x509.ExtKeyUsageClientAuth,
+	certificatesv1.UsageServerAuth: x509.ExtKeyUsageServerAuth,
+}
+
+// keyUsagesFromStrings converts the usages of the csr to the key usages of the certificate, only the usages of the
+// client and serving certificates are supported
+func keyUsagesFromStrings(usages []certificatesv1.KeyUsage) (x509.KeyUsage, []x509.ExtKeyUsage, error) {
+	var keyUsage x509.KeyUsage
+	var extKeyUsage []x509.ExtKeyUsage
+	for _, usage := range usages {
+		if value, ok := keyUsages[usage]; ok {
+			keyUsage |= value
+			continue
+		}
+		if value, ok := extKeyUsages[usage]; ok {
+			extKeyUsage = append(extKeyUsage, value)
+			continue
+		}
+		return 0, nil, fmt.Errorf("unsupported key usage %q", usage)
+	}
+	return keyUsage, extKeyUsage, nil
+}
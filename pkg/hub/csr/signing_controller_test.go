@@ -0,0 +1,235 @@
+package csr
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clienttesting "k8s.io/client-go/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+const testSignerName = "example.com/signer"
+
+var customSignerCSR = testinghelpers.CSRHolder{
+	Name:         "addon-custom-csr",
+	SignerName:   testSignerName,
+	CN:           "system:open-cluster-management:cluster:managedcluster1:addon:addon1:agent:agent1",
+	ReqBlockType: "CERTIFICATE REQUEST",
+}
+
+func TestCSRSigningSync(t *testing.T) {
+	ca := testinghelpers.NewTestCert("signer-ca", 24*time.Hour)
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "open-cluster-management-hub", Name: "signer-ca"},
+		Data:       map[string][]byte{"tls.crt": ca.Cert, "tls.key": ca.Key},
+	}
+
+	cases := []struct {
+		name            string
+		csr             *certificatesv1.CertificateSigningRequest
+		secrets         []runtime.Object
+		addOns          []runtime.Object
+		expectedErr     bool
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+		// validateAddOnActions is optional, no addon action is expected if it is nil
+		validateAddOnActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "pending csr",
+			csr:             testinghelpers.NewCSR(customSignerCSR),
+			secrets:         []runtime.Object{caSecret},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "denied csr",
+			csr:             testinghelpers.NewDeniedCSR(customSignerCSR),
+			secrets:         []runtime.Object{caSecret},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name: "csr of other signer",
+			csr: testinghelpers.NewApprovedCSR(testinghelpers.CSRHolder{
+				Name:         "addon-csr",
+				SignerName:   certificatesv1.KubeAPIServerClientSignerName,
+				ReqBlockType: "CERTIFICATE REQUEST",
+			}),
+			secrets:         []runtime.Object{caSecret},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name: "csr is already signed",
+			csr: func() *certificatesv1.CertificateSigningRequest {
+				csr := testinghelpers.NewApprovedCSR(customSignerCSR)
+				csr.Status.Certificate = ca.Cert
+				return csr
+			}(),
+			secrets:         []runtime.Object{caSecret},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "ca secret is not found",
+			csr:             testinghelpers.NewApprovedCSR(customSignerCSR),
+			expectedErr:     true,
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:    "sign approved csr",
+			csr:     testinghelpers.NewApprovedCSR(customSignerCSR),
+			secrets: []runtime.Object{caSecret},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				csr := actions[0].(clienttesting.UpdateAction).GetObject().(*certificatesv1.CertificateSigningRequest)
+				block, _ := pem.Decode(csr.Status.Certificate)
+				if block == nil {
+					t.Fatalf("expected a signed certificate, but got %q", csr.Status.Certificate)
+				}
+				cert, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cert.Subject.CommonName != customSignerCSR.CN {
+					t.Errorf("expected common name %q, but got %q", customSignerCSR.CN, cert.Subject.CommonName)
+				}
+				// the certificate must not outlive the ca
+				if cert.NotAfter.After(time.Now().Add(24 * time.Hour)) {
+					t.Errorf("expected the certificate expires with the ca, but got %v", cert.NotAfter)
+				}
+			},
+		},
+		{
+			name: "sign approved addon csr",
+			csr: func() *certificatesv1.CertificateSigningRequest {
+				csr := testinghelpers.NewApprovedCSR(customSignerCSR)
+				csr.Labels = map[string]string{
+					clusterv1.ClusterNameLabelKey: "managedcluster1",
+					addonv1alpha1.AddonLabelKey:   "addon1",
+				}
+				return csr
+			}(),
+			secrets: []runtime.Object{caSecret},
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "managedcluster1", Name: "addon1"},
+				Status: addonv1alpha1.ManagedClusterAddOnStatus{
+					Registrations: []addonv1alpha1.RegistrationConfig{
+						{SignerName: certificatesv1.KubeAPIServerClientSignerName},
+						{SignerName: testSignerName},
+					},
+				},
+			}},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+			},
+			validateAddOnActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch")
+				addOn := &addonv1alpha1.ManagedClusterAddOn{}
+				if err := json.Unmarshal(actions[0].(clienttesting.PatchAction).GetPatch(), addOn); err != nil {
+					t.Fatal(err)
+				}
+				if len(addOn.Annotations[helpers.AddOnSignerCAHashAnnotation]) == 0 {
+					t.Errorf("expected the signer ca hash is recorded, but got %v", addOn.Annotations)
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOnClient := addonfake.NewSimpleClientset(c.addOns...)
+			addOnInformerFactory := addoninformers.NewSharedInformerFactory(addOnClient, 3*time.Minute)
+			for _, addOn := range c.addOns {
+				if err := addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore().Add(addOn); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient := kubefake.NewSimpleClientset(append(c.secrets, c.csr)...)
+			informerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 3*time.Minute)
+			if err := informerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore().Add(c.csr); err != nil {
+				t.Fatal(err)
+			}
+			for _, secret := range c.secrets {
+				if err := informerFactory.Core().V1().Secrets().Informer().GetStore().Add(secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &csrSigningController{
+				kubeClient:  kubeClient,
+				addOnClient: addOnClient,
+				csrLister:   informerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+				addOnLister: addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				secretListers: map[string]corev1listers.SecretLister{
+					"open-cluster-management-hub": informerFactory.Core().V1().Secrets().Lister(),
+				},
+				caSecrets:     map[string]string{testSignerName: "open-cluster-management-hub/signer-ca"},
+				certDuration:  365 * 24 * time.Hour,
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+			}
+			err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, c.csr.Name))
+			if c.expectedErr && err == nil {
+				t.Errorf("expected error, but got nil")
+			}
+			if !c.expectedErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			c.validateActions(t, kubeClient.Actions())
+			if c.validateAddOnActions == nil {
+				testinghelpers.AssertNoActions(t, addOnClient.Actions())
+				return
+			}
+			c.validateAddOnActions(t, addOnClient.Actions())
+		})
+	}
+}
+
+func TestValidateSignerCASecrets(t *testing.T) {
+	cases := []struct {
+		name        string
+		caSecrets   map[string]string
+		expectedErr bool
+	}{
+		{
+			name: "no signers",
+		},
+		{
+			name:      "valid signer",
+			caSecrets: map[string]string{testSignerName: "open-cluster-management-hub/signer-ca"},
+		},
+		{
+			name:        "kubernetes.io signer",
+			caSecrets:   map[string]string{certificatesv1.KubeAPIServerClientSignerName: "open-cluster-management-hub/signer-ca"},
+			expectedErr: true,
+		},
+		{
+			name:        "secret without namespace",
+			caSecrets:   map[string]string{testSignerName: "signer-ca"},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateSignerCASecrets(c.caSecrets)
+			if c.expectedErr != (err != nil) {
+				t.Errorf("expected error %v, but got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package hub
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// dryRunRoundTripper sends the write requests of the hub controllers as server-side dry-run requests, so the requests
+// are validated and admitted by the hub apiserver without being persisted, and logs them as the actions which would
+// be taken. The read requests are sent as they are.
+type dryRunRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+func newDryRunRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return &dryRunRoundTripper{delegate: rt}
+}
+
+func (rt *dryRunRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return rt.delegate.RoundTrip(req)
+	}
+
+	// the request must not be modified by the round tripper
+	req = req.Clone(req.Context())
+	query := req.URL.Query()
+	query.Set("dryRun", metav1.DryRunAll)
+	req.URL.RawQuery = query.Encode()
+
+	var body []byte
+	if req.Body != nil && req.Method == http.MethodPatch {
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(data))
+		body = data
+	}
+
+	// the requests sent in the syncs carry the logger of the controller
+	logger := klog.FromContext(req.Context()).WithValues("method", req.Method, "path", req.URL.Path)
+	resp, err := rt.delegate.RoundTrip(req)
+	switch {
+	case err != nil:
+		logger.Info("[dry-run] request failed", "err", err)
+	case len(body) > 0:
+		logger.Info("[dry-run] request", "patch", string(body), "status", resp.Status)
+	default:
+		logger.Info("[dry-run] request", "status", resp.Status)
+	}
+	return resp, err
+}
@@ -0,0 +1,52 @@
+package hub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestDryRunRoundTripper(t *testing.T) {
+	dryRuns := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		dryRuns[req.Method] = req.URL.Query().Get("dryRun")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"cluster1"}}`))
+	}))
+	defer server.Close()
+
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{
+		Host:          server.URL,
+		WrapTransport: newDryRunRoundTripper,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.TODO()
+	if _, err := kubeClient.CoreV1().Namespaces().Get(ctx, "cluster1", metav1.GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kubeClient.CoreV1().Namespaces().Patch(
+		ctx, "cluster1", types.MergePatchType, []byte(`{"metadata":{"labels":{"a":"b"}}}`), metav1.PatchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := kubeClient.CoreV1().Namespaces().Delete(ctx, "cluster1", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if dryRuns[http.MethodGet] != "" {
+		t.Errorf("expected the read requests are not dry-run, but got %q", dryRuns[http.MethodGet])
+	}
+	for _, method := range []string{http.MethodPatch, http.MethodDelete} {
+		if dryRuns[method] != metav1.DryRunAll {
+			t.Errorf("expected the %s requests are dry-run, but got %q", method, dryRuns[method])
+		}
+	}
+}
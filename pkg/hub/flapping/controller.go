@@ -0,0 +1,189 @@
+package flapping
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/clock"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+// maxHoldDownFactor bounds the exponential hold-down to the multiple of the initial hold-down
+const maxHoldDownFactor = 32
+
+// flapState is the availability of a managed cluster tracked by the controller
+type flapState struct {
+	// lastTransitionTime is the last transition time of the available condition seen
+	lastTransitionTime metav1.Time
+	// transitions are the times the transitions of the available condition are seen within the window
+	transitions []time.Time
+	// holdDown is the current hold-down of the flapping cluster, it is 0 if the cluster is not held down
+	holdDown  time.Duration
+	holdUntil time.Time
+}
+
+// flappingController counts the transitions of the available condition of each managed cluster. Once the condition
+// changes more than threshold times within the window, the cluster is held down with the Flapping condition, the
+// taint controller keeps the unavailable taint on the cluster while it is held down, so the placements do not
+// churn with an unstable WAN link. Each transition seen while the cluster is held down doubles the hold-down, and
+// the cluster is released once it is stable for the whole hold-down.
+//
+// The available condition is reported by the agent, so it is not changed by the controller. The transitions are
+// counted in memory, a cluster which is flapping when the hub is restarted is held down with the initial hold-down.
+type flappingController struct {
+	clusterClient clientset.Interface
+	clusterLister listerv1.ManagedClusterLister
+	threshold     int
+	window        time.Duration
+	holdDown      time.Duration
+	clock         clock.Clock
+	eventRecorder events.Recorder
+
+	lock   sync.Mutex
+	states map[string]*flapState
+}
+
+// NewFlappingController creates a new flapping controller on hub cluster.
+func NewFlappingController(
+	clusterClient clientset.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	threshold int,
+	window, holdDown time.Duration,
+	recorder events.Recorder) factory.Controller {
+	c := &flappingController{
+		clusterClient: clusterClient,
+		clusterLister: clusterInformer.Lister(),
+		threshold:     threshold,
+		window:        window,
+		holdDown:      holdDown,
+		clock:         clock.RealClock{},
+		eventRecorder: recorder.WithComponentSuffix("flapping-controller"),
+		states:        map[string]*flapState{},
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithSync(logging.SyncWithLogger("FlappingController", c.sync)).
+		ToController("FlappingController", recorder)
+}
+
+func (c *flappingController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling availability flapping of ManagedCluster")
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		delete(c.states, clusterName)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	available := meta.FindStatusCondition(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable)
+	if available == nil {
+		return nil
+	}
+
+	now := c.clock.Now()
+	flappingCond := meta.FindStatusCondition(cluster.Status.Conditions, helpers.ManagedClusterConditionFlapping)
+	state, ok := c.states[clusterName]
+	transitioned := false
+	switch {
+	case !ok:
+		state = &flapState{lastTransitionTime: available.LastTransitionTime}
+		// the cluster was held down before the hub is restarted
+		if flappingCond != nil && flappingCond.Status == metav1.ConditionTrue {
+			state.holdDown = c.holdDown
+			state.holdUntil = now.Add(state.holdDown)
+		}
+		c.states[clusterName] = state
+	case !state.lastTransitionTime.Equal(&available.LastTransitionTime):
+		state.lastTransitionTime = available.LastTransitionTime
+		state.transitions = append(state.transitions, now)
+		transitioned = true
+	}
+
+	// only the transitions within the window are counted
+	windowStart := now.Add(-c.window)
+	for len(state.transitions) > 0 && state.transitions[0].Before(windowStart) {
+		state.transitions = state.transitions[1:]
+	}
+
+	switch {
+	case state.holdDown > 0 && transitioned:
+		// the cluster flaps while it is held down, double the hold-down
+		state.holdDown *= 2
+		if state.holdDown > c.holdDown*maxHoldDownFactor {
+			state.holdDown = c.holdDown * maxHoldDownFactor
+		}
+		state.holdUntil = now.Add(state.holdDown)
+	case state.holdDown == 0 && len(state.transitions) > c.threshold:
+		state.holdDown = c.holdDown
+		state.holdUntil = now.Add(state.holdDown)
+	case state.holdDown > 0 && !now.Before(state.holdUntil):
+		// the cluster is stable for the whole hold-down, release it
+		state.holdDown = 0
+		state.transitions = nil
+	}
+
+	var cond metav1.Condition
+	if state.holdDown > 0 {
+		cond = metav1.Condition{
+			Type:   helpers.ManagedClusterConditionFlapping,
+			Status: metav1.ConditionTrue,
+			Reason: "AvailabilityFlapping",
+			Message: fmt.Sprintf("The available condition changed %d times within %s, the cluster is held down until %s",
+				len(state.transitions), c.window, state.holdUntil.UTC().Format(time.RFC3339)),
+		}
+		syncCtx.Queue().AddAfter(clusterName, state.holdUntil.Sub(now))
+	} else {
+		// a cluster which has never flapped does not get the condition
+		if flappingCond == nil {
+			return nil
+		}
+		cond = metav1.Condition{
+			Type:    helpers.ManagedClusterConditionFlapping,
+			Status:  metav1.ConditionFalse,
+			Reason:  "AvailabilityStable",
+			Message: "The available condition is stable",
+		}
+	}
+	if flappingCond != nil && flappingCond.Status == cond.Status && flappingCond.Message == cond.Message {
+		return nil
+	}
+
+	_, updated, err := helpers.UpdateManagedClusterStatus(
+		ctx, c.clusterClient, clusterName, helpers.UpdateManagedClusterConditionFn(cond))
+	if updated {
+		if cond.Status == metav1.ConditionTrue {
+			c.eventRecorder.Warningf("ManagedClusterFlapping", "managed cluster %s: %s", clusterName, cond.Message)
+		} else {
+			c.eventRecorder.Eventf("ManagedClusterStable", "managed cluster %s: %s", clusterName, cond.Message)
+		}
+	}
+	return err
+}
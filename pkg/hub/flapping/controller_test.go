@@ -0,0 +1,164 @@
+package flapping
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSync(t *testing.T) {
+	cases := []struct {
+		name             string
+		cluster          *clusterv1.ManagedCluster
+		expectedFlapping metav1.ConditionStatus
+	}{
+		{
+			name:    "stable cluster",
+			cluster: testinghelpers.NewAvailableManagedCluster(),
+		},
+		{
+			name: "cluster held down before the restart",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := testinghelpers.NewAvailableManagedCluster()
+				cluster.Status.Conditions = append(cluster.Status.Conditions, testinghelpers.NewManagedClusterCondition(
+					helpers.ManagedClusterConditionFlapping, "True", "AvailabilityFlapping", "", nil))
+				return cluster
+			}(),
+			expectedFlapping: metav1.ConditionTrue,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl, _ := newTestController(t, c.cluster)
+			syncCtx := testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)
+			testinghelpers.AssertError(t, ctrl.sync(context.TODO(), syncCtx), "")
+			assertFlapping(t, ctrl, c.expectedFlapping)
+		})
+	}
+}
+
+func TestFlapping(t *testing.T) {
+	ctrl, store := newTestController(t, testinghelpers.NewAvailableManagedCluster())
+	fakeClock := ctrl.clock.(*clocktesting.FakeClock)
+	syncCtx := testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)
+
+	// flip changes the available condition of the cluster and syncs it after the duration
+	flip := func(after time.Duration) {
+		fakeClock.Step(after)
+		cluster, err := ctrl.clusterClient.ClusterV1().ManagedClusters().Get(
+			context.TODO(), testinghelpers.TestManagedClusterName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		status := metav1.ConditionFalse
+		if meta.IsStatusConditionFalse(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable) {
+			status = metav1.ConditionTrue
+		}
+		meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+			Type:               clusterv1.ManagedClusterConditionAvailable,
+			Status:             status,
+			Reason:             "ManagedClusterAvailabilityChanged",
+			LastTransitionTime: metav1.NewTime(fakeClock.Now().Truncate(time.Second)),
+		})
+		if _, err := ctrl.clusterClient.ClusterV1().ManagedClusters().UpdateStatus(
+			context.TODO(), cluster, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		syncCluster(t, ctrl, store, syncCtx)
+	}
+
+	syncCluster(t, ctrl, store, syncCtx)
+	assertFlapping(t, ctrl, "")
+
+	// the transitions within the threshold are tolerated
+	flip(time.Minute)
+	flip(time.Minute)
+	assertFlapping(t, ctrl, "")
+
+	// the third transition within the window holds the cluster down
+	flip(time.Minute)
+	assertFlapping(t, ctrl, metav1.ConditionTrue)
+	if holdDown := ctrl.states[testinghelpers.TestManagedClusterName].holdDown; holdDown != time.Minute {
+		t.Errorf("expected hold-down 1m, but got %s", holdDown)
+	}
+
+	// the cluster flaps while it is held down, the hold-down is doubled
+	flip(30 * time.Second)
+	assertFlapping(t, ctrl, metav1.ConditionTrue)
+	if holdDown := ctrl.states[testinghelpers.TestManagedClusterName].holdDown; holdDown != 2*time.Minute {
+		t.Errorf("expected hold-down 2m, but got %s", holdDown)
+	}
+
+	// the cluster is still held down
+	fakeClock.Step(time.Minute)
+	syncCluster(t, ctrl, store, syncCtx)
+	assertFlapping(t, ctrl, metav1.ConditionTrue)
+
+	// the cluster is stable for the whole hold-down
+	fakeClock.Step(time.Minute)
+	syncCluster(t, ctrl, store, syncCtx)
+	assertFlapping(t, ctrl, metav1.ConditionFalse)
+}
+
+func newTestController(t *testing.T, cluster *clusterv1.ManagedCluster) (*flappingController, cache.Store) {
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+	store := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+	if err := store.Add(cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	return &flappingController{
+		clusterClient: clusterClient,
+		clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		threshold:     2,
+		window:        10 * time.Minute,
+		holdDown:      time.Minute,
+		clock:         clocktesting.NewFakeClock(time.Now()),
+		eventRecorder: eventstesting.NewTestingEventRecorder(t),
+		states:        map[string]*flapState{},
+	}, store
+}
+
+// syncCluster syncs the cluster in the client into the store, and syncs the controller
+func syncCluster(t *testing.T, ctrl *flappingController, store cache.Store, syncCtx *testinghelpers.FakeSyncContext) {
+	cluster, err := ctrl.clusterClient.ClusterV1().ManagedClusters().Get(
+		context.TODO(), testinghelpers.TestManagedClusterName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Update(cluster); err != nil {
+		t.Fatal(err)
+	}
+	testinghelpers.AssertError(t, ctrl.sync(context.TODO(), syncCtx), "")
+}
+
+// assertFlapping asserts the status of the flapping condition of the cluster in the client, the condition is
+// expected to be absent if the status is empty
+func assertFlapping(t *testing.T, ctrl *flappingController, status metav1.ConditionStatus) {
+	cluster, err := ctrl.clusterClient.ClusterV1().ManagedClusters().Get(
+		context.TODO(), testinghelpers.TestManagedClusterName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, helpers.ManagedClusterConditionFlapping)
+	switch {
+	case len(status) == 0 && cond != nil:
+		t.Errorf("expected no flapping condition, but got %v", cond)
+	case len(status) > 0 && (cond == nil || cond.Status != status):
+		t.Errorf("expected flapping condition %s, but got %v", status, cond)
+	}
+}
@@ -0,0 +1,3 @@
+// package flapping contains the hub-side controller which detects the managed clusters whose available condition
+// flaps, and holds them down with the Flapping condition until their availability is stable.
+package flapping
@@ -0,0 +1,4 @@
+// package grpcserver contains the hub-side endpoint of the agent-less registration. It allows the edge devices
+// which do not run Kubernetes to obtain a ManagedCluster identity by submitting certificate signing requests, and
+// to report the heartbeats with the issued client certificates. The endpoint speaks JSON over HTTPS.
+package grpcserver
@@ -0,0 +1,431 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	authnuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/hub/user"
+)
+
+const (
+	// RegistrationsPath is the path to submit a certificate signing request with a bootstrap token, and to get
+	// the issued certificate with RegistrationsPath/<csr name>.
+	RegistrationsPath = "/v1/registrations"
+	// HeartbeatsPath is the path to renew the lease of a cluster with its issued client certificate.
+	HeartbeatsPath = "/v1/heartbeats"
+
+	// AgentlessRequesterAnnotation is the annotation on the CSRs submitted through the agent-less registration,
+	// its value is the user name authenticated with the bearer token of the request.
+	AgentlessRequesterAnnotation = "open-cluster-management.io/agentless-requester"
+
+	leaseName = "managed-cluster-lease"
+
+	// maxRequestBodySize limits the size of the request bodies
+	maxRequestBodySize = 64 * 1024
+)
+
+// RegistrationRequest is the request to register a cluster
+type RegistrationRequest struct {
+	// ClusterName is the name of the ManagedCluster to register
+	ClusterName string `json:"clusterName"`
+	// Request is the PEM encoded certificate signing request of the cluster, its subject must be in the format
+	// of CN=system:open-cluster-management:<cluster name>:<agent name>, O=system:open-cluster-management:<cluster name>
+	Request []byte `json:"request"`
+}
+
+// RegistrationStatus is the status of a registration request
+type RegistrationStatus struct {
+	// Name is the name of the certificate signing request on the hub
+	Name string `json:"name"`
+	// Approved is true once the certificate signing request is approved by the hub cluster admin
+	Approved bool `json:"approved"`
+	// Denied is true if the certificate signing request is denied by the hub cluster admin
+	Denied bool `json:"denied"`
+	// Certificate is the PEM encoded client certificate issued by the hub
+	Certificate []byte `json:"certificate,omitempty"`
+}
+
+// ImpersonatingClientFunc returns a kube client impersonating the user
+type ImpersonatingClientFunc func(userInfo authenticationv1.UserInfo) (kubernetes.Interface, error)
+
+// NewImpersonatingClientFunc returns an ImpersonatingClientFunc building the kube clients from the config. Only the
+// user name and the groups of the user are impersonated, and the system:authenticated group is left to the kube
+// apiserver, so the hub is only required to impersonate the bootstrap users and groups, see
+// deploy/hub/agentless-registration.
+func NewImpersonatingClientFunc(config *rest.Config) ImpersonatingClientFunc {
+	return func(userInfo authenticationv1.UserInfo) (kubernetes.Interface, error) {
+		groups := []string{}
+		for _, group := range userInfo.Groups {
+			if group == authnuser.AllAuthenticated {
+				continue
+			}
+			groups = append(groups, group)
+		}
+		impersonatingConfig := rest.CopyConfig(config)
+		impersonatingConfig.Impersonate = rest.ImpersonationConfig{
+			UserName: userInfo.Username,
+			Groups:   groups,
+		}
+		return kubernetes.NewForConfig(impersonatingConfig)
+	}
+}
+
+// Server serves the agent-less registration endpoint on the hub
+type Server struct {
+	kubeClient         kubernetes.Interface
+	clusterClient      clusterclientset.Interface
+	impersonatedClient ImpersonatingClientFunc
+	eventRecorder      events.Recorder
+}
+
+// NewServer returns a Server. The certificate signing requests are created by impersonating the requesters, so
+// the requesters are recorded as the usernames of the requests and verified by the csr approving controller.
+func NewServer(kubeClient kubernetes.Interface, clusterClient clusterclientset.Interface,
+	impersonatedClient ImpersonatingClientFunc, recorder events.Recorder) *Server {
+	return &Server{
+		kubeClient:         kubeClient,
+		clusterClient:      clusterClient,
+		impersonatedClient: impersonatedClient,
+		eventRecorder:      recorder.WithComponentSuffix("agentless-registration-server"),
+	}
+}
+
+// Handler returns the http handler of the endpoint
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(RegistrationsPath, s.handleRegistration)
+	mux.HandleFunc(RegistrationsPath+"/", s.handleRegistrationStatus)
+	mux.HandleFunc(HeartbeatsPath, s.handleHeartbeat)
+	return mux
+}
+
+// Run serves the endpoint on the bind address with the serving certificate until the context is done. The client
+// certificates of the heartbeats are verified with the client CA bundle.
+func (s *Server) Run(ctx context.Context, bindAddress, certFile, keyFile string, clientCAs *x509.CertPool) error {
+	server := &http.Server{
+		Addr:    bindAddress,
+		Handler: s.Handler(),
+		TLSConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			// the registration requests are authenticated with the bearer tokens
+			ClientAuth: tls.VerifyClientCertIfGiven,
+			ClientCAs:  clientCAs,
+		},
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			klog.Errorf("failed to shutdown the agent-less registration server: %v", err)
+		}
+	}()
+
+	klog.Infof("Serving the agent-less registration on %s", bindAddress)
+	if err := server.ListenAndServeTLS(certFile, keyFile); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// handleRegistration creates the ManagedCluster if it does not exist, and submits the certificate signing request
+// of the cluster as the requester. The requester must be allowed to create certificate signing requests on the hub.
+func (s *Server) handleRegistration(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+
+	userInfo, status, err := s.authorize(r.Context(), r, "create")
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	request := &RegistrationRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unable to decode the registration request: %v", err))
+		return
+	}
+	if err := validateRegistrationRequest(request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.ensureManagedCluster(r.Context(), request.ClusterName); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	requesterClient, err := s.impersonatedClient(userInfo)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	csr, err := requesterClient.CertificatesV1().CertificateSigningRequests().Create(r.Context(), &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-", request.ClusterName),
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabelKey: request.ClusterName,
+			},
+			Annotations: map[string]string{
+				AgentlessRequesterAnnotation: userInfo.Username,
+			},
+		},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:    request.Request,
+			SignerName: certificatesv1.KubeAPIServerClientSignerName,
+			Usages: []certificatesv1.KeyUsage{
+				certificatesv1.UsageDigitalSignature,
+				certificatesv1.UsageKeyEncipherment,
+				certificatesv1.UsageClientAuth,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.eventRecorder.Eventf("AgentlessRegistrationRequested", "csr %q of cluster %q is submitted by %q",
+		csr.Name, request.ClusterName, userInfo.Username)
+	writeJSON(w, http.StatusCreated, &RegistrationStatus{Name: csr.Name})
+}
+
+// handleRegistrationStatus returns the status of a certificate signing request submitted through the agent-less
+// registration. The requester must be allowed to get certificate signing requests on the hub.
+func (s *Server) handleRegistrationStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+
+	if _, status, err := s.authorize(r.Context(), r, "get"); err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, RegistrationsPath+"/")
+	csr, err := s.kubeClient.CertificatesV1().CertificateSigningRequests().Get(r.Context(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) || (err == nil && len(csr.Annotations[AgentlessRequesterAnnotation]) == 0) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("registration %q is not found", name))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	status := &RegistrationStatus{Name: csr.Name, Certificate: csr.Status.Certificate}
+	for _, condition := range csr.Status.Conditions {
+		switch condition.Type {
+		case certificatesv1.CertificateApproved:
+			status.Approved = true
+		case certificatesv1.CertificateDenied:
+			status.Denied = true
+		}
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleHeartbeat renews the lease of the cluster identified by the verified client certificate, and marks the
+// cluster joined with the first heartbeat.
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("a client certificate issued by hub is required"))
+		return
+	}
+	clusterName, err := clusterNameFromCertificate(r.TLS.VerifiedChains[0][0])
+	if err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := s.renewLease(r.Context(), clusterName); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorize authenticates the bearer token of the request, and checks whether the user is allowed to access the
+// certificate signing requests with the verb. It returns the user, or the http status code with the error.
+func (s *Server) authorize(ctx context.Context, r *http.Request, verb string) (authenticationv1.UserInfo, int, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if len(token) == 0 || token == r.Header.Get("Authorization") {
+		return authenticationv1.UserInfo{}, http.StatusUnauthorized, fmt.Errorf("a bearer token is required")
+	}
+
+	review, err := s.kubeClient.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return authenticationv1.UserInfo{}, http.StatusInternalServerError, err
+	}
+	if !review.Status.Authenticated {
+		return authenticationv1.UserInfo{}, http.StatusUnauthorized, fmt.Errorf("the bearer token is not authenticated")
+	}
+
+	userInfo := review.Status.User
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, v := range userInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	sar, err := s.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   userInfo.Username,
+			UID:    userInfo.UID,
+			Groups: userInfo.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:    "certificates.k8s.io",
+				Resource: "certificatesigningrequests",
+				Verb:     verb,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return authenticationv1.UserInfo{}, http.StatusInternalServerError, err
+	}
+	if !sar.Status.Allowed {
+		return authenticationv1.UserInfo{}, http.StatusForbidden, fmt.Errorf("user %q is not allowed to %s certificate signing requests",
+			userInfo.Username, verb)
+	}
+	return userInfo, http.StatusOK, nil
+}
+
+// ensureManagedCluster creates the ManagedCluster if it does not exist, the cluster is not accepted until the
+// hub cluster admin accepts it.
+func (s *Server) ensureManagedCluster(ctx context.Context, clusterName string) error {
+	_, err := s.clusterClient.ClusterV1().ManagedClusters().Get(ctx, clusterName, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	_, err = s.clusterClient.ClusterV1().ManagedClusters().Create(ctx, &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterName},
+	}, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.eventRecorder.Eventf("ManagedClusterCreated", "managed cluster %q is created by the agent-less registration", clusterName)
+	return nil
+}
+
+// renewLease renews the lease of the cluster, the lease is created by the hub once the cluster is accepted.
+func (s *Server) renewLease(ctx context.Context, clusterName string) error {
+	lease, err := s.kubeClient.CoordinationV1().Leases(clusterName).Get(ctx, leaseName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get the lease of cluster %q: %w", clusterName, err)
+	}
+
+	lease = lease.DeepCopy()
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now()}
+	if _, err := s.kubeClient.CoordinationV1().Leases(clusterName).Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update the lease of cluster %q: %w", clusterName, err)
+	}
+
+	_, updated, err := helpers.UpdateManagedClusterStatus(ctx, s.clusterClient, clusterName,
+		helpers.UpdateManagedClusterConditionFn(metav1.Condition{
+			Type:    clusterv1.ManagedClusterConditionJoined,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ManagedClusterJoined",
+			Message: "Managed cluster joined",
+		}))
+	if err != nil {
+		return err
+	}
+	if updated {
+		s.eventRecorder.Eventf("ManagedClusterJoined", "managed cluster %q joined hub with the agent-less registration", clusterName)
+	}
+	return nil
+}
+
+// validateRegistrationRequest verifies the cluster name and the subject of the certificate signing request
+func validateRegistrationRequest(request *RegistrationRequest) error {
+	if errs := validation.IsDNS1123Label(request.ClusterName); len(errs) > 0 {
+		return fmt.Errorf("cluster name %q is invalid: %s", request.ClusterName, strings.Join(errs, ", "))
+	}
+
+	block, _ := pem.Decode(request.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return fmt.Errorf("the request is not a PEM encoded certificate signing request")
+	}
+	x509cr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("unable to parse the certificate signing request: %v", err)
+	}
+
+	expectedOrg := user.SubjectPrefix + request.ClusterName
+	if len(x509cr.Subject.Organization) != 1 || x509cr.Subject.Organization[0] != expectedOrg {
+		return fmt.Errorf("the organization of the certificate signing request must be %q", expectedOrg)
+	}
+	if !strings.HasPrefix(x509cr.Subject.CommonName, expectedOrg+":") {
+		return fmt.Errorf("the common name of the certificate signing request must be in the format of %s:<agent name>", expectedOrg)
+	}
+	return nil
+}
+
+// clusterNameFromCertificate returns the cluster name from the subject of a client certificate issued by hub
+func clusterNameFromCertificate(cert *x509.Certificate) (string, error) {
+	if !strings.HasPrefix(cert.Subject.CommonName, user.SubjectPrefix) {
+		return "", fmt.Errorf("the client certificate %q is not issued for a managed cluster", cert.Subject.CommonName)
+	}
+	names := strings.Split(strings.TrimPrefix(cert.Subject.CommonName, user.SubjectPrefix), ":")
+	if len(names) != 2 || len(names[0]) == 0 || len(names[1]) == 0 {
+		return "", fmt.Errorf("the client certificate %q is not issued for a managed cluster", cert.Subject.CommonName)
+	}
+	for _, org := range cert.Subject.Organization {
+		if org == user.SubjectPrefix+names[0] {
+			return names[0], nil
+		}
+	}
+	return "", fmt.Errorf("the client certificate %q is not issued for a managed cluster", cert.Subject.CommonName)
+}
+
+func writeJSON(w http.ResponseWriter, status int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		klog.Errorf("failed to write the response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,312 @@
+package grpcserver
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+const testCN = "system:open-cluster-management:" + testinghelpers.TestManagedClusterName + ":agent1"
+const testOrg = "system:open-cluster-management:" + testinghelpers.TestManagedClusterName
+
+func TestRegistration(t *testing.T) {
+	cases := []struct {
+		name                   string
+		token                  string
+		authenticated          bool
+		allowed                bool
+		request                *RegistrationRequest
+		existingClusters       []runtime.Object
+		expectedStatus         int
+		expectedClusterActions []string
+	}{
+		{
+			name:           "no bearer token",
+			request:        newRegistrationRequest(testinghelpers.TestManagedClusterName, testCN, testOrg),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "token is not authenticated",
+			token:          "invalid",
+			request:        newRegistrationRequest(testinghelpers.TestManagedClusterName, testCN, testOrg),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "user is not allowed",
+			token:          "token",
+			authenticated:  true,
+			request:        newRegistrationRequest(testinghelpers.TestManagedClusterName, testCN, testOrg),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "invalid subject",
+			token:          "token",
+			authenticated:  true,
+			allowed:        true,
+			request:        newRegistrationRequest(testinghelpers.TestManagedClusterName, testCN, "system:open-cluster-management:cluster2"),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:                   "register a new cluster",
+			token:                  "token",
+			authenticated:          true,
+			allowed:                true,
+			request:                newRegistrationRequest(testinghelpers.TestManagedClusterName, testCN, testOrg),
+			expectedStatus:         http.StatusCreated,
+			expectedClusterActions: []string{"get", "create"},
+		},
+		{
+			name:                   "register an existing cluster",
+			token:                  "token",
+			authenticated:          true,
+			allowed:                true,
+			request:                newRegistrationRequest(testinghelpers.TestManagedClusterName, testCN, testOrg),
+			existingClusters:       []runtime.Object{testinghelpers.NewManagedCluster()},
+			expectedStatus:         http.StatusCreated,
+			expectedClusterActions: []string{"get"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := newFakeKubeClient(c.authenticated, c.allowed)
+			clusterClient := clusterfake.NewSimpleClientset(c.existingClusters...)
+			var impersonated []string
+			server := NewServer(kubeClient, clusterClient, func(userInfo authenticationv1.UserInfo) (kubernetes.Interface, error) {
+				impersonated = append(impersonated, userInfo.Username)
+				return kubeClient, nil
+			}, eventstesting.NewTestingEventRecorder(t))
+
+			body, err := json.Marshal(c.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := httptest.NewRequest(http.MethodPost, RegistrationsPath, bytes.NewReader(body))
+			if len(c.token) > 0 {
+				r.Header.Set("Authorization", "Bearer "+c.token)
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, r)
+
+			if w.Code != c.expectedStatus {
+				t.Errorf("expected status %d, but got %d: %s", c.expectedStatus, w.Code, w.Body.String())
+			}
+			testinghelpers.AssertActions(t, clusterClient.Actions(), c.expectedClusterActions...)
+			if c.expectedStatus != http.StatusCreated {
+				return
+			}
+
+			csrs, err := kubeClient.CertificatesV1().CertificateSigningRequests().List(r.Context(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(csrs.Items) != 1 {
+				t.Fatalf("expected 1 csr, but got %d", len(csrs.Items))
+			}
+			if len(impersonated) != 1 || impersonated[0] != "system:bootstrap:abcdef" {
+				t.Errorf("expected the csr is created by impersonating the requester, but got %v", impersonated)
+			}
+			if csrs.Items[0].Annotations[AgentlessRequesterAnnotation] != "system:bootstrap:abcdef" {
+				t.Errorf("unexpected csr annotations %v", csrs.Items[0].Annotations)
+			}
+			if csrs.Items[0].Spec.SignerName != certificatesv1.KubeAPIServerClientSignerName {
+				t.Errorf("unexpected signer %q", csrs.Items[0].Spec.SignerName)
+			}
+		})
+	}
+}
+
+func TestRegistrationStatus(t *testing.T) {
+	issued := testinghelpers.NewApprovedCSR(testinghelpers.CSRHolder{Name: "issued", CN: testCN, Orgs: []string{testOrg}})
+	issued.Annotations = map[string]string{AgentlessRequesterAnnotation: "system:bootstrap:abcdef"}
+	issued.Status.Certificate = []byte("cert")
+	other := testinghelpers.NewCSR(testinghelpers.CSRHolder{Name: "other", CN: testCN, Orgs: []string{testOrg}})
+
+	cases := []struct {
+		name           string
+		csrName        string
+		expectedStatus int
+		expected       *RegistrationStatus
+	}{
+		{
+			name:           "issued certificate",
+			csrName:        "issued",
+			expectedStatus: http.StatusOK,
+			expected:       &RegistrationStatus{Name: "issued", Approved: true, Certificate: []byte("cert")},
+		},
+		{
+			name:           "csr is not submitted through the agent-less registration",
+			csrName:        "other",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "csr does not exist",
+			csrName:        "missing",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := newFakeKubeClient(true, true, issued, other)
+			server := NewServer(kubeClient, clusterfake.NewSimpleClientset(), nil, eventstesting.NewTestingEventRecorder(t))
+
+			r := httptest.NewRequest(http.MethodGet, RegistrationsPath+"/"+c.csrName, nil)
+			r.Header.Set("Authorization", "Bearer token")
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, r)
+
+			if w.Code != c.expectedStatus {
+				t.Fatalf("expected status %d, but got %d: %s", c.expectedStatus, w.Code, w.Body.String())
+			}
+			if c.expected == nil {
+				return
+			}
+			actual := &RegistrationStatus{}
+			if err := json.Unmarshal(w.Body.Bytes(), actual); err != nil {
+				t.Fatal(err)
+			}
+			if actual.Name != c.expected.Name || actual.Approved != c.expected.Approved ||
+				actual.Denied != c.expected.Denied || !bytes.Equal(actual.Certificate, c.expected.Certificate) {
+				t.Errorf("expected %v, but got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	cases := []struct {
+		name            string
+		commonName      string
+		orgs            []string
+		expectedStatus  int
+		expectedActions []string
+	}{
+		{
+			name:           "no client certificate",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "certificate is not issued for a cluster",
+			commonName:     "system:open-cluster-management:cluster:" + testinghelpers.TestManagedClusterName + ":addon:addon1:agent:agent1",
+			orgs:           []string{testOrg},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:            "renew the lease",
+			commonName:      testCN,
+			orgs:            []string{testOrg},
+			expectedStatus:  http.StatusNoContent,
+			expectedActions: []string{"get", "update"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(testinghelpers.NewManagedClusterLease(leaseName, time.Now().Add(-5*time.Minute)))
+			clusterClient := clusterfake.NewSimpleClientset(testinghelpers.NewAcceptedManagedCluster())
+			server := NewServer(kubeClient, clusterClient, nil, eventstesting.NewTestingEventRecorder(t))
+
+			r := httptest.NewRequest(http.MethodPut, HeartbeatsPath, nil)
+			if len(c.commonName) > 0 {
+				cert := testinghelpers.NewTestCertWithSubject(pkix.Name{CommonName: c.commonName, Organization: c.orgs}, time.Hour)
+				block, _ := pem.Decode(cert.Cert)
+				x509Cert, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{x509Cert}}}
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, r)
+
+			if w.Code != c.expectedStatus {
+				t.Errorf("expected status %d, but got %d: %s", c.expectedStatus, w.Code, w.Body.String())
+			}
+			testinghelpers.AssertActions(t, kubeClient.Actions(), c.expectedActions...)
+		})
+	}
+}
+
+func TestClusterNameFromCertificate(t *testing.T) {
+	cases := []struct {
+		name         string
+		subject      pkix.Name
+		expectedName string
+	}{
+		{
+			name:         "cluster certificate",
+			subject:      pkix.Name{CommonName: testCN, Organization: []string{testOrg}},
+			expectedName: testinghelpers.TestManagedClusterName,
+		},
+		{
+			name:    "organization mismatch",
+			subject: pkix.Name{CommonName: testCN, Organization: []string{"system:open-cluster-management:cluster2"}},
+		},
+		{
+			name:    "not an open-cluster-management user",
+			subject: pkix.Name{CommonName: "admin", Organization: []string{testOrg}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			name, err := clusterNameFromCertificate(&x509.Certificate{Subject: c.subject})
+			if name != c.expectedName {
+				t.Errorf("expected %q, but got %q", c.expectedName, name)
+			}
+			if len(c.expectedName) == 0 && err == nil {
+				t.Errorf("expected error, but got nil")
+			}
+		})
+	}
+}
+
+func newRegistrationRequest(clusterName, commonName, org string) *RegistrationRequest {
+	csr := testinghelpers.NewCSR(testinghelpers.CSRHolder{
+		CN:           commonName,
+		Orgs:         []string{org},
+		ReqBlockType: "CERTIFICATE REQUEST",
+	})
+	return &RegistrationRequest{ClusterName: clusterName, Request: csr.Spec.Request}
+}
+
+// newFakeKubeClient returns a fake kube client which authenticates the token "token" and authorizes the
+// requests with the results
+func newFakeKubeClient(authenticated, allowed bool, objects ...runtime.Object) *kubefake.Clientset {
+	kubeClient := kubefake.NewSimpleClientset(objects...)
+	kubeClient.PrependReactor("create", "tokenreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		review.Status = authenticationv1.TokenReviewStatus{
+			Authenticated: authenticated && review.Spec.Token == "token",
+			User:          authenticationv1.UserInfo{Username: "system:bootstrap:abcdef"},
+		}
+		return true, review, nil
+	})
+	kubeClient.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, &authorizationv1.SubjectAccessReview{
+			Status: authorizationv1.SubjectAccessReviewStatus{Allowed: allowed},
+		}, nil
+	})
+	return kubeClient
+}
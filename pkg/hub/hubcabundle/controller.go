@@ -0,0 +1,134 @@
+package hubcabundle
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+	"open-cluster-management.io/registration/pkg/tracing"
+)
+
+// hubCABundleController publishes the CA bundle in the source configmap to the cluster namespace of each accepted
+// managed cluster, so that the agents are able to trust the hub apiserver after its serving certificate is rotated.
+type hubCABundleController struct {
+	kubeClient      kubernetes.Interface
+	clusterLister   listerv1.ManagedClusterLister
+	configMapLister corev1listers.ConfigMapLister
+	sourceNamespace string
+	sourceName      string
+	eventRecorder   events.Recorder
+}
+
+// NewHubCABundleController creates a new hub CA bundle controller. The configmap informer is expected to watch the
+// namespace of the source configmap.
+func NewHubCABundleController(
+	kubeClient kubernetes.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	sourceNamespace, sourceName string,
+	recorder events.Recorder) factory.Controller {
+	c := &hubCABundleController{
+		kubeClient:      kubeClient,
+		clusterLister:   clusterInformer.Lister(),
+		configMapLister: configMapInformer.Lister(),
+		sourceNamespace: sourceNamespace,
+		sourceName:      sourceName,
+		eventRecorder:   recorder.WithComponentSuffix("hub-ca-bundle-controller"),
+	}
+
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				// publish to all clusters once the CA bundle is rotated
+				return factory.DefaultQueueKey
+			},
+			func(obj interface{}) bool {
+				accessor, err := meta.Accessor(obj)
+				if err != nil {
+					return false
+				}
+				return accessor.GetNamespace() == sourceNamespace && accessor.GetName() == sourceName
+			},
+			configMapInformer.Informer()).
+		WithSync(tracing.TraceSync("HubCABundleController", c.sync)).
+		ToController("HubCABundleController", recorder)
+}
+
+func (c *hubCABundleController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	if clusterName == factory.DefaultQueueKey {
+		clusters, err := c.clusterLister.List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		for _, cluster := range clusters {
+			syncCtx.Queue().Add(cluster.Name)
+		}
+		return nil
+	}
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling hub CA bundle of ManagedCluster")
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// the cluster namespace is cleaned up with the cluster
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// the cluster namespace only exists after the cluster is accepted
+	if !cluster.Spec.HubAcceptsClient || !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	source, err := c.configMapLister.ConfigMaps(c.sourceNamespace).Get(c.sourceName)
+	if errors.IsNotFound(err) {
+		logger.V(4).Info("Hub CA bundle configmap is not found", "configMap", c.sourceNamespace+"/"+c.sourceName)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	caBundle, ok := source.Data[helpers.HubCABundleKey]
+	if !ok || len(caBundle) == 0 {
+		logger.Info("Hub CA bundle configmap has no CA bundle", "configMap", c.sourceNamespace+"/"+c.sourceName, "key", helpers.HubCABundleKey)
+		return nil
+	}
+
+	_, _, err = resourceapply.ApplyConfigMap(ctx, c.kubeClient.CoreV1(), c.eventRecorder, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: clusterName,
+			Name:      helpers.HubCABundleConfigMapName,
+		},
+		Data: map[string]string{
+			helpers.HubCABundleKey: caBundle,
+		},
+	})
+	if errors.IsNotFound(err) {
+		// the cluster namespace is not created yet, wait for the next cluster event
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+package hubcabundle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+const (
+	sourceNamespace = "open-cluster-management-hub"
+	sourceName      = "hub-ca-bundle"
+	caBundle        = "ca-bundle"
+)
+
+func TestSync(t *testing.T) {
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: sourceNamespace, Name: sourceName},
+		Data:       map[string]string{helpers.HubCABundleKey: caBundle},
+	}
+
+	cases := []struct {
+		name            string
+		queueKey        string
+		clusters        []runtime.Object
+		configMaps      []runtime.Object
+		expectedQueued  int
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "enqueue all clusters",
+			queueKey:        factory.DefaultQueueKey,
+			clusters:        []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			expectedQueued:  1,
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "cluster is not found",
+			queueKey:        testinghelpers.TestManagedClusterName,
+			configMaps:      []runtime.Object{source},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "cluster is not accepted",
+			queueKey:        testinghelpers.TestManagedClusterName,
+			clusters:        []runtime.Object{testinghelpers.NewManagedCluster()},
+			configMaps:      []runtime.Object{source},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "source configmap is not found",
+			queueKey:        testinghelpers.TestManagedClusterName,
+			clusters:        []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:     "source configmap has no CA bundle",
+			queueKey: testinghelpers.TestManagedClusterName,
+			clusters: []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			configMaps: []runtime.Object{&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: sourceNamespace, Name: sourceName},
+			}},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:       "publish CA bundle",
+			queueKey:   testinghelpers.TestManagedClusterName,
+			clusters:   []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			configMaps: []runtime.Object{source},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "create")
+				configMap := actions[1].(clienttesting.CreateAction).GetObject().(*corev1.ConfigMap)
+				if configMap.Namespace != testinghelpers.TestManagedClusterName || configMap.Name != helpers.HubCABundleConfigMapName {
+					t.Errorf("unexpected configmap %s/%s", configMap.Namespace, configMap.Name)
+				}
+				if configMap.Data[helpers.HubCABundleKey] != caBundle {
+					t.Errorf("unexpected CA bundle %q", configMap.Data[helpers.HubCABundleKey])
+				}
+			},
+		},
+		{
+			name:     "CA bundle is rotated",
+			queueKey: testinghelpers.TestManagedClusterName,
+			clusters: []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			configMaps: []runtime.Object{source, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: helpers.HubCABundleConfigMapName},
+				Data:       map[string]string{helpers.HubCABundleKey: "old-ca-bundle"},
+			}},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "update")
+				configMap := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.ConfigMap)
+				if configMap.Data[helpers.HubCABundleKey] != caBundle {
+					t.Errorf("unexpected CA bundle %q", configMap.Data[helpers.HubCABundleKey])
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.clusters...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+			for _, cluster := range c.clusters {
+				if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kubeClient := kubefake.NewSimpleClientset(c.configMaps...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+			for _, configMap := range c.configMaps {
+				if err := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(configMap); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient.ClearActions()
+
+			ctrl := &hubCABundleController{
+				kubeClient:      kubeClient,
+				clusterLister:   clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				configMapLister: kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+				sourceNamespace: sourceNamespace,
+				sourceName:      sourceName,
+				eventRecorder:   eventstesting.NewTestingEventRecorder(t),
+			}
+
+			syncCtx := testinghelpers.NewFakeSyncContext(t, c.queueKey)
+			syncErr := ctrl.sync(context.TODO(), syncCtx)
+			testinghelpers.AssertError(t, syncErr, "")
+
+			if actual := syncCtx.Queue().Len(); actual != c.expectedQueued {
+				t.Errorf("expected %d queued clusters, but got %d", c.expectedQueued, actual)
+			}
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
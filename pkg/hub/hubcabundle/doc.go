@@ -0,0 +1,3 @@
+// package hubcabundle contains the hub-side controller which publishes the CA bundle of the hub apiserver to the
+// cluster namespaces of the managed clusters.
+package hubcabundle
@@ -0,0 +1,4 @@
+// package importer renders the manifests deployed on a managed cluster to import it to the hub, i.e. the agent
+// namespace, the bootstrap kubeconfig secret, the RBAC of the agent and the agent deployment, so the provisioning
+// systems do not duplicate the manifest templates. The manifests are rendered from the spoke manifests in deploy/spoke.
+package importer
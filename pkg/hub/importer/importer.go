@@ -0,0 +1,309 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/yaml"
+
+	"open-cluster-management.io/registration/deploy"
+)
+
+const (
+	// DefaultAgentNamespace is the default namespace of the agent on the managed cluster
+	DefaultAgentNamespace = "open-cluster-management-agent"
+	// DefaultImage is the default image of the agent
+	DefaultImage = "quay.io/open-cluster-management/registration:latest"
+	// BootstrapSecretName is the name of the secret in the agent namespace holding the bootstrap kubeconfig, it is
+	// refreshed by the agent with the bootstrap kubeconfig published by the hub
+	BootstrapSecretName = "bootstrap-hub-kubeconfig"
+
+	agentContainerName         = "spoke-agent"
+	bootstrapSecretVolumeName  = "bootstrap-secret"
+	clusterNameArgPrefix       = "--cluster-name="
+	bootstrapSecretArgPrefix   = "--bootstrap-kubeconfig-secret="
+	bootstrapKubeconfigDataKey = "kubeconfig"
+)
+
+// manifests are the spoke manifests in the order they are applied, the bootstrap kubeconfig secret is rendered after
+// the service account. The objects in the agent namespace of the manifests are moved to the agent namespace of the
+// options, the objects in the other namespaces, e.g. kube-system and kube-public, are kept in their namespaces.
+var manifests = []string{
+	"spoke/0000_02_clusters.open-cluster-management.io_clusterclaims.crd.yaml",
+	"spoke/namespace.yaml",
+	"spoke/service_account.yaml",
+	"spoke/clusterrole.yaml",
+	"spoke/clusterrole_binding.yaml",
+	"spoke/clusterrole_addon-management.yaml",
+	"spoke/clusterrole_binding_addon-management.yaml",
+	"spoke/role.yaml",
+	"spoke/role_binding.yaml",
+	"spoke/role_extension-apiserver.yaml",
+	"spoke/role_binding_extension-apiserver.yaml",
+	"spoke/role_cluster-info.yaml",
+	"spoke/role_binding_cluster-info.yaml",
+	"spoke/deployment.yaml",
+}
+
+// Options are the options to render the import manifests of a managed cluster. The bootstrap kubeconfig is built
+// with the hub endpoint, the CA bundle and the bootstrap token unless it is specified.
+type Options struct {
+	// ClusterName is the name of the managed cluster
+	ClusterName string
+	// HubAPIServer is the URL of the hub kube-apiserver, and HubCABundle is its CA bundle
+	HubAPIServer string
+	HubCABundle  []byte
+	// BootstrapToken is the token the agent bootstraps with
+	BootstrapToken string
+	// BootstrapKubeconfig is the bootstrap kubeconfig of the agent
+	BootstrapKubeconfig []byte
+	// AgentNamespace is the namespace of the agent, DefaultAgentNamespace is used if it is empty
+	AgentNamespace string
+	// Image is the image of the agent, DefaultImage is used if it is empty
+	Image string
+}
+
+// Validate verifies the options.
+func (o Options) Validate() error {
+	if errs := validation.IsDNS1123Label(o.ClusterName); len(errs) > 0 {
+		return fmt.Errorf("cluster name %q is invalid: %s", o.ClusterName, strings.Join(errs, ", "))
+	}
+	if len(o.AgentNamespace) > 0 {
+		if errs := validation.IsDNS1123Label(o.AgentNamespace); len(errs) > 0 {
+			return fmt.Errorf("agent namespace %q is invalid: %s", o.AgentNamespace, strings.Join(errs, ", "))
+		}
+	}
+	if len(o.BootstrapKubeconfig) > 0 {
+		if _, err := clientcmd.Load(o.BootstrapKubeconfig); err != nil {
+			return fmt.Errorf("bootstrap kubeconfig is invalid: %v", err)
+		}
+		return nil
+	}
+	if len(o.HubAPIServer) == 0 {
+		return errors.New("hub api server is required without a bootstrap kubeconfig")
+	}
+	if len(o.BootstrapToken) == 0 {
+		return errors.New("bootstrap token is required without a bootstrap kubeconfig")
+	}
+	return nil
+}
+
+// Render returns the manifests to import the managed cluster in the order they are applied, i.e. the ClusterClaim
+// CRD, the agent namespace, the bootstrap kubeconfig secret, the RBAC of the agent and the agent deployment. The
+// manifests are rendered from the spoke manifests in deploy/spoke, so they do not drift from the agent deployment.
+func Render(o Options) ([][]byte, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	bootstrapKubeconfig := o.BootstrapKubeconfig
+	if len(bootstrapKubeconfig) == 0 {
+		var err error
+		bootstrapKubeconfig, err = clientcmd.Write(buildBootstrapKubeconfig(o.HubAPIServer, o.HubCABundle, o.BootstrapToken))
+		if err != nil {
+			return nil, fmt.Errorf("failed to build the bootstrap kubeconfig: %v", err)
+		}
+	}
+	agentNamespace := o.AgentNamespace
+	if len(agentNamespace) == 0 {
+		agentNamespace = DefaultAgentNamespace
+	}
+	image := o.Image
+	if len(image) == 0 {
+		image = DefaultImage
+	}
+
+	rendered := [][]byte{}
+	for _, name := range manifests {
+		raw, err := deploy.SpokeManifestFiles.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(raw, &obj.Object); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", name, err)
+		}
+		if err := customize(obj, o.ClusterName, agentNamespace, image); err != nil {
+			return nil, fmt.Errorf("failed to render %s: %v", name, err)
+		}
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %v", name, err)
+		}
+		rendered = append(rendered, data)
+
+		if obj.GetKind() == "ServiceAccount" {
+			secret, err := bootstrapSecret(agentNamespace, bootstrapKubeconfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render the bootstrap kubeconfig secret: %v", err)
+			}
+			rendered = append(rendered, secret)
+		}
+	}
+	return rendered, nil
+}
+
+// RenderYAML returns the manifests to import the managed cluster as a multi-document yaml
+func RenderYAML(o Options) ([]byte, error) {
+	rendered, err := Render(o)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	for _, manifest := range rendered {
+		buf.WriteString("---\n")
+		buf.Write(bytes.TrimSpace(manifest))
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func buildBootstrapKubeconfig(hubAPIServer string, caBundle []byte, token string) clientcmdapi.Config {
+	return clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{"hub": {
+			Server:                   hubAPIServer,
+			CertificateAuthorityData: caBundle,
+		}},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{"bootstrap": {
+			Token: token,
+		}},
+		Contexts: map[string]*clientcmdapi.Context{"bootstrap": {
+			Cluster:  "hub",
+			AuthInfo: "bootstrap",
+		}},
+		CurrentContext: "bootstrap",
+	}
+}
+
+// customize sets the cluster name, the agent namespace and the image of the agent on the spoke manifest
+func customize(obj *unstructured.Unstructured, clusterName, agentNamespace, image string) error {
+	switch obj.GetKind() {
+	case "CustomResourceDefinition", "ClusterRole":
+	case "Namespace":
+		obj.SetName(agentNamespace)
+	case "ClusterRoleBinding", "RoleBinding":
+		if err := setSubjectsNamespace(obj, agentNamespace); err != nil {
+			return err
+		}
+	case "Deployment":
+		if err := customizeDeployment(obj, clusterName, image); err != nil {
+			return err
+		}
+	}
+
+	switch obj.GetKind() {
+	case "ServiceAccount", "Role", "RoleBinding", "Deployment":
+		// kustomize sets the agent namespace on the manifests without a namespace
+		if ns := obj.GetNamespace(); len(ns) == 0 || ns == DefaultAgentNamespace {
+			obj.SetNamespace(agentNamespace)
+		}
+	}
+	return nil
+}
+
+// setSubjectsNamespace moves the service account subjects of the binding to the agent namespace
+func setSubjectsNamespace(obj *unstructured.Unstructured, agentNamespace string) error {
+	subjects, _, err := unstructured.NestedSlice(obj.Object, "subjects")
+	if err != nil {
+		return err
+	}
+	for _, subject := range subjects {
+		s, ok := subject.(map[string]interface{})
+		if !ok || s["kind"] != "ServiceAccount" {
+			continue
+		}
+		s["namespace"] = agentNamespace
+	}
+	return unstructured.SetNestedSlice(obj.Object, subjects, "subjects")
+}
+
+// customizeDeployment sets the image, the cluster name and the bootstrap kubeconfig secret of the agent
+func customizeDeployment(obj *unstructured.Unstructured, clusterName, image string) error {
+	podSpec, _, err := unstructured.NestedMap(obj.Object, "spec", "template", "spec")
+	if err != nil {
+		return err
+	}
+
+	containers, _, err := unstructured.NestedSlice(podSpec, "containers")
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok || container["name"] != agentContainerName {
+			continue
+		}
+		found = true
+		container["image"] = image
+
+		args, _, err := unstructured.NestedStringSlice(container, "args")
+		if err != nil {
+			return err
+		}
+		newArgs := []interface{}{}
+		for _, arg := range args {
+			switch {
+			case strings.HasPrefix(arg, clusterNameArgPrefix):
+				arg = clusterNameArgPrefix + clusterName
+			case strings.HasPrefix(arg, bootstrapSecretArgPrefix):
+				continue
+			}
+			newArgs = append(newArgs, arg)
+		}
+		container["args"] = append(newArgs, bootstrapSecretArgPrefix+BootstrapSecretName)
+	}
+	if !found {
+		return fmt.Errorf("container %q is not found", agentContainerName)
+	}
+	if err := unstructured.SetNestedSlice(podSpec, containers, "containers"); err != nil {
+		return err
+	}
+
+	volumes, _, err := unstructured.NestedSlice(podSpec, "volumes")
+	if err != nil {
+		return err
+	}
+	for _, v := range volumes {
+		volume, ok := v.(map[string]interface{})
+		if !ok || volume["name"] != bootstrapSecretVolumeName {
+			continue
+		}
+		if err := unstructured.SetNestedField(volume, BootstrapSecretName, "secret", "secretName"); err != nil {
+			return err
+		}
+	}
+	if err := unstructured.SetNestedSlice(podSpec, volumes, "volumes"); err != nil {
+		return err
+	}
+	return unstructured.SetNestedMap(obj.Object, podSpec, "spec", "template", "spec")
+}
+
+// bootstrapSecret returns the secret holding the bootstrap kubeconfig the agent registers the managed cluster with
+func bootstrapSecret(agentNamespace string, bootstrapKubeconfig []byte) ([]byte, error) {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BootstrapSecretName,
+			Namespace: agentNamespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{bootstrapKubeconfigDataKey: bootstrapKubeconfig},
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(secret)
+	if err != nil {
+		return nil, err
+	}
+	// drop the empty creationTimestamp
+	unstructured.RemoveNestedField(obj, "metadata", "creationTimestamp")
+	return yaml.Marshal(obj)
+}
@@ -0,0 +1,187 @@
+package importer
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name        string
+		options     Options
+		expectedErr string
+	}{
+		{
+			name:    "bootstrap token",
+			options: Options{ClusterName: "cluster1", HubAPIServer: "https://hub:6443", BootstrapToken: "token"},
+		},
+		{
+			name: "bootstrap kubeconfig",
+			options: Options{
+				ClusterName:         "cluster1",
+				BootstrapKubeconfig: newBootstrapKubeconfig(t, "https://hub:6443", "token"),
+			},
+		},
+		{
+			name:        "invalid cluster name",
+			options:     Options{ClusterName: "Cluster1", HubAPIServer: "https://hub:6443", BootstrapToken: "token"},
+			expectedErr: "cluster name \"Cluster1\" is invalid: a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')",
+		},
+		{
+			name: "invalid agent namespace",
+			options: Options{
+				ClusterName: "cluster1", HubAPIServer: "https://hub:6443", BootstrapToken: "token", AgentNamespace: "agent_ns",
+			},
+			expectedErr: "agent namespace \"agent_ns\" is invalid: a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')",
+		},
+		{
+			name:        "invalid bootstrap kubeconfig",
+			options:     Options{ClusterName: "cluster1", BootstrapKubeconfig: []byte("invalid")},
+			expectedErr: "bootstrap kubeconfig is invalid: couldn't get version/kind; json parse error: json: cannot unmarshal string into Go value of type struct { APIVersion string \"json:\\\"apiVersion,omitempty\\\"\"; Kind string \"json:\\\"kind,omitempty\\\"\" }",
+		},
+		{
+			name:        "no hub api server",
+			options:     Options{ClusterName: "cluster1", BootstrapToken: "token"},
+			expectedErr: "hub api server is required without a bootstrap kubeconfig",
+		},
+		{
+			name:        "no bootstrap token",
+			options:     Options{ClusterName: "cluster1", HubAPIServer: "https://hub:6443"},
+			expectedErr: "bootstrap token is required without a bootstrap kubeconfig",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testinghelpers.AssertError(t, c.options.Validate(), c.expectedErr)
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	rendered, err := Render(Options{
+		ClusterName:    "cluster1",
+		HubAPIServer:   "https://hub:6443",
+		HubCABundle:    []byte("ca"),
+		BootstrapToken: "token",
+		AgentNamespace: "agent",
+		Image:          "registration:test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedKinds := []string{
+		"CustomResourceDefinition", "Namespace", "ServiceAccount", "Secret", "ClusterRole", "ClusterRoleBinding",
+		"ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding", "Role", "RoleBinding", "Role", "RoleBinding",
+		"Deployment",
+	}
+	if len(rendered) != len(expectedKinds) {
+		t.Fatalf("expected %d manifests, but got %d", len(expectedKinds), len(rendered))
+	}
+	for i, manifest := range rendered {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(manifest, &obj.Object); err != nil {
+			t.Fatalf("failed to parse manifest %d: %v", i, err)
+		}
+		if obj.GetKind() != expectedKinds[i] {
+			t.Errorf("expected manifest %d is a %s, but got %s", i, expectedKinds[i], obj.GetKind())
+		}
+		switch obj.GetKind() {
+		case "Namespace":
+			if obj.GetName() != "agent" {
+				t.Errorf("expected namespace agent, but got %s", obj.GetName())
+			}
+		case "ServiceAccount", "Secret", "Role", "RoleBinding", "Deployment":
+			// the roles to read the extension apiserver and cluster info configmaps are kept in their namespaces
+			if ns := obj.GetNamespace(); ns != "agent" && ns != "kube-system" && ns != "kube-public" {
+				t.Errorf("expected %s in namespace agent, but got %s", obj.GetKind(), ns)
+			}
+		}
+		if obj.GetKind() == "ClusterRoleBinding" || obj.GetKind() == "RoleBinding" {
+			subjects, _, _ := unstructured.NestedSlice(obj.Object, "subjects")
+			for _, subject := range subjects {
+				if ns := subject.(map[string]interface{})["namespace"]; ns != "agent" {
+					t.Errorf("expected the subject of %s %s in namespace agent, but got %v", obj.GetKind(), obj.GetName(), ns)
+				}
+			}
+		}
+	}
+
+	secret := &corev1.Secret{}
+	if err := yaml.Unmarshal(rendered[3], secret); err != nil {
+		t.Fatal(err)
+	}
+	kubeconfig, err := clientcmd.Load(secret.Data["kubeconfig"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cluster := kubeconfig.Clusters["hub"]; cluster == nil || cluster.Server != "https://hub:6443" || string(cluster.CertificateAuthorityData) != "ca" {
+		t.Errorf("unexpected hub cluster of the bootstrap kubeconfig: %v", cluster)
+	}
+	if authInfo := kubeconfig.AuthInfos["bootstrap"]; authInfo == nil || authInfo.Token != "token" {
+		t.Errorf("unexpected auth info of the bootstrap kubeconfig: %v", authInfo)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := yaml.Unmarshal(rendered[len(rendered)-1], deployment); err != nil {
+		t.Fatal(err)
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if container.Image != "registration:test" {
+		t.Errorf("expected image registration:test, but got %s", container.Image)
+	}
+	if !hasArg(container.Args, "--cluster-name=cluster1") {
+		t.Errorf("expected the cluster name in the args, but got %v", container.Args)
+	}
+	if !hasArg(container.Args, "--bootstrap-kubeconfig-secret="+BootstrapSecretName) {
+		t.Errorf("expected the bootstrap kubeconfig secret in the args, but got %v", container.Args)
+	}
+	if secretName := deployment.Spec.Template.Spec.Volumes[0].Secret.SecretName; secretName != BootstrapSecretName {
+		t.Errorf("expected the bootstrap kubeconfig secret is mounted, but got %s", secretName)
+	}
+}
+
+func TestRenderDefaults(t *testing.T) {
+	rendered, err := Render(Options{
+		ClusterName:         "cluster1",
+		BootstrapKubeconfig: newBootstrapKubeconfig(t, "https://hub:6443", "token"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := yaml.Unmarshal(rendered[len(rendered)-1], deployment); err != nil {
+		t.Fatal(err)
+	}
+	if deployment.Namespace != DefaultAgentNamespace {
+		t.Errorf("expected namespace %s, but got %s", DefaultAgentNamespace, deployment.Namespace)
+	}
+	if image := deployment.Spec.Template.Spec.Containers[0].Image; image != DefaultImage {
+		t.Errorf("expected image %s, but got %s", DefaultImage, image)
+	}
+}
+
+func hasArg(args []string, arg string) bool {
+	for _, a := range args {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+func newBootstrapKubeconfig(t *testing.T, server, token string) []byte {
+	data, err := clientcmd.Write(buildBootstrapKubeconfig(server, nil, token))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
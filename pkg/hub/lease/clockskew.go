@@ -0,0 +1,139 @@
+package lease
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// ManagedClusterConditionClockOutOfSync is the condition type set on a managed cluster whose clock is skewed from
+// the clock of the hub beyond the tolerance.
+const ManagedClusterConditionClockOutOfSync = "ClockOutOfSync"
+
+// clusterClockSkew is the skew of the clock of each managed cluster measured by the lease controller
+var clusterClockSkew = metrics.NewGaugeVec(
+	&metrics.GaugeOpts{
+		Subsystem:      "registration",
+		Name:           "managed_cluster_clock_skew_seconds",
+		Help:           "The hub receipt time minus the renew time of the last lease renewal of the managed cluster.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"cluster"},
+)
+
+func init() {
+	legacyregistry.MustRegister(clusterClockSkew)
+}
+
+// renewalObservation is the last renewal of the lease of a managed cluster observed by the hub
+type renewalObservation struct {
+	renewTime  time.Time
+	observedAt time.Time
+	// skew is the observedAt minus the renewTime, it is nil until a renewal is observed while the hub is running,
+	// since the lease found at the start of the hub is not received at the observedAt
+	skew *time.Duration
+}
+
+// observeRenewal records the hub time when the renewal of the lease is observed, and returns the renew time the
+// lease is evaluated with and the measured skew. The renew time is set by the agent with the clock of the managed
+// cluster, once the skew is beyond the tolerance, the hub receipt time of the renewal is used instead.
+func (c *leaseController) observeRenewal(clusterName string, renewTime, now time.Time) (time.Time, *time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	observation, ok := c.observations[clusterName]
+	switch {
+	case !ok:
+		observation = &renewalObservation{renewTime: renewTime, observedAt: now}
+		// a renew time in the future beyond the tolerance cannot be explained by the latency
+		if c.clockSkewTolerance > 0 && renewTime.After(now.Add(c.clockSkewTolerance)) {
+			skew := now.Sub(renewTime)
+			observation.skew = &skew
+		}
+		c.observations[clusterName] = observation
+	case !observation.renewTime.Equal(renewTime):
+		skew := now.Sub(renewTime)
+		observation.renewTime = renewTime
+		observation.observedAt = now
+		observation.skew = &skew
+	}
+
+	if observation.skew == nil {
+		return renewTime, nil
+	}
+	clusterClockSkew.WithLabelValues(clusterName).Set(observation.skew.Seconds())
+	if c.clockSkewTolerance > 0 && absDuration(*observation.skew) > c.clockSkewTolerance {
+		return observation.observedAt, observation.skew
+	}
+	return renewTime, observation.skew
+}
+
+// forgetRenewal removes the observation of the deleted cluster
+func (c *leaseController) forgetRenewal(clusterName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.observations, clusterName)
+	clusterClockSkew.DeleteLabelValues(clusterName)
+}
+
+// updateClockOutOfSyncCondition reports the cluster whose clock skew is beyond the tolerance with the
+// ClockOutOfSync condition. A cluster whose clock has never been out of sync does not get the condition.
+func (c *leaseController) updateClockOutOfSyncCondition(
+	ctx context.Context, cluster *clusterv1.ManagedCluster, skew *time.Duration) error {
+	if c.clockSkewTolerance <= 0 || skew == nil {
+		return nil
+	}
+
+	outOfSync := absDuration(*skew) > c.clockSkewTolerance
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, ManagedClusterConditionClockOutOfSync)
+	if cond == nil && !outOfSync {
+		return nil
+	}
+
+	clockCondition := metav1.Condition{
+		Type:    ManagedClusterConditionClockOutOfSync,
+		Status:  metav1.ConditionFalse,
+		Reason:  "ManagedClusterClockInSync",
+		Message: "The clock of the managed cluster is in sync with the hub.",
+	}
+	if outOfSync {
+		// the renew times set by a cluster whose clock is ahead of the hub are later than their receipt
+		direction := "behind"
+		if *skew < 0 {
+			direction = "ahead of"
+		}
+		clockCondition.Status = metav1.ConditionTrue
+		clockCondition.Reason = "ManagedClusterClockSkewed"
+		clockCondition.Message = fmt.Sprintf(
+			"The clock of the managed cluster is about %s %s the hub, beyond the tolerance %s. "+
+				"The lease is evaluated with the time the hub receives its renewals.",
+			absDuration(*skew).Round(time.Second), direction, c.clockSkewTolerance)
+	}
+	// the skew of an out of sync clock changes slightly with each renewal, the message is not updated for it
+	if cond != nil && cond.Status == clockCondition.Status {
+		return nil
+	}
+
+	_, updated, err := helpers.UpdateManagedClusterStatus(
+		ctx, c.clusterClient, cluster.Name, helpers.UpdateManagedClusterConditionFn(clockCondition))
+	if updated && outOfSync {
+		c.eventRecorder.Warningf("ManagedClusterClockOutOfSync", "managed cluster %q: %s", cluster.Name, clockCondition.Message)
+	}
+	return err
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,197 @@
+package lease
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestObserveRenewal(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name              string
+		observation       *renewalObservation
+		renewTime         time.Time
+		expectedRenewTime time.Time
+		expectedSkew      *time.Duration
+	}{
+		{
+			name:              "lease found at the start",
+			renewTime:         now.Add(-10 * time.Minute),
+			expectedRenewTime: now.Add(-10 * time.Minute),
+		},
+		{
+			name:              "lease renewed in the future at the start",
+			renewTime:         now.Add(10 * time.Minute),
+			expectedRenewTime: now,
+			expectedSkew:      durationPtr(-10 * time.Minute),
+		},
+		{
+			name:              "renewal is not changed",
+			observation:       &renewalObservation{renewTime: now.Add(-time.Minute), observedAt: now.Add(-time.Minute)},
+			renewTime:         now.Add(-time.Minute),
+			expectedRenewTime: now.Add(-time.Minute),
+		},
+		{
+			name:              "renewal within the tolerance",
+			observation:       &renewalObservation{renewTime: now.Add(-time.Minute), observedAt: now.Add(-time.Minute)},
+			renewTime:         now.Add(-time.Second),
+			expectedRenewTime: now.Add(-time.Second),
+			expectedSkew:      durationPtr(time.Second),
+		},
+		{
+			name:              "renewal of a slow clock",
+			observation:       &renewalObservation{renewTime: now.Add(-time.Hour), observedAt: now.Add(-time.Minute)},
+			renewTime:         now.Add(-59 * time.Minute),
+			expectedRenewTime: now,
+			expectedSkew:      durationPtr(59 * time.Minute),
+		},
+		{
+			name: "skewed renewal is not changed",
+			observation: &renewalObservation{
+				renewTime:  now.Add(-59 * time.Minute),
+				observedAt: now.Add(-30 * time.Second),
+				skew:       durationPtr(59 * time.Minute),
+			},
+			renewTime:         now.Add(-59 * time.Minute),
+			expectedRenewTime: now.Add(-30 * time.Second),
+			expectedSkew:      durationPtr(59 * time.Minute),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := &leaseController{
+				clockSkewTolerance: 30 * time.Second,
+				observations:       map[string]*renewalObservation{},
+			}
+			if c.observation != nil {
+				ctrl.observations[testinghelpers.TestManagedClusterName] = c.observation
+			}
+
+			renewTime, skew := ctrl.observeRenewal(testinghelpers.TestManagedClusterName, c.renewTime, now)
+			if !renewTime.Equal(c.expectedRenewTime) {
+				t.Errorf("expected renew time %v, but got %v", c.expectedRenewTime, renewTime)
+			}
+			switch {
+			case c.expectedSkew == nil && skew != nil:
+				t.Errorf("expected no skew, but got %s", *skew)
+			case c.expectedSkew != nil && (skew == nil || *skew != *c.expectedSkew):
+				t.Errorf("expected skew %s, but got %v", *c.expectedSkew, skew)
+			}
+		})
+	}
+}
+
+func TestClockSkewMetric(t *testing.T) {
+	now := time.Now()
+	ctrl := &leaseController{
+		clockSkewTolerance: 30 * time.Second,
+		observations: map[string]*renewalObservation{
+			"cluster1": {renewTime: now.Add(-time.Hour), observedAt: now.Add(-time.Minute)},
+		},
+	}
+	ctrl.observeRenewal("cluster1", now.Add(-2*time.Minute), now)
+
+	if value, err := testutil.GetGaugeMetricValue(clusterClockSkew.WithLabelValues("cluster1")); err != nil || value != 120 {
+		t.Errorf("expected clock skew 120 seconds, but got %v, %v", value, err)
+	}
+
+	ctrl.forgetRenewal("cluster1")
+	if _, ok := ctrl.observations["cluster1"]; ok {
+		t.Errorf("expected the observation of the deleted cluster is removed")
+	}
+}
+
+func TestUpdateClockOutOfSyncCondition(t *testing.T) {
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		skew            *time.Duration
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "skew is not measured",
+			cluster:         testinghelpers.NewAvailableManagedCluster(),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "clock in sync",
+			cluster:         testinghelpers.NewAvailableManagedCluster(),
+			skew:            durationPtr(time.Second),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:    "clock out of sync",
+			cluster: testinghelpers.NewAvailableManagedCluster(),
+			skew:    durationPtr(-10 * time.Minute),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertClockCondition(t, actions, metav1.Condition{
+					Type:   ManagedClusterConditionClockOutOfSync,
+					Status: metav1.ConditionTrue,
+					Reason: "ManagedClusterClockSkewed",
+					Message: "The clock of the managed cluster is about 10m0s ahead of the hub, beyond the tolerance " +
+						"30s. The lease is evaluated with the time the hub receives its renewals.",
+				})
+			},
+		},
+		{
+			name:            "clock is still out of sync",
+			cluster:         newClockOutOfSyncManagedCluster(),
+			skew:            durationPtr(-9 * time.Minute),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:    "clock is back in sync",
+			cluster: newClockOutOfSyncManagedCluster(),
+			skew:    durationPtr(time.Second),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertClockCondition(t, actions, metav1.Condition{
+					Type:    ManagedClusterConditionClockOutOfSync,
+					Status:  metav1.ConditionFalse,
+					Reason:  "ManagedClusterClockInSync",
+					Message: "The clock of the managed cluster is in sync with the hub.",
+				})
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			ctrl := &leaseController{
+				clusterClient:      clusterClient,
+				eventRecorder:      eventstesting.NewTestingEventRecorder(t),
+				clockSkewTolerance: 30 * time.Second,
+			}
+			testinghelpers.AssertError(t, ctrl.updateClockOutOfSyncCondition(context.TODO(), c.cluster, c.skew), "")
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func newClockOutOfSyncManagedCluster() *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAvailableManagedCluster()
+	cluster.Status.Conditions = append(cluster.Status.Conditions, testinghelpers.NewManagedClusterCondition(
+		ManagedClusterConditionClockOutOfSync, "True", "ManagedClusterClockSkewed", "", nil))
+	return cluster
+}
+
+func assertClockCondition(t *testing.T, actions []clienttesting.Action, expected metav1.Condition) {
+	testinghelpers.AssertActions(t, actions, "get", "patch")
+	testinghelpers.AssertConditionPatched(t, actions[1], expected)
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -2,6 +2,8 @@ package lease
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
@@ -9,6 +11,7 @@ import (
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/tracing"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -17,10 +20,13 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	coordinformers "k8s.io/client-go/informers/coordination/v1"
 	"k8s.io/client-go/kubernetes"
 	coordlisters "k8s.io/client-go/listers/coordination/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
 	"k8s.io/utils/pointer"
 )
 
@@ -33,59 +39,131 @@ var (
 )
 
 // leaseController checks the lease of managed clusters on hub cluster to determine whether a managed cluster is available.
+// The clusters are synced on the changes of their leases, and requeued at the time their leases reach the next
+// threshold, so the availability is changed right after the grace period of the lease expires. The resyncs of the
+// informers are ignored, so the clusters are not rescanned periodically.
 type leaseController struct {
 	kubeClient    kubernetes.Interface
 	clusterClient clientset.Interface
 	clusterLister clusterv1listers.ManagedClusterLister
 	leaseLister   coordlisters.LeaseLister
+	queue         workqueue.RateLimitingInterface
 	eventRecorder events.Recorder
+	// clock is the clock the leases are checked with, it is faked in the tests of the grace periods
+	clock clock.Clock
+
+	// clockSkewTolerance is the tolerated skew of the clocks of the managed clusters, the skew is not checked if it
+	// is 0
+	clockSkewTolerance time.Duration
+	lock               sync.Mutex
+	observations       map[string]*renewalObservation
 }
 
-// NewClusterLeaseController creates a cluster lease controller on hub cluster.
+// NewClusterLeaseController creates a cluster lease controller on hub cluster. The leases are checked against the
+// time of the clock.
 func NewClusterLeaseController(
 	kubeClient kubernetes.Interface,
 	clusterClient clientset.Interface,
 	clusterInformer clusterv1informer.ManagedClusterInformer,
 	leaseInformer coordinformers.LeaseInformer,
+	clockSkewTolerance time.Duration,
+	clock clock.Clock,
 	recorder events.Recorder) factory.Controller {
+	syncCtx := factory.NewSyncContext("ManagedClusterLeaseController", recorder)
 	c := &leaseController{
-		kubeClient:    kubeClient,
-		clusterClient: clusterClient,
-		clusterLister: clusterInformer.Lister(),
-		leaseLister:   leaseInformer.Lister(),
-		eventRecorder: recorder.WithComponentSuffix("managed-cluster-lease-controller"),
+		kubeClient:         kubeClient,
+		clusterClient:      clusterClient,
+		clusterLister:      clusterInformer.Lister(),
+		leaseLister:        leaseInformer.Lister(),
+		queue:              syncCtx.Queue(),
+		eventRecorder:      recorder.WithComponentSuffix("managed-cluster-lease-controller"),
+		clock:              clock,
+		clockSkewTolerance: clockSkewTolerance,
+		observations:       map[string]*renewalObservation{},
+	}
+
+	_, err := leaseInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isManagedClusterLease,
+		Handler: c.enqueueHandler(func(obj metav1.Object) string {
+			return obj.GetLabels()[clusterv1.ClusterNameLabelKey]
+		}),
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+	_, err = clusterInformer.Informer().AddEventHandler(c.enqueueHandler(func(obj metav1.Object) string {
+		return obj.GetName()
+	}))
+	if err != nil {
+		utilruntime.HandleError(err)
 	}
+
 	return factory.New().
-		WithFilteredEventsInformersQueueKeyFunc(
-			func(obj runtime.Object) string {
-				accessor, _ := meta.Accessor(obj)
-				return accessor.GetLabels()[clusterv1.ClusterNameLabelKey]
-			},
-			func(obj interface{}) bool {
-				metaObj, ok := obj.(metav1.ObjectMetaAccessor)
-				if !ok {
-					return false
-				}
-
-				// only handle the managed cluster lease
-				// TODO instead of this by adding label filter in the SharedInformerFactory
-				// see https://github.com/open-cluster-management-io/registration/issues/225
-				if _, ok := metaObj.GetObjectMeta().GetLabels()[clusterv1.ClusterNameLabelKey]; !ok {
-					return false
-				}
-
-				return metaObj.GetObjectMeta().GetName() == leaseName
-			},
-			leaseInformer.Informer(),
-		).
-		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
-			accessor, _ := meta.Accessor(obj)
-			return accessor.GetName()
-		}, clusterInformer.Informer()).
-		WithSync(c.sync).
+		WithSyncContext(syncCtx).
+		WithBareInformers(leaseInformer.Informer(), clusterInformer.Informer()).
+		WithSync(tracing.TraceSync("ManagedClusterLeaseController", c.sync)).
 		ToController("ManagedClusterLeaseController", recorder)
 }
 
+// isManagedClusterLease returns true if the object is the lease of a managed cluster
+func isManagedClusterLease(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	metaObj, ok := obj.(metav1.ObjectMetaAccessor)
+	if !ok {
+		return false
+	}
+
+	// only handle the managed cluster lease
+	// TODO instead of this by adding label filter in the SharedInformerFactory
+	// see https://github.com/open-cluster-management-io/registration/issues/225
+	if _, ok := metaObj.GetObjectMeta().GetLabels()[clusterv1.ClusterNameLabelKey]; !ok {
+		return false
+	}
+
+	return metaObj.GetObjectMeta().GetName() == leaseName
+}
+
+// enqueueHandler enqueues the cluster of the changed objects. The updates of the resyncs are ignored, since the
+// clusters are requeued at the time their leases reach the next threshold.
+func (c *leaseController) enqueueHandler(keyFunc func(obj metav1.Object) string) cache.ResourceEventHandler {
+	enqueue := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			utilruntime.HandleError(fmt.Errorf("error to get the accessor of %T: %v", obj, err))
+			return
+		}
+		if key := keyFunc(accessor); len(key) > 0 {
+			c.queue.Add(key)
+		}
+	}
+
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueue,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldAccessor, err := meta.Accessor(oldObj)
+			if err != nil {
+				utilruntime.HandleError(fmt.Errorf("error to get the accessor of %T: %v", oldObj, err))
+				return
+			}
+			newAccessor, err := meta.Accessor(newObj)
+			if err != nil {
+				utilruntime.HandleError(fmt.Errorf("error to get the accessor of %T: %v", newObj, err))
+				return
+			}
+			if oldAccessor.GetResourceVersion() == newAccessor.GetResourceVersion() {
+				return
+			}
+			enqueue(newObj)
+		},
+		DeleteFunc: enqueue,
+	}
+}
+
 // sync checks the lease of each accepted cluster on hub to determine whether a managed cluster is available.
 func (c *leaseController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
 	clusterName := syncCtx.QueueKey()
@@ -93,12 +171,15 @@ func (c *leaseController) sync(ctx context.Context, syncCtx factory.SyncContext)
 	cluster, err := c.clusterLister.Get(clusterName)
 	if errors.IsNotFound(err) {
 		// the cluster is not found, do nothing
+		c.forgetRenewal(clusterName)
 		return nil
 	}
 	if err != nil {
 		return err
 	}
 
+	tracing.SetCluster(ctx, cluster.Name, cluster.CreationTimestamp)
+
 	if !meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionHubAccepted) {
 		// cluster is not accepted, skip it.
 		return nil
@@ -120,35 +201,101 @@ func (c *leaseController) sync(ctx context.Context, syncCtx factory.SyncContext)
 			},
 			Spec: coordv1.LeaseSpec{
 				HolderIdentity: pointer.StringPtr(leaseName),
-				RenewTime:      &metav1.MicroTime{Time: time.Now()},
+				RenewTime:      &metav1.MicroTime{Time: c.clock.Now()},
 			},
 		}
-		_, err := c.kubeClient.CoordinationV1().Leases(cluster.Name).Create(ctx, lease, metav1.CreateOptions{})
-		return err
+		_, err = c.kubeClient.CoordinationV1().Leases(cluster.Name).Create(ctx, lease, metav1.CreateOptions{})
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		// the lease is not watched if it is not selected by the lease label selector, check the live one instead
+		observedLease, err = c.kubeClient.CoordinationV1().Leases(cluster.Name).Get(ctx, leaseName, metav1.GetOptions{})
 	}
 	if err != nil {
 		return err
 	}
 
-	gracePeriod := time.Duration(leaseDurationTimes*cluster.Spec.LeaseDurationSeconds) * time.Second
-	if gracePeriod == 0 {
+	leaseDuration := time.Duration(cluster.Spec.LeaseDurationSeconds) * time.Second
+	if leaseDuration == 0 {
 		// FIX: #183 avoid gracePeriod is zero, will non-stop update ManagedClusterLeaseUpdateStopped condition.
-		gracePeriod = time.Duration(leaseDurationTimes*LeaseDurationSeconds) * time.Second
+		leaseDuration = time.Duration(LeaseDurationSeconds) * time.Second
 	}
 
-	now := time.Now()
-	if !now.Before(observedLease.Spec.RenewTime.Add(gracePeriod)) {
-		// the lease is not updated constantly, change the cluster available condition to unknown
-		if err := c.updateClusterStatus(ctx, cluster); err != nil {
+	thresholds := getLeaseThresholds(cluster)
+	gracePeriod := getLeaseGracePeriod(cluster, time.Duration(thresholds.unknown)*leaseDuration)
+	degradedPeriod := time.Duration(thresholds.degraded) * leaseDuration
+	if degradedPeriod >= gracePeriod {
+		// the cluster reaches the grace period before it is degraded
+		thresholds.degraded = 0
+	}
+
+	now := c.clock.Now()
+	renewTime, skew := c.observeRenewal(clusterName, observedLease.Spec.RenewTime.Time, now)
+	if err := c.updateClockOutOfSyncCondition(ctx, cluster, skew); err != nil {
+		return err
+	}
+	switch {
+	case !now.Before(renewTime.Add(gracePeriod)):
+		// the lease is not updated constantly, change the cluster available condition to unknown. The cluster is
+		// synced again once its lease is renewed.
+		return c.updateClusterStatus(ctx, cluster)
+	case thresholds.degraded > 0 && !now.Before(renewTime.Add(degradedPeriod)):
+		// the lease missed some renewals, mark the cluster lease as degraded
+		if err := c.updateLeaseDegradedCondition(ctx, cluster, metav1.ConditionTrue); err != nil {
 			return err
 		}
+		// check the lease again once it reaches the unknown threshold
+		syncCtx.Queue().AddAfter(clusterName, renewTime.Add(gracePeriod).Sub(now))
+		return nil
+	default:
+		if err := c.updateLeaseDegradedCondition(ctx, cluster, metav1.ConditionFalse); err != nil {
+			return err
+		}
+		if thresholds.degraded > 0 {
+			// check the lease again once it reaches the degraded threshold
+			syncCtx.Queue().AddAfter(clusterName, renewTime.Add(degradedPeriod).Sub(now))
+			return nil
+		}
 	}
 
-	// always requeue this cluster to check its lease constantly
-	syncCtx.Queue().AddAfter(clusterName, gracePeriod)
+	// check the lease again once it reaches the unknown threshold
+	syncCtx.Queue().AddAfter(clusterName, renewTime.Add(gracePeriod).Sub(now))
 	return nil
 }
 
+// updateLeaseDegradedCondition sets the lease degraded condition of the cluster to the given status. A cluster
+// that has never been degraded does not get the condition, so the status of healthy clusters is not changed.
+func (c *leaseController) updateLeaseDegradedCondition(
+	ctx context.Context, cluster *clusterv1.ManagedCluster, status metav1.ConditionStatus) error {
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, ManagedClusterConditionLeaseDegraded)
+	if cond == nil && status == metav1.ConditionFalse {
+		return nil
+	}
+	if cond != nil && cond.Status == status {
+		return nil
+	}
+
+	degradedCondition := metav1.Condition{
+		Type:    ManagedClusterConditionLeaseDegraded,
+		Status:  status,
+		Reason:  "ManagedClusterLeaseRenewed",
+		Message: "Registration agent is updating its lease.",
+	}
+	if status == metav1.ConditionTrue {
+		degradedCondition.Reason = "ManagedClusterLeaseRenewalMissed"
+		degradedCondition.Message = "Registration agent missed some renewals of its lease."
+	}
+
+	_, updated, err := helpers.UpdateManagedClusterStatus(
+		ctx, c.clusterClient, cluster.Name, helpers.UpdateManagedClusterConditionFn(degradedCondition))
+	if updated {
+		c.eventRecorder.Eventf("ManagedClusterLeaseDegradedConditionUpdated",
+			"update managed cluster %q lease degraded condition to %s", cluster.Name, status)
+	}
+
+	return err
+}
+
 func (c *leaseController) updateClusterStatus(ctx context.Context, cluster *clusterv1.ManagedCluster) error {
 	if meta.IsStatusConditionPresentAndEqual(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable, metav1.ConditionUnknown) {
 		// the managed cluster available condition alreay is unknown, do nothing
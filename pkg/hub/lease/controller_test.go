@@ -13,20 +13,24 @@ import (
 	v1 "open-cluster-management.io/api/cluster/v1"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubeinformers "k8s.io/client-go/informers"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
 )
 
 var now = time.Now()
 
 func TestSync(t *testing.T) {
 	cases := []struct {
-		name            string
-		clusters        []runtime.Object
-		clusterLeases   []runtime.Object
+		name          string
+		clusters      []runtime.Object
+		clusterLeases []runtime.Object
+		// unwatchedLeases are not in the cache, e.g. they are not selected by the lease label selector
+		unwatchedLeases []runtime.Object
 		validateActions func(t *testing.T, leaseActions, clusterActions []clienttesting.Action)
 	}{
 		{
@@ -62,13 +66,23 @@ func TestSync(t *testing.T) {
 					Message: "Registration agent stopped updating its lease.",
 				}
 				testinghelpers.AssertActions(t, clusterActions, "get", "patch")
-				patch := clusterActions[1].(clienttesting.PatchAction).GetPatch()
-				managedCluster := &v1.ManagedCluster{}
-				err := json.Unmarshal(patch, managedCluster)
-				if err != nil {
-					t.Fatal(err)
+				testinghelpers.AssertConditionPatched(t, clusterActions[1], expected)
+			},
+		},
+		{
+			name:            "managed cluster lease is not watched",
+			clusters:        []runtime.Object{testinghelpers.NewAvailableManagedCluster()},
+			unwatchedLeases: []runtime.Object{testinghelpers.NewManagedClusterLease("managed-cluster-lease", now.Add(-5*time.Minute))},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				expected := metav1.Condition{
+					Type:    clusterv1.ManagedClusterConditionAvailable,
+					Status:  metav1.ConditionUnknown,
+					Reason:  "ManagedClusterLeaseUpdateStopped",
+					Message: "Registration agent stopped updating its lease.",
 				}
-				testinghelpers.AssertCondition(t, managedCluster.Status.Conditions, expected)
+				testinghelpers.AssertActions(t, leaseActions, "create", "get")
+				testinghelpers.AssertActions(t, clusterActions, "get", "patch")
+				testinghelpers.AssertConditionPatched(t, clusterActions[1], expected)
 			},
 		},
 		{
@@ -90,13 +104,70 @@ func TestSync(t *testing.T) {
 					Message: "Registration agent stopped updating its lease.",
 				}
 				testinghelpers.AssertActions(t, clusterActions, "get", "patch")
+				testinghelpers.AssertConditionPatched(t, clusterActions[1], expected)
+			},
+		},
+		{
+			name:          "managed cluster lease missed some renewals",
+			clusters:      []runtime.Object{testinghelpers.NewAvailableManagedCluster()},
+			clusterLeases: []runtime.Object{testinghelpers.NewManagedClusterLease("managed-cluster-lease", now.Add(-3500*time.Millisecond))},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				expected := metav1.Condition{
+					Type:    ManagedClusterConditionLeaseDegraded,
+					Status:  metav1.ConditionTrue,
+					Reason:  "ManagedClusterLeaseRenewalMissed",
+					Message: "Registration agent missed some renewals of its lease.",
+				}
+				testinghelpers.AssertActions(t, clusterActions, "get", "patch")
+				testinghelpers.AssertConditionPatched(t, clusterActions[1], expected)
+			},
+		},
+		{
+			name:          "managed cluster lease is renewed after degraded",
+			clusters:      []runtime.Object{newLeaseDegradedManagedCluster()},
+			clusterLeases: []runtime.Object{testinghelpers.NewManagedClusterLease("managed-cluster-lease", now)},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				expected := metav1.Condition{
+					Type:    ManagedClusterConditionLeaseDegraded,
+					Status:  metav1.ConditionFalse,
+					Reason:  "ManagedClusterLeaseRenewed",
+					Message: "Registration agent is updating its lease.",
+				}
+				testinghelpers.AssertActions(t, clusterActions, "get", "patch")
+				testinghelpers.AssertConditionPatched(t, clusterActions[1], expected)
+			},
+		},
+		{
+			name: "managed cluster disables the degraded state",
+			clusters: []runtime.Object{func() *clusterv1.ManagedCluster {
+				cluster := testinghelpers.NewAvailableManagedCluster()
+				cluster.Annotations = map[string]string{LeaseDegradedThresholdAnnotation: "0"}
+				return cluster
+			}()},
+			clusterLeases: []runtime.Object{testinghelpers.NewManagedClusterLease("managed-cluster-lease", now.Add(-3500*time.Millisecond))},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+		{
+			name: "managed cluster lease is within the grace period of the cluster",
+			clusters: []runtime.Object{func() *clusterv1.ManagedCluster {
+				cluster := testinghelpers.NewAvailableManagedCluster()
+				cluster.Annotations = map[string]string{LeaseGracePeriodAnnotation: "1h"}
+				return cluster
+			}()},
+			clusterLeases: []runtime.Object{testinghelpers.NewManagedClusterLease("managed-cluster-lease", now.Add(-5*time.Minute))},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				// the lease is degraded, but the cluster is not changed to unknown
+				testinghelpers.AssertActions(t, clusterActions, "get", "patch")
 				patch := clusterActions[1].(clienttesting.PatchAction).GetPatch()
 				managedCluster := &v1.ManagedCluster{}
-				err := json.Unmarshal(patch, managedCluster)
-				if err != nil {
+				if err := json.Unmarshal(patch, managedCluster); err != nil {
 					t.Fatal(err)
 				}
-				testinghelpers.AssertCondition(t, managedCluster.Status.Conditions, expected)
+				if !meta.IsStatusConditionTrue(managedCluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable) {
+					t.Errorf("expected the cluster is still available, but got %v", managedCluster.Status.Conditions)
+				}
 			},
 		},
 		{
@@ -120,7 +191,7 @@ func TestSync(t *testing.T) {
 				}
 			}
 
-			leaseClient := kubefake.NewSimpleClientset(c.clusterLeases...)
+			leaseClient := kubefake.NewSimpleClientset(append(c.clusterLeases, c.unwatchedLeases...)...)
 			leaseInformerFactory := kubeinformers.NewSharedInformerFactory(leaseClient, time.Minute*10)
 			leaseStore := leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore()
 			for _, lease := range c.clusterLeases {
@@ -137,6 +208,8 @@ func TestSync(t *testing.T) {
 				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
 				leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
 				eventRecorder: syncCtx.Recorder(),
+				clock:         clocktesting.NewFakeClock(now),
+				observations:  map[string]*renewalObservation{},
 			}
 			syncErr := ctrl.sync(context.TODO(), syncCtx)
 			if syncErr != nil {
@@ -147,9 +220,211 @@ func TestSync(t *testing.T) {
 	}
 }
 
+func TestGetLeaseThresholds(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expected    leaseThresholds
+	}{
+		{
+			name:     "no annotations",
+			expected: leaseThresholds{degraded: LeaseDegradedThreshold, unknown: LeaseUnknownThreshold},
+		},
+		{
+			name: "override thresholds",
+			annotations: map[string]string{
+				LeaseDegradedThresholdAnnotation: "2",
+				LeaseUnknownThresholdAnnotation:  "10",
+			},
+			expected: leaseThresholds{degraded: 2, unknown: 10},
+		},
+		{
+			name: "invalid thresholds",
+			annotations: map[string]string{
+				LeaseDegradedThresholdAnnotation: "-1",
+				LeaseUnknownThresholdAnnotation:  "abc",
+			},
+			expected: leaseThresholds{degraded: LeaseDegradedThreshold, unknown: LeaseUnknownThreshold},
+		},
+		{
+			name: "degraded threshold is not less than unknown threshold",
+			annotations: map[string]string{
+				LeaseDegradedThresholdAnnotation: "4",
+				LeaseUnknownThresholdAnnotation:  "2",
+			},
+			expected: leaseThresholds{degraded: 0, unknown: 2},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewAcceptedManagedCluster()
+			cluster.Annotations = c.annotations
+			actual := getLeaseThresholds(cluster)
+			if actual != c.expected {
+				t.Errorf("expected %v, but got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetLeaseGracePeriod(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expected    time.Duration
+	}{
+		{
+			name:     "no annotation",
+			expected: 5 * time.Minute,
+		},
+		{
+			name:        "override grace period",
+			annotations: map[string]string{LeaseGracePeriodAnnotation: "30m"},
+			expected:    30 * time.Minute,
+		},
+		{
+			name:        "invalid grace period",
+			annotations: map[string]string{LeaseGracePeriodAnnotation: "30"},
+			expected:    5 * time.Minute,
+		},
+		{
+			name:        "negative grace period",
+			annotations: map[string]string{LeaseGracePeriodAnnotation: "-1m"},
+			expected:    5 * time.Minute,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewAcceptedManagedCluster()
+			cluster.Annotations = c.annotations
+			actual := getLeaseGracePeriod(cluster, 5*time.Minute)
+			if actual != c.expected {
+				t.Errorf("expected %s, but got %s", c.expected, actual)
+			}
+		})
+	}
+}
+
+func newLeaseDegradedManagedCluster() *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAvailableManagedCluster()
+	cluster.Status.Conditions = append(cluster.Status.Conditions, testinghelpers.NewManagedClusterCondition(
+		ManagedClusterConditionLeaseDegraded,
+		"True",
+		"ManagedClusterLeaseRenewalMissed",
+		"Registration agent missed some renewals of its lease.",
+		nil,
+	))
+	return cluster
+}
+
 func newDeletingManagedCluster() *clusterv1.ManagedCluster {
 	now := metav1.Now()
 	cluster := testinghelpers.NewAcceptedManagedCluster()
 	cluster.DeletionTimestamp = &now
 	return cluster
 }
+
+func TestEnqueueHandler(t *testing.T) {
+	syncCtx := testinghelpers.NewFakeSyncContext(t, "")
+	ctrl := &leaseController{queue: syncCtx.Queue()}
+	handler := ctrl.enqueueHandler(func(obj metav1.Object) string {
+		return obj.GetLabels()[clusterv1.ClusterNameLabelKey]
+	})
+
+	lease := testinghelpers.NewManagedClusterLease(leaseName, now)
+	lease.Labels = map[string]string{clusterv1.ClusterNameLabelKey: testinghelpers.TestManagedClusterName}
+	lease.ResourceVersion = "1"
+
+	// the resync of the informer is ignored
+	handler.OnUpdate(lease, lease)
+	if syncCtx.Queue().Len() != 0 {
+		t.Errorf("expected the resync is ignored, but got %d clusters enqueued", syncCtx.Queue().Len())
+	}
+
+	renewed := lease.DeepCopy()
+	renewed.ResourceVersion = "2"
+	handler.OnUpdate(lease, renewed)
+	if syncCtx.Queue().Len() != 1 {
+		t.Fatalf("expected the cluster is enqueued on the renewal, but got %d clusters enqueued", syncCtx.Queue().Len())
+	}
+	key, _ := syncCtx.Queue().Get()
+	if key != testinghelpers.TestManagedClusterName {
+		t.Errorf("expected cluster %q is enqueued, but got %v", testinghelpers.TestManagedClusterName, key)
+	}
+}
+
+func TestSyncWithLeaseStarvation(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(now)
+	cluster := testinghelpers.NewAvailableManagedCluster()
+	lease := testinghelpers.NewManagedClusterLease(leaseName, fakeClock.Now())
+
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+		t.Fatal(err)
+	}
+	leaseClient := kubefake.NewSimpleClientset(lease)
+	leaseInformerFactory := kubeinformers.NewSharedInformerFactory(leaseClient, time.Minute*10)
+	if err := leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore().Add(lease); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCtx := testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)
+	ctrl := &leaseController{
+		kubeClient:    leaseClient,
+		clusterClient: clusterClient,
+		clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
+		eventRecorder: syncCtx.Recorder(),
+		clock:         fakeClock,
+		observations:  map[string]*renewalObservation{},
+	}
+
+	// the lease is starved of the renewals, the cluster is degraded and then unknown once the clock passes the
+	// thresholds of the lease
+	steps := []struct {
+		starvation        time.Duration
+		expectedCondition *metav1.Condition
+	}{
+		{
+			starvation: 0,
+		},
+		{
+			starvation: time.Duration(LeaseDegradedThreshold) * time.Second,
+			expectedCondition: &metav1.Condition{
+				Type:    ManagedClusterConditionLeaseDegraded,
+				Status:  metav1.ConditionTrue,
+				Reason:  "ManagedClusterLeaseRenewalMissed",
+				Message: "Registration agent missed some renewals of its lease.",
+			},
+		},
+		{
+			starvation: time.Duration(LeaseUnknownThreshold) * time.Second,
+			expectedCondition: &metav1.Condition{
+				Type:    clusterv1.ManagedClusterConditionAvailable,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "ManagedClusterLeaseUpdateStopped",
+				Message: "Registration agent stopped updating its lease.",
+			},
+		},
+	}
+	for _, step := range steps {
+		fakeClock.SetTime(now.Add(step.starvation))
+		clusterClient.ClearActions()
+		if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+
+		actions := clusterClient.Actions()
+		if step.expectedCondition == nil {
+			testinghelpers.AssertNoActions(t, actions)
+			continue
+		}
+		testinghelpers.AssertActions(t, actions, "get", "patch")
+		managedCluster := &v1.ManagedCluster{}
+		testinghelpers.UnmarshalPatch(t, actions[1], managedCluster)
+		testinghelpers.AssertCondition(t, managedCluster.Status.Conditions, *step.expectedCondition)
+	}
+}
@@ -0,0 +1,90 @@
+package lease
+
+import (
+	"strconv"
+	"time"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/logging"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ManagedClusterConditionLeaseDegraded is the condition type set on a managed cluster whose lease missed
+	// some renewals but has not reached the threshold to mark the cluster available condition unknown.
+	ManagedClusterConditionLeaseDegraded = "ManagedClusterLeaseDegraded"
+
+	// LeaseDegradedThresholdAnnotation is the annotation on a managed cluster to override the number of missed lease
+	// renewals after which the cluster lease is considered as degraded. Set it to 0 to disable the degraded state.
+	LeaseDegradedThresholdAnnotation = "cluster.open-cluster-management.io/lease-degraded-threshold"
+
+	// LeaseUnknownThresholdAnnotation is the annotation on a managed cluster to override the number of missed lease
+	// renewals after which the cluster available condition is changed to unknown.
+	LeaseUnknownThresholdAnnotation = "cluster.open-cluster-management.io/lease-unknown-threshold"
+
+	// LeaseGracePeriodAnnotation is the annotation on a managed cluster to override the period, e.g. 30m, after the
+	// last lease renewal when the cluster available condition is changed to unknown. It takes precedence over the
+	// unknown threshold, so the clusters on the links which legitimately miss the renewals, e.g. the satellite links,
+	// are not marked as unknown too aggressively.
+	LeaseGracePeriodAnnotation = "registration.open-cluster-management.io/lease-grace-period"
+)
+
+var (
+	// LeaseDegradedThreshold is the default number of missed lease renewals to consider a cluster lease degraded
+	LeaseDegradedThreshold = 3
+	// LeaseUnknownThreshold is the default number of missed lease renewals to change a cluster to unknown
+	LeaseUnknownThreshold = leaseDurationTimes
+)
+
+// leaseThresholds holds the number of missed lease renewals for each availability state of a cluster
+type leaseThresholds struct {
+	degraded int
+	unknown  int
+}
+
+// getLeaseThresholds returns the lease thresholds of a managed cluster. The defaults are used if the annotations
+// of the cluster are not set or invalid. The degraded state is disabled if its threshold is not less than the
+// unknown threshold.
+func getLeaseThresholds(cluster *clusterv1.ManagedCluster) leaseThresholds {
+	thresholds := leaseThresholds{
+		degraded: parseThreshold(cluster, LeaseDegradedThresholdAnnotation, LeaseDegradedThreshold, 0),
+		unknown:  parseThreshold(cluster, LeaseUnknownThresholdAnnotation, LeaseUnknownThreshold, 1),
+	}
+	if thresholds.degraded >= thresholds.unknown {
+		thresholds.degraded = 0
+	}
+	return thresholds
+}
+
+func parseThreshold(cluster *clusterv1.ManagedCluster, annotation string, defaultValue, minValue int) int {
+	value, ok := cluster.Annotations[annotation]
+	if !ok {
+		return defaultValue
+	}
+
+	threshold, err := strconv.Atoi(value)
+	if err != nil || threshold < minValue {
+		klog.InfoS("The annotation of managed cluster is invalid, use the default value",
+			logging.ClusterKey, cluster.Name, "annotation", annotation, "default", defaultValue)
+		return defaultValue
+	}
+	return threshold
+}
+
+// getLeaseGracePeriod returns the grace period of the lease of a managed cluster set by its annotation, or the
+// default grace period if the annotation is not set or invalid
+func getLeaseGracePeriod(cluster *clusterv1.ManagedCluster, defaultGracePeriod time.Duration) time.Duration {
+	value, ok := cluster.Annotations[LeaseGracePeriodAnnotation]
+	if !ok {
+		return defaultGracePeriod
+	}
+
+	gracePeriod, err := time.ParseDuration(value)
+	if err != nil || gracePeriod <= 0 {
+		klog.InfoS("The annotation of managed cluster is invalid, use the default value",
+			logging.ClusterKey, cluster.Name, "annotation", LeaseGracePeriodAnnotation, "default", defaultGracePeriod)
+		return defaultGracePeriod
+	}
+	return gracePeriod
+}
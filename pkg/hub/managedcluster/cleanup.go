@@ -0,0 +1,145 @@
+package managedcluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"open-cluster-management.io/registration/pkg/helpers"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// ManagedClusterConditionAddOnsDeleted is the condition type of the cleanup stage which deletes the addons of
+	// a deleting cluster and waits until the addon finalizers are removed.
+	ManagedClusterConditionAddOnsDeleted = "ManagedClusterAddOnsDeleted"
+	// ManagedClusterConditionRBACDeleted is the condition type of the cleanup stage which deletes the clusterrole,
+	// clusterrolebinding, roles and rolebindings of a deleting cluster.
+	ManagedClusterConditionRBACDeleted = "ManagedClusterRBACDeleted"
+	// ManagedClusterConditionNamespaceDeleted is the condition type of the cleanup stage which deletes the
+	// namespace of a deleting cluster.
+	ManagedClusterConditionNamespaceDeleted = "ManagedClusterNamespaceDeleted"
+
+	cleanupCompletedReason  = "CleanupCompleted"
+	cleanupInProgressReason = "CleanupInProgress"
+	cleanupFailedReason     = "CleanupFailed"
+)
+
+// cleanupStage is one stage of the cleanup pipeline of a deleting cluster. The cleanup returns false with a
+// message if the stage is still in progress, the next stage does not start until the current one completes.
+type cleanupStage struct {
+	conditionType string
+	cleanup       func(ctx context.Context, managedClusterName string) (bool, string, error)
+}
+
+// cleanupStages returns the ordered cleanup pipeline of a deleting cluster
+func (c *managedClusterController) cleanupStages() []cleanupStage {
+	return []cleanupStage{
+		{conditionType: ManagedClusterConditionAddOnsDeleted, cleanup: c.cleanupAddOns},
+		{conditionType: ManagedClusterConditionRBACDeleted, cleanup: c.cleanupRBAC},
+		{conditionType: ManagedClusterConditionNamespaceDeleted, cleanup: c.cleanupNamespace},
+	}
+}
+
+// cleanup runs the cleanup pipeline of a deleting cluster, and reports the result of each started stage with
+// a condition on the cluster. It returns true once all of the stages are completed.
+func (c *managedClusterController) cleanup(ctx context.Context, managedClusterName string) (bool, error) {
+	completed := true
+	var cleanupErr error
+	updateFuncs := []helpers.UpdateManagedClusterStatusFunc{}
+	for _, stage := range c.cleanupStages() {
+		done, message, err := stage.cleanup(ctx, managedClusterName)
+		condition := metav1.Condition{
+			Type:    stage.conditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  cleanupCompletedReason,
+			Message: message,
+		}
+		switch {
+		case err != nil:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = cleanupFailedReason
+			condition.Message = err.Error()
+		case !done:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = cleanupInProgressReason
+		}
+		updateFuncs = append(updateFuncs, helpers.UpdateManagedClusterConditionFn(condition))
+
+		if err != nil || !done {
+			completed, cleanupErr = false, err
+			break
+		}
+	}
+
+	_, _, err := helpers.UpdateManagedClusterStatus(ctx, c.clusterClient, managedClusterName, updateFuncs...)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return completed, cleanupErr
+}
+
+// cleanupAddOns deletes the addons of the cluster, and waits until they are removed by the addon managers
+func (c *managedClusterController) cleanupAddOns(ctx context.Context, managedClusterName string) (bool, string, error) {
+	addOns, err := c.addOnLister.ManagedClusterAddOns(managedClusterName).List(labels.Everything())
+	if err != nil {
+		return false, "", err
+	}
+	if len(addOns) == 0 {
+		return true, "All addons are deleted", nil
+	}
+
+	names := []string{}
+	for _, addOn := range addOns {
+		names = append(names, addOn.Name)
+		if !addOn.DeletionTimestamp.IsZero() {
+			continue
+		}
+		err := c.addOnClient.AddonV1alpha1().ManagedClusterAddOns(managedClusterName).Delete(ctx, addOn.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return false, "", err
+		}
+	}
+	sort.Strings(names)
+	return false, fmt.Sprintf("Waiting for the finalizers of %d addons to be removed: %s",
+		len(names), strings.Join(names, ", ")), nil
+}
+
+// cleanupRBAC deletes the clusterrole, clusterrolebinding, roles and rolebindings of the cluster
+func (c *managedClusterController) cleanupRBAC(ctx context.Context, managedClusterName string) (bool, string, error) {
+	if err := c.removeManagedClusterResources(ctx, managedClusterName); err != nil {
+		return false, "", err
+	}
+	return true, "The rbac resources are deleted", nil
+}
+
+// cleanupNamespace deletes the namespace of the cluster. It does not wait for the namespace to be removed, the
+// rolebindings in it are kept until the manifestworks are deleted.
+func (c *managedClusterController) cleanupNamespace(ctx context.Context, managedClusterName string) (bool, string, error) {
+	err := c.kubeClient.CoreV1().Namespaces().Delete(ctx, managedClusterName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return false, "", err
+	}
+	return true, "The namespace is deleted", nil
+}
+
+// addOnNamespace returns the namespace of the addon, the obj can be a tombstone of a deleted addon
+func addOnNamespace(obj interface{}) string {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return ""
+	}
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return ""
+	}
+	return namespace
+}
@@ -0,0 +1,183 @@
+package managedcluster
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	v1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestCleanup(t *testing.T) {
+	cases := []struct {
+		name                    string
+		addOns                  []runtime.Object
+		expectedCompleted       bool
+		expectedConditions      []metav1.Condition
+		validateAddOnActions    func(t *testing.T, actions []clienttesting.Action)
+		validateKubeActions     func(t *testing.T, actions []clienttesting.Action)
+		unexpectedConditionType string
+	}{
+		{
+			name:              "delete addons",
+			addOns:            []runtime.Object{newAddOn("addon1", false)},
+			expectedCompleted: false,
+			expectedConditions: []metav1.Condition{
+				{
+					Type:    ManagedClusterConditionAddOnsDeleted,
+					Status:  metav1.ConditionFalse,
+					Reason:  cleanupInProgressReason,
+					Message: "Waiting for the finalizers of 1 addons to be removed: addon1",
+				},
+			},
+			validateAddOnActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "delete")
+			},
+			validateKubeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+			unexpectedConditionType: ManagedClusterConditionRBACDeleted,
+		},
+		{
+			name:              "wait for addon finalizers",
+			addOns:            []runtime.Object{newAddOn("addon2", true), newAddOn("addon1", true)},
+			expectedCompleted: false,
+			expectedConditions: []metav1.Condition{
+				{
+					Type:    ManagedClusterConditionAddOnsDeleted,
+					Status:  metav1.ConditionFalse,
+					Reason:  cleanupInProgressReason,
+					Message: "Waiting for the finalizers of 2 addons to be removed: addon1, addon2",
+				},
+			},
+			validateAddOnActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+			validateKubeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+			unexpectedConditionType: ManagedClusterConditionRBACDeleted,
+		},
+		{
+			name:              "all stages completed",
+			expectedCompleted: true,
+			expectedConditions: []metav1.Condition{
+				{
+					Type:    ManagedClusterConditionAddOnsDeleted,
+					Status:  metav1.ConditionTrue,
+					Reason:  cleanupCompletedReason,
+					Message: "All addons are deleted",
+				},
+				{
+					Type:    ManagedClusterConditionRBACDeleted,
+					Status:  metav1.ConditionTrue,
+					Reason:  cleanupCompletedReason,
+					Message: "The rbac resources are deleted",
+				},
+				{
+					Type:    ManagedClusterConditionNamespaceDeleted,
+					Status:  metav1.ConditionTrue,
+					Reason:  cleanupCompletedReason,
+					Message: "The namespace is deleted",
+				},
+			},
+			validateAddOnActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+			validateKubeActions: func(t *testing.T, actions []clienttesting.Action) {
+				// the clusterrole, clusterrolebinding, two rolebindings and the namespace
+				testinghelpers.AssertActions(t, actions, "delete", "delete", "delete", "delete", "delete")
+				if actions[4].GetResource().Resource != "namespaces" {
+					t.Errorf("expected the namespace to be deleted at last, but got %v", actions[4])
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewDeletingManagedCluster()
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+				t.Fatal(err)
+			}
+			addOnClient := addonfake.NewSimpleClientset(c.addOns...)
+			addOnInformerFactory := addoninformers.NewSharedInformerFactory(addOnClient, time.Minute*10)
+			for _, addOn := range c.addOns {
+				if err := addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore().Add(addOn); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient := kubefake.NewSimpleClientset()
+
+			ctrl := managedClusterController{
+				kubeClient:         kubeClient,
+				clusterClient:      clusterClient,
+				clusterLister:      clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				addOnClient:        addOnClient,
+				addOnLister:        addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				cache:              resourceapply.NewResourceCache(),
+				rbacPolicyProvider: DefaultRBACPolicyProvider,
+				eventRecorder:      eventstesting.NewTestingEventRecorder(t),
+			}
+			completed, err := ctrl.cleanup(context.TODO(), testinghelpers.TestManagedClusterName)
+			if err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if completed != c.expectedCompleted {
+				t.Errorf("expected completed %v, but got %v", c.expectedCompleted, completed)
+			}
+
+			c.validateAddOnActions(t, addOnClient.Actions())
+			c.validateKubeActions(t, kubeClient.Actions())
+
+			clusterActions := clusterClient.Actions()
+			testinghelpers.AssertActions(t, clusterActions, "get", "patch")
+			patch := clusterActions[1].(clienttesting.PatchAction).GetPatch()
+			managedCluster := &v1.ManagedCluster{}
+			if err := json.Unmarshal(patch, managedCluster); err != nil {
+				t.Fatal(err)
+			}
+			for _, condition := range c.expectedConditions {
+				testinghelpers.AssertCondition(t, managedCluster.Status.Conditions, condition)
+			}
+			if len(c.unexpectedConditionType) > 0 {
+				for _, condition := range managedCluster.Status.Conditions {
+					if condition.Type == c.unexpectedConditionType {
+						t.Errorf("unexpected condition %v", condition)
+					}
+				}
+			}
+		})
+	}
+}
+
+func newAddOn(name string, deleting bool) *addonv1alpha1.ManagedClusterAddOn {
+	addOn := &addonv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testinghelpers.TestManagedClusterName,
+			Name:      name,
+		},
+	}
+	if deleting {
+		now := metav1.Now()
+		addOn.DeletionTimestamp = &now
+		addOn.Finalizers = []string{"addon.open-cluster-management.io/addon-pre-delete"}
+	}
+	return addOn
+}
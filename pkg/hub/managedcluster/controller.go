@@ -6,11 +6,17 @@ import (
 	"encoding/json"
 	"fmt"
 
+	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
+	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
+	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
 	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	v1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/hub/user"
+	"open-cluster-management.io/registration/pkg/logging"
+	"open-cluster-management.io/registration/pkg/tracing"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -20,10 +26,12 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/klog/v2"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 )
 
 const (
@@ -45,35 +53,134 @@ type managedClusterController struct {
 	kubeClient    kubernetes.Interface
 	clusterClient clientset.Interface
 	clusterLister listerv1.ManagedClusterLister
+	addOnClient   addonclient.Interface
+	addOnLister   addonlisterv1alpha1.ManagedClusterAddOnLister
 	cache         resourceapply.ResourceCache
 	eventRecorder events.Recorder
+
+	// the provider of the RBAC resources granted to the accepted clusters
+	rbacPolicyProvider RBACPolicyProvider
+
+	// the namespace policy ConfigMap, the namespace policy is disabled if the lister is nil
+	namespacePolicyLister    corev1listers.ConfigMapLister
+	namespacePolicyNamespace string
+	namespacePolicyName      string
+	// the listers of the resources provisioned by the namespace policy
+	namespacePolicyListers namespacePolicyListers
+
+	// the username prefix of the tokens of the clusters registered with the oidc driver, the users of the tokens are
+	// bound with the roles of the clusters if it is not nil
+	oidcUsernamePrefix *string
 }
 
-// NewManagedClusterController creates a new managed cluster controller
+// NewManagedClusterController creates a new managed cluster controller. If the namespace policy informer is
+// not nil, the namespaces of the accepted clusters are provisioned with the policy in the ConfigMap
+// namespacePolicyNamespace/namespacePolicyName, and the provisioned resources are read with the namespace, resource
+// quota and limit range informers. If the oidc username prefix is not nil, the roles of the accepted
+// clusters are also bound with the users of the tokens the clusters report with the OIDC annotations. The RBAC
+// resources of the accepted clusters are provided by the DefaultRBACPolicyProvider if the rbac policy provider is nil.
 func NewManagedClusterController(
 	kubeClient kubernetes.Interface,
 	clusterClient clientset.Interface,
 	clusterInformer informerv1.ManagedClusterInformer,
+	addOnClient addonclient.Interface,
+	addOnInformer addoninformerv1alpha1.ManagedClusterAddOnInformer,
+	namespacePolicyInformer corev1informers.ConfigMapInformer,
+	namespacePolicyNamespace, namespacePolicyName string,
+	namespaceInformer corev1informers.NamespaceInformer,
+	resourceQuotaInformer corev1informers.ResourceQuotaInformer,
+	limitRangeInformer corev1informers.LimitRangeInformer,
+	oidcUsernamePrefix *string,
+	rbacPolicyProvider RBACPolicyProvider,
 	recorder events.Recorder) factory.Controller {
+	if rbacPolicyProvider == nil {
+		rbacPolicyProvider = DefaultRBACPolicyProvider
+	}
 	c := &managedClusterController{
-		kubeClient:    kubeClient,
-		clusterClient: clusterClient,
-		clusterLister: clusterInformer.Lister(),
-		cache:         resourceapply.NewResourceCache(),
-		eventRecorder: recorder.WithComponentSuffix("managed-cluster-controller"),
+		kubeClient:         kubeClient,
+		clusterClient:      clusterClient,
+		clusterLister:      clusterInformer.Lister(),
+		addOnClient:        addOnClient,
+		addOnLister:        addOnInformer.Lister(),
+		cache:              resourceapply.NewResourceCache(),
+		eventRecorder:      recorder.WithComponentSuffix("managed-cluster-controller"),
+		oidcUsernamePrefix: oidcUsernamePrefix,
+		rbacPolicyProvider: rbacPolicyProvider,
 	}
-	return factory.New().
+	controllerFactory := factory.New().
 		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
 			accessor, _ := meta.Accessor(obj)
 			return accessor.GetName()
 		}, clusterInformer.Informer()).
-		WithSync(c.sync).
+		// requeue the deleting cluster once its addons are changed, so the cleanup pipeline continues
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				return addOnNamespace(obj)
+			},
+			func(obj interface{}) bool {
+				cluster, err := c.clusterLister.Get(addOnNamespace(obj))
+				if err != nil {
+					return false
+				}
+				return !cluster.DeletionTimestamp.IsZero()
+			},
+			addOnInformer.Informer())
+
+	if namespacePolicyInformer != nil {
+		c.namespacePolicyLister = namespacePolicyInformer.Lister()
+		c.namespacePolicyNamespace = namespacePolicyNamespace
+		c.namespacePolicyName = namespacePolicyName
+		c.namespacePolicyListers = namespacePolicyListers{
+			namespaceLister:     namespaceInformer.Lister(),
+			resourceQuotaLister: resourceQuotaInformer.Lister(),
+			limitRangeLister:    limitRangeInformer.Lister(),
+		}
+		// requeue all of the clusters once the namespace policy is changed
+		controllerFactory = controllerFactory.WithFilteredEventsInformersQueueKeysFunc(
+			func(obj runtime.Object) []string {
+				clusters, err := c.clusterLister.List(labels.Everything())
+				if err != nil {
+					return nil
+				}
+				keys := []string{}
+				for _, cluster := range clusters {
+					keys = append(keys, cluster.Name)
+				}
+				return keys
+			},
+			func(obj interface{}) bool {
+				accessor, err := meta.Accessor(obj)
+				if err != nil {
+					return false
+				}
+				return accessor.GetNamespace() == namespacePolicyNamespace && accessor.GetName() == namespacePolicyName
+			},
+			namespacePolicyInformer.Informer())
+		// requeue the cluster once the resource quota or limit range in its namespace is changed
+		controllerFactory = controllerFactory.WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetNamespace()
+			},
+			func(obj interface{}) bool {
+				accessor, err := meta.Accessor(obj)
+				if err != nil {
+					return false
+				}
+				return accessor.GetName() == NamespacePolicyResourceName
+			},
+			resourceQuotaInformer.Informer(), limitRangeInformer.Informer())
+	}
+
+	return controllerFactory.
+		WithSync(tracing.TraceSync("ManagedClusterController", c.sync)).
 		ToController("ManagedClusterController", recorder)
 }
 
 func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
 	managedClusterName := syncCtx.QueueKey()
-	klog.V(4).Infof("Reconciling ManagedCluster %s", managedClusterName)
+	ctx, logger := logging.WithCluster(ctx, managedClusterName)
+	logger.V(4).Info("Reconciling ManagedCluster")
 	managedCluster, err := c.clusterLister.Get(managedClusterName)
 	if errors.IsNotFound(err) {
 		// Spoke cluster not found, could have been deleted, do nothing.
@@ -83,6 +190,8 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 		return err
 	}
 
+	tracing.SetCluster(ctx, managedClusterName, managedCluster.CreationTimestamp)
+
 	managedCluster = managedCluster.DeepCopy()
 	if managedCluster.DeletionTimestamp.IsZero() {
 		hasFinalizer := false
@@ -105,9 +214,11 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 		}
 	}
 
-	// Spoke cluster is deleting, we remove its related resources
+	// Spoke cluster is deleting, we remove its related resources in order, and remove the finalizer once all of
+	// the cleanup stages are completed
 	if !managedCluster.DeletionTimestamp.IsZero() {
-		if err := c.removeManagedClusterResources(ctx, managedClusterName); err != nil {
+		completed, err := c.cleanup(ctx, managedClusterName)
+		if err != nil || !completed {
 			return err
 		}
 		return c.removeManagedClusterFinalizer(ctx, managedCluster)
@@ -140,23 +251,28 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 		return err
 	}
 
-	// TODO consider to add the managedcluster-namespace.yaml back to staticFiles,
-	// currently, we keep the namespace after the managed cluster is deleted.
-	applyFiles := []string{"manifests/managedcluster-namespace.yaml"}
-	applyFiles = append(applyFiles, staticFiles...)
-
 	// Hub cluster-admin accepts the spoke cluster, we apply
-	// 1. clusterrole and clusterrolebinding for this spoke cluster.
-	// 2. namespace for this spoke cluster.
-	// 3. role and rolebinding for this spoke cluster on its namespace.
+	// 1. namespace for this spoke cluster. It is not one of the RBAC resources, it is kept after the managed cluster
+	//    is denied, and deleted in the last stage of the cleanup pipeline after the managed cluster is deleted.
+	// 2. the RBAC resources of the rbac policy provider for this spoke cluster, by default the clusterrole and
+	//    clusterrolebinding for this spoke cluster, and the rolebindings for this spoke cluster on its namespace.
 	resourceResults := resourceapply.ApplyDirectly(
 		ctx,
 		resourceapply.NewKubeClientHolder(c.kubeClient),
 		syncCtx.Recorder(),
 		c.cache,
 		helpers.ManagedClusterAssetFn(manifestFiles, managedClusterName),
-		applyFiles...,
+		"manifests/managedcluster-namespace.yaml",
 	)
+	rbacAssetFn, rbacFiles := c.rbacPolicyProvider.Manifests(managedClusterName, c.clusterUser(managedCluster))
+	resourceResults = append(resourceResults, resourceapply.ApplyDirectly(
+		ctx,
+		resourceapply.NewKubeClientHolder(c.kubeClient),
+		syncCtx.Recorder(),
+		c.cache,
+		rbacAssetFn,
+		rbacFiles...,
+	)...)
 	errs := []error{}
 	for _, result := range resourceResults {
 		if result.Error != nil {
@@ -164,6 +280,11 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 		}
 	}
 
+	// provision the namespace with the namespace policy
+	if err := c.applyNamespacePolicy(ctx, syncCtx.Recorder(), managedClusterName); err != nil {
+		errs = append(errs, fmt.Errorf("failed to apply the namespace policy: %v", err))
+	}
+
 	// We add the accepted condition to spoke cluster
 	acceptedCondition := metav1.Condition{
 		Type:    v1.ManagedClusterConditionHubAccepted,
@@ -189,15 +310,53 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 	}
 	if updated {
 		c.eventRecorder.Eventf("ManagedClusterAccepted", "managed cluster %s is accepted by hub cluster admin", managedClusterName)
+		tracing.AddEvent(ctx, "ManagedClusterAccepted")
 	}
 	return operatorhelpers.NewMultiLineAggregate(errs)
 }
 
+// clusterUser returns the user of the token the cluster authenticates with by the oidc driver, it is empty if the
+// cluster is identified by the group of its client certificates only. The identity annotations are set by the users
+// allowed to accept the cluster, the webhook denies them from the agents.
+func (c *managedClusterController) clusterUser(managedCluster *v1.ManagedCluster) string {
+	if c.oidcUsernamePrefix == nil {
+		return ""
+	}
+	issuer := managedCluster.Annotations[helpers.ManagedClusterOIDCIssuerAnnotation]
+	subject := managedCluster.Annotations[helpers.ManagedClusterOIDCSubjectAnnotation]
+	if len(issuer) == 0 || len(subject) == 0 {
+		return ""
+	}
+	return user.OIDCUsername(issuer, subject, *c.oidcUsernamePrefix)
+}
+
+// applyNamespacePolicy provisions the cluster namespace with the namespace policy, it does nothing if the namespace
+// policy is disabled or the policy ConfigMap does not exist.
+func (c *managedClusterController) applyNamespacePolicy(ctx context.Context, recorder events.Recorder, managedClusterName string) error {
+	if c.namespacePolicyLister == nil {
+		return nil
+	}
+
+	configMap, err := c.namespacePolicyLister.ConfigMaps(c.namespacePolicyNamespace).Get(c.namespacePolicyName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	policy, err := LoadNamespacePolicy([]byte(configMap.Data[NamespacePolicyKey]))
+	if err != nil {
+		return err
+	}
+	return policy.apply(ctx, c.kubeClient, c.namespacePolicyListers, recorder, managedClusterName)
+}
+
 func (c *managedClusterController) removeManagedClusterResources(ctx context.Context, managedClusterName string) error {
 	errs := []error{}
-	// Clean up managed cluster manifests
-	assetFn := helpers.ManagedClusterAssetFn(manifestFiles, managedClusterName)
-	if err := helpers.CleanUpManagedClusterManifests(ctx, c.kubeClient, c.eventRecorder, assetFn, staticFiles...); err != nil {
+	// Clean up the RBAC resources of the managed cluster
+	assetFn, files := c.rbacPolicyProvider.Manifests(managedClusterName, "")
+	if err := helpers.CleanUpManagedClusterManifests(ctx, c.kubeClient, c.eventRecorder, assetFn, files...); err != nil {
 		errs = append(errs, err)
 	}
 	return operatorhelpers.NewMultiLineAggregate(errs)
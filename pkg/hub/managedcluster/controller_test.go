@@ -2,22 +2,29 @@ package managedcluster
 
 import (
 	"context"
-	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
 	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	v1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 )
 
 func TestSyncManagedCluster(t *testing.T) {
@@ -38,12 +45,8 @@ func TestSyncManagedCluster(t *testing.T) {
 			startingObjects: []runtime.Object{testinghelpers.NewManagedCluster()},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, actions, "patch")
-				patch := actions[0].(clienttesting.PatchAction).GetPatch()
 				managedCluster := &v1.ManagedCluster{}
-				err := json.Unmarshal(patch, managedCluster)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[0], managedCluster)
 				testinghelpers.AssertFinalizers(t, managedCluster, []string{managedClusterFinalizer})
 			},
 		},
@@ -58,13 +61,7 @@ func TestSyncManagedCluster(t *testing.T) {
 					Message: "Accepted by hub cluster admin",
 				}
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
-				managedCluster := &v1.ManagedCluster{}
-				err := json.Unmarshal(patch, managedCluster)
-				if err != nil {
-					t.Fatal(err)
-				}
-				testinghelpers.AssertCondition(t, managedCluster.Status.Conditions, expectedCondition)
+				testinghelpers.AssertConditionPatched(t, actions[1], expectedCondition)
 			},
 		},
 		{
@@ -85,26 +82,16 @@ func TestSyncManagedCluster(t *testing.T) {
 					Message: "Denied by hub cluster admin",
 				}
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
-				managedCluster := &v1.ManagedCluster{}
-				err := json.Unmarshal(patch, managedCluster)
-				if err != nil {
-					t.Fatal(err)
-				}
-				testinghelpers.AssertCondition(t, managedCluster.Status.Conditions, expectedCondition)
+				testinghelpers.AssertConditionPatched(t, actions[1], expectedCondition)
 			},
 		},
 		{
 			name:            "delete a spoke cluster",
 			startingObjects: []runtime.Object{testinghelpers.NewDeletingManagedCluster()},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testinghelpers.AssertActions(t, actions, "patch")
-				patch := actions[0].(clienttesting.PatchAction).GetPatch()
+				testinghelpers.AssertActions(t, actions, "get", "patch", "patch")
 				managedCluster := &v1.ManagedCluster{}
-				err := json.Unmarshal(patch, managedCluster)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[2], managedCluster)
 				testinghelpers.AssertFinalizers(t, managedCluster, []string{})
 			},
 		},
@@ -122,7 +109,16 @@ func TestSyncManagedCluster(t *testing.T) {
 				}
 			}
 
-			ctrl := managedClusterController{kubeClient, clusterClient, clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(), resourceapply.NewResourceCache(), eventstesting.NewTestingEventRecorder(t)}
+			ctrl := managedClusterController{
+				kubeClient:         kubeClient,
+				clusterClient:      clusterClient,
+				clusterLister:      clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				addOnClient:        addonfake.NewSimpleClientset(),
+				addOnLister:        addoninformers.NewSharedInformerFactory(addonfake.NewSimpleClientset(), time.Minute*10).Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				cache:              resourceapply.NewResourceCache(),
+				rbacPolicyProvider: DefaultRBACPolicyProvider,
+				eventRecorder:      eventstesting.NewTestingEventRecorder(t),
+			}
 			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
 			if syncErr != nil {
 				t.Errorf("unexpected err: %v", syncErr)
@@ -132,3 +128,283 @@ func TestSyncManagedCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncManagedClusterWithNamespacePolicy(t *testing.T) {
+	cases := []struct {
+		name            string
+		policy          *corev1.ConfigMap
+		existingObjects []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name: "no namespace policy",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				for _, action := range actions {
+					if action.GetResource().Resource == "resourcequotas" || action.GetResource().Resource == "limitranges" {
+						t.Errorf("unexpected action %v", action)
+					}
+				}
+			},
+		},
+		{
+			name:   "apply namespace policy",
+			policy: newNamespacePolicyConfigMap(testNamespacePolicy),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				namespace := &corev1.Namespace{}
+				quota := &corev1.ResourceQuota{}
+				limitRange := &corev1.LimitRange{}
+				for _, action := range actions {
+					createAction, ok := action.(clienttesting.CreateAction)
+					if !ok {
+						continue
+					}
+					switch obj := createAction.GetObject().(type) {
+					case *corev1.ResourceQuota:
+						quota = obj
+					case *corev1.LimitRange:
+						limitRange = obj
+					}
+				}
+				for _, action := range actions {
+					updateAction, ok := action.(clienttesting.UpdateAction)
+					if !ok {
+						continue
+					}
+					if obj, ok := updateAction.GetObject().(*corev1.Namespace); ok {
+						namespace = obj
+					}
+				}
+
+				if namespace.Labels["pod-security.kubernetes.io/enforce"] != "baseline" {
+					t.Errorf("expected pod security label, but got %v", namespace.Labels)
+				}
+				if namespace.Annotations["open-cluster-management.io/cluster"] != testinghelpers.TestManagedClusterName {
+					t.Errorf("expected cluster annotation, but got %v", namespace.Annotations)
+				}
+				if quota.Name != NamespacePolicyResourceName || quota.Spec.Hard.Pods().String() != "10" {
+					t.Errorf("unexpected resource quota %v", quota)
+				}
+				if limitRange.Name != NamespacePolicyResourceName || len(limitRange.Spec.Limits) != 1 {
+					t.Errorf("unexpected limit range %v", limitRange)
+				}
+			},
+		},
+		{
+			name:   "remove resource quota",
+			policy: newNamespacePolicyConfigMap("labels:\n  pod-security.kubernetes.io/enforce: baseline\n"),
+			existingObjects: []runtime.Object{
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: NamespacePolicyResourceName},
+				},
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				deleted := false
+				for _, action := range actions {
+					if action.GetResource().Resource == "limitranges" {
+						t.Errorf("unexpected action %v on the limit range which does not exist", action)
+					}
+				}
+				for _, action := range actions {
+					if action.GetVerb() == "delete" && action.GetResource().Resource == "resourcequotas" {
+						deleted = true
+					}
+				}
+				if !deleted {
+					t.Errorf("expected resource quota to be deleted, but got %v", actions)
+				}
+			},
+		},
+		{
+			name:   "resource quota is unchanged",
+			policy: newNamespacePolicyConfigMap("resourceQuota:\n  hard:\n    pods: \"10\"\n"),
+			existingObjects: []runtime.Object{
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: NamespacePolicyResourceName},
+					Spec:       corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")}},
+				},
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				for _, action := range actions {
+					if action.GetResource().Resource == "resourcequotas" || action.GetResource().Resource == "limitranges" {
+						t.Errorf("unexpected action %v", action)
+					}
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewAcceptedManagedCluster()
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+			kubeClient := kubefake.NewSimpleClientset(c.existingObjects...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+				t.Fatal(err)
+			}
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+			if c.policy != nil {
+				if err := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(c.policy); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, obj := range c.existingObjects {
+				var store cache.Store
+				switch obj.(type) {
+				case *corev1.ResourceQuota:
+					store = kubeInformerFactory.Core().V1().ResourceQuotas().Informer().GetStore()
+				case *corev1.LimitRange:
+					store = kubeInformerFactory.Core().V1().LimitRanges().Informer().GetStore()
+				default:
+					continue
+				}
+				if err := store.Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := managedClusterController{
+				kubeClient:               kubeClient,
+				clusterClient:            clusterClient,
+				clusterLister:            clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				addOnClient:              addonfake.NewSimpleClientset(),
+				addOnLister:              addoninformers.NewSharedInformerFactory(addonfake.NewSimpleClientset(), time.Minute*10).Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				cache:                    resourceapply.NewResourceCache(),
+				rbacPolicyProvider:       DefaultRBACPolicyProvider,
+				eventRecorder:            eventstesting.NewTestingEventRecorder(t),
+				namespacePolicyLister:    kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+				namespacePolicyNamespace: "open-cluster-management-hub",
+				namespacePolicyName:      "namespace-policy",
+				namespacePolicyListers: namespacePolicyListers{
+					namespaceLister:     kubeInformerFactory.Core().V1().Namespaces().Lister(),
+					resourceQuotaLister: kubeInformerFactory.Core().V1().ResourceQuotas().Lister(),
+					limitRangeLister:    kubeInformerFactory.Core().V1().LimitRanges().Lister(),
+				},
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+const testNamespacePolicy = `labels:
+  pod-security.kubernetes.io/enforce: baseline
+annotations:
+  open-cluster-management.io/cluster: "{{ .ManagedClusterName }}"
+resourceQuota:
+  hard:
+    pods: "10"
+limitRange:
+  limits:
+  - type: Container
+    default:
+      cpu: 100m
+`
+
+func newNamespacePolicyConfigMap(policy string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "open-cluster-management-hub",
+			Name:      "namespace-policy",
+		},
+		Data: map[string]string{
+			NamespacePolicyKey: policy,
+		},
+	}
+}
+
+func TestSyncManagedClusterWithOIDCUser(t *testing.T) {
+	prefix := ""
+	cases := []struct {
+		name         string
+		prefix       *string
+		annotations  map[string]string
+		expectedUser string
+	}{
+		{
+			name:   "oidc is disabled",
+			prefix: nil,
+			annotations: map[string]string{
+				helpers.ManagedClusterOIDCIssuerAnnotation:  "https://issuer.example.com",
+				helpers.ManagedClusterOIDCSubjectAnnotation: "system:serviceaccount:open-cluster-management-agent:klusterlet",
+			},
+		},
+		{
+			name:   "cluster without oidc annotations",
+			prefix: &prefix,
+		},
+		{
+			name:   "bind the oidc user",
+			prefix: &prefix,
+			annotations: map[string]string{
+				helpers.ManagedClusterOIDCIssuerAnnotation:  "https://issuer.example.com",
+				helpers.ManagedClusterOIDCSubjectAnnotation: "system:serviceaccount:open-cluster-management-agent:klusterlet",
+			},
+			expectedUser: "https://issuer.example.com#system:serviceaccount:open-cluster-management-agent:klusterlet",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewAcceptedManagedCluster()
+			cluster.Annotations = c.annotations
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+			kubeClient := kubefake.NewSimpleClientset()
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := managedClusterController{
+				kubeClient:         kubeClient,
+				clusterClient:      clusterClient,
+				clusterLister:      clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				addOnClient:        addonfake.NewSimpleClientset(),
+				addOnLister:        addoninformers.NewSharedInformerFactory(addonfake.NewSimpleClientset(), time.Minute*10).Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				cache:              resourceapply.NewResourceCache(),
+				rbacPolicyProvider: DefaultRBACPolicyProvider,
+				eventRecorder:      eventstesting.NewTestingEventRecorder(t),
+				oidcUsernamePrefix: c.prefix,
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			bindings := 0
+			for _, action := range kubeClient.Actions() {
+				createAction, ok := action.(clienttesting.CreateAction)
+				if !ok {
+					continue
+				}
+				var subjects []rbacv1.Subject
+				switch obj := createAction.GetObject().(type) {
+				case *rbacv1.ClusterRoleBinding:
+					subjects = obj.Subjects
+				case *rbacv1.RoleBinding:
+					subjects = obj.Subjects
+				default:
+					continue
+				}
+				bindings++
+
+				user := ""
+				for _, subject := range subjects {
+					if subject.Kind == rbacv1.UserKind {
+						user = subject.Name
+					}
+				}
+				if user != c.expectedUser {
+					t.Errorf("expected user %q in the subjects of %s, but got %v", c.expectedUser, action.GetResource().Resource, subjects)
+				}
+			}
+			if bindings != 3 {
+				t.Errorf("expected 3 bindings to be created, but got %d", bindings)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package managedcluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	// NamespacePolicyKey is the key of the namespace policy in the data of the namespace policy ConfigMap
+	NamespacePolicyKey = "policy.yaml"
+
+	// NamespacePolicyResourceName is the name of the resource quota and the limit range created in the
+	// cluster namespace by the namespace policy
+	NamespacePolicyResourceName = "managed-cluster-namespace-policy"
+)
+
+// NamespacePolicy is the policy to provision the namespace of an accepted managed cluster on the hub. It is
+// loaded from a ConfigMap on the hub. The values of the labels and annotations are go templates, and the
+// "{{ .ManagedClusterName }}" in them is replaced with the name of the managed cluster.
+type NamespacePolicy struct {
+	// Labels are added to the cluster namespace, e.g. the pod security admission levels. The labels removed
+	// from the policy are kept on the namespace.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are added to the cluster namespace.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// ResourceQuota is the spec of the resource quota created in the cluster namespace, the resource quota is
+	// removed if it is not set.
+	ResourceQuota *corev1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+	// LimitRange is the spec of the limit range created in the cluster namespace, the limit range is removed
+	// if it is not set.
+	LimitRange *corev1.LimitRangeSpec `json:"limitRange,omitempty"`
+}
+
+// LoadNamespacePolicy loads the namespace policy from the yaml or json data, and verifies the templates in it.
+func LoadNamespacePolicy(data []byte) (*NamespacePolicy, error) {
+	policy := &NamespacePolicy{}
+	// an empty policy does nothing
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096).Decode(policy); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("unable to decode the namespace policy: %w", err)
+	}
+
+	// render the templates with an empty cluster name to verify them
+	if _, err := renderValues(policy.Labels, ""); err != nil {
+		return nil, err
+	}
+	if _, err := renderValues(policy.Annotations, ""); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// namespacePolicyListers are the listers of the resources provisioned by the namespace policy
+type namespacePolicyListers struct {
+	namespaceLister     corev1listers.NamespaceLister
+	resourceQuotaLister corev1listers.ResourceQuotaLister
+	limitRangeLister    corev1listers.LimitRangeLister
+}
+
+// apply provisions the namespace of the managed cluster with the policy. The provisioned resources are read from the
+// listers, so they are only written once they are changed.
+func (p *NamespacePolicy) apply(ctx context.Context, kubeClient kubernetes.Interface, listers namespacePolicyListers,
+	recorder events.Recorder, managedClusterName string) error {
+	labels, err := renderValues(p.Labels, managedClusterName)
+	if err != nil {
+		return err
+	}
+	annotations, err := renderValues(p.Annotations, managedClusterName)
+	if err != nil {
+		return err
+	}
+
+	// the labels and annotations are merged into the existing ones of the namespace
+	namespace, err := listers.namespaceLister.Get(managedClusterName)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err != nil || !containsAll(namespace.Labels, labels) || !containsAll(namespace.Annotations, annotations) {
+		if _, _, err := resourceapply.ApplyNamespace(ctx, kubeClient.CoreV1(), recorder, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        managedClusterName,
+				Labels:      labels,
+				Annotations: annotations,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := applyResourceQuota(ctx, kubeClient, listers.resourceQuotaLister, recorder, managedClusterName, p.ResourceQuota); err != nil {
+		return err
+	}
+	return applyLimitRange(ctx, kubeClient, listers.limitRangeLister, recorder, managedClusterName, p.LimitRange)
+}
+
+// containsAll returns true if all of the expected keys and values are in the map
+func containsAll(values, expected map[string]string) bool {
+	for key, value := range expected {
+		if current, ok := values[key]; !ok || current != value {
+			return false
+		}
+	}
+	return true
+}
+
+// renderValues renders the values of the map as go templates with the managed cluster name
+func renderValues(values map[string]string, managedClusterName string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	config := struct {
+		ManagedClusterName string
+	}{
+		ManagedClusterName: managedClusterName,
+	}
+
+	rendered := map[string]string{}
+	for key, value := range values {
+		tmpl, err := template.New(key).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the template of %q in the namespace policy: %w", key, err)
+		}
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, config); err != nil {
+			return nil, fmt.Errorf("unable to render the template of %q in the namespace policy: %w", key, err)
+		}
+		rendered[key] = buf.String()
+	}
+	return rendered, nil
+}
+
+func applyResourceQuota(ctx context.Context, kubeClient kubernetes.Interface, lister corev1listers.ResourceQuotaLister,
+	recorder events.Recorder, namespace string, spec *corev1.ResourceQuotaSpec) error {
+	client := kubeClient.CoreV1().ResourceQuotas(namespace)
+	existing, err := lister.ResourceQuotas(namespace).Get(NamespacePolicyResourceName)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if spec == nil {
+		if err != nil {
+			// the resource quota does not exist
+			return nil
+		}
+		err := client.Delete(ctx, NamespacePolicyResourceName, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err == nil {
+			recorder.Eventf("ResourceQuotaDeleted", "resource quota %s/%s is deleted", namespace, NamespacePolicyResourceName)
+		}
+		return err
+	}
+
+	if errors.IsNotFound(err) {
+		_, err := client.Create(ctx, &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: NamespacePolicyResourceName},
+			Spec:       *spec,
+		}, metav1.CreateOptions{})
+		if err == nil {
+			recorder.Eventf("ResourceQuotaCreated", "resource quota %s/%s is created", namespace, NamespacePolicyResourceName)
+		}
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(existing.Spec, *spec) {
+		return nil
+	}
+	existing = existing.DeepCopy()
+	existing.Spec = *spec
+	if _, err := client.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	recorder.Eventf("ResourceQuotaUpdated", "resource quota %s/%s is updated", namespace, NamespacePolicyResourceName)
+	return nil
+}
+
+func applyLimitRange(ctx context.Context, kubeClient kubernetes.Interface, lister corev1listers.LimitRangeLister,
+	recorder events.Recorder, namespace string, spec *corev1.LimitRangeSpec) error {
+	client := kubeClient.CoreV1().LimitRanges(namespace)
+	existing, err := lister.LimitRanges(namespace).Get(NamespacePolicyResourceName)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if spec == nil {
+		if err != nil {
+			// the limit range does not exist
+			return nil
+		}
+		err := client.Delete(ctx, NamespacePolicyResourceName, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err == nil {
+			recorder.Eventf("LimitRangeDeleted", "limit range %s/%s is deleted", namespace, NamespacePolicyResourceName)
+		}
+		return err
+	}
+
+	if errors.IsNotFound(err) {
+		_, err := client.Create(ctx, &corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: NamespacePolicyResourceName},
+			Spec:       *spec,
+		}, metav1.CreateOptions{})
+		if err == nil {
+			recorder.Eventf("LimitRangeCreated", "limit range %s/%s is created", namespace, NamespacePolicyResourceName)
+		}
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(existing.Spec, *spec) {
+		return nil
+	}
+	existing = existing.DeepCopy()
+	existing.Spec = *spec
+	if _, err := client.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	recorder.Eventf("LimitRangeUpdated", "limit range %s/%s is updated", namespace, NamespacePolicyResourceName)
+	return nil
+}
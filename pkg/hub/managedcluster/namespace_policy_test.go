@@ -0,0 +1,65 @@
+package managedcluster
+
+import (
+	"reflect"
+	"testing"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestLoadNamespacePolicy(t *testing.T) {
+	cases := []struct {
+		name        string
+		data        string
+		expectedErr string
+	}{
+		{
+			name: "empty policy",
+		},
+		{
+			name: "valid policy",
+			data: testNamespacePolicy,
+		},
+		{
+			name: "invalid policy",
+			data: "labels: invalid",
+			expectedErr: "unable to decode the namespace policy: error unmarshaling JSON: while decoding JSON: " +
+				"json: cannot unmarshal string into Go struct field .labels of type map[string]string",
+		},
+		{
+			name:        "invalid template",
+			data:        "labels:\n  cluster: \"{{ .ManagedClusterName \"\n",
+			expectedErr: "unable to parse the template of \"cluster\" in the namespace policy: template: cluster:1: unclosed action",
+		},
+		{
+			name:        "unknown template field",
+			data:        "labels:\n  cluster: \"{{ .ClusterLabels }}\"\n",
+			expectedErr: "unable to render the template of \"cluster\" in the namespace policy: template: cluster:1:3: executing \"cluster\" at <.ClusterLabels>: can't evaluate field ClusterLabels in type struct { ManagedClusterName string }",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := LoadNamespacePolicy([]byte(c.data))
+			testinghelpers.AssertError(t, err, c.expectedErr)
+		})
+	}
+}
+
+func TestRenderValues(t *testing.T) {
+	rendered, err := renderValues(map[string]string{
+		"static":  "baseline",
+		"cluster": "cluster-{{ .ManagedClusterName }}",
+	}, "cluster1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"static":  "baseline",
+		"cluster": "cluster-cluster1",
+	}
+	if !reflect.DeepEqual(rendered, expected) {
+		t.Errorf("expected %v, but got %v", expected, rendered)
+	}
+}
@@ -0,0 +1,38 @@
+package managedcluster
+
+import (
+	"io/fs"
+
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+
+	"open-cluster-management.io/registration/pkg/helpers"
+)
+
+// RBACPolicyProvider provides the RBAC resources granted to the agents of the accepted clusters on the hub. The
+// downstream distributions can substitute the default provider to grant a restricted set of roles, e.g. read-only
+// work, without patching the controller.
+type RBACPolicyProvider interface {
+	// Manifests returns the asset func and the files of the RBAC manifests of the cluster. The resources are applied
+	// once the cluster is accepted, and removed once it is denied or deleted. The user is bound with the roles besides
+	// the group of the cluster if it is not empty.
+	Manifests(managedClusterName, managedClusterUser string) (resourceapply.AssetFunc, []string)
+}
+
+// DefaultRBACPolicyProvider grants the registration and work roles of the cluster, and binds the work role in the
+// cluster namespace.
+var DefaultRBACPolicyProvider = NewRBACPolicyProvider(manifestFiles, staticFiles...)
+
+type templateRBACPolicyProvider struct {
+	fsys  fs.ReadFileFS
+	files []string
+}
+
+// NewRBACPolicyProvider returns a provider of the RBAC manifests in the files of the fsys. The manifests are
+// templates rendered with the ManagedClusterName, and the ManagedClusterUser which is a quoted string or empty.
+func NewRBACPolicyProvider(fsys fs.ReadFileFS, files ...string) RBACPolicyProvider {
+	return &templateRBACPolicyProvider{fsys: fsys, files: files}
+}
+
+func (p *templateRBACPolicyProvider) Manifests(managedClusterName, managedClusterUser string) (resourceapply.AssetFunc, []string) {
+	return helpers.ManagedClusterAssetFnWithUser(p.fsys, managedClusterName, managedClusterUser), p.files
+}
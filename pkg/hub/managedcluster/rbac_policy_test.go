@@ -0,0 +1,111 @@
+package managedcluster
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+const readOnlyWorkRoleBinding = `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: open-cluster-management:managedcluster:{{ .ManagedClusterName }}:work-readonly
+  namespace: "{{ .ManagedClusterName }}"
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: open-cluster-management:managedcluster:work-readonly
+subjects:
+- kind: Group
+  apiGroup: rbac.authorization.k8s.io
+  name: system:open-cluster-management:{{ .ManagedClusterName }}
+`
+
+func TestSyncManagedClusterWithRBACPolicyProvider(t *testing.T) {
+	provider := NewRBACPolicyProvider(fstest.MapFS{
+		"work-readonly-rolebinding.yaml": &fstest.MapFile{Data: []byte(readOnlyWorkRoleBinding)},
+	}, "work-readonly-rolebinding.yaml")
+
+	cases := []struct {
+		name            string
+		cluster         runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:    "apply the rbac resources of the provider",
+			cluster: testinghelpers.NewAcceptedManagedCluster(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				roleBindings := []string{}
+				for _, action := range actions {
+					createAction, ok := action.(clienttesting.CreateAction)
+					if !ok {
+						continue
+					}
+					switch obj := createAction.GetObject().(type) {
+					case *rbacv1.RoleBinding:
+						roleBindings = append(roleBindings, obj.Name)
+					case *rbacv1.ClusterRole, *rbacv1.ClusterRoleBinding:
+						t.Errorf("unexpected %s is created", action.GetResource().Resource)
+					}
+				}
+				if len(roleBindings) != 1 || roleBindings[0] != "open-cluster-management:managedcluster:testmanagedcluster:work-readonly" {
+					t.Errorf("expected the read-only work rolebinding to be created, but got %v", roleBindings)
+				}
+			},
+		},
+		{
+			name:    "remove the rbac resources of the provider",
+			cluster: testinghelpers.NewDeniedManagedCluster(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "delete")
+				deleteAction := actions[0].(clienttesting.DeleteAction)
+				if deleteAction.GetResource().Resource != "rolebindings" ||
+					deleteAction.GetName() != "open-cluster-management:managedcluster:testmanagedcluster:work-readonly" {
+					t.Errorf("expected the read-only work rolebinding to be deleted, but got %v", deleteAction)
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			kubeClient := kubefake.NewSimpleClientset()
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := managedClusterController{
+				kubeClient:         kubeClient,
+				clusterClient:      clusterClient,
+				clusterLister:      clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				addOnClient:        addonfake.NewSimpleClientset(),
+				addOnLister:        addoninformers.NewSharedInformerFactory(addonfake.NewSimpleClientset(), time.Minute*10).Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				cache:              resourceapply.NewResourceCache(),
+				rbacPolicyProvider: provider,
+				eventRecorder:      eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
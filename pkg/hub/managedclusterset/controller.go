@@ -10,6 +10,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -23,6 +24,8 @@ import (
 	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
 	v1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
 )
 
 // managedClusterSetController reconciles instances of ManagedClusterSet on the hub.
@@ -63,7 +66,7 @@ func NewManagedClusterSetController(
 			c.enqueueClusterClusterSet(cluster)
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
-			// only need handle label update
+			// only need handle label and availability update
 			oldCluster, ok := oldObj.(*v1.ManagedCluster)
 			if !ok {
 				utilruntime.HandleError(fmt.Errorf("error to get object: %v", oldObj))
@@ -74,10 +77,12 @@ func NewManagedClusterSetController(
 				utilruntime.HandleError(fmt.Errorf("error to get object: %v", newObj))
 				return
 			}
-			if reflect.DeepEqual(oldCluster.Labels, newCluster.Labels) {
+			if reflect.DeepEqual(oldCluster.Labels, newCluster.Labels) && !isAvailabilityChanged(oldCluster, newCluster) {
 				return
 			}
 			c.enqueueUpdateClusterClusterSet(oldCluster, newCluster)
+			// the member summary of the clustersets which still select the cluster should be refreshed as well
+			c.enqueueClusterClusterSet(newCluster)
 		},
 		DeleteFunc: func(obj interface{}) {
 			switch t := obj.(type) {
@@ -103,6 +108,40 @@ func NewManagedClusterSetController(
 		utilruntime.HandleError(err)
 	}
 
+	_, err = clusterSetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueExclusiveClusterSets(obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// only need handle the change of the selector and the exclusive annotation
+			oldClusterSet, ok := oldObj.(*clusterv1beta2.ManagedClusterSet)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("error to get object: %v", oldObj))
+				return
+			}
+			newClusterSet, ok := newObj.(*clusterv1beta2.ManagedClusterSet)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("error to get object: %v", newObj))
+				return
+			}
+			if reflect.DeepEqual(oldClusterSet.Spec, newClusterSet.Spec) &&
+				isExclusiveClusterSet(oldClusterSet) == isExclusiveClusterSet(newClusterSet) {
+				return
+			}
+			c.enqueueExclusiveClusterSets(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			c.enqueueExclusiveClusterSets(obj)
+		},
+	})
+
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+
 	return factory.New().
 		WithSyncContext(syncCtx).
 		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
@@ -110,7 +149,7 @@ func NewManagedClusterSetController(
 			return accessor.GetName()
 		}, clusterSetInformer.Informer()).
 		WithBareInformers(clusterInformer.Informer()).
-		WithSync(c.sync).
+		WithSync(logging.SyncWithLogger("ManagedClusterSetController", c.sync)).
 		ToController("ManagedClusterSetController", recorder)
 }
 
@@ -119,7 +158,7 @@ func (c *managedClusterSetController) sync(ctx context.Context, syncCtx factory.
 	if len(clusterSetName) == 0 {
 		return nil
 	}
-	klog.V(4).Infof("Reconciling ManagedClusterSet %s", clusterSetName)
+	klog.FromContext(ctx).V(4).Info("Reconciling ManagedClusterSet")
 	clusterSet, err := c.clusterSetLister.Get(clusterSetName)
 	if errors.IsNotFound(err) {
 		// cluster set not found, could have been deleted, do nothing.
@@ -148,6 +187,35 @@ func (c *managedClusterSetController) syncClusterSet(ctx context.Context, origin
 	if err != nil {
 		return err
 	}
+
+	var updateFuncs []helpers.UpdateManagedClusterSetStatusFunc
+	if isExclusiveClusterSet(clusterSet) {
+		// a cluster selected by multiple exclusive clustersets only belongs to one of them
+		var conflictMessage string
+		clusters, conflictMessage, err = c.resolveExclusiveMembers(clusterSet, clusters)
+		if err != nil {
+			return err
+		}
+
+		conflictCondition := metav1.Condition{
+			Type:    ManagedClusterSetConditionMembershipConflict,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NoConflict",
+			Message: "No ManagedCluster is selected by other exclusive ManagedClusterSets",
+		}
+		if len(conflictMessage) > 0 {
+			conflictCondition.Status = metav1.ConditionTrue
+			conflictCondition.Reason = "ClustersConflicted"
+			conflictCondition.Message = conflictMessage
+		}
+		updateFuncs = append(updateFuncs, setConditionFn(clusterSet.Generation, conflictCondition))
+	} else {
+		updateFuncs = append(updateFuncs, func(status *clusterv1beta2.ManagedClusterSetStatus) error {
+			meta.RemoveStatusCondition(&status.Conditions, ManagedClusterSetConditionMembershipConflict)
+			return nil
+		})
+	}
+
 	count := len(clusters)
 	// update clusterset status
 	emptyCondition := metav1.Condition{
@@ -162,14 +230,21 @@ func (c *managedClusterSetController) syncClusterSet(ctx context.Context, origin
 		emptyCondition.Reason = "ClustersSelected"
 		emptyCondition.Message = fmt.Sprintf("%d ManagedClusters selected", count)
 	}
-	meta.SetStatusCondition(&clusterSet.Status.Conditions, emptyCondition)
+	updateFuncs = append(updateFuncs,
+		setConditionFn(clusterSet.Generation, emptyCondition),
+		setConditionFn(clusterSet.Generation, newMembersAvailableCondition(summarizeMembers(clusters))))
 
 	// skip update if cluster set status does not change
+	for _, update := range updateFuncs {
+		if err := update(&clusterSet.Status); err != nil {
+			return err
+		}
+	}
 	if reflect.DeepEqual(clusterSet.Status.Conditions, originalClusterSet.Status.Conditions) {
 		return nil
 	}
 
-	_, err = c.clusterClient.ClusterV1beta2().ManagedClusterSets().UpdateStatus(ctx, clusterSet, metav1.UpdateOptions{})
+	_, _, err = helpers.UpdateManagedClusterSetStatus(ctx, c.clusterClient, clusterSet.Name, updateFuncs...)
 	if err != nil {
 		return fmt.Errorf("failed to update status of ManagedClusterSet %q: %w", clusterSet.Name, err)
 	}
@@ -177,6 +252,16 @@ func (c *managedClusterSetController) syncClusterSet(ctx context.Context, origin
 	return nil
 }
 
+// setConditionFn returns the update func setting the condition of the cluster set, the condition is observed with
+// the generation of the cluster set it is computed from, so an unchanged condition is not updated.
+func setConditionFn(generation int64, cond metav1.Condition) helpers.UpdateManagedClusterSetStatusFunc {
+	cond.ObservedGeneration = generation
+	return func(status *clusterv1beta2.ManagedClusterSetStatus) error {
+		meta.SetStatusCondition(&status.Conditions, cond)
+		return nil
+	}
+}
+
 // enqueueClusterClusterSet enqueue a cluster related clusterset
 func (c *managedClusterSetController) enqueueClusterClusterSet(cluster *v1.ManagedCluster) {
 	clusterSets, err := clusterv1beta2.GetClusterSetsOfCluster(cluster, c.clusterSetLister)
@@ -189,8 +274,34 @@ func (c *managedClusterSetController) enqueueClusterClusterSet(cluster *v1.Manag
 	}
 }
 
+// enqueueExclusiveClusterSets enqueues all exclusive clustersets once an exclusive clusterset is changed, because
+// the membership of exclusive clustersets depends on each other.
+func (c *managedClusterSetController) enqueueExclusiveClusterSets(obj interface{}) {
+	clusterSet, ok := obj.(*clusterv1beta2.ManagedClusterSet)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("error to get object: %v", obj))
+		return
+	}
+	if !isExclusiveClusterSet(clusterSet) {
+		return
+	}
+
+	clusterSets, err := c.clusterSetLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("error to list ManagedClusterSets. Error %v", err))
+		return
+	}
+	for _, set := range clusterSets {
+		if set.Name != clusterSet.Name && isExclusiveClusterSet(set) {
+			c.queue.Add(set.Name)
+		}
+	}
+}
+
 // enqueueUpdateClusterClusterSet get the oldCluster related clustersets and newCluster related clustersets,
-// then enqueue the diff clustersets(added clustersets and removed clustersets)
+// then enqueue the diff clustersets(added clustersets and removed clustersets). The exclusive clustersets which
+// claim the cluster before or after the update are enqueued as well, since the members resolved by each of them
+// depend on the other exclusive clustersets of the cluster.
 func (c *managedClusterSetController) enqueueUpdateClusterClusterSet(oldCluster, newCluster *v1.ManagedCluster) {
 	oldClusterSets, err := clusterv1beta2.GetClusterSetsOfCluster(oldCluster, c.clusterSetLister)
 	if err != nil {
@@ -207,6 +318,21 @@ func (c *managedClusterSetController) enqueueUpdateClusterClusterSet(oldCluster,
 	for diffSet := range diffClusterSets {
 		c.queue.Add(diffSet)
 	}
+	for _, clusterSet := range append(oldClusterSets, newClusterSets...) {
+		if isExclusiveClusterSet(clusterSet) {
+			c.queue.Add(clusterSet.Name)
+		}
+	}
+}
+
+// isAvailabilityChanged returns true if the available condition status of the cluster is changed
+func isAvailabilityChanged(oldCluster, newCluster *v1.ManagedCluster) bool {
+	oldCond := meta.FindStatusCondition(oldCluster.Status.Conditions, v1.ManagedClusterConditionAvailable)
+	newCond := meta.FindStatusCondition(newCluster.Status.Conditions, v1.ManagedClusterConditionAvailable)
+	if oldCond == nil || newCond == nil {
+		return oldCond != newCond
+	}
+	return oldCond.Status != newCond.Status
 }
 
 // getDiffClusterSetsNames return the diff clustersets names
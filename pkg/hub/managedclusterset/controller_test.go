@@ -359,6 +359,18 @@ func TestEnqueueUpdateClusterClusterSet(t *testing.T) {
 			oldCluster:     newClusterWithLabel("c1", map[string]string{clusterv1beta2.ClusterSetLabel: "mcs2"}),
 			expectQueueLen: 1,
 		},
+		{
+			name: "update a cluster claimed by exclusive clustersets",
+			existingClusterSets: []*clusterv1beta2.ManagedClusterSet{
+				newManagedClusterSet("mcs1"),
+				newManagedClusterSet("mcs2"),
+				newExclusiveLabelSelectorClusterSet("mcs3", time.Now(), map[string]string{"vendor": "openShift"}),
+			},
+			oldCluster: newClusterWithLabel("c1", map[string]string{clusterv1beta2.ClusterSetLabel: "mcs1", "vendor": "openShift"}),
+			newCluster: newClusterWithLabel("c1", map[string]string{
+				clusterv1beta2.ClusterSetLabel: "mcs1", "vendor": "openShift", "env": "prod"}),
+			expectQueueLen: 2,
+		},
 	}
 
 	for _, c := range cases {
@@ -0,0 +1,135 @@
+package managedclusterset
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterinformerv1beta2 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta2"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+// defaultClusterSetLabelController adds the default clusterset label to the managed clusters which have no
+// clusterset label, so that they become the members of the default clusterset. The mutating webhook sets the
+// label on admission as well, this controller covers the clusters which are created before the DefaultClusterSet
+// feature is enabled.
+type defaultClusterSetLabelController struct {
+	clusterClient    clientset.Interface
+	clusterLister    clusterlisterv1.ManagedClusterLister
+	clusterSetLister clusterlisterv1beta2.ManagedClusterSetLister
+	eventRecorder    events.Recorder
+}
+
+// NewDefaultClusterSetLabelController creates a new default clusterset label controller
+func NewDefaultClusterSetLabelController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	clusterSetInformer clusterinformerv1beta2.ManagedClusterSetInformer,
+	recorder events.Recorder) factory.Controller {
+
+	c := &defaultClusterSetLabelController{
+		clusterClient:    clusterClient,
+		clusterLister:    clusterInformer.Lister(),
+		clusterSetLister: clusterSetInformer.Lister(),
+		eventRecorder:    recorder.WithComponentSuffix("default-clusterset-label-controller"),
+	}
+
+	return factory.New().
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetName()
+			},
+			func(obj interface{}) bool {
+				metaObj, ok := obj.(metav1.ObjectMetaAccessor)
+				if !ok {
+					return false
+				}
+				// only handle the clusters without clusterset label
+				return len(metaObj.GetObjectMeta().GetLabels()[clusterv1beta2.ClusterSetLabel]) == 0
+			},
+			clusterInformer.Informer(),
+		).
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				// resync all clusters once the default clusterset is changed
+				return factory.DefaultQueueKey
+			},
+			func(obj interface{}) bool {
+				metaObj, ok := obj.(metav1.ObjectMetaAccessor)
+				if !ok {
+					return false
+				}
+				return metaObj.GetObjectMeta().GetName() == DefaultManagedClusterSetName
+			},
+			clusterSetInformer.Informer(),
+		).
+		WithSync(logging.SyncWithLogger("DefaultClusterSetLabelController", c.sync)).
+		ToController("DefaultClusterSetLabelController", recorder)
+}
+
+func (c *defaultClusterSetLabelController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	if clusterName == factory.DefaultQueueKey {
+		clusters, err := c.clusterLister.List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		for _, cluster := range clusters {
+			if len(cluster.Labels[clusterv1beta2.ClusterSetLabel]) == 0 {
+				syncCtx.Queue().Add(cluster.Name)
+			}
+		}
+		return nil
+	}
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling default clusterset label of ManagedCluster")
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// cluster is deleted
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// no work if cluster is deleting or it already has a clusterset label
+	if !cluster.DeletionTimestamp.IsZero() || len(cluster.Labels[clusterv1beta2.ClusterSetLabel]) > 0 {
+		return nil
+	}
+
+	defaultClusterSet, err := c.clusterSetLister.Get(DefaultManagedClusterSetName)
+	switch {
+	case errors.IsNotFound(err):
+		// wait for the default clusterset to be created
+		return nil
+	case err != nil:
+		return err
+	case hasAnnotation(defaultClusterSet, autoUpdateAnnotation, "false"):
+		// the default clusterset is disabled by user, do not add the label
+		return nil
+	}
+
+	cluster = cluster.DeepCopy()
+	if cluster.Labels == nil {
+		cluster.Labels = map[string]string{}
+	}
+	cluster.Labels[clusterv1beta2.ClusterSetLabel] = DefaultManagedClusterSetName
+
+	if _, err := c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, cluster, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	c.eventRecorder.Eventf("DefaultClusterSetLabelAdded", "Add ManagedCluster %q to the DefaultManagedClusterSet", clusterName)
+	return nil
+}
@@ -0,0 +1,95 @@
+package managedclusterset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSyncDefaultClusterSetLabel(t *testing.T) {
+	cases := []struct {
+		name               string
+		existingCluster    *clusterv1.ManagedCluster
+		existingClusterSet *clusterv1beta2.ManagedClusterSet
+		validateActions    func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:               "add default clusterset label",
+			existingCluster:    newManagedCluster("cluster1", map[string]string{"vendor": "openShift"}),
+			existingClusterSet: newDefaultManagedClusterSet(DefaultManagedClusterSetName, DefaultManagedClusterSet.Spec, false),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				cluster := actions[0].(clienttesting.UpdateAction).GetObject().(*clusterv1.ManagedCluster)
+				if cluster.Labels[clusterv1beta2.ClusterSetLabel] != DefaultManagedClusterSetName {
+					t.Errorf("expected default clusterset label, but got %v", cluster.Labels)
+				}
+				if cluster.Labels["vendor"] != "openShift" {
+					t.Errorf("expected existing labels are kept, but got %v", cluster.Labels)
+				}
+			},
+		},
+		{
+			name: "cluster has clusterset label",
+			existingCluster: newManagedCluster("cluster1", map[string]string{
+				clusterv1beta2.ClusterSetLabel: "mcs1",
+			}),
+			existingClusterSet: newDefaultManagedClusterSet(DefaultManagedClusterSetName, DefaultManagedClusterSet.Spec, false),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:            "default clusterset does not exist",
+			existingCluster: newManagedCluster("cluster1", nil),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:               "default clusterset is disabled",
+			existingCluster:    newManagedCluster("cluster1", nil),
+			existingClusterSet: newDefaultManagedClusterSetWithAnnotation(DefaultManagedClusterSetName, autoUpdateAnnotation, "false", DefaultManagedClusterSet.Spec, false),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objects := []runtime.Object{c.existingCluster}
+			clusterClient := clusterfake.NewSimpleClientset(objects...)
+			informerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 5*time.Minute)
+			if err := informerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.existingCluster); err != nil {
+				t.Fatal(err)
+			}
+			if c.existingClusterSet != nil {
+				if err := informerFactory.Cluster().V1beta2().ManagedClusterSets().Informer().GetStore().Add(c.existingClusterSet); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := defaultClusterSetLabelController{
+				clusterClient:    clusterClient,
+				clusterLister:    informerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterSetLister: informerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(),
+				eventRecorder:    eventstesting.NewTestingEventRecorder(t),
+			}
+
+			syncErr := ctrl.sync(context.Background(), testinghelpers.NewFakeSyncContext(t, c.existingCluster.Name))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
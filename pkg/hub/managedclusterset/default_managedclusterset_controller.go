@@ -17,6 +17,7 @@ import (
 	clusterinformerv1beta2 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta2"
 	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
 	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"open-cluster-management.io/registration/pkg/logging"
 )
 
 const (
@@ -68,7 +69,7 @@ func NewDefaultManagedClusterSetController(
 			},
 			clusterSetInformer.Informer(),
 		).
-		WithSync(c.sync).
+		WithSync(logging.SyncWithLogger("DefaultManagedClusterSetController", c.sync)).
 		// use ResyncEvery to make sure:
 		// 1. create the default clusterset once controller is launched
 		// 2. the default clusterset be recreated once it is deleted for some reason
@@ -77,7 +78,7 @@ func NewDefaultManagedClusterSetController(
 }
 
 func (c *defaultManagedClusterSetController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
-	klog.V(4).Infof("Reconciling DefaultManagedClusterSet")
+	klog.FromContext(ctx).V(4).Info("Reconciling DefaultManagedClusterSet")
 
 	defaultClusterSet, err := c.clusterSetLister.Get(DefaultManagedClusterSetName)
 	if err != nil {
@@ -105,7 +106,7 @@ func (c *defaultManagedClusterSetController) syncDefaultClusterSet(ctx context.C
 
 	// if the annotation has set to disable, default clusterset controller will not work.
 	if hasAnnotation(defaultClusterSet, autoUpdateAnnotation, "false") {
-		klog.V(4).Info("DefaultManagedClusterSetDisabled", "The DefaultManagedClusterSet is disabled by user")
+		klog.FromContext(ctx).V(4).Info("The DefaultManagedClusterSet is disabled by user")
 		return nil
 	}
 
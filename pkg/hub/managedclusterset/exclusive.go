@@ -0,0 +1,131 @@
+package managedclusterset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
+	v1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+)
+
+const (
+	// ExclusiveClusterSetAnnotation marks a LabelSelector clusterset as exclusive. A managed cluster can only
+	// be the member of one exclusive clusterset, clustersets with ExclusiveClusterSetLabel selector type are
+	// always exclusive.
+	ExclusiveClusterSetAnnotation = "cluster.open-cluster-management.io/exclusive"
+
+	// ManagedClusterSetConditionMembershipConflict is the condition type of a clusterset which indicates whether
+	// some of the clusters selected by the clusterset are also selected by other exclusive clustersets.
+	ManagedClusterSetConditionMembershipConflict = "ClusterSetMembershipConflict"
+
+	// maxConflictsInMessage is the max number of the conflicted clusters listed in the condition message, so the
+	// message stays within the size limit of the condition message on a large fleet
+	maxConflictsInMessage = 10
+)
+
+// isExclusiveClusterSet returns true if a managed cluster selected by the clusterset cannot be the member of
+// other exclusive clustersets.
+func isExclusiveClusterSet(clusterSet *clusterv1beta2.ManagedClusterSet) bool {
+	switch clusterSet.Spec.ClusterSelector.SelectorType {
+	case "", clusterv1beta2.ExclusiveClusterSetLabel:
+		return true
+	case clusterv1beta2.LabelSelector:
+		return hasAnnotation(clusterSet, ExclusiveClusterSetAnnotation, "true")
+	}
+	return false
+}
+
+// getExclusiveClusterSetsOfCluster returns the exclusive clustersets which select the managed cluster, the
+// clustersets are sorted by the conflict policy, so the first one is the clusterset the cluster belongs to.
+//
+// The conflict policy is deterministic:
+//  1. a clusterset with ExclusiveClusterSetLabel selector type wins, since the cluster is labeled explicitly;
+//  2. then the clusterset which is created earliest wins;
+//  3. then the clusterset whose name is smallest in lexicographical order wins.
+func getExclusiveClusterSetsOfCluster(
+	cluster *v1.ManagedCluster,
+	clusterSetLister clusterlisterv1beta2.ManagedClusterSetLister) ([]*clusterv1beta2.ManagedClusterSet, error) {
+	clusterSets, err := clusterv1beta2.GetClusterSetsOfCluster(cluster, clusterSetLister)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusiveClusterSets := []*clusterv1beta2.ManagedClusterSet{}
+	for _, clusterSet := range clusterSets {
+		if !clusterSet.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if isExclusiveClusterSet(clusterSet) {
+			exclusiveClusterSets = append(exclusiveClusterSets, clusterSet)
+		}
+	}
+
+	sort.SliceStable(exclusiveClusterSets, func(i, j int) bool {
+		iLabeled := exclusiveClusterSets[i].Spec.ClusterSelector.SelectorType != clusterv1beta2.LabelSelector
+		jLabeled := exclusiveClusterSets[j].Spec.ClusterSelector.SelectorType != clusterv1beta2.LabelSelector
+		if iLabeled != jLabeled {
+			return iLabeled
+		}
+		iCreated := exclusiveClusterSets[i].CreationTimestamp
+		jCreated := exclusiveClusterSets[j].CreationTimestamp
+		if !iCreated.Equal(&jCreated) {
+			return iCreated.Before(&jCreated)
+		}
+		return exclusiveClusterSets[i].Name < exclusiveClusterSets[j].Name
+	})
+
+	return exclusiveClusterSets, nil
+}
+
+// resolveExclusiveMembers filters out the clusters which belong to other exclusive clustersets by the conflict
+// policy and returns the remaining members. It also returns a message describing the conflicted clusters, the
+// message is empty if there is no conflict.
+func (c *managedClusterSetController) resolveExclusiveMembers(
+	clusterSet *clusterv1beta2.ManagedClusterSet,
+	clusters []*v1.ManagedCluster) ([]*v1.ManagedCluster, string, error) {
+	members := []*v1.ManagedCluster{}
+	conflicts := []string{}
+	for _, cluster := range clusters {
+		exclusiveClusterSets, err := getExclusiveClusterSetsOfCluster(cluster, c.clusterSetLister)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if len(exclusiveClusterSets) == 0 || exclusiveClusterSets[0].Name == clusterSet.Name {
+			members = append(members, cluster)
+		}
+
+		if len(exclusiveClusterSets) <= 1 {
+			continue
+		}
+
+		others := sets.NewString()
+		for _, exclusiveClusterSet := range exclusiveClusterSets {
+			if exclusiveClusterSet.Name != clusterSet.Name {
+				others.Insert(exclusiveClusterSet.Name)
+			}
+		}
+		conflicts = append(conflicts, fmt.Sprintf("%s (also selected by %s, belongs to %s)",
+			cluster.Name, strings.Join(others.List(), ","), exclusiveClusterSets[0].Name))
+	}
+
+	if len(conflicts) == 0 {
+		return members, "", nil
+	}
+	return members, conflictMessage(conflicts), nil
+}
+
+// conflictMessage returns the message of the conflicted clusters, only the first maxConflictsInMessage clusters are
+// listed
+func conflictMessage(conflicts []string) string {
+	sort.Strings(conflicts)
+	message := "ManagedClusters selected by multiple exclusive ManagedClusterSets: "
+	if len(conflicts) <= maxConflictsInMessage {
+		return message + strings.Join(conflicts, "; ")
+	}
+	return fmt.Sprintf("%s%s and %d more", message, strings.Join(conflicts[:maxConflictsInMessage], "; "),
+		len(conflicts)-maxConflictsInMessage)
+}
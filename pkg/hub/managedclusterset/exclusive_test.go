@@ -0,0 +1,197 @@
+package managedclusterset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+)
+
+func TestSyncExclusiveClusterSet(t *testing.T) {
+	now := time.Now()
+	existingClusterSets := []*clusterv1beta2.ManagedClusterSet{
+		newExclusiveLabelSelectorClusterSet("mcs1", now, map[string]string{"vendor": "openShift"}),
+		newExclusiveLabelSelectorClusterSet("mcs2", now.Add(time.Minute), map[string]string{"vendor": "openShift"}),
+		newManagedClusterSet("mcs3"),
+	}
+	existingClusters := []*clusterv1.ManagedCluster{
+		newManagedCluster("cluster1", map[string]string{"vendor": "openShift"}),
+		newManagedCluster("cluster2", map[string]string{
+			clusterv1beta2.ClusterSetLabel: "mcs3",
+			"vendor":                       "openShift",
+		}),
+	}
+
+	cases := []struct {
+		name            string
+		clusterSet      string
+		expectCondition []metav1.Condition
+	}{
+		{
+			name:       "the earliest created clusterset wins",
+			clusterSet: "mcs1",
+			expectCondition: []metav1.Condition{
+				{
+					Type:    clusterv1beta2.ManagedClusterSetConditionEmpty,
+					Status:  metav1.ConditionFalse,
+					Reason:  "ClustersSelected",
+					Message: "1 ManagedClusters selected",
+				},
+				{
+					Type:   ManagedClusterSetConditionMembershipConflict,
+					Status: metav1.ConditionTrue,
+					Reason: "ClustersConflicted",
+					Message: "ManagedClusters selected by multiple exclusive ManagedClusterSets: " +
+						"cluster1 (also selected by mcs2, belongs to mcs1); cluster2 (also selected by mcs2,mcs3, belongs to mcs3)",
+				},
+			},
+		},
+		{
+			name:       "the later created clusterset loses",
+			clusterSet: "mcs2",
+			expectCondition: []metav1.Condition{
+				{
+					Type:    clusterv1beta2.ManagedClusterSetConditionEmpty,
+					Status:  metav1.ConditionTrue,
+					Reason:  "NoClusterMatched",
+					Message: "No ManagedCluster selected",
+				},
+				{
+					Type:   ManagedClusterSetConditionMembershipConflict,
+					Status: metav1.ConditionTrue,
+					Reason: "ClustersConflicted",
+					Message: "ManagedClusters selected by multiple exclusive ManagedClusterSets: " +
+						"cluster1 (also selected by mcs1, belongs to mcs1); cluster2 (also selected by mcs1,mcs3, belongs to mcs3)",
+				},
+			},
+		},
+		{
+			name:       "the clusterset with cluster set label wins",
+			clusterSet: "mcs3",
+			expectCondition: []metav1.Condition{
+				{
+					Type:    clusterv1beta2.ManagedClusterSetConditionEmpty,
+					Status:  metav1.ConditionFalse,
+					Reason:  "ClustersSelected",
+					Message: "1 ManagedClusters selected",
+				},
+				{
+					Type:   ManagedClusterSetConditionMembershipConflict,
+					Status: metav1.ConditionTrue,
+					Reason: "ClustersConflicted",
+					Message: "ManagedClusters selected by multiple exclusive ManagedClusterSets: " +
+						"cluster2 (also selected by mcs1,mcs2, belongs to mcs3)",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objects := []runtime.Object{}
+			for _, cluster := range existingClusters {
+				objects = append(objects, cluster)
+			}
+			for _, clusterSet := range existingClusterSets {
+				objects = append(objects, clusterSet)
+			}
+
+			clusterClient := clusterfake.NewSimpleClientset(objects...)
+			informerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 5*time.Minute)
+			for _, cluster := range existingClusters {
+				if err := informerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, clusterSet := range existingClusterSets {
+				if err := informerFactory.Cluster().V1beta2().ManagedClusterSets().Informer().GetStore().Add(clusterSet); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := managedClusterSetController{
+				clusterClient:    clusterClient,
+				clusterLister:    informerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterSetLister: informerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(),
+				eventRecorder:    eventstesting.NewTestingEventRecorder(t),
+			}
+
+			clusterSet, err := ctrl.clusterSetLister.Get(c.clusterSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ctrl.syncClusterSet(context.Background(), clusterSet); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			updatedSet, err := clusterClient.ClusterV1beta2().ManagedClusterSets().Get(context.Background(), c.clusterSet, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, cond := range c.expectCondition {
+				if !hasCondition(updatedSet.Status.Conditions, cond) {
+					t.Errorf("expected conditon:%v. is not found: %v", cond, updatedSet.Status.Conditions)
+				}
+			}
+		})
+	}
+}
+
+func TestIsExclusiveClusterSet(t *testing.T) {
+	labelSelectorClusterSet := newExclusiveLabelSelectorClusterSet("mcs1", time.Now(), nil)
+	labelSelectorClusterSet.Annotations = nil
+
+	if !isExclusiveClusterSet(newManagedClusterSet("mcs1")) {
+		t.Errorf("expected the legacy clusterset is exclusive")
+	}
+	if !isExclusiveClusterSet(newExclusiveLabelSelectorClusterSet("mcs1", time.Now(), nil)) {
+		t.Errorf("expected the annotated label selector clusterset is exclusive")
+	}
+	if isExclusiveClusterSet(labelSelectorClusterSet) {
+		t.Errorf("expected the label selector clusterset is not exclusive")
+	}
+}
+
+func TestConflictMessage(t *testing.T) {
+	conflicts := []string{}
+	for i := 0; i < maxConflictsInMessage; i++ {
+		conflicts = append(conflicts, fmt.Sprintf("cluster%02d (also selected by mcs2, belongs to mcs1)", i))
+	}
+	message := conflictMessage(conflicts)
+	if strings.Contains(message, "more") || strings.Count(message, "cluster") != maxConflictsInMessage {
+		t.Errorf("expected all of the conflicted clusters are listed, but got %q", message)
+	}
+
+	// only the first clusters are listed once there are too many conflicts
+	conflicts = append(conflicts, "cluster98 (also selected by mcs2, belongs to mcs1)",
+		"cluster99 (also selected by mcs2, belongs to mcs1)")
+	message = conflictMessage(conflicts)
+	if strings.Contains(message, "cluster98") || !strings.HasSuffix(message, " and 2 more") {
+		t.Errorf("expected the conflicted clusters are truncated, but got %q", message)
+	}
+}
+
+func newExclusiveLabelSelectorClusterSet(name string, created time.Time, matchLabels map[string]string) *clusterv1beta2.ManagedClusterSet {
+	return &clusterv1beta2.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+			Annotations:       map[string]string{ExclusiveClusterSetAnnotation: "true"},
+		},
+		Spec: clusterv1beta2.ManagedClusterSetSpec{
+			ClusterSelector: clusterv1beta2.ManagedClusterSelector{
+				SelectorType:  clusterv1beta2.LabelSelector,
+				LabelSelector: &metav1.LabelSelector{MatchLabels: matchLabels},
+			},
+		},
+	}
+}
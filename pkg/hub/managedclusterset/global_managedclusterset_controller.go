@@ -17,6 +17,7 @@ import (
 	clusterinformerv1beta2 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta2"
 	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
 	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"open-cluster-management.io/registration/pkg/logging"
 )
 
 const (
@@ -68,7 +69,7 @@ func NewGlobalManagedClusterSetController(
 			},
 			clusterSetInformer.Informer(),
 		).
-		WithSync(c.sync).
+		WithSync(logging.SyncWithLogger("GlobalManagedClusterSetController", c.sync)).
 		// use ResyncEvery to make sure:
 		// 1. create the global clusterset once controller is launched
 		// 2. the global clusterset be recreated once it is deleted for some reason
@@ -77,7 +78,7 @@ func NewGlobalManagedClusterSetController(
 }
 
 func (c *globalManagedClusterSetController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
-	klog.V(4).Infof("Reconciling GlobalManagedClusterSet")
+	klog.FromContext(ctx).V(4).Info("Reconciling GlobalManagedClusterSet")
 	globalClusterSet, err := c.clusterSetLister.Get(GlobalManagedClusterSetName)
 	// if the globalClusterSet not found, apply it.
 	if err != nil {
@@ -104,7 +105,7 @@ func (c *globalManagedClusterSetController) applyGlobalClusterSet(ctx context.Co
 
 	// if the annotation has set to disable, global clusterset controller will not work.
 	if hasAnnotation(globalClusterSet, autoUpdateAnnotation, "false") {
-		klog.V(4).Info("GlobalManagedClusterSetDisabled", "The GlobalManagedClusterSet is disabled by user")
+		klog.FromContext(ctx).V(4).Info("The GlobalManagedClusterSet is disabled by user")
 		return nil
 	}
 
@@ -0,0 +1,124 @@
+package managedclusterset
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+// clusterSetNamespaceLabelController labels the namespaces of the managed clusters with the clusterset label of the
+// clusters, so the namespace-scoped RBAC and network policies can be granted per clusterset with namespace selectors.
+// The label is changed or removed once the cluster is moved to another clusterset or removed from its clusterset.
+type clusterSetNamespaceLabelController struct {
+	kubeClient      kubernetes.Interface
+	clusterLister   clusterlisterv1.ManagedClusterLister
+	namespaceLister corev1listers.NamespaceLister
+	eventRecorder   events.Recorder
+}
+
+// NewClusterSetNamespaceLabelController creates a new clusterset namespace label controller
+func NewClusterSetNamespaceLabelController(
+	kubeClient kubernetes.Interface,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	namespaceInformer corev1informers.NamespaceInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &clusterSetNamespaceLabelController{
+		kubeClient:      kubeClient,
+		clusterLister:   clusterInformer.Lister(),
+		namespaceLister: namespaceInformer.Lister(),
+		eventRecorder:   recorder.WithComponentSuffix("clusterset-namespace-label-controller"),
+	}
+
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		// requeue the cluster once the label of its namespace is changed by others
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetName()
+			},
+			func(obj interface{}) bool {
+				accessor, err := meta.Accessor(obj)
+				if err != nil {
+					return false
+				}
+				_, err = c.clusterLister.Get(accessor.GetName())
+				return err == nil
+			},
+			namespaceInformer.Informer()).
+		WithSync(logging.SyncWithLogger("ClusterSetNamespaceLabelController", c.sync)).
+		ToController("ClusterSetNamespaceLabelController", recorder)
+}
+
+func (c *clusterSetNamespaceLabelController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling clusterset label of the ManagedCluster namespace")
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// the namespace is deleted with the cluster by the cleanup pipeline
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	namespace, err := c.namespaceLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// the namespace is created once the cluster is accepted
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	clusterSetName := cluster.Labels[clusterv1beta2.ClusterSetLabel]
+	current, labeled := namespace.Labels[clusterv1beta2.ClusterSetLabel]
+	if current == clusterSetName && labeled == (len(clusterSetName) > 0) {
+		return nil
+	}
+
+	// a null label removes the label of the namespace in the merge patch
+	var label interface{}
+	if len(clusterSetName) > 0 {
+		label = clusterSetName
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{clusterv1beta2.ClusterSetLabel: label},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.kubeClient.CoreV1().Namespaces().Patch(ctx, clusterName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return err
+	}
+
+	if len(clusterSetName) == 0 {
+		c.eventRecorder.Eventf("ClusterSetNamespaceLabelRemoved",
+			"Remove the clusterset label of the namespace of ManagedCluster %q", clusterName)
+		return nil
+	}
+	c.eventRecorder.Eventf("ClusterSetNamespaceLabelUpdated",
+		"Label the namespace of ManagedCluster %q with the ManagedClusterSet %q", clusterName, clusterSetName)
+	return nil
+}
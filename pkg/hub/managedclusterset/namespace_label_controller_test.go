@@ -0,0 +1,130 @@
+package managedclusterset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSyncClusterSetNamespaceLabel(t *testing.T) {
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		namespace       *corev1.Namespace
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "cluster is not found",
+			namespace:       newClusterNamespace("cluster1", nil),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "namespace is not found",
+			cluster:         newManagedCluster("cluster1", map[string]string{clusterv1beta2.ClusterSetLabel: "mcs1"}),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:      "label the namespace",
+			cluster:   newManagedCluster("cluster1", map[string]string{clusterv1beta2.ClusterSetLabel: "mcs1"}),
+			namespace: newClusterNamespace("cluster1", map[string]string{"team": "a"}),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertNamespaceLabelPatch(t, actions, "mcs1")
+			},
+		},
+		{
+			name:      "change the label of the namespace",
+			cluster:   newManagedCluster("cluster1", map[string]string{clusterv1beta2.ClusterSetLabel: "mcs2"}),
+			namespace: newClusterNamespace("cluster1", map[string]string{clusterv1beta2.ClusterSetLabel: "mcs1"}),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertNamespaceLabelPatch(t, actions, "mcs2")
+			},
+		},
+		{
+			name:      "remove the label of the namespace",
+			cluster:   newManagedCluster("cluster1", nil),
+			namespace: newClusterNamespace("cluster1", map[string]string{clusterv1beta2.ClusterSetLabel: "mcs1"}),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertNamespaceLabelPatch(t, actions, nil)
+			},
+		},
+		{
+			name:            "namespace is labeled",
+			cluster:         newManagedCluster("cluster1", map[string]string{clusterv1beta2.ClusterSetLabel: "mcs1"}),
+			namespace:       newClusterNamespace("cluster1", map[string]string{clusterv1beta2.ClusterSetLabel: "mcs1"}),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "namespace of the cluster without clusterset",
+			cluster:         newManagedCluster("cluster1", nil),
+			namespace:       newClusterNamespace("cluster1", nil),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset()
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+			if c.cluster != nil {
+				if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			objects := []runtime.Object{}
+			if c.namespace != nil {
+				objects = append(objects, c.namespace)
+			}
+			kubeClient := kubefake.NewSimpleClientset(objects...)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
+			for _, obj := range objects {
+				if err := kubeInformerFactory.Core().V1().Namespaces().Informer().GetStore().Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &clusterSetNamespaceLabelController{
+				kubeClient:      kubeClient,
+				clusterLister:   clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				namespaceLister: kubeInformerFactory.Core().V1().Namespaces().Lister(),
+				eventRecorder:   eventstesting.NewTestingEventRecorder(t),
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "cluster1"))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func newClusterNamespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+	}
+}
+
+func assertNamespaceLabelPatch(t *testing.T, actions []clienttesting.Action, expectedLabel interface{}) {
+	testinghelpers.AssertActions(t, actions, "patch")
+	testinghelpers.AssertPatch(t, actions[0], types.MergePatchType, "")
+	testinghelpers.AssertPatchLabel(t, actions[0], clusterv1beta2.ClusterSetLabel, expectedLabel)
+}
@@ -0,0 +1,106 @@
+package managedclusterset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+)
+
+const (
+	// ManagedClusterSetConditionMembersAvailable is the condition type of a clusterset which summarizes the
+	// health of its member clusters and their addons.
+	ManagedClusterSetConditionMembersAvailable = "ClusterSetMembersAvailable"
+)
+
+// addOnSummary holds the availability counts of an addon among the members of a clusterset
+type addOnSummary struct {
+	total     int
+	available int
+}
+
+// memberSummary holds the statistics of the members of a clusterset
+type memberSummary struct {
+	total       int
+	available   int
+	unavailable int
+	unreachable int
+	addOns      map[string]*addOnSummary
+}
+
+// summarizeMembers aggregates the availability of the given clusters and their addons
+func summarizeMembers(clusters []*v1.ManagedCluster) memberSummary {
+	summary := memberSummary{
+		total:  len(clusters),
+		addOns: map[string]*addOnSummary{},
+	}
+
+	for _, cluster := range clusters {
+		cond := meta.FindStatusCondition(cluster.Status.Conditions, v1.ManagedClusterConditionAvailable)
+		switch {
+		case cond == nil || cond.Status == metav1.ConditionUnknown:
+			summary.unreachable++
+		case cond.Status == metav1.ConditionFalse:
+			summary.unavailable++
+		default:
+			summary.available++
+		}
+
+		for key, value := range cluster.Labels {
+			if !strings.HasPrefix(key, helpers.AddOnFeatureLabelPrefix) {
+				continue
+			}
+			addOnName := strings.TrimPrefix(key, helpers.AddOnFeatureLabelPrefix)
+			if _, ok := summary.addOns[addOnName]; !ok {
+				summary.addOns[addOnName] = &addOnSummary{}
+			}
+			summary.addOns[addOnName].total++
+			// the status suffixes, e.g. available-degraded, are not counted
+			if helpers.GetAddOnLabelStatus(value) == helpers.AddOnStatusAvailable {
+				summary.addOns[addOnName].available++
+			}
+		}
+	}
+
+	return summary
+}
+
+// newMembersAvailableCondition builds the condition which summarizes the member statistics
+func newMembersAvailableCondition(summary memberSummary) metav1.Condition {
+	message := fmt.Sprintf("total: %d, available: %d, unavailable: %d, unreachable: %d",
+		summary.total, summary.available, summary.unavailable, summary.unreachable)
+
+	addOnNames := []string{}
+	for name := range summary.addOns {
+		addOnNames = append(addOnNames, name)
+	}
+	sort.Strings(addOnNames)
+	addOnMessages := []string{}
+	for _, name := range addOnNames {
+		addOnMessages = append(addOnMessages, fmt.Sprintf("%s: %d/%d available",
+			name, summary.addOns[name].available, summary.addOns[name].total))
+	}
+	if len(addOnMessages) > 0 {
+		message = fmt.Sprintf("%s; addons: %s", message, strings.Join(addOnMessages, ", "))
+	}
+
+	cond := metav1.Condition{
+		Type:    ManagedClusterSetConditionMembersAvailable,
+		Status:  metav1.ConditionTrue,
+		Reason:  "AllMembersAvailable",
+		Message: message,
+	}
+	switch {
+	case summary.total == 0:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "NoMembers"
+	case summary.available < summary.total:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "MembersUnavailable"
+	}
+	return cond
+}
@@ -0,0 +1,120 @@
+package managedclusterset
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+)
+
+func TestNewMembersAvailableCondition(t *testing.T) {
+	cases := []struct {
+		name            string
+		clusters        []*clusterv1.ManagedCluster
+		expectCondition metav1.Condition
+	}{
+		{
+			name: "no members",
+			expectCondition: metav1.Condition{
+				Type:    ManagedClusterSetConditionMembersAvailable,
+				Status:  metav1.ConditionFalse,
+				Reason:  "NoMembers",
+				Message: "total: 0, available: 0, unavailable: 0, unreachable: 0",
+			},
+		},
+		{
+			name: "all members are available",
+			clusters: []*clusterv1.ManagedCluster{
+				newManagedClusterWithAvailability("cluster1", metav1.ConditionTrue, map[string]string{
+					helpers.AddOnFeatureLabelPrefix + "addon1": "available",
+				}),
+				newManagedClusterWithAvailability("cluster2", metav1.ConditionTrue, map[string]string{
+					helpers.AddOnFeatureLabelPrefix + "addon1": "available",
+				}),
+			},
+			expectCondition: metav1.Condition{
+				Type:    ManagedClusterSetConditionMembersAvailable,
+				Status:  metav1.ConditionTrue,
+				Reason:  "AllMembersAvailable",
+				Message: "total: 2, available: 2, unavailable: 0, unreachable: 0; addons: addon1: 2/2 available",
+			},
+		},
+		{
+			name: "addon status with suffixes",
+			clusters: []*clusterv1.ManagedCluster{
+				newManagedClusterWithAvailability("cluster1", metav1.ConditionTrue, map[string]string{
+					helpers.AddOnFeatureLabelPrefix + "addon1": "available-degraded",
+				}),
+				newManagedClusterWithAvailability("cluster2", metav1.ConditionTrue, map[string]string{
+					helpers.AddOnFeatureLabelPrefix + "addon1": "unhealthy-progressing",
+				}),
+			},
+			expectCondition: metav1.Condition{
+				Type:    ManagedClusterSetConditionMembersAvailable,
+				Status:  metav1.ConditionTrue,
+				Reason:  "AllMembersAvailable",
+				Message: "total: 2, available: 2, unavailable: 0, unreachable: 0; addons: addon1: 1/2 available",
+			},
+		},
+		{
+			name: "some members are not available",
+			clusters: []*clusterv1.ManagedCluster{
+				newManagedClusterWithAvailability("cluster1", metav1.ConditionTrue, map[string]string{
+					helpers.AddOnFeatureLabelPrefix + "addon1": "available",
+					helpers.AddOnFeatureLabelPrefix + "addon2": "unhealthy",
+				}),
+				newManagedClusterWithAvailability("cluster2", metav1.ConditionFalse, map[string]string{
+					helpers.AddOnFeatureLabelPrefix + "addon1": "unreachable",
+				}),
+				newManagedClusterWithAvailability("cluster3", metav1.ConditionUnknown, nil),
+				newManagedCluster("cluster4", nil),
+			},
+			expectCondition: metav1.Condition{
+				Type:    ManagedClusterSetConditionMembersAvailable,
+				Status:  metav1.ConditionFalse,
+				Reason:  "MembersUnavailable",
+				Message: "total: 4, available: 1, unavailable: 1, unreachable: 2; addons: addon1: 1/2 available, addon2: 0/1 available",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cond := newMembersAvailableCondition(summarizeMembers(c.clusters))
+			if !hasCondition([]metav1.Condition{cond}, c.expectCondition) {
+				t.Errorf("expected condition %v, but got %v", c.expectCondition, cond)
+			}
+		})
+	}
+}
+
+func TestIsAvailabilityChanged(t *testing.T) {
+	available := newManagedClusterWithAvailability("cluster1", metav1.ConditionTrue, nil)
+	unknown := newManagedClusterWithAvailability("cluster1", metav1.ConditionUnknown, nil)
+	noCondition := newManagedCluster("cluster1", nil)
+
+	if isAvailabilityChanged(available, available) {
+		t.Errorf("expected availability is not changed")
+	}
+	if !isAvailabilityChanged(available, unknown) {
+		t.Errorf("expected availability is changed")
+	}
+	if !isAvailabilityChanged(noCondition, available) {
+		t.Errorf("expected availability is changed")
+	}
+	if isAvailabilityChanged(noCondition, noCondition) {
+		t.Errorf("expected availability is not changed")
+	}
+}
+
+func newManagedClusterWithAvailability(name string, status metav1.ConditionStatus, labels map[string]string) *clusterv1.ManagedCluster {
+	cluster := newManagedCluster(name, labels)
+	cluster.Status.Conditions = []metav1.Condition{
+		{
+			Type:   clusterv1.ManagedClusterConditionAvailable,
+			Status: status,
+		},
+	}
+	return cluster
+}
@@ -5,16 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 
-	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
@@ -22,6 +23,8 @@ import (
 	clusterinformerv1beta2 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta2"
 	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
 	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
 )
 
 const (
@@ -30,6 +33,7 @@ const (
 
 // managedClusterSetController reconciles instances of ManagedClusterSet on the hub.
 type managedClusterSetBindingController struct {
+	kubeClient                kubernetes.Interface
 	clusterClient             clientset.Interface
 	clusterSetBindingLister   clusterlisterv1beta2.ManagedClusterSetBindingLister
 	clusterSetLister          clusterlisterv1beta2.ManagedClusterSetLister
@@ -39,6 +43,7 @@ type managedClusterSetBindingController struct {
 }
 
 func NewManagedClusterSetBindingController(
+	kubeClient kubernetes.Interface,
 	clusterClient clientset.Interface,
 	clusterSetInformer clusterinformerv1beta2.ManagedClusterSetInformer,
 	clusterSetBindingInformer clusterinformerv1beta2.ManagedClusterSetBindingInformer,
@@ -56,6 +61,7 @@ func NewManagedClusterSetBindingController(
 	}
 
 	c := &managedClusterSetBindingController{
+		kubeClient:                kubeClient,
 		clusterClient:             clusterClient,
 		clusterSetLister:          clusterSetInformer.Lister(),
 		clusterSetBindingLister:   clusterSetBindingInformer.Lister(),
@@ -84,7 +90,7 @@ func NewManagedClusterSetBindingController(
 			return key
 		}, clusterSetBindingInformer.Informer()).
 		WithBareInformers(clusterSetInformer.Informer()).
-		WithSync(c.sync).
+		WithSync(logging.SyncWithLogger("ManagedClusterSetBindingController", c.sync)).
 		ToController("ManagedClusterSetController", recorder)
 }
 
@@ -141,7 +147,7 @@ func (c *managedClusterSetBindingController) sync(ctx context.Context, syncCtx f
 		return nil
 	}
 
-	klog.V(4).Infof("Reconciling ManagedClusterSetBinding %s", key)
+	klog.FromContext(ctx).V(4).Info("Reconciling ManagedClusterSetBinding")
 
 	bindingNamespace, bindingName, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
@@ -162,62 +168,98 @@ func (c *managedClusterSetBindingController) sync(ctx context.Context, syncCtx f
 
 	_, err = c.clusterSetLister.Get(binding.Spec.ClusterSet)
 
-	bindingCopy := binding.DeepCopy()
 	switch {
 	case errors.IsNotFound(err):
-		meta.SetStatusCondition(&bindingCopy.Status.Conditions, metav1.Condition{
+		return c.updateBoundCondition(ctx, binding, metav1.Condition{
 			Type:   clusterv1beta2.ClusterSetBindingBoundType,
 			Status: metav1.ConditionFalse,
 			Reason: "ClusterSetNotFound",
 		})
-		return c.patchCondition(ctx, binding, bindingCopy)
 	case err != nil:
 		return err
 	}
 
-	meta.SetStatusCondition(&bindingCopy.Status.Conditions, metav1.Condition{
+	// the binder may lose the permission to bind the clusterset after the binding is created
+	allowed, err := c.allowedToBind(ctx, binding)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return c.updateBoundCondition(ctx, binding, metav1.Condition{
+			Type:   clusterv1beta2.ClusterSetBindingBoundType,
+			Status: metav1.ConditionFalse,
+			Reason: "BinderNotAllowed",
+			Message: fmt.Sprintf("The binder is not allowed to bind the ManagedClusterSet %q any more",
+				binding.Spec.ClusterSet),
+		})
+	}
+
+	return c.updateBoundCondition(ctx, binding, metav1.Condition{
 		Type:   clusterv1beta2.ClusterSetBindingBoundType,
 		Status: metav1.ConditionTrue,
 		Reason: "ClusterSetBound",
 	})
-
-	return c.patchCondition(ctx, binding, bindingCopy)
 }
 
-func (c *managedClusterSetBindingController) patchCondition(ctx context.Context, old, new *clusterv1beta2.ManagedClusterSetBinding) error {
-	if equality.Semantic.DeepEqual(old.Status.Conditions, new.Status.Conditions) {
-		return nil
+// allowedToBind returns true if the binder recorded by the webhook is allowed to bind the clusterset of the binding, or
+// there is no binder recorded, e.g. the binding was created before the binders were recorded.
+func (c *managedClusterSetBindingController) allowedToBind(ctx context.Context, binding *clusterv1beta2.ManagedClusterSetBinding) (bool, error) {
+	binderData, ok := binding.Annotations[helpers.ClusterSetBindingBinderAnnotation]
+	if !ok {
+		return true, nil
 	}
-
-	oldData, err := json.Marshal(clusterv1beta2.ManagedClusterSetBinding{
-		Status: clusterv1beta2.ManagedClusterSetBindingStatus{
-			Conditions: old.Status.Conditions,
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to Marshal old data for workspace %s: %w", old.Name, err)
+	binder := &authenticationv1.UserInfo{}
+	if err := json.Unmarshal([]byte(binderData), binder); err != nil {
+		// the annotation is only set by the webhook, a binding with a broken binder is not trusted
+		klog.FromContext(ctx).Error(err, "Failed to decode the binder of the ManagedClusterSetBinding")
+		return false, nil
 	}
 
-	newData, err := json.Marshal(clusterv1beta2.ManagedClusterSetBinding{
-		ObjectMeta: metav1.ObjectMeta{
-			UID:             old.UID,
-			ResourceVersion: old.ResourceVersion,
-		}, // to ensure they appear in the patch as preconditions
-		Status: clusterv1beta2.ManagedClusterSetBindingStatus{
-			Conditions: new.Status.Conditions,
+	sar, err := c.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   binder.Username,
+			Groups: binder.Groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:       clusterv1beta2.GroupName,
+				Resource:    "managedclustersets",
+				Subresource: "bind",
+				Verb:        "create",
+				Name:        binding.Spec.ClusterSet,
+			},
 		},
-	})
+	}, metav1.CreateOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to Marshal new data for workspace %s: %w", new.Name, err)
+		return false, err
 	}
+	return sar.Status.Allowed, nil
+}
 
-	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
-	if err != nil {
-		return fmt.Errorf("failed to create patch for workspace %s: %w", new.Name, err)
+// updateBoundCondition updates the bound condition of the binding if it is changed, it is retried on conflict with
+// the latest binding.
+func (c *managedClusterSetBindingController) updateBoundCondition(ctx context.Context, binding *clusterv1beta2.ManagedClusterSetBinding, cond metav1.Condition) error {
+	cond.ObservedGeneration = binding.Generation
+	conditions := append([]metav1.Condition{}, binding.Status.Conditions...)
+	meta.SetStatusCondition(&conditions, cond)
+	if equality.Semantic.DeepEqual(binding.Status.Conditions, conditions) {
+		return nil
 	}
 
-	c.eventRecorder.Eventf("PatchClusterSetBindingCondition", "patch clustersetbinding %s/%s condition", new.Namespace, new.Name)
+	_, updated, err := helpers.UpdateManagedClusterSetBindingStatus(ctx, c.clusterClient, binding.Namespace, binding.Name,
+		func(status *clusterv1beta2.ManagedClusterSetBindingStatus) error {
+			meta.SetStatusCondition(&status.Conditions, cond)
+			return nil
+		})
+	if err != nil || !updated {
+		return err
+	}
 
-	_, err = c.clusterClient.ClusterV1beta2().ManagedClusterSetBindings(new.Namespace).Patch(ctx, new.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
-	return err
+	c.eventRecorder.Eventf("PatchClusterSetBindingCondition", "patch clustersetbinding %s/%s condition", binding.Namespace, binding.Name)
+	// flag the bindings which are not bound any more, the placements of their namespaces do not select the clusters
+	// of the clusterset from now on
+	if meta.IsStatusConditionTrue(binding.Status.Conditions, clusterv1beta2.ClusterSetBindingBoundType) &&
+		cond.Status == metav1.ConditionFalse {
+		c.eventRecorder.Warningf("ClusterSetBindingUnbound", "clustersetbinding %s/%s is unbound: %s",
+			binding.Namespace, binding.Name, cond.Reason)
+	}
+	return nil
 }
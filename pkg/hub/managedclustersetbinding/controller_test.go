@@ -7,14 +7,17 @@ import (
 	"time"
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"open-cluster-management.io/registration/pkg/helpers"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 )
 
@@ -36,8 +39,8 @@ func TestSync(t *testing.T) {
 			clusterSets:       []runtime.Object{},
 			clusterSetBinding: newManagedClusterSetBinding("test", "testns"),
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testinghelpers.AssertActions(t, actions, "patch")
-				patchData := actions[0].(clienttesting.PatchActionImpl).Patch
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				patchData := actions[1].(clienttesting.PatchActionImpl).Patch
 				binding := &clusterv1beta2.ManagedClusterSetBinding{}
 				err := json.Unmarshal(patchData, binding)
 				if err != nil {
@@ -56,8 +59,8 @@ func TestSync(t *testing.T) {
 			clusterSets:       []runtime.Object{newManagedClusterSet("test")},
 			clusterSetBinding: newManagedClusterSetBinding("test", "testns"),
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testinghelpers.AssertActions(t, actions, "patch")
-				patchData := actions[0].(clienttesting.PatchActionImpl).Patch
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				patchData := actions[1].(clienttesting.PatchActionImpl).Patch
 				binding := &clusterv1beta2.ManagedClusterSetBinding{}
 				err := json.Unmarshal(patchData, binding)
 				if err != nil {
@@ -209,3 +212,100 @@ func newManagedClusterSetBinding(name, namespace string) *clusterv1beta2.Managed
 		},
 	}
 }
+
+func TestSyncWithBinder(t *testing.T) {
+	cases := []struct {
+		name              string
+		binder            string
+		allowed           bool
+		expectedSARs      int
+		expectedCondition metav1.Condition
+	}{
+		{
+			name:         "binder is allowed",
+			binder:       `{"username":"user1","groups":["group1"]}`,
+			allowed:      true,
+			expectedSARs: 1,
+			expectedCondition: metav1.Condition{
+				Type:   clusterv1beta2.ClusterSetBindingBoundType,
+				Status: metav1.ConditionTrue,
+				Reason: "ClusterSetBound",
+			},
+		},
+		{
+			name:         "binder is not allowed",
+			binder:       `{"username":"user1","groups":["group1"]}`,
+			expectedSARs: 1,
+			expectedCondition: metav1.Condition{
+				Type:    clusterv1beta2.ClusterSetBindingBoundType,
+				Status:  metav1.ConditionFalse,
+				Reason:  "BinderNotAllowed",
+				Message: "The binder is not allowed to bind the ManagedClusterSet \"test\" any more",
+			},
+		},
+		{
+			name:   "invalid binder",
+			binder: "user1",
+			expectedCondition: metav1.Condition{
+				Type:    clusterv1beta2.ClusterSetBindingBoundType,
+				Status:  metav1.ConditionFalse,
+				Reason:  "BinderNotAllowed",
+				Message: "The binder is not allowed to bind the ManagedClusterSet \"test\" any more",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterSet := newManagedClusterSet("test")
+			binding := newManagedClusterSetBinding("test", "testns")
+			binding.Annotations = map[string]string{helpers.ClusterSetBindingBinderAnnotation: c.binder}
+
+			clusterClient := clusterfake.NewSimpleClientset(clusterSet, binding)
+			informerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 5*time.Minute)
+			if err := informerFactory.Cluster().V1beta2().ManagedClusterSets().Informer().GetStore().Add(clusterSet); err != nil {
+				t.Fatal(err)
+			}
+			if err := informerFactory.Cluster().V1beta2().ManagedClusterSetBindings().Informer().GetStore().Add(binding); err != nil {
+				t.Fatal(err)
+			}
+
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "subjectaccessreviews",
+				func(action clienttesting.Action) (bool, runtime.Object, error) {
+					sar := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+					if sar.Spec.User != "user1" || sar.Spec.ResourceAttributes.Name != "test" ||
+						sar.Spec.ResourceAttributes.Subresource != "bind" {
+						t.Errorf("unexpected subject access review %v", sar.Spec)
+					}
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{Allowed: c.allowed},
+					}, nil
+				})
+
+			ctrl := managedClusterSetBindingController{
+				kubeClient:              kubeClient,
+				clusterClient:           clusterClient,
+				clusterSetBindingLister: informerFactory.Cluster().V1beta2().ManagedClusterSetBindings().Lister(),
+				clusterSetLister:        informerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(),
+				eventRecorder:           eventstesting.NewTestingEventRecorder(t),
+			}
+
+			syncErr := ctrl.sync(context.Background(), testinghelpers.NewFakeSyncContext(t, "testns/test"))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			if len(kubeClient.Actions()) != c.expectedSARs {
+				t.Errorf("expected %d subject access reviews, but got %d", c.expectedSARs, len(kubeClient.Actions()))
+			}
+			actions := clusterClient.Actions()
+			testinghelpers.AssertActions(t, actions, "get", "patch")
+			patched := &clusterv1beta2.ManagedClusterSetBinding{}
+			if err := json.Unmarshal(actions[1].(clienttesting.PatchActionImpl).Patch, patched); err != nil {
+				t.Fatal(err)
+			}
+			testinghelpers.AssertCondition(t, patched.Status.Conditions, c.expectedCondition)
+		})
+	}
+}
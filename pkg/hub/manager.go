@@ -2,8 +2,13 @@ package hub
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"hash/fnv"
 	certv1 "k8s.io/api/certificates/v1"
 	certv1beta1 "k8s.io/api/certificates/v1beta1"
+	"os"
+	"strings"
 	"time"
 
 	ocmfeature "open-cluster-management.io/api/feature"
@@ -19,23 +24,52 @@ import (
 	clusterv1informers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	workv1client "open-cluster-management.io/api/client/work/clientset/versioned"
 	workv1informers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/hub/acceptancereview"
 	"open-cluster-management.io/registration/pkg/hub/addon"
+	"open-cluster-management.io/registration/pkg/hub/availabilityhistory"
+	"open-cluster-management.io/registration/pkg/hub/awsiam"
+	"open-cluster-management.io/registration/pkg/hub/bootstrapkubeconfig"
+	"open-cluster-management.io/registration/pkg/hub/certexpiration"
+	"open-cluster-management.io/registration/pkg/hub/clusterendpoints"
+	"open-cluster-management.io/registration/pkg/hub/clusterevents"
+	"open-cluster-management.io/registration/pkg/hub/clusterid"
 	"open-cluster-management.io/registration/pkg/hub/clusterrole"
 	"open-cluster-management.io/registration/pkg/hub/csr"
+	"open-cluster-management.io/registration/pkg/hub/flapping"
+	"open-cluster-management.io/registration/pkg/hub/grpcserver"
+	"open-cluster-management.io/registration/pkg/hub/hubcabundle"
 	"open-cluster-management.io/registration/pkg/hub/lease"
 	"open-cluster-management.io/registration/pkg/hub/managedcluster"
 	"open-cluster-management.io/registration/pkg/hub/managedclusterset"
+	"open-cluster-management.io/registration/pkg/hub/onboarding"
 	"open-cluster-management.io/registration/pkg/hub/rbacfinalizerdeletion"
+	"open-cluster-management.io/registration/pkg/hub/shard"
+	"open-cluster-management.io/registration/pkg/hub/statusconsumer"
+	"open-cluster-management.io/registration/pkg/logging"
+	"open-cluster-management.io/registration/pkg/tracing"
+	"open-cluster-management.io/registration/pkg/transport"
+	"open-cluster-management.io/registration/pkg/transport/mqtt"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
 	kubeinformers "k8s.io/client-go/informers"
+	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/cert"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	// register the workqueue metrics provider, the queues of the controllers are named after the controllers
+	_ "k8s.io/component-base/metrics/prometheus/workqueue"
 )
 
 var ResyncInterval = 5 * time.Minute
@@ -43,30 +77,516 @@ var ResyncInterval = 5 * time.Minute
 // HubManagerOptions holds configuration for hub manager controller
 type HubManagerOptions struct {
 	ClusterAutoApprovalUsers []string
+	// ClusterAutoApprovalDeniedClusters and ClusterAutoApprovalDeniedUsers block the auto approval of the
+	// registration requests from the clusters or bootstrap users
+	ClusterAutoApprovalDeniedClusters []string
+	ClusterAutoApprovalDeniedUsers    []string
+	// ClusterCSRAllowedDNSNames and ClusterCSRAllowedIPRanges are the subject alternative names allowed in the
+	// auto approved csrs of the clusters, the csrs with any other SAN are not auto approved
+	ClusterCSRAllowedDNSNames []string
+	ClusterCSRAllowedIPRanges []string
+	// ClusterIdentitySecretNamespace is the namespace of the secrets holding the pre-shared keys of the clusters, the
+	// registration requests are auto approved only with valid identity proofs if it is set
+	ClusterIdentitySecretNamespace string
+	ClusterResyncInterval          time.Duration
+	AddOnResyncInterval            time.Duration
+	CSRResyncInterval              time.Duration
+	CSRAuditSinks                  []string
+	CSRAuditWebhookURL             string
+	// CSRPendingCondition enables reporting the PendingCSR condition on the managed clusters whose registration
+	// csrs are awaiting the approval
+	CSRPendingCondition       bool
+	BootstrapKubeconfigSecret string
+	// HubCABundleConfigMap is the namespace/name of the configmap holding the CA bundle of the hub apiserver, it is
+	// published to the managed clusters so the agents keep trusting the hub after its certificate is rotated
+	HubCABundleConfigMap string
+	// SyncClusterEndpoints enables copying the api server urls and CA bundle published by the agents into the client
+	// configs of the managed clusters
+	SyncClusterEndpoints bool
+	// AvailabilityHistoryLength is the number of the last transitions of the available condition of each managed
+	// cluster recorded in its cluster namespace, the history is not recorded if it is 0
+	AvailabilityHistoryLength int
+	// AvailabilityFlapThreshold is the number of the transitions of the available condition of a managed cluster
+	// within the AvailabilityFlapWindow, above which the cluster is held down as flapping, it is disabled if it is 0
+	AvailabilityFlapThreshold int
+	AvailabilityFlapWindow    time.Duration
+	// AvailabilityFlapHoldDown is the initial hold-down of a flapping cluster, it is doubled each time the cluster
+	// flaps while it is held down
+	AvailabilityFlapHoldDown time.Duration
+	// ClockSkewTolerance is the tolerated skew of the clocks of the managed clusters from the clock of the hub, the
+	// leases of the clusters beyond it are evaluated with the hub receipt time of their renewals
+	ClockSkewTolerance time.Duration
+	// ClusterCertExpirationWarningPeriod is the period before the expiration of the client certificate of a
+	// managed cluster when the hub starts warning about it
+	ClusterCertExpirationWarningPeriod time.Duration
+	// AddOnCSRPruneAge is the age after which the finished addon CSRs are pruned from the hub
+	AddOnCSRPruneAge time.Duration
+	// AddOnSignerCASecrets maps the custom signers of the addons to the namespace/name of the secrets holding their
+	// CAs, the approved addon CSRs of these signers are signed by the hub. AddOnSignerCertDuration is the max duration
+	// of the signed certificates.
+	AddOnSignerCASecrets    map[string]string
+	AddOnSignerCertDuration time.Duration
+	// ClusterServingSignerCASecret is the namespace/name of the secret holding the CA of the cluster serving signer,
+	// the approved serving csrs of the agents are signed by the hub with it for AddOnSignerCertDuration at most
+	ClusterServingSignerCASecret string
+	// ClusterAcceptanceReview enables the review of the new clusters, they are accepted only after a reviewer approves
+	// them. The clusters which are not reviewed within ClusterAcceptanceReviewTimeout are denied.
+	ClusterAcceptanceReview        bool
+	ClusterAcceptanceReviewTimeout time.Duration
+	// ClusterNamespacePolicyConfigMap is the namespace/name of the ConfigMap holding the policy to provision
+	// the namespaces of the accepted clusters
+	ClusterNamespacePolicyConfigMap string
+	// RBACPolicyProvider provides the RBAC resources granted to the agents of the accepted clusters, it is not a flag
+	// but set by the downstream distributions to substitute a restricted set of roles. The default registration and
+	// work roles are granted if it is nil.
+	RBACPolicyProvider managedcluster.RBACPolicyProvider
+	// AgentlessRegistrationBindAddress, AgentlessRegistrationCertFile, AgentlessRegistrationKeyFile and
+	// AgentlessRegistrationClientCAFile configure the agent-less registration endpoint, they are used only if the
+	// AgentlessRegistration feature gate is enabled
+	AgentlessRegistrationBindAddress  string
+	AgentlessRegistrationCertFile     string
+	AgentlessRegistrationKeyFile      string
+	AgentlessRegistrationClientCAFile string
+	// AWSIAMRolePatterns are the patterns of the AWS IAM roles allowed for the managed clusters registered with the
+	// awsirsa driver, the {cluster} in the patterns is replaced with the cluster name. The roles of the clusters which
+	// match any of them are mapped to the identities of the clusters in the aws-auth configmap of the EKS hub.
+	AWSIAMRolePatterns []string
+	// OIDCUsernamePrefix is the username prefix of the tokens of the clusters registered with the oidc driver, as the
+	// --oidc-username-prefix of the hub kube-apiserver. It is used only if the OIDCRegistrationDriver feature gate is
+	// enabled.
+	OIDCUsernamePrefix string
+	// RegistrationTransport is the transport of the heartbeats and status of the managed clusters. With the mqtt
+	// transport, the hub consumes them from the MQTT broker configured by the MQTT options.
+	RegistrationTransport string
+	MQTTBrokerURL         string
+	MQTTCAFile            string
+	MQTTClientCertFile    string
+	MQTTClientKeyFile     string
+	// ShardCount and ShardID shard the managed clusters across the replicas of the hub, each replica only handles
+	// the clusters of its shard. The controllers which are not per cluster, e.g. the csr approving and clusterset
+	// controllers, run in the shard 0 only.
+	ShardCount int
+	ShardID    int
+	// ClusterLabelSelector, AddOnLabelSelector and LeaseLabelSelector restrict the informers of the per cluster
+	// controllers to the managed clusters, addons and leases, so that multiple scoped hub controllers can run on a
+	// large hub with less memory. The controllers which are not per cluster still watch all of the managed clusters.
+	ClusterLabelSelector string
+	AddOnLabelSelector   string
+	LeaseLabelSelector   string
+	// AddOnStatusConditionSuffixes maps the addon condition types, e.g. Degraded, to the suffixes appended to the
+	// addon labels of the clusters when the conditions are true, e.g. available-degraded
+	AddOnStatusConditionSuffixes map[string]string
+	// TracingEndpoint is the OTLP gRPC endpoint to export the spans of the controller syncs to, the tracing is
+	// disabled if it is empty. TracingSamplingRatio is the ratio of the traces to sample.
+	TracingEndpoint      string
+	TracingSamplingRatio float64
+	// FeatureGatesFile is the file holding the feature gates, it is usually mounted from a ConfigMap so the feature
+	// gates can be toggled without editing the deployment
+	FeatureGatesFile string
+	// DryRun makes the hub controllers send their writes as server-side dry-run requests and log them, so the effect
+	// of the controllers on the fleet can be previewed without changing it
+	DryRun bool
+	// KubeAPIQPS and KubeAPIBurst are the rate limit of the clients of the hub controllers, they are raised from the
+	// client-go defaults so the requests of a large fleet are handled concurrently. The rate limit of the kubeconfig
+	// is kept if they are 0.
+	KubeAPIQPS   float32
+	KubeAPIBurst int
+	// LoggingFormat is the format of the log lines, text or json. ControllerLogLevels maps the names of the
+	// controllers to the verbosities of their syncs overriding the -v flag, so the logs of a large hub can be
+	// filtered by controller.
+	LoggingFormat       string
+	ControllerLogLevels map[string]int
 }
 
 // NewHubManagerOptions returns a HubManagerOptions
 func NewHubManagerOptions() *HubManagerOptions {
-	return &HubManagerOptions{}
+	return &HubManagerOptions{
+		ClusterResyncInterval:              10 * time.Minute,
+		AddOnResyncInterval:                10 * time.Minute,
+		CSRResyncInterval:                  10 * time.Minute,
+		ClusterCertExpirationWarningPeriod: 7 * 24 * time.Hour,
+		ClockSkewTolerance:                 time.Minute,
+		AddOnSignerCertDuration:            365 * 24 * time.Hour,
+		AgentlessRegistrationBindAddress:   ":8090",
+		RegistrationTransport:              transport.TransportKube,
+		ShardCount:                         1,
+		TracingSamplingRatio:               1,
+		LoggingFormat:                      logging.FormatText,
+		AvailabilityFlapWindow:             10 * time.Minute,
+		AvailabilityFlapHoldDown:           time.Minute,
+		KubeAPIQPS:                         100,
+		KubeAPIBurst:                       200,
+	}
 }
 
 // AddFlags registers flags for manager
 func (m *HubManagerOptions) AddFlags(fs *pflag.FlagSet) {
 	features.DefaultHubMutableFeatureGate.AddFlag(fs)
+	fs.StringVar(&m.FeatureGatesFile, "feature-gates-file", m.FeatureGatesFile,
+		"The file holding a map of the feature gates to true or false, it takes precedence over --feature-gates. "+
+			"The feature gates are not reloaded in-process, the controller manager exits to be restarted once any feature "+
+			"gate in the file is toggled.")
+	fs.BoolVar(&m.DryRun, "dry-run", m.DryRun,
+		"If true, the writes of the hub controllers, e.g. csr approvals, label patches, taints and namespace deletions, "+
+			"are sent as server-side dry-run requests and logged, so they are not persisted.")
+	fs.Float32Var(&m.KubeAPIQPS, "kube-api-qps", m.KubeAPIQPS,
+		"The QPS of the clients of the hub controllers. If it is 0, the QPS of the kubeconfig is used.")
+	fs.IntVar(&m.KubeAPIBurst, "kube-api-burst", m.KubeAPIBurst,
+		"The burst of the clients of the hub controllers. If it is 0, the burst of the kubeconfig is used.")
 	fs.StringSliceVar(&m.ClusterAutoApprovalUsers, "cluster-auto-approval-users", m.ClusterAutoApprovalUsers,
 		"A bootstrap user list whose cluster registration requests can be automatically approved.")
+	fs.StringSliceVar(&m.ClusterAutoApprovalDeniedClusters, "cluster-auto-approval-denied-clusters",
+		m.ClusterAutoApprovalDeniedClusters,
+		"A list of cluster names or name patterns (e.g. dev-*) whose registration requests cannot be automatically approved.")
+	fs.StringSliceVar(&m.ClusterAutoApprovalDeniedUsers, "cluster-auto-approval-denied-users",
+		m.ClusterAutoApprovalDeniedUsers,
+		"A bootstrap user list whose cluster registration requests cannot be automatically approved, "+
+			"even if the users are in the auto approval user list.")
+	fs.StringSliceVar(&m.ClusterCSRAllowedDNSNames, "cluster-csr-allowed-dns-names", m.ClusterCSRAllowedDNSNames,
+		"A list of DNS names or name patterns (e.g. *.example.com) allowed in the subject alternative names of the "+
+			"automatically approved cluster csrs, including the serving csrs of the agents. The webhook is expected to be "+
+			"configured with the same list.")
+	fs.StringSliceVar(&m.ClusterCSRAllowedIPRanges, "cluster-csr-allowed-ip-ranges", m.ClusterCSRAllowedIPRanges,
+		"A list of IPv4 or IPv6 ranges in CIDR notation allowed in the subject alternative names of the "+
+			"automatically approved cluster csrs, including the serving csrs of the agents. The webhook is expected to be "+
+			"configured with the same list.")
+	fs.StringVar(&m.ClusterIdentitySecretNamespace, "cluster-identity-secret-namespace", m.ClusterIdentitySecretNamespace,
+		"The namespace of the secrets holding the pre-shared keys of the clusters in the key "+csr.IdentityKeySecretKey+
+			", the secrets are named after the clusters. If it is set, the cluster registration requests are automatically "+
+			"approved only if they are annotated with the identity proofs made with the keys.")
+	fs.DurationVar(&m.ClusterResyncInterval, "cluster-resync-interval", m.ClusterResyncInterval,
+		"The resync interval of the informers for managed clusters and managed cluster sets.")
+	fs.DurationVar(&m.AddOnResyncInterval, "addon-resync-interval", m.AddOnResyncInterval,
+		"The resync interval of the informers for managed cluster addons.")
+	fs.DurationVar(&m.CSRResyncInterval, "csr-resync-interval", m.CSRResyncInterval,
+		"The resync interval of the informers for certificate signing requests.")
+	fs.StringSliceVar(&m.CSRAuditSinks, "csr-audit-sinks", m.CSRAuditSinks,
+		"The sinks to record the auto approval decisions of certificate signing requests, "+
+			"the supported sinks are event, log and webhook.")
+	fs.StringVar(&m.CSRAuditWebhookURL, "csr-audit-webhook-url", m.CSRAuditWebhookURL,
+		"The url of the external webhook endpoint to post the approval records, it is required by the webhook audit sink.")
+	fs.BoolVar(&m.CSRPendingCondition, "csr-pending-condition", m.CSRPendingCondition,
+		"If true, the PendingCSR condition is reported on the managed clusters whose registration certificate signing "+
+			"requests are awaiting the approval.")
+	fs.StringVar(&m.BootstrapKubeconfigSecret, "bootstrap-kubeconfig-secret", m.BootstrapKubeconfigSecret,
+		"The namespace/name of the secret holding the bootstrap kubeconfig on hub. If it is set, the bootstrap kubeconfig is "+
+			"published to the managed clusters to refresh their local bootstrap kubeconfig.")
+	fs.StringVar(&m.HubCABundleConfigMap, "hub-ca-bundle-configmap", m.HubCABundleConfigMap,
+		"The namespace/name of the configmap holding the CA bundle of the hub apiserver in the key ca.crt. If it is set, "+
+			"the CA bundle is published to the managed clusters to refresh the CA of their hub kubeconfig once it is rotated.")
+	fs.BoolVar(&m.SyncClusterEndpoints, "sync-cluster-endpoints", m.SyncClusterEndpoints,
+		"Create the secret "+helpers.ClusterEndpointsSecretName+" in the cluster namespaces for the agents to publish the api "+
+			"server urls and CA bundle of the managed clusters, and copy them into the client configs of the managed clusters. "+
+			"The client configs applied by the agents themselves are left alone.")
+	fs.IntVar(&m.AvailabilityHistoryLength, "availability-history-length", m.AvailabilityHistoryLength,
+		"The number of the last transitions of the available condition of each managed cluster recorded with their "+
+			"timestamps in the configmap "+availabilityhistory.ConfigMapName+" of its cluster namespace for flap analysis. "+
+			"Set it to 0 to disable the history.")
+	fs.IntVar(&m.AvailabilityFlapThreshold, "availability-flap-threshold", m.AvailabilityFlapThreshold,
+		"The number of the transitions of the available condition of a managed cluster within the flap window, above "+
+			"which the cluster is held down with the Flapping condition and tainted as unavailable. Set it to 0 to disable "+
+			"the flap detection. It requires the ClusterTaint feature.")
+	fs.DurationVar(&m.AvailabilityFlapWindow, "availability-flap-window", m.AvailabilityFlapWindow,
+		"The window in which the transitions of the available condition of a managed cluster are counted.")
+	fs.DurationVar(&m.AvailabilityFlapHoldDown, "availability-flap-hold-down", m.AvailabilityFlapHoldDown,
+		"The initial hold-down of a flapping managed cluster, it is doubled each time the cluster flaps while it is "+
+			"held down. The cluster is released once it is stable for the whole hold-down.")
+	fs.DurationVar(&m.ClockSkewTolerance, "clock-skew-tolerance", m.ClockSkewTolerance,
+		"The tolerated skew of the clocks of the managed clusters from the clock of the hub, measured with the renewals "+
+			"of their leases. The clusters beyond it get the ClockOutOfSync condition, and their leases are evaluated with "+
+			"the time the hub receives the renewals. Set it to 0 to disable the check.")
+	fs.DurationVar(&m.ClusterCertExpirationWarningPeriod, "cluster-cert-expiration-warning-period", m.ClusterCertExpirationWarningPeriod,
+		"The period before the expiration of the client certificate of a managed cluster when the hub starts warning about it. "+
+			"It should be shorter than the renewal window of the client certificates. Set it to 0 to disable the warning.")
+	fs.DurationVar(&m.AddOnCSRPruneAge, "addon-csr-prune-age", m.AddOnCSRPruneAge,
+		"The age after which the approved, denied or expired certificate signing requests of the addons are pruned "+
+			"from the hub. Set it to 0 to disable the pruning.")
+	fs.StringToStringVar(&m.AddOnSignerCASecrets, "addon-signer-ca-secrets", m.AddOnSignerCASecrets,
+		"The custom signers of the addons and the namespace/name of the secrets holding their CAs in the keys tls.crt and "+
+			"tls.key, e.g. open-cluster-management.io/addon-signer=open-cluster-management-hub/addon-signer-ca. The approved "+
+			"certificate signing requests of these signers are signed by the hub. The hub must be granted to sign for the "+
+			"signers, the default role only grants the signers of the open-cluster-management.io domain.")
+	fs.DurationVar(&m.AddOnSignerCertDuration, "addon-signer-cert-duration", m.AddOnSignerCertDuration,
+		"The max duration of the certificates signed for the custom signers of the addons and the cluster serving signer.")
+	fs.StringVar(&m.ClusterServingSignerCASecret, "cluster-serving-signer-ca-secret", m.ClusterServingSignerCASecret,
+		"The namespace/name of the secret holding the CA of the "+helpers.ClusterServingSignerName+" signer in the keys "+
+			"tls.crt and tls.key. The serving certificate signing requests of the agents are approved if their subject "+
+			"alternative names are allowed by --cluster-csr-allowed-dns-names and --cluster-csr-allowed-ip-ranges, and "+
+			"are signed by the hub with the CA.")
+	fs.BoolVar(&m.ClusterAcceptanceReview, "cluster-acceptance-review", m.ClusterAcceptanceReview,
+		"Accept the new clusters only after a reviewer sets the annotation "+acceptancereview.AcceptanceReviewAnnotation+
+			" of the clusters to approved, which requires the approve verb on the managedclusters/accept subresource of the "+
+			"register.open-cluster-management.io group. The clusters accepted by the auto approval are not reviewed. Enable the "+
+			"--require-acceptance-review of the webhook to prevent the other users from accepting the clusters without the review.")
+	fs.DurationVar(&m.ClusterAcceptanceReviewTimeout, "cluster-acceptance-review-timeout", m.ClusterAcceptanceReviewTimeout,
+		"The period after which the clusters not reviewed are denied. If it is not set, the review does not time out.")
+	fs.StringVar(&m.ClusterNamespacePolicyConfigMap, "cluster-namespace-policy-configmap", m.ClusterNamespacePolicyConfigMap,
+		"The namespace/name of the ConfigMap holding the policy to provision the namespaces of the accepted clusters with "+
+			"the labels, annotations, resource quota and limit range. The policy is read from the key "+managedcluster.NamespacePolicyKey+".")
+	fs.StringVar(&m.AgentlessRegistrationBindAddress, "agentless-registration-bind-address", m.AgentlessRegistrationBindAddress,
+		"The address to serve the agent-less registration endpoint on.")
+	fs.StringVar(&m.AgentlessRegistrationCertFile, "agentless-registration-cert-file", m.AgentlessRegistrationCertFile,
+		"The serving certificate file of the agent-less registration endpoint.")
+	fs.StringVar(&m.AgentlessRegistrationKeyFile, "agentless-registration-key-file", m.AgentlessRegistrationKeyFile,
+		"The serving key file of the agent-less registration endpoint.")
+	fs.StringVar(&m.AgentlessRegistrationClientCAFile, "agentless-registration-client-ca-file", m.AgentlessRegistrationClientCAFile,
+		"The CA bundle file to verify the client certificates of the heartbeats, it is the CA bundle of the "+
+			"kube-apiserver-client signer on the hub.")
+	fs.StringSliceVar(&m.AWSIAMRolePatterns, "aws-iam-role-patterns", m.AWSIAMRolePatterns,
+		"A list of the AWS IAM role ARN patterns allowed for the managed clusters registered with the awsirsa driver, "+
+			"e.g. arn:aws:iam::123456789012:role/ocm_{cluster}_*. The "+awsiam.ClusterNamePlaceholder+" in the patterns is "+
+			"replaced with the cluster name, it must be delimited by any of "+awsiam.RoleSeparators+" which the cluster "+
+			"names cannot contain. The matched roles are mapped to the clusters in the aws-auth configmap of the "+
+			"EKS hub. It requires the AWSIAMRegistrationDriver feature.")
+	fs.StringVar(&m.OIDCUsernamePrefix, "oidc-username-prefix", m.OIDCUsernamePrefix,
+		"The username prefix of the tokens of the managed clusters registered with the oidc driver, it must be the same as "+
+			"the username prefix of the issuers of the clusters configured on the hub kube-apiserver. If it is not set, the "+
+			"issuer followed by # is the prefix, and there is no prefix if it is -. The users are bound with the roles of the "+
+			"accepted clusters if the OIDCRegistrationDriver feature is enabled.")
+	fs.StringVar(&m.RegistrationTransport, "registration-transport", m.RegistrationTransport,
+		"The transport of the heartbeats and status of the managed clusters, it must be one of kube and mqtt. With mqtt, "+
+			"the hub consumes them from the MQTT broker. The mqtt transport requires the MQTTTransport feature.")
+	fs.StringVar(&m.MQTTBrokerURL, "mqtt-broker-url", m.MQTTBrokerURL,
+		"The URL of the MQTT broker for the mqtt registration transport, e.g. tls://broker:8883. The broker must "+
+			"authenticate the agents with their hub client certificates and only allow them to publish to the topics "+
+			"under their own user names, e.g. open-cluster-management/agents/%u/# with the user name of the certificate.")
+	fs.StringVar(&m.MQTTCAFile, "mqtt-ca-file", m.MQTTCAFile,
+		"The CA file to verify the MQTT broker. If it is not set, the system CAs are used.")
+	fs.StringVar(&m.MQTTClientCertFile, "mqtt-client-cert-file", m.MQTTClientCertFile,
+		"The client certificate file to authenticate to the MQTT broker.")
+	fs.StringVar(&m.MQTTClientKeyFile, "mqtt-client-key-file", m.MQTTClientKeyFile,
+		"The client key file to authenticate to the MQTT broker.")
+	fs.IntVar(&m.ShardCount, "shard-count", m.ShardCount,
+		"The number of the shards of the managed clusters. Each replica of the hub runs with a distinct shard id and only "+
+			"handles the managed clusters with the label "+shard.LabelKey+" of its shard id. The label is set with the hash "+
+			"of the cluster name by the shard 0 if it is not set.")
+	fs.IntVar(&m.ShardID, "shard-id", m.ShardID,
+		"The id of the shard handled by this replica, it must be in the range of [0, shard-count). The controllers which "+
+			"are not per cluster run in the shard 0 only.")
+	fs.StringVar(&m.ClusterLabelSelector, "cluster-label-selector", m.ClusterLabelSelector,
+		"The label selector of the managed clusters handled by the per cluster controllers of the hub, e.g. "+
+			"cluster.open-cluster-management.io/clusterset=prod. The controllers which are not per cluster, e.g. the clusterset, "+
+			"clusterrole and csr controllers, handle all of the managed clusters. If it is not set, all of the managed clusters are handled.")
+	fs.StringVar(&m.AddOnLabelSelector, "addon-label-selector", m.AddOnLabelSelector,
+		"The label selector of the managed cluster addons handled by the hub. If it is not set, all of the addons are handled.")
+	fs.StringVar(&m.LeaseLabelSelector, "lease-label-selector", m.LeaseLabelSelector,
+		"The label selector of the cluster leases watched by the hub, e.g. "+clusterv1.ClusterNameLabelKey+". If it is not "+
+			"set, all of the leases are watched. The addon leases are not filtered by it.")
+	fs.StringToStringVar(&m.AddOnStatusConditionSuffixes, "addon-status-condition-suffixes", m.AddOnStatusConditionSuffixes,
+		"The addon condition types and the suffixes appended to the feature.open-cluster-management.io/addon-<addon name> "+
+			"labels of the clusters when the conditions are true, e.g. Degraded=degraded,Progressing=progressing. The suffixes are "+
+			"appended in the order of the condition types, e.g. available-degraded-progressing.")
+	fs.StringVar(&m.TracingEndpoint, "tracing-endpoint", m.TracingEndpoint,
+		"The OTLP gRPC endpoint to export the traces of the controller syncs to, e.g. otel-collector:4317. The exporter "+
+			"can be further configured with the OTEL_EXPORTER_OTLP_* environment variables. If it is not set, the tracing is disabled.")
+	fs.Float64Var(&m.TracingSamplingRatio, "tracing-sampling-ratio", m.TracingSamplingRatio,
+		"The ratio of the traces to sample, it must be in the range of [0, 1].")
+	fs.StringVar(&m.LoggingFormat, "logging-format", m.LoggingFormat,
+		"The format of the log lines, text or json. The log lines of the controller syncs carry the controller, "+
+			"queueKey, cluster and addon fields in both formats.")
+	fs.StringToIntVar(&m.ControllerLogLevels, "controller-log-levels", m.ControllerLogLevels,
+		"The verbosities of the syncs of the controllers overriding -v, e.g. CSRApprovingController=4,"+
+			"ManagedClusterLeaseController=0. The log lines out of the controller syncs are still filtered by -v.")
+}
+
+// Validate verifies the inputs.
+func (m *HubManagerOptions) Validate() error {
+	if m.ClusterResyncInterval <= 0 {
+		return errors.New("cluster resync interval must greater than zero")
+	}
+	if m.AddOnResyncInterval <= 0 {
+		return errors.New("addon resync interval must greater than zero")
+	}
+	if m.CSRResyncInterval <= 0 {
+		return errors.New("csr resync interval must greater than zero")
+	}
+	if m.ClockSkewTolerance < 0 {
+		return errors.New("clock skew tolerance must not be negative")
+	}
+	if m.KubeAPIQPS < 0 || m.KubeAPIBurst < 0 {
+		return errors.New("kube api qps and burst must not be negative")
+	}
+	if m.ClusterCertExpirationWarningPeriod < 0 {
+		return errors.New("cluster cert expiration warning period must not be negative")
+	}
+	if m.AddOnCSRPruneAge < 0 {
+		return errors.New("addon csr prune age must not be negative")
+	}
+	if len(m.signerCASecrets()) > 0 && m.AddOnSignerCertDuration <= 0 {
+		return errors.New("addon signer cert duration must greater than zero")
+	}
+	if err := csr.ValidateSignerCASecrets(m.signerCASecrets()); err != nil {
+		return err
+	}
+	if m.ClusterAcceptanceReviewTimeout < 0 {
+		return errors.New("cluster acceptance review timeout must not be negative")
+	}
+	if m.ShardCount < 0 {
+		return errors.New("shard count must not be negative")
+	}
+	// the managed clusters are not sharded if the shard count is 0 or 1, the shard id must be 0 in that case
+	if m.ShardID < 0 || (m.ShardID > 0 && m.ShardID >= m.ShardCount) {
+		return errors.Errorf("shard id %d must be in the range of [0, %d)", m.ShardID, m.ShardCount)
+	}
+	if m.AvailabilityHistoryLength < 0 || m.AvailabilityHistoryLength > availabilityhistory.MaxHistoryLength {
+		return errors.Errorf("availability history length %d must be in the range of [0, %d]",
+			m.AvailabilityHistoryLength, availabilityhistory.MaxHistoryLength)
+	}
+	if m.AvailabilityFlapThreshold < 0 {
+		return errors.New("availability flap threshold must not be negative")
+	}
+	if m.AvailabilityFlapThreshold > 0 && (m.AvailabilityFlapWindow <= 0 || m.AvailabilityFlapHoldDown <= 0) {
+		return errors.New("availability flap window and hold-down must greater than zero")
+	}
+	if m.TracingSamplingRatio < 0 || m.TracingSamplingRatio > 1 {
+		return errors.Errorf("tracing sampling ratio %v must be in the range of [0, 1]", m.TracingSamplingRatio)
+	}
+	if err := addon.ValidateStatusSuffixes(m.AddOnStatusConditionSuffixes); err != nil {
+		return err
+	}
+	if err := logging.Validate(m.LoggingFormat, m.ControllerLogLevels); err != nil {
+		return err
+	}
+	if _, err := csr.NewApprovalDenyList(m.ClusterAutoApprovalDeniedClusters, m.ClusterAutoApprovalDeniedUsers); err != nil {
+		return err
+	}
+	if _, err := csr.NewSANAllowList(m.ClusterCSRAllowedDNSNames, m.ClusterCSRAllowedIPRanges); err != nil {
+		return err
+	}
+	for _, selector := range []struct{ kind, value string }{
+		{kind: "cluster", value: m.ClusterLabelSelector},
+		{kind: "addon", value: m.AddOnLabelSelector},
+		{kind: "lease", value: m.LeaseLabelSelector},
+	} {
+		if _, err := labels.Parse(selector.value); err != nil {
+			return errors.Wrapf(err, "%s label selector %q is invalid", selector.kind, selector.value)
+		}
+	}
+	if len(m.BootstrapKubeconfigSecret) > 0 {
+		if namespace, name, err := cache.SplitMetaNamespaceKey(m.BootstrapKubeconfigSecret); err != nil ||
+			len(namespace) == 0 || len(name) == 0 {
+			return errors.Errorf("bootstrap kubeconfig secret %q must be in the format of namespace/name", m.BootstrapKubeconfigSecret)
+		}
+	}
+	if len(m.HubCABundleConfigMap) > 0 {
+		if namespace, name, err := cache.SplitMetaNamespaceKey(m.HubCABundleConfigMap); err != nil ||
+			len(namespace) == 0 || len(name) == 0 {
+			return errors.Errorf("hub CA bundle configmap %q must be in the format of namespace/name", m.HubCABundleConfigMap)
+		}
+	}
+	if len(m.ClusterNamespacePolicyConfigMap) > 0 {
+		if namespace, name, err := cache.SplitMetaNamespaceKey(m.ClusterNamespacePolicyConfigMap); err != nil ||
+			len(namespace) == 0 || len(name) == 0 {
+			return errors.Errorf("cluster namespace policy configmap %q must be in the format of namespace/name",
+				m.ClusterNamespacePolicyConfigMap)
+		}
+	}
+	for _, sink := range m.CSRAuditSinks {
+		if sink == csr.AuditSinkWebhook && len(m.CSRAuditWebhookURL) == 0 {
+			return errors.New("csr audit webhook url is required by the webhook audit sink")
+		}
+		if !sets.New(csr.AuditSinks...).Has(sink) {
+			return errors.Errorf("unsupported csr audit sink %q", sink)
+		}
+	}
+	if features.DefaultHubMutableFeatureGate.Enabled(features.AgentlessRegistration) {
+		if len(m.AgentlessRegistrationCertFile) == 0 || len(m.AgentlessRegistrationKeyFile) == 0 ||
+			len(m.AgentlessRegistrationClientCAFile) == 0 {
+			return errors.New("agentless registration cert file, key file and client ca file are required by the " +
+				"AgentlessRegistration feature")
+		}
+	}
+	if len(m.AWSIAMRolePatterns) > 0 && !features.DefaultHubMutableFeatureGate.Enabled(features.AWSIAMRegistrationDriver) {
+		return errors.New("aws iam role patterns require the AWSIAMRegistrationDriver feature")
+	}
+	if err := awsiam.ValidateRolePatterns(m.AWSIAMRolePatterns); err != nil {
+		return err
+	}
+	switch m.RegistrationTransport {
+	case "", transport.TransportKube:
+	case transport.TransportMQTT:
+		if !features.DefaultHubMutableFeatureGate.Enabled(features.MQTTTransport) {
+			return errors.Errorf("registration transport %q requires the MQTTTransport feature", m.RegistrationTransport)
+		}
+		if len(m.MQTTBrokerURL) == 0 {
+			return errors.New("mqtt broker url is required by the mqtt registration transport")
+		}
+		if (len(m.MQTTClientCertFile) == 0) != (len(m.MQTTClientKeyFile) == 0) {
+			return errors.New("mqtt client cert file and key file must be specified together")
+		}
+	default:
+		return errors.Errorf("registration transport %q is invalid, it must be one of %v",
+			m.RegistrationTransport, transport.Transports)
+	}
+	return nil
+}
 
+// signerCASecrets returns the signers the hub signs the approved csrs for and the namespace/name of the secrets
+// holding their CAs
+func (m *HubManagerOptions) signerCASecrets() map[string]string {
+	if len(m.ClusterServingSignerCASecret) == 0 {
+		return m.AddOnSignerCASecrets
+	}
+	caSecrets := map[string]string{helpers.ClusterServingSignerName: m.ClusterServingSignerCASecret}
+	for signerName, secret := range m.AddOnSignerCASecrets {
+		caSecrets[signerName] = secret
+	}
+	return caSecrets
+}
+
+// LeaderElectionLockName returns the name of the leader election lock of the replicas handling the same managed
+// clusters, addons and leases, it is empty if the replicas handle all of them. The replicas of different shards or
+// label selectors are elected separately.
+func (m *HubManagerOptions) LeaderElectionLockName() string {
+	scopes := []string{}
+	if m.ShardCount > 1 {
+		scopes = append(scopes, fmt.Sprintf("shard-%d", m.ShardID))
+	}
+	if len(m.ClusterLabelSelector) > 0 || len(m.AddOnLabelSelector) > 0 || len(m.LeaseLabelSelector) > 0 {
+		// the selectors are hashed since they are not valid in the names
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(strings.Join([]string{m.ClusterLabelSelector, m.AddOnLabelSelector, m.LeaseLabelSelector}, ";")))
+		scopes = append(scopes, fmt.Sprintf("selector-%08x", hash.Sum32()))
+	}
+	if len(scopes) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("registration-controller-%s-lock", strings.Join(scopes, "-"))
 }
 
 // RunControllerManager starts the controllers on hub to manage spoke cluster registration.
 func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
-	// If qps in kubconfig is not set, increase the qps and burst to enhance the ability of kube client to handle
-	// requests in concurrent
-	// TODO: Use ClientConnectionOverrides flags to change qps/burst when library-go exposes them in the future
-	kubeConfig := rest.CopyConfig(controllerContext.KubeConfig)
-	if kubeConfig.QPS == 0.0 {
-		kubeConfig.QPS = 100.0
-		kubeConfig.Burst = 200
+	if err := logging.Setup(m.LoggingFormat, m.ControllerLogLevels); err != nil {
+		return err
+	}
+
+	// the feature gates file is loaded before the leader election, the controller manager exits to be restarted once
+	// any feature gate in it is toggled
+	ctx, err := features.WatchFeatureGatesFile(ctx, features.DefaultHubMutableFeatureGate, m.FeatureGatesFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to watch the feature gates file")
+	}
+
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	kubeConfig := helpers.WithRateLimit(controllerContext.KubeConfig, m.KubeAPIQPS, m.KubeAPIBurst)
+	if m.DryRun {
+		klog.Warningf("The hub controllers run in dry-run mode, their writes are logged but not persisted")
+		kubeConfig.Wrap(newDryRunRoundTripper)
+	}
+
+	if len(m.TracingEndpoint) > 0 {
+		shutdown, err := tracing.Setup(ctx, "registration-controller", m.TracingEndpoint, m.TracingSamplingRatio)
+		if err != nil {
+			return errors.Wrap(err, "failed to set up the tracing")
+		}
+		defer func() {
+			if err := shutdown(context.Background()); err != nil {
+				klog.Warningf("failed to shut down the tracing: %v", err)
+			}
+		}()
 	}
 
 	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
@@ -89,118 +609,468 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 		return err
 	}
 
-	clusterInformers := clusterv1informers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+	clusterInformers := clusterv1informers.NewSharedInformerFactory(clusterClient, m.ClusterResyncInterval)
 	workInformers := workv1informers.NewSharedInformerFactory(workClient, 10*time.Minute)
 	kubeInfomers := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
-	addOnInformers := addoninformers.NewSharedInformerFactory(addOnClient, 10*time.Minute)
+	leaseInformers := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 10*time.Minute,
+		kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+			listOptions.LabelSelector = m.LeaseLabelSelector
+		}))
+	// use a separate informer factory for CSRs so that they can be resynced independently from other kube resources
+	csrInformers := kubeinformers.NewSharedInformerFactory(kubeClient, m.CSRResyncInterval)
+	addOnInformers := addoninformers.NewSharedInformerFactoryWithOptions(addOnClient, m.AddOnResyncInterval,
+		addoninformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+			listOptions.LabelSelector = m.AddOnLabelSelector
+		}))
+	// the per cluster controllers only watch the managed clusters selected by the cluster label selector and of the
+	// shard, the controllers which are not per cluster watch all of the managed clusters and only run in the primary
+	// shard
+	shardClusterInformers := clusterInformers
+	shardSelectors := []string{}
+	if len(m.ClusterLabelSelector) > 0 {
+		shardSelectors = append(shardSelectors, m.ClusterLabelSelector)
+	}
+	if m.ShardCount > 1 {
+		shardSelectors = append(shardSelectors, shard.Selector(m.ShardID))
+	}
+	if len(shardSelectors) > 0 {
+		shardClusterInformers = clusterv1informers.NewSharedInformerFactoryWithOptions(clusterClient, m.ClusterResyncInterval,
+			clusterv1informers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.LabelSelector = strings.Join(shardSelectors, ",")
+			}))
+	}
+	primary := m.ShardID == 0
+
+	var namespacePolicyInformers kubeinformers.SharedInformerFactory
+	var namespacePolicyInformer corev1informers.ConfigMapInformer
+	var namespacePolicyResourceInformers kubeinformers.SharedInformerFactory
+	var namespaceInformer corev1informers.NamespaceInformer
+	var resourceQuotaInformer corev1informers.ResourceQuotaInformer
+	var limitRangeInformer corev1informers.LimitRangeInformer
+	var namespacePolicyNamespace, namespacePolicyName string
+	if len(m.ClusterNamespacePolicyConfigMap) > 0 {
+		// the configmap is validated already
+		namespacePolicyNamespace, namespacePolicyName, _ = cache.SplitMetaNamespaceKey(m.ClusterNamespacePolicyConfigMap)
+		namespacePolicyInformers = kubeinformers.NewSharedInformerFactoryWithOptions(
+			kubeClient, 10*time.Minute, kubeinformers.WithNamespace(namespacePolicyNamespace))
+		namespacePolicyInformer = namespacePolicyInformers.Core().V1().ConfigMaps()
+		// only watch the resource quotas and limit ranges provisioned by the namespace policy
+		namespacePolicyResourceInformers = kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 10*time.Minute,
+			kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.FieldSelector = fields.OneTermEqualSelector(
+					"metadata.name", managedcluster.NamespacePolicyResourceName).String()
+			}))
+		namespaceInformer = kubeInfomers.Core().V1().Namespaces()
+		resourceQuotaInformer = namespacePolicyResourceInformers.Core().V1().ResourceQuotas()
+		limitRangeInformer = namespacePolicyResourceInformers.Core().V1().LimitRanges()
+	}
+
+	var oidcUsernamePrefix *string
+	if features.DefaultHubMutableFeatureGate.Enabled(features.OIDCRegistrationDriver) {
+		oidcUsernamePrefix = &m.OIDCUsernamePrefix
+	}
 
 	managedClusterController := managedcluster.NewManagedClusterController(
 		kubeClient,
 		clusterClient,
-		clusterInformers.Cluster().V1().ManagedClusters(),
+		shardClusterInformers.Cluster().V1().ManagedClusters(),
+		addOnClient,
+		addOnInformers.Addon().V1alpha1().ManagedClusterAddOns(),
+		namespacePolicyInformer,
+		namespacePolicyNamespace, namespacePolicyName,
+		namespaceInformer, resourceQuotaInformer, limitRangeInformer,
+		oidcUsernamePrefix,
+		m.RBACPolicyProvider,
 		controllerContext.EventRecorder,
 	)
 
-	taintController := taint.NewTaintController(
-		clusterClient,
-		clusterInformers.Cluster().V1().ManagedClusters(),
+	clusterSetNamespaceLabelController := managedclusterset.NewClusterSetNamespaceLabelController(
+		kubeClient,
+		shardClusterInformers.Cluster().V1().ManagedClusters(),
+		kubeInfomers.Core().V1().Namespaces(),
 		controllerContext.EventRecorder,
 	)
 
-	csrReconciles := []csr.Reconciler{csr.NewCSRRenewalReconciler(kubeClient, controllerContext.EventRecorder)}
-	if features.DefaultHubMutableFeatureGate.Enabled(ocmfeature.ManagedClusterAutoApproval) {
-		csrReconciles = append(csrReconciles, csr.NewCSRBootstrapReconciler(
-			kubeClient,
+	var taintController, maintenanceController, flappingController factory.Controller
+	if features.DefaultHubMutableFeatureGate.Enabled(features.ClusterTaint) {
+		taintController = taint.NewTaintController(
 			clusterClient,
-			clusterInformers.Cluster().V1().ManagedClusters().Lister(),
-			m.ClusterAutoApprovalUsers,
+			shardClusterInformers.Cluster().V1().ManagedClusters(),
+			clock.RealClock{},
 			controllerContext.EventRecorder,
-		))
+		)
+		maintenanceController = taint.NewMaintenanceController(
+			clusterClient,
+			shardClusterInformers.Cluster().V1().ManagedClusters(),
+			clock.RealClock{},
+			controllerContext.EventRecorder,
+		)
+		// the flapping clusters are held down with the unavailable taint
+		if m.AvailabilityFlapThreshold > 0 {
+			flappingController = flapping.NewFlappingController(
+				clusterClient,
+				shardClusterInformers.Cluster().V1().ManagedClusters(),
+				m.AvailabilityFlapThreshold,
+				m.AvailabilityFlapWindow,
+				m.AvailabilityFlapHoldDown,
+				controllerContext.EventRecorder,
+			)
+		}
 	}
 
-	var csrController factory.Controller
-	if features.DefaultHubMutableFeatureGate.Enabled(ocmfeature.V1beta1CSRAPICompatibility) {
-		v1CSRSupported, v1beta1CSRSupported, err := helpers.IsCSRSupported(kubeClient)
+	// hubSecretInformers watch the secrets read by the hub, e.g. the CAs of the signers and the identity keys of the
+	// clusters, in their namespaces
+	hubSecretInformers := map[string]kubeinformers.SharedInformerFactory{}
+	hubSecretInformer := func(namespace string) corev1informers.SecretInformer {
+		if _, ok := hubSecretInformers[namespace]; !ok {
+			hubSecretInformers[namespace] = kubeinformers.NewSharedInformerFactoryWithOptions(
+				kubeClient, 10*time.Minute, kubeinformers.WithNamespace(namespace))
+		}
+		return hubSecretInformers[namespace].Core().V1().Secrets()
+	}
+
+	var csrController, csrGCController, csrSigningController, csrPendingController factory.Controller
+	if primary {
+		csrAuditSink, err := csr.NewAuditSink(m.CSRAuditSinks, m.CSRAuditWebhookURL, controllerContext.EventRecorder)
 		if err != nil {
-			return errors.Wrapf(err, "failed CSR api discovery")
+			return err
 		}
 
-		if !v1CSRSupported && v1beta1CSRSupported {
-			csrController = csr.NewCSRApprovingController[*certv1beta1.CertificateSigningRequest](
-				kubeInfomers.Certificates().V1beta1().CertificateSigningRequests().Informer(),
-				kubeInfomers.Certificates().V1beta1().CertificateSigningRequests().Lister(),
-				csr.NewCSRV1beta1Approver(kubeClient),
+		sanAllowList, err := csr.NewSANAllowList(m.ClusterCSRAllowedDNSNames, m.ClusterCSRAllowedIPRanges)
+		if err != nil {
+			return err
+		}
+		denyList, err := csr.NewApprovalDenyList(m.ClusterAutoApprovalDeniedClusters, m.ClusterAutoApprovalDeniedUsers)
+		if err != nil {
+			return err
+		}
+		csrReconciles := []csr.Reconciler{}
+		// the serving csrs are approved only if they are signed by the hub with the CA of the cluster serving signer
+		if len(m.ClusterServingSignerCASecret) > 0 {
+			csrReconciles = append(csrReconciles, csr.NewCSRServingReconciler(
+				kubeClient, denyList, sanAllowList, csrAuditSink, controllerContext.EventRecorder))
+		}
+		csrReconciles = append(csrReconciles,
+			csr.NewCSRRenewalReconciler(kubeClient, denyList, sanAllowList, csrAuditSink, controllerContext.EventRecorder))
+		if features.DefaultHubMutableFeatureGate.Enabled(ocmfeature.ManagedClusterAutoApproval) {
+			var identityVerifier *csr.IdentityVerifier
+			if len(m.ClusterIdentitySecretNamespace) > 0 {
+				identityVerifier = csr.NewIdentityVerifier(
+					hubSecretInformer(m.ClusterIdentitySecretNamespace), m.ClusterIdentitySecretNamespace)
+			}
+			csrReconciles = append(csrReconciles, csr.NewCSRBootstrapReconciler(
+				kubeClient,
+				clusterClient,
+				clusterInformers.Cluster().V1().ManagedClusters().Lister(),
+				m.ClusterAutoApprovalUsers,
+				denyList,
+				sanAllowList,
+				identityVerifier,
+				csrAuditSink,
+				controllerContext.EventRecorder,
+			), csr.NewCSRBootstrapTokenReconciler(
+				kubeClient,
+				clusterClient,
+				clusterInformers.Cluster().V1().ManagedClusters().Lister(),
+				denyList,
+				sanAllowList,
+				identityVerifier,
+				csrAuditSink,
+				controllerContext.EventRecorder,
+			))
+		}
+
+		if features.DefaultHubMutableFeatureGate.Enabled(ocmfeature.V1beta1CSRAPICompatibility) {
+			v1CSRSupported, v1beta1CSRSupported, err := helpers.IsCSRSupported(kubeClient)
+			if err != nil {
+				return errors.Wrapf(err, "failed CSR api discovery")
+			}
+
+			if !v1CSRSupported && v1beta1CSRSupported {
+				csrController = csr.NewCSRApprovingController[*certv1beta1.CertificateSigningRequest](
+					csrInformers.Certificates().V1beta1().CertificateSigningRequests().Informer(),
+					csrInformers.Certificates().V1beta1().CertificateSigningRequests().Lister(),
+					csr.NewCSRV1beta1Approver(kubeClient),
+					csrReconciles,
+					controllerContext.EventRecorder,
+				)
+				klog.Info("Using v1beta1 CSR api to manage spoke client certificate")
+			}
+		}
+		if csrController == nil {
+			csrController = csr.NewCSRApprovingController[*certv1.CertificateSigningRequest](
+				csrInformers.Certificates().V1().CertificateSigningRequests().Informer(),
+				csrInformers.Certificates().V1().CertificateSigningRequests().Lister(),
+				csr.NewCSRV1Approver(kubeClient),
 				csrReconciles,
 				controllerContext.EventRecorder,
 			)
-			klog.Info("Using v1beta1 CSR api to manage spoke client certificate")
+
+			// the addon CSRs are pruned only with the v1 CSR api
+			if m.AddOnCSRPruneAge > 0 {
+				csrGCController = csr.NewCSRGCController(
+					kubeClient,
+					csrInformers.Certificates().V1().CertificateSigningRequests(),
+					m.AddOnCSRPruneAge,
+					controllerContext.EventRecorder,
+				)
+			}
+
+			// the pending registration CSRs are tracked only with the v1 CSR api
+			csrPendingController = csr.NewCSRPendingController(
+				kubeClient,
+				clusterClient,
+				csrInformers.Certificates().V1().CertificateSigningRequests(),
+				clusterInformers.Cluster().V1().ManagedClusters(),
+				m.CSRPendingCondition,
+				controllerContext.EventRecorder,
+			)
+
+			// the addon CSRs of the custom signers and the serving CSRs of the agents are signed only with the v1 CSR api
+			if signerCASecrets := m.signerCASecrets(); len(signerCASecrets) > 0 {
+				caSecretInformers := map[string]corev1informers.SecretInformer{}
+				for _, secret := range signerCASecrets {
+					// the secrets are validated already
+					namespace, _, _ := cache.SplitMetaNamespaceKey(secret)
+					caSecretInformers[namespace] = hubSecretInformer(namespace)
+				}
+				csrSigningController = csr.NewCSRSigningController(
+					kubeClient,
+					addOnClient,
+					csrInformers.Certificates().V1().CertificateSigningRequests(),
+					addOnInformers.Addon().V1alpha1().ManagedClusterAddOns(),
+					caSecretInformers,
+					signerCASecrets,
+					m.AddOnSignerCertDuration,
+					controllerContext.EventRecorder,
+				)
+			}
 		}
 	}
-	if csrController == nil {
-		csrController = csr.NewCSRApprovingController[*certv1.CertificateSigningRequest](
-			kubeInfomers.Certificates().V1().CertificateSigningRequests().Informer(),
-			kubeInfomers.Certificates().V1().CertificateSigningRequests().Lister(),
-			csr.NewCSRV1Approver(kubeClient),
-			csrReconciles,
-			controllerContext.EventRecorder,
-		)
-	}
 
 	leaseController := lease.NewClusterLeaseController(
 		kubeClient,
 		clusterClient,
-		clusterInformers.Cluster().V1().ManagedClusters(),
-		kubeInfomers.Coordination().V1().Leases(),
+		shardClusterInformers.Cluster().V1().ManagedClusters(),
+		leaseInformers.Coordination().V1().Leases(),
+		m.ClockSkewTolerance,
+		clock.RealClock{},
 		controllerContext.EventRecorder,
 	)
 
-	rbacFinalizerController := rbacfinalizerdeletion.NewFinalizeController(
-		kubeInfomers.Rbac().V1().Roles(),
-		kubeInfomers.Rbac().V1().RoleBindings(),
-		kubeInfomers.Core().V1().Namespaces().Lister(),
-		clusterInformers.Cluster().V1().ManagedClusters().Lister(),
-		workInformers.Work().V1().ManifestWorks().Lister(),
-		kubeClient.RbacV1(),
+	clusterLifecycleEventController := clusterevents.NewClusterLifecycleEventController(
+		kubeClient,
+		shardClusterInformers.Cluster().V1().ManagedClusters(),
 		controllerContext.EventRecorder,
 	)
 
-	managedClusterSetController := managedclusterset.NewManagedClusterSetController(
+	onboardingController := onboarding.NewOnboardingController(
 		clusterClient,
-		clusterInformers.Cluster().V1().ManagedClusters(),
-		clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
+		shardClusterInformers.Cluster().V1().ManagedClusters(),
 		controllerContext.EventRecorder,
 	)
 
-	managedClusterSetBindingController := managedclustersetbinding.NewManagedClusterSetBindingController(
-		clusterClient,
-		clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
-		clusterInformers.Cluster().V1beta2().ManagedClusterSetBindings(),
-		controllerContext.EventRecorder,
-	)
+	var rbacFinalizerController, managedClusterSetController, managedClusterSetBindingController factory.Controller
+	var clusterroleController, shardAssignmentController, duplicateClusterIDController factory.Controller
+	if primary {
+		rbacFinalizerController = rbacfinalizerdeletion.NewFinalizeController(
+			kubeInfomers.Rbac().V1().Roles(),
+			kubeInfomers.Rbac().V1().RoleBindings(),
+			kubeInfomers.Core().V1().Namespaces().Lister(),
+			clusterInformers.Cluster().V1().ManagedClusters().Lister(),
+			workInformers.Work().V1().ManifestWorks().Lister(),
+			kubeClient.RbacV1(),
+			controllerContext.EventRecorder,
+		)
 
-	clusterroleController := clusterrole.NewManagedClusterClusterroleController(
-		kubeClient,
-		clusterInformers.Cluster().V1().ManagedClusters(),
-		kubeInfomers.Rbac().V1().ClusterRoles(),
+		managedClusterSetController = managedclusterset.NewManagedClusterSetController(
+			clusterClient,
+			clusterInformers.Cluster().V1().ManagedClusters(),
+			clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
+			controllerContext.EventRecorder,
+		)
+
+		managedClusterSetBindingController = managedclustersetbinding.NewManagedClusterSetBindingController(
+			kubeClient,
+			clusterClient,
+			clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
+			clusterInformers.Cluster().V1beta2().ManagedClusterSetBindings(),
+			controllerContext.EventRecorder,
+		)
+
+		clusterroleController = clusterrole.NewManagedClusterClusterroleController(
+			kubeClient,
+			clusterInformers.Cluster().V1().ManagedClusters(),
+			kubeInfomers.Rbac().V1().ClusterRoles(),
+			kubeInfomers.Rbac().V1().RoleBindings(),
+			controllerContext.EventRecorder,
+		)
+		// the cluster ids are compared across all of the clusters, so the controller runs in the shard 0 only
+		duplicateClusterIDController = clusterid.NewDuplicateClusterIDController(
+			clusterClient,
+			clusterInformers.Cluster().V1().ManagedClusters(),
+			controllerContext.EventRecorder,
+		)
+		if m.ShardCount > 1 {
+			shardAssignmentController = shard.NewShardAssignmentController(
+				clusterClient,
+				clusterInformers.Cluster().V1().ManagedClusters(),
+				m.ShardCount,
+				controllerContext.EventRecorder,
+			)
+		}
+	}
+
+	addOnHealthCheckController := addon.NewManagedClusterAddOnHealthCheckController(
+		addOnClient,
+		addOnInformers.Addon().V1alpha1().ManagedClusterAddOns(),
+		shardClusterInformers.Cluster().V1().ManagedClusters(),
 		controllerContext.EventRecorder,
 	)
 
-	addOnHealthCheckController := addon.NewManagedClusterAddOnHealthCheckController(
+	// the addon leases are created by the addon agents without the labels of the cluster leases, so they are watched
+	// by an unfiltered lease informer if the leases of the clusters are filtered
+	addOnLeaseInformer := leaseInformers.Coordination().V1().Leases()
+	if len(m.LeaseLabelSelector) > 0 {
+		addOnLeaseInformer = kubeInfomers.Coordination().V1().Leases()
+	}
+	addOnLeaseController := addon.NewAddOnLeaseController(
 		addOnClient,
 		addOnInformers.Addon().V1alpha1().ManagedClusterAddOns(),
-		clusterInformers.Cluster().V1().ManagedClusters(),
+		addOnLeaseInformer,
+		clock.RealClock{},
 		controllerContext.EventRecorder,
 	)
 
 	addOnFeatureDiscoveryController := addon.NewAddOnFeatureDiscoveryController(
 		clusterClient,
-		clusterInformers.Cluster().V1().ManagedClusters(),
+		shardClusterInformers.Cluster().V1().ManagedClusters(),
 		addOnInformers.Addon().V1alpha1().ManagedClusterAddOns(),
+		m.AddOnStatusConditionSuffixes,
 		controllerContext.EventRecorder,
 	)
 
-	var defaultManagedClusterSetController, globalManagedClusterSetController factory.Controller
-	if features.DefaultHubMutableFeatureGate.Enabled(ocmfeature.DefaultClusterSet) {
+	// the ClusterManagementAddOns are watched with a separate informer factory, so that the addon label selector
+	// only restricts the ManagedClusterAddOns
+	var clusterManagementAddOnInformers addoninformers.SharedInformerFactory
+	var addOnInstallLabelController factory.Controller
+	if features.DefaultHubMutableFeatureGate.Enabled(features.AddOnInstallLabels) {
+		clusterManagementAddOnInformers = addoninformers.NewSharedInformerFactory(addOnClient, m.AddOnResyncInterval)
+		addOnInstallLabelController = addon.NewAddOnInstallLabelController(
+			clusterClient,
+			shardClusterInformers.Cluster().V1().ManagedClusters(),
+			clusterManagementAddOnInformers.Addon().V1alpha1().ClusterManagementAddOns(),
+			clusterInformers.Cluster().V1beta1().PlacementDecisions(),
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var acceptanceReviewController factory.Controller
+	if m.ClusterAcceptanceReview {
+		acceptanceReviewController = acceptancereview.NewAcceptanceReviewController(
+			clusterClient,
+			shardClusterInformers.Cluster().V1().ManagedClusters(),
+			m.ClusterAcceptanceReviewTimeout,
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var certExpirationController factory.Controller
+	if m.ClusterCertExpirationWarningPeriod > 0 {
+		certExpirationController = certexpiration.NewCertExpirationController(
+			clusterClient,
+			shardClusterInformers.Cluster().V1().ManagedClusters(),
+			m.ClusterCertExpirationWarningPeriod,
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var bootstrapKubeconfigController factory.Controller
+	var bootstrapKubeconfigInformers kubeinformers.SharedInformerFactory
+	if len(m.BootstrapKubeconfigSecret) > 0 {
+		// the secret is validated already
+		namespace, name, _ := cache.SplitMetaNamespaceKey(m.BootstrapKubeconfigSecret)
+		bootstrapKubeconfigInformers = kubeinformers.NewSharedInformerFactoryWithOptions(
+			kubeClient, 10*time.Minute, kubeinformers.WithNamespace(namespace))
+		bootstrapKubeconfigController = bootstrapkubeconfig.NewBootstrapKubeconfigController(
+			kubeClient,
+			shardClusterInformers.Cluster().V1().ManagedClusters(),
+			bootstrapKubeconfigInformers.Core().V1().Secrets(),
+			namespace, name,
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var hubCABundleController factory.Controller
+	var hubCABundleInformers kubeinformers.SharedInformerFactory
+	if len(m.HubCABundleConfigMap) > 0 {
+		// the configmap is validated already
+		namespace, name, _ := cache.SplitMetaNamespaceKey(m.HubCABundleConfigMap)
+		hubCABundleInformers = kubeinformers.NewSharedInformerFactoryWithOptions(
+			kubeClient, 10*time.Minute, kubeinformers.WithNamespace(namespace))
+		hubCABundleController = hubcabundle.NewHubCABundleController(
+			kubeClient,
+			shardClusterInformers.Cluster().V1().ManagedClusters(),
+			hubCABundleInformers.Core().V1().ConfigMaps(),
+			namespace, name,
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var clusterEndpointsController factory.Controller
+	var clusterEndpointsInformers kubeinformers.SharedInformerFactory
+	if m.SyncClusterEndpoints {
+		// only watch the cluster endpoints secrets in the cluster namespaces
+		clusterEndpointsInformers = kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 10*time.Minute,
+			kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.FieldSelector = fields.OneTermEqualSelector("metadata.name", helpers.ClusterEndpointsSecretName).String()
+			}))
+		clusterEndpointsController = clusterendpoints.NewClusterEndpointsController(
+			kubeClient,
+			clusterClient,
+			shardClusterInformers.Cluster().V1().ManagedClusters(),
+			clusterEndpointsInformers.Core().V1().Secrets(),
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var availabilityHistoryController factory.Controller
+	var availabilityHistoryInformers kubeinformers.SharedInformerFactory
+	if m.AvailabilityHistoryLength > 0 {
+		// only watch the availability history configmaps in the cluster namespaces
+		availabilityHistoryInformers = kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 10*time.Minute,
+			kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.FieldSelector = fields.OneTermEqualSelector("metadata.name", availabilityhistory.ConfigMapName).String()
+			}))
+		availabilityHistoryController = availabilityhistory.NewAvailabilityHistoryController(
+			kubeClient,
+			shardClusterInformers.Cluster().V1().ManagedClusters(),
+			availabilityHistoryInformers.Core().V1().ConfigMaps(),
+			m.AvailabilityHistoryLength,
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var awsIAMController factory.Controller
+	var awsAuthInformers kubeinformers.SharedInformerFactory
+	if primary && len(m.AWSIAMRolePatterns) > 0 {
+		// only watch the aws-auth configmap of the EKS hub
+		awsAuthInformers = kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 10*time.Minute,
+			kubeinformers.WithNamespace(awsiam.AWSAuthConfigMapNamespace),
+			kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.FieldSelector = fields.OneTermEqualSelector("metadata.name", awsiam.AWSAuthConfigMapName).String()
+			}))
+		awsIAMController = awsiam.NewAWSIAMController(
+			kubeClient,
+			clusterInformers.Cluster().V1().ManagedClusters(),
+			awsAuthInformers.Core().V1().ConfigMaps(),
+			m.AWSIAMRolePatterns,
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var defaultManagedClusterSetController, globalManagedClusterSetController, defaultClusterSetLabelController factory.Controller
+	if primary && features.DefaultHubMutableFeatureGate.Enabled(ocmfeature.DefaultClusterSet) {
 		defaultManagedClusterSetController = managedclusterset.NewDefaultManagedClusterSetController(
 			clusterClient.ClusterV1beta2(),
 			clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
@@ -211,28 +1081,188 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 			clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
 			controllerContext.EventRecorder,
 		)
+		defaultClusterSetLabelController = managedclusterset.NewDefaultClusterSetLabelController(
+			clusterClient,
+			clusterInformers.Cluster().V1().ManagedClusters(),
+			clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var mqttClient *mqtt.Client
+	var statusConsumerController factory.Controller
+	if m.RegistrationTransport == transport.TransportMQTT {
+		mqttClient, err = m.newMQTTClient()
+		if err != nil {
+			return err
+		}
+		statusConsumerController = statusconsumer.NewStatusConsumerController(
+			kubeClient,
+			clusterClient,
+			shardClusterInformers.Cluster().V1().ManagedClusters(),
+			mqttClient,
+			controllerContext.EventRecorder,
+		)
 	}
 
 	go clusterInformers.Start(ctx.Done())
+	if len(shardSelectors) > 0 {
+		go shardClusterInformers.Start(ctx.Done())
+	}
 	go workInformers.Start(ctx.Done())
 	go kubeInfomers.Start(ctx.Done())
+	go leaseInformers.Start(ctx.Done())
+	go csrInformers.Start(ctx.Done())
 	go addOnInformers.Start(ctx.Done())
+	if bootstrapKubeconfigInformers != nil {
+		go bootstrapKubeconfigInformers.Start(ctx.Done())
+	}
+	if hubCABundleInformers != nil {
+		go hubCABundleInformers.Start(ctx.Done())
+	}
+	if clusterEndpointsInformers != nil {
+		go clusterEndpointsInformers.Start(ctx.Done())
+	}
+	if availabilityHistoryInformers != nil {
+		go availabilityHistoryInformers.Start(ctx.Done())
+	}
+	if awsAuthInformers != nil {
+		go awsAuthInformers.Start(ctx.Done())
+	}
+	if clusterManagementAddOnInformers != nil {
+		go clusterManagementAddOnInformers.Start(ctx.Done())
+	}
+	if namespacePolicyInformers != nil {
+		go namespacePolicyInformers.Start(ctx.Done())
+		go namespacePolicyResourceInformers.Start(ctx.Done())
+	}
+	for _, informers := range hubSecretInformers {
+		go informers.Start(ctx.Done())
+	}
+
+	if primary && features.DefaultHubMutableFeatureGate.Enabled(features.AgentlessRegistration) {
+		clientCAs, err := cert.NewPool(m.AgentlessRegistrationClientCAFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load the agentless registration client ca file")
+		}
+		server := grpcserver.NewServer(kubeClient, clusterClient,
+			grpcserver.NewImpersonatingClientFunc(kubeConfig), controllerContext.EventRecorder)
+		go func() {
+			if err := server.Run(ctx, m.AgentlessRegistrationBindAddress,
+				m.AgentlessRegistrationCertFile, m.AgentlessRegistrationKeyFile, clientCAs); err != nil {
+				klog.Errorf("failed to serve the agent-less registration: %v", err)
+			}
+		}()
+	}
 
 	go managedClusterController.Run(ctx, 1)
-	go taintController.Run(ctx, 1)
-	go csrController.Run(ctx, 1)
+	go clusterSetNamespaceLabelController.Run(ctx, 1)
+	if features.DefaultHubMutableFeatureGate.Enabled(features.ClusterTaint) {
+		go taintController.Run(ctx, 1)
+		go maintenanceController.Run(ctx, 1)
+	}
+	if flappingController != nil {
+		go flappingController.Run(ctx, 1)
+	}
 	go leaseController.Run(ctx, 1)
-	go rbacFinalizerController.Run(ctx, 1)
-	go managedClusterSetController.Run(ctx, 1)
-	go managedClusterSetBindingController.Run(ctx, 1)
-	go clusterroleController.Run(ctx, 1)
+	go clusterLifecycleEventController.Run(ctx, 1)
+	go onboardingController.Run(ctx, 1)
+	if primary {
+		go csrController.Run(ctx, 1)
+		go rbacFinalizerController.Run(ctx, 1)
+		go managedClusterSetController.Run(ctx, 1)
+		go managedClusterSetBindingController.Run(ctx, 1)
+		go clusterroleController.Run(ctx, 1)
+		go duplicateClusterIDController.Run(ctx, 1)
+	}
+	if shardAssignmentController != nil {
+		go shardAssignmentController.Run(ctx, 1)
+	}
 	go addOnHealthCheckController.Run(ctx, 1)
+	go addOnLeaseController.Run(ctx, 1)
 	go addOnFeatureDiscoveryController.Run(ctx, 1)
-	if features.DefaultHubMutableFeatureGate.Enabled(ocmfeature.DefaultClusterSet) {
+	if addOnInstallLabelController != nil {
+		go addOnInstallLabelController.Run(ctx, 1)
+	}
+	if bootstrapKubeconfigController != nil {
+		go bootstrapKubeconfigController.Run(ctx, 1)
+	}
+	if hubCABundleController != nil {
+		go hubCABundleController.Run(ctx, 1)
+	}
+	if clusterEndpointsController != nil {
+		go clusterEndpointsController.Run(ctx, 1)
+	}
+	if availabilityHistoryController != nil {
+		go availabilityHistoryController.Run(ctx, 1)
+	}
+	if certExpirationController != nil {
+		go certExpirationController.Run(ctx, 1)
+	}
+	if awsIAMController != nil {
+		go awsIAMController.Run(ctx, 1)
+	}
+	if acceptanceReviewController != nil {
+		go acceptanceReviewController.Run(ctx, 1)
+	}
+	if csrGCController != nil {
+		go csrGCController.Run(ctx, 1)
+	}
+	if csrSigningController != nil {
+		go csrSigningController.Run(ctx, 1)
+	}
+	if csrPendingController != nil {
+		go csrPendingController.Run(ctx, 1)
+	}
+	if mqttClient != nil {
+		go mqttClient.Run(ctx)
+		go statusConsumerController.Run(ctx, 1)
+	}
+	if primary && features.DefaultHubMutableFeatureGate.Enabled(ocmfeature.DefaultClusterSet) {
 		go defaultManagedClusterSetController.Run(ctx, 1)
 		go globalManagedClusterSetController.Run(ctx, 1)
+		go defaultClusterSetLabelController.Run(ctx, 1)
 	}
 
 	<-ctx.Done()
 	return nil
 }
+
+// newMQTTClient returns the MQTT client to consume the heartbeats and status of the managed clusters
+func (m *HubManagerOptions) newMQTTClient() (*mqtt.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(m.MQTTCAFile) > 0 {
+		pool, err := cert.NewPool(m.MQTTCAFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load the mqtt ca file")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(m.MQTTClientCertFile) > 0 {
+		clientCert, err := tls.LoadX509KeyPair(m.MQTTClientCertFile, m.MQTTClientKeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load the mqtt client cert")
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	clientID, err := m.mqttClientID()
+	if err != nil {
+		return nil, err
+	}
+	return mqtt.NewClient(mqtt.Options{
+		BrokerURL: m.MQTTBrokerURL,
+		ClientID:  clientID,
+		TLSConfig: tlsConfig,
+	})
+}
+
+// mqttClientID returns the client id of the replica to connect to the MQTT broker. The broker disconnects the
+// existing session once a client connects with the same id, so the id is unique with the shard and the pod name.
+func (m *HubManagerOptions) mqttClientID() (string, error) {
+	podName, err := os.Hostname()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the pod name for the mqtt client id")
+	}
+	return fmt.Sprintf("registration-hub-controller-shard-%d-%s", m.ShardID, podName), nil
+}
@@ -0,0 +1,438 @@
+package hub
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"open-cluster-management.io/registration/pkg/features"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+	"open-cluster-management.io/registration/pkg/transport"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name        string
+		options     *HubManagerOptions
+		expectedErr string
+	}{
+		{
+			name:        "default options",
+			options:     NewHubManagerOptions(),
+			expectedErr: "",
+		},
+		{
+			name: "invalid cluster resync interval",
+			options: &HubManagerOptions{
+				AddOnResyncInterval: 10 * time.Minute,
+				CSRResyncInterval:   10 * time.Minute,
+			},
+			expectedErr: "cluster resync interval must greater than zero",
+		},
+		{
+			name: "invalid addon resync interval",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+			},
+			expectedErr: "addon resync interval must greater than zero",
+		},
+		{
+			name: "invalid csr resync interval",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+			},
+			expectedErr: "csr resync interval must greater than zero",
+		},
+		{
+			name: "invalid denied cluster pattern",
+			options: &HubManagerOptions{
+				ClusterResyncInterval:             10 * time.Minute,
+				AddOnResyncInterval:               10 * time.Minute,
+				CSRResyncInterval:                 10 * time.Minute,
+				ClusterAutoApprovalDeniedClusters: []string{"dev-["},
+			},
+			expectedErr: "the denied cluster pattern \"dev-[\" is invalid: syntax error in pattern",
+		},
+		{
+			name: "invalid cluster acceptance review timeout",
+			options: &HubManagerOptions{
+				ClusterResyncInterval:          10 * time.Minute,
+				AddOnResyncInterval:            10 * time.Minute,
+				CSRResyncInterval:              10 * time.Minute,
+				ClusterAcceptanceReviewTimeout: -1 * time.Minute,
+			},
+			expectedErr: "cluster acceptance review timeout must not be negative",
+		},
+		{
+			name: "invalid csr allowed ip range",
+			options: &HubManagerOptions{
+				ClusterResyncInterval:     10 * time.Minute,
+				AddOnResyncInterval:       10 * time.Minute,
+				CSRResyncInterval:         10 * time.Minute,
+				ClusterCSRAllowedIPRanges: []string{"10.0.0.1"},
+			},
+			expectedErr: "the allowed ip range \"10.0.0.1\" is invalid: invalid CIDR address: 10.0.0.1",
+		},
+		{
+			name: "invalid bootstrap kubeconfig secret",
+			options: &HubManagerOptions{
+				ClusterResyncInterval:     10 * time.Minute,
+				AddOnResyncInterval:       10 * time.Minute,
+				CSRResyncInterval:         10 * time.Minute,
+				BootstrapKubeconfigSecret: "bootstrap-hub-kubeconfig",
+			},
+			expectedErr: "bootstrap kubeconfig secret \"bootstrap-hub-kubeconfig\" must be in the format of namespace/name",
+		},
+		{
+			name: "invalid hub CA bundle configmap",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+				HubCABundleConfigMap:  "hub-ca-bundle",
+			},
+			expectedErr: "hub CA bundle configmap \"hub-ca-bundle\" must be in the format of namespace/name",
+		},
+		{
+			name: "csr audit sinks",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+				CSRAuditSinks:         []string{"event", "log", "webhook"},
+				CSRAuditWebhookURL:    "https://audit.example.com",
+			},
+			expectedErr: "",
+		},
+		{
+			name: "unsupported csr audit sink",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+				CSRAuditSinks:         []string{"configmap"},
+			},
+			expectedErr: "unsupported csr audit sink \"configmap\"",
+		},
+		{
+			name: "csr audit webhook without url",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+				CSRAuditSinks:         []string{"webhook"},
+			},
+			expectedErr: "csr audit webhook url is required by the webhook audit sink",
+		},
+		{
+			name: "invalid cluster cert expiration warning period",
+			options: &HubManagerOptions{
+				ClusterResyncInterval:              10 * time.Minute,
+				AddOnResyncInterval:                10 * time.Minute,
+				CSRResyncInterval:                  10 * time.Minute,
+				ClusterCertExpirationWarningPeriod: -1 * time.Hour,
+			},
+			expectedErr: "cluster cert expiration warning period must not be negative",
+		},
+		{
+			name: "invalid addon csr prune age",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+				AddOnCSRPruneAge:      -1 * time.Hour,
+			},
+			expectedErr: "addon csr prune age must not be negative",
+		},
+		{
+			name: "invalid addon signer ca secret",
+			options: &HubManagerOptions{
+				ClusterResyncInterval:   10 * time.Minute,
+				AddOnResyncInterval:     10 * time.Minute,
+				CSRResyncInterval:       10 * time.Minute,
+				AddOnSignerCASecrets:    map[string]string{"example.com/signer": "signer-ca"},
+				AddOnSignerCertDuration: 24 * time.Hour,
+			},
+			expectedErr: "ca secret \"signer-ca\" of signer \"example.com/signer\" must be in the format of namespace/name",
+		},
+		{
+			name: "invalid cluster serving signer ca secret",
+			options: &HubManagerOptions{
+				ClusterResyncInterval:        10 * time.Minute,
+				AddOnResyncInterval:          10 * time.Minute,
+				CSRResyncInterval:            10 * time.Minute,
+				ClusterServingSignerCASecret: "serving-ca",
+				AddOnSignerCertDuration:      24 * time.Hour,
+			},
+			expectedErr: "ca secret \"serving-ca\" of signer \"open-cluster-management.io/cluster-serving\" must be in the format of namespace/name",
+		},
+		{
+			name: "invalid cluster namespace policy configmap",
+			options: &HubManagerOptions{
+				ClusterResyncInterval:           10 * time.Minute,
+				AddOnResyncInterval:             10 * time.Minute,
+				CSRResyncInterval:               10 * time.Minute,
+				ClusterNamespacePolicyConfigMap: "namespace-policy",
+			},
+			expectedErr: "cluster namespace policy configmap \"namespace-policy\" must be in the format of namespace/name",
+		},
+		{
+			name: "sharding",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+				ShardCount:            4,
+				ShardID:               3,
+			},
+			expectedErr: "",
+		},
+		{
+			name: "invalid shard id",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+				ShardCount:            4,
+				ShardID:               4,
+			},
+			expectedErr: "shard id 4 must be in the range of [0, 4)",
+		},
+		{
+			name: "invalid cluster label selector",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+				ClusterLabelSelector:  "env in (prod",
+			},
+			expectedErr: "cluster label selector \"env in (prod\" is invalid: unable to parse requirement: found '', expected: ',' or ')'",
+		},
+		{
+			name: "invalid tracing sampling ratio",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+				TracingSamplingRatio:  1.5,
+			},
+			expectedErr: "tracing sampling ratio 1.5 must be in the range of [0, 1]",
+		},
+		{
+			name: "unsupported logging format",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+				LoggingFormat:         "yaml",
+			},
+			expectedErr: "logging format \"yaml\" is not supported, it must be text or json",
+		},
+		{
+			name: "empty addon status condition suffix",
+			options: &HubManagerOptions{
+				ClusterResyncInterval:        10 * time.Minute,
+				AddOnResyncInterval:          10 * time.Minute,
+				CSRResyncInterval:            10 * time.Minute,
+				AddOnStatusConditionSuffixes: map[string]string{"Degraded": ""},
+			},
+			expectedErr: "the condition type and suffix of the addon status must not be empty",
+		},
+		{
+			name: "invalid availability history length",
+			options: &HubManagerOptions{
+				ClusterResyncInterval:     10 * time.Minute,
+				AddOnResyncInterval:       10 * time.Minute,
+				CSRResyncInterval:         10 * time.Minute,
+				AvailabilityHistoryLength: 1000,
+			},
+			expectedErr: "availability history length 1000 must be in the range of [0, 100]",
+		},
+		{
+			name: "invalid clock skew tolerance",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+				ClockSkewTolerance:    -time.Second,
+			},
+			expectedErr: "clock skew tolerance must not be negative",
+		},
+		{
+			name: "invalid kube api burst",
+			options: &HubManagerOptions{
+				ClusterResyncInterval: 10 * time.Minute,
+				AddOnResyncInterval:   10 * time.Minute,
+				CSRResyncInterval:     10 * time.Minute,
+				KubeAPIQPS:            100,
+				KubeAPIBurst:          -1,
+			},
+			expectedErr: "kube api qps and burst must not be negative",
+		},
+		{
+			name: "invalid availability flap window",
+			options: &HubManagerOptions{
+				ClusterResyncInterval:     10 * time.Minute,
+				AddOnResyncInterval:       10 * time.Minute,
+				CSRResyncInterval:         10 * time.Minute,
+				AvailabilityFlapThreshold: 3,
+				AvailabilityFlapHoldDown:  time.Minute,
+			},
+			expectedErr: "availability flap window and hold-down must greater than zero",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.options.Validate()
+			testinghelpers.AssertError(t, err, c.expectedErr)
+		})
+	}
+}
+
+func TestValidateAgentlessRegistration(t *testing.T) {
+	if err := features.DefaultHubMutableFeatureGate.Set(fmt.Sprintf("%s=true", string(features.AgentlessRegistration))); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := features.DefaultHubMutableFeatureGate.Set(fmt.Sprintf("%s=false", string(features.AgentlessRegistration))); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	options := NewHubManagerOptions()
+	testinghelpers.AssertError(t, options.Validate(),
+		"agentless registration cert file, key file and client ca file are required by the AgentlessRegistration feature")
+
+	options.AgentlessRegistrationCertFile = "/serving-cert/tls.crt"
+	options.AgentlessRegistrationKeyFile = "/serving-cert/tls.key"
+	options.AgentlessRegistrationClientCAFile = "/client-ca/ca.crt"
+	testinghelpers.AssertError(t, options.Validate(), "")
+}
+
+func TestValidateAWSIAMRolePatterns(t *testing.T) {
+	options := NewHubManagerOptions()
+	options.AWSIAMRolePatterns = []string{"arn:aws:iam::123456789012:role/ocm-{cluster}"}
+	testinghelpers.AssertError(t, options.Validate(), "aws iam role patterns require the AWSIAMRegistrationDriver feature")
+
+	if err := features.DefaultHubMutableFeatureGate.Set(fmt.Sprintf("%s=true", string(features.AWSIAMRegistrationDriver))); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := features.DefaultHubMutableFeatureGate.Set(fmt.Sprintf("%s=false", string(features.AWSIAMRegistrationDriver))); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	testinghelpers.AssertError(t, options.Validate(), "")
+
+	options.AWSIAMRolePatterns = []string{"arn:aws:iam::123456789012:role/ocm-*"}
+	testinghelpers.AssertError(t, options.Validate(),
+		"the aws iam role pattern \"arn:aws:iam::123456789012:role/ocm-*\" must contain {cluster}")
+
+	options.AWSIAMRolePatterns = []string{"arn:aws:iam::123456789012:role/ocm-{cluster}-["}
+	testinghelpers.AssertError(t, options.Validate(),
+		"the aws iam role pattern \"arn:aws:iam::123456789012:role/ocm-{cluster}-[\" is invalid: syntax error in pattern")
+}
+
+func TestValidateRegistrationTransport(t *testing.T) {
+	options := NewHubManagerOptions()
+	options.RegistrationTransport = "grpc"
+	testinghelpers.AssertError(t, options.Validate(), "registration transport \"grpc\" is invalid, it must be one of [kube mqtt]")
+
+	options.RegistrationTransport = transport.TransportMQTT
+	testinghelpers.AssertError(t, options.Validate(), "registration transport \"mqtt\" requires the MQTTTransport feature")
+
+	if err := features.DefaultHubMutableFeatureGate.Set(fmt.Sprintf("%s=true", string(features.MQTTTransport))); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := features.DefaultHubMutableFeatureGate.Set(fmt.Sprintf("%s=false", string(features.MQTTTransport))); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	testinghelpers.AssertError(t, options.Validate(), "mqtt broker url is required by the mqtt registration transport")
+
+	options.MQTTBrokerURL = "tls://broker:8883"
+	options.MQTTClientCertFile = "/mqtt/tls.crt"
+	testinghelpers.AssertError(t, options.Validate(), "mqtt client cert file and key file must be specified together")
+
+	options.MQTTClientKeyFile = "/mqtt/tls.key"
+	testinghelpers.AssertError(t, options.Validate(), "")
+}
+
+func TestLeaderElectionLockName(t *testing.T) {
+	cases := []struct {
+		name         string
+		options      *HubManagerOptions
+		expectedName string
+	}{
+		{
+			name:    "all of the clusters",
+			options: &HubManagerOptions{},
+		},
+		{
+			name:         "shard",
+			options:      &HubManagerOptions{ShardCount: 3, ShardID: 1},
+			expectedName: "registration-controller-shard-1-lock",
+		},
+		{
+			name:         "label selectors",
+			options:      &HubManagerOptions{ClusterLabelSelector: "env=prod"},
+			expectedName: "registration-controller-selector-",
+		},
+		{
+			name:         "shard and label selectors",
+			options:      &HubManagerOptions{ShardCount: 3, ShardID: 1, LeaseLabelSelector: "env=prod"},
+			expectedName: "registration-controller-shard-1-selector-",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			name := c.options.LeaderElectionLockName()
+			if len(c.expectedName) == 0 && len(name) != 0 {
+				t.Errorf("expected no lock name, but got %q", name)
+			}
+			if !strings.HasPrefix(name, c.expectedName) {
+				t.Errorf("expected lock name %q, but got %q", c.expectedName, name)
+			}
+		})
+	}
+
+	// the replicas of different selectors are elected separately
+	prod := (&HubManagerOptions{ClusterLabelSelector: "env=prod"}).LeaderElectionLockName()
+	dev := (&HubManagerOptions{ClusterLabelSelector: "env=dev"}).LeaderElectionLockName()
+	if prod == dev {
+		t.Errorf("expected different lock names, but got %q", prod)
+	}
+	lease := (&HubManagerOptions{LeaseLabelSelector: "env=prod"}).LeaderElectionLockName()
+	if prod == lease {
+		t.Errorf("expected different lock names for the cluster and lease selectors, but got %q", prod)
+	}
+}
+
+func TestMQTTClientID(t *testing.T) {
+	shard0, err := (&HubManagerOptions{ShardCount: 2}).mqttClientID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shard1, err := (&HubManagerOptions{ShardCount: 2, ShardID: 1}).mqttClientID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the replicas of different shards connect with different client ids
+	if shard0 == shard1 {
+		t.Errorf("expected different client ids, but got %q", shard0)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(shard1, "-shard-1-"+hostname) {
+		t.Errorf("expected the client id with the shard and pod name, but got %q", shard1)
+	}
+}
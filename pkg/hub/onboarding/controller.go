@@ -0,0 +1,189 @@
+package onboarding
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/logging"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	// AcceptedTimeAnnotation, JoinedTimeAnnotation and AvailableTimeAnnotation are the annotations on a managed
+	// cluster holding the RFC3339 times it is first accepted, joined and available after it is accepted. They are
+	// removed once the cluster is denied, so the onboarding is tracked again after the cluster is accepted again.
+	AcceptedTimeAnnotation  = "cluster.open-cluster-management.io/accepted-time"
+	JoinedTimeAnnotation    = "cluster.open-cluster-management.io/joined-time"
+	AvailableTimeAnnotation = "cluster.open-cluster-management.io/available-time"
+)
+
+// clusterJoinDuration is the duration from the acceptance of each managed cluster to its joined and available
+// conditions turn true
+var clusterJoinDuration = metrics.NewHistogramVec(
+	&metrics.HistogramOpts{
+		Subsystem:      "registration",
+		Name:           "managed_cluster_join_duration_seconds",
+		Help:           "The duration from the acceptance of the managed cluster to the condition turns true.",
+		Buckets:        metrics.ExponentialBuckets(5, 2, 12),
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"condition"},
+)
+
+func init() {
+	legacyregistry.MustRegister(clusterJoinDuration)
+}
+
+// onboardingStage is a stage of the onboarding, the time of the stage is the last transition time of the condition
+type onboardingStage struct {
+	annotation    string
+	conditionType string
+}
+
+// onboardingStages are the stages of the onboarding in order, a stage is only recorded after the previous one
+var onboardingStages = []onboardingStage{
+	{annotation: AcceptedTimeAnnotation, conditionType: clusterv1.ManagedClusterConditionHubAccepted},
+	{annotation: JoinedTimeAnnotation, conditionType: clusterv1.ManagedClusterConditionJoined},
+	{annotation: AvailableTimeAnnotation, conditionType: clusterv1.ManagedClusterConditionAvailable},
+}
+
+// onboardingController records the times of the accepted, joined and available transitions of each managed cluster
+// in its annotations, and observes the join duration once the joined and available times are recorded. The times
+// are kept in the annotations rather than in memory, so the durations are observed once for each onboarding even if
+// the hub is restarted.
+type onboardingController struct {
+	clusterClient clientset.Interface
+	clusterLister clusterv1listers.ManagedClusterLister
+}
+
+// NewOnboardingController creates a new onboarding controller on hub cluster.
+func NewOnboardingController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &onboardingController{
+		clusterClient: clusterClient,
+		clusterLister: clusterInformer.Lister(),
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithSync(logging.SyncWithLogger("ManagedClusterOnboardingController", c.sync)).
+		ToController("ManagedClusterOnboardingController", recorder)
+}
+
+func (c *onboardingController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling onboarding of ManagedCluster")
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	// the annotations to patch, a nil value removes the annotation
+	annotations := map[string]interface{}{}
+	accepted := cluster.Spec.HubAcceptsClient &&
+		meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionHubAccepted)
+	if !accepted {
+		for _, stage := range onboardingStages {
+			if _, ok := cluster.Annotations[stage.annotation]; ok {
+				annotations[stage.annotation] = nil
+			}
+		}
+	} else {
+		for _, stage := range onboardingStages {
+			if _, ok := cluster.Annotations[stage.annotation]; ok {
+				continue
+			}
+			cond := meta.FindStatusCondition(cluster.Status.Conditions, stage.conditionType)
+			if cond == nil || cond.Status != metav1.ConditionTrue {
+				break
+			}
+			annotations[stage.annotation] = cond.LastTransitionTime.UTC().Format(time.RFC3339)
+		}
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.clusterClient.ClusterV1().ManagedClusters().Patch(
+		ctx, clusterName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return err
+	}
+
+	if !accepted {
+		return nil
+	}
+	acceptedTime, ok := stageTime(cluster, annotations, AcceptedTimeAnnotation)
+	if !ok {
+		return nil
+	}
+	for _, stage := range onboardingStages[1:] {
+		// only the stages just recorded are observed
+		if _, recorded := annotations[stage.annotation]; !recorded {
+			continue
+		}
+		t, ok := stageTime(cluster, annotations, stage.annotation)
+		if !ok {
+			continue
+		}
+		duration := t.Sub(acceptedTime)
+		if duration < 0 {
+			// the condition is not reset after the cluster is accepted again, e.g. the agent is not restarted
+			duration = 0
+		}
+		clusterJoinDuration.WithLabelValues(stage.conditionType).Observe(duration.Seconds())
+		logger.V(2).Info("ManagedCluster onboarding stage recorded", "condition", stage.conditionType, "duration", duration)
+	}
+	return nil
+}
+
+// stageTime returns the time of the stage, either just recorded or in the annotation of the cluster
+func stageTime(cluster *clusterv1.ManagedCluster, annotations map[string]interface{}, annotation string) (time.Time, bool) {
+	value, ok := annotations[annotation].(string)
+	if !ok {
+		value, ok = cluster.Annotations[annotation]
+	}
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,151 @@
+package onboarding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestSync(t *testing.T) {
+	acceptedTime := metav1.NewTime(time.Date(2023, 1, 2, 1, 0, 0, 0, time.UTC))
+	joinedTime := metav1.NewTime(acceptedTime.Add(30 * time.Second))
+	availableTime := metav1.NewTime(acceptedTime.Add(time.Minute))
+
+	cases := []struct {
+		name                string
+		cluster             *clusterv1.ManagedCluster
+		expectedAnnotations map[string]interface{}
+		expectedObserved    map[string]uint64
+	}{
+		{
+			name:    "unaccepted cluster",
+			cluster: testinghelpers.NewManagedCluster(),
+		},
+		{
+			name:    "accepted cluster",
+			cluster: newManagedCluster(nil, acceptedTime, nil, nil),
+			expectedAnnotations: map[string]interface{}{
+				AcceptedTimeAnnotation: "2023-01-02T01:00:00Z",
+			},
+		},
+		{
+			name:    "joined and available cluster",
+			cluster: newManagedCluster(nil, acceptedTime, &joinedTime, &availableTime),
+			expectedAnnotations: map[string]interface{}{
+				AcceptedTimeAnnotation:  "2023-01-02T01:00:00Z",
+				JoinedTimeAnnotation:    "2023-01-02T01:00:30Z",
+				AvailableTimeAnnotation: "2023-01-02T01:01:00Z",
+			},
+			expectedObserved: map[string]uint64{
+				clusterv1.ManagedClusterConditionJoined:    1,
+				clusterv1.ManagedClusterConditionAvailable: 1,
+			},
+		},
+		{
+			name: "available cluster with the joined time recorded",
+			cluster: newManagedCluster(map[string]string{
+				AcceptedTimeAnnotation: "2023-01-02T01:00:00Z",
+				JoinedTimeAnnotation:   "2023-01-02T01:00:30Z",
+			}, acceptedTime, &joinedTime, &availableTime),
+			expectedAnnotations: map[string]interface{}{
+				AvailableTimeAnnotation: "2023-01-02T01:01:00Z",
+			},
+			expectedObserved: map[string]uint64{
+				clusterv1.ManagedClusterConditionAvailable: 1,
+			},
+		},
+		{
+			name: "recorded cluster",
+			cluster: newManagedCluster(map[string]string{
+				AcceptedTimeAnnotation:  "2023-01-02T01:00:00Z",
+				JoinedTimeAnnotation:    "2023-01-02T01:00:30Z",
+				AvailableTimeAnnotation: "2023-01-02T01:01:00Z",
+			}, acceptedTime, &joinedTime, &availableTime),
+		},
+		{
+			name: "denied cluster",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := newManagedCluster(map[string]string{
+					AcceptedTimeAnnotation: "2023-01-02T01:00:00Z",
+				}, acceptedTime, nil, nil)
+				cluster.Spec.HubAcceptsClient = false
+				return cluster
+			}(),
+			expectedAnnotations: map[string]interface{}{
+				AcceptedTimeAnnotation: nil,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			observed := map[string]uint64{}
+			for _, condition := range []string{clusterv1.ManagedClusterConditionJoined, clusterv1.ManagedClusterConditionAvailable} {
+				count, err := testutil.GetHistogramMetricCount(clusterJoinDuration.WithLabelValues(condition))
+				if err != nil {
+					t.Fatal(err)
+				}
+				observed[condition] = count
+			}
+
+			ctrl := &onboardingController{
+				clusterClient: clusterClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			actions := clusterClient.Actions()
+			if len(c.expectedAnnotations) == 0 {
+				testinghelpers.AssertNoActions(t, actions)
+			} else {
+				testinghelpers.AssertActions(t, actions, "patch")
+				testinghelpers.AssertPatchField(t, actions[0], c.expectedAnnotations, "metadata", "annotations")
+			}
+
+			for condition := range observed {
+				count, err := testutil.GetHistogramMetricCount(clusterJoinDuration.WithLabelValues(condition))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if count-observed[condition] != c.expectedObserved[condition] {
+					t.Errorf("expected %d observations of %s, but got %d",
+						c.expectedObserved[condition], condition, count-observed[condition])
+				}
+			}
+		})
+	}
+}
+
+func newManagedCluster(annotations map[string]string, acceptedTime metav1.Time, joinedTime, availableTime *metav1.Time) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAcceptingManagedCluster()
+	cluster.Annotations = annotations
+	cluster.Status.Conditions = []metav1.Condition{
+		testinghelpers.NewManagedClusterCondition(clusterv1.ManagedClusterConditionHubAccepted, "True", "", "", &acceptedTime),
+	}
+	if joinedTime != nil {
+		cluster.Status.Conditions = append(cluster.Status.Conditions,
+			testinghelpers.NewManagedClusterCondition(clusterv1.ManagedClusterConditionJoined, "True", "", "", joinedTime))
+	}
+	if availableTime != nil {
+		cluster.Status.Conditions = append(cluster.Status.Conditions,
+			testinghelpers.NewManagedClusterCondition(clusterv1.ManagedClusterConditionAvailable, "True", "", "", availableTime))
+	}
+	return cluster
+}
@@ -0,0 +1,3 @@
+// package onboarding contains the hub-side controller which tracks the onboarding of the managed clusters, i.e. the
+// times they are accepted, joined and available, and exports the join duration metric for the onboarding SLOs.
+package onboarding
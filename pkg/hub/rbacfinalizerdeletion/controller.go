@@ -8,6 +8,7 @@ import (
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	worklister "open-cluster-management.io/api/client/work/listers/work/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/logging"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -68,7 +69,7 @@ func NewFinalizeController(
 			key, _ := cache.MetaNamespaceKeyFunc(obj)
 			return key
 		}, roleInformer.Informer(), roleBindingInformer.Informer()).
-		WithSync(controller.sync).ToController("FinalizeController", eventRecorder)
+		WithSync(logging.SyncWithLogger("FinalizeController", controller.sync)).ToController("FinalizeController", eventRecorder)
 }
 
 func (m *finalizeController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
@@ -97,7 +98,7 @@ func (m *finalizeController) sync(ctx context.Context, controllerContext factory
 	err = m.syncRoleAndRoleBinding(ctx, controllerContext, role, rolebinding, ns, cluster)
 
 	if err != nil {
-		klog.Errorf("Reconcile role/rolebinding %s fails with err: %v", key, err)
+		klog.FromContext(ctx).Error(err, "Reconcile role/rolebinding fails")
 	}
 	return err
 }
@@ -0,0 +1,125 @@
+package shard
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	"open-cluster-management.io/registration/pkg/logging"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// LabelKey is the label of the managed cluster holding the id of the shard which owns the cluster. It is set by
+// the shard assignment controller, a valid label set by the user is kept, so the clusters can be moved across the
+// shards manually.
+const LabelKey = "registration.open-cluster-management.io/shard"
+
+// ShardOf returns the id of the shard which owns the cluster by default, it is the hash of the cluster name
+func ShardOf(clusterName string, shardCount int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(clusterName))
+	return int(h.Sum32() % uint32(shardCount))
+}
+
+// Selector returns the label selector of the managed clusters owned by the shard
+func Selector(shardID int) string {
+	return labels.SelectorFromSet(labels.Set{LabelKey: strconv.Itoa(shardID)}).String()
+}
+
+// validShard returns true if the shard label value is a valid shard id
+func validShard(value string, shardCount int) bool {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return false
+	}
+	return id >= 0 && id < shardCount
+}
+
+// shardAssignmentController sets the shard label on the managed clusters which have no valid shard label, so that
+// each cluster is owned by exactly one shard.
+type shardAssignmentController struct {
+	clusterClient clientset.Interface
+	clusterLister clusterlisterv1.ManagedClusterLister
+	shardCount    int
+	eventRecorder events.Recorder
+}
+
+// NewShardAssignmentController creates a new shard assignment controller, it watches all of the managed clusters
+// and runs in a single replica of the hub.
+func NewShardAssignmentController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	shardCount int,
+	recorder events.Recorder) factory.Controller {
+	c := &shardAssignmentController{
+		clusterClient: clusterClient,
+		clusterLister: clusterInformer.Lister(),
+		shardCount:    shardCount,
+		eventRecorder: recorder.WithComponentSuffix("shard-assignment-controller"),
+	}
+
+	return factory.New().
+		WithFilteredEventsInformersQueueKeyFunc(
+			func(obj runtime.Object) string {
+				accessor, _ := meta.Accessor(obj)
+				return accessor.GetName()
+			},
+			func(obj interface{}) bool {
+				accessor, err := meta.Accessor(obj)
+				if err != nil {
+					return false
+				}
+				// only handle the clusters without a valid shard label
+				return !validShard(accessor.GetLabels()[LabelKey], shardCount)
+			},
+			clusterInformer.Informer(),
+		).
+		WithSync(logging.SyncWithLogger("ShardAssignmentController", c.sync)).
+		ToController("ShardAssignmentController", recorder)
+}
+
+func (c *shardAssignmentController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	if clusterName == factory.DefaultQueueKey {
+		return nil
+	}
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+	logger.V(4).Info("Reconciling shard of ManagedCluster")
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		// cluster is deleted
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !cluster.DeletionTimestamp.IsZero() || validShard(cluster.Labels[LabelKey], c.shardCount) {
+		return nil
+	}
+
+	shardID := strconv.Itoa(ShardOf(cluster.Name, c.shardCount))
+	cluster = cluster.DeepCopy()
+	if cluster.Labels == nil {
+		cluster.Labels = map[string]string{}
+	}
+	cluster.Labels[LabelKey] = shardID
+
+	if _, err := c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, cluster, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	c.eventRecorder.Eventf("ShardAssigned", "ManagedCluster %q is assigned to shard %s", clusterName, shardID)
+	return nil
+}
@@ -0,0 +1,114 @@
+package shard
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestShardOf(t *testing.T) {
+	counts := map[int]int{}
+	for i := 0; i < 1000; i++ {
+		shardID := ShardOf("cluster"+strconv.Itoa(i), 4)
+		if shardID < 0 || shardID >= 4 {
+			t.Fatalf("unexpected shard id %d", shardID)
+		}
+		counts[shardID]++
+	}
+	// the clusters are spread across all of the shards
+	for shardID := 0; shardID < 4; shardID++ {
+		if counts[shardID] < 150 {
+			t.Errorf("expected the clusters are spread evenly, but got %v", counts)
+		}
+	}
+	if ShardOf("cluster1", 4) != ShardOf("cluster1", 4) {
+		t.Errorf("expected the shard id is deterministic")
+	}
+}
+
+func TestSyncShardAssignment(t *testing.T) {
+	expectedShard := strconv.Itoa(ShardOf(testinghelpers.TestManagedClusterName, 3))
+
+	cases := []struct {
+		name            string
+		shardLabel      string
+		deleting        bool
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name: "assign shard",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				cluster := actions[0].(clienttesting.UpdateAction).GetObject().(*clusterv1.ManagedCluster)
+				if cluster.Labels[LabelKey] != expectedShard {
+					t.Errorf("expected shard %q, but got %v", expectedShard, cluster.Labels)
+				}
+			},
+		},
+		{
+			name:       "reassign invalid shard",
+			shardLabel: "3",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				cluster := actions[0].(clienttesting.UpdateAction).GetObject().(*clusterv1.ManagedCluster)
+				if cluster.Labels[LabelKey] != expectedShard {
+					t.Errorf("expected shard %q, but got %v", expectedShard, cluster.Labels)
+				}
+			},
+		},
+		{
+			name:       "keep valid shard",
+			shardLabel: "2",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:     "cluster is deleting",
+			deleting: true,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewManagedCluster()
+			if c.deleting {
+				cluster = testinghelpers.NewDeletingManagedCluster()
+			}
+			if len(c.shardLabel) > 0 {
+				cluster.Labels = map[string]string{LabelKey: c.shardLabel}
+			}
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+			informerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 5*time.Minute)
+			if err := informerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := shardAssignmentController{
+				clusterClient: clusterClient,
+				clusterLister: informerFactory.Cluster().V1().ManagedClusters().Lister(),
+				shardCount:    3,
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+			}
+
+			syncErr := ctrl.sync(context.Background(), testinghelpers.NewFakeSyncContext(t, cluster.Name))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
@@ -0,0 +1,3 @@
+// package shard contains the helpers and the assignment controller to shard the managed clusters across the
+// replicas of the hub registration controller
+package shard
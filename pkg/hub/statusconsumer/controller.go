@@ -0,0 +1,239 @@
+package statusconsumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
+	"open-cluster-management.io/registration/pkg/transport"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const leaseName = "managed-cluster-lease"
+
+// statusConsumerController consumes the heartbeats and status published by the registration agents, it renews
+// the leases and updates the status of the managed clusters on the hub on behalf of the agents.
+type statusConsumerController struct {
+	kubeClient    kubernetes.Interface
+	clusterClient clientset.Interface
+	clusterLister clusterv1listers.ManagedClusterLister
+
+	lock sync.Mutex
+	// heartbeats are the times when the latest heartbeats of the managed clusters are received
+	heartbeats map[string]time.Time
+	// statuses are the latest status updates of the managed clusters
+	statuses map[string]transport.StatusUpdate
+}
+
+// NewStatusConsumerController creates a controller which subscribes to the heartbeat and status topics with the
+// subscriber. Only the latest message of each managed cluster is handled.
+func NewStatusConsumerController(
+	kubeClient kubernetes.Interface,
+	clusterClient clientset.Interface,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	subscriber transport.Subscriber,
+	recorder events.Recorder) factory.Controller {
+	c := &statusConsumerController{
+		kubeClient:    kubeClient,
+		clusterClient: clusterClient,
+		clusterLister: clusterInformer.Lister(),
+		heartbeats:    map[string]time.Time{},
+		statuses:      map[string]transport.StatusUpdate{},
+	}
+
+	syncCtx := factory.NewSyncContext("ManagedClusterStatusConsumerController", recorder)
+	subscriber.Subscribe(transport.HeartbeatTopicFilter, func(topic string, payload []byte) {
+		if clusterName := c.receiveHeartbeat(topic, payload); len(clusterName) > 0 {
+			syncCtx.Queue().Add(clusterName)
+		}
+	})
+	subscriber.Subscribe(transport.StatusTopicFilter, func(topic string, payload []byte) {
+		if clusterName := c.receiveStatus(topic, payload); len(clusterName) > 0 {
+			syncCtx.Queue().Add(clusterName)
+		}
+	})
+
+	return factory.New().
+		WithSyncContext(syncCtx).
+		WithBareInformers(clusterInformer.Informer()).
+		WithSync(logging.SyncWithLogger("ManagedClusterStatusConsumerController", c.sync)).
+		ToController("ManagedClusterStatusConsumerController", recorder)
+}
+
+// receiveHeartbeat records the heartbeat and returns the name of the managed cluster, it returns an empty name if
+// the message is invalid.
+func (c *statusConsumerController) receiveHeartbeat(topic string, payload []byte) string {
+	heartbeat := transport.Heartbeat{}
+	if err := json.Unmarshal(payload, &heartbeat); err != nil {
+		klog.ErrorS(err, "Unable to decode the heartbeat", "topic", topic)
+		return ""
+	}
+	// the agents can only publish to the topics of their own user names, so the cluster of the topic is the
+	// authenticated one, and the cluster name in the message must match it
+	clusterName := transport.ClusterNameFromTopic(topic)
+	if len(clusterName) == 0 || heartbeat.ClusterName != clusterName {
+		klog.InfoS("The heartbeat is received from unexpected topic", logging.ClusterKey, heartbeat.ClusterName, "topic", topic)
+		return ""
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.heartbeats[clusterName] = time.Now()
+	return clusterName
+}
+
+// receiveStatus records the status update and returns the name of the managed cluster, it returns an empty name
+// if the message is invalid.
+func (c *statusConsumerController) receiveStatus(topic string, payload []byte) string {
+	update := transport.StatusUpdate{}
+	if err := json.Unmarshal(payload, &update); err != nil {
+		klog.ErrorS(err, "Unable to decode the status update", "topic", topic)
+		return ""
+	}
+	clusterName := transport.ClusterNameFromTopic(topic)
+	if len(clusterName) == 0 || update.ClusterName != clusterName {
+		klog.InfoS("The status update is received from unexpected topic", logging.ClusterKey, update.ClusterName, "topic", topic)
+		return ""
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.statuses[clusterName] = update
+	return clusterName
+}
+
+// pop returns and removes the pending heartbeat and status update of the managed cluster
+func (c *statusConsumerController) pop(clusterName string) (*time.Time, *transport.StatusUpdate) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var renewTime *time.Time
+	var status *transport.StatusUpdate
+	if t, ok := c.heartbeats[clusterName]; ok {
+		renewTime = &t
+		delete(c.heartbeats, clusterName)
+	}
+	if s, ok := c.statuses[clusterName]; ok {
+		status = &s
+		delete(c.statuses, clusterName)
+	}
+	return renewTime, status
+}
+
+func (c *statusConsumerController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusterName := syncCtx.QueueKey()
+	if clusterName == factory.DefaultQueueKey {
+		return nil
+	}
+
+	ctx, logger := logging.WithCluster(ctx, clusterName)
+
+	renewTime, status := c.pop(clusterName)
+	if renewTime == nil && status == nil {
+		return nil
+	}
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		logger.V(4).Info("Ignore the messages of the unknown cluster")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// the agent of a cluster which is not accepted is not allowed to update the lease and status
+	if !meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionHubAccepted) {
+		logger.V(4).Info("Ignore the messages of the cluster which is not accepted")
+		return nil
+	}
+
+	if renewTime != nil {
+		if err := c.renewLease(ctx, clusterName, *renewTime); err != nil {
+			return err
+		}
+	}
+	if status != nil {
+		if err := c.updateStatus(ctx, syncCtx, clusterName, *status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renewLease renews the lease of the managed cluster which is created by the lease controller on the hub
+func (c *statusConsumerController) renewLease(ctx context.Context, clusterName string, renewTime time.Time) error {
+	lease, err := c.kubeClient.CoordinationV1().Leases(clusterName).Get(ctx, leaseName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// the lease is created by the lease controller, the next heartbeat renews it
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	lease = lease.DeepCopy()
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: renewTime}
+	if _, err := c.kubeClient.CoordinationV1().Leases(clusterName).Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to renew the lease of cluster %q: %w", clusterName, err)
+	}
+	return nil
+}
+
+// updateStatus updates the available condition, the probe conditions, resources and version of the managed cluster
+// with the status update published by the agent, the other status fields are owned by the hub controllers. The
+// resources are merged into the status as the agent does with the kube transport, and kept if they are not reported.
+func (c *statusConsumerController) updateStatus(ctx context.Context, syncCtx factory.SyncContext,
+	clusterName string, update transport.StatusUpdate) error {
+	status := update.Status
+	updateStatusFuncs := []helpers.UpdateManagedClusterStatusFunc{}
+	if resources := update.Resources; resources != nil {
+		updateStatusFuncs = append(updateStatusFuncs,
+			helpers.UpdateManagedClusterResourcesFn(resources.Capacity, resources.Allocatable, resources.Version))
+	}
+	if condition := meta.FindStatusCondition(status.Conditions, clusterv1.ManagedClusterConditionAvailable); condition != nil {
+		updateStatusFuncs = append(updateStatusFuncs, helpers.UpdateManagedClusterConditionFn(*condition))
+	}
+	// the conditions of the availability probes are replaced with the published ones
+	updateStatusFuncs = append(updateStatusFuncs, func(oldStatus *clusterv1.ManagedClusterStatus) error {
+		conditions := []metav1.Condition{}
+		for _, condition := range oldStatus.Conditions {
+			if !strings.HasPrefix(condition.Type, helpers.ManagedClusterConditionProbePrefix) {
+				conditions = append(conditions, condition)
+			}
+		}
+		oldStatus.Conditions = conditions
+		for _, condition := range status.Conditions {
+			if strings.HasPrefix(condition.Type, helpers.ManagedClusterConditionProbePrefix) {
+				meta.SetStatusCondition(&oldStatus.Conditions, condition)
+			}
+		}
+		return nil
+	})
+
+	_, updated, err := helpers.UpdateManagedClusterStatus(ctx, c.clusterClient, clusterName, updateStatusFuncs...)
+	if err != nil {
+		return fmt.Errorf("unable to update status of managed cluster %q: %w", clusterName, err)
+	}
+	if updated {
+		syncCtx.Recorder().Eventf("ManagedClusterStatusConsumed",
+			"the status of managed cluster %q is updated with the status published by the agent", clusterName)
+	}
+	return nil
+}
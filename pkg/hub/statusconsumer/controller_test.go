@@ -0,0 +1,231 @@
+package statusconsumer
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+	"open-cluster-management.io/registration/pkg/transport"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestSync(t *testing.T) {
+	availableCondition := metav1.Condition{
+		Type:    clusterv1.ManagedClusterConditionAvailable,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ManagedClusterAvailable",
+		Message: "Managed cluster is available",
+	}
+	status := clusterv1.ManagedClusterStatus{
+		Conditions: []metav1.Condition{availableCondition},
+	}
+	resources := &transport.Resources{
+		Capacity:    clusterv1.ResourceList{clusterv1.ResourceCPU: *resource.NewQuantity(8, resource.DecimalSI)},
+		Allocatable: clusterv1.ResourceList{clusterv1.ResourceCPU: *resource.NewQuantity(4, resource.DecimalSI)},
+		Version:     clusterv1.ManagedClusterVersion{Kubernetes: "v1.25.0"},
+	}
+	reportedCluster := testinghelpers.NewAcceptedManagedCluster()
+	reportedCluster.Status.Capacity = clusterv1.ResourceList{
+		clusterv1.ResourceCPU: *resource.NewQuantity(4, resource.DecimalSI),
+		"gpu":                 *resource.NewQuantity(1, resource.DecimalSI),
+		helpers.NodeRoleResourcePrefix + "worker.cpu": *resource.NewQuantity(4, resource.DecimalSI),
+	}
+	reportedCluster.Status.Version = clusterv1.ManagedClusterVersion{Kubernetes: "v1.24.0"}
+
+	cases := []struct {
+		name            string
+		clusters        []runtime.Object
+		leases          []runtime.Object
+		topic           string
+		message         interface{}
+		validateActions func(t *testing.T, leaseActions, clusterActions []clienttesting.Action)
+	}{
+		{
+			name:     "renew lease",
+			clusters: []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			leases: []runtime.Object{
+				testinghelpers.NewManagedClusterLease("managed-cluster-lease", time.Now().Add(-5*time.Minute)),
+			},
+			topic:   transport.HeartbeatTopic(testinghelpers.TestManagedClusterName, "agent1"),
+			message: transport.Heartbeat{ClusterName: testinghelpers.TestManagedClusterName, RenewTime: metav1.Now()},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, leaseActions, "get", "update")
+				lease := leaseActions[1].(clienttesting.UpdateAction).GetObject().(*coordv1.Lease)
+				if time.Since(lease.Spec.RenewTime.Time) > time.Minute {
+					t.Errorf("the lease is not renewed: %v", lease.Spec.RenewTime)
+				}
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+		{
+			name:     "lease is not created",
+			clusters: []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			topic:    transport.HeartbeatTopic(testinghelpers.TestManagedClusterName, "agent1"),
+			message:  transport.Heartbeat{ClusterName: testinghelpers.TestManagedClusterName, RenewTime: metav1.Now()},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, leaseActions, "get")
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+		{
+			name:     "update status",
+			clusters: []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			topic:    transport.StatusTopic(testinghelpers.TestManagedClusterName, "agent1"),
+			message: transport.StatusUpdate{
+				ClusterName: testinghelpers.TestManagedClusterName,
+				Status:      status,
+				Resources:   resources,
+			},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, leaseActions)
+				testinghelpers.AssertActions(t, clusterActions, "get", "patch")
+				patch := clusterActions[1].(clienttesting.PatchAction).GetPatch()
+				cluster := &clusterv1.ManagedCluster{}
+				if err := json.Unmarshal(patch, cluster); err != nil {
+					t.Fatal(err)
+				}
+				testinghelpers.AssertCondition(t, cluster.Status.Conditions, availableCondition)
+				if cluster.Status.Version.Kubernetes != "v1.25.0" {
+					t.Errorf("unexpected version %v", cluster.Status.Version)
+				}
+			},
+		},
+		{
+			name:     "replace the reported resources",
+			clusters: []runtime.Object{reportedCluster},
+			topic:    transport.StatusTopic(testinghelpers.TestManagedClusterName, "agent1"),
+			message: transport.StatusUpdate{
+				ClusterName: testinghelpers.TestManagedClusterName,
+				Status:      status,
+				Resources:   resources,
+			},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, clusterActions, "get", "patch")
+				// the capacity is replaced with the reported one, the stale resources are removed
+				patch := map[string]struct {
+					Capacity map[string]interface{} `json:"capacity"`
+				}{}
+				if err := json.Unmarshal(clusterActions[1].(clienttesting.PatchAction).GetPatch(), &patch); err != nil {
+					t.Fatal(err)
+				}
+				expectedCapacity := map[string]interface{}{
+					"cpu": "8",
+					"gpu": nil,
+					helpers.NodeRoleResourcePrefix + "worker.cpu": nil,
+				}
+				if !reflect.DeepEqual(patch["status"].Capacity, expectedCapacity) {
+					t.Errorf("unexpected capacity patch %v", patch["status"].Capacity)
+				}
+			},
+		},
+		{
+			name:     "resources are not reported",
+			clusters: []runtime.Object{reportedCluster},
+			topic:    transport.StatusTopic(testinghelpers.TestManagedClusterName, "agent1"),
+			message:  transport.StatusUpdate{ClusterName: testinghelpers.TestManagedClusterName, Status: status},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, clusterActions, "get", "patch")
+				patch := clusterActions[1].(clienttesting.PatchAction).GetPatch()
+				cluster := &clusterv1.ManagedCluster{}
+				if err := json.Unmarshal(patch, cluster); err != nil {
+					t.Fatal(err)
+				}
+				testinghelpers.AssertCondition(t, cluster.Status.Conditions, availableCondition)
+				if len(cluster.Status.Capacity) != 0 || len(cluster.Status.Version.Kubernetes) != 0 {
+					t.Errorf("the resources are updated: %v", cluster.Status)
+				}
+			},
+		},
+		{
+			name:     "cluster is not accepted",
+			clusters: []runtime.Object{testinghelpers.NewManagedCluster()},
+			leases: []runtime.Object{
+				testinghelpers.NewManagedClusterLease("managed-cluster-lease", time.Now().Add(-5*time.Minute)),
+			},
+			topic:   transport.HeartbeatTopic(testinghelpers.TestManagedClusterName, "agent1"),
+			message: transport.Heartbeat{ClusterName: testinghelpers.TestManagedClusterName, RenewTime: metav1.Now()},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, leaseActions)
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+		{
+			name:     "cluster name does not match the topic",
+			clusters: []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			leases: []runtime.Object{
+				testinghelpers.NewManagedClusterLease("managed-cluster-lease", time.Now().Add(-5*time.Minute)),
+			},
+			topic:   transport.HeartbeatTopic(testinghelpers.TestManagedClusterName, "agent1"),
+			message: transport.Heartbeat{ClusterName: "cluster2", RenewTime: metav1.Now()},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, leaseActions)
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+		{
+			name:     "topic is not of an agent user",
+			clusters: []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			leases: []runtime.Object{
+				testinghelpers.NewManagedClusterLease("managed-cluster-lease", time.Now().Add(-5*time.Minute)),
+			},
+			topic:   "open-cluster-management/agents/" + testinghelpers.TestManagedClusterName + "/heartbeat",
+			message: transport.Heartbeat{ClusterName: testinghelpers.TestManagedClusterName, RenewTime: metav1.Now()},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, leaseActions)
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.clusters...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			for _, cluster := range c.clusters {
+				if err := clusterStore.Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+			kubeClient := kubefake.NewSimpleClientset(c.leases...)
+
+			ctrl := &statusConsumerController{
+				kubeClient:    kubeClient,
+				clusterClient: clusterClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				heartbeats:    map[string]time.Time{},
+				statuses:      map[string]transport.StatusUpdate{},
+			}
+
+			payload, err := json.Marshal(c.message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch c.message.(type) {
+			case transport.Heartbeat:
+				ctrl.receiveHeartbeat(c.topic, payload)
+			case transport.StatusUpdate:
+				ctrl.receiveStatus(c.topic, payload)
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions(), clusterClient.Actions())
+		})
+	}
+}
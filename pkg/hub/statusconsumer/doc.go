@@ -0,0 +1,3 @@
+// package statusconsumer contains the hub-side controller which consumes the heartbeats and status of the managed
+// clusters published to an MQTT broker, and translates them into the updates of the leases and ManagedClusterStatus.
+package statusconsumer
@@ -8,12 +8,14 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	v1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/helpers/taints"
+	"open-cluster-management.io/registration/pkg/logging"
 )
 
 var (
@@ -28,35 +30,42 @@ var (
 	}
 )
 
+// taintFieldManager is the field manager of the taints applied by the taint controller
+const taintFieldManager = "taint-controller"
+
 // taintController
 type taintController struct {
 	clusterClient clientset.Interface
 	clusterLister listerv1.ManagedClusterLister
 	eventRecorder events.Recorder
+	clock         clock.Clock
 }
 
-// NewTaintController creates a new taint controller
+// NewTaintController creates a new taint controller, the TimeAdded of the taints is set with the clock
 func NewTaintController(
 	clusterClient clientset.Interface,
 	clusterInformer informerv1.ManagedClusterInformer,
+	clock clock.Clock,
 	recorder events.Recorder) factory.Controller {
 	c := &taintController{
 		clusterClient: clusterClient,
 		clusterLister: clusterInformer.Lister(),
 		eventRecorder: recorder.WithComponentSuffix("taint-controller"),
+		clock:         clock,
 	}
 	return factory.New().
 		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
 			accessor, _ := meta.Accessor(obj)
 			return accessor.GetName()
 		}, clusterInformer.Informer()).
-		WithSync(c.sync).
+		WithSync(logging.SyncWithLogger("taintController", c.sync)).
 		ToController("taintController", recorder)
 }
 
 func (c *taintController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
 	managedClusterName := syncCtx.QueueKey()
-	klog.V(4).Infof("Reconciling ManagedCluster %s", managedClusterName)
+	ctx, logger := logging.WithCluster(ctx, managedClusterName)
+	logger.V(4).Info("Reconciling ManagedCluster")
 	managedCluster, err := c.clusterLister.Get(managedClusterName)
 	if errors.IsNotFound(err) {
 		// Spoke cluster not found, could have been deleted, do nothing.
@@ -69,28 +78,51 @@ func (c *taintController) sync(ctx context.Context, syncCtx factory.SyncContext)
 		return nil
 	}
 
+	originalTaints := managedCluster.Spec.Taints
 	managedCluster = managedCluster.DeepCopy()
 	newTaints := managedCluster.Spec.Taints
 	cond := meta.FindStatusCondition(managedCluster.Status.Conditions, v1.ManagedClusterConditionAvailable)
-	var updated bool
+
+	// The TimeAdded of a new taint is set by the managed cluster mutating webhook when the taint is added.
+	// If the webhook is not deployed, set the TimeAdded of the existing taints maintained by this controller.
+	now := metav1.NewTime(c.clock.Now())
+	updated := setTaintsTimeAdded(newTaints, now, UnavailableTaint, UnreachableTaint)
 
 	switch {
 	case cond == nil || cond.Status == metav1.ConditionUnknown:
-		updated = helpers.RemoveTaints(&newTaints, UnavailableTaint)
+		updated = helpers.RemoveTaints(&newTaints, UnavailableTaint) || updated
 		updated = helpers.AddTaints(&newTaints, UnreachableTaint) || updated
-	case cond.Status == metav1.ConditionFalse:
-		updated = helpers.RemoveTaints(&newTaints, UnreachableTaint)
+	case cond.Status == metav1.ConditionFalse,
+		meta.IsStatusConditionTrue(managedCluster.Status.Conditions, helpers.ManagedClusterConditionFlapping):
+		// the flapping cluster is tainted as unavailable until its availability is stable
+		updated = helpers.RemoveTaints(&newTaints, UnreachableTaint) || updated
 		updated = helpers.AddTaints(&newTaints, UnavailableTaint) || updated
 	case cond.Status == metav1.ConditionTrue:
-		updated = helpers.RemoveTaints(&newTaints, UnavailableTaint, UnreachableTaint)
+		updated = helpers.RemoveTaints(&newTaints, UnavailableTaint, UnreachableTaint) || updated
 	}
 
 	if updated {
-		managedCluster.Spec.Taints = newTaints
-		if _, err = c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, managedCluster, metav1.UpdateOptions{}); err != nil {
+		// set the TimeAdded of the taints added above in the way of the webhook, so it does not depend on the
+		// webhook and the taints are not updated again to set it
+		taints.SetTimeAdded(newTaints, originalTaints, false, now)
+		if err = helpers.ApplyManagedClusterTaints(ctx, c.clusterClient, managedCluster, taintFieldManager, newTaints); err != nil {
 			return err
 		}
 		c.eventRecorder.Eventf("ManagedClusterConditionAvailableUpdated", "Update the original taints to the %+v", newTaints)
 	}
 	return nil
 }
+
+// setTaintsTimeAdded sets the TimeAdded of the target taints if it is not set, returns true if any taint is changed.
+func setTaintsTimeAdded(taints []v1.Taint, now metav1.Time, targets ...v1.Taint) bool {
+	updated := false
+	for _, target := range targets {
+		taint := helpers.FindTaint(taints, target)
+		if taint == nil || !taint.TimeAdded.IsZero() {
+			continue
+		}
+		taint.TimeAdded = now
+		updated = true
+	}
+	return updated
+}
@@ -2,7 +2,6 @@ package taint
 
 import (
 	"context"
-	"reflect"
 	"testing"
 	"time"
 
@@ -10,15 +9,20 @@ import (
 
 	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	"open-cluster-management.io/registration/pkg/helpers"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
 )
 
 func TestSyncTaintCluster(t *testing.T) {
+	now := time.Date(2023, 1, 7, 2, 0, 0, 0, time.UTC)
+
 	cases := []struct {
 		name            string
 		startingObjects []runtime.Object
@@ -35,35 +39,91 @@ func TestSyncTaintCluster(t *testing.T) {
 			name:            "ManagedClusterConditionAvailable conditionStatus is False",
 			startingObjects: []runtime.Object{testinghelpers.NewUnAvailableManagedCluster()},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testinghelpers.AssertActions(t, actions, "update")
-				managedCluster := (actions[0].(clienttesting.UpdateActionImpl).Object).(*v1.ManagedCluster)
-				taints := []v1.Taint{UnavailableTaint}
-				if !reflect.DeepEqual(managedCluster.Spec.Taints, taints) {
-					t.Errorf("expected taint %#v, but actualTaints: %#v", taints, managedCluster.Spec.Taints)
-				}
+				testinghelpers.AssertActions(t, actions, "patch")
+				managedCluster := appliedManagedCluster(t, actions[0])
+				assertTaintsAdded(t, managedCluster.Spec.Taints, UnavailableTaint)
+			},
+		},
+		{
+			name: "ManagedClusterConditionAvailable conditionStatus is True but the cluster is flapping",
+			startingObjects: []runtime.Object{func() *v1.ManagedCluster {
+				cluster := testinghelpers.NewAvailableManagedCluster()
+				cluster.Status.Conditions = append(cluster.Status.Conditions, testinghelpers.NewManagedClusterCondition(
+					helpers.ManagedClusterConditionFlapping, "True", "AvailabilityFlapping", "", nil))
+				return cluster
+			}()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch")
+				managedCluster := appliedManagedCluster(t, actions[0])
+				assertTaintsAdded(t, managedCluster.Spec.Taints, UnavailableTaint)
 			},
 		},
 		{
 			name:            "There is no ManagedClusterConditionAvailable",
 			startingObjects: []runtime.Object{testinghelpers.NewManagedCluster()},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testinghelpers.AssertActions(t, actions, "update")
-				managedCluster := (actions[0].(clienttesting.UpdateActionImpl).Object).(*v1.ManagedCluster)
-				taints := []v1.Taint{UnreachableTaint}
-				if !reflect.DeepEqual(managedCluster.Spec.Taints, taints) {
-					t.Errorf("expected taint %#v, but actualTaints: %#v", taints, managedCluster.Spec.Taints)
-				}
+				testinghelpers.AssertActions(t, actions, "patch")
+				managedCluster := appliedManagedCluster(t, actions[0])
+				assertTaintsAdded(t, managedCluster.Spec.Taints, UnreachableTaint)
 			},
 		},
 		{
 			name:            "ManagedClusterConditionAvailable conditionStatus is Unknown",
 			startingObjects: []runtime.Object{testinghelpers.NewUnknownManagedCluster()},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
-				testinghelpers.AssertActions(t, actions, "update")
-				managedCluster := (actions[0].(clienttesting.UpdateActionImpl).Object).(*v1.ManagedCluster)
-				taints := []v1.Taint{UnreachableTaint}
-				if !reflect.DeepEqual(managedCluster.Spec.Taints, taints) {
-					t.Errorf("expected taint %#v, but actualTaints: %#v", taints, managedCluster.Spec.Taints)
+				testinghelpers.AssertActions(t, actions, "patch")
+				managedCluster := appliedManagedCluster(t, actions[0])
+				assertTaintsAdded(t, managedCluster.Spec.Taints, UnreachableTaint)
+			},
+		},
+		{
+			name: "set TimeAdded of the existing taint",
+			startingObjects: []runtime.Object{func() *v1.ManagedCluster {
+				cluster := testinghelpers.NewUnknownManagedCluster()
+				cluster.Spec.Taints = []v1.Taint{UnreachableTaint}
+				return cluster
+			}()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch")
+				managedCluster := appliedManagedCluster(t, actions[0])
+				if len(managedCluster.Spec.Taints) != 1 || !helpers.IsTaintEqual(managedCluster.Spec.Taints[0], UnreachableTaint) {
+					t.Errorf("expected taint %#v, but actualTaints: %#v", UnreachableTaint, managedCluster.Spec.Taints)
+				}
+				if !managedCluster.Spec.Taints[0].TimeAdded.Time.Equal(now) {
+					t.Errorf("expected TimeAdded of taint is set to %v, but got %v", now, managedCluster.Spec.Taints[0].TimeAdded)
+				}
+			},
+		},
+		{
+			name: "keep TimeAdded of the existing taint",
+			startingObjects: []runtime.Object{func() *v1.ManagedCluster {
+				cluster := testinghelpers.NewUnknownManagedCluster()
+				taint := UnreachableTaint
+				taint.TimeAdded = metav1.Now()
+				cluster.Spec.Taints = []v1.Taint{taint}
+				return cluster
+			}()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "keep TimeAdded of the other taints",
+			startingObjects: []runtime.Object{func() *v1.ManagedCluster {
+				cluster := testinghelpers.NewUnAvailableManagedCluster()
+				cluster.Spec.Taints = []v1.Taint{{
+					Key:       "gpu",
+					Effect:    v1.TaintEffectNoSelect,
+					TimeAdded: metav1.NewTime(time.Now().Add(-time.Hour)),
+				}}
+				return cluster
+			}()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch")
+				managedCluster := appliedManagedCluster(t, actions[0])
+				assertTaintsAdded(t, managedCluster.Spec.Taints, v1.Taint{Key: "gpu", Effect: v1.TaintEffectNoSelect}, UnavailableTaint)
+				if time.Since(managedCluster.Spec.Taints[0].TimeAdded.Time) < time.Hour {
+					t.Errorf("expected TimeAdded of taint %q is kept, but got %v", "gpu", managedCluster.Spec.Taints[0].TimeAdded)
 				}
 			},
 		},
@@ -87,7 +147,12 @@ func TestSyncTaintCluster(t *testing.T) {
 				}
 			}
 
-			ctrl := taintController{clusterClient, clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(), eventstesting.NewTestingEventRecorder(t)}
+			ctrl := taintController{
+				clusterClient: clusterClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+				clock:         clocktesting.NewFakeClock(now),
+			}
 			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
 			if syncErr != nil {
 				t.Errorf("unexpected err: %v", syncErr)
@@ -97,3 +162,26 @@ func TestSyncTaintCluster(t *testing.T) {
 		})
 	}
 }
+
+// assertTaintsAdded asserts the taints are the expected ones with the TimeAdded set
+func assertTaintsAdded(t *testing.T, actual []v1.Taint, expected ...v1.Taint) {
+	if len(actual) != len(expected) {
+		t.Fatalf("expected taints %#v, but actualTaints: %#v", expected, actual)
+	}
+	for i := range expected {
+		if !helpers.IsTaintEqual(actual[i], expected[i]) {
+			t.Errorf("expected taint %#v, but actualTaint: %#v", expected[i], actual[i])
+		}
+		if actual[i].TimeAdded.IsZero() {
+			t.Errorf("expected TimeAdded of taint %q is set, but it is not", actual[i].Key)
+		}
+	}
+}
+
+// appliedManagedCluster returns the managed cluster in the apply patch of the action
+func appliedManagedCluster(t *testing.T, action clienttesting.Action) *v1.ManagedCluster {
+	testinghelpers.AssertApplyPatch(t, action)
+	managedCluster := &v1.ManagedCluster{}
+	testinghelpers.UnmarshalPatch(t, action, managedCluster)
+	return managedCluster
+}
@@ -0,0 +1,213 @@
+package taint
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/robfig/cron"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/clock"
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/helpers/taints"
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+// MaintenanceTaint is added to the managed clusters during their maintenance windows, so the placements prefer the
+// other clusters and the workloads can be drained before the clusters are upgraded. The taint is owned by the hub,
+// it is removed from the clusters out of their maintenance windows.
+var MaintenanceTaint = v1.Taint{
+	Key:    "cluster.open-cluster-management.io/maintenance",
+	Effect: v1.TaintEffectPreferNoSelect,
+}
+
+// maintenanceFieldManager is the field manager of the taints applied by the maintenance controller
+const maintenanceFieldManager = "maintenance-taint-controller"
+
+// maintenanceWindow returns whether the time is in a window of the schedule, and the time the window ends if it
+// is, or the time the next window starts if it is not.
+type maintenanceWindow func(now time.Time) (bool, time.Time)
+
+// parseMaintenanceWindow parses the value of the maintenance window annotation, it is either an RFC3339 interval
+// <start>/<end> or a cron schedule with the duration of the windows <schedule>;<duration>.
+func parseMaintenanceWindow(value string) (maintenanceWindow, error) {
+	if schedule, duration, ok := strings.Cut(value, ";"); ok {
+		return parseCronMaintenanceWindow(strings.TrimSpace(schedule), strings.TrimSpace(duration))
+	}
+
+	start, end, ok := strings.Cut(value, "/")
+	if !ok {
+		return nil, fmt.Errorf("maintenance window %q is neither an RFC3339 interval nor a cron schedule with a duration", value)
+	}
+	startTime, err := time.Parse(time.RFC3339, strings.TrimSpace(start))
+	if err != nil {
+		return nil, fmt.Errorf("maintenance window %q has an invalid start: %w", value, err)
+	}
+	endTime, err := time.Parse(time.RFC3339, strings.TrimSpace(end))
+	if err != nil {
+		return nil, fmt.Errorf("maintenance window %q has an invalid end: %w", value, err)
+	}
+	if !endTime.After(startTime) {
+		return nil, fmt.Errorf("maintenance window %q ends before it starts", value)
+	}
+
+	return func(now time.Time) (bool, time.Time) {
+		if now.Before(startTime) {
+			return false, startTime
+		}
+		// the window is over, there is no next window
+		return now.Before(endTime), endTime
+	}, nil
+}
+
+func parseCronMaintenanceWindow(spec, durationSpec string) (maintenanceWindow, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("maintenance window schedule %q is invalid: %w", spec, err)
+	}
+	duration, err := time.ParseDuration(durationSpec)
+	if err != nil {
+		return nil, fmt.Errorf("maintenance window duration %q is invalid: %w", durationSpec, err)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("maintenance window duration %q must be positive", durationSpec)
+	}
+
+	return func(now time.Time) (bool, time.Time) {
+		// the latest window which has not ended starts after now - duration
+		start := schedule.Next(now.Add(-duration))
+		if start.After(now) {
+			return false, start
+		}
+		return true, start.Add(duration)
+	}, nil
+}
+
+// maintenanceController taints the managed clusters with the maintenance taint during the maintenance windows
+// scheduled with the maintenance window annotation, and removes the taint once the windows end.
+type maintenanceController struct {
+	clusterClient clientset.Interface
+	clusterLister listerv1.ManagedClusterLister
+	eventRecorder events.Recorder
+	clock         clock.Clock
+
+	lock sync.Mutex
+	// invalidWindows are the invalid maintenance windows which are reported, keyed by the cluster names, so the
+	// invalid windows are only reported once they are changed
+	invalidWindows map[string]string
+}
+
+// NewMaintenanceController creates a new maintenance controller
+func NewMaintenanceController(
+	clusterClient clientset.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	clock clock.Clock,
+	recorder events.Recorder) factory.Controller {
+	c := &maintenanceController{
+		clusterClient:  clusterClient,
+		clusterLister:  clusterInformer.Lister(),
+		eventRecorder:  recorder.WithComponentSuffix("maintenance-controller"),
+		clock:          clock,
+		invalidWindows: map[string]string{},
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithSync(logging.SyncWithLogger("maintenanceController", c.sync)).
+		ToController("maintenanceController", recorder)
+}
+
+func (c *maintenanceController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	ctx, logger := logging.WithCluster(ctx, managedClusterName)
+	logger.V(4).Info("Reconciling maintenance window of ManagedCluster")
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		c.forgetInvalidWindow(managedClusterName)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !managedCluster.DeletionTimestamp.IsZero() {
+		c.forgetInvalidWindow(managedClusterName)
+		return nil
+	}
+
+	inMaintenance := false
+	value, ok := managedCluster.Annotations[helpers.ManagedClusterMaintenanceWindowAnnotation]
+	if !ok {
+		c.forgetInvalidWindow(managedClusterName)
+	} else {
+		window, err := parseMaintenanceWindow(value)
+		if err != nil {
+			// the taint is removed until the annotation is fixed, the cluster is synced again once it is updated
+			c.reportInvalidWindow(managedClusterName, value, err)
+		} else {
+			c.forgetInvalidWindow(managedClusterName)
+			now := c.clock.Now()
+			var next time.Time
+			inMaintenance, next = window(now)
+			if next.After(now) {
+				syncCtx.Queue().AddAfter(managedClusterName, next.Sub(now))
+			}
+		}
+	}
+
+	newTaints := append([]v1.Taint{}, managedCluster.Spec.Taints...)
+	var updated bool
+	if inMaintenance {
+		updated = helpers.AddTaints(&newTaints, MaintenanceTaint)
+	} else {
+		updated = helpers.RemoveTaints(&newTaints, MaintenanceTaint)
+	}
+	if !updated {
+		return nil
+	}
+
+	taints.SetTimeAdded(newTaints, managedCluster.Spec.Taints, false, metav1.NewTime(c.clock.Now()))
+	if err := helpers.ApplyManagedClusterTaints(ctx, c.clusterClient, managedCluster, maintenanceFieldManager, newTaints); err != nil {
+		return err
+	}
+	if inMaintenance {
+		c.eventRecorder.Eventf("MaintenanceWindowStarted", "The managed cluster %q is tainted for maintenance", managedClusterName)
+	} else {
+		c.eventRecorder.Eventf("MaintenanceWindowEnded", "The maintenance taint of the managed cluster %q is removed", managedClusterName)
+	}
+	return nil
+}
+
+// reportInvalidWindow emits an event for the invalid maintenance window of the cluster, unless the same window is
+// already reported
+func (c *maintenanceController) reportInvalidWindow(clusterName, value string, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if reported, ok := c.invalidWindows[clusterName]; ok && reported == value {
+		return
+	}
+	c.invalidWindows[clusterName] = value
+	c.eventRecorder.Warningf("MaintenanceWindowInvalid", "The maintenance window of the managed cluster %q is invalid: %v",
+		clusterName, err)
+}
+
+// forgetInvalidWindow forgets the reported invalid maintenance window of the cluster
+func (c *maintenanceController) forgetInvalidWindow(clusterName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.invalidWindows, clusterName)
+}
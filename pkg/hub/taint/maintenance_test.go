@@ -0,0 +1,214 @@
+package taint
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	v1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestParseMaintenanceWindow(t *testing.T) {
+	now := time.Date(2023, 1, 7, 2, 0, 0, 0, time.UTC) // Saturday
+
+	cases := []struct {
+		name            string
+		value           string
+		expectedErr     string
+		expectedActive  bool
+		expectedNextRun time.Time
+	}{
+		{
+			name:            "in the interval",
+			value:           "2023-01-07T01:00:00Z/2023-01-07T03:00:00Z",
+			expectedActive:  true,
+			expectedNextRun: time.Date(2023, 1, 7, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "before the interval",
+			value:           "2023-01-07T04:00:00Z/2023-01-07T05:00:00Z",
+			expectedNextRun: time.Date(2023, 1, 7, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "after the interval",
+			value:           "2023-01-07T00:00:00Z/2023-01-07T01:00:00Z",
+			expectedNextRun: time.Date(2023, 1, 7, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "in the cron window",
+			value:           "0 1 * * SAT;2h",
+			expectedActive:  true,
+			expectedNextRun: time.Date(2023, 1, 7, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "out of the cron window",
+			value:           "30 2 * * *; 1h",
+			expectedNextRun: time.Date(2023, 1, 7, 2, 30, 0, 0, time.UTC),
+		},
+		{
+			name:        "invalid interval",
+			value:       "2023-01-07T01:00:00Z/tomorrow",
+			expectedErr: "maintenance window \"2023-01-07T01:00:00Z/tomorrow\" has an invalid end: parsing time \"tomorrow\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"tomorrow\" as \"2006\"",
+		},
+		{
+			name:        "interval ends before it starts",
+			value:       "2023-01-07T03:00:00Z/2023-01-07T01:00:00Z",
+			expectedErr: "maintenance window \"2023-01-07T03:00:00Z/2023-01-07T01:00:00Z\" ends before it starts",
+		},
+		{
+			name:        "invalid duration",
+			value:       "0 1 * * SAT;-2h",
+			expectedErr: "maintenance window duration \"-2h\" must be positive",
+		},
+		{
+			name:        "neither interval nor cron",
+			value:       "weekends",
+			expectedErr: "maintenance window \"weekends\" is neither an RFC3339 interval nor a cron schedule with a duration",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			window, err := parseMaintenanceWindow(c.value)
+			testinghelpers.AssertError(t, err, c.expectedErr)
+			if err != nil {
+				return
+			}
+
+			active, next := window(now)
+			if active != c.expectedActive || !next.Equal(c.expectedNextRun) {
+				t.Errorf("expected %v until %v, but got %v until %v", c.expectedActive, c.expectedNextRun, active, next)
+			}
+		})
+	}
+}
+
+func TestSyncMaintenance(t *testing.T) {
+	now := time.Now()
+	activeWindow := now.Add(-time.Hour).UTC().Format(time.RFC3339) + "/" + now.Add(time.Hour).UTC().Format(time.RFC3339)
+	pastWindow := now.Add(-2*time.Hour).UTC().Format(time.RFC3339) + "/" + now.Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	cases := []struct {
+		name            string
+		annotation      string
+		taints          []v1.Taint
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "no maintenance window",
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:       "maintenance window starts",
+			annotation: activeWindow,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch")
+				managedCluster := appliedManagedCluster(t, actions[0])
+				assertTaintsAdded(t, managedCluster.Spec.Taints, MaintenanceTaint)
+			},
+		},
+		{
+			name:            "in maintenance window",
+			annotation:      activeWindow,
+			taints:          []v1.Taint{MaintenanceTaint},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:       "maintenance window ends",
+			annotation: pastWindow,
+			taints:     []v1.Taint{UnreachableTaint, MaintenanceTaint},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch")
+				managedCluster := appliedManagedCluster(t, actions[0])
+				if len(managedCluster.Spec.Taints) != 1 || !helpers.IsTaintEqual(managedCluster.Spec.Taints[0], UnreachableTaint) {
+					t.Errorf("expected taint %#v, but actualTaints: %#v", UnreachableTaint, managedCluster.Spec.Taints)
+				}
+			},
+		},
+		{
+			name:       "invalid maintenance window",
+			annotation: "weekends",
+			taints:     []v1.Taint{MaintenanceTaint},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewAvailableManagedCluster()
+			if len(c.annotation) > 0 {
+				cluster.Annotations = map[string]string{helpers.ManagedClusterMaintenanceWindowAnnotation: c.annotation}
+			}
+			cluster.Spec.Taints = c.taints
+
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := &maintenanceController{
+				clusterClient:  clusterClient,
+				clusterLister:  clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				eventRecorder:  eventstesting.NewTestingEventRecorder(t),
+				clock:          clocktesting.NewFakeClock(now),
+				invalidWindows: map[string]string{},
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			testinghelpers.AssertError(t, syncErr, "")
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func TestReportInvalidMaintenanceWindow(t *testing.T) {
+	cluster := testinghelpers.NewAvailableManagedCluster()
+	cluster.Annotations = map[string]string{helpers.ManagedClusterMaintenanceWindowAnnotation: "weekends"}
+
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+	if err := clusterStore.Add(cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := events.NewInMemoryRecorder("")
+	ctrl := &maintenanceController{
+		clusterClient:  clusterClient,
+		clusterLister:  clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		eventRecorder:  recorder,
+		clock:          clocktesting.NewFakeClock(time.Now()),
+		invalidWindows: map[string]string{},
+	}
+
+	sync := func(annotation string) {
+		cluster = cluster.DeepCopy()
+		cluster.Annotations[helpers.ManagedClusterMaintenanceWindowAnnotation] = annotation
+		if err := clusterStore.Update(cluster); err != nil {
+			t.Fatal(err)
+		}
+		syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+		testinghelpers.AssertError(t, syncErr, "")
+	}
+
+	// the same invalid window is reported once, and it is reported again once it is changed
+	sync("weekends")
+	sync("weekends")
+	sync("weekdays")
+	sync("weekdays")
+	if len(recorder.Events()) != 2 {
+		t.Errorf("expected 2 events, but got %d", len(recorder.Events()))
+	}
+}
@@ -6,3 +6,17 @@ const (
 	// ManagedClustersGroup is a common group for all spoke clusters
 	ManagedClustersGroup = SubjectPrefix + "managed-clusters"
 )
+
+// OIDCUsername returns the user of the token with the issuer and subject claims authenticated by the hub
+// kube-apiserver, the subject is prefixed with the username prefix as the --oidc-username-prefix of the
+// kube-apiserver: the issuer followed by # if the prefix is empty, and nothing if the prefix is "-".
+func OIDCUsername(issuer, subject, prefix string) string {
+	switch prefix {
+	case "":
+		return issuer + "#" + subject
+	case "-":
+		return subject
+	default:
+		return prefix + subject
+	}
+}
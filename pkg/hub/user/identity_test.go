@@ -0,0 +1,34 @@
+package user
+
+import "testing"
+
+func TestOIDCUsername(t *testing.T) {
+	cases := []struct {
+		name     string
+		prefix   string
+		expected string
+	}{
+		{
+			name:     "no prefix",
+			expected: "https://issuer.example.com#system:serviceaccount:ns:agent",
+		},
+		{
+			name:     "prefix is disabled",
+			prefix:   "-",
+			expected: "system:serviceaccount:ns:agent",
+		},
+		{
+			name:     "custom prefix",
+			prefix:   "oidc:",
+			expected: "oidc:system:serviceaccount:ns:agent",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := OIDCUsername("https://issuer.example.com", "system:serviceaccount:ns:agent", c.prefix); actual != c.expected {
+				t.Errorf("expected %q, but got %q", c.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,3 @@
+// package logging contains the helpers to write the structured logs of the controllers in the text or json format,
+// with the per-controller verbosities
+package logging
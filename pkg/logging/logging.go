@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/zapr"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/registration/pkg/supervisor"
+)
+
+const (
+	// FormatText writes the log lines in the klog text format
+	FormatText = "text"
+	// FormatJSON writes the log lines as json objects, one object per line
+	FormatJSON = "json"
+)
+
+const (
+	// ControllerKey is the key of the name of the controller on the log lines of its syncs
+	ControllerKey = "controller"
+	// QueueKeyKey is the key of the queue key handled by a sync
+	QueueKeyKey = "queueKey"
+	// ClusterKey is the key of the name of the managed cluster handled by a sync
+	ClusterKey = "cluster"
+	// AddOnKey is the key of the name of the addon handled by a sync
+	AddOnKey = "addon"
+)
+
+var (
+	// base is the logger the controller loggers derive from if the context has no logger, it is the global klog
+	// logger until Setup is called
+	base = klog.Background()
+	// controllerLevels are the verbosities of the controllers overriding the -v flag
+	controllerLevels = map[string]int{}
+)
+
+// Validate verifies the logging format and the verbosities of the controllers
+func Validate(format string, levels map[string]int) error {
+	switch format {
+	case "", FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("logging format %q is not supported, it must be %s or %s", format, FormatText, FormatJSON)
+	}
+	for controller, level := range levels {
+		if level < 0 {
+			return fmt.Errorf("log level %d of controller %q must not be negative", level, controller)
+		}
+	}
+	return nil
+}
+
+// Setup installs the logger of the controllers writing in the format. The verbosities of the controllers in the
+// levels override the -v flag for the log lines of their syncs, so a noisy controller can be silenced or a single
+// controller can be debugged on a large hub. In the json format the klog lines of the other components are written
+// as json objects as well. It must be called before the controllers are started.
+func Setup(format string, levels map[string]int) error {
+	if err := Validate(format, levels); err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatJSON:
+		encoderConfig := zap.NewProductionEncoderConfig()
+		encoderConfig.TimeKey = "ts"
+		encoderConfig.EncodeTime = zapcore.EpochMillisTimeEncoder
+		// the levels of zap are meaningless for the logr verbosities, the verbosity is written with the v key
+		encoderConfig.LevelKey = ""
+		// all of the verbosities are enabled in zap, they are filtered by the levelSink instead
+		core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.Lock(os.Stderr), zapcore.Level(math.MinInt8))
+		zapLogger := zap.New(core, zap.AddCaller())
+
+		jsonLogger := zapr.NewLoggerWithOptions(zapLogger, zapr.LogInfoLevel("v"), zapr.ErrorKey("err"))
+		base = logr.New(newLevelSink(jsonLogger.GetSink(), -1))
+		klog.SetLoggerWithOptions(base, klog.ContextualLogger(true), klog.FlushLogger(func() {
+			_ = zapLogger.Sync()
+		}))
+	default:
+		base = logr.New(newLevelSink(logr.New(&textSink{}).GetSink(), -1))
+	}
+
+	controllerLevels = levels
+	return nil
+}
+
+// FromContext returns the logger in the context, or the logger installed by Setup if the context has no logger
+func FromContext(ctx context.Context) klog.Logger {
+	if logger, err := logr.FromContext(ctx); err == nil {
+		return logger
+	}
+	return base
+}
+
+// ControllerLogger returns the logger with the name of the controller. Its verbosity is the level of the controller
+// passed to Setup, or the -v flag if the controller has no level.
+func ControllerLogger(logger klog.Logger, controllerName string) klog.Logger {
+	logger = logger.WithValues(ControllerKey, controllerName)
+	if level, ok := controllerLevels[controllerName]; ok {
+		return logr.New(newLevelSink(logger.GetSink(), level))
+	}
+	return logger
+}
+
+// SyncWithLogger wraps the sync function of a controller, so the context of the sync function carries the logger of
+// the controller with the queue key. The sync function gets the logger with klog.FromContext. The panics of the sync
+// function are recovered by the supervisor, so a panicked controller is restarted rather than crashing the process.
+func SyncWithLogger(controllerName string, sync factory.SyncFunc) factory.SyncFunc {
+	sync = supervisor.Sync(controllerName, sync)
+	return func(ctx context.Context, syncCtx factory.SyncContext) error {
+		logger := ControllerLogger(FromContext(ctx), controllerName).WithValues(QueueKeyKey, syncCtx.QueueKey())
+		return sync(klog.NewContext(ctx, logger), syncCtx)
+	}
+}
+
+// WithCluster adds the name of the managed cluster to the logger in the context, and returns the context and the
+// logger
+func WithCluster(ctx context.Context, clusterName string) (context.Context, klog.Logger) {
+	logger := FromContext(ctx).WithValues(ClusterKey, clusterName)
+	return klog.NewContext(ctx, logger), logger
+}
+
+// WithAddOn adds the names of the managed cluster and the addon to the logger in the context, and returns the context
+// and the logger
+func WithAddOn(ctx context.Context, clusterName, addOnName string) (context.Context, klog.Logger) {
+	logger := FromContext(ctx).WithValues(ClusterKey, clusterName, AddOnKey, addOnName)
+	return klog.NewContext(ctx, logger), logger
+}
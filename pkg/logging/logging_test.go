@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/openshift/library-go/pkg/controller/factory"
+
+	"k8s.io/klog/v2"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name        string
+		format      string
+		levels      map[string]int
+		expectedErr string
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:   "json with levels",
+			format: FormatJSON,
+			levels: map[string]int{"CSRApprovingController": 4},
+		},
+		{
+			name:        "unsupported format",
+			format:      "yaml",
+			expectedErr: "logging format \"yaml\" is not supported, it must be text or json",
+		},
+		{
+			name:        "negative level",
+			format:      FormatText,
+			levels:      map[string]int{"CSRApprovingController": -1},
+			expectedErr: "log level -1 of controller \"CSRApprovingController\" must not be negative",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testinghelpers.AssertError(t, Validate(c.format, c.levels), c.expectedErr)
+		})
+	}
+}
+
+func TestSyncWithLogger(t *testing.T) {
+	defer func(levels map[string]int) {
+		controllerLevels = levels
+	}(controllerLevels)
+	controllerLevels = map[string]int{"QuietController": 0, "VerboseController": 4}
+
+	cases := []struct {
+		name           string
+		controllerName string
+		expectedLines  []string
+	}{
+		{
+			name:           "controller without level",
+			controllerName: "DefaultController",
+			expectedLines: []string{
+				`"level"=0 "msg"="sync" "controller"="DefaultController" "queueKey"="cluster1" "cluster"="cluster1" "addon"="addon1"`,
+				`"level"=2 "msg"="debug" "controller"="DefaultController" "queueKey"="cluster1" "cluster"="cluster1" "addon"="addon1"`,
+				`"msg"="failed" "error"="boom" "controller"="DefaultController" "queueKey"="cluster1" "cluster"="cluster1" "addon"="addon1"`,
+			},
+		},
+		{
+			name:           "quiet controller",
+			controllerName: "QuietController",
+			expectedLines: []string{
+				`"level"=0 "msg"="sync" "controller"="QuietController" "queueKey"="cluster1" "cluster"="cluster1" "addon"="addon1"`,
+				`"msg"="failed" "error"="boom" "controller"="QuietController" "queueKey"="cluster1" "cluster"="cluster1" "addon"="addon1"`,
+			},
+		},
+		{
+			name:           "verbose controller",
+			controllerName: "VerboseController",
+			expectedLines: []string{
+				`"level"=0 "msg"="sync" "controller"="VerboseController" "queueKey"="cluster1" "cluster"="cluster1" "addon"="addon1"`,
+				`"level"=2 "msg"="debug" "controller"="VerboseController" "queueKey"="cluster1" "cluster"="cluster1" "addon"="addon1"`,
+				`"level"=4 "msg"="trace" "controller"="VerboseController" "queueKey"="cluster1" "cluster"="cluster1" "addon"="addon1"`,
+				`"msg"="failed" "error"="boom" "controller"="VerboseController" "queueKey"="cluster1" "cluster"="cluster1" "addon"="addon1"`,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			lines := []string{}
+			logger := funcr.New(func(prefix, args string) {
+				lines = append(lines, args)
+			}, funcr.Options{Verbosity: 2})
+
+			sync := SyncWithLogger(c.controllerName, func(ctx context.Context, syncCtx factory.SyncContext) error {
+				_, logger := WithAddOn(ctx, "cluster1", "addon1")
+				logger.Info("sync")
+				logger.V(2).Info("debug")
+				logger.V(4).Info("trace")
+				logger.Error(errors.New("boom"), "failed")
+				return nil
+			})
+			ctx := klog.NewContext(context.TODO(), logger)
+			if err := sync(ctx, testinghelpers.NewFakeSyncContext(t, "cluster1")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(lines, c.expectedLines) {
+				t.Errorf("expected lines:\n%v\nbut got:\n%v", c.expectedLines, lines)
+			}
+		})
+	}
+}
+
+func TestWithCluster(t *testing.T) {
+	lines := []string{}
+	logger := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{})
+
+	ctx, _ := WithCluster(klog.NewContext(context.TODO(), logger), "cluster1")
+	klog.FromContext(ctx).Info("reconciling")
+
+	expectedLines := []string{`"level"=0 "msg"="reconciling" "cluster"="cluster1"`}
+	if !reflect.DeepEqual(lines, expectedLines) {
+		t.Errorf("expected lines %v, but got %v", expectedLines, lines)
+	}
+}
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/klog/v2"
+)
+
+// levelSink filters the info lines of the wrapped sink by its verbosity, or by the -v flag if the verbosity is
+// negative. The wrapped sink is expected to write all of the lines it gets.
+type levelSink struct {
+	sink  logr.LogSink
+	level int
+}
+
+var _ logr.LogSink = &levelSink{}
+var _ logr.CallDepthLogSink = &levelSink{}
+
+// newLevelSink wraps the initialized sink, the call depth of the sink is increased for the frame of the levelSink
+func newLevelSink(sink logr.LogSink, level int) *levelSink {
+	if withCallDepth, ok := sink.(logr.CallDepthLogSink); ok {
+		sink = withCallDepth.WithCallDepth(1)
+	}
+	return &levelSink{sink: sink, level: level}
+}
+
+// Init does nothing since the wrapped sink is already initialized
+func (s *levelSink) Init(logr.RuntimeInfo) {}
+
+func (s *levelSink) Enabled(level int) bool {
+	if s.level < 0 {
+		return klog.V(klog.Level(level)).Enabled()
+	}
+	return level <= s.level
+}
+
+func (s *levelSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	// the messages of the klog Infof calls end with a line break
+	s.sink.Info(level, strings.TrimSuffix(msg, "\n"), keysAndValues...)
+}
+
+func (s *levelSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.sink.Error(err, strings.TrimSuffix(msg, "\n"), keysAndValues...)
+}
+
+func (s *levelSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &levelSink{sink: s.sink.WithValues(keysAndValues...), level: s.level}
+}
+
+func (s *levelSink) WithName(name string) logr.LogSink {
+	return &levelSink{sink: s.sink.WithName(name), level: s.level}
+}
+
+func (s *levelSink) WithCallDepth(depth int) logr.LogSink {
+	withCallDepth, ok := s.sink.(logr.CallDepthLogSink)
+	if !ok {
+		return s
+	}
+	return &levelSink{sink: withCallDepth.WithCallDepth(depth), level: s.level}
+}
+
+// textSink writes the lines in the klog text format without checking the -v flag, so the lines of a controller
+// whose verbosity is higher than the -v flag are not dropped by klog.
+type textSink struct {
+	name      string
+	values    []interface{}
+	callDepth int
+}
+
+var _ logr.LogSink = &textSink{}
+var _ logr.CallDepthLogSink = &textSink{}
+
+func (s *textSink) Init(info logr.RuntimeInfo) {
+	s.callDepth += info.CallDepth
+}
+
+func (s *textSink) Enabled(int) bool {
+	return true
+}
+
+func (s *textSink) Info(_ int, msg string, keysAndValues ...interface{}) {
+	klog.InfoSDepth(s.callDepth+1, s.message(msg), s.merge(keysAndValues)...)
+}
+
+func (s *textSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	klog.ErrorSDepth(s.callDepth+1, err, s.message(msg), s.merge(keysAndValues)...)
+}
+
+func (s textSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	s.values = s.merge(keysAndValues)
+	return &s
+}
+
+func (s textSink) WithName(name string) logr.LogSink {
+	if len(s.name) > 0 {
+		s.name += "/"
+	}
+	s.name += name
+	return &s
+}
+
+func (s textSink) WithCallDepth(depth int) logr.LogSink {
+	s.callDepth += depth
+	return &s
+}
+
+func (s *textSink) message(msg string) string {
+	if len(s.name) == 0 {
+		return msg
+	}
+	return s.name + ": " + msg
+}
+
+// merge returns a new slice of the values of the sink followed by the keysAndValues
+func (s *textSink) merge(keysAndValues []interface{}) []interface{} {
+	merged := make([]interface{}, 0, len(s.values)+len(keysAndValues))
+	merged = append(merged, s.values...)
+	return append(merged, keysAndValues...)
+}
@@ -10,14 +10,31 @@ import (
 
 	certificatesv1 "k8s.io/api/certificates/v1"
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"open-cluster-management.io/registration/pkg/helpers"
 )
 
 const (
 	defaultAddOnInstallationNamespace = "open-cluster-management-agent-addon"
 	// hostingClusterNameAnnotation is the annotation for indicating the hosting cluster name
 	hostingClusterNameAnnotation = "addon.open-cluster-management.io/hosting-cluster-name"
+	// registrationSecretsAnnotation is the annotation for overriding the name and namespace of the secrets of the
+	// client certificates on the managed cluster, its value is a json map from the signer names to the secrets, e.g.
+	// {"kubernetes.io/kube-apiserver-client": {"name": "hub-kubeconfig", "namespace": "addon-system"}}. It allows the
+	// addons deployed with fixed manifests to consume the credentials without copying the secrets. The secrets must be
+	// in the installation namespace of the addon, which cannot be the namespace of the agent.
+	registrationSecretsAnnotation = "addon.open-cluster-management.io/registration-secrets"
+	// registrationSecretOwnerLabel is the label of the secrets of the client certificates created by the agent, its
+	// value is the name of the addon. Only the labeled secrets are deleted once the registrations are stopped.
+	registrationSecretOwnerLabel = "addon.open-cluster-management.io/registration-owner"
 )
 
+// registrationSecret is the name and namespace of the secret of a client certificate, the default name or the
+// installation namespace of the addon is used if it is empty.
+type registrationSecret struct {
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
 // registrationConfig contains necessary information for addon registration
 // TODO: Refactor the code here once the registration configuration is available in spec of ManagedClusterAddOn
 type registrationConfig struct {
@@ -27,8 +44,18 @@ type registrationConfig struct {
 	// secretName is the name of secret containing client certificate. If the SignerName is "kubernetes.io/kube-apiserver-client",
 	// the secret name will be "{addon name}-hub-kubeconfig". Otherwise, the secret name will be "{addon name}-{signer name}-client-cert".
 	secretName string
-	hash       string
-	stopFunc   context.CancelFunc
+	// secretNamespace is the namespace of the secret containing client certificate, the installation namespace of
+	// the addon is used if it is empty.
+	secretNamespace string
+	hash            string
+	stopFunc        context.CancelFunc
+
+	// signerCAHash is the hash of the CA bundle of the signer, the client certificate issued by the previous
+	// signer CA is revoked once it is changed.
+	signerCAHash string
+	// baseHash is the hash of the config without the signer CA hash, so the config is kept once the signer CA hash
+	// is recorded by the hub for the first time.
+	baseHash string
 
 	addonInstallOption
 }
@@ -58,6 +85,14 @@ func (c *registrationConfig) x509Subject(clusterName, agentName string) *pkix.Na
 	return subject
 }
 
+// getSecretNamespace returns the namespace of the secret containing client certificate
+func (c *registrationConfig) getSecretNamespace() string {
+	if len(c.secretNamespace) > 0 {
+		return c.secretNamespace
+	}
+	return c.InstallationNamespace
+}
+
 // getAddOnInstallationNamespace returns addon installation namespace from addon spec.
 // It first checks the installation namespace in status then addon spec, the addon default
 // installation namespace open-cluster-management-agent-addon will be returned.
@@ -82,11 +117,24 @@ func isAddonRunningOutsideManagedCluster(addOn *addonv1alpha1.ManagedClusterAddO
 	return false
 }
 
+// getAddOnLeaseDurationSeconds returns the lease duration seconds of the addon, the
+// AddOnLeaseControllerLeaseDurationSeconds is returned if its annotation is not set or invalid.
+func getAddOnLeaseDurationSeconds(addOn *addonv1alpha1.ManagedClusterAddOn) int {
+	return helpers.GetAddOnLeaseDurationSeconds(addOn, AddOnLeaseControllerLeaseDurationSeconds)
+}
+
 // getRegistrationConfigs reads annotations of a addon and returns a map of registrationConfig whose
-// key is the hash of the registrationConfig
-func getRegistrationConfigs(addOn *addonv1alpha1.ManagedClusterAddOn) (map[string]registrationConfig, error) {
+// key is the hash of the registrationConfig. The secrets cannot be overridden in the namespace of the agent.
+func getRegistrationConfigs(addOn *addonv1alpha1.ManagedClusterAddOn, agentNamespace string) (map[string]registrationConfig, error) {
 	configs := map[string]registrationConfig{}
 
+	secrets := map[string]registrationSecret{}
+	if value, ok := addOn.Annotations[registrationSecretsAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &secrets); err != nil {
+			return configs, fmt.Errorf("failed to parse the annotation %q of addon %q: %w", registrationSecretsAnnotation, addOn.Name, err)
+		}
+	}
+
 	for _, registration := range addOn.Status.Registrations {
 		config := registrationConfig{
 			addOnName: addOn.Name,
@@ -95,6 +143,7 @@ func getRegistrationConfigs(addOn *addonv1alpha1.ManagedClusterAddOn) (map[strin
 				InstallationNamespace:             getAddOnInstallationNamespace(addOn),
 			},
 			registration: registration,
+			signerCAHash: addOn.Annotations[helpers.AddOnSignerCAHashAnnotation],
 		}
 
 		// set the secret name of client certificate
@@ -105,22 +154,48 @@ func getRegistrationConfigs(addOn *addonv1alpha1.ManagedClusterAddOn) (map[strin
 			config.secretName = fmt.Sprintf("%s-%s-client-cert", addOn.Name, strings.ReplaceAll(registration.SignerName, "/", "-"))
 		}
 
-		// hash registration configuration, install namespace and addOnAgentRunningOutsideManagedCluster. Use the hash
-		// value as the key of map to make sure each registration configuration and addon installation option is unique
+		// override the name and namespace of the secret
+		var secretOverride string
+		if secret, ok := secrets[registration.SignerName]; ok {
+			if len(secret.Namespace) > 0 && secret.Namespace != config.InstallationNamespace {
+				return configs, fmt.Errorf("the secret of signer %q of addon %q must be in its installation namespace %q",
+					registration.SignerName, addOn.Name, config.InstallationNamespace)
+			}
+			if config.InstallationNamespace == agentNamespace {
+				return configs, fmt.Errorf("the secret of signer %q of addon %q cannot be overridden in the namespace %q of the agent",
+					registration.SignerName, addOn.Name, agentNamespace)
+			}
+			if len(secret.Name) > 0 {
+				config.secretName = secret.Name
+			}
+			config.secretNamespace = secret.Namespace
+			secretOverride = fmt.Sprintf("%s/%s", secret.Namespace, secret.Name)
+		}
+
+		// hash registration configuration, install namespace, addOnAgentRunningOutsideManagedCluster, signer CA hash
+		// and secret override. Use the hash value as the key of map to make sure each registration configuration and
+		// addon installation option is unique
 		hash, err := getConfigHash(
 			registration,
-			config.addonInstallOption)
+			config.addonInstallOption,
+			config.signerCAHash,
+			secretOverride)
 		if err != nil {
 			return configs, err
 		}
 		config.hash = hash
+		config.baseHash, err = getConfigHash(registration, config.addonInstallOption, "", secretOverride)
+		if err != nil {
+			return configs, err
+		}
 		configs[config.hash] = config
 	}
 
 	return configs, nil
 }
 
-func getConfigHash(registration addonv1alpha1.RegistrationConfig, installOption addonInstallOption) (string, error) {
+func getConfigHash(registration addonv1alpha1.RegistrationConfig, installOption addonInstallOption,
+	signerCAHash, secretOverride string) (string, error) {
 	data, err := json.Marshal(registration)
 	if err != nil {
 		return "", err
@@ -134,6 +209,14 @@ func getConfigHash(registration addonv1alpha1.RegistrationConfig, installOption
 	h := sha256.New()
 	h.Write(data)
 	h.Write(installOptionData)
+	// keep the hash unchanged for the addons without signer CA hash
+	if len(signerCAHash) > 0 {
+		h.Write([]byte(signerCAHash))
+	}
+	// keep the hash unchanged for the addons without secret override
+	if len(secretOverride) > 0 {
+		h.Write([]byte(secretOverride))
+	}
 
 	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
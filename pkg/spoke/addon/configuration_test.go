@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"open-cluster-management.io/registration/pkg/helpers"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -124,7 +125,7 @@ func TestGetRegistrationConfigs(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			configs, err := getRegistrationConfigs(c.addon)
+			configs, err := getRegistrationConfigs(c.addon, "open-cluster-management-agent")
 			if err != nil {
 				t.Errorf("unexpected error: %v", err)
 			}
@@ -141,6 +142,105 @@ func TestGetRegistrationConfigs(t *testing.T) {
 	}
 }
 
+func TestGetRegistrationConfigsWithSecretOverride(t *testing.T) {
+	cases := []struct {
+		name              string
+		annotation        string
+		agentNamespace    string
+		expectedErr       bool
+		expectedName      string
+		expectedNamespace string
+	}{
+		{
+			name:              "no override",
+			expectedName:      "addon1-hub-kubeconfig",
+			expectedNamespace: "ns1",
+		},
+		{
+			name:              "override name and namespace",
+			annotation:        `{"kubernetes.io/kube-apiserver-client": {"name": "hub-kubeconfig", "namespace": "ns1"}}`,
+			expectedName:      "hub-kubeconfig",
+			expectedNamespace: "ns1",
+		},
+		{
+			name:        "override namespace out of the installation namespace",
+			annotation:  `{"kubernetes.io/kube-apiserver-client": {"name": "hub-kubeconfig", "namespace": "addon-system"}}`,
+			expectedErr: true,
+		},
+		{
+			name:           "override in the namespace of the agent",
+			annotation:     `{"kubernetes.io/kube-apiserver-client": {"name": "hub-kubeconfig-secret"}}`,
+			agentNamespace: "ns1",
+			expectedErr:    true,
+		},
+		{
+			name:              "override name only",
+			annotation:        `{"kubernetes.io/kube-apiserver-client": {"name": "hub-kubeconfig"}}`,
+			expectedName:      "hub-kubeconfig",
+			expectedNamespace: "ns1",
+		},
+		{
+			name:              "override of other signer",
+			annotation:        `{"mysigner": {"name": "client-cert"}}`,
+			expectedName:      "addon1-hub-kubeconfig",
+			expectedNamespace: "ns1",
+		},
+		{
+			name:        "invalid annotation",
+			annotation:  "hub-kubeconfig",
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := &addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "addon1",
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "ns1",
+				},
+				Status: addonv1alpha1.ManagedClusterAddOnStatus{
+					Registrations: []addonv1alpha1.RegistrationConfig{
+						{
+							SignerName: "kubernetes.io/kube-apiserver-client",
+						},
+					},
+				},
+			}
+			if len(c.annotation) > 0 {
+				addOn.Annotations = map[string]string{registrationSecretsAnnotation: c.annotation}
+			}
+
+			agentNamespace := "open-cluster-management-agent"
+			if len(c.agentNamespace) > 0 {
+				agentNamespace = c.agentNamespace
+			}
+			configs, err := getRegistrationConfigs(addOn, agentNamespace)
+			if c.expectedErr {
+				if err == nil {
+					t.Errorf("expected error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(configs) != 1 {
+				t.Fatalf("expected 1 config, but got %d", len(configs))
+			}
+			for _, config := range configs {
+				if config.secretName != c.expectedName || config.getSecretNamespace() != c.expectedNamespace {
+					t.Errorf("expected secret %s/%s, but got %s/%s",
+						c.expectedNamespace, c.expectedName, config.getSecretNamespace(), config.secretName)
+				}
+			}
+		})
+	}
+}
+
 func newRegistrationConfig(addOnName, addOnNamespace, signerName, commonName string, organization []string,
 	addOnAgentRunningOutsideManagedCluster bool) registrationConfig {
 	registration := addonv1alpha1.RegistrationConfig{
@@ -160,8 +260,41 @@ func newRegistrationConfig(addOnName, addOnNamespace, signerName, commonName str
 		registration: registration,
 	}
 
-	hash, _ := getConfigHash(registration, config.addonInstallOption)
+	hash, _ := getConfigHash(registration, config.addonInstallOption, config.signerCAHash, "")
 	config.hash = hash
 
 	return config
 }
+
+func TestGetAddOnLeaseDurationSeconds(t *testing.T) {
+	cases := []struct {
+		name            string
+		annotations     map[string]string
+		expectedSeconds int
+	}{
+		{
+			name:            "no annotation",
+			expectedSeconds: AddOnLeaseControllerLeaseDurationSeconds,
+		},
+		{
+			name:            "lease duration seconds",
+			annotations:     map[string]string{helpers.AddOnLeaseDurationSecondsAnnotation: "300"},
+			expectedSeconds: 300,
+		},
+		{
+			name:            "invalid lease duration seconds",
+			annotations:     map[string]string{helpers.AddOnLeaseDurationSecondsAnnotation: "-1"},
+			expectedSeconds: AddOnLeaseControllerLeaseDurationSeconds,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := &addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: c.annotations},
+			}
+			if seconds := getAddOnLeaseDurationSeconds(addOn); seconds != c.expectedSeconds {
+				t.Errorf("expected %d, but got %d", c.expectedSeconds, seconds)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
 	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
 	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+	"open-cluster-management.io/registration/pkg/health"
 	"open-cluster-management.io/registration/pkg/helpers"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -27,7 +28,8 @@ import (
 const leaseDurationTimes = 5
 
 // AddOnLeaseControllerLeaseDurationSeconds is exposed so that integration tests can crank up the lease update speed.
-// TODO we may add this to ManagedClusterAddOn API to allow addon to adjust its own lease duration seconds
+// It is the default lease duration seconds of the addons, an addon can adjust its own lease duration seconds with
+// the addon.open-cluster-management.io/lease-duration-seconds annotation.
 var AddOnLeaseControllerLeaseDurationSeconds = 60
 
 // managedClusterAddOnLeaseController updates the managed cluster addons status on the hub cluster through checking the add-on
@@ -50,10 +52,11 @@ func NewManagedClusterAddOnLeaseController(clusterName string,
 	managementLeaseClient coordv1client.CoordinationV1Interface,
 	spokeLeaseClient coordv1client.CoordinationV1Interface,
 	resyncInterval time.Duration,
+	clock clock.Clock,
 	recorder events.Recorder) factory.Controller {
 	c := &managedClusterAddOnLeaseController{
 		clusterName:           clusterName,
-		clock:                 clock.RealClock{},
+		clock:                 clock,
 		addOnClient:           addOnClient,
 		addOnLister:           addOnInformer.Lister(),
 		hubLeaseClient:        hubLeaseClient,
@@ -66,7 +69,7 @@ func NewManagedClusterAddOnLeaseController(clusterName string,
 	// informer cache sync and result in fatal exit of this controller. The code will be factored
 	// when we no longer support kubernetes version lower than 1.17.
 	return factory.New().
-		WithSync(c.sync).
+		WithSync(health.DefaultRegistry.TrackSync("ManagedClusterAddOnLeaseController", c.sync)).
 		ResyncEvery(resyncInterval).
 		ToController("ManagedClusterAddOnLeaseController", recorder)
 }
@@ -114,7 +117,7 @@ func (c *managedClusterAddOnLeaseController) syncSingle(ctx context.Context,
 	addOn *addonv1alpha1.ManagedClusterAddOn,
 	recorder events.Recorder) error {
 	now := c.clock.Now()
-	gracePeriod := time.Duration(leaseDurationTimes*AddOnLeaseControllerLeaseDurationSeconds) * time.Second
+	gracePeriod := time.Duration(leaseDurationTimes*getAddOnLeaseDurationSeconds(addOn)) * time.Second
 
 	// if the add-on agent is running on the managed cluster, try to fetch the add-on lease on the managed cluster,
 	// otherwise (running outside of the managed cluster), fetch the add-on lease on the management cluster instead.
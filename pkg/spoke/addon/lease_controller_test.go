@@ -2,13 +2,13 @@ package addon
 
 import (
 	"context"
-	"encoding/json"
 	"testing"
 	"time"
 
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
 	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	"open-cluster-management.io/registration/pkg/helpers"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -141,12 +141,8 @@ func TestSync(t *testing.T) {
 			spokeLeases: []runtime.Object{},
 			validateActions: func(t *testing.T, ctx *testinghelpers.FakeSyncContext, actions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
 				addOn := &addonv1alpha1.ManagedClusterAddOn{}
-				err := json.Unmarshal(patch, addOn)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[1], addOn)
 				addOnCond := meta.FindStatusCondition(addOn.Status.Conditions, "Available")
 				if addOnCond == nil {
 					t.Errorf("expected addon available condition, but failed")
@@ -175,12 +171,8 @@ func TestSync(t *testing.T) {
 			},
 			validateActions: func(t *testing.T, ctx *testinghelpers.FakeSyncContext, actions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
 				addOn := &addonv1alpha1.ManagedClusterAddOn{}
-				err := json.Unmarshal(patch, addOn)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[1], addOn)
 				addOnCond := meta.FindStatusCondition(addOn.Status.Conditions, "Available")
 				if addOnCond == nil {
 					t.Errorf("expected addon available condition, but failed")
@@ -191,6 +183,37 @@ func TestSync(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:     "addon updates its lease slowly by design",
+			queueKey: "test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   testinghelpers.TestManagedClusterName,
+					Name:        "test",
+					Annotations: map[string]string{helpers.AddOnLeaseDurationSecondsAnnotation: "120"},
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "test",
+				},
+			}},
+			hubLeases: []runtime.Object{},
+			spokeLeases: []runtime.Object{
+				testinghelpers.NewAddOnLease("test", "test", now.Add(-5*time.Minute)),
+			},
+			validateActions: func(t *testing.T, ctx *testinghelpers.FakeSyncContext, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				addOn := &addonv1alpha1.ManagedClusterAddOn{}
+				testinghelpers.UnmarshalPatch(t, actions[1], addOn)
+				addOnCond := meta.FindStatusCondition(addOn.Status.Conditions, "Available")
+				if addOnCond == nil {
+					t.Errorf("expected addon available condition, but failed")
+					return
+				}
+				if addOnCond.Status != metav1.ConditionTrue {
+					t.Errorf("expected addon available condition is available, but failed")
+				}
+			},
+		},
 		{
 			name:     "addon update its lease constantly",
 			queueKey: "test/test",
@@ -209,12 +232,8 @@ func TestSync(t *testing.T) {
 			},
 			validateActions: func(t *testing.T, ctx *testinghelpers.FakeSyncContext, actions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
 				addOn := &addonv1alpha1.ManagedClusterAddOn{}
-				err := json.Unmarshal(patch, addOn)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[1], addOn)
 				addOnCond := meta.FindStatusCondition(addOn.Status.Conditions, "Available")
 				if addOnCond == nil {
 					t.Errorf("expected addon available condition, but failed")
@@ -306,12 +325,8 @@ func TestSync(t *testing.T) {
 			},
 			validateActions: func(t *testing.T, ctx *testinghelpers.FakeSyncContext, actions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
 				addOn := &addonv1alpha1.ManagedClusterAddOn{}
-				err := json.Unmarshal(patch, addOn)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[1], addOn)
 				addOnCond := meta.FindStatusCondition(addOn.Status.Conditions, "Available")
 				if addOnCond == nil {
 					t.Errorf("expected addon available condition, but failed")
@@ -335,12 +350,8 @@ func TestSync(t *testing.T) {
 			spokeLeases: []runtime.Object{},
 			validateActions: func(t *testing.T, ctx *testinghelpers.FakeSyncContext, actions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
 				addOn := &addonv1alpha1.ManagedClusterAddOn{}
-				err := json.Unmarshal(patch, addOn)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[1], addOn)
 				addOnCond := meta.FindStatusCondition(addOn.Status.Conditions, "Available")
 				if addOnCond == nil {
 					t.Errorf("expected addon available condition, but failed")
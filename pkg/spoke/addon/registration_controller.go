@@ -1,37 +1,59 @@
 package addon
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 	operatorhelpers "github.com/openshift/library-go/pkg/operator/v1helpers"
 	certificatesv1 "k8s.io/api/certificates/v1"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
+	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
 	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
 	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
 	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/health"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
 )
 
 const (
-	indexByAddon = "indexByAddon"
+	indexByAddon        = "indexByAddon"
+	indexByAddonCluster = "indexByAddonCluster"
 
 	// TODO(qiujian16) expose it if necessary in the future.
 	addonCSRThreshold = 10
+
+	// ClientCertificateRevokedCondition is the condition type of ManagedClusterAddOn that the client certificate
+	// of the addon is revoked on the managed cluster, and its secret is deleted.
+	ClientCertificateRevokedCondition = "ClientCertificateRevoked"
+
+	// RegistrationAppliedCondition is the condition type of ManagedClusterAddOn that the client certificates of all
+	// the registrations of the addon are issued and saved in their secrets.
+	RegistrationAppliedCondition = "RegistrationApplied"
+
+	// RegistrationConfigDriftedCondition is the condition type of ManagedClusterAddOn that the kubeconfig in the
+	// secret of a registration is different from the hub kubeconfig the registration agent declares for it.
+	RegistrationConfigDriftedCondition = "RegistrationConfigDrifted"
 )
 
 // addOnRegistrationController monitors ManagedClusterAddOns on hub and starts addOn registration
@@ -41,17 +63,33 @@ const (
 type addOnRegistrationController struct {
 	clusterName          string
 	agentName            string
+	agentNamespace       string
 	kubeconfigData       []byte
 	managementKubeClient kubernetes.Interface // in-cluster local management kubeClient
 	spokeKubeClient      kubernetes.Interface
 	hubAddOnLister       addonlisterv1alpha1.ManagedClusterAddOnLister
 	addOnClient          addonclient.Interface
-	csrControl           clientcert.CSRControl
+	registrationDriver   clientcert.RegistrationDriver
 	recorder             events.Recorder
 	csrIndexer           cache.Indexer
 
+	// renewalThreshold and renewalJitter configure when the addon client certificates are rotated
+	renewalThreshold float64
+	renewalJitter    float64
+	// maxConcurrentCSRs caps the number of pending addon csrs created by the agent, 0 means no limit
+	maxConcurrentCSRs int
+	// keyProvider generates and holds the private keys of the addon client certificates
+	keyProvider clientcert.KeyProvider
+
 	startRegistrationFunc func(ctx context.Context, config registrationConfig) context.CancelFunc
 
+	// secretInformers are the informer factories of the secrets in the namespaces of the registrations, keyed by the
+	// cluster and the namespace of the secrets. They are shared by the client certificate controllers and the status
+	// reports of the registrations.
+	secretInformers map[string]informers.SharedInformerFactory
+	// queue is the queue of the controller, the addons are requeued once the secrets of their registrations are changed
+	queue workqueue.RateLimitingInterface
+
 	// registrationConfigs maps the addon name to a map of registrationConfigs whose key is the hash of
 	// the registrationConfig
 	addOnRegistrationConfigs map[string]map[string]registrationConfig
@@ -61,30 +99,40 @@ type addOnRegistrationController struct {
 func NewAddOnRegistrationController(
 	clusterName string,
 	agentName string,
+	agentNamespace string,
 	kubeconfigData []byte,
 	addOnClient addonclient.Interface,
 	managementKubeClient kubernetes.Interface,
 	managedKubeClient kubernetes.Interface,
-	csrControl clientcert.CSRControl,
+	registrationDriver clientcert.RegistrationDriver,
 	hubAddOnInformers addoninformerv1alpha1.ManagedClusterAddOnInformer,
+	renewalThreshold, renewalJitter float64,
+	maxConcurrentCSRs int,
+	keyProvider clientcert.KeyProvider,
 	recorder events.Recorder,
 ) factory.Controller {
 	c := &addOnRegistrationController{
 		clusterName:              clusterName,
 		agentName:                agentName,
+		agentNamespace:           agentNamespace,
 		kubeconfigData:           kubeconfigData,
 		managementKubeClient:     managementKubeClient,
 		spokeKubeClient:          managedKubeClient,
 		hubAddOnLister:           hubAddOnInformers.Lister(),
-		csrControl:               csrControl,
+		registrationDriver:       registrationDriver,
 		addOnClient:              addOnClient,
 		recorder:                 recorder,
-		csrIndexer:               csrControl.Informer().GetIndexer(),
+		csrIndexer:               registrationDriver.Informer().GetIndexer(),
+		renewalThreshold:         renewalThreshold,
+		renewalJitter:            renewalJitter,
+		maxConcurrentCSRs:        maxConcurrentCSRs,
+		keyProvider:              keyProvider,
 		addOnRegistrationConfigs: map[string]map[string]registrationConfig{},
 	}
 
-	err := csrControl.Informer().AddIndexers(cache.Indexers{
-		indexByAddon: indexByAddonFunc,
+	err := registrationDriver.Informer().AddIndexers(cache.Indexers{
+		indexByAddon:        indexByAddonFunc,
+		indexByAddonCluster: indexByAddonClusterFunc,
 	})
 	if err != nil {
 		utilruntime.HandleError(err)
@@ -92,14 +140,18 @@ func NewAddOnRegistrationController(
 
 	c.startRegistrationFunc = c.startRegistration
 
+	syncCtx := factory.NewSyncContext("AddOnRegistrationController", recorder)
+	c.queue = syncCtx.Queue()
+
 	return factory.New().
+		WithSyncContext(syncCtx).
 		WithInformersQueueKeyFunc(
 			func(obj runtime.Object) string {
 				accessor, _ := meta.Accessor(obj)
 				return accessor.GetName()
 			},
 			hubAddOnInformers.Informer()).
-		WithSync(c.sync).
+		WithSync(health.DefaultRegistry.TrackSync("AddOnRegistrationController", c.sync)).
 		ResyncEvery(10*time.Minute).
 		ToController("AddOnRegistrationController", recorder)
 }
@@ -132,7 +184,8 @@ func (c *addOnRegistrationController) sync(ctx context.Context, syncCtx factory.
 }
 
 func (c *addOnRegistrationController) syncAddOn(ctx context.Context, syncCtx factory.SyncContext, addOnName string) error {
-	klog.V(4).Infof("Reconciling addOn %q", addOnName)
+	ctx, logger := logging.WithAddOn(ctx, c.clusterName, addOnName)
+	logger.V(4).Info("Reconciling addOn")
 
 	addOn, err := c.hubAddOnLister.ManagedClusterAddOns(c.clusterName).Get(addOnName)
 	if errors.IsNotFound(err) {
@@ -150,21 +203,55 @@ func (c *addOnRegistrationController) syncAddOn(ctx context.Context, syncCtx fac
 	}
 
 	cachedConfigs := c.addOnRegistrationConfigs[addOnName]
-	configs, err := getRegistrationConfigs(addOn)
+	configs, err := getRegistrationConfigs(addOn, c.agentNamespace)
 	if err != nil {
 		return err
 	}
 
+	// the client certificates issued before the signer CA hash is recorded by the hub for the first time are kept,
+	// the hub records the hash of the CA which issues them
+	adopted, adoptedHashes := map[string]registrationConfig{}, sets.New[string]()
+	for hash, cachedConfig := range cachedConfigs {
+		if _, ok := configs[hash]; ok || len(cachedConfig.signerCAHash) > 0 {
+			continue
+		}
+		for newHash, config := range configs {
+			if _, ok := cachedConfigs[newHash]; ok || config.baseHash != cachedConfig.baseHash {
+				continue
+			}
+			cachedConfig.hash = newHash
+			cachedConfig.signerCAHash = config.signerCAHash
+			adopted[newHash] = cachedConfig
+			adoptedHashes.Insert(hash)
+			break
+		}
+	}
+
 	// stop registration for the stale registration configs
 	errs := []error{}
 	for hash, cachedConfig := range cachedConfigs {
-		if _, ok := configs[hash]; ok {
+		if _, ok := configs[hash]; ok || adoptedHashes.Has(hash) {
 			continue
 		}
 
 		if err := c.stopRegistration(ctx, cachedConfig); err != nil {
 			errs = append(errs, err)
+			continue
 		}
+
+		// report the revoked client certificate, so the stale credentials are not expected to be used any more
+		reason := revokedReason(cachedConfig, configs)
+		if err := c.generateStatusUpdate(c.clusterName, addOnName)(ctx, metav1.Condition{
+			Type:   ClientCertificateRevokedCondition,
+			Status: metav1.ConditionTrue,
+			Reason: reason,
+			Message: fmt.Sprintf("The client certificate of signer %q in secret %s/%s is revoked",
+				cachedConfig.registration.SignerName, cachedConfig.getSecretNamespace(), cachedConfig.secretName),
+		}); err != nil {
+			errs = append(errs, err)
+		}
+		c.recorder.Eventf(reason, "The client certificate of addon %q in secret %s/%s is revoked",
+			addOnName, cachedConfig.getSecretNamespace(), cachedConfig.secretName)
 	}
 	if err := operatorhelpers.NewMultiLineAggregate(errs); err != nil {
 		return err
@@ -177,6 +264,10 @@ func (c *addOnRegistrationController) syncAddOn(ctx context.Context, syncCtx fac
 			syncedConfigs[hash] = cachedConfig
 			continue
 		}
+		if adoptedConfig, ok := adopted[hash]; ok {
+			syncedConfigs[hash] = adoptedConfig
+			continue
+		}
 
 		// start registration for the new added configs
 		config.stopFunc = c.startRegistrationFunc(ctx, config)
@@ -188,11 +279,111 @@ func (c *addOnRegistrationController) syncAddOn(ctx context.Context, syncCtx fac
 		return nil
 	}
 	c.addOnRegistrationConfigs[addOnName] = syncedConfigs
+
+	// the secrets are checked again on the resync, and once the client certificate controllers report the rotated
+	// certificates with the addon status
+	return c.reportRegistrationStatus(ctx, addOn, syncedConfigs)
+}
+
+// reportRegistrationStatus reports whether the registrations of the addon are applied, which means the secret of
+// each registration is created with the client certificate issued by its csr, and whether the kubeconfig in the
+// secrets drifts from the hub kubeconfig of the agent. The conditions are only updated when they are changed.
+func (c *addOnRegistrationController) reportRegistrationStatus(ctx context.Context,
+	addOn *addonv1alpha1.ManagedClusterAddOn, configs map[string]registrationConfig) error {
+	pending, drifted := []string{}, []string{}
+	for _, config := range configs {
+		secretKey := fmt.Sprintf("%s/%s", config.getSecretNamespace(), config.secretName)
+		secret, err := c.secretInformer(ctx, config).Lister().Secrets(config.getSecretNamespace()).Get(config.secretName)
+		switch {
+		case errors.IsNotFound(err):
+			pending = append(pending, secretKey)
+			continue
+		case err != nil:
+			return err
+		}
+
+		if len(secret.Data[clientcert.TLSCertFile]) == 0 || len(secret.Data[clientcert.TLSKeyFile]) == 0 {
+			pending = append(pending, secretKey)
+		}
+		if config.registration.SignerName == certificatesv1.KubeAPIServerClientSignerName &&
+			!bytes.Equal(secret.Data[clientcert.KubeconfigFile], c.kubeconfigData) {
+			drifted = append(drifted, secretKey)
+		}
+	}
+	sort.Strings(pending)
+	sort.Strings(drifted)
+
+	conditions := []metav1.Condition{}
+	if len(pending) == 0 {
+		conditions = append(conditions, metav1.Condition{
+			Type:    RegistrationAppliedCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "RegistrationApplied",
+			Message: "The client certificates of all registrations are issued",
+		})
+	} else {
+		conditions = append(conditions, metav1.Condition{
+			Type:   RegistrationAppliedCondition,
+			Status: metav1.ConditionFalse,
+			Reason: "RegistrationPending",
+			Message: fmt.Sprintf("The client certificates in secrets %s are not issued yet",
+				strings.Join(pending, ", ")),
+		})
+	}
+	// the revoked client certificates are replaced once the client certificates of all registrations are issued
+	if len(pending) == 0 && meta.IsStatusConditionTrue(addOn.Status.Conditions, ClientCertificateRevokedCondition) {
+		conditions = append(conditions, metav1.Condition{
+			Type:    ClientCertificateRevokedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ClientCertificateReissued",
+			Message: "The client certificates of all registrations are issued again",
+		})
+	}
+	if len(drifted) > 0 {
+		conditions = append(conditions, metav1.Condition{
+			Type:   RegistrationConfigDriftedCondition,
+			Status: metav1.ConditionTrue,
+			Reason: "KubeconfigDrifted",
+			Message: fmt.Sprintf("The kubeconfig in secrets %s is different from the hub kubeconfig of the agent",
+				strings.Join(drifted, ", ")),
+		})
+	} else if meta.FindStatusCondition(addOn.Status.Conditions, RegistrationConfigDriftedCondition) != nil {
+		conditions = append(conditions, metav1.Condition{
+			Type:    RegistrationConfigDriftedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "KubeconfigUpToDate",
+			Message: "The kubeconfig in the secrets of all registrations is up to date",
+		})
+	}
+
+	updateFuncs := []helpers.UpdateManagedClusterAddOnStatusFunc{}
+	for _, cond := range conditions {
+		existing := meta.FindStatusCondition(addOn.Status.Conditions, cond.Type)
+		if existing != nil && existing.Status == cond.Status && existing.Reason == cond.Reason &&
+			existing.Message == cond.Message {
+			continue
+		}
+		updateFuncs = append(updateFuncs, helpers.UpdateManagedClusterAddOnStatusFn(cond))
+	}
+	if len(updateFuncs) == 0 {
+		return nil
+	}
+
+	_, updated, err := helpers.UpdateManagedClusterAddOnStatus(ctx, c.addOnClient, c.clusterName, addOn.Name, updateFuncs...)
+	if err != nil {
+		return err
+	}
+	if updated && len(drifted) > 0 {
+		c.recorder.Warningf("RegistrationConfigDrifted", "The kubeconfig of addon %q in secrets %s is drifted",
+			addOn.Name, strings.Join(drifted, ", "))
+	}
 	return nil
 }
 
 // startRegistration starts a client certificate controller with the given config
 func (c *addOnRegistrationController) startRegistration(ctx context.Context, config registrationConfig) context.CancelFunc {
+	// the secret informer outlives the registration, it is shared by the registrations in the same namespace
+	secretInformer := c.secretInformer(ctx, config)
 	ctx, stopFunc := context.WithCancel(ctx)
 
 	// the kubeClient here will be used to generate the hub kubeconfig secret for addon agents, it generates the secret
@@ -205,9 +396,6 @@ func (c *addOnRegistrationController) startRegistration(ctx context.Context, con
 		kubeClient = c.managementKubeClient
 	}
 
-	kubeInformerFactory := informers.NewSharedInformerFactoryWithOptions(
-		kubeClient, 10*time.Minute, informers.WithNamespace(config.InstallationNamespace))
-
 	additonalSecretData := map[string][]byte{}
 	if config.registration.SignerName == certificatesv1.KubeAPIServerClientSignerName {
 		additonalSecretData[clientcert.KubeconfigFile] = c.kubeconfigData
@@ -215,10 +403,13 @@ func (c *addOnRegistrationController) startRegistration(ctx context.Context, con
 
 	// build and start a client cert controller
 	clientCertOption := clientcert.ClientCertOption{
-		SecretNamespace:               config.InstallationNamespace,
+		SecretNamespace:               config.getSecretNamespace(),
 		SecretName:                    config.secretName,
+		SecretLabels:                  map[string]string{registrationSecretOwnerLabel: config.addOnName},
 		AdditionalSecretData:          additonalSecretData,
 		AdditionalSecretDataSensitive: true,
+		RenewalThreshold:              c.renewalThreshold,
+		RenewalJitter:                 c.renewalJitter,
 	}
 
 	csrOption := clientcert.CSROption{
@@ -235,6 +426,7 @@ func (c *addOnRegistrationController) startRegistration(ctx context.Context, con
 		SignerName:      config.registration.SignerName,
 		EventFilterFunc: createCSREventFilterFunc(c.clusterName, config.addOnName, config.registration.SignerName),
 		HaltCSRCreation: c.haltCSRCreationFunc(config.addOnName),
+		KeyProvider:     c.keyProvider,
 	}
 
 	controllerName := fmt.Sprintf("ClientCertController@addon:%s:signer:%s", config.addOnName, config.registration.SignerName)
@@ -244,18 +436,77 @@ func (c *addOnRegistrationController) startRegistration(ctx context.Context, con
 	clientCertController := clientcert.NewClientCertificateController(
 		clientCertOption,
 		csrOption,
-		c.csrControl,
-		kubeInformerFactory.Core().V1().Secrets(),
+		c.registrationDriver,
+		secretInformer,
 		kubeClient.CoreV1(),
 		statusUpdater,
 		c.recorder,
 		controllerName,
 	)
 
-	go kubeInformerFactory.Start(ctx.Done())
 	go clientCertController.Run(ctx, 1)
 
-	return stopFunc
+	return func() {
+		stopFunc()
+		// the stopped controller is no longer reported by the health endpoints
+		health.DefaultRegistry.Remove(controllerName)
+	}
+}
+
+// secretInformer returns the informer of the secrets in the namespace of the registration, on the managed cluster or
+// on the management cluster if the addon agent runs outside the managed cluster. The informer is started and synced
+// once it is created, and it runs until the context is done.
+func (c *addOnRegistrationController) secretInformer(ctx context.Context, config registrationConfig) corev1informers.SecretInformer {
+	var kubeClient kubernetes.Interface = c.spokeKubeClient
+	key := fmt.Sprintf("managed/%s", config.getSecretNamespace())
+	if config.AgentRunningOutsideManagedCluster {
+		kubeClient = c.managementKubeClient
+		key = fmt.Sprintf("management/%s", config.getSecretNamespace())
+	}
+
+	if c.secretInformers == nil {
+		c.secretInformers = map[string]informers.SharedInformerFactory{}
+	}
+	informerFactory, ok := c.secretInformers[key]
+	if !ok {
+		informerFactory = informers.NewSharedInformerFactoryWithOptions(
+			kubeClient, 10*time.Minute, informers.WithNamespace(config.getSecretNamespace()))
+		informer := informerFactory.Core().V1().Secrets().Informer()
+		if c.queue != nil {
+			// report the registration status once the secrets of the registrations are changed
+			if _, err := informer.AddEventHandler(cache.FilteringResourceEventHandler{
+				FilterFunc: func(obj interface{}) bool {
+					accessor, err := meta.Accessor(obj)
+					return err == nil && len(accessor.GetLabels()[registrationSecretOwnerLabel]) > 0
+				},
+				Handler: cache.ResourceEventHandlerFuncs{
+					AddFunc:    c.enqueueSecretOwner,
+					UpdateFunc: func(_, obj interface{}) { c.enqueueSecretOwner(obj) },
+					DeleteFunc: c.enqueueSecretOwner,
+				},
+			}); err != nil {
+				utilruntime.HandleError(err)
+			}
+		}
+		informerFactory.Start(ctx.Done())
+		informerFactory.WaitForCacheSync(ctx.Done())
+		c.secretInformers[key] = informerFactory
+	}
+	return informerFactory.Core().V1().Secrets()
+}
+
+// enqueueSecretOwner enqueues the addon owning the secret of a registration
+func (c *addOnRegistrationController) enqueueSecretOwner(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	if addOnName := accessor.GetLabels()[registrationSecretOwnerLabel]; len(addOnName) > 0 {
+		c.queue.Add(addOnName)
+	}
 }
 
 func (c *addOnRegistrationController) haltCSRCreationFunc(addonName string) func() bool {
@@ -269,6 +520,27 @@ func (c *addOnRegistrationController) haltCSRCreationFunc(addonName string) func
 			return true
 		}
 
+		if c.maxConcurrentCSRs <= 0 {
+			return false
+		}
+
+		// halt the csr creation if there are too many pending csrs created by the agent for all addons
+		items, err = c.csrIndexer.ByIndex(indexByAddonCluster, c.clusterName)
+		if err != nil {
+			return false
+		}
+		pending := 0
+		for _, item := range items {
+			if isPendingCSR(item) {
+				pending++
+			}
+		}
+		if pending >= c.maxConcurrentCSRs {
+			klog.V(4).InfoS("Halt the csr creation of addon, there are too many pending addon csrs",
+				logging.ClusterKey, c.clusterName, logging.AddOnKey, addonName, "pending", pending)
+			return true
+		}
+
 		return false
 	}
 }
@@ -283,7 +555,8 @@ func (c *addOnRegistrationController) generateStatusUpdate(clusterName, addonNam
 	}
 }
 
-// stopRegistration stops the client certificate controller for the given config
+// stopRegistration stops the client certificate controller for the given config, and deletes its secret if it is
+// created by the agent for the addon
 func (c *addOnRegistrationController) stopRegistration(ctx context.Context, config registrationConfig) error {
 	if config.stopFunc != nil {
 		config.stopFunc()
@@ -295,8 +568,22 @@ func (c *addOnRegistrationController) stopRegistration(ctx context.Context, conf
 		kubeClient = c.managementKubeClient
 	}
 
-	err := kubeClient.CoreV1().Secrets(config.InstallationNamespace).
-		Delete(ctx, config.secretName, metav1.DeleteOptions{})
+	secret, err := kubeClient.CoreV1().Secrets(config.getSecretNamespace()).Get(ctx, config.secretName, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	if owner, ok := secret.Labels[registrationSecretOwnerLabel]; !ok || owner != config.addOnName {
+		klog.Warningf("The secret %s/%s of addon %q is not created by the agent, it is not deleted",
+			secret.Namespace, secret.Name, config.addOnName)
+		return nil
+	}
+
+	err = kubeClient.CoreV1().Secrets(secret.Namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{
+		Preconditions: metav1.NewUIDPreconditions(string(secret.UID)),
+	})
 	if err != nil && !errors.IsNotFound(err) {
 		return err
 	}
@@ -340,6 +627,51 @@ func indexByAddonFunc(obj interface{}) ([]string, error) {
 	return []string{fmt.Sprintf("%s/%s", cluster, addon)}, nil
 }
 
+// revokedReason returns the reason why the client certificate of the stale config is revoked
+func revokedReason(staleConfig registrationConfig, configs map[string]registrationConfig) string {
+	reason := "RegistrationRemoved"
+	for _, config := range configs {
+		if config.registration.SignerName != staleConfig.registration.SignerName {
+			continue
+		}
+		if config.signerCAHash != staleConfig.signerCAHash {
+			return "SignerCARotated"
+		}
+		reason = "RegistrationChanged"
+	}
+	return reason
+}
+
+func indexByAddonClusterFunc(obj interface{}) ([]string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster, ok := accessor.GetLabels()[clusterv1.ClusterNameLabelKey]
+	if !ok {
+		return []string{}, nil
+	}
+
+	// only index the addon csrs
+	if _, ok := accessor.GetLabels()[addonv1alpha1.AddonLabelKey]; !ok {
+		return []string{}, nil
+	}
+
+	return []string{cluster}, nil
+}
+
+// isPendingCSR returns true if the csr is neither approved nor denied
+func isPendingCSR(obj interface{}) bool {
+	switch csr := obj.(type) {
+	case *certificatesv1.CertificateSigningRequest:
+		return !helpers.IsCSRInTerminalState(&csr.Status)
+	case *certificatesv1beta1.CertificateSigningRequest:
+		return !helpers.Isv1beta1CSRInTerminalState(&csr.Status)
+	}
+	return false
+}
+
 func createCSREventFilterFunc(clusterName, addOnName, signerName string) factory.EventFilterFunc {
 	return func(obj interface{}) bool {
 		accessor, err := meta.Accessor(obj)
@@ -356,17 +688,20 @@ func createCSREventFilterFunc(clusterName, addOnName, signerName string) factory
 			return false
 		}
 
-		// only enqueue csr with a specific signer name
-		csr, ok := obj.(*certificatesv1.CertificateSigningRequest)
-		if !ok {
-			return false
-		}
-		if len(csr.Spec.SignerName) == 0 {
-			return false
+		// only enqueue csr with a specific signer name, the v1beta1 csrs are created if the hub does not serve
+		// the v1 csr api
+		var csrSignerName string
+		switch csr := obj.(type) {
+		case *certificatesv1.CertificateSigningRequest:
+			csrSignerName = csr.Spec.SignerName
+		case *certificatesv1beta1.CertificateSigningRequest:
+			if csr.Spec.SignerName != nil {
+				csrSignerName = *csr.Spec.SignerName
+			}
 		}
-		if csr.Spec.SignerName != signerName {
+		if len(csrSignerName) == 0 {
 			return false
 		}
-		return true
+		return csrSignerName == signerName
 	}
 }
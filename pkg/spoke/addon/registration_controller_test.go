@@ -8,15 +8,20 @@ import (
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	certificates "k8s.io/api/certificates/v1"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
 	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	"open-cluster-management.io/registration/pkg/helpers"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 )
 
@@ -27,7 +32,7 @@ func TestFilterCSREvents(t *testing.T) {
 
 	cases := []struct {
 		name     string
-		csr      *certificates.CertificateSigningRequest
+		csr      runtime.Object
 		expected bool
 	}{
 		{
@@ -58,6 +63,32 @@ func TestFilterCSREvents(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "valid v1beta1 csr",
+			csr: &certificatesv1beta1.CertificateSigningRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						clusterv1.ClusterNameLabelKey: clusterName,
+						addonv1alpha1.AddonLabelKey:   addonName,
+					},
+				},
+				Spec: certificatesv1beta1.CertificateSigningRequestSpec{
+					SignerName: &signerName,
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "v1beta1 csr without signer name",
+			csr: &certificatesv1beta1.CertificateSigningRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						clusterv1.ClusterNameLabelKey: clusterName,
+						addonv1alpha1.AddonLabelKey:   addonName,
+					},
+				},
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -118,9 +149,7 @@ func TestRegistrationSync(t *testing.T) {
 				addonName: {hash(config1, "", false)},
 			},
 			validateActions: func(t *testing.T, actions, managementActions []clienttesting.Action) {
-				if len(actions) != 0 {
-					t.Errorf("expect 0 actions but got %d", len(actions))
-				}
+				testinghelpers.AssertNoActions(t, actions)
 			},
 		},
 		{
@@ -142,10 +171,7 @@ func TestRegistrationSync(t *testing.T) {
 				addonName: {hash(config2, "", false)},
 			},
 			validateActions: func(t *testing.T, actions, managementActions []clienttesting.Action) {
-				if len(actions) != 1 {
-					t.Errorf("expect 1 actions but got %d", len(actions))
-				}
-				testinghelpers.AssertActions(t, actions, "delete")
+				testinghelpers.AssertActions(t, actions, "get", "delete")
 			},
 		},
 		{
@@ -167,10 +193,56 @@ func TestRegistrationSync(t *testing.T) {
 				addonName: {hash(config2, "ns1", false)},
 			},
 			validateActions: func(t *testing.T, actions, managementActions []clienttesting.Action) {
-				if len(actions) != 1 {
-					t.Errorf("expect 1 actions but got %d", len(actions))
-				}
-				testinghelpers.AssertActions(t, actions, "delete")
+				testinghelpers.AssertActions(t, actions, "get", "delete")
+			},
+		},
+		{
+			name:     "addon signer CA hash is recorded",
+			queueKey: addonName,
+			addOn: setAddonSignerCAHash(newManagedClusterAddOn(clusterName, addonName,
+				[]addonv1alpha1.RegistrationConfig{config2}, false), "ca2"),
+			addOnRegistrationConfigs: map[string]map[string]registrationConfig{
+				addonName: {
+					hash(config2, "", false): {
+						secretName: "secret1",
+						addonInstallOption: addonInstallOption{
+							InstallationNamespace: addonName,
+						},
+						hash:     hash(config2, "", false),
+						baseHash: hash(config2, "", false),
+					},
+				},
+			},
+			expectedAddOnRegistrationConfigHashs: map[string][]string{
+				addonName: {signerCAHash(config2, "ca2")},
+			},
+			validateActions: func(t *testing.T, actions, managementActions []clienttesting.Action) {
+				// the client certificate is not revoked
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:     "addon signer CA rotated",
+			queueKey: addonName,
+			addOn: setAddonSignerCAHash(newManagedClusterAddOn(clusterName, addonName,
+				[]addonv1alpha1.RegistrationConfig{config2}, false), "ca2"),
+			addOnRegistrationConfigs: map[string]map[string]registrationConfig{
+				addonName: {
+					signerCAHash(config2, "ca1"): {
+						secretName: "secret1",
+						addonInstallOption: addonInstallOption{
+							InstallationNamespace: addonName,
+						},
+						signerCAHash: "ca1",
+						baseHash:     hash(config2, "", false),
+					},
+				},
+			},
+			expectedAddOnRegistrationConfigHashs: map[string][]string{
+				addonName: {signerCAHash(config2, "ca2")},
+			},
+			validateActions: func(t *testing.T, actions, managementActions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "delete")
 			},
 		},
 		{
@@ -187,10 +259,7 @@ func TestRegistrationSync(t *testing.T) {
 				},
 			},
 			validateActions: func(t *testing.T, actions, managementActions []clienttesting.Action) {
-				if len(actions) != 1 {
-					t.Errorf("expect 1 actions but got %d", len(actions))
-				}
-				testinghelpers.AssertActions(t, actions, "delete")
+				testinghelpers.AssertActions(t, actions, "get", "delete")
 			},
 		},
 		{
@@ -205,9 +274,7 @@ func TestRegistrationSync(t *testing.T) {
 				if len(actions) != 0 {
 					t.Errorf("expect 0 actions but got %d", len(actions))
 				}
-				if len(managementActions) != 0 {
-					t.Errorf("expect 0 management actions but got %d", len(managementActions))
-				}
+				testinghelpers.AssertNoActions(t, managementActions)
 			},
 		},
 		{
@@ -234,10 +301,7 @@ func TestRegistrationSync(t *testing.T) {
 				if len(actions) != 0 {
 					t.Errorf("expect 0 actions but got %d", len(actions))
 				}
-				if len(managementActions) != 1 {
-					t.Errorf("expect 1 management actions but got %d", len(managementActions))
-				}
-				testinghelpers.AssertActions(t, managementActions, "delete")
+				testinghelpers.AssertActions(t, managementActions, "get", "delete")
 			},
 		},
 		{
@@ -260,13 +324,8 @@ func TestRegistrationSync(t *testing.T) {
 				addonName: {hash(config2, "", false)},
 			},
 			validateActions: func(t *testing.T, actions, managementActions []clienttesting.Action) {
-				if len(actions) != 0 {
-					t.Errorf("expect 0 actions but got %d", len(actions))
-				}
-				if len(managementActions) != 1 {
-					t.Errorf("expect 1 management actions but got %d", len(managementActions))
-				}
-				testinghelpers.AssertActions(t, managementActions, "delete")
+				testinghelpers.AssertNoActions(t, actions)
+				testinghelpers.AssertActions(t, managementActions, "get", "delete")
 			},
 		},
 		{
@@ -290,13 +349,8 @@ func TestRegistrationSync(t *testing.T) {
 				addonName: {hash(config2, "", true)},
 			},
 			validateActions: func(t *testing.T, actions, managementActions []clienttesting.Action) {
-				if len(managementActions) != 0 {
-					t.Errorf("expect 0 actions but got %d", len(managementActions))
-				}
-				if len(actions) != 1 {
-					t.Errorf("expect 1 management actions but got %d", len(actions))
-				}
-				testinghelpers.AssertActions(t, actions, "delete")
+				testinghelpers.AssertNoActions(t, managementActions)
+				testinghelpers.AssertActions(t, actions, "get", "delete")
 			},
 		},
 		{
@@ -314,10 +368,7 @@ func TestRegistrationSync(t *testing.T) {
 				},
 			},
 			validateActions: func(t *testing.T, actions, managementActions []clienttesting.Action) {
-				if len(managementActions) != 1 {
-					t.Errorf("expect 1 actions but got %d", len(managementActions))
-				}
-				testinghelpers.AssertActions(t, managementActions, "delete")
+				testinghelpers.AssertActions(t, managementActions, "get", "delete")
 			},
 		},
 		{
@@ -347,18 +398,28 @@ func TestRegistrationSync(t *testing.T) {
 				addonName: {hash(config1, "", false)},
 			},
 			validateActions: func(t *testing.T, actions, managementActions []clienttesting.Action) {
-				if len(actions) != 1 {
-					t.Errorf("expect 1 actions but got %d", len(actions))
-				}
-				testinghelpers.AssertActions(t, actions, "delete")
+				testinghelpers.AssertActions(t, actions, "get", "delete")
 			},
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			kubeClient := kubefake.NewSimpleClientset()
-			managementClient := kubefake.NewSimpleClientset()
+			// the secrets of the cached configs are created by the agent
+			secrets := []runtime.Object{}
+			for _, configs := range c.addOnRegistrationConfigs {
+				for _, config := range configs {
+					secrets = append(secrets, &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: config.getSecretNamespace(),
+							Name:      config.secretName,
+							Labels:    map[string]string{registrationSecretOwnerLabel: config.addOnName},
+						},
+					})
+				}
+			}
+			kubeClient := kubefake.NewSimpleClientset(secrets...)
+			managementClient := kubefake.NewSimpleClientset(secrets...)
 			addons := []runtime.Object{}
 			if c.addOn != nil {
 				addons = append(addons, c.addOn)
@@ -381,6 +442,7 @@ func TestRegistrationSync(t *testing.T) {
 				managementKubeClient: managementClient,
 				spokeKubeClient:      kubeClient,
 				hubAddOnLister:       addonInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				addOnClient:          addonClient,
 				recorder:             eventstesting.NewTestingEventRecorder(t),
 				startRegistrationFunc: func(ctx context.Context, config registrationConfig) context.CancelFunc {
 					_, cancel := context.WithCancel(context.Background())
@@ -419,12 +481,84 @@ func TestRegistrationSync(t *testing.T) {
 			}
 
 			if c.validateActions != nil {
-				c.validateActions(t, kubeClient.Actions(), managementClient.Actions())
+				c.validateActions(t, filterInformerActions(kubeClient.Actions()), filterInformerActions(managementClient.Actions()))
+			}
+		})
+	}
+}
+
+// filterInformerActions removes the list and watch actions of the secret informers
+func filterInformerActions(actions []clienttesting.Action) []clienttesting.Action {
+	filtered := []clienttesting.Action{}
+	for _, action := range actions {
+		if action.GetVerb() == "list" || action.GetVerb() == "watch" {
+			continue
+		}
+		filtered = append(filtered, action)
+	}
+	return filtered
+}
+
+func TestStopRegistration(t *testing.T) {
+	config := registrationConfig{
+		addOnName:          "addon1",
+		secretName:         "secret1",
+		addonInstallOption: addonInstallOption{InstallationNamespace: "ns1"},
+	}
+
+	cases := []struct {
+		name            string
+		secret          *corev1.Secret
+		expectedActions []string
+	}{
+		{
+			name:            "secret is not found",
+			expectedActions: []string{"get"},
+		},
+		{
+			name:            "secret is created by the agent",
+			secret:          newRegistrationSecret("addon1"),
+			expectedActions: []string{"get", "delete"},
+		},
+		{
+			name:            "secret is not created by the agent",
+			secret:          newRegistrationSecret(""),
+			expectedActions: []string{"get"},
+		},
+		{
+			name:            "secret is created for another addon",
+			secret:          newRegistrationSecret("addon2"),
+			expectedActions: []string{"get"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objects := []runtime.Object{}
+			if c.secret != nil {
+				objects = append(objects, c.secret)
 			}
+			kubeClient := kubefake.NewSimpleClientset(objects...)
+			controller := &addOnRegistrationController{spokeKubeClient: kubeClient}
+
+			if err := controller.stopRegistration(context.TODO(), config); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			testinghelpers.AssertActions(t, kubeClient.Actions(), c.expectedActions...)
 		})
 	}
 }
 
+func newRegistrationSecret(owner string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "secret1"},
+	}
+	if len(owner) > 0 {
+		secret.Labels = map[string]string{registrationSecretOwnerLabel: owner}
+	}
+	return secret
+}
+
 func newManagedClusterAddOn(namespace, name string, registrations []addonv1alpha1.RegistrationConfig,
 	hostedMode bool) *addonv1alpha1.ManagedClusterAddOn {
 	addon := &addonv1alpha1.ManagedClusterAddOn{
@@ -450,6 +584,20 @@ func setAddonInstallNamespace(
 	return addon
 }
 
+func setAddonSignerCAHash(
+	addon *addonv1alpha1.ManagedClusterAddOn,
+	caHash string) *addonv1alpha1.ManagedClusterAddOn {
+	addon.SetAnnotations(map[string]string{helpers.AddOnSignerCAHashAnnotation: caHash})
+	return addon
+}
+
+func signerCAHash(registration addonv1alpha1.RegistrationConfig, caHash string) string {
+	h, _ := getConfigHash(registration, addonInstallOption{
+		InstallationNamespace: defaultAddOnInstallationNamespace,
+	}, caHash, "")
+	return h
+}
+
 func hash(registration addonv1alpha1.RegistrationConfig, installNamespace string,
 	addOnAgentRunningOutsideManagedCluster bool) string {
 	if len(installNamespace) == 0 {
@@ -459,6 +607,297 @@ func hash(registration addonv1alpha1.RegistrationConfig, installNamespace string
 	h, _ := getConfigHash(registration, addonInstallOption{
 		InstallationNamespace:             installNamespace,
 		AgentRunningOutsideManagedCluster: addOnAgentRunningOutsideManagedCluster,
-	})
+	}, "", "")
 	return h
 }
+
+func TestHaltCSRCreation(t *testing.T) {
+	clusterName := "cluster1"
+
+	newAddOnCSR := func(name, addOnName string, approved bool) *certificates.CertificateSigningRequest {
+		holder := testinghelpers.CSRHolder{
+			Name: name,
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabelKey: clusterName,
+				addonv1alpha1.AddonLabelKey:   addOnName,
+			},
+		}
+		if approved {
+			return testinghelpers.NewApprovedCSR(holder)
+		}
+		return testinghelpers.NewCSR(holder)
+	}
+
+	cases := []struct {
+		name              string
+		csrs              []runtime.Object
+		maxConcurrentCSRs int
+		expectedHalt      bool
+	}{
+		{
+			name:         "no csr",
+			expectedHalt: false,
+		},
+		{
+			name: "no limit of concurrent csrs",
+			csrs: []runtime.Object{
+				newAddOnCSR("csr1", "addon2", false),
+				newAddOnCSR("csr2", "addon3", false),
+			},
+			expectedHalt: false,
+		},
+		{
+			name: "pending csrs are less than the limit",
+			csrs: []runtime.Object{
+				newAddOnCSR("csr1", "addon2", false),
+				newAddOnCSR("csr2", "addon3", true),
+			},
+			maxConcurrentCSRs: 2,
+			expectedHalt:      false,
+		},
+		{
+			name: "pending csrs reach the limit",
+			csrs: []runtime.Object{
+				newAddOnCSR("csr1", "addon2", false),
+				newAddOnCSR("csr2", "addon3", false),
+			},
+			maxConcurrentCSRs: 2,
+			expectedHalt:      true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+				indexByAddon:        indexByAddonFunc,
+				indexByAddonCluster: indexByAddonClusterFunc,
+			})
+			for _, csr := range c.csrs {
+				if err := indexer.Add(csr); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			controller := &addOnRegistrationController{
+				clusterName:       clusterName,
+				csrIndexer:        indexer,
+				maxConcurrentCSRs: c.maxConcurrentCSRs,
+			}
+			if actual := controller.haltCSRCreationFunc("addon1")(); actual != c.expectedHalt {
+				t.Errorf("expected halt %v, but got %v", c.expectedHalt, actual)
+			}
+		})
+	}
+}
+
+func TestRevokedReason(t *testing.T) {
+	newConfig := func(signerName, caHash string) registrationConfig {
+		return registrationConfig{
+			registration: addonv1alpha1.RegistrationConfig{SignerName: signerName},
+			signerCAHash: caHash,
+		}
+	}
+
+	cases := []struct {
+		name           string
+		staleConfig    registrationConfig
+		configs        map[string]registrationConfig
+		expectedReason string
+	}{
+		{
+			name:           "registration removed",
+			staleConfig:    newConfig("signer1", ""),
+			configs:        map[string]registrationConfig{"hash": newConfig("signer2", "")},
+			expectedReason: "RegistrationRemoved",
+		},
+		{
+			name:           "registration changed",
+			staleConfig:    newConfig("signer1", ""),
+			configs:        map[string]registrationConfig{"hash": newConfig("signer1", "")},
+			expectedReason: "RegistrationChanged",
+		},
+		{
+			name:           "signer CA rotated",
+			staleConfig:    newConfig("signer1", "ca1"),
+			configs:        map[string]registrationConfig{"hash": newConfig("signer1", "ca2")},
+			expectedReason: "SignerCARotated",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := revokedReason(c.staleConfig, c.configs); actual != c.expectedReason {
+				t.Errorf("expected reason %q, but got %q", c.expectedReason, actual)
+			}
+		})
+	}
+}
+
+func TestReportRegistrationStatus(t *testing.T) {
+	clusterName := "cluster1"
+	addonName := "addon1"
+	kubeconfigData := []byte("kubeconfig")
+	cert := &testinghelpers.TestCert{Cert: []byte("cert"), Key: []byte("key")}
+
+	newConfig := func(signerName string) registrationConfig {
+		return registrationConfig{
+			addOnName:          addonName,
+			secretName:         "secret1",
+			registration:       addonv1alpha1.RegistrationConfig{SignerName: signerName},
+			addonInstallOption: addonInstallOption{InstallationNamespace: addonName},
+		}
+	}
+
+	cases := []struct {
+		name               string
+		config             registrationConfig
+		existingConditions []metav1.Condition
+		secrets            []runtime.Object
+		expectedConditions map[string]metav1.ConditionStatus
+	}{
+		{
+			name:               "secret is not created",
+			config:             newConfig("signer1"),
+			expectedConditions: map[string]metav1.ConditionStatus{RegistrationAppliedCondition: metav1.ConditionFalse},
+		},
+		{
+			name:   "client certificate is not issued",
+			config: newConfig("signer1"),
+			secrets: []runtime.Object{
+				testinghelpers.NewHubKubeconfigSecret(addonName, "secret1", "1", nil, map[string][]byte{}),
+			},
+			expectedConditions: map[string]metav1.ConditionStatus{RegistrationAppliedCondition: metav1.ConditionFalse},
+		},
+		{
+			name:   "registration applied",
+			config: newConfig("signer1"),
+			secrets: []runtime.Object{
+				testinghelpers.NewHubKubeconfigSecret(addonName, "secret1", "1", cert, map[string][]byte{}),
+			},
+			expectedConditions: map[string]metav1.ConditionStatus{RegistrationAppliedCondition: metav1.ConditionTrue},
+		},
+		{
+			name:   "registration applied already",
+			config: newConfig("signer1"),
+			existingConditions: []metav1.Condition{{
+				Type:    RegistrationAppliedCondition,
+				Status:  metav1.ConditionTrue,
+				Reason:  "RegistrationApplied",
+				Message: "The client certificates of all registrations are issued",
+			}},
+			secrets: []runtime.Object{
+				testinghelpers.NewHubKubeconfigSecret(addonName, "secret1", "1", cert, map[string][]byte{}),
+			},
+		},
+		{
+			name:   "revoked client certificate is issued again",
+			config: newConfig("signer1"),
+			existingConditions: []metav1.Condition{
+				{
+					Type:    RegistrationAppliedCondition,
+					Status:  metav1.ConditionTrue,
+					Reason:  "RegistrationApplied",
+					Message: "The client certificates of all registrations are issued",
+				},
+				{
+					Type:   ClientCertificateRevokedCondition,
+					Status: metav1.ConditionTrue,
+					Reason: "SignerCARotated",
+				},
+			},
+			secrets: []runtime.Object{
+				testinghelpers.NewHubKubeconfigSecret(addonName, "secret1", "1", cert, map[string][]byte{}),
+			},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				ClientCertificateRevokedCondition: metav1.ConditionFalse,
+			},
+		},
+		{
+			name:   "revoked client certificate is not issued yet",
+			config: newConfig("signer1"),
+			existingConditions: []metav1.Condition{{
+				Type:   ClientCertificateRevokedCondition,
+				Status: metav1.ConditionTrue,
+				Reason: "SignerCARotated",
+			}},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				RegistrationAppliedCondition:      metav1.ConditionFalse,
+				ClientCertificateRevokedCondition: metav1.ConditionTrue,
+			},
+		},
+		{
+			name:   "kubeconfig drifted",
+			config: newConfig(certificates.KubeAPIServerClientSignerName),
+			secrets: []runtime.Object{
+				testinghelpers.NewHubKubeconfigSecret(addonName, "secret1", "1", cert, map[string][]byte{
+					"kubeconfig": []byte("modified"),
+				}),
+			},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				RegistrationAppliedCondition:       metav1.ConditionTrue,
+				RegistrationConfigDriftedCondition: metav1.ConditionTrue,
+			},
+		},
+		{
+			name:   "kubeconfig drift resolved",
+			config: newConfig(certificates.KubeAPIServerClientSignerName),
+			existingConditions: []metav1.Condition{
+				{
+					Type:    RegistrationAppliedCondition,
+					Status:  metav1.ConditionTrue,
+					Reason:  "RegistrationApplied",
+					Message: "The client certificates of all registrations are issued",
+				},
+				{
+					Type:   RegistrationConfigDriftedCondition,
+					Status: metav1.ConditionTrue,
+					Reason: "KubeconfigDrifted",
+				},
+			},
+			secrets: []runtime.Object{
+				testinghelpers.NewHubKubeconfigSecret(addonName, "secret1", "1", cert, map[string][]byte{
+					"kubeconfig": kubeconfigData,
+				}),
+			},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				RegistrationConfigDriftedCondition: metav1.ConditionFalse,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := newManagedClusterAddOn(clusterName, addonName, nil, false)
+			addOn.Status.Conditions = c.existingConditions
+			addonClient := addonfake.NewSimpleClientset(addOn)
+
+			controller := addOnRegistrationController{
+				clusterName:     clusterName,
+				kubeconfigData:  kubeconfigData,
+				spokeKubeClient: kubefake.NewSimpleClientset(c.secrets...),
+				addOnClient:     addonClient,
+				recorder:        eventstesting.NewTestingEventRecorder(t),
+			}
+
+			err := controller.reportRegistrationStatus(context.TODO(), addOn, map[string]registrationConfig{"hash": c.config})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if len(c.expectedConditions) == 0 {
+				testinghelpers.AssertNoActions(t, addonClient.Actions())
+				return
+			}
+			updated, err := addonClient.AddonV1alpha1().ManagedClusterAddOns(clusterName).Get(
+				context.TODO(), addonName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for condType, status := range c.expectedConditions {
+				cond := meta.FindStatusCondition(updated.Status.Conditions, condType)
+				if cond == nil || cond.Status != status {
+					t.Errorf("expected condition %s to be %s, but got %v", condType, status, cond)
+				}
+			}
+		})
+	}
+}
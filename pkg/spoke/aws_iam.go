@@ -0,0 +1,96 @@
+package spoke
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"open-cluster-management.io/registration/pkg/features"
+	"open-cluster-management.io/registration/pkg/transport"
+)
+
+// awsRoleARNEnv is the environment variable injected by the EKS pod identity webhook with the IAM role of the
+// service account of the agent
+const awsRoleARNEnv = "AWS_ROLE_ARN"
+
+// validateAWSIAMOptions verifies the options of the awsirsa registration driver
+func (o *SpokeAgentOptions) validateAWSIAMOptions() error {
+	if !features.DefaultSpokeMutableFeatureGate.Enabled(features.AWSIAMRegistrationDriver) {
+		return fmt.Errorf("registration driver %q requires the AWSIAMRegistrationDriver feature", o.RegistrationDriver)
+	}
+	if _, _, err := parseHubClusterARN(o.HubClusterARN); err != nil {
+		return err
+	}
+	if o.managedClusterIAMRoleARN() == "" {
+		return fmt.Errorf("managed cluster iam role arn is required by the awsirsa registration driver, "+
+			"it is read from the %s environment variable if it is not set", awsRoleARNEnv)
+	}
+	// the mqtt transport authenticates to the broker with the hub client certificate, which is not issued
+	if o.RegistrationTransport == transport.TransportMQTT {
+		return errors.New("registration transport \"mqtt\" is not supported by the awsirsa registration driver")
+	}
+	return nil
+}
+
+// managedClusterIAMRoleARN returns the IAM role the agent authenticates to the hub with
+func (o *SpokeAgentOptions) managedClusterIAMRoleARN() string {
+	if o.ManagedClusterIAMRoleARN != "" {
+		return o.ManagedClusterIAMRoleARN
+	}
+	return os.Getenv(awsRoleARNEnv)
+}
+
+// parseHubClusterARN returns the region and name of the EKS hub cluster from its ARN in the format of
+// arn:aws:eks:<region>:<account>:cluster/<name>
+func parseHubClusterARN(arn string) (string, string, error) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "eks" || len(parts[3]) == 0 ||
+		!strings.HasPrefix(parts[5], "cluster/") || len(strings.TrimPrefix(parts[5], "cluster/")) == 0 {
+		return "", "", fmt.Errorf("hub cluster arn %q is invalid, it must be in the format of "+
+			"arn:aws:eks:<region>:<account>:cluster/<name>", arn)
+	}
+	return parts[3], strings.TrimPrefix(parts[5], "cluster/"), nil
+}
+
+// buildAWSIAMKubeconfig builds the hub kubeconfig which authenticates to the EKS hub with the tokens of the IAM role
+// of the agent, the tokens are issued by the aws cli with the web identity token of the service account.
+func (o *SpokeAgentOptions) buildAWSIAMKubeconfig(bootstrapClientConfig *rest.Config) (clientcmdapi.Config, error) {
+	region, hubClusterName, err := parseHubClusterARN(o.HubClusterARN)
+	if err != nil {
+		return clientcmdapi.Config{}, err
+	}
+
+	return clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{"default-cluster": {
+			Server:                   bootstrapClientConfig.Host,
+			InsecureSkipTLSVerify:    false,
+			CertificateAuthorityData: bootstrapClientConfig.CAData,
+		}},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{"default-auth": {
+			Exec: &clientcmdapi.ExecConfig{
+				APIVersion: "client.authentication.k8s.io/v1beta1",
+				Command:    "aws",
+				Args: []string{
+					"--region", region,
+					"eks", "get-token",
+					"--cluster-name", hubClusterName,
+					"--output", "json",
+				},
+				Env: []clientcmdapi.ExecEnvVar{
+					{Name: awsRoleARNEnv, Value: o.managedClusterIAMRoleARN()},
+				},
+				InteractiveMode: clientcmdapi.NeverExecInteractiveMode,
+			},
+		}},
+		Contexts: map[string]*clientcmdapi.Context{"default-context": {
+			Cluster:   "default-cluster",
+			AuthInfo:  "default-auth",
+			Namespace: "configuration",
+		}},
+		CurrentContext: "default-context",
+	}, nil
+}
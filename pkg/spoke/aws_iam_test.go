@@ -0,0 +1,121 @@
+package spoke
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/rest"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/features"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+const (
+	testHubClusterARN = "arn:aws:eks:us-west-2:123456789012:cluster/hub"
+	testIAMRoleARN    = "arn:aws:iam::123456789012:role/ocm-testcluster-agent"
+)
+
+func TestValidateAWSIAMOptions(t *testing.T) {
+	if err := features.DefaultSpokeMutableFeatureGate.Set(fmt.Sprintf("%s=true", string(features.AWSIAMRegistrationDriver))); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := features.DefaultSpokeMutableFeatureGate.Set(fmt.Sprintf("%s=false", string(features.AWSIAMRegistrationDriver))); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	t.Setenv(awsRoleARNEnv, "")
+
+	cases := []struct {
+		name        string
+		options     *SpokeAgentOptions
+		expectedErr string
+	}{
+		{
+			name: "valid options",
+			options: &SpokeAgentOptions{
+				RegistrationDriver:       clientcert.RegistrationDriverAWSIRSA,
+				HubClusterARN:            testHubClusterARN,
+				ManagedClusterIAMRoleARN: testIAMRoleARN,
+			},
+		},
+		{
+			name: "invalid hub cluster arn",
+			options: &SpokeAgentOptions{
+				RegistrationDriver:       clientcert.RegistrationDriverAWSIRSA,
+				HubClusterARN:            "arn:aws:eks:us-west-2:123456789012:nodegroup/hub",
+				ManagedClusterIAMRoleARN: testIAMRoleARN,
+			},
+			expectedErr: "hub cluster arn \"arn:aws:eks:us-west-2:123456789012:nodegroup/hub\" is invalid, it must be " +
+				"in the format of arn:aws:eks:<region>:<account>:cluster/<name>",
+		},
+		{
+			name: "no iam role",
+			options: &SpokeAgentOptions{
+				RegistrationDriver: clientcert.RegistrationDriverAWSIRSA,
+				HubClusterARN:      testHubClusterARN,
+			},
+			expectedErr: "managed cluster iam role arn is required by the awsirsa registration driver, it is read from " +
+				"the AWS_ROLE_ARN environment variable if it is not set",
+		},
+		{
+			name: "mqtt transport",
+			options: &SpokeAgentOptions{
+				RegistrationDriver:       clientcert.RegistrationDriverAWSIRSA,
+				HubClusterARN:            testHubClusterARN,
+				ManagedClusterIAMRoleARN: testIAMRoleARN,
+				RegistrationTransport:    "mqtt",
+			},
+			expectedErr: "registration transport \"mqtt\" is not supported by the awsirsa registration driver",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testinghelpers.AssertError(t, c.options.validateRegistrationDriverOptions(), c.expectedErr)
+		})
+	}
+}
+
+func TestManagedClusterIAMRoleARN(t *testing.T) {
+	t.Setenv(awsRoleARNEnv, "arn:aws:iam::123456789012:role/irsa")
+
+	options := &SpokeAgentOptions{}
+	if role := options.managedClusterIAMRoleARN(); role != "arn:aws:iam::123456789012:role/irsa" {
+		t.Errorf("expected the role of the environment variable, but got %q", role)
+	}
+
+	options.ManagedClusterIAMRoleARN = testIAMRoleARN
+	if role := options.managedClusterIAMRoleARN(); role != testIAMRoleARN {
+		t.Errorf("expected the role of the option, but got %q", role)
+	}
+}
+
+func TestBuildAWSIAMKubeconfig(t *testing.T) {
+	options := &SpokeAgentOptions{
+		HubClusterARN:            testHubClusterARN,
+		ManagedClusterIAMRoleARN: testIAMRoleARN,
+	}
+	kubeconfig, err := options.buildAWSIAMKubeconfig(&rest.Config{
+		Host:            "https://hub.example.com",
+		TLSClientConfig: rest.TLSClientConfig{CAData: []byte("ca")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cluster := kubeconfig.Clusters["default-cluster"]
+	if cluster.Server != "https://hub.example.com" || string(cluster.CertificateAuthorityData) != "ca" {
+		t.Errorf("unexpected cluster %v", cluster)
+	}
+	exec := kubeconfig.AuthInfos["default-auth"].Exec
+	expectedArgs := []string{"--region", "us-west-2", "eks", "get-token", "--cluster-name", "hub", "--output", "json"}
+	if exec.Command != "aws" || !reflect.DeepEqual(exec.Args, expectedArgs) {
+		t.Errorf("unexpected exec config %v", exec)
+	}
+	if len(exec.Env) != 1 || exec.Env[0].Value != testIAMRoleARN {
+		t.Errorf("expected the role %q in the exec env, but got %v", testIAMRoleARN, exec.Env)
+	}
+}
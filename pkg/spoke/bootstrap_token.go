@@ -0,0 +1,138 @@
+package spoke
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	certutil "k8s.io/client-go/util/cert"
+
+	"open-cluster-management.io/registration/pkg/helpers"
+)
+
+const (
+	// clusterInfoNamespace and clusterInfoName are the namespace and name of the public ConfigMap on the hub
+	// which contains the kubeconfig with the CA bundle of the hub, it is published by kubeadm-style clusters.
+	clusterInfoNamespace = "kube-public"
+	clusterInfoName      = "cluster-info"
+	clusterInfoKey       = "kubeconfig"
+
+	// caCertHashPrefix is the prefix of a CA cert hash, the only supported hash format is
+	// "sha256:<hex encoded SHA-256 hash of the Subject Public Key Info of the CA cert>"
+	caCertHashPrefix = "sha256:"
+)
+
+var (
+	bootstrapTokenRegexp = regexp.MustCompile(`^[a-z0-9]{6}\.[a-z0-9]{16}$`)
+	caCertHashRegexp     = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// validateBootstrapTokenOptions verifies the inputs of the bootstrap token flow
+func (o *SpokeAgentOptions) validateBootstrapTokenOptions() error {
+	if !bootstrapTokenRegexp.MatchString(o.BootstrapToken) {
+		return fmt.Errorf("bootstrap token is invalid, it must be in the format of [a-z0-9]{6}.[a-z0-9]{16}")
+	}
+	if o.HubAPIServer == "" {
+		return fmt.Errorf("hub-apiserver is required by the bootstrap token")
+	}
+	if !helpers.IsValidHTTPSURL(o.HubAPIServer) {
+		return fmt.Errorf("hub-apiserver %q is invalid", o.HubAPIServer)
+	}
+	if len(o.HubCACertHashes) == 0 {
+		return fmt.Errorf("hub-ca-cert-hash is required by the bootstrap token")
+	}
+	for _, hash := range o.HubCACertHashes {
+		if !caCertHashRegexp.MatchString(strings.ToLower(hash)) {
+			return fmt.Errorf("hub CA cert hash %q is invalid, it must be in the format of sha256:<hex>", hash)
+		}
+	}
+	return nil
+}
+
+// bootstrapClientConfig returns the client config to bootstrap the agent. It is loaded from the bootstrap
+// kubeconfig file, or built from the bootstrap token if the bootstrap kubeconfig is not specified.
+func (o *SpokeAgentOptions) bootstrapClientConfig(ctx context.Context) (*rest.Config, error) {
+	if o.BootstrapKubeconfig != "" {
+		config, err := clientcmd.BuildConfigFromFlags("", o.BootstrapKubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load bootstrap kubeconfig from file %q: %w", o.BootstrapKubeconfig, err)
+		}
+		return config, nil
+	}
+
+	// the hub CA bundle is not known yet, so the public cluster info is fetched without verifying the hub
+	// serving certificate, the CA bundle in it is trusted only if it matches the pinned CA cert hashes.
+	insecureClient, err := kubernetes.NewForConfig(&rest.Config{
+		Host:            o.HubAPIServer,
+		TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	caData, err := getHubCABundle(ctx, insecureClient, o.HubCACertHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rest.Config{
+		Host:            o.HubAPIServer,
+		BearerToken:     o.BootstrapToken,
+		TLSClientConfig: rest.TLSClientConfig{CAData: caData},
+	}, nil
+}
+
+// getHubCABundle reads the hub CA bundle from the public cluster info on the hub, and verifies it with the
+// pinned CA cert hashes.
+func getHubCABundle(ctx context.Context, client kubernetes.Interface, caCertHashes []string) ([]byte, error) {
+	clusterInfo, err := client.CoreV1().ConfigMaps(clusterInfoNamespace).Get(ctx, clusterInfoName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the cluster info from hub: %w", err)
+	}
+
+	kubeconfig, err := clientcmd.Load([]byte(clusterInfo.Data[clusterInfoKey]))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the kubeconfig in the cluster info: %w", err)
+	}
+
+	var caData []byte
+	for _, cluster := range kubeconfig.Clusters {
+		if len(cluster.CertificateAuthorityData) > 0 {
+			caData = cluster.CertificateAuthorityData
+			break
+		}
+	}
+	if len(caData) == 0 {
+		return nil, fmt.Errorf("no CA bundle is found in the cluster info")
+	}
+
+	certs, err := certutil.ParseCertsPEM(caData)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the CA bundle in the cluster info: %w", err)
+	}
+	for _, cert := range certs {
+		if caCertHashMatched(cert, caCertHashes) {
+			return caData, nil
+		}
+	}
+	return nil, fmt.Errorf("the CA bundle in the cluster info does not match any of the hub CA cert hashes")
+}
+
+// caCertHashMatched returns true if the hash of the Subject Public Key Info of the cert is one of the hashes
+func caCertHashMatched(cert *x509.Certificate, caCertHashes []string) bool {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	hash := caCertHashPrefix + hex.EncodeToString(sum[:])
+	for _, expected := range caCertHashes {
+		if strings.ToLower(expected) == hash {
+			return true
+		}
+	}
+	return false
+}
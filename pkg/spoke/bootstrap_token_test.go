@@ -0,0 +1,163 @@
+package spoke
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	certutil "k8s.io/client-go/util/cert"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func newClusterInfo(t *testing.T, caData []byte) *corev1.ConfigMap {
+	kubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{"": {
+			Server:                   "https://hub.example.com:6443",
+			CertificateAuthorityData: caData,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: clusterInfoNamespace,
+			Name:      clusterInfoName,
+		},
+		Data: map[string]string{
+			clusterInfoKey: string(kubeconfig),
+		},
+	}
+}
+
+func TestGetHubCABundle(t *testing.T) {
+	caData := testinghelpers.NewTestCert("hub-ca", time.Hour).Cert
+	certs, err := certutil.ParseCertsPEM(caData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(certs[0].RawSubjectPublicKeyInfo)
+	caHash := "sha256:" + hex.EncodeToString(sum[:])
+
+	cases := []struct {
+		name         string
+		objects      []runtime.Object
+		caCertHashes []string
+		expectedErr  string
+	}{
+		{
+			name:         "cluster info not found",
+			caCertHashes: []string{caHash},
+			expectedErr:  "unable to get the cluster info from hub: configmaps \"cluster-info\" not found",
+		},
+		{
+			name:         "no CA bundle",
+			objects:      []runtime.Object{newClusterInfo(t, nil)},
+			caCertHashes: []string{caHash},
+			expectedErr:  "no CA bundle is found in the cluster info",
+		},
+		{
+			name:         "CA hash mismatched",
+			objects:      []runtime.Object{newClusterInfo(t, caData)},
+			caCertHashes: []string{"sha256:0000000000000000000000000000000000000000000000000000000000000000"},
+			expectedErr:  "the CA bundle in the cluster info does not match any of the hub CA cert hashes",
+		},
+		{
+			name:         "CA hash matched",
+			objects:      []runtime.Object{newClusterInfo(t, caData)},
+			caCertHashes: []string{"sha256:0000000000000000000000000000000000000000000000000000000000000000", caHash},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(c.objects...)
+			actual, err := getHubCABundle(context.TODO(), kubeClient, c.caCertHashes)
+			testinghelpers.AssertError(t, err, c.expectedErr)
+			if err == nil && string(actual) != string(caData) {
+				t.Errorf("expected CA bundle %q, but got %q", string(caData), string(actual))
+			}
+		})
+	}
+}
+
+func TestValidateBootstrapTokenOptions(t *testing.T) {
+	validHash := "sha256:" + hex.EncodeToString(make([]byte, 32))
+	cases := []struct {
+		name        string
+		options     *SpokeAgentOptions
+		expectedErr string
+	}{
+		{
+			name: "both bootstrap kubeconfig and token",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig: "/spoke/bootstrap/kubeconfig",
+				BootstrapToken:      "abcdef.0123456789abcdef",
+			},
+			expectedErr: "bootstrap-kubeconfig and bootstrap-token cannot be specified at the same time",
+		},
+		{
+			name:        "invalid token",
+			options:     &SpokeAgentOptions{BootstrapToken: "abcdef"},
+			expectedErr: "bootstrap token is invalid, it must be in the format of [a-z0-9]{6}.[a-z0-9]{16}",
+		},
+		{
+			name:        "no hub apiserver",
+			options:     &SpokeAgentOptions{BootstrapToken: "abcdef.0123456789abcdef"},
+			expectedErr: "hub-apiserver is required by the bootstrap token",
+		},
+		{
+			name: "invalid hub apiserver",
+			options: &SpokeAgentOptions{
+				BootstrapToken: "abcdef.0123456789abcdef",
+				HubAPIServer:   "http://hub.example.com",
+			},
+			expectedErr: "hub-apiserver \"http://hub.example.com\" is invalid",
+		},
+		{
+			name: "no CA hash",
+			options: &SpokeAgentOptions{
+				BootstrapToken: "abcdef.0123456789abcdef",
+				HubAPIServer:   "https://hub.example.com",
+			},
+			expectedErr: "hub-ca-cert-hash is required by the bootstrap token",
+		},
+		{
+			name: "invalid CA hash",
+			options: &SpokeAgentOptions{
+				BootstrapToken:  "abcdef.0123456789abcdef",
+				HubAPIServer:    "https://hub.example.com",
+				HubCACertHashes: []string{"md5:abc"},
+			},
+			expectedErr: "hub CA cert hash \"md5:abc\" is invalid, it must be in the format of sha256:<hex>",
+		},
+		{
+			name: "valid token options",
+			options: &SpokeAgentOptions{
+				BootstrapToken:             "abcdef.0123456789abcdef",
+				HubAPIServer:               "https://hub.example.com",
+				HubCACertHashes:            []string{validHash},
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testinghelpers.AssertError(t, c.options.Validate(), c.expectedErr)
+		})
+	}
+}
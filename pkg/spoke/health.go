@@ -0,0 +1,39 @@
+package spoke
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/registration/pkg/health"
+)
+
+// bootstrapHealthComponent is the name of the bootstrap in the health endpoints, it succeeds once the hub
+// kubeconfig is valid
+const bootstrapHealthComponent = "Bootstrap"
+
+// serveHealthProbes serves the /healthz and /readyz endpoints with the states of the controllers until the
+// context is done
+func (o *SpokeAgentOptions) serveHealthProbes(ctx context.Context) {
+	server := &http.Server{
+		Addr:              o.HealthProbeBindAddress,
+		Handler:           health.NewHandler(health.DefaultRegistry, o.HealthProbeFailureThreshold),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			klog.Warningf("Failed to shutdown the health probe server: %v", err)
+		}
+	}()
+
+	klog.Infof("Serving the health probes on %q", o.HealthProbeBindAddress)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		klog.Errorf("Failed to serve the health probes: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package spoke
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/fileobserver"
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+)
+
+// hubKubeconfigObserveInterval is the interval to check the changes of the hub kubeconfig files
+var hubKubeconfigObserveInterval = 10 * time.Second
+
+// watchHubKubeconfig observes the hub kubeconfig file and the client certificate files in the hub kubeconfig dir
+// if the hub kubeconfig is reloaded in-process. The files are dumped from the hub kubeconfig secret, so they are
+// changed once the client certificate is rotated, or the secret is updated by the CA bundle or bootstrap flows. The
+// returned context is cancelled once any of the files is changed, so the hub clients are rebuilt and the controllers
+// talking to the hub are restarted without restarting the agent.
+func (o *SpokeAgentOptions) watchHubKubeconfig(ctx context.Context) (context.Context, error) {
+	if !o.ReloadHubKubeconfig {
+		return ctx, nil
+	}
+
+	observer, err := fileobserver.NewObserver(hubKubeconfigObserveInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	files := hubKubeconfigFiles(o.HubKubeconfigDir)
+	startingContent := map[string][]byte{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(path.Clean(file))
+		if os.IsNotExist(err) {
+			// there is no client certificate file with the token drivers, the observer reacts to its creation
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		startingContent[file] = data
+	}
+
+	watchCtx, reload := context.WithCancel(ctx)
+	observer.AddReactor(func(filename string, action fileobserver.ActionType) error {
+		klog.Infof("Reloading the hub kubeconfig because the file %q is changed", filename)
+		reload()
+		return nil
+	}, startingContent, files...)
+
+	go observer.Run(watchCtx.Done())
+	return watchCtx, nil
+}
+
+// hubKubeconfigFiles returns the hub kubeconfig file and the client certificate and key files referenced by it
+func hubKubeconfigFiles(hubKubeconfigDir string) []string {
+	return []string{
+		path.Join(hubKubeconfigDir, clientcert.KubeconfigFile),
+		path.Join(hubKubeconfigDir, clientcert.TLSCertFile),
+		path.Join(hubKubeconfigDir, clientcert.TLSKeyFile),
+	}
+}
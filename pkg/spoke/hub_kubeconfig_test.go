@@ -0,0 +1,60 @@
+package spoke
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestWatchHubKubeconfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hub-kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, file := range []string{"kubeconfig", "tls.crt", "tls.key"} {
+		if err := ioutil.WriteFile(path.Join(dir, file), []byte(file), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	interval := hubKubeconfigObserveInterval
+	hubKubeconfigObserveInterval = 100 * time.Millisecond
+	defer func() { hubKubeconfigObserveInterval = interval }()
+
+	// the hub kubeconfig is not watched unless it is reloaded in-process
+	o := &SpokeAgentOptions{HubKubeconfigDir: dir}
+	ctx, err := o.watchHubKubeconfig(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx != context.TODO() {
+		t.Errorf("expected the context is not changed")
+	}
+
+	o.ReloadHubKubeconfig = true
+	ctx, err = o.watchHubKubeconfig(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the hub controllers keep running if the files are not changed
+	select {
+	case <-ctx.Done():
+		t.Fatalf("unexpected reload")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	if err := ioutil.WriteFile(path.Join(dir, "tls.crt"), []byte("rotated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected the context to be cancelled after the client certificate is rotated")
+	}
+}
@@ -0,0 +1,167 @@
+package managedcluster
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// ProbeTypeReadyz checks an individual check of the kube-apiserver readyz endpoint, e.g. etcd
+	ProbeTypeReadyz = "readyz"
+	// ProbeTypePath checks the response of a path of the kube-apiserver is 200
+	ProbeTypePath = "path"
+	// ProbeTypeAPIGroup checks a group version is served, e.g. metrics.k8s.io/v1beta1 of the metrics-server
+	ProbeTypeAPIGroup = "apigroup"
+	// ProbeTypeResource checks a resource is served in a group version, e.g. the resource of a CRD
+	ProbeTypeResource = "resource"
+)
+
+// ProbeTypes are the supported types of the availability probes
+var ProbeTypes = []string{ProbeTypeReadyz, ProbeTypePath, ProbeTypeAPIGroup, ProbeTypeResource}
+
+// AvailabilityProbe checks the availability of a critical API of the managed cluster, the result is reported with
+// the condition of the probe on the managed cluster, so the partial degradation of the cluster is visible on the hub.
+type AvailabilityProbe struct {
+	Name   string
+	Type   string
+	Target string
+}
+
+// ParseAvailabilityProbe parses a probe in the format of <name>=<type>:<target>, e.g. etcd=readyz:etcd,
+// metrics=apigroup:metrics.k8s.io/v1beta1 or works=resource:work.open-cluster-management.io/v1/appliedmanifestworks.
+func ParseAvailabilityProbe(value string) (AvailabilityProbe, error) {
+	name, spec, ok := strings.Cut(value, "=")
+	if !ok {
+		return AvailabilityProbe{}, fmt.Errorf("availability probe %q must be in the format of <name>=<type>:<target>", value)
+	}
+	probeType, target, ok := strings.Cut(spec, ":")
+	if !ok || len(target) == 0 {
+		return AvailabilityProbe{}, fmt.Errorf("availability probe %q must be in the format of <name>=<type>:<target>", value)
+	}
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return AvailabilityProbe{}, fmt.Errorf("the name of availability probe %q is invalid: %s", value, strings.Join(errs, ", "))
+	}
+
+	probe := AvailabilityProbe{Name: name, Type: probeType, Target: target}
+	switch probeType {
+	case ProbeTypeReadyz:
+	case ProbeTypePath:
+		if !strings.HasPrefix(target, "/") {
+			return AvailabilityProbe{}, fmt.Errorf("the path of availability probe %q must be absolute", value)
+		}
+	case ProbeTypeAPIGroup:
+		if len(strings.Split(target, "/")) != 2 {
+			return AvailabilityProbe{}, fmt.Errorf("the target of availability probe %q must be in the format of <group>/<version>", value)
+		}
+	case ProbeTypeResource:
+		if len(strings.Split(target, "/")) != 3 {
+			return AvailabilityProbe{}, fmt.Errorf("the target of availability probe %q must be in the format of <group>/<version>/<resource>", value)
+		}
+	default:
+		return AvailabilityProbe{}, fmt.Errorf("the type of availability probe %q must be one of %v", value, ProbeTypes)
+	}
+	return probe, nil
+}
+
+// ConditionType returns the type of the condition which reports the result of the probe
+func (p AvailabilityProbe) ConditionType() string {
+	return helpers.ManagedClusterConditionProbePrefix + p.Name
+}
+
+// probe runs the availability probe against the kube-apiserver of the managed cluster
+func (c *managedClusterStatusController) probe(ctx context.Context, probe AvailabilityProbe) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    probe.ConditionType(),
+		Status:  metav1.ConditionTrue,
+		Reason:  "ProbeSucceeded",
+		Message: fmt.Sprintf("The %s probe of %q succeeded", probe.Type, probe.Target),
+	}
+
+	var err error
+	switch probe.Type {
+	case ProbeTypeReadyz:
+		err = c.probePath(ctx, "/readyz/"+probe.Target)
+	case ProbeTypePath:
+		err = c.probePath(ctx, probe.Target)
+	case ProbeTypeAPIGroup:
+		_, err = c.managedClusterDiscoveryClient.ServerResourcesForGroupVersion(probe.Target)
+	case ProbeTypeResource:
+		err = c.probeResource(probe.Target)
+	default:
+		err = fmt.Errorf("unsupported probe type %q", probe.Type)
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ProbeFailed"
+		condition.Message = fmt.Sprintf("The %s probe of %q failed: %v", probe.Type, probe.Target, err)
+	}
+	return condition
+}
+
+func (c *managedClusterStatusController) probePath(ctx context.Context, path string) error {
+	statusCode := 0
+	result := c.managedClusterDiscoveryClient.RESTClient().Get().AbsPath(path).Do(ctx).StatusCode(&statusCode)
+	if statusCode == http.StatusOK {
+		return nil
+	}
+	if body, err := result.Raw(); err == nil {
+		return fmt.Errorf("status code: %d, %s", statusCode, string(body))
+	}
+	return fmt.Errorf("status code: %d, %v", statusCode, result.Error())
+}
+
+func (c *managedClusterStatusController) probeResource(target string) error {
+	index := strings.LastIndex(target, "/")
+	groupVersion, resource := target[:index], target[index+1:]
+	resources, err := c.managedClusterDiscoveryClient.ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return err
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == resource {
+			return nil
+		}
+	}
+	return fmt.Errorf("resource %q is not served in %q", resource, groupVersion)
+}
+
+// unknownProbeConditions returns the conditions of the probes when the kube-apiserver is unavailable
+func unknownProbeConditions(probes []AvailabilityProbe) []metav1.Condition {
+	conditions := []metav1.Condition{}
+	for _, probe := range probes {
+		conditions = append(conditions, metav1.Condition{
+			Type:    probe.ConditionType(),
+			Status:  metav1.ConditionUnknown,
+			Reason:  "ManagedClusterKubeAPIServerUnavailable",
+			Message: "The probe is not run because the kube-apiserver is unavailable",
+		})
+	}
+	return conditions
+}
+
+// removeStaleProbeConditionsFn removes the conditions of the probes which are no longer configured
+func removeStaleProbeConditionsFn(probes []AvailabilityProbe) helpers.UpdateManagedClusterStatusFunc {
+	return func(oldStatus *clusterv1.ManagedClusterStatus) error {
+		configured := map[string]bool{}
+		for _, probe := range probes {
+			configured[probe.ConditionType()] = true
+		}
+		conditions := []metav1.Condition{}
+		for _, condition := range oldStatus.Conditions {
+			if strings.HasPrefix(condition.Type, helpers.ManagedClusterConditionProbePrefix) && !configured[condition.Type] {
+				continue
+			}
+			conditions = append(conditions, condition)
+		}
+		oldStatus.Conditions = conditions
+		return nil
+	}
+}
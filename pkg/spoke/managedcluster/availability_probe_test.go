@@ -0,0 +1,170 @@
+package managedcluster
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	discovery "k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+func TestParseAvailabilityProbe(t *testing.T) {
+	cases := []struct {
+		name          string
+		value         string
+		expectedProbe AvailabilityProbe
+		expectedErr   string
+	}{
+		{
+			name:          "readyz",
+			value:         "etcd=readyz:etcd",
+			expectedProbe: AvailabilityProbe{Name: "etcd", Type: ProbeTypeReadyz, Target: "etcd"},
+		},
+		{
+			name:          "resource",
+			value:         "works=resource:work.open-cluster-management.io/v1/appliedmanifestworks",
+			expectedProbe: AvailabilityProbe{Name: "works", Type: ProbeTypeResource, Target: "work.open-cluster-management.io/v1/appliedmanifestworks"},
+		},
+		{
+			name:        "no name",
+			value:       "readyz:etcd",
+			expectedErr: "availability probe \"readyz:etcd\" must be in the format of <name>=<type>:<target>",
+		},
+		{
+			name:        "invalid name",
+			value:       "Etcd=readyz:etcd",
+			expectedErr: "the name of availability probe \"Etcd=readyz:etcd\" is invalid: a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')",
+		},
+		{
+			name:        "relative path",
+			value:       "livez=path:livez",
+			expectedErr: "the path of availability probe \"livez=path:livez\" must be absolute",
+		},
+		{
+			name:        "invalid api group",
+			value:       "metrics=apigroup:metrics.k8s.io",
+			expectedErr: "the target of availability probe \"metrics=apigroup:metrics.k8s.io\" must be in the format of <group>/<version>",
+		},
+		{
+			name:        "unsupported type",
+			value:       "crd=crd:foos.example.com",
+			expectedErr: "the type of availability probe \"crd=crd:foos.example.com\" must be one of [readyz path apigroup resource]",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			probe, err := ParseAvailabilityProbe(c.value)
+			testinghelpers.AssertError(t, err, c.expectedErr)
+			if probe != c.expectedProbe {
+				t.Errorf("expected probe %v, but got %v", c.expectedProbe, probe)
+			}
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/readyz/etcd":
+			w.WriteHeader(http.StatusOK)
+		case "/apis/metrics.k8s.io/v1beta1":
+			output, err := json.Marshal(metav1.APIResourceList{
+				GroupVersion: "metrics.k8s.io/v1beta1",
+				APIResources: []metav1.APIResource{{Name: "nodes", Kind: "NodeMetrics"}},
+			})
+			if err != nil {
+				t.Errorf("unexpected encoding error: %v", err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write(output); err != nil {
+				t.Fatal(err)
+			}
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer apiServer.Close()
+
+	ctrl := &managedClusterStatusController{
+		managedClusterDiscoveryClient: discovery.NewDiscoveryClientForConfigOrDie(&rest.Config{Host: apiServer.URL}),
+	}
+
+	cases := []struct {
+		name           string
+		probe          AvailabilityProbe
+		expectedStatus metav1.ConditionStatus
+	}{
+		{
+			name:           "readyz check succeeded",
+			probe:          AvailabilityProbe{Name: "etcd", Type: ProbeTypeReadyz, Target: "etcd"},
+			expectedStatus: metav1.ConditionTrue,
+		},
+		{
+			name:           "readyz check failed",
+			probe:          AvailabilityProbe{Name: "informer-sync", Type: ProbeTypeReadyz, Target: "informer-sync"},
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "api group is served",
+			probe:          AvailabilityProbe{Name: "metrics", Type: ProbeTypeAPIGroup, Target: "metrics.k8s.io/v1beta1"},
+			expectedStatus: metav1.ConditionTrue,
+		},
+		{
+			name:           "resource is served",
+			probe:          AvailabilityProbe{Name: "node-metrics", Type: ProbeTypeResource, Target: "metrics.k8s.io/v1beta1/nodes"},
+			expectedStatus: metav1.ConditionTrue,
+		},
+		{
+			name:           "resource is not served",
+			probe:          AvailabilityProbe{Name: "pod-metrics", Type: ProbeTypeResource, Target: "metrics.k8s.io/v1beta1/pods"},
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "api group is not served",
+			probe:          AvailabilityProbe{Name: "works", Type: ProbeTypeAPIGroup, Target: "work.open-cluster-management.io/v1"},
+			expectedStatus: metav1.ConditionFalse,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			condition := ctrl.probe(context.TODO(), c.probe)
+			if condition.Type != "probe.open-cluster-management.io/"+c.probe.Name {
+				t.Errorf("unexpected condition type %q", condition.Type)
+			}
+			if condition.Status != c.expectedStatus {
+				t.Errorf("expected status %q, but got %q: %s", c.expectedStatus, condition.Status, condition.Message)
+			}
+		})
+	}
+}
+
+func TestRemoveStaleProbeConditions(t *testing.T) {
+	status := &clusterv1.ManagedClusterStatus{
+		Conditions: []metav1.Condition{
+			{Type: clusterv1.ManagedClusterConditionAvailable, Status: metav1.ConditionTrue},
+			{Type: "probe.open-cluster-management.io/etcd", Status: metav1.ConditionTrue},
+			{Type: "probe.open-cluster-management.io/metrics", Status: metav1.ConditionFalse},
+		},
+	}
+	probes := []AvailabilityProbe{{Name: "etcd", Type: ProbeTypeReadyz, Target: "etcd"}}
+	if err := removeStaleProbeConditionsFn(probes)(status); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(status.Conditions) != 2 {
+		t.Errorf("expected 2 conditions, but got %v", status.Conditions)
+	}
+	if meta.FindStatusCondition(status.Conditions, "probe.open-cluster-management.io/metrics") != nil {
+		t.Errorf("the stale probe condition is not removed")
+	}
+}
@@ -0,0 +1,105 @@
+package managedcluster
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/health"
+	"open-cluster-management.io/registration/pkg/helpers"
+)
+
+// bootstrapKubeconfigController watches the bootstrap kubeconfig published by the hub in the cluster namespace,
+// and replaces the local bootstrap kubeconfig secret with it, so that the agent is still able to bootstrap again
+// after the hub endpoint or CA bundle is changed.
+type bootstrapKubeconfigController struct {
+	clusterName                        string
+	bootstrapKubeconfigSecretNamespace string
+	bootstrapKubeconfigSecretName      string
+	hubSecretLister                    corev1listers.SecretLister
+	managementCoreClient               corev1client.CoreV1Interface
+}
+
+// NewBootstrapKubeconfigController returns a new bootstrapKubeconfigController. The hub secret informer is
+// expected to watch the cluster namespace on the hub.
+func NewBootstrapKubeconfigController(
+	clusterName, bootstrapKubeconfigSecretNamespace, bootstrapKubeconfigSecretName string,
+	hubSecretInformer corev1informers.SecretInformer,
+	managementCoreClient corev1client.CoreV1Interface,
+	recorder events.Recorder) factory.Controller {
+	c := &bootstrapKubeconfigController{
+		clusterName:                        clusterName,
+		bootstrapKubeconfigSecretNamespace: bootstrapKubeconfigSecretNamespace,
+		bootstrapKubeconfigSecretName:      bootstrapKubeconfigSecretName,
+		hubSecretLister:                    hubSecretInformer.Lister(),
+		managementCoreClient:               managementCoreClient,
+	}
+
+	return factory.New().
+		WithInformers(hubSecretInformer.Informer()).
+		WithSync(health.DefaultRegistry.TrackSync("BootstrapKubeconfigController", c.sync)).
+		ResyncEvery(10*time.Minute).
+		ToController("BootstrapKubeconfigController", recorder)
+}
+
+func (c *bootstrapKubeconfigController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithValues("secret", c.bootstrapKubeconfigSecretName)
+	logger.V(4).Info("Reconciling bootstrap kubeconfig secret")
+
+	published, err := c.hubSecretLister.Secrets(c.clusterName).Get(helpers.BootstrapHubKubeconfigSecretName)
+	if errors.IsNotFound(err) {
+		// the hub does not publish the bootstrap kubeconfig
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	kubeconfig := published.Data[clientcert.KubeconfigFile]
+	if _, err := clientcmd.Load(kubeconfig); err != nil || len(kubeconfig) == 0 {
+		syncCtx.Recorder().Warningf("BootstrapKubeconfigInvalid",
+			"The bootstrap kubeconfig published by hub is invalid: %v", err)
+		return nil
+	}
+
+	secret, err := c.managementCoreClient.Secrets(c.bootstrapKubeconfigSecretNamespace).Get(
+		ctx, c.bootstrapKubeconfigSecretName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// only replace the existing bootstrap kubeconfig secret, it is not created by the agent
+		logger.V(4).Info("Bootstrap kubeconfig secret is not found")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(secret.Data[clientcert.KubeconfigFile], kubeconfig) {
+		return nil
+	}
+
+	secret = secret.DeepCopy()
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[clientcert.KubeconfigFile] = kubeconfig
+	if _, err := c.managementCoreClient.Secrets(c.bootstrapKubeconfigSecretNamespace).Update(
+		ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	syncCtx.Recorder().Eventf("BootstrapKubeconfigUpdated",
+		"Bootstrap kubeconfig secret %q is updated with the one published by hub", c.bootstrapKubeconfigSecretName)
+	return nil
+}
@@ -0,0 +1,108 @@
+package managedcluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSyncBootstrapKubeconfig(t *testing.T) {
+	publishedKubeconfig := testinghelpers.NewKubeconfig(nil, nil)
+	localKubeconfig := []byte("local")
+
+	cases := []struct {
+		name            string
+		hubSecrets      []runtime.Object
+		spokeSecrets    []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "no published bootstrap kubeconfig",
+			spokeSecrets:    []runtime.Object{newBootstrapKubeconfigSecret("testns", "bootstrap-hub-kubeconfig", localKubeconfig)},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name: "invalid published bootstrap kubeconfig",
+			hubSecrets: []runtime.Object{
+				newBootstrapKubeconfigSecret(testinghelpers.TestManagedClusterName, helpers.BootstrapHubKubeconfigSecretName, []byte("invalid")),
+			},
+			spokeSecrets:    []runtime.Object{newBootstrapKubeconfigSecret("testns", "bootstrap-hub-kubeconfig", localKubeconfig)},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name: "no local bootstrap kubeconfig secret",
+			hubSecrets: []runtime.Object{
+				newBootstrapKubeconfigSecret(testinghelpers.TestManagedClusterName, helpers.BootstrapHubKubeconfigSecretName, publishedKubeconfig),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name: "bootstrap kubeconfig is not changed",
+			hubSecrets: []runtime.Object{
+				newBootstrapKubeconfigSecret(testinghelpers.TestManagedClusterName, helpers.BootstrapHubKubeconfigSecretName, publishedKubeconfig),
+			},
+			spokeSecrets: []runtime.Object{newBootstrapKubeconfigSecret("testns", "bootstrap-hub-kubeconfig", publishedKubeconfig)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name: "bootstrap kubeconfig is changed",
+			hubSecrets: []runtime.Object{
+				newBootstrapKubeconfigSecret(testinghelpers.TestManagedClusterName, helpers.BootstrapHubKubeconfigSecretName, publishedKubeconfig),
+			},
+			spokeSecrets: []runtime.Object{newBootstrapKubeconfigSecret("testns", "bootstrap-hub-kubeconfig", localKubeconfig)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "update")
+				secret := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+				if string(secret.Data[clientcert.KubeconfigFile]) != string(publishedKubeconfig) {
+					t.Errorf("expected the bootstrap kubeconfig is updated, but got %q", secret.Data[clientcert.KubeconfigFile])
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			hubKubeClient := kubefake.NewSimpleClientset(c.hubSecrets...)
+			informerFactory := kubeinformers.NewSharedInformerFactory(hubKubeClient, 10*time.Minute)
+			for _, secret := range c.hubSecrets {
+				if err := informerFactory.Core().V1().Secrets().Informer().GetStore().Add(secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			managementKubeClient := kubefake.NewSimpleClientset(c.spokeSecrets...)
+			ctrl := &bootstrapKubeconfigController{
+				clusterName:                        testinghelpers.TestManagedClusterName,
+				bootstrapKubeconfigSecretNamespace: "testns",
+				bootstrapKubeconfigSecretName:      "bootstrap-hub-kubeconfig",
+				hubSecretLister:                    informerFactory.Core().V1().Secrets().Lister(),
+				managementCoreClient:               managementKubeClient.CoreV1(),
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, ""))
+			testinghelpers.AssertError(t, syncErr, "")
+
+			c.validateActions(t, managementKubeClient.Actions())
+		})
+	}
+}
+
+func newBootstrapKubeconfigSecret(namespace, name string, kubeconfig []byte) *corev1.Secret {
+	return testinghelpers.NewHubKubeconfigSecret(namespace, name, "", nil, map[string][]byte{
+		clientcert.KubeconfigFile: kubeconfig,
+	})
+}
@@ -0,0 +1,173 @@
+package managedcluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// BootstrapStatusConfigMapName is the name of the ConfigMap in the agent namespace holding the bootstrap
+	// progress of the agent
+	BootstrapStatusConfigMapName = "registration-agent-bootstrap-status"
+	// BootstrapStatusConditionsKey is the key of the bootstrap conditions in the ConfigMap, its value is a json
+	// list of the conditions
+	BootstrapStatusConditionsKey = "conditions"
+
+	// The conditions of the bootstrap phases
+	BootstrapKubeconfigValidCondition = "BootstrapKubeconfigValid"
+	BootstrapCSRCreatedCondition      = "CSRCreated"
+	BootstrapCSRApprovedCondition     = "CSRApproved"
+	HubKubeconfigReadyCondition       = "HubKubeconfigReady"
+	ClusterJoinedCondition            = "ClusterJoined"
+)
+
+// BootstrapStatusRecorder records the bootstrap phases of the agent as conditions in a ConfigMap on the cluster
+// where the agent runs, so the bootstrap can be debugged without reading the agent logs. The status is for
+// debugging only, the failures of recording it are logged and do not block the bootstrap.
+type BootstrapStatusRecorder struct {
+	configMapClient corev1client.ConfigMapInterface
+
+	lock       sync.Mutex
+	conditions []metav1.Condition
+	created    bool
+}
+
+// NewBootstrapStatusRecorder returns a BootstrapStatusRecorder writing the ConfigMap in the namespace
+func NewBootstrapStatusRecorder(configMapsGetter corev1client.ConfigMapsGetter, namespace string) *BootstrapStatusRecorder {
+	return &BootstrapStatusRecorder{
+		configMapClient: configMapsGetter.ConfigMaps(namespace),
+	}
+}
+
+// Record sets the condition in the bootstrap status, the ConfigMap is written only if the conditions are changed.
+func (r *BootstrapStatusRecorder) Record(ctx context.Context, cond metav1.Condition) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	conditions := make([]metav1.Condition, len(r.conditions))
+	copy(conditions, r.conditions)
+	meta.SetStatusCondition(&conditions, cond)
+	if r.created && equality.Semantic.DeepEqual(conditions, r.conditions) {
+		return
+	}
+
+	if err := r.write(ctx, conditions); err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to record the bootstrap condition", "condition", cond.Type)
+		return
+	}
+	r.conditions = conditions
+	r.created = true
+}
+
+// RecordCSRStatus records the CSRCreated and CSRApproved conditions with the latest bootstrap csr of the cluster in
+// the indexer of the csr informer.
+func (r *BootstrapStatusRecorder) RecordCSRStatus(ctx context.Context, indexer cache.Indexer, clusterName string) {
+	items, err := indexer.ByIndex(indexByCluster, clusterName)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to list the bootstrap csrs of cluster")
+		return
+	}
+
+	var latest interface{}
+	var latestCreation metav1.Time
+	for _, item := range items {
+		accessor, err := meta.Accessor(item)
+		if err != nil {
+			continue
+		}
+		if !strings.HasPrefix(accessor.GetName(), fmt.Sprintf("%s-", clusterName)) {
+			continue
+		}
+		creation := accessor.GetCreationTimestamp()
+		if latest == nil || latestCreation.Before(&creation) {
+			latest = item
+			latestCreation = creation
+		}
+	}
+	if latest == nil {
+		return
+	}
+
+	accessor, _ := meta.Accessor(latest)
+	r.Record(ctx, metav1.Condition{
+		Type:    BootstrapCSRCreatedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "CSRCreated",
+		Message: fmt.Sprintf("The csr %q is created on the hub", accessor.GetName()),
+	})
+
+	cond := metav1.Condition{
+		Type:    BootstrapCSRApprovedCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  "CSRPending",
+		Message: fmt.Sprintf("The csr %q is waiting for approval", accessor.GetName()),
+	}
+	switch approved, denied := csrApprovalStatus(latest); {
+	case denied:
+		cond.Reason = "CSRDenied"
+		cond.Message = fmt.Sprintf("The csr %q is denied", accessor.GetName())
+	case approved:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "CSRApproved"
+		cond.Message = fmt.Sprintf("The csr %q is approved", accessor.GetName())
+	}
+	r.Record(ctx, cond)
+}
+
+func (r *BootstrapStatusRecorder) write(ctx context.Context, conditions []metav1.Condition) error {
+	data, err := json.Marshal(conditions)
+	if err != nil {
+		return err
+	}
+
+	configMap, err := r.configMapClient.Get(ctx, BootstrapStatusConfigMapName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = r.configMapClient.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: BootstrapStatusConfigMapName},
+			Data:       map[string]string{BootstrapStatusConditionsKey: string(data)},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	configMap = configMap.DeepCopy()
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[BootstrapStatusConditionsKey] = string(data)
+	_, err = r.configMapClient.Update(ctx, configMap, metav1.UpdateOptions{})
+	return err
+}
+
+// csrApprovalStatus returns whether the csr is approved or denied
+func csrApprovalStatus(obj interface{}) (approved, denied bool) {
+	switch csr := obj.(type) {
+	case *certificatesv1.CertificateSigningRequest:
+		for _, c := range csr.Status.Conditions {
+			approved = approved || c.Type == certificatesv1.CertificateApproved
+			denied = denied || c.Type == certificatesv1.CertificateDenied
+		}
+	case *certificatesv1beta1.CertificateSigningRequest:
+		for _, c := range csr.Status.Conditions {
+			approved = approved || c.Type == certificatesv1beta1.CertificateApproved
+			denied = denied || c.Type == certificatesv1beta1.CertificateDenied
+		}
+	}
+	return approved, denied
+}
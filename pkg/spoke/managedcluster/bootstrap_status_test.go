@@ -0,0 +1,129 @@
+package managedcluster
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestBootstrapStatusRecorder(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	recorder := NewBootstrapStatusRecorder(kubeClient.CoreV1(), testNamespace)
+
+	cond := metav1.Condition{
+		Type:    BootstrapKubeconfigValidCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "BootstrapKubeconfigLoaded",
+		Message: "The bootstrap kubeconfig is loaded",
+	}
+	recorder.Record(context.TODO(), cond)
+	testinghelpers.AssertActions(t, kubeClient.Actions(), "get", "create")
+
+	// the unchanged condition is not written again
+	kubeClient.ClearActions()
+	recorder.Record(context.TODO(), cond)
+	testinghelpers.AssertNoActions(t, kubeClient.Actions())
+
+	recorder.Record(context.TODO(), metav1.Condition{
+		Type:    HubKubeconfigReadyCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "HubKubeconfigReady",
+		Message: "The hub kubeconfig is valid",
+	})
+	testinghelpers.AssertActions(t, kubeClient.Actions(), "get", "update")
+
+	configMap, err := kubeClient.CoreV1().ConfigMaps(testNamespace).Get(context.TODO(), BootstrapStatusConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conditions := []metav1.Condition{}
+	if err := json.Unmarshal([]byte(configMap.Data[BootstrapStatusConditionsKey]), &conditions); err != nil {
+		t.Fatal(err)
+	}
+	if !meta.IsStatusConditionTrue(conditions, BootstrapKubeconfigValidCondition) ||
+		!meta.IsStatusConditionTrue(conditions, HubKubeconfigReadyCondition) {
+		t.Errorf("unexpected conditions: %v", conditions)
+	}
+}
+
+func TestRecordCSRStatus(t *testing.T) {
+	clusterName := testinghelpers.TestManagedClusterName
+	holder := testinghelpers.CSRHolder{
+		Name:   clusterName + "-abcde",
+		Labels: map[string]string{clusterv1.ClusterNameLabelKey: clusterName},
+	}
+
+	cases := []struct {
+		name             string
+		csr              *certificatesv1.CertificateSigningRequest
+		expectedCreated  bool
+		expectedApproved metav1.ConditionStatus
+		expectedReason   string
+	}{
+		{
+			name: "no csr",
+		},
+		{
+			name:             "pending csr",
+			csr:              testinghelpers.NewCSR(holder),
+			expectedCreated:  true,
+			expectedApproved: metav1.ConditionFalse,
+			expectedReason:   "CSRPending",
+		},
+		{
+			name:             "approved csr",
+			csr:              testinghelpers.NewApprovedCSR(holder),
+			expectedCreated:  true,
+			expectedApproved: metav1.ConditionTrue,
+			expectedReason:   "CSRApproved",
+		},
+		{
+			name:             "denied csr",
+			csr:              testinghelpers.NewDeniedCSR(holder),
+			expectedCreated:  true,
+			expectedApproved: metav1.ConditionFalse,
+			expectedReason:   "CSRDenied",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			informerFactory := informers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 10*time.Minute)
+			indexer := informerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetIndexer()
+			if err := indexer.AddIndexers(cache.Indexers{indexByCluster: indexByClusterFunc}); err != nil {
+				t.Fatal(err)
+			}
+			if c.csr != nil {
+				if err := indexer.Add(c.csr); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			recorder := NewBootstrapStatusRecorder(kubefake.NewSimpleClientset().CoreV1(), testNamespace)
+			recorder.RecordCSRStatus(context.TODO(), indexer, clusterName)
+
+			created := meta.FindStatusCondition(recorder.conditions, BootstrapCSRCreatedCondition)
+			if (created != nil) != c.expectedCreated {
+				t.Errorf("expected csr created %v, but got %v", c.expectedCreated, created)
+			}
+			if !c.expectedCreated {
+				return
+			}
+			approved := meta.FindStatusCondition(recorder.conditions, BootstrapCSRApprovedCondition)
+			if approved == nil || approved.Status != c.expectedApproved || approved.Reason != c.expectedReason {
+				t.Errorf("expected csr approved condition %s/%s, but got %v", c.expectedApproved, c.expectedReason, approved)
+			}
+		})
+	}
+}
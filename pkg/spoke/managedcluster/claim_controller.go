@@ -13,6 +13,7 @@ import (
 	clusterv1alpha1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1alpha1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	"open-cluster-management.io/registration/pkg/health"
 	"open-cluster-management.io/registration/pkg/helpers"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -58,7 +59,7 @@ func NewManagedClusterClaimController(
 			accessor, _ := meta.Accessor(obj)
 			return accessor.GetName()
 		}, hubManagedClusterInformer.Informer()).
-		WithSync(c.sync).
+		WithSync(health.DefaultRegistry.TrackSync("ClusterClaimController", c.sync)).
 		ToController("ClusterClaimController", recorder)
 }
 
@@ -136,14 +137,15 @@ func (c managedClusterClaimController) exposeClaims(ctx context.Context, syncCtx
 		return fmt.Errorf("unable to update status of managed cluster %q: %w", c.clusterName, err)
 	}
 	if updated {
-		klog.V(4).Infof("The cluster claims in status of managed cluster %q has been updated", c.clusterName)
+		klog.FromContext(ctx).V(4).Info("The cluster claims in status of managed cluster has been updated")
 	}
 	return nil
 }
 
 func updateClusterClaimsFn(status clusterv1.ManagedClusterStatus) helpers.UpdateManagedClusterStatusFunc {
 	return func(oldStatus *clusterv1.ManagedClusterStatus) error {
-		oldStatus.ClusterClaims = status.ClusterClaims
+		// the node summary and tunnel endpoint claims are owned by the other controllers, they are kept
+		oldStatus.ClusterClaims = append(status.ClusterClaims, agentClaimsOf(oldStatus.ClusterClaims)...)
 		return nil
 	}
 }
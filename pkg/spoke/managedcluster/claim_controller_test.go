@@ -2,7 +2,6 @@ package managedcluster
 
 import (
 	"context"
-	"encoding/json"
 	"reflect"
 	"testing"
 	"time"
@@ -51,12 +50,8 @@ func TestSync(t *testing.T) {
 			},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
 				cluster := &clusterv1.ManagedCluster{}
-				err := json.Unmarshal(patch, cluster)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[1], cluster)
 				expected := []clusterv1.ManagedClusterClaim{
 					{
 						Name:  "a",
@@ -132,12 +127,8 @@ func TestExposeClaims(t *testing.T) {
 			},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
 				cluster := &clusterv1.ManagedCluster{}
-				err := json.Unmarshal(patch, cluster)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[1], cluster)
 				expected := []clusterv1.ManagedClusterClaim{
 					{
 						Name:  "a",
@@ -190,12 +181,8 @@ func TestExposeClaims(t *testing.T) {
 			maxCustomClusterClaims: 2,
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
 				cluster := &clusterv1.ManagedCluster{}
-				err := json.Unmarshal(patch, cluster)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[1], cluster)
 				expected := []clusterv1.ManagedClusterClaim{
 					{
 						Name:  "id.k8s.io",
@@ -226,12 +213,8 @@ func TestExposeClaims(t *testing.T) {
 			}),
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
 				cluster := &clusterv1.ManagedCluster{}
-				err := json.Unmarshal(patch, cluster)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[1], cluster)
 				actual := cluster.Status.ClusterClaims
 				if len(actual) > 0 {
 					t.Errorf("expected no cluster claim but got: %v", actual)
@@ -262,12 +245,8 @@ func TestExposeClaims(t *testing.T) {
 			},
 			validateActions: func(t *testing.T, actions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
 				cluster := &clusterv1.ManagedCluster{}
-				err := json.Unmarshal(patch, cluster)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[1], cluster)
 				expected := []clusterv1.ManagedClusterClaim{
 					{
 						Name:  "c",
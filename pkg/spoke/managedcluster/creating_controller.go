@@ -7,6 +7,7 @@ import (
 
 	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/health"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -26,24 +27,31 @@ type managedClusterCreatingController struct {
 	clusterName             string
 	spokeExternalServerURLs []string
 	spokeCABundle           []byte
-	hubClusterClient        clientset.Interface
+	// clusterLabels and clusterAnnotations are the metadata stamped by the provisioning systems, they are only set
+	// when the ManagedCluster is created
+	clusterLabels      map[string]string
+	clusterAnnotations map[string]string
+	hubClusterClient   clientset.Interface
 }
 
 // NewManagedClusterCreatingController creates a new managedClusterCreatingController on the managed cluster.
 func NewManagedClusterCreatingController(
 	clusterName string, spokeExternalServerURLs []string,
 	spokeCABundle []byte,
+	clusterLabels, clusterAnnotations map[string]string,
 	hubClusterClient clientset.Interface,
 	recorder events.Recorder) factory.Controller {
 	c := &managedClusterCreatingController{
 		clusterName:             clusterName,
 		spokeExternalServerURLs: spokeExternalServerURLs,
 		spokeCABundle:           spokeCABundle,
+		clusterLabels:           clusterLabels,
+		clusterAnnotations:      clusterAnnotations,
 		hubClusterClient:        hubClusterClient,
 	}
 
 	return factory.New().
-		WithSync(c.sync).
+		WithSync(health.DefaultRegistry.TrackSync("ManagedClusterCreatingController", c.sync)).
 		ResyncEvery(wait.Jitter(CreatingControllerSyncInterval, 1.0)).
 		ToController("ManagedClusterCreatingController", recorder)
 }
@@ -52,7 +60,7 @@ func (c *managedClusterCreatingController) sync(ctx context.Context, syncCtx fac
 	existingCluster, err := c.hubClusterClient.ClusterV1().ManagedClusters().Get(ctx, c.clusterName, metav1.GetOptions{})
 	// ManagedCluster is only allowed created during bootstrap. After bootstrap secret expired, an unauthorized error will be got, output log at the debug level
 	if err != nil && skipUnauthorizedError(err) == nil {
-		klog.V(4).Infof("unable to get the managed cluster %q from hub: %v", c.clusterName, err)
+		klog.FromContext(ctx).V(4).Info("Unable to get the managed cluster from hub", "err", err)
 		return nil
 	}
 
@@ -67,6 +75,18 @@ func (c *managedClusterCreatingController) sync(ctx context.Context, syncCtx fac
 				Name: c.clusterName,
 			},
 		}
+		for key, value := range c.clusterLabels {
+			if managedCluster.Labels == nil {
+				managedCluster.Labels = map[string]string{}
+			}
+			managedCluster.Labels[key] = value
+		}
+		for key, value := range c.clusterAnnotations {
+			if managedCluster.Annotations == nil {
+				managedCluster.Annotations = map[string]string{}
+			}
+			managedCluster.Annotations[key] = value
+		}
 
 		if len(c.spokeExternalServerURLs) != 0 {
 			var managedClusterClientConfigs []clusterv1.ClientConfig
@@ -88,12 +108,8 @@ func (c *managedClusterCreatingController) sync(ctx context.Context, syncCtx fac
 		return nil
 	}
 
-	// do not update ManagedClusterClientConfigs in ManagedCluster if spokeExternalServerURLs is empty
-	if len(c.spokeExternalServerURLs) == 0 {
-		return nil
-	}
-
-	// merge ClientConfig
+	// merge ClientConfig, do not update ManagedClusterClientConfigs in ManagedCluster if spokeExternalServerURLs is
+	// empty
 	managedClusterClientConfigs := existingCluster.Spec.ManagedClusterClientConfigs
 	for _, serverURL := range c.spokeExternalServerURLs {
 		isIncludeByExisting := false
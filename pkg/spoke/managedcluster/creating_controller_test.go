@@ -12,13 +12,18 @@ import (
 	clienttesting "k8s.io/client-go/testing"
 )
 
-const testSpokeExternalServerUrl = "https://192.168.3.77:32769"
+const (
+	testSpokeExternalServerUrl = "https://192.168.3.77:32769"
+	testAnnotationKey          = "agent.open-cluster-management.io/test"
+)
 
 func TestCreateSpokeCluster(t *testing.T) {
 	cases := []struct {
-		name            string
-		startingObjects []runtime.Object
-		validateActions func(t *testing.T, actions []clienttesting.Action)
+		name               string
+		startingObjects    []runtime.Object
+		clusterLabels      map[string]string
+		clusterAnnotations map[string]string
+		validateActions    func(t *testing.T, actions []clienttesting.Action)
 	}{
 		{
 			name:            "create a new cluster",
@@ -43,6 +48,38 @@ func TestCreateSpokeCluster(t *testing.T) {
 				testinghelpers.AssertActions(t, actions, "get", "update")
 			},
 		},
+		{
+			name:               "create a new cluster with the metadata of the provisioning systems",
+			startingObjects:    []runtime.Object{},
+			clusterLabels:      map[string]string{"environment": "production"},
+			clusterAnnotations: map[string]string{"example.com/owner": "team-a", testAnnotationKey: "value"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "create")
+				actual := actions[1].(clienttesting.CreateActionImpl).Object.(*clusterv1.ManagedCluster)
+				if actual.Labels["environment"] != "production" {
+					t.Errorf("expected label environment, but got %v", actual.Labels)
+				}
+				if actual.Annotations["example.com/owner"] != "team-a" || actual.Annotations[testAnnotationKey] != "value" {
+					t.Errorf("expected annotations example.com/owner and %q, but got %v", testAnnotationKey, actual.Annotations)
+				}
+			},
+		},
+		{
+			name:               "metadata of the provisioning systems are not set on an existed cluster",
+			startingObjects:    []runtime.Object{newManagedClusterWithClientConfig()},
+			clusterLabels:      map[string]string{"environment": "production"},
+			clusterAnnotations: map[string]string{"example.com/owner": "team-a"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name:            "existed cluster is not changed",
+			startingObjects: []runtime.Object{newManagedClusterWithClientConfig()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get")
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -52,6 +89,8 @@ func TestCreateSpokeCluster(t *testing.T) {
 				clusterName:             testinghelpers.TestManagedClusterName,
 				spokeExternalServerURLs: []string{testSpokeExternalServerUrl},
 				spokeCABundle:           []byte("testcabundle"),
+				clusterLabels:           c.clusterLabels,
+				clusterAnnotations:      c.clusterAnnotations,
 				hubClusterClient:        clusterClient,
 			}
 
@@ -64,3 +103,14 @@ func TestCreateSpokeCluster(t *testing.T) {
 		})
 	}
 }
+
+func newManagedClusterWithClientConfig() *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewManagedCluster()
+	cluster.Spec.ManagedClusterClientConfigs = []clusterv1.ClientConfig{
+		{
+			URL:      testSpokeExternalServerUrl,
+			CABundle: []byte("testcabundle"),
+		},
+	}
+	return cluster
+}
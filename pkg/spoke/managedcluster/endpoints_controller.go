@@ -0,0 +1,146 @@
+package managedcluster
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/registration/pkg/health"
+	"open-cluster-management.io/registration/pkg/helpers"
+)
+
+const (
+	// clusterInfoNamespace and clusterInfoName locate the configmap holding the public kubeconfig of the cluster,
+	// which is published by kubeadm and most of the distributions
+	clusterInfoNamespace = "kube-public"
+	clusterInfoName      = "cluster-info"
+	clusterInfoKey       = "kubeconfig"
+)
+
+// clusterEndpointsController discovers the api server urls and CA bundle of the managed cluster, and publishes them
+// in the cluster endpoints secret created by the hub in the cluster namespace, so the hub is able to keep the client
+// configs of the managed cluster accurate. The external server urls of the agent take precedence over the public
+// kubeconfig in the cluster-info configmap.
+type clusterEndpointsController struct {
+	clusterName             string
+	spokeExternalServerURLs []string
+	spokeCABundle           []byte
+	spokeCoreClient         corev1client.CoreV1Interface
+	hubCoreClient           corev1client.CoreV1Interface
+}
+
+// NewClusterEndpointsController returns a new clusterEndpointsController.
+func NewClusterEndpointsController(
+	clusterName string, spokeExternalServerURLs []string,
+	spokeCABundle []byte,
+	spokeCoreClient corev1client.CoreV1Interface,
+	hubCoreClient corev1client.CoreV1Interface,
+	recorder events.Recorder) factory.Controller {
+	c := &clusterEndpointsController{
+		clusterName:             clusterName,
+		spokeExternalServerURLs: spokeExternalServerURLs,
+		spokeCABundle:           spokeCABundle,
+		spokeCoreClient:         spokeCoreClient,
+		hubCoreClient:           hubCoreClient,
+	}
+
+	return factory.New().
+		WithSync(health.DefaultRegistry.TrackSync("ClusterEndpointsController", c.sync)).
+		ResyncEvery(10*time.Minute).
+		ToController("ClusterEndpointsController", recorder)
+}
+
+func (c *clusterEndpointsController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	logger := klog.FromContext(ctx)
+	logger.V(4).Info("Reconciling endpoints of managed cluster")
+
+	urls, caBundle, err := c.discoverEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+	if len(urls) == 0 {
+		logger.V(4).Info("No api server url of managed cluster is discovered")
+		return nil
+	}
+
+	secret, err := c.hubCoreClient.Secrets(c.clusterName).Get(ctx, helpers.ClusterEndpointsSecretName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// the secret is created by the hub once the cluster is accepted
+		logger.V(4).Info("Cluster endpoints secret of managed cluster is not found")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	urlsData := []byte(strings.Join(urls, "\n"))
+	if bytes.Equal(secret.Data[helpers.ClusterEndpointsURLsKey], urlsData) &&
+		bytes.Equal(secret.Data[helpers.ClusterEndpointsCABundleKey], caBundle) {
+		return nil
+	}
+
+	secret = secret.DeepCopy()
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[helpers.ClusterEndpointsURLsKey] = urlsData
+	secret.Data[helpers.ClusterEndpointsCABundleKey] = caBundle
+	if _, err := c.hubCoreClient.Secrets(c.clusterName).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	syncCtx.Recorder().Eventf("ClusterEndpointsPublished",
+		"The api server urls %s of managed cluster %q are published to hub", strings.Join(urls, ", "), c.clusterName)
+	return nil
+}
+
+// discoverEndpoints returns the api server urls and CA bundle of the managed cluster
+func (c *clusterEndpointsController) discoverEndpoints(ctx context.Context) ([]string, []byte, error) {
+	if len(c.spokeExternalServerURLs) > 0 {
+		return c.spokeExternalServerURLs, c.spokeCABundle, nil
+	}
+
+	clusterInfo, err := c.spokeCoreClient.ConfigMaps(clusterInfoNamespace).Get(ctx, clusterInfoName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kubeconfig, err := clientcmd.Load([]byte(clusterInfo.Data[clusterInfoKey]))
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Unable to load the kubeconfig in configmap", "configMap", clusterInfoNamespace+"/"+clusterInfoName)
+		return nil, nil, nil
+	}
+
+	urls := sets.NewString()
+	var caBundle []byte
+	// the clusters are iterated in order, so the CA bundle is stable if the clusters have different ones
+	for _, name := range sets.StringKeySet(kubeconfig.Clusters).List() {
+		cluster := kubeconfig.Clusters[name]
+		// only the https urls are accepted by the hub
+		if !strings.HasPrefix(cluster.Server, "https://") {
+			continue
+		}
+		urls.Insert(cluster.Server)
+		if len(caBundle) == 0 {
+			caBundle = cluster.CertificateAuthorityData
+		}
+	}
+	if len(caBundle) == 0 {
+		caBundle = c.spokeCABundle
+	}
+	return urls.List(), caBundle, nil
+}
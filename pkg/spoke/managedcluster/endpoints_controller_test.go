@@ -0,0 +1,135 @@
+package managedcluster
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSyncClusterEndpoints(t *testing.T) {
+	cases := []struct {
+		name                    string
+		spokeExternalServerURLs []string
+		spokeObjects            []runtime.Object
+		hubObjects              []runtime.Object
+		expectedURLs            string
+		expectedCABundle        string
+		validateActions         func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "no endpoints discovered",
+			hubObjects:      []runtime.Object{newClusterEndpointsSecret("", "")},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:         "no cluster endpoints secret",
+			spokeObjects: []runtime.Object{newClusterInfo(t, "https://api.cluster1.example.com:6443", "ca")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name:            "http endpoints are ignored",
+			spokeObjects:    []runtime.Object{newClusterInfo(t, "http://api.cluster1.example.com:6443", "ca")},
+			hubObjects:      []runtime.Object{newClusterEndpointsSecret("", "")},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:             "publish endpoints in cluster info",
+			spokeObjects:     []runtime.Object{newClusterInfo(t, "https://api.cluster1.example.com:6443", "ca")},
+			hubObjects:       []runtime.Object{newClusterEndpointsSecret("", "")},
+			expectedURLs:     "https://api.cluster1.example.com:6443",
+			expectedCABundle: "ca",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "update")
+			},
+		},
+		{
+			name:                    "publish external server urls",
+			spokeExternalServerURLs: []string{"https://10.0.0.1:6443", "https://10.0.0.2:6443"},
+			spokeObjects:            []runtime.Object{newClusterInfo(t, "https://api.cluster1.example.com:6443", "ca")},
+			hubObjects:              []runtime.Object{newClusterEndpointsSecret("", "")},
+			expectedURLs:            "https://10.0.0.1:6443\nhttps://10.0.0.2:6443",
+			expectedCABundle:        "spoke-ca",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "update")
+			},
+		},
+		{
+			name:         "endpoints are not changed",
+			spokeObjects: []runtime.Object{newClusterInfo(t, "https://api.cluster1.example.com:6443", "ca")},
+			hubObjects:   []runtime.Object{newClusterEndpointsSecret("https://api.cluster1.example.com:6443", "ca")},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			hubKubeClient := kubefake.NewSimpleClientset(c.hubObjects...)
+			ctrl := &clusterEndpointsController{
+				clusterName:             testinghelpers.TestManagedClusterName,
+				spokeExternalServerURLs: c.spokeExternalServerURLs,
+				spokeCABundle:           []byte("spoke-ca"),
+				spokeCoreClient:         kubefake.NewSimpleClientset(c.spokeObjects...).CoreV1(),
+				hubCoreClient:           hubKubeClient.CoreV1(),
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, ""))
+			testinghelpers.AssertError(t, syncErr, "")
+
+			actions := hubKubeClient.Actions()
+			c.validateActions(t, actions)
+			if len(c.expectedURLs) == 0 {
+				return
+			}
+			secret := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+			if string(secret.Data[helpers.ClusterEndpointsURLsKey]) != c.expectedURLs {
+				t.Errorf("expected urls %q, but got %q", c.expectedURLs, secret.Data[helpers.ClusterEndpointsURLsKey])
+			}
+			if string(secret.Data[helpers.ClusterEndpointsCABundleKey]) != c.expectedCABundle {
+				t.Errorf("expected CA bundle %q, but got %q", c.expectedCABundle, secret.Data[helpers.ClusterEndpointsCABundleKey])
+			}
+		})
+	}
+}
+
+func newClusterInfo(t *testing.T, server, caBundle string) *corev1.ConfigMap {
+	kubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{"": {
+			Server:                   server,
+			CertificateAuthorityData: []byte(caBundle),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: clusterInfoNamespace, Name: clusterInfoName},
+		Data:       map[string]string{clusterInfoKey: string(kubeconfig)},
+	}
+}
+
+func newClusterEndpointsSecret(urls, caBundle string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testinghelpers.TestManagedClusterName,
+			Name:      helpers.ClusterEndpointsSecretName,
+		},
+		Data: map[string][]byte{
+			helpers.ClusterEndpointsURLsKey:     []byte(urls),
+			helpers.ClusterEndpointsCABundleKey: []byte(caBundle),
+		},
+	}
+}
@@ -0,0 +1,195 @@
+package managedcluster
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	certutil "k8s.io/client-go/util/cert"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+const (
+	// HealthCheckLivez checks the livez endpoint of the kube-apiserver
+	HealthCheckLivez = "livez"
+	// HealthCheckReadyz checks the readyz endpoint of the kube-apiserver
+	HealthCheckReadyz = "readyz"
+	// HealthCheckHealthz checks the deprecated healthz endpoint of the kube-apiserver
+	HealthCheckHealthz = "healthz"
+	// HealthCheckList lists one item of a canary resource, e.g. v1/namespaces or apps/v1/deployments
+	HealthCheckList = "list"
+	// HealthCheckURL checks the response of a user provided url is 200, e.g. the health endpoint of the load
+	// balancer of a managed kubernetes provider. The url is requested without the credentials of the agent.
+	HealthCheckURL = "url"
+
+	healthCheckTimeoutOption = "timeout="
+
+	// defaultHealthCheckURLTimeout is the timeout of the url strategy if the strategy has no timeout
+	defaultHealthCheckURLTimeout = 10 * time.Second
+)
+
+// HealthCheckTypes are the supported types of the health check strategies
+var HealthCheckTypes = []string{HealthCheckLivez, HealthCheckReadyz, HealthCheckHealthz, HealthCheckList, HealthCheckURL}
+
+// DefaultHealthCheckStrategies check the livez endpoint, and fall back to the healthz endpoint on the
+// kube-apiservers older than Kubernetes 1.16
+var DefaultHealthCheckStrategies = []HealthCheckStrategy{{Type: HealthCheckLivez}, {Type: HealthCheckHealthz}}
+
+// HealthCheckStrategy is a method to check the health of the kube-apiserver of the managed cluster. The strategies
+// are tried in order, the next one is tried if a strategy is not found or forbidden, since the managed kubernetes
+// providers restrict some endpoints.
+type HealthCheckStrategy struct {
+	Type   string
+	Target string
+	// Timeout is the timeout of the check, the timeout of the client is used if it is zero
+	Timeout time.Duration
+}
+
+// ParseHealthCheckStrategy parses a strategy in the format of <type>[:<target>][,timeout=<duration>], e.g. livez,
+// readyz,timeout=5s, list:v1/namespaces or url:https://lb.example.com/healthz,timeout=10s.
+func ParseHealthCheckStrategy(value string) (HealthCheckStrategy, error) {
+	spec, timeout := value, ""
+	if index := strings.LastIndex(value, ","+healthCheckTimeoutOption); index >= 0 {
+		spec, timeout = value[:index], value[index+len(healthCheckTimeoutOption)+1:]
+	}
+
+	checkType, target, _ := strings.Cut(spec, ":")
+	strategy := HealthCheckStrategy{Type: checkType, Target: target}
+	if len(timeout) > 0 {
+		duration, err := time.ParseDuration(timeout)
+		if err != nil || duration <= 0 {
+			return HealthCheckStrategy{}, fmt.Errorf("the timeout of health check strategy %q must be a positive duration", value)
+		}
+		strategy.Timeout = duration
+	}
+
+	switch checkType {
+	case HealthCheckLivez, HealthCheckReadyz, HealthCheckHealthz:
+		if len(target) > 0 {
+			return HealthCheckStrategy{}, fmt.Errorf("health check strategy %q must have no target", value)
+		}
+	case HealthCheckList:
+		if parts := strings.Split(target, "/"); len(parts) != 2 && len(parts) != 3 {
+			return HealthCheckStrategy{}, fmt.Errorf("the target of health check strategy %q must be in the format of "+
+				"[<group>/]<version>/<resource>", value)
+		}
+	case HealthCheckURL:
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return HealthCheckStrategy{}, fmt.Errorf("the target of health check strategy %q must be an http or https url", value)
+		}
+	default:
+		return HealthCheckStrategy{}, fmt.Errorf("the type of health check strategy %q must be one of %v", value, HealthCheckTypes)
+	}
+	return strategy, nil
+}
+
+// NewHealthCheckHTTPClient returns the client to request the url strategies. The server is verified with the CA file,
+// or the system CAs if it is not set. The requests time out after defaultHealthCheckURLTimeout unless the strategy
+// has a timeout.
+func NewHealthCheckHTTPClient(caFile string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(caFile) > 0 {
+		pool, err := certutil.NewPool(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load health check ca file %q: %w", caFile, err)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// path returns the path of the kube-apiserver requested by the strategy
+func (s HealthCheckStrategy) path() string {
+	if s.Type != HealthCheckList {
+		return "/" + s.Type
+	}
+	if parts := strings.Split(s.Target, "/"); len(parts) == 2 {
+		return "/api/" + s.Target
+	}
+	return "/apis/" + s.Target
+}
+
+// checkKubeAPIServerStatus checks the health of the kube-apiserver with the health check strategies
+func (c *managedClusterStatusController) checkKubeAPIServerStatus(ctx context.Context) metav1.Condition {
+	strategies := c.healthCheckStrategies
+	if len(strategies) == 0 {
+		strategies = DefaultHealthCheckStrategies
+	}
+
+	condition := metav1.Condition{Type: clusterv1.ManagedClusterConditionAvailable}
+	var statusCode int
+	var message string
+	for _, strategy := range strategies {
+		statusCode, message = c.check(ctx, strategy)
+		if statusCode == http.StatusOK {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = "ManagedClusterAvailable"
+			condition.Message = "Managed cluster is available"
+			return condition
+		}
+		// the strategy is restricted, try the next one
+		if statusCode != http.StatusNotFound && statusCode != http.StatusForbidden {
+			break
+		}
+	}
+
+	condition.Status = metav1.ConditionFalse
+	condition.Reason = "ManagedClusterKubeAPIServerUnavailable"
+	condition.Message = fmt.Sprintf("The kube-apiserver is not ok, status code: %d, %v", statusCode, message)
+	return condition
+}
+
+// check runs the strategy and returns the status code and the body or error of the response
+func (c *managedClusterStatusController) check(ctx context.Context, strategy HealthCheckStrategy) (int, string) {
+	if strategy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, strategy.Timeout)
+		defer cancel()
+	}
+
+	if strategy.Type == HealthCheckURL {
+		if strategy.Timeout == 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, defaultHealthCheckURLTimeout)
+			defer cancel()
+		}
+		return c.checkURL(ctx, strategy.Target)
+	}
+
+	statusCode := 0
+	request := c.managedClusterDiscoveryClient.RESTClient().Get().AbsPath(strategy.path())
+	if strategy.Type == HealthCheckList {
+		request = request.Param("limit", "1")
+	}
+	return statusCode, responseMessage(request.Do(ctx).StatusCode(&statusCode))
+}
+
+func (c *managedClusterStatusController) checkURL(ctx context.Context, target string) (int, string) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return 0, err.Error()
+	}
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer response.Body.Close()
+	return response.StatusCode, response.Status
+}
+
+func responseMessage(result rest.Result) string {
+	body, err := result.Raw()
+	if err == nil {
+		return string(body)
+	}
+	return err.Error()
+}
@@ -0,0 +1,198 @@
+package managedcluster
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestParseHealthCheckStrategy(t *testing.T) {
+	cases := []struct {
+		name             string
+		value            string
+		expectedStrategy HealthCheckStrategy
+		expectedErr      string
+	}{
+		{
+			name:             "livez",
+			value:            "livez",
+			expectedStrategy: HealthCheckStrategy{Type: HealthCheckLivez},
+		},
+		{
+			name:             "readyz with timeout",
+			value:            "readyz,timeout=5s",
+			expectedStrategy: HealthCheckStrategy{Type: HealthCheckReadyz, Timeout: 5 * time.Second},
+		},
+		{
+			name:             "list core resource",
+			value:            "list:v1/namespaces",
+			expectedStrategy: HealthCheckStrategy{Type: HealthCheckList, Target: "v1/namespaces"},
+		},
+		{
+			name:             "url with timeout",
+			value:            "url:https://lb.example.com/healthz?verbose=true,timeout=10s",
+			expectedStrategy: HealthCheckStrategy{Type: HealthCheckURL, Target: "https://lb.example.com/healthz?verbose=true", Timeout: 10 * time.Second},
+		},
+		{
+			name:        "unknown type",
+			value:       "ping",
+			expectedErr: "the type of health check strategy \"ping\" must be one of [livez readyz healthz list url]",
+		},
+		{
+			name:        "endpoint with target",
+			value:       "readyz:etcd",
+			expectedErr: "health check strategy \"readyz:etcd\" must have no target",
+		},
+		{
+			name:        "invalid list target",
+			value:       "list:namespaces",
+			expectedErr: "the target of health check strategy \"list:namespaces\" must be in the format of [<group>/]<version>/<resource>",
+		},
+		{
+			name:        "invalid url",
+			value:       "url:lb.example.com/healthz",
+			expectedErr: "the target of health check strategy \"url:lb.example.com/healthz\" must be an http or https url",
+		},
+		{
+			name:        "invalid timeout",
+			value:       "livez,timeout=-1s",
+			expectedErr: "the timeout of health check strategy \"livez,timeout=-1s\" must be a positive duration",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			strategy, err := ParseHealthCheckStrategy(c.value)
+			testinghelpers.AssertError(t, err, c.expectedErr)
+			if strategy != c.expectedStrategy {
+				t.Errorf("expected strategy %v, but got %v", c.expectedStrategy, strategy)
+			}
+		})
+	}
+}
+
+func TestCheckKubeAPIServerStatus(t *testing.T) {
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/livez":
+			w.WriteHeader(http.StatusForbidden)
+		case req.URL.Path == "/readyz":
+			w.WriteHeader(http.StatusInternalServerError)
+		case req.URL.Path == "/api/v1/namespaces" && req.URL.Query().Get("limit") == "1":
+			w.WriteHeader(http.StatusOK)
+		case req.URL.Path == "/apis/apps/v1/deployments":
+			time.Sleep(time.Second)
+			w.WriteHeader(http.StatusOK)
+		case req.URL.Path == "/lb/healthz":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer apiServer.Close()
+
+	cases := []struct {
+		name           string
+		strategies     []HealthCheckStrategy
+		expectedStatus metav1.ConditionStatus
+	}{
+		{
+			name:           "default strategies fail",
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "fall back to the next strategy once livez is forbidden",
+			strategies:     []HealthCheckStrategy{{Type: HealthCheckLivez}, {Type: HealthCheckList, Target: "v1/namespaces"}},
+			expectedStatus: metav1.ConditionTrue,
+		},
+		{
+			name:           "no fall back once readyz fails",
+			strategies:     []HealthCheckStrategy{{Type: HealthCheckReadyz}, {Type: HealthCheckList, Target: "v1/namespaces"}},
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "list times out",
+			strategies:     []HealthCheckStrategy{{Type: HealthCheckList, Target: "apps/v1/deployments", Timeout: 100 * time.Millisecond}},
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "url",
+			strategies:     []HealthCheckStrategy{{Type: HealthCheckURL, Target: apiServer.URL + "/lb/healthz"}},
+			expectedStatus: metav1.ConditionTrue,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := &managedClusterStatusController{
+				managedClusterDiscoveryClient: discovery.NewDiscoveryClientForConfigOrDie(&rest.Config{Host: apiServer.URL}),
+				healthCheckStrategies:         c.strategies,
+				httpClient:                    http.DefaultClient,
+			}
+			condition := ctrl.checkKubeAPIServerStatus(context.TODO())
+			if condition.Status != c.expectedStatus {
+				t.Errorf("expected status %q, but got %q: %s", c.expectedStatus, condition.Status, condition.Message)
+			}
+		})
+	}
+}
+
+func TestNewHealthCheckHTTPClient(t *testing.T) {
+	lb := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer lb.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: lb.Certificate().Raw})
+	if err := os.WriteFile(caFile, caData, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name           string
+		caFile         string
+		expectedStatus metav1.ConditionStatus
+	}{
+		{
+			name:           "lb is not trusted by the system cas",
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "lb is verified with the ca file",
+			caFile:         caFile,
+			expectedStatus: metav1.ConditionTrue,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			httpClient, err := NewHealthCheckHTTPClient(c.caFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctrl := &managedClusterStatusController{
+				healthCheckStrategies: []HealthCheckStrategy{{Type: HealthCheckURL, Target: lb.URL + "/healthz"}},
+				httpClient:            httpClient,
+			}
+			condition := ctrl.checkKubeAPIServerStatus(context.TODO())
+			if condition.Status != c.expectedStatus {
+				t.Errorf("expected status %q, but got %q: %s", c.expectedStatus, condition.Status, condition.Message)
+			}
+		})
+	}
+
+	if _, err := NewHealthCheckHTTPClient(filepath.Join(t.TempDir(), "missing.crt")); err == nil {
+		t.Errorf("expected an error with the missing ca file")
+	}
+}
@@ -0,0 +1,175 @@
+package managedcluster
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/health"
+	"open-cluster-management.io/registration/pkg/helpers"
+)
+
+// hubCABundleController watches the hub CA bundle published by the hub in the cluster namespace, and replaces the
+// certificate-authority-data of the hub kubeconfig in use with it, so that the agent is able to keep connecting to
+// the hub after the serving certificate of the hub apiserver is rotated, without bootstrapping again. The running hub
+// clients do not reload the CA, so onUpdated is called once the hub kubeconfig is updated to restart the agent.
+type hubCABundleController struct {
+	clusterName                  string
+	hubKubeconfigSecretNamespace string
+	hubKubeconfigSecretName      string
+	hubConfigMapLister           corev1listers.ConfigMapLister
+	managementCoreClient         corev1client.CoreV1Interface
+	onUpdated                    func()
+}
+
+// NewHubCABundleController returns a new hubCABundleController. The hub configmap informer is expected to watch
+// the cluster namespace on the hub.
+func NewHubCABundleController(
+	clusterName, hubKubeconfigSecretNamespace, hubKubeconfigSecretName string,
+	hubConfigMapInformer corev1informers.ConfigMapInformer,
+	managementCoreClient corev1client.CoreV1Interface,
+	onUpdated func(),
+	recorder events.Recorder) factory.Controller {
+	c := &hubCABundleController{
+		clusterName:                  clusterName,
+		hubKubeconfigSecretNamespace: hubKubeconfigSecretNamespace,
+		hubKubeconfigSecretName:      hubKubeconfigSecretName,
+		hubConfigMapLister:           hubConfigMapInformer.Lister(),
+		managementCoreClient:         managementCoreClient,
+		onUpdated:                    onUpdated,
+	}
+
+	return factory.New().
+		WithInformers(hubConfigMapInformer.Informer()).
+		WithSync(health.DefaultRegistry.TrackSync("HubCABundleController", c.sync)).
+		ResyncEvery(10*time.Minute).
+		ToController("HubCABundleController", recorder)
+}
+
+func (c *hubCABundleController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithValues("secret", c.hubKubeconfigSecretName)
+	logger.V(4).Info("Reconciling hub CA bundle of hub kubeconfig secret")
+
+	published, err := c.hubConfigMapLister.ConfigMaps(c.clusterName).Get(helpers.HubCABundleConfigMapName)
+	if errors.IsNotFound(err) {
+		// the hub does not publish the CA bundle
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	caBundle := []byte(published.Data[helpers.HubCABundleKey])
+	if len(caBundle) == 0 {
+		syncCtx.Recorder().Warningf("HubCABundleInvalid", "The hub CA bundle published by hub is empty")
+		return nil
+	}
+
+	secret, err := c.managementCoreClient.Secrets(c.hubKubeconfigSecretNamespace).Get(
+		ctx, c.hubKubeconfigSecretName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// the hub kubeconfig is not created until the agent is bootstrapped
+		logger.V(4).Info("Hub kubeconfig secret is not found")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	kubeconfigData, ok := secret.Data[clientcert.KubeconfigFile]
+	if !ok {
+		return nil
+	}
+	kubeconfig, err := clientcmd.Load(kubeconfigData)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, cluster := range kubeconfig.Clusters {
+		if bytes.Equal(cluster.CertificateAuthorityData, caBundle) {
+			continue
+		}
+		cluster.CertificateAuthority = ""
+		cluster.CertificateAuthorityData = caBundle
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	kubeconfigData, err = clientcmd.Write(*kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	secret = secret.DeepCopy()
+	secret.Data[clientcert.KubeconfigFile] = kubeconfigData
+	if _, err := c.managementCoreClient.Secrets(c.hubKubeconfigSecretNamespace).Update(
+		ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	syncCtx.Recorder().Eventf("HubCABundleUpdated",
+		"The CA bundle of hub kubeconfig secret %q is updated with the one published by hub", c.hubKubeconfigSecretName)
+	if c.onUpdated != nil {
+		c.onUpdated()
+	}
+	return nil
+}
+
+// mergeHubCABundle returns the additional secret data of the hub kubeconfig secret with the CA of the hub kubeconfig
+// in the current secret, so that the CA replaced by the hubCABundleController is not reverted once the client
+// certificate is renewed.
+func mergeHubCABundle(additionalSecretData, currentSecretData map[string][]byte) (map[string][]byte, error) {
+	currentKubeconfigData, ok := currentSecretData[clientcert.KubeconfigFile]
+	if !ok || len(additionalSecretData[clientcert.KubeconfigFile]) == 0 {
+		return additionalSecretData, nil
+	}
+	currentKubeconfig, err := clientcmd.Load(currentKubeconfigData)
+	if err != nil {
+		return nil, err
+	}
+	kubeconfig, err := clientcmd.Load(additionalSecretData[clientcert.KubeconfigFile])
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	for name, cluster := range kubeconfig.Clusters {
+		currentCluster, ok := currentKubeconfig.Clusters[name]
+		if !ok || currentCluster.Server != cluster.Server || len(currentCluster.CertificateAuthorityData) == 0 ||
+			bytes.Equal(currentCluster.CertificateAuthorityData, cluster.CertificateAuthorityData) {
+			continue
+		}
+		cluster.CertificateAuthority = ""
+		cluster.CertificateAuthorityData = currentCluster.CertificateAuthorityData
+		changed = true
+	}
+	if !changed {
+		return additionalSecretData, nil
+	}
+
+	kubeconfigData, err := clientcmd.Write(*kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	merged := map[string][]byte{}
+	for k, v := range additionalSecretData {
+		merged[k] = v
+	}
+	merged[clientcert.KubeconfigFile] = kubeconfigData
+	return merged, nil
+}
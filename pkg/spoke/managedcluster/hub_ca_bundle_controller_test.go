@@ -0,0 +1,211 @@
+package managedcluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSyncHubCABundle(t *testing.T) {
+	caBundle := "ca-bundle"
+	kubeconfig := testinghelpers.NewKubeconfig(nil, nil)
+
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cluster := range config.Clusters {
+		cluster.CertificateAuthorityData = []byte(caBundle)
+	}
+	rotatedKubeconfig, err := clientcmd.Write(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name            string
+		hubConfigMaps   []runtime.Object
+		spokeSecrets    []runtime.Object
+		expectedUpdated bool
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "no published CA bundle",
+			spokeSecrets:    []runtime.Object{newBootstrapKubeconfigSecret("testns", "hub-kubeconfig-secret", kubeconfig)},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "empty published CA bundle",
+			hubConfigMaps:   []runtime.Object{newHubCABundleConfigMap("")},
+			spokeSecrets:    []runtime.Object{newBootstrapKubeconfigSecret("testns", "hub-kubeconfig-secret", kubeconfig)},
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:          "no hub kubeconfig secret",
+			hubConfigMaps: []runtime.Object{newHubCABundleConfigMap(caBundle)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name:          "CA bundle is not changed",
+			hubConfigMaps: []runtime.Object{newHubCABundleConfigMap(caBundle)},
+			spokeSecrets:  []runtime.Object{newBootstrapKubeconfigSecret("testns", "hub-kubeconfig-secret", rotatedKubeconfig)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name:            "CA bundle is rotated",
+			hubConfigMaps:   []runtime.Object{newHubCABundleConfigMap(caBundle)},
+			spokeSecrets:    []runtime.Object{newBootstrapKubeconfigSecret("testns", "hub-kubeconfig-secret", kubeconfig)},
+			expectedUpdated: true,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "update")
+				secret := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+				updated, err := clientcmd.Load(secret.Data[clientcert.KubeconfigFile])
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, cluster := range updated.Clusters {
+					if string(cluster.CertificateAuthorityData) != caBundle {
+						t.Errorf("expected the CA bundle is updated, but got %q", cluster.CertificateAuthorityData)
+					}
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			hubKubeClient := kubefake.NewSimpleClientset(c.hubConfigMaps...)
+			informerFactory := kubeinformers.NewSharedInformerFactory(hubKubeClient, 10*time.Minute)
+			for _, configMap := range c.hubConfigMaps {
+				if err := informerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(configMap); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			updated := false
+			managementKubeClient := kubefake.NewSimpleClientset(c.spokeSecrets...)
+			ctrl := &hubCABundleController{
+				clusterName:                  testinghelpers.TestManagedClusterName,
+				hubKubeconfigSecretNamespace: "testns",
+				hubKubeconfigSecretName:      "hub-kubeconfig-secret",
+				hubConfigMapLister:           informerFactory.Core().V1().ConfigMaps().Lister(),
+				managementCoreClient:         managementKubeClient.CoreV1(),
+				onUpdated:                    func() { updated = true },
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, ""))
+			testinghelpers.AssertError(t, syncErr, "")
+
+			if updated != c.expectedUpdated {
+				t.Errorf("expected updated %v, but got %v", c.expectedUpdated, updated)
+			}
+			c.validateActions(t, managementKubeClient.Actions())
+		})
+	}
+}
+
+func newHubCABundleConfigMap(caBundle string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testinghelpers.TestManagedClusterName,
+			Name:      helpers.HubCABundleConfigMapName,
+		},
+		Data: map[string]string{helpers.HubCABundleKey: caBundle},
+	}
+}
+
+func TestMergeHubCABundle(t *testing.T) {
+	kubeconfig := testinghelpers.NewKubeconfig(nil, nil)
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cluster := range config.Clusters {
+		cluster.CertificateAuthorityData = []byte("ca-bundle")
+	}
+	rotatedKubeconfig, err := clientcmd.Write(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cluster := range config.Clusters {
+		cluster.Server = "https://other-hub:6443"
+	}
+	otherHubKubeconfig, err := clientcmd.Write(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name              string
+		currentSecretData map[string][]byte
+		expectedCABundle  string
+	}{
+		{
+			name: "no current secret",
+		},
+		{
+			name:              "the CA is not replaced",
+			currentSecretData: map[string][]byte{clientcert.KubeconfigFile: kubeconfig},
+		},
+		{
+			name:              "the CA is replaced",
+			currentSecretData: map[string][]byte{clientcert.KubeconfigFile: rotatedKubeconfig},
+			expectedCABundle:  "ca-bundle",
+		},
+		{
+			name:              "the current kubeconfig is of another hub",
+			currentSecretData: map[string][]byte{clientcert.KubeconfigFile: otherHubKubeconfig},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			additionalSecretData := map[string][]byte{
+				clientcert.ClusterNameFile: []byte(testinghelpers.TestManagedClusterName),
+				clientcert.KubeconfigFile:  kubeconfig,
+			}
+			merged, err := mergeHubCABundle(additionalSecretData, c.currentSecretData)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(merged[clientcert.ClusterNameFile]) != testinghelpers.TestManagedClusterName {
+				t.Errorf("unexpected cluster name %q", merged[clientcert.ClusterNameFile])
+			}
+
+			mergedConfig, err := clientcmd.Load(merged[clientcert.KubeconfigFile])
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, cluster := range mergedConfig.Clusters {
+				if len(c.expectedCABundle) == 0 {
+					if string(cluster.CertificateAuthorityData) == "ca-bundle" {
+						t.Errorf("unexpected CA bundle %q", cluster.CertificateAuthorityData)
+					}
+					continue
+				}
+				if string(cluster.CertificateAuthorityData) != c.expectedCABundle {
+					t.Errorf("expected CA bundle %q, but got %q", c.expectedCABundle, cluster.CertificateAuthorityData)
+				}
+			}
+			// the additional secret data are not changed
+			if string(additionalSecretData[clientcert.KubeconfigFile]) != string(kubeconfig) {
+				t.Errorf("the additional secret data are changed")
+			}
+		})
+	}
+}
@@ -3,12 +3,14 @@ package managedcluster
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/health"
 	"open-cluster-management.io/registration/pkg/helpers"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -16,6 +18,17 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// joiningBackoffInitial and joiningBackoffMax are the initial and the max intervals between the attempts to set
+	// the joined condition after it fails, the interval is doubled after each consecutive failure
+	joiningBackoffInitial = 5 * time.Second
+	joiningBackoffMax     = 5 * time.Minute
+	// joiningDegradedFailures is the number of the consecutive failures after which the controller is reported as
+	// degraded in the health registry
+	joiningDegradedFailures = 5
 )
 
 // managedClusterJoiningController add the joined condition to a ManagedCluster on the managed cluster after it is accepted by hub cluster admin.
@@ -23,6 +36,13 @@ type managedClusterJoiningController struct {
 	clusterName      string
 	hubClusterClient clientset.Interface
 	hubClusterLister clusterv1listers.ManagedClusterLister
+
+	// failures is the number of the consecutive failures to set the joined condition, and nextAttemptTime is the
+	// earliest time of the next attempt, lastErr is the error of the last failed attempt
+	failures        int
+	nextAttemptTime time.Time
+	lastErr         error
+	nowFunc         func() time.Time
 }
 
 // NewManagedClusterJoiningController creates a new managed cluster joining controller on the managed cluster.
@@ -35,18 +55,21 @@ func NewManagedClusterJoiningController(
 		clusterName:      clusterName,
 		hubClusterClient: hubClusterClient,
 		hubClusterLister: hubManagedClusterInformer.Lister(),
+		nowFunc:          time.Now,
 	}
 
 	return factory.New().
 		WithInformers(hubManagedClusterInformer.Informer()).
-		WithSync(c.sync).
+		WithSync(health.DefaultRegistry.TrackSync("ManagedClusterJoiningController", c.sync)).
 		ResyncEvery(5*time.Minute).
 		ToController("ManagedClusterJoiningController", recorder)
 }
 
 // sync maintains the managed cluster side status of a ManagedCluster, it maintains the ManagedClusterJoined condition according to
-// the value of the ManagedClusterHubAccepted condition.
-func (c managedClusterJoiningController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+// the value of the ManagedClusterHubAccepted condition. The failed attempts are retried with an exponential backoff, and
+// the condition is not patched if it is already set, e.g. after the agent restarts.
+func (c *managedClusterJoiningController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	logger := klog.FromContext(ctx)
 	managedCluster, err := c.hubClusterLister.Get(c.clusterName)
 	if err != nil {
 		return fmt.Errorf("unable to get managed cluster with name %q from hub: %w", c.clusterName, err)
@@ -61,9 +84,19 @@ func (c managedClusterJoiningController) sync(ctx context.Context, syncCtx facto
 	joined := meta.IsStatusConditionTrue(managedCluster.Status.Conditions, clusterv1.ManagedClusterConditionJoined)
 	if joined {
 		// current managed cluster is joined, do nothing.
+		c.failures = 0
+		c.nextAttemptTime = time.Time{}
+		c.lastErr = nil
 		return nil
 	}
 
+	// throttle the attempts with the backoff after the previous attempts failed
+	if wait := c.nextAttemptTime.Sub(c.nowFunc()); wait > 0 {
+		logger.V(4).Info("Wait to set the joined condition", "wait", wait, "failures", c.failures)
+		syncCtx.Queue().AddAfter(syncCtx.QueueKey(), wait)
+		return c.failuresError()
+	}
+
 	// current managed cluster did not join the hub cluster, join it.
 	_, updated, err := helpers.UpdateManagedClusterStatus(
 		ctx,
@@ -77,10 +110,45 @@ func (c managedClusterJoiningController) sync(ctx context.Context, syncCtx facto
 		}),
 	)
 	if err != nil {
-		return fmt.Errorf("unable to update status of managed cluster %q: %w", c.clusterName, err)
+		c.failures++
+		c.lastErr = err
+		interval := joiningBackoff(c.failures)
+		c.nextAttemptTime = c.nowFunc().Add(interval)
+		logger.Error(err, "Failed to set the joined condition", "failures", c.failures, "retryAfter", interval)
+		syncCtx.Queue().AddAfter(syncCtx.QueueKey(), interval)
+		if c.failures == joiningDegradedFailures {
+			syncCtx.Recorder().Warningf("ManagedClusterJoinFailed",
+				"Failed to set the joined condition of managed cluster %q %d times: %v", c.clusterName, c.failures, err)
+		}
+		return c.failuresError()
 	}
+	c.failures = 0
+	c.nextAttemptTime = time.Time{}
+	c.lastErr = nil
 	if updated {
 		syncCtx.Recorder().Eventf("ManagedClusterJoined", "Managed cluster %q joined hub", c.clusterName)
 	}
 	return nil
 }
+
+// failuresError returns an error if the consecutive failures to set the joined condition reach joiningDegradedFailures,
+// the error is recorded in the health registry so the agent is reported as degraded. It wraps the error of the last
+// attempt, so the failures caused by the hub connectivity are not reported as stuck by the liveness probe.
+func (c *managedClusterJoiningController) failuresError() error {
+	if c.failures < joiningDegradedFailures {
+		return nil
+	}
+	return fmt.Errorf("failed to set the joined condition of managed cluster %q %d times: %w", c.clusterName, c.failures, c.lastErr)
+}
+
+// joiningBackoff returns the interval before the next attempt after the given number of consecutive failures
+func joiningBackoff(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	interval := float64(joiningBackoffInitial) * math.Pow(2, float64(failures-1))
+	if interval > float64(joiningBackoffMax) {
+		return joiningBackoffMax
+	}
+	return time.Duration(interval)
+}
@@ -2,7 +2,6 @@ package managedcluster
 
 import (
 	"context"
-	"encoding/json"
 	"testing"
 	"time"
 
@@ -11,6 +10,7 @@ import (
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clienttesting "k8s.io/client-go/testing"
@@ -45,15 +45,14 @@ func TestSyncManagedCluster(t *testing.T) {
 					Message: "Managed cluster joined",
 				}
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
-				managedCluster := &clusterv1.ManagedCluster{}
-				err := json.Unmarshal(patch, managedCluster)
-				if err != nil {
-					t.Fatal(err)
-				}
-				testinghelpers.AssertCondition(t, managedCluster.Status.Conditions, expectedCondition)
+				testinghelpers.AssertConditionPatched(t, actions[1], expectedCondition)
 			},
 		},
+		{
+			name:            "sync a joined managed cluster",
+			startingObjects: []runtime.Object{testinghelpers.NewJoinedManagedCluster()},
+			validateActions: testinghelpers.AssertNoActions,
+		},
 	}
 
 	for _, c := range cases {
@@ -67,10 +66,11 @@ func TestSyncManagedCluster(t *testing.T) {
 				}
 			}
 
-			ctrl := managedClusterJoiningController{
+			ctrl := &managedClusterJoiningController{
 				clusterName:      testinghelpers.TestManagedClusterName,
 				hubClusterClient: clusterClient,
 				hubClusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				nowFunc:          time.Now,
 			}
 
 			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, ""))
@@ -80,3 +80,84 @@ func TestSyncManagedCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncManagedClusterWithBackoff(t *testing.T) {
+	managedCluster := testinghelpers.NewAcceptedManagedCluster()
+	clusterClient := clusterfake.NewSimpleClientset(managedCluster)
+	rejected := true
+	clusterClient.PrependReactor("patch", "managedclusters", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if !rejected {
+			return false, nil, nil
+		}
+		return true, &clusterv1.ManagedCluster{}, apierrors.NewBadRequest("admission webhook denied the request")
+	})
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(managedCluster); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	ctrl := &managedClusterJoiningController{
+		clusterName:      testinghelpers.TestManagedClusterName,
+		hubClusterClient: clusterClient,
+		hubClusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		nowFunc:          func() time.Time { return now },
+	}
+	syncCtx := testinghelpers.NewFakeSyncContext(t, "")
+
+	// the failures are retried after the backoff, the controller is degraded after joiningDegradedFailures failures
+	for i := 1; i <= joiningDegradedFailures; i++ {
+		clusterClient.ClearActions()
+		err := ctrl.sync(context.TODO(), syncCtx)
+		if i < joiningDegradedFailures && err != nil {
+			t.Errorf("expected no error after %d failures, but got %v", i, err)
+		}
+		if i == joiningDegradedFailures && err == nil {
+			t.Errorf("expected an error after %d failures, but got nil", i)
+		}
+		testinghelpers.AssertActions(t, clusterClient.Actions(), "get", "patch")
+		if ctrl.failures != i {
+			t.Errorf("expected %d failures, but got %d", i, ctrl.failures)
+		}
+
+		// the condition is not patched within the backoff
+		clusterClient.ClearActions()
+		now = now.Add(joiningBackoff(i) - time.Second)
+		err = ctrl.sync(context.TODO(), syncCtx)
+		if (err != nil) != (i >= joiningDegradedFailures) {
+			t.Errorf("unexpected error within the backoff after %d failures: %v", i, err)
+		}
+		testinghelpers.AssertNoActions(t, clusterClient.Actions())
+		now = now.Add(time.Second)
+	}
+
+	// the failures are reset once the condition is set
+	rejected = false
+	clusterClient.ClearActions()
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	testinghelpers.AssertActions(t, clusterClient.Actions(), "get", "patch")
+	if ctrl.failures != 0 || !ctrl.nextAttemptTime.IsZero() {
+		t.Errorf("expected the failures are reset, but got %d failures", ctrl.failures)
+	}
+}
+
+func TestJoiningBackoff(t *testing.T) {
+	cases := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 0, expected: 0},
+		{failures: 1, expected: 5 * time.Second},
+		{failures: 2, expected: 10 * time.Second},
+		{failures: 4, expected: 40 * time.Second},
+		{failures: 7, expected: 5 * time.Minute},
+		{failures: 100, expected: 5 * time.Minute},
+	}
+	for _, c := range cases {
+		if actual := joiningBackoff(c.failures); actual != c.expected {
+			t.Errorf("expected %v after %d failures, but got %v", c.expected, c.failures, actual)
+		}
+	}
+}
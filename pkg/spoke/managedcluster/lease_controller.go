@@ -9,6 +9,8 @@ import (
 	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/health"
+	"open-cluster-management.io/registration/pkg/transport"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -18,10 +20,30 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/utils/clock"
 )
 
 const leaseUpdateJitterFactor = 0.25
 
+// leaseRenewalDuration is the latency of the renewals of the lease of the managed cluster, a slow or throttled hub
+// shows up as the renewals approaching the lease duration
+var leaseRenewalDuration = metrics.NewHistogramVec(
+	&metrics.HistogramOpts{
+		Subsystem:      "registration_agent",
+		Name:           "lease_renewal_duration_seconds",
+		Help:           "The latency of the renewals of the lease of the managed cluster on the hub, by the result of the renewal.",
+		Buckets:        []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"result"},
+)
+
+func init() {
+	legacyregistry.MustRegister(leaseRenewalDuration)
+}
+
 // managedClusterLeaseController periodically updates the lease of a managed cluster on hub cluster to keep the heartbeat of a managed cluster.
 type managedClusterLeaseController struct {
 	clusterName              string
@@ -30,11 +52,16 @@ type managedClusterLeaseController struct {
 	leaseUpdater             *leaseUpdater
 }
 
-// NewManagedClusterLeaseController creates a new managed cluster lease controller on the managed cluster.
+// NewManagedClusterLeaseController creates a new managed cluster lease controller on the managed cluster. If the
+// publisher is not nil, the heartbeats are published with it instead of updating the lease on the hub. The hubClient
+// is expected to be dedicated to the lease renewals, so they are not throttled by the requests of the other
+// controllers. The lease is renewed with the time of the clock.
 func NewManagedClusterLeaseController(
-	clusterName string,
+	clusterName, agentName string,
 	hubClient clientset.Interface,
 	hubClusterInformer clusterv1informer.ManagedClusterInformer,
+	publisher transport.Publisher,
+	clock clock.Clock,
 	recorder events.Recorder) factory.Controller {
 	c := &managedClusterLeaseController{
 		clusterName:      clusterName,
@@ -42,14 +69,17 @@ func NewManagedClusterLeaseController(
 		leaseUpdater: &leaseUpdater{
 			hubClient:   hubClient,
 			clusterName: clusterName,
+			agentName:   agentName,
 			leaseName:   "managed-cluster-lease",
+			publisher:   publisher,
+			clock:       clock,
 			recorder:    recorder,
 		},
 	}
 
 	return factory.New().
 		WithInformers(hubClusterInformer.Informer()).
-		WithSync(c.sync).
+		WithSync(health.DefaultRegistry.TrackSync("ManagedClusterLeaseController", c.sync)).
 		ToController("ManagedClusterLeaseController", recorder)
 }
 
@@ -90,10 +120,15 @@ func (c *managedClusterLeaseController) sync(ctx context.Context, syncCtx factor
 type leaseUpdater struct {
 	hubClient   clientset.Interface
 	clusterName string
+	agentName   string
 	leaseName   string
-	lock        sync.Mutex
-	cancel      context.CancelFunc
-	recorder    events.Recorder
+	publisher   transport.Publisher
+	// clock is the clock the lease is renewed with and the renewals are scheduled on, so the tests step through
+	// the renewals with a fake clock
+	clock    clock.Clock
+	lock     sync.Mutex
+	cancel   context.CancelFunc
+	recorder events.Recorder
 }
 
 // start a lease update routine to update the lease of a managed cluster periodically.
@@ -107,7 +142,9 @@ func (u *leaseUpdater) start(ctx context.Context, leaseDuration time.Duration) {
 
 	var updateCtx context.Context
 	updateCtx, u.cancel = context.WithCancel(ctx)
-	go wait.JitterUntilWithContext(updateCtx, u.update, leaseDuration, leaseUpdateJitterFactor, true)
+	go wait.BackoffUntil(func() {
+		u.update(updateCtx)
+	}, wait.NewJitteredBackoffManager(leaseDuration, leaseUpdateJitterFactor, u.clock), true, updateCtx.Done())
 	u.recorder.Eventf("ManagedClusterLeaseUpdateStarted", "Start to update lease %q on cluster %q", u.leaseName, u.clusterName)
 }
 
@@ -126,14 +163,33 @@ func (u *leaseUpdater) stop() {
 
 // update the lease of a given managed cluster.
 func (u *leaseUpdater) update(ctx context.Context) {
+	start := u.clock.Now()
+	result := "success"
+	if err := u.renew(ctx); err != nil {
+		utilruntime.HandleError(err)
+		result = "error"
+	}
+	leaseRenewalDuration.WithLabelValues(result).Observe(u.clock.Since(start).Seconds())
+}
+
+// renew publishes a heartbeat or updates the lease of the managed cluster on the hub
+func (u *leaseUpdater) renew(ctx context.Context) error {
+	if u.publisher != nil {
+		heartbeat := transport.Heartbeat{ClusterName: u.clusterName, RenewTime: metav1.NewTime(u.clock.Now())}
+		if err := transport.PublishJSON(ctx, u.publisher, transport.HeartbeatTopic(u.clusterName, u.agentName), heartbeat); err != nil {
+			return fmt.Errorf("unable to publish the heartbeat of cluster %q: %w", u.clusterName, err)
+		}
+		return nil
+	}
+
 	lease, err := u.hubClient.CoordinationV1().Leases(u.clusterName).Get(ctx, u.leaseName, metav1.GetOptions{})
 	if err != nil {
-		utilruntime.HandleError(fmt.Errorf("unable to get cluster lease %q on hub cluster: %w", u.leaseName, err))
-		return
+		return fmt.Errorf("unable to get cluster lease %q on hub cluster: %w", u.leaseName, err)
 	}
 
-	lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now()}
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: u.clock.Now()}
 	if _, err = u.hubClient.CoordinationV1().Leases(u.clusterName).Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
-		utilruntime.HandleError(fmt.Errorf("unable to update cluster lease %q on hub cluster: %w", u.leaseName, err))
+		return fmt.Errorf("unable to update cluster lease %q on hub cluster: %w", u.leaseName, err)
 	}
+	return nil
 }
@@ -2,19 +2,26 @@ package managedcluster
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
 	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+	"open-cluster-management.io/registration/pkg/transport"
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 
 	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
 )
 
 func TestLeaseUpdate(t *testing.T) {
@@ -62,18 +69,39 @@ func TestLeaseUpdate(t *testing.T) {
 			}
 
 			hubClient := kubefake.NewSimpleClientset(testinghelpers.NewManagedClusterLease("managed-cluster-lease", time.Now()))
+			fakeClock := clocktesting.NewFakeClock(time.Now())
+			leaseDuration := time.Duration(testinghelpers.TestLeaseDurationSeconds) * time.Second
 
 			leaseUpdater := &leaseUpdater{
 				hubClient:   hubClient,
 				clusterName: testinghelpers.TestManagedClusterName,
 				leaseName:   "managed-cluster-lease",
+				clock:       fakeClock,
 				recorder:    eventstesting.NewTestingEventRecorder(t),
 			}
 
+			// waitForRenewals waits until the lease is updated the number of times and the lease update routine
+			// waits for the next renewal on the clock, or until the routine is stopped if renewals is 0
+			waitForRenewals := func(renewals int) {
+				if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+					if renewals == 0 {
+						return !fakeClock.HasWaiters(), nil
+					}
+					updates := 0
+					for _, action := range hubClient.Actions() {
+						if action.GetVerb() == "update" {
+							updates++
+						}
+					}
+					return updates >= renewals && fakeClock.HasWaiters(), nil
+				}); err != nil {
+					t.Fatalf("timeout waiting for %d lease renewals: %v", renewals, err)
+				}
+			}
+
 			if c.needToStartUpdateBefore {
-				leaseUpdater.start(context.TODO(), time.Duration(testinghelpers.TestLeaseDurationSeconds)*time.Second)
-				// wait a few milliseconds to start the lease update routine
-				time.Sleep(200 * time.Millisecond)
+				leaseUpdater.start(context.TODO(), leaseDuration)
+				waitForRenewals(1)
 			}
 
 			ctrl := &managedClusterLeaseController{
@@ -84,9 +112,120 @@ func TestLeaseUpdate(t *testing.T) {
 			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, ""))
 			testinghelpers.AssertError(t, syncErr, c.expectedErr)
 
-			// wait one cycle
-			time.Sleep(1200 * time.Millisecond)
+			// step the clock over one renewal cycle including the jitter
+			if c.needToStartUpdateBefore {
+				waitForRenewals(0)
+				fakeClock.Step(2 * leaseDuration)
+			} else {
+				waitForRenewals(1)
+				fakeClock.Step(2 * leaseDuration)
+				waitForRenewals(2)
+			}
 			c.validateActions(t, hubClient.Actions())
 		})
 	}
 }
+
+type fakePublisher struct {
+	topics   []string
+	payloads [][]byte
+}
+
+func (p *fakePublisher) Publish(_ context.Context, topic string, payload []byte) error {
+	p.topics = append(p.topics, topic)
+	p.payloads = append(p.payloads, payload)
+	return nil
+}
+
+func TestLeaseUpdateWithPublisher(t *testing.T) {
+	hubClient := kubefake.NewSimpleClientset(testinghelpers.NewManagedClusterLease("managed-cluster-lease", time.Now()))
+	publisher := &fakePublisher{}
+	leaseUpdater := &leaseUpdater{
+		hubClient:   hubClient,
+		clusterName: testinghelpers.TestManagedClusterName,
+		agentName:   "agent1",
+		leaseName:   "managed-cluster-lease",
+		publisher:   publisher,
+		clock:       clocktesting.NewFakeClock(time.Now()),
+		recorder:    eventstesting.NewTestingEventRecorder(t),
+	}
+	leaseUpdater.update(context.TODO())
+
+	testinghelpers.AssertNoActions(t, hubClient.Actions())
+	if len(publisher.topics) != 1 || publisher.topics[0] != transport.HeartbeatTopic(testinghelpers.TestManagedClusterName, "agent1") {
+		t.Fatalf("unexpected published topics %v", publisher.topics)
+	}
+	heartbeat := transport.Heartbeat{}
+	if err := json.Unmarshal(publisher.payloads[0], &heartbeat); err != nil {
+		t.Fatal(err)
+	}
+	if heartbeat.ClusterName != testinghelpers.TestManagedClusterName {
+		t.Errorf("unexpected cluster name %q in the heartbeat", heartbeat.ClusterName)
+	}
+}
+
+func TestLeaseRenewalDuration(t *testing.T) {
+	leaseRenewalDuration.Reset()
+
+	hubClient := kubefake.NewSimpleClientset(testinghelpers.NewManagedClusterLease("managed-cluster-lease", time.Now()))
+	leaseUpdater := &leaseUpdater{
+		hubClient:   hubClient,
+		clusterName: testinghelpers.TestManagedClusterName,
+		leaseName:   "managed-cluster-lease",
+		clock:       clock.RealClock{},
+		recorder:    eventstesting.NewTestingEventRecorder(t),
+	}
+	leaseUpdater.update(context.TODO())
+
+	// the renewal of a missing lease fails
+	leaseUpdater.leaseName = "missing-lease"
+	leaseUpdater.update(context.TODO())
+	leaseUpdater.update(context.TODO())
+
+	for result, expected := range map[string]uint64{"success": 1, "error": 2} {
+		count, err := testutil.GetHistogramMetricCount(leaseRenewalDuration.WithLabelValues(result))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != expected {
+			t.Errorf("expected %d %s renewals, but got %d", expected, result, count)
+		}
+	}
+}
+
+func TestLeaseUpdateWithNetworkPartition(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	hubClient := kubefake.NewSimpleClientset(
+		testinghelpers.NewStarvedManagedClusterLease("managed-cluster-lease", fakeClock, 10))
+	partition := testinghelpers.NewNetworkPartition(&hubClient.Fake)
+	leaseUpdater := &leaseUpdater{
+		hubClient:   hubClient,
+		clusterName: testinghelpers.TestManagedClusterName,
+		leaseName:   "managed-cluster-lease",
+		clock:       fakeClock,
+		recorder:    eventstesting.NewTestingEventRecorder(t),
+	}
+
+	// the lease is not renewed while the hub is unreachable
+	partition.Partition("leases")
+	leaseUpdater.update(context.TODO())
+	if partition.Rejected() != 1 {
+		t.Errorf("expected 1 rejected request, but got %d", partition.Rejected())
+	}
+	partition.Heal()
+	assertRenewTime := func(renewed bool) {
+		lease, err := hubClient.CoordinationV1().Leases(testinghelpers.TestManagedClusterName).Get(
+			context.TODO(), "managed-cluster-lease", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lease.Spec.RenewTime.Time.Equal(fakeClock.Now()) != renewed {
+			t.Errorf("expected the lease renewed %v, but got renew time %v", renewed, lease.Spec.RenewTime)
+		}
+	}
+	assertRenewTime(false)
+
+	// the lease is renewed with the time of the clock once the partition is healed
+	leaseUpdater.update(context.TODO())
+	assertRenewTime(true)
+}
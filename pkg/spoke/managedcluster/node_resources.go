@@ -0,0 +1,204 @@
+package managedcluster
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strings"
+	"time"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// nodeRoleLabelPrefix is the prefix of the node role labels, e.g. node-role.kubernetes.io/worker
+const nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+
+// ClusterResourceOptions configures how the capacity and allocatable of the managed cluster are aggregated from
+// the nodes. The zero value aggregates all of the nodes.
+type ClusterResourceOptions struct {
+	// NodeSelector selects the nodes to aggregate, all of the nodes are aggregated if it is nil
+	NodeSelector labels.Selector
+	// ExcludedNodeTaints are the keys of the taints, the nodes with any of them are not aggregated
+	ExcludedNodeTaints []string
+	// ExcludeNotReadyNodes excludes the nodes whose Ready condition is not true
+	ExcludeNotReadyNodes bool
+	// ExcludeCordonedNodes excludes the unschedulable nodes from both the capacity and allocatable, otherwise
+	// they are only excluded from the allocatable
+	ExcludeCordonedNodes bool
+	// RoleBreakdown adds the capacity and allocatable of the nodes of each role as extended resources
+	RoleBreakdown bool
+	// PlatformBreakdown adds the capacity and allocatable of the nodes of each architecture and OS as extended
+	// resources, so the workloads of the mixed-architecture clusters can be placed by the platforms
+	PlatformBreakdown bool
+	// ExtendedResources are the patterns of the extended resources with a domain to aggregate, e.g. nvidia.com/gpu
+	// or amd.com/*. All of the extended resources are aggregated if it is empty. The resources without a domain,
+	// e.g. cpu, memory and ephemeral-storage, are always aggregated.
+	ExtendedResources []string
+	// MinUpdateInterval is the min interval between the updates of the capacity and allocatable, the changes in
+	// the interval are delayed to cut the writes caused by the node churn. It is not throttled if it is zero.
+	MinUpdateInterval time.Duration
+	// UpdateThresholdPercent is the percentage of the change of a resource, the change larger than it is updated
+	// immediately even in the min update interval.
+	UpdateThresholdPercent float64
+	// NodeSummary reports the counts of all of the nodes by the OS, architecture and kubelet minor version with the
+	// cluster claims prefixed with NodeSummaryClaimPrefix
+	NodeSummary bool
+}
+
+// ValidateExtendedResourcePatterns verifies the patterns of the extended resources
+func ValidateExtendedResourcePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("extended resource pattern %q is invalid: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// significantlyChanged returns true if any resource is added or removed, or changed by more than the update
+// threshold.
+func (o ClusterResourceOptions) significantlyChanged(oldList, newList clusterv1.ResourceList) bool {
+	if o.UpdateThresholdPercent <= 0 {
+		return false
+	}
+	for name := range oldList {
+		if _, ok := newList[name]; !ok {
+			return true
+		}
+	}
+	for name, newValue := range newList {
+		oldValue, ok := oldList[name]
+		if !ok {
+			return true
+		}
+		oldFloat, newFloat := oldValue.AsApproximateFloat64(), newValue.AsApproximateFloat64()
+		if oldFloat == 0 {
+			if newFloat != 0 {
+				return true
+			}
+			continue
+		}
+		if math.Abs(newFloat-oldFloat)/oldFloat*100 > o.UpdateThresholdPercent {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceIncluded returns true if the node resource is aggregated
+func (o ClusterResourceOptions) resourceIncluded(name corev1.ResourceName) bool {
+	if len(o.ExtendedResources) == 0 || !strings.Contains(string(name), "/") {
+		return true
+	}
+	for _, pattern := range o.ExtendedResources {
+		if matched, _ := path.Match(pattern, string(name)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// included returns true if the capacity and allocatable of the node are aggregated
+func (o ClusterResourceOptions) included(node *corev1.Node) bool {
+	if o.NodeSelector != nil && !o.NodeSelector.Matches(labels.Set(node.Labels)) {
+		return false
+	}
+	if o.ExcludeCordonedNodes && node.Spec.Unschedulable {
+		return false
+	}
+	for _, taint := range node.Spec.Taints {
+		for _, key := range o.ExcludedNodeTaints {
+			if taint.Key == key {
+				return false
+			}
+		}
+	}
+	if o.ExcludeNotReadyNodes && !isNodeReady(node) {
+		return false
+	}
+	return true
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// nodeRoles returns the roles of the node from its node role labels
+func nodeRoles(node *corev1.Node) []string {
+	roles := []string{}
+	for key := range node.Labels {
+		if role := strings.TrimPrefix(key, nodeRoleLabelPrefix); role != key && len(role) > 0 {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// breakdownGroups returns the groups the resources of the node are broken down by, they are the node roles,
+// architecture and OS prefixed with the breakdown resource prefixes
+func (o ClusterResourceOptions) breakdownGroups(node *corev1.Node) []string {
+	groups := []string{}
+	if o.RoleBreakdown {
+		for _, role := range nodeRoles(node) {
+			groups = append(groups, helpers.NodeRoleResourcePrefix+role)
+		}
+	}
+	if o.PlatformBreakdown {
+		if arch := nodePlatform(node, corev1.LabelArchStable, node.Status.NodeInfo.Architecture); len(arch) > 0 {
+			groups = append(groups, helpers.NodeArchResourcePrefix+arch)
+		}
+		if os := nodePlatform(node, corev1.LabelOSStable, node.Status.NodeInfo.OperatingSystem); len(os) > 0 {
+			groups = append(groups, helpers.NodeOSResourcePrefix+os)
+		}
+	}
+	return groups
+}
+
+// nodePlatform returns the value of the well-known platform label of the node, or the value reported in the node
+// info if the label is not set
+func nodePlatform(node *corev1.Node, label, nodeInfoValue string) string {
+	if value := node.Labels[label]; len(value) > 0 {
+		return value
+	}
+	return nodeInfoValue
+}
+
+// addResources adds the included node resources to the resource list, the resources are broken down by the groups
+// if the groups are specified. Only the resources without a domain, e.g. cpu and memory, are broken down.
+func (o ClusterResourceOptions) addResources(list map[clusterv1.ResourceName]resource.Quantity,
+	resources corev1.ResourceList, groups ...string) {
+	for key, value := range resources {
+		if !o.resourceIncluded(key) {
+			continue
+		}
+		if len(groups) == 0 {
+			addQuantity(list, clusterv1.ResourceName(key), value)
+			continue
+		}
+		if strings.Contains(string(key), "/") {
+			continue
+		}
+		for _, group := range groups {
+			addQuantity(list, clusterv1.ResourceName(group+"."+string(key)), value)
+		}
+	}
+}
+
+func addQuantity(list map[clusterv1.ResourceName]resource.Quantity, name clusterv1.ResourceName, value resource.Quantity) {
+	if existing, exist := list[name]; exist {
+		existing.Add(value)
+		list[name] = existing
+		return
+	}
+	list[name] = value.DeepCopy()
+}
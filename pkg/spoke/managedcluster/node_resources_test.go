@@ -0,0 +1,314 @@
+package managedcluster
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestNode(name string, roles []string, ready, unschedulable bool, taints ...string) *corev1.Node {
+	node := testinghelpers.NewNode(name, testinghelpers.NewResourceList(4, 8), testinghelpers.NewResourceList(2, 4))
+	node.Labels = map[string]string{}
+	for _, role := range roles {
+		node.Labels[nodeRoleLabelPrefix+role] = ""
+	}
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}
+	node.Spec.Unschedulable = unschedulable
+	for _, key := range taints {
+		node.Spec.Taints = append(node.Spec.Taints, corev1.Taint{Key: key, Effect: corev1.TaintEffectNoSchedule})
+	}
+	return node
+}
+
+func TestGetClusterResources(t *testing.T) {
+	nodes := []*corev1.Node{
+		newTestNode("master", []string{"control-plane", "master"}, true, false, "node-role.kubernetes.io/master"),
+		newTestNode("worker1", []string{"worker"}, true, false),
+		newTestNode("worker2", []string{"worker"}, false, false),
+		newTestNode("worker3", []string{"worker"}, true, true),
+	}
+	// worker1 is a windows arm64 node reporting its architecture in the node info only, the others are linux amd64
+	for _, node := range nodes {
+		node.Labels[corev1.LabelArchStable] = "amd64"
+		node.Labels[corev1.LabelOSStable] = "linux"
+	}
+	delete(nodes[1].Labels, corev1.LabelArchStable)
+	nodes[1].Status.NodeInfo.Architecture = "arm64"
+	nodes[1].Labels[corev1.LabelOSStable] = "windows"
+
+	cases := []struct {
+		name                string
+		options             ClusterResourceOptions
+		expectedCapacity    map[clusterv1.ResourceName]int64
+		expectedAllocatable map[clusterv1.ResourceName]int64
+	}{
+		{
+			name:                "all nodes",
+			expectedCapacity:    map[clusterv1.ResourceName]int64{clusterv1.ResourceCPU: 16},
+			expectedAllocatable: map[clusterv1.ResourceName]int64{clusterv1.ResourceCPU: 6},
+		},
+		{
+			name:                "exclude control plane nodes",
+			options:             ClusterResourceOptions{NodeSelector: labels.SelectorFromSet(labels.Set{nodeRoleLabelPrefix + "worker": ""})},
+			expectedCapacity:    map[clusterv1.ResourceName]int64{clusterv1.ResourceCPU: 12},
+			expectedAllocatable: map[clusterv1.ResourceName]int64{clusterv1.ResourceCPU: 4},
+		},
+		{
+			name:                "exclude tainted nodes",
+			options:             ClusterResourceOptions{ExcludedNodeTaints: []string{"node-role.kubernetes.io/master"}},
+			expectedCapacity:    map[clusterv1.ResourceName]int64{clusterv1.ResourceCPU: 12},
+			expectedAllocatable: map[clusterv1.ResourceName]int64{clusterv1.ResourceCPU: 4},
+		},
+		{
+			name:                "exclude not ready and cordoned nodes",
+			options:             ClusterResourceOptions{ExcludeNotReadyNodes: true, ExcludeCordonedNodes: true},
+			expectedCapacity:    map[clusterv1.ResourceName]int64{clusterv1.ResourceCPU: 8},
+			expectedAllocatable: map[clusterv1.ResourceName]int64{clusterv1.ResourceCPU: 4},
+		},
+		{
+			name:    "role breakdown",
+			options: ClusterResourceOptions{RoleBreakdown: true},
+			expectedCapacity: map[clusterv1.ResourceName]int64{
+				clusterv1.ResourceCPU:                                16,
+				helpers.NodeRoleResourcePrefix + "control-plane.cpu": 4,
+				helpers.NodeRoleResourcePrefix + "master.cpu":        4,
+				helpers.NodeRoleResourcePrefix + "worker.cpu":        12,
+			},
+			expectedAllocatable: map[clusterv1.ResourceName]int64{
+				clusterv1.ResourceCPU:                                6,
+				helpers.NodeRoleResourcePrefix + "control-plane.cpu": 2,
+				helpers.NodeRoleResourcePrefix + "master.cpu":        2,
+				helpers.NodeRoleResourcePrefix + "worker.cpu":        4,
+			},
+		},
+		{
+			name:    "platform breakdown",
+			options: ClusterResourceOptions{PlatformBreakdown: true},
+			expectedCapacity: map[clusterv1.ResourceName]int64{
+				clusterv1.ResourceCPU:                        16,
+				helpers.NodeArchResourcePrefix + "amd64.cpu": 12,
+				helpers.NodeArchResourcePrefix + "arm64.cpu": 4,
+				helpers.NodeOSResourcePrefix + "linux.cpu":   12,
+				helpers.NodeOSResourcePrefix + "windows.cpu": 4,
+			},
+			expectedAllocatable: map[clusterv1.ResourceName]int64{
+				clusterv1.ResourceCPU:                        6,
+				helpers.NodeArchResourcePrefix + "amd64.cpu": 4,
+				helpers.NodeArchResourcePrefix + "arm64.cpu": 2,
+				helpers.NodeOSResourcePrefix + "linux.cpu":   4,
+				helpers.NodeOSResourcePrefix + "windows.cpu": 2,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+			nodeStore := kubeInformerFactory.Core().V1().Nodes().Informer().GetStore()
+			for _, node := range nodes {
+				if err := nodeStore.Add(node); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &managedClusterStatusController{
+				nodeLister:      kubeInformerFactory.Core().V1().Nodes().Lister(),
+				resourceOptions: c.options,
+			}
+			capacity, allocatable, err := ctrl.getClusterResources()
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			assertResources(t, capacity, c.expectedCapacity)
+			assertResources(t, allocatable, c.expectedAllocatable)
+		})
+	}
+}
+
+// assertResources verifies the cpu resources, the memory is aggregated in the same way
+func assertResources(t *testing.T, actual clusterv1.ResourceList, expected map[clusterv1.ResourceName]int64) {
+	for name, value := range expected {
+		quantity, ok := actual[name]
+		if !ok {
+			t.Errorf("expected resource %q, but got %v", name, actual)
+			continue
+		}
+		if !quantity.Equal(*resource.NewQuantity(value, resource.DecimalExponent)) {
+			t.Errorf("expected %d of resource %q, but got %s", value, name, quantity.String())
+		}
+	}
+	for name := range actual {
+		if strings.HasSuffix(string(name), string(clusterv1.ResourceMemory)) {
+			continue
+		}
+		if _, ok := expected[name]; !ok {
+			t.Errorf("unexpected resource %q", name)
+		}
+	}
+}
+
+func TestExtendedResources(t *testing.T) {
+	node := testinghelpers.NewNode("gpu-node", corev1.ResourceList{
+		corev1.ResourceCPU:              *resource.NewQuantity(4, resource.DecimalExponent),
+		corev1.ResourceEphemeralStorage: *resource.NewQuantity(100, resource.DecimalExponent),
+		"nvidia.com/gpu":                *resource.NewQuantity(2, resource.DecimalExponent),
+		"amd.com/gpu":                   *resource.NewQuantity(1, resource.DecimalExponent),
+		"example.com/dongle":            *resource.NewQuantity(3, resource.DecimalExponent),
+	}, nil)
+
+	cases := []struct {
+		name              string
+		extendedResources []string
+		expectedCapacity  map[clusterv1.ResourceName]int64
+	}{
+		{
+			name: "all extended resources",
+			expectedCapacity: map[clusterv1.ResourceName]int64{
+				clusterv1.ResourceCPU: 4, "ephemeral-storage": 100, "nvidia.com/gpu": 2, "amd.com/gpu": 1, "example.com/dongle": 3,
+			},
+		},
+		{
+			name:              "allowed extended resources",
+			extendedResources: []string{"nvidia.com/gpu", "amd.com/*"},
+			expectedCapacity: map[clusterv1.ResourceName]int64{
+				clusterv1.ResourceCPU: 4, "ephemeral-storage": 100, "nvidia.com/gpu": 2, "amd.com/gpu": 1,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+			if err := kubeInformerFactory.Core().V1().Nodes().Informer().GetStore().Add(node); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := &managedClusterStatusController{
+				nodeLister:      kubeInformerFactory.Core().V1().Nodes().Lister(),
+				resourceOptions: ClusterResourceOptions{ExtendedResources: c.extendedResources},
+			}
+			capacity, _, err := ctrl.getClusterResources()
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			assertResources(t, capacity, c.expectedCapacity)
+		})
+	}
+}
+
+func TestResourcesOfExcludedNodes(t *testing.T) {
+	gpuNode := testinghelpers.NewNode("gpu-node", corev1.ResourceList{
+		corev1.ResourceCPU: *resource.NewQuantity(4, resource.DecimalExponent),
+		"nvidia.com/gpu":   *resource.NewQuantity(2, resource.DecimalExponent),
+	}, nil)
+	workerNode := newTestNode("worker", []string{"worker"}, true, false)
+
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+	for _, node := range []*corev1.Node{gpuNode, workerNode} {
+		if err := kubeInformerFactory.Core().V1().Nodes().Informer().GetStore().Add(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the gpus of the excluded node are dropped from the capacity, the capacity is replaced as a whole in the status
+	ctrl := &managedClusterStatusController{
+		nodeLister: kubeInformerFactory.Core().V1().Nodes().Lister(),
+		resourceOptions: ClusterResourceOptions{
+			NodeSelector: labels.SelectorFromSet(labels.Set{nodeRoleLabelPrefix + "worker": ""}),
+		},
+	}
+	capacity, _, err := ctrl.getClusterResources()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	assertResources(t, capacity, map[clusterv1.ResourceName]int64{clusterv1.ResourceCPU: 4})
+}
+
+func TestValidateExtendedResourcePatterns(t *testing.T) {
+	testinghelpers.AssertError(t, ValidateExtendedResourcePatterns([]string{"nvidia.com/gpu", "amd.com/*"}), "")
+	testinghelpers.AssertError(t, ValidateExtendedResourcePatterns([]string{"nvidia.com/[gpu"}),
+		"extended resource pattern \"nvidia.com/[gpu\" is invalid: syntax error in pattern")
+}
+
+func TestThrottleResources(t *testing.T) {
+	reported := newClusterResourceList(100, 200)
+
+	cases := []struct {
+		name                   string
+		lastResourceUpdateTime time.Time
+		capacity               clusterv1.ResourceList
+		expectedCPU            int64
+		expectDelay            bool
+	}{
+		{
+			name:                   "not changed",
+			lastResourceUpdateTime: time.Now(),
+			capacity:               newClusterResourceList(100, 200),
+			expectedCPU:            100,
+		},
+		{
+			name:                   "changed in the min update interval",
+			lastResourceUpdateTime: time.Now(),
+			capacity:               newClusterResourceList(96, 200),
+			expectedCPU:            100,
+			expectDelay:            true,
+		},
+		{
+			name:                   "significantly changed in the min update interval",
+			lastResourceUpdateTime: time.Now(),
+			capacity:               newClusterResourceList(80, 200),
+			expectedCPU:            80,
+		},
+		{
+			name:                   "resource removed in the min update interval",
+			lastResourceUpdateTime: time.Now(),
+			capacity:               clusterv1.ResourceList{clusterv1.ResourceCPU: *resource.NewQuantity(100, resource.DecimalExponent)},
+			expectedCPU:            100,
+		},
+		{
+			name:                   "changed after the min update interval",
+			lastResourceUpdateTime: time.Now().Add(-10 * time.Minute),
+			capacity:               newClusterResourceList(96, 200),
+			expectedCPU:            96,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := &managedClusterStatusController{
+				resourceOptions:        ClusterResourceOptions{MinUpdateInterval: 5 * time.Minute, UpdateThresholdPercent: 10},
+				lastResourceUpdateTime: c.lastResourceUpdateTime,
+			}
+			status := clusterv1.ManagedClusterStatus{Capacity: reported, Allocatable: reported}
+			capacity, _, delay := ctrl.throttleResources(status, c.capacity, reported)
+			assertResources(t, capacity, map[clusterv1.ResourceName]int64{clusterv1.ResourceCPU: c.expectedCPU})
+			if c.expectDelay != (delay > 0) {
+				t.Errorf("expected delay %t, but got %v", c.expectDelay, delay)
+			}
+			if !c.expectDelay && !resourcesEqual(ctrl.reportedCapacity, capacity) {
+				t.Errorf("expected reported capacity %v, but got %v", capacity, ctrl.reportedCapacity)
+			}
+		})
+	}
+}
+
+func newClusterResourceList(cpu, mem int64) clusterv1.ResourceList {
+	return clusterv1.ResourceList{
+		clusterv1.ResourceCPU:    *resource.NewQuantity(cpu, resource.DecimalExponent),
+		clusterv1.ResourceMemory: *resource.NewQuantity(1024*1024*mem, resource.BinarySI),
+	}
+}
@@ -0,0 +1,108 @@
+package managedcluster
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+)
+
+const (
+	// NodeSummaryClaimPrefix is the prefix of the cluster claims reporting the counts of the nodes of the managed
+	// cluster by the OS, architecture and kubelet minor version, e.g. nodes.open-cluster-management.io/os.linux,
+	// nodes.open-cluster-management.io/arch.amd64 and nodes.open-cluster-management.io/kubelet.v1.26
+	NodeSummaryClaimPrefix = "nodes.open-cluster-management.io/"
+
+	unknownNodeInfo = "unknown"
+)
+
+// nodeSummaryClaims returns the claims with the counts of the nodes by the OS, architecture and kubelet minor
+// version, sorted by the names
+func nodeSummaryClaims(nodes []*corev1.Node) []clusterv1.ManagedClusterClaim {
+	counts := map[string]int{}
+	for _, node := range nodes {
+		nodeInfo := node.Status.NodeInfo
+		counts["os."+valueOrUnknown(nodeInfo.OperatingSystem)]++
+		counts["arch."+valueOrUnknown(nodeInfo.Architecture)]++
+		counts["kubelet."+kubeletMinorVersion(nodeInfo.KubeletVersion)]++
+	}
+
+	claims := []clusterv1.ManagedClusterClaim{}
+	for name, count := range counts {
+		claims = append(claims, clusterv1.ManagedClusterClaim{
+			Name:  NodeSummaryClaimPrefix + name,
+			Value: strconv.Itoa(count),
+		})
+	}
+	sort.Slice(claims, func(i, j int) bool {
+		return claims[i].Name < claims[j].Name
+	})
+	return claims
+}
+
+// kubeletMinorVersion returns the minor version of the kubelet version, e.g. v1.26 of v1.26.3+k3s1
+func kubeletMinorVersion(kubeletVersion string) string {
+	parts := strings.SplitN(strings.TrimPrefix(kubeletVersion, "v"), ".", 3)
+	if len(parts) < 2 {
+		return unknownNodeInfo
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return unknownNodeInfo
+	}
+	minor, err := strconv.Atoi(strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' }))
+	if err != nil {
+		return unknownNodeInfo
+	}
+	return fmt.Sprintf("v%d.%d", major, minor)
+}
+
+func valueOrUnknown(value string) string {
+	if len(value) == 0 {
+		return unknownNodeInfo
+	}
+	return value
+}
+
+// updateNodeSummaryClaimsFn replaces the node summary claims in the status with the new ones, the other claims
+// are maintained by the claim controller.
+func updateNodeSummaryClaimsFn(claims []clusterv1.ManagedClusterClaim) helpers.UpdateManagedClusterStatusFunc {
+	return func(oldStatus *clusterv1.ManagedClusterStatus) error {
+		oldStatus.ClusterClaims = append(claimsWithoutPrefix(oldStatus.ClusterClaims, NodeSummaryClaimPrefix), claims...)
+		return nil
+	}
+}
+
+// agentClaimPrefixes are the prefixes of the claims maintained by the agent rather than the ClusterClaims on the
+// managed cluster, they are kept by the claim controller
+var agentClaimPrefixes = []string{NodeSummaryClaimPrefix, TunnelEndpointClaimPrefix}
+
+// agentClaimsOf returns the claims maintained by the agent in the claims
+func agentClaimsOf(claims []clusterv1.ManagedClusterClaim) []clusterv1.ManagedClusterClaim {
+	var filtered []clusterv1.ManagedClusterClaim
+	for _, claim := range claims {
+		for _, prefix := range agentClaimPrefixes {
+			if strings.HasPrefix(claim.Name, prefix) {
+				filtered = append(filtered, claim)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// claimsWithoutPrefix returns the claims whose names do not have the prefix
+func claimsWithoutPrefix(claims []clusterv1.ManagedClusterClaim, prefix string) []clusterv1.ManagedClusterClaim {
+	var filtered []clusterv1.ManagedClusterClaim
+	for _, claim := range claims {
+		if !strings.HasPrefix(claim.Name, prefix) {
+			filtered = append(filtered, claim)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,90 @@
+package managedcluster
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func newSummaryTestNode(name, os, arch, kubeletVersion string) *corev1.Node {
+	node := testinghelpers.NewNode(name, testinghelpers.NewResourceList(4, 8), testinghelpers.NewResourceList(2, 4))
+	node.Status.NodeInfo = corev1.NodeSystemInfo{
+		OperatingSystem: os,
+		Architecture:    arch,
+		KubeletVersion:  kubeletVersion,
+	}
+	return node
+}
+
+func TestNodeSummaryClaims(t *testing.T) {
+	nodes := []*corev1.Node{
+		newSummaryTestNode("node1", "linux", "amd64", "v1.26.3"),
+		newSummaryTestNode("node2", "linux", "arm64", "v1.26.1+k3s1"),
+		newSummaryTestNode("node3", "windows", "amd64", "v1.25.8"),
+		newSummaryTestNode("node4", "", "", ""),
+	}
+
+	expected := []clusterv1.ManagedClusterClaim{
+		{Name: NodeSummaryClaimPrefix + "arch.amd64", Value: "2"},
+		{Name: NodeSummaryClaimPrefix + "arch.arm64", Value: "1"},
+		{Name: NodeSummaryClaimPrefix + "arch.unknown", Value: "1"},
+		{Name: NodeSummaryClaimPrefix + "kubelet.unknown", Value: "1"},
+		{Name: NodeSummaryClaimPrefix + "kubelet.v1.25", Value: "1"},
+		{Name: NodeSummaryClaimPrefix + "kubelet.v1.26", Value: "2"},
+		{Name: NodeSummaryClaimPrefix + "os.linux", Value: "2"},
+		{Name: NodeSummaryClaimPrefix + "os.unknown", Value: "1"},
+		{Name: NodeSummaryClaimPrefix + "os.windows", Value: "1"},
+	}
+	if actual := nodeSummaryClaims(nodes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected claims %v, but got %v", expected, actual)
+	}
+}
+
+func TestKubeletMinorVersion(t *testing.T) {
+	cases := map[string]string{
+		"v1.26.3":          "v1.26",
+		"1.27.0":           "v1.27",
+		"v1.25.8-eks-1234": "v1.25",
+		"v1.24+":           "v1.24",
+		"v1":               unknownNodeInfo,
+		"vx.y.z":           unknownNodeInfo,
+		"":                 unknownNodeInfo,
+	}
+	for version, expected := range cases {
+		if actual := kubeletMinorVersion(version); actual != expected {
+			t.Errorf("expected minor version %q of %q, but got %q", expected, version, actual)
+		}
+	}
+}
+
+func TestNodeSummaryClaimsOwnership(t *testing.T) {
+	customClaim := clusterv1.ManagedClusterClaim{Name: "region", Value: "us-east-1"}
+	staleClaim := clusterv1.ManagedClusterClaim{Name: NodeSummaryClaimPrefix + "os.windows", Value: "1"}
+	summaryClaim := clusterv1.ManagedClusterClaim{Name: NodeSummaryClaimPrefix + "os.linux", Value: "3"}
+
+	// the status controller replaces the node summary claims only
+	status := &clusterv1.ManagedClusterStatus{ClusterClaims: []clusterv1.ManagedClusterClaim{customClaim, staleClaim}}
+	if err := updateNodeSummaryClaimsFn([]clusterv1.ManagedClusterClaim{summaryClaim})(status); err != nil {
+		t.Fatal(err)
+	}
+	expected := []clusterv1.ManagedClusterClaim{customClaim, summaryClaim}
+	if !reflect.DeepEqual(status.ClusterClaims, expected) {
+		t.Errorf("expected claims %v, but got %v", expected, status.ClusterClaims)
+	}
+
+	// the claim controller keeps the node summary claims
+	newClaim := clusterv1.ManagedClusterClaim{Name: "zone", Value: "a"}
+	if err := updateClusterClaimsFn(clusterv1.ManagedClusterStatus{
+		ClusterClaims: []clusterv1.ManagedClusterClaim{newClaim},
+	})(status); err != nil {
+		t.Fatal(err)
+	}
+	expected = []clusterv1.ManagedClusterClaim{newClaim, summaryClaim}
+	if !reflect.DeepEqual(status.ClusterClaims, expected) {
+		t.Errorf("expected claims %v, but got %v", expected, status.ClusterClaims)
+	}
+}
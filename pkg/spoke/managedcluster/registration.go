@@ -2,8 +2,11 @@ package managedcluster
 
 import (
 	"crypto/x509/pkix"
+	"encoding/json"
 	"fmt"
+	"net"
 	"strings"
+	"time"
 
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
@@ -14,6 +17,7 @@ import (
 	certificates "k8s.io/api/certificates/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -31,6 +35,10 @@ const (
 
 	// TODO(qiujian16) expose it if necessary in the future.
 	clusterCSRThreshold = 10
+
+	// servingCertSANsFile is the name of the file in the serving certificate secret holding the requested subject
+	// alternative names, the serving certificate is requested again once they are changed
+	servingCertSANsFile = "subject-alt-names"
 )
 
 // NewClientCertForHubController returns a controller to
@@ -43,19 +51,20 @@ func NewClientCertForHubController(
 	clientCertSecretName string,
 	kubeconfigData []byte,
 	spokeSecretInformer corev1informers.SecretInformer,
-	csrControl clientcert.CSRControl,
+	registrationDriver clientcert.RegistrationDriver,
 	csrExpirationSeconds int32,
+	identityKey []byte,
+	clusterID string,
+	renewalThreshold, renewalJitter float64,
+	keyProvider clientcert.KeyProvider,
+	csrBackoff clientcert.CSRBackoffOption,
 	spokeKubeClient kubernetes.Interface,
 	statusUpdater clientcert.StatusUpdateFunc,
+	expirationReporter func(ctx context.Context, notAfter time.Time) error,
 	recorder events.Recorder,
 	controllerName string,
 ) factory.Controller {
-	err := csrControl.Informer().AddIndexers(cache.Indexers{
-		indexByCluster: indexByClusterFunc,
-	})
-	if err != nil {
-		utilruntime.HandleError(err)
-	}
+	addClusterIndexer(registrationDriver)
 	clientCertOption := clientcert.ClientCertOption{
 		SecretNamespace: clientCertSecretNamespace,
 		SecretName:      clientCertSecretName,
@@ -64,6 +73,11 @@ func NewClientCertForHubController(
 			clientcert.AgentNameFile:   []byte(agentName),
 			clientcert.KubeconfigFile:  kubeconfigData,
 		},
+		// the hub CA bundle controller may have replaced the CA of the hub kubeconfig in the secret
+		MergeAdditionalSecretData: mergeHubCABundle,
+		RenewalThreshold:          renewalThreshold,
+		RenewalJitter:             renewalJitter,
+		ExpirationReporter:        expirationReporter,
 	}
 
 	var csrExpirationSecondsInCSROption *int32
@@ -106,14 +120,23 @@ func NewClientCertForHubController(
 			// only enqueue csr whose name starts with the cluster name
 			return strings.HasPrefix(accessor.GetName(), fmt.Sprintf("%s-", clusterName))
 		},
-		HaltCSRCreation:   haltCSRCreationFunc(csrControl.Informer().GetIndexer(), clusterName),
+		HaltCSRCreation:   haltCSRCreationFunc(registrationDriver.Informer().GetIndexer(), clusterName),
 		ExpirationSeconds: csrExpirationSecondsInCSROption,
+		KeyProvider:       keyProvider,
+		Backoff:           csrBackoff,
+		IdentityKey:       identityKey,
+	}
+
+	if len(clusterID) > 0 {
+		csrOption.ObjectMeta.Annotations = map[string]string{
+			clientcert.ClusterIDAnnotation: clusterID,
+		}
 	}
 
 	return clientcert.NewClientCertificateController(
 		clientCertOption,
 		csrOption,
-		csrControl,
+		registrationDriver,
 		spokeSecretInformer,
 		spokeKubeClient.CoreV1(),
 		statusUpdater,
@@ -122,6 +145,102 @@ func NewClientCertForHubController(
 	)
 }
 
+// NewServingCertController returns a controller to request the serving certificate of the agent with the subject
+// alternative names from the cluster serving signer of the hub, and to rotate it before it becomes expired. The hub
+// calls back to the cluster over mTLS with it, and only approves the names in its allow list. The csrs are requested
+// with the client certificate of the agent, so the controller only runs after the cluster is registered.
+func NewServingCertController(
+	clusterName string,
+	agentName string,
+	secretNamespace string,
+	secretName string,
+	spokeSecretInformer corev1informers.SecretInformer,
+	registrationDriver clientcert.RegistrationDriver,
+	dnsNames []string, ipAddresses []net.IP,
+	renewalThreshold, renewalJitter float64,
+	keyProvider clientcert.KeyProvider,
+	csrBackoff clientcert.CSRBackoffOption,
+	spokeKubeClient kubernetes.Interface,
+	recorder events.Recorder,
+	controllerName string,
+) factory.Controller {
+	addClusterIndexer(registrationDriver)
+	sans := append([]string{}, dnsNames...)
+	for _, ip := range ipAddresses {
+		sans = append(sans, ip.String())
+	}
+	clientCertOption := clientcert.ClientCertOption{
+		SecretNamespace: secretNamespace,
+		SecretName:      secretName,
+		AdditionalSecretData: map[string][]byte{
+			servingCertSANsFile: []byte(strings.Join(sans, ",")),
+		},
+		AdditionalSecretDataSensitive: true,
+		RenewalThreshold:              renewalThreshold,
+		RenewalJitter:                 renewalJitter,
+	}
+
+	csrNamePrefix := fmt.Sprintf("%s-serving-", clusterName)
+	csrOption := clientcert.CSROption{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: csrNamePrefix,
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabelKey: clusterName,
+			},
+		},
+		Subject: &pkix.Name{
+			Organization: []string{
+				fmt.Sprintf("%s%s", user.SubjectPrefix, clusterName),
+				user.ManagedClustersGroup,
+			},
+			CommonName: fmt.Sprintf("%s%s:%s", user.SubjectPrefix, clusterName, agentName),
+		},
+		DNSNames:    dnsNames,
+		IPAddresses: ipAddresses,
+		SignerName:  helpers.ClusterServingSignerName,
+		Usages:      clientcert.ServingCertUsages,
+		EventFilterFunc: func(obj interface{}) bool {
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return false
+			}
+			if accessor.GetLabels()[clusterv1.ClusterNameLabelKey] != clusterName {
+				return false
+			}
+			return strings.HasPrefix(accessor.GetName(), csrNamePrefix)
+		},
+		HaltCSRCreation: haltCSRCreationFunc(registrationDriver.Informer().GetIndexer(), clusterName),
+		KeyProvider:     keyProvider,
+		Backoff:         csrBackoff,
+	}
+
+	return clientcert.NewClientCertificateController(
+		clientCertOption,
+		csrOption,
+		registrationDriver,
+		spokeSecretInformer,
+		spokeKubeClient.CoreV1(),
+		// the conditions of the cluster only report the client certificate
+		GenerateBootstrapStatusUpdater(),
+		recorder,
+		controllerName,
+	)
+}
+
+// addClusterIndexer indexes the csrs by the cluster, it is shared by the controllers of the client and serving
+// certificates of the cluster
+func addClusterIndexer(registrationDriver clientcert.RegistrationDriver) {
+	if _, ok := registrationDriver.Informer().GetIndexer().GetIndexers()[indexByCluster]; ok {
+		return
+	}
+	err := registrationDriver.Informer().AddIndexers(cache.Indexers{
+		indexByCluster: indexByClusterFunc,
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+}
+
 func haltCSRCreationFunc(indexer cache.Indexer, clusterName string) func() bool {
 	return func() bool {
 		items, err := indexer.ByIndex(indexByCluster, clusterName)
@@ -153,6 +272,32 @@ func GenerateStatusUpdater(hubClusterClient clientset.Interface, clusterName str
 	}
 }
 
+// GenerateExpirationReporter generates the func reporting the expiration time of the client certificate with the
+// client certificate expiration annotation of the managed cluster, the annotation is only patched once it is changed.
+func GenerateExpirationReporter(hubClusterClient clientset.Interface, clusterName string) func(context.Context, time.Time) error {
+	reported := ""
+	return func(ctx context.Context, notAfter time.Time) error {
+		expiration := notAfter.UTC().Format(time.RFC3339)
+		if expiration == reported {
+			return nil
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{helpers.ManagedClusterClientCertExpirationAnnotation: expiration},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := hubClusterClient.ClusterV1().ManagedClusters().Patch(
+			ctx, clusterName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("unable to report the client certificate expiration of managed cluster %q: %w", clusterName, err)
+		}
+		reported = expiration
+		return nil
+	}
+}
+
 // GetClusterAgentNamesFromCertificate returns the cluster name and agent name by parsing
 // the common name of the certification
 func GetClusterAgentNamesFromCertificate(certData []byte) (clusterName, agentName string, err error) {
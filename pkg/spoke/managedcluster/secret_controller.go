@@ -20,6 +20,8 @@ import (
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/registration/pkg/health"
 )
 
 // hubKubeconfigSecretController watches the HubKubeconfig secret, if the secret is changed, this controller creates/updates the
@@ -61,13 +63,13 @@ func NewHubKubeconfigSecretController(
 				}
 				return false
 			}, spokeSecretInformer.Informer()).
-		WithSync(s.sync).
+		WithSync(health.DefaultRegistry.TrackSync("HubKubeconfigSecretController", s.sync)).
 		ResyncEvery(5*time.Minute).
 		ToController("HubKubeconfigSecretController", recorder)
 }
 
 func (s *hubKubeconfigSecretController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
-	klog.V(4).Infof("Reconciling Hub KubeConfig secret %q", s.hubKubeconfigSecretName)
+	klog.FromContext(ctx).V(4).Info("Reconciling Hub KubeConfig secret", "secret", s.hubKubeconfigSecretName)
 	return DumpSecret(s.spokeCoreClient, s.hubKubeconfigSecretNamespace, s.hubKubeconfigSecretName, s.hubKubeconfigDir, ctx, syncCtx.Recorder())
 }
 
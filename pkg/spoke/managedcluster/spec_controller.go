@@ -0,0 +1,143 @@
+package managedcluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/health"
+	"open-cluster-management.io/registration/pkg/helpers"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// ClusterSpecFieldClientConfigs and ClusterSpecFieldLeaseDuration are the spec fields of the managed cluster
+	// the agent is able to keep reconciled after the managed cluster is created
+	ClusterSpecFieldClientConfigs = "managedClusterClientConfigs"
+	ClusterSpecFieldLeaseDuration = "leaseDurationSeconds"
+
+	// ClusterSpecFieldManager is the field manager of the spec fields applied by the agent, so the fields owned by
+	// the hub, e.g. hubAcceptsClient and taints, are never overwritten
+	ClusterSpecFieldManager = helpers.ClusterSpecFieldManager
+)
+
+// ClusterSpecFields are the spec fields of the managed cluster which are able to be reconciled by the agent
+var ClusterSpecFields = []string{ClusterSpecFieldClientConfigs, ClusterSpecFieldLeaseDuration}
+
+// managedClusterSpecController keeps the selected spec fields of the managed cluster reconciled against the local
+// truth of the agent with server-side apply, while the other fields are only set when the cluster is created. Only
+// the reconciled fields are in the applied configuration, so the agent owns them with its field manager and never
+// touches the fields owned by the hub.
+type managedClusterSpecController struct {
+	clusterName          string
+	fields               []string
+	clientConfigs        []clusterv1.ClientConfig
+	leaseDurationSeconds int32
+	hubClusterClient     clientset.Interface
+	hubClusterLister     clusterv1listers.ManagedClusterLister
+}
+
+// NewManagedClusterSpecController creates a new managed cluster spec controller on the managed cluster. The client
+// configs are built with the external server urls and the CA bundle of the managed cluster.
+func NewManagedClusterSpecController(
+	clusterName string,
+	fields []string,
+	spokeExternalServerURLs []string,
+	spokeCABundle []byte,
+	leaseDurationSeconds int32,
+	hubClusterClient clientset.Interface,
+	hubManagedClusterInformer clusterv1informer.ManagedClusterInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &managedClusterSpecController{
+		clusterName:          clusterName,
+		fields:               fields,
+		leaseDurationSeconds: leaseDurationSeconds,
+		hubClusterClient:     hubClusterClient,
+		hubClusterLister:     hubManagedClusterInformer.Lister(),
+	}
+	for _, serverURL := range spokeExternalServerURLs {
+		c.clientConfigs = append(c.clientConfigs, clusterv1.ClientConfig{
+			URL:      serverURL,
+			CABundle: spokeCABundle,
+		})
+	}
+
+	return factory.New().
+		WithInformers(hubManagedClusterInformer.Informer()).
+		WithSync(health.DefaultRegistry.TrackSync("ManagedClusterSpecController", c.sync)).
+		ResyncEvery(10*time.Minute).
+		ToController("ManagedClusterSpecController", recorder)
+}
+
+func (c *managedClusterSpecController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedCluster, err := c.hubClusterLister.Get(c.clusterName)
+	if err != nil {
+		return fmt.Errorf("unable to get managed cluster with name %q from hub: %w", c.clusterName, err)
+	}
+
+	// only the reconciled fields are in the applied spec
+	spec := map[string]interface{}{}
+	modified := false
+	for _, field := range c.fields {
+		switch field {
+		case ClusterSpecFieldClientConfigs:
+			spec[field] = c.clientConfigs
+			modified = modified || !equalClientConfigs(managedCluster.Spec.ManagedClusterClientConfigs, c.clientConfigs)
+		case ClusterSpecFieldLeaseDuration:
+			spec[field] = c.leaseDurationSeconds
+			modified = modified || managedCluster.Spec.LeaseDurationSeconds != c.leaseDurationSeconds
+		}
+	}
+	if !modified {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"apiVersion": clusterv1.GroupVersion.String(),
+		"kind":       "ManagedCluster",
+		"metadata": map[string]interface{}{
+			"name": c.clusterName,
+		},
+		"spec": spec,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the fields are forced, so the agent takes them over from the managers which set them before, e.g. the agent
+	// itself when the cluster is created. The agent is the sole owner of the fields afterwards, the hub cluster
+	// endpoints controller leaves the client configs applied by the agent alone.
+	_, err = c.hubClusterClient.ClusterV1().ManagedClusters().Patch(ctx, c.clusterName, types.ApplyPatchType, patch,
+		metav1.PatchOptions{FieldManager: ClusterSpecFieldManager, Force: pointer.Bool(true)})
+	if err != nil {
+		return fmt.Errorf("unable to apply the spec of managed cluster %q: %w", c.clusterName, err)
+	}
+
+	syncCtx.Recorder().Eventf("ManagedClusterSpecReconciled", "The spec fields %v of managed cluster %q are reconciled",
+		c.fields, c.clusterName)
+	return nil
+}
+
+func equalClientConfigs(a, b []clusterv1.ClientConfig) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].URL != b[i].URL || !bytes.Equal(a[i].CABundle, b[i].CABundle) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package managedcluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestSyncManagedClusterSpec(t *testing.T) {
+	cases := []struct {
+		name            string
+		fields          []string
+		cluster         *clusterv1.ManagedCluster
+		expectedSpec    map[string]interface{}
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:    "apply the client configs",
+			fields:  []string{ClusterSpecFieldClientConfigs},
+			cluster: testinghelpers.NewAcceptedManagedCluster(),
+			expectedSpec: map[string]interface{}{
+				ClusterSpecFieldClientConfigs: []interface{}{
+					map[string]interface{}{"url": testSpokeExternalServerUrl, "caBundle": "dGVzdGNhYnVuZGxl"},
+				},
+			},
+		},
+		{
+			name:    "apply the lease duration",
+			fields:  []string{ClusterSpecFieldLeaseDuration},
+			cluster: newManagedClusterWithClientConfig(),
+			expectedSpec: map[string]interface{}{
+				ClusterSpecFieldLeaseDuration: float64(120),
+			},
+		},
+		{
+			name:   "spec fields are not changed",
+			fields: []string{ClusterSpecFieldClientConfigs, ClusterSpecFieldLeaseDuration},
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := newManagedClusterWithClientConfig()
+				cluster.Spec.LeaseDurationSeconds = 120
+				return cluster
+			}(),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			// the fake client does not support the apply patch
+			clusterClient.PrependReactor("patch", "managedclusters", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				return true, c.cluster, nil
+			})
+			informerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := informerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := &managedClusterSpecController{
+				clusterName: testinghelpers.TestManagedClusterName,
+				fields:      c.fields,
+				clientConfigs: []clusterv1.ClientConfig{
+					{URL: testSpokeExternalServerUrl, CABundle: []byte("testcabundle")},
+				},
+				leaseDurationSeconds: 120,
+				hubClusterClient:     clusterClient,
+				hubClusterLister:     informerFactory.Cluster().V1().ManagedClusters().Lister(),
+			}
+			if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			actions := clusterClient.Actions()
+			if c.validateActions != nil {
+				c.validateActions(t, actions)
+				return
+			}
+
+			testinghelpers.AssertActions(t, actions, "patch")
+			testinghelpers.AssertApplyPatch(t, actions[0])
+			// the hub owned fields, e.g. hubAcceptsClient, are not in the applied spec
+			testinghelpers.AssertPatchField(t, actions[0], c.expectedSpec, "spec")
+		})
+	}
+}
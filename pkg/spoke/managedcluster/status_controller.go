@@ -10,7 +10,9 @@ import (
 	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/health"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/transport"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -27,32 +29,60 @@ import (
 // and ensure that the managed cluster resources and version are up to date.
 type managedClusterStatusController struct {
 	clusterName                   string
+	agentName                     string
 	hubClusterClient              clientset.Interface
 	hubClusterLister              clusterv1listers.ManagedClusterLister
 	managedClusterDiscoveryClient discovery.DiscoveryInterface
 	nodeLister                    corev1lister.NodeLister
+	resourceOptions               ClusterResourceOptions
+	availabilityProbes            []AvailabilityProbe
+	healthCheckStrategies         []HealthCheckStrategy
+	httpClient                    *http.Client
+	publisher                     transport.Publisher
+
+	// lastResourceUpdateTime, reportedCapacity and reportedAllocatable are the time and the resources of the last
+	// update of the changed capacity and allocatable, which are used to throttle the updates
+	lastResourceUpdateTime time.Time
+	reportedCapacity       clusterv1.ResourceList
+	reportedAllocatable    clusterv1.ResourceList
 }
 
-// NewManagedClusterStatusController creates a managed cluster status controller on managed cluster.
+// NewManagedClusterStatusController creates a managed cluster status controller on managed cluster. The capacity and
+// allocatable are aggregated from the nodes with the resource options. The kube-apiserver is checked with the health
+// check strategies, or the DefaultHealthCheckStrategies if they are empty, the url strategies are requested with the
+// http client. The result of
+// each availability probe is reported with a condition on the managed cluster. If the publisher is not nil, the
+// status is published with it instead of updating the managed cluster on the hub.
 func NewManagedClusterStatusController(
-	clusterName string,
+	clusterName, agentName string,
 	hubClusterClient clientset.Interface,
 	hubClusterInformer clusterv1informer.ManagedClusterInformer,
 	managedClusterDiscoveryClient discovery.DiscoveryInterface,
 	nodeInformer corev1informers.NodeInformer,
+	resourceOptions ClusterResourceOptions,
+	availabilityProbes []AvailabilityProbe,
+	healthCheckStrategies []HealthCheckStrategy,
+	httpClient *http.Client,
+	publisher transport.Publisher,
 	resyncInterval time.Duration,
 	recorder events.Recorder) factory.Controller {
 	c := &managedClusterStatusController{
 		clusterName:                   clusterName,
+		agentName:                     agentName,
 		hubClusterClient:              hubClusterClient,
 		hubClusterLister:              hubClusterInformer.Lister(),
 		managedClusterDiscoveryClient: managedClusterDiscoveryClient,
 		nodeLister:                    nodeInformer.Lister(),
+		resourceOptions:               resourceOptions,
+		availabilityProbes:            availabilityProbes,
+		healthCheckStrategies:         healthCheckStrategies,
+		httpClient:                    httpClient,
+		publisher:                     publisher,
 	}
 
 	return factory.New().
 		WithInformers(hubClusterInformer.Informer(), nodeInformer.Informer()).
-		WithSync(c.sync).
+		WithSync(health.DefaultRegistry.TrackSync("ManagedClusterStatusController", c.sync)).
 		ResyncEvery(resyncInterval).
 		ToController("ManagedClusterStatusController", recorder)
 }
@@ -60,15 +90,31 @@ func NewManagedClusterStatusController(
 // sync updates managed cluster available condition by checking kube-apiserver health on managed cluster.
 // if the kube-apiserver is health, it will ensure that managed cluster resources and version are up to date.
 func (c *managedClusterStatusController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
-	if _, err := c.hubClusterLister.Get(c.clusterName); err != nil {
+	cluster, err := c.hubClusterLister.Get(c.clusterName)
+	if err != nil {
 		return fmt.Errorf("unable to get managed cluster %q from hub: %w", c.clusterName, err)
 	}
 
 	updateStatusFuncs := []helpers.UpdateManagedClusterStatusFunc{}
+	// resources are only published once they are collected, so the hub keeps the reported ones otherwise
+	var resources *transport.Resources
 
 	// check the kube-apiserver health on managed cluster.
 	condition := c.checkKubeAPIServerStatus(ctx)
 
+	// run the availability probes if the kube-apiserver is health, so the partial degradation is reported
+	probeConditions := unknownProbeConditions(c.availabilityProbes)
+	if condition.Status == metav1.ConditionTrue {
+		probeConditions = []metav1.Condition{}
+		for _, probe := range c.availabilityProbes {
+			probeConditions = append(probeConditions, c.probe(ctx, probe))
+		}
+	}
+	updateStatusFuncs = append(updateStatusFuncs, removeStaleProbeConditionsFn(c.availabilityProbes))
+	for _, probeCondition := range probeConditions {
+		updateStatusFuncs = append(updateStatusFuncs, helpers.UpdateManagedClusterConditionFn(probeCondition))
+	}
+
 	// the managed cluster kube-apiserver is health, update its version and resources if necessary.
 	if condition.Status == metav1.ConditionTrue {
 		clusterVersion, err := c.getClusterVersion()
@@ -81,14 +127,35 @@ func (c *managedClusterStatusController) sync(ctx context.Context, syncCtx facto
 			return fmt.Errorf("unable to get capacity and allocatable of managed cluster %q: %w", c.clusterName, err)
 		}
 
-		updateStatusFuncs = append(updateStatusFuncs, updateClusterResourcesFn(clusterv1.ManagedClusterStatus{
-			Capacity:    capacity,
-			Allocatable: allocatable,
-			Version:     *clusterVersion,
-		}))
+		if c.resourceOptions.MinUpdateInterval > 0 {
+			var delay time.Duration
+			capacity, allocatable, delay = c.throttleResources(cluster.Status, capacity, allocatable)
+			if delay > 0 {
+				// update the resources once the min update interval passes
+				syncCtx.Queue().AddAfter(syncCtx.QueueKey(), delay)
+			}
+		}
+
+		updateStatusFuncs = append(updateStatusFuncs, helpers.UpdateManagedClusterResourcesFn(capacity, allocatable, *clusterVersion))
+		resources = &transport.Resources{Capacity: capacity, Allocatable: allocatable, Version: *clusterVersion}
+
+		// the stale node summary claims are removed if the summary is disabled
+		var summaryClaims []clusterv1.ManagedClusterClaim
+		if c.resourceOptions.NodeSummary {
+			nodes, err := c.nodeLister.List(labels.Everything())
+			if err != nil {
+				return fmt.Errorf("unable to list nodes of managed cluster %q: %w", c.clusterName, err)
+			}
+			summaryClaims = nodeSummaryClaims(nodes)
+		}
+		updateStatusFuncs = append(updateStatusFuncs, updateNodeSummaryClaimsFn(summaryClaims))
 	}
 
 	updateStatusFuncs = append(updateStatusFuncs, helpers.UpdateManagedClusterConditionFn(condition))
+	if c.publisher != nil {
+		return c.publishStatus(ctx, cluster, resources, updateStatusFuncs...)
+	}
+
 	_, updated, err := helpers.UpdateManagedClusterStatus(ctx, c.hubClusterClient, c.clusterName, updateStatusFuncs...)
 	if err != nil {
 		return fmt.Errorf("unable to update status of managed cluster %q: %w", c.clusterName, err)
@@ -100,40 +167,65 @@ func (c *managedClusterStatusController) sync(ctx context.Context, syncCtx facto
 	return nil
 }
 
-// using readyz api to check the status of kube apiserver
-func (c *managedClusterStatusController) checkKubeAPIServerStatus(ctx context.Context) metav1.Condition {
-	statusCode := 0
-	condition := metav1.Condition{Type: clusterv1.ManagedClusterConditionAvailable}
-	result := c.managedClusterDiscoveryClient.RESTClient().Get().AbsPath("/livez").Do(ctx).StatusCode(&statusCode)
-	if statusCode == http.StatusOK {
-		condition.Status = metav1.ConditionTrue
-		condition.Reason = "ManagedClusterAvailable"
-		condition.Message = "Managed cluster is available"
-		return condition
-	}
-
-	// for backward compatible, the livez endpoint is supported from Kubernetes 1.16, so if the livez is not found or
-	// forbidden, the healthz endpoint will be used.
-	if statusCode == http.StatusNotFound || statusCode == http.StatusForbidden {
-		result = c.managedClusterDiscoveryClient.RESTClient().Get().AbsPath("/healthz").Do(ctx).StatusCode(&statusCode)
-		if statusCode == http.StatusOK {
-			condition.Status = metav1.ConditionTrue
-			condition.Reason = "ManagedClusterAvailable"
-			condition.Message = "Managed cluster is available"
-			return condition
+// throttleResources returns the capacity and allocatable to report. If the resources are changed in the min update
+// interval and the change is not significant, the last reported ones are returned with the delay to update them.
+func (c *managedClusterStatusController) throttleResources(status clusterv1.ManagedClusterStatus,
+	capacity, allocatable clusterv1.ResourceList) (clusterv1.ResourceList, clusterv1.ResourceList, time.Duration) {
+	// the resources reported by the previous agent are in the status
+	if c.reportedCapacity == nil {
+		c.reportedCapacity, c.reportedAllocatable = status.Capacity.DeepCopy(), status.Allocatable.DeepCopy()
+	}
+	if resourcesEqual(c.reportedCapacity, capacity) && resourcesEqual(c.reportedAllocatable, allocatable) {
+		return capacity, allocatable, 0
+	}
+
+	now := time.Now()
+	nextUpdateTime := c.lastResourceUpdateTime.Add(c.resourceOptions.MinUpdateInterval)
+	if now.Before(nextUpdateTime) &&
+		!c.resourceOptions.significantlyChanged(c.reportedCapacity, capacity) &&
+		!c.resourceOptions.significantlyChanged(c.reportedAllocatable, allocatable) {
+		return c.reportedCapacity.DeepCopy(), c.reportedAllocatable.DeepCopy(), nextUpdateTime.Sub(now)
+	}
+
+	c.lastResourceUpdateTime = now
+	c.reportedCapacity, c.reportedAllocatable = capacity.DeepCopy(), allocatable.DeepCopy()
+	return capacity, allocatable, 0
+}
+
+// resourcesEqual returns true if the resources in the new list are same with the old ones
+func resourcesEqual(oldList, newList clusterv1.ResourceList) bool {
+	if len(oldList) != len(newList) {
+		return false
+	}
+	for name, newValue := range newList {
+		oldValue, ok := oldList[name]
+		if !ok || !oldValue.Equal(newValue) {
+			return false
 		}
 	}
+	return true
+}
 
-	condition.Status = metav1.ConditionFalse
-	condition.Reason = "ManagedClusterKubeAPIServerUnavailable"
-	body, err := result.Raw()
-	if err == nil {
-		condition.Message = fmt.Sprintf("The kube-apiserver is not ok, status code: %d, %v", statusCode, string(body))
-		return condition
+// publishStatus publishes the status of the managed cluster updated with the update funcs, and the resources if they
+// are collected in this sync. The status is published in every sync even if it is not changed, because the messages
+// may be dropped when the broker is unavailable.
+func (c *managedClusterStatusController) publishStatus(ctx context.Context, cluster *clusterv1.ManagedCluster,
+	resources *transport.Resources, updateStatusFuncs ...helpers.UpdateManagedClusterStatusFunc) error {
+	status := cluster.Status.DeepCopy()
+	for _, update := range updateStatusFuncs {
+		if err := update(status); err != nil {
+			return err
+		}
 	}
 
-	condition.Message = fmt.Sprintf("The kube-apiserver is not ok, status code: %d, %v", statusCode, err)
-	return condition
+	if err := transport.PublishJSON(ctx, c.publisher, transport.StatusTopic(c.clusterName, c.agentName), transport.StatusUpdate{
+		ClusterName: c.clusterName,
+		Status:      *status,
+		Resources:   resources,
+	}); err != nil {
+		return fmt.Errorf("unable to publish status of managed cluster %q: %w", c.clusterName, err)
+	}
+	return nil
 }
 
 func (c *managedClusterStatusController) getClusterVersion() (*clusterv1.ManagedClusterVersion, error) {
@@ -154,13 +246,15 @@ func (c *managedClusterStatusController) getClusterResources() (capacity, alloca
 	allocatableList := make(map[clusterv1.ResourceName]resource.Quantity)
 
 	for _, node := range nodes {
-		for key, value := range node.Status.Capacity {
-			if capacity, exist := capacityList[clusterv1.ResourceName(key)]; exist {
-				capacity.Add(value)
-				capacityList[clusterv1.ResourceName(key)] = capacity
-			} else {
-				capacityList[clusterv1.ResourceName(key)] = value
-			}
+		if !c.resourceOptions.included(node) {
+			continue
+		}
+
+		groups := c.resourceOptions.breakdownGroups(node)
+
+		c.resourceOptions.addResources(capacityList, node.Status.Capacity)
+		if len(groups) > 0 {
+			c.resourceOptions.addResources(capacityList, node.Status.Capacity, groups...)
 		}
 
 		// the node is unschedulable, ignore its allocatable resources
@@ -168,32 +262,11 @@ func (c *managedClusterStatusController) getClusterResources() (capacity, alloca
 			continue
 		}
 
-		for key, value := range node.Status.Allocatable {
-			if allocatable, exist := allocatableList[clusterv1.ResourceName(key)]; exist {
-				allocatable.Add(value)
-				allocatableList[clusterv1.ResourceName(key)] = allocatable
-			} else {
-				allocatableList[clusterv1.ResourceName(key)] = value
-			}
+		c.resourceOptions.addResources(allocatableList, node.Status.Allocatable)
+		if len(groups) > 0 {
+			c.resourceOptions.addResources(allocatableList, node.Status.Allocatable, groups...)
 		}
 	}
 
 	return capacityList, allocatableList, nil
 }
-
-func updateClusterResourcesFn(status clusterv1.ManagedClusterStatus) helpers.UpdateManagedClusterStatusFunc {
-	return func(oldStatus *clusterv1.ManagedClusterStatus) error {
-		// merge the old capacity to new capacity, if one old capacity entry does not exist in new capacity,
-		// we add it back to new capacity
-		for key, val := range oldStatus.Capacity {
-			if _, ok := status.Capacity[key]; !ok {
-				status.Capacity[key] = val
-				continue
-			}
-		}
-		oldStatus.Capacity = status.Capacity
-		oldStatus.Allocatable = status.Allocatable
-		oldStatus.Version = status.Version
-		return nil
-	}
-}
@@ -11,9 +11,12 @@ import (
 	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+	"open-cluster-management.io/registration/pkg/transport"
 
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -67,6 +70,7 @@ func TestHealthCheck(t *testing.T) {
 		name            string
 		clusters        []runtime.Object
 		nodes           []runtime.Object
+		probes          []AvailabilityProbe
 		httpStatus      int
 		responseMsg     string
 		validateActions func(t *testing.T, actions []clienttesting.Action)
@@ -91,13 +95,24 @@ func TestHealthCheck(t *testing.T) {
 					Message: "The kube-apiserver is not ok, status code: 500, an error on the server (\"internal server error\") has prevented the request from succeeding",
 				}
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
-				managedCluster := &clusterv1.ManagedCluster{}
-				err := json.Unmarshal(patch, managedCluster)
-				if err != nil {
-					t.Fatal(err)
+				testinghelpers.AssertConditionPatched(t, actions[1], expectedCondition)
+			},
+		},
+		{
+			name:        "probes are not run if kube-apiserver is not health",
+			clusters:    []runtime.Object{testinghelpers.NewAcceptedManagedCluster()},
+			probes:      []AvailabilityProbe{{Name: "etcd", Type: ProbeTypeReadyz, Target: "etcd"}},
+			httpStatus:  http.StatusInternalServerError,
+			responseMsg: "internal server error",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				expectedCondition := metav1.Condition{
+					Type:    "probe.open-cluster-management.io/etcd",
+					Status:  metav1.ConditionUnknown,
+					Reason:  "ManagedClusterKubeAPIServerUnavailable",
+					Message: "The probe is not run because the kube-apiserver is unavailable",
 				}
-				testinghelpers.AssertCondition(t, managedCluster.Status.Conditions, expectedCondition)
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				testinghelpers.AssertConditionPatched(t, actions[1], expectedCondition)
 			},
 		},
 		{
@@ -128,12 +143,8 @@ func TestHealthCheck(t *testing.T) {
 					},
 				}
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
 				managedCluster := &clusterv1.ManagedCluster{}
-				err := json.Unmarshal(patch, managedCluster)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[1], managedCluster)
 				testinghelpers.AssertCondition(t, managedCluster.Status.Conditions, expectedCondition)
 				testinghelpers.AssertManagedClusterStatus(t, managedCluster.Status, expectedStatus)
 			},
@@ -151,13 +162,7 @@ func TestHealthCheck(t *testing.T) {
 					Message: "Managed cluster is available",
 				}
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
-				managedCluster := &clusterv1.ManagedCluster{}
-				err := json.Unmarshal(patch, managedCluster)
-				if err != nil {
-					t.Fatal(err)
-				}
-				testinghelpers.AssertCondition(t, managedCluster.Status.Conditions, expectedCondition)
+				testinghelpers.AssertConditionPatched(t, actions[1], expectedCondition)
 			},
 		},
 		{
@@ -173,13 +178,7 @@ func TestHealthCheck(t *testing.T) {
 					Message: "Managed cluster is available",
 				}
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
-				managedCluster := &clusterv1.ManagedCluster{}
-				err := json.Unmarshal(patch, managedCluster)
-				if err != nil {
-					t.Fatal(err)
-				}
-				testinghelpers.AssertCondition(t, managedCluster.Status.Conditions, expectedCondition)
+				testinghelpers.AssertConditionPatched(t, actions[1], expectedCondition)
 			},
 		},
 		{
@@ -220,12 +219,8 @@ func TestHealthCheck(t *testing.T) {
 					},
 				}
 				testinghelpers.AssertActions(t, actions, "get", "patch")
-				patch := actions[1].(clienttesting.PatchAction).GetPatch()
 				managedCluster := &clusterv1.ManagedCluster{}
-				err := json.Unmarshal(patch, managedCluster)
-				if err != nil {
-					t.Fatal(err)
-				}
+				testinghelpers.UnmarshalPatch(t, actions[1], managedCluster)
 				testinghelpers.AssertCondition(t, managedCluster.Status.Conditions, expectedCondition)
 				testinghelpers.AssertManagedClusterStatus(t, managedCluster.Status, expectedStatus)
 			},
@@ -260,6 +255,7 @@ func TestHealthCheck(t *testing.T) {
 				hubClusterLister:              clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
 				managedClusterDiscoveryClient: discoveryClient,
 				nodeLister:                    kubeInformerFactory.Core().V1().Nodes().Lister(),
+				availabilityProbes:            c.probes,
 			}
 			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, ""))
 			testinghelpers.AssertError(t, syncErr, c.expectedErr)
@@ -268,3 +264,47 @@ func TestHealthCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestPublishStatus(t *testing.T) {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	publisher := &fakePublisher{}
+	ctrl := &managedClusterStatusController{
+		clusterName:      testinghelpers.TestManagedClusterName,
+		agentName:        "agent1",
+		hubClusterClient: clusterClient,
+		publisher:        publisher,
+	}
+
+	condition := metav1.Condition{
+		Type:    clusterv1.ManagedClusterConditionAvailable,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ManagedClusterAvailable",
+		Message: "Managed cluster is available",
+	}
+	resources := &transport.Resources{
+		Capacity:    clusterv1.ResourceList{clusterv1.ResourceCPU: *resource.NewQuantity(8, resource.DecimalSI)},
+		Allocatable: clusterv1.ResourceList{clusterv1.ResourceCPU: *resource.NewQuantity(4, resource.DecimalSI)},
+		Version:     clusterv1.ManagedClusterVersion{Kubernetes: "v1.26.0"},
+	}
+	if err := ctrl.publishStatus(context.TODO(), cluster, resources, helpers.UpdateManagedClusterConditionFn(condition)); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	testinghelpers.AssertNoActions(t, clusterClient.Actions())
+	if len(publisher.topics) != 1 || publisher.topics[0] != transport.StatusTopic(testinghelpers.TestManagedClusterName, "agent1") {
+		t.Fatalf("unexpected published topics %v", publisher.topics)
+	}
+	update := transport.StatusUpdate{}
+	if err := json.Unmarshal(publisher.payloads[0], &update); err != nil {
+		t.Fatal(err)
+	}
+	testinghelpers.AssertCondition(t, update.Status.Conditions, condition)
+	if update.Resources == nil || !apiequality.Semantic.DeepEqual(*update.Resources, *resources) {
+		t.Errorf("unexpected published resources %v", update.Resources)
+	}
+	// the status in the cache is not changed
+	if len(cluster.Status.Conditions) != 1 {
+		t.Errorf("the cached cluster is changed: %v", cluster.Status.Conditions)
+	}
+}
@@ -0,0 +1,186 @@
+package managedcluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/health"
+	"open-cluster-management.io/registration/pkg/helpers"
+)
+
+const (
+	// TunnelEndpointClaimPrefix is the prefix of the cluster claims holding the metadata of the tunnels published by
+	// the claims endpoint publisher, the name of the metadata follows the prefix
+	TunnelEndpointClaimPrefix = "tunnel.open-cluster-management.io/"
+
+	// EndpointPublisherSecret publishes the metadata of the tunnels in the cluster endpoints secret created by the
+	// hub in the cluster namespace, and EndpointPublisherClaims publishes them with the cluster claims of the
+	// managed cluster
+	EndpointPublisherSecret = "secret"
+	EndpointPublisherClaims = "claims"
+)
+
+// EndpointPublishers are the supported endpoint publishers
+var EndpointPublishers = []string{EndpointPublisherSecret, EndpointPublisherClaims}
+
+// EndpointPublisher publishes the metadata of the tunnels the hub components reach the managed cluster through,
+// e.g. the identifiers of the konnectivity agent of a proxy. The downstream distributions are able to substitute
+// their own publisher, e.g. to register the cluster with the tunnel server.
+type EndpointPublisher interface {
+	// Publish publishes the metadata to the hub, the metadata published before are replaced. It returns true if
+	// the published metadata are changed.
+	Publish(ctx context.Context, metadata map[string]string) (bool, error)
+}
+
+// ValidateTunnelEndpointMetadata verifies the names of the metadata of the tunnels, they are used as the keys of
+// the secret and the suffixes of the cluster claims.
+func ValidateTunnelEndpointMetadata(metadata map[string]string) error {
+	for name := range metadata {
+		if errs := validation.IsConfigMapKey(name); len(errs) > 0 {
+			return fmt.Errorf("tunnel endpoint metadata name %q is invalid: %s", name, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// NewEndpointPublisher returns the endpoint publisher of the type, the type is one of EndpointPublishers
+func NewEndpointPublisher(publisherType, clusterName string,
+	hubCoreClient corev1client.CoreV1Interface, hubClusterClient clientset.Interface) (EndpointPublisher, error) {
+	switch publisherType {
+	case EndpointPublisherSecret:
+		return &secretEndpointPublisher{clusterName: clusterName, hubCoreClient: hubCoreClient}, nil
+	case EndpointPublisherClaims:
+		return &claimEndpointPublisher{clusterName: clusterName, hubClusterClient: hubClusterClient}, nil
+	}
+	return nil, fmt.Errorf("endpoint publisher %q is invalid, it must be one of %v", publisherType, EndpointPublishers)
+}
+
+// secretEndpointPublisher publishes the metadata in the cluster endpoints secret with the keys of the
+// ClusterEndpointsTunnelKeyPrefix, the secret is created by the hub once the cluster is accepted.
+type secretEndpointPublisher struct {
+	clusterName   string
+	hubCoreClient corev1client.CoreV1Interface
+}
+
+func (p *secretEndpointPublisher) Publish(ctx context.Context, metadata map[string]string) (bool, error) {
+	secret, err := p.hubCoreClient.Secrets(p.clusterName).Get(ctx, helpers.ClusterEndpointsSecretName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		klog.FromContext(ctx).V(4).Info("Cluster endpoints secret of managed cluster is not found")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	data := map[string][]byte{}
+	for key, value := range secret.Data {
+		if !strings.HasPrefix(key, helpers.ClusterEndpointsTunnelKeyPrefix) {
+			data[key] = value
+		}
+	}
+	for name, value := range metadata {
+		data[helpers.ClusterEndpointsTunnelKeyPrefix+name] = []byte(value)
+	}
+	if equalSecretData(secret.Data, data) {
+		return false, nil
+	}
+
+	secret = secret.DeepCopy()
+	secret.Data = data
+	if _, err := p.hubCoreClient.Secrets(p.clusterName).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// claimEndpointPublisher publishes the metadata with the cluster claims of the TunnelEndpointClaimPrefix in the
+// status of the managed cluster
+type claimEndpointPublisher struct {
+	clusterName      string
+	hubClusterClient clientset.Interface
+}
+
+func (p *claimEndpointPublisher) Publish(ctx context.Context, metadata map[string]string) (bool, error) {
+	claims := []clusterv1.ManagedClusterClaim{}
+	for name, value := range metadata {
+		claims = append(claims, clusterv1.ManagedClusterClaim{Name: TunnelEndpointClaimPrefix + name, Value: value})
+	}
+	sort.Slice(claims, func(i, j int) bool {
+		return claims[i].Name < claims[j].Name
+	})
+
+	_, updated, err := helpers.UpdateManagedClusterStatus(ctx, p.hubClusterClient, p.clusterName,
+		func(oldStatus *clusterv1.ManagedClusterStatus) error {
+			oldStatus.ClusterClaims = append(claimsWithoutPrefix(oldStatus.ClusterClaims, TunnelEndpointClaimPrefix), claims...)
+			return nil
+		})
+	return updated, err
+}
+
+// tunnelEndpointsController publishes the metadata of the tunnels of the managed cluster with the endpoint
+// publisher, so the hub components reaching the managed cluster through the tunnels find the endpoints of it.
+type tunnelEndpointsController struct {
+	clusterName string
+	metadata    map[string]string
+	publisher   EndpointPublisher
+}
+
+// NewTunnelEndpointsController returns a new tunnelEndpointsController.
+func NewTunnelEndpointsController(
+	clusterName string,
+	metadata map[string]string,
+	publisher EndpointPublisher,
+	recorder events.Recorder) factory.Controller {
+	c := &tunnelEndpointsController{
+		clusterName: clusterName,
+		metadata:    metadata,
+		publisher:   publisher,
+	}
+
+	return factory.New().
+		WithSync(health.DefaultRegistry.TrackSync("TunnelEndpointsController", c.sync)).
+		ResyncEvery(10*time.Minute).
+		ToController("TunnelEndpointsController", recorder)
+}
+
+func (c *tunnelEndpointsController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.FromContext(ctx).V(4).Info("Reconciling tunnel endpoints of managed cluster")
+
+	updated, err := c.publisher.Publish(ctx, c.metadata)
+	if err != nil {
+		return fmt.Errorf("unable to publish the tunnel endpoints of managed cluster %q: %w", c.clusterName, err)
+	}
+	if updated {
+		syncCtx.Recorder().Eventf("TunnelEndpointsPublished",
+			"The metadata of %d tunnel endpoints of managed cluster %q are published to hub", len(c.metadata), c.clusterName)
+	}
+	return nil
+}
+
+func equalSecretData(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		other, ok := b[key]
+		if !ok || !bytes.Equal(value, other) {
+			return false
+		}
+	}
+	return true
+}
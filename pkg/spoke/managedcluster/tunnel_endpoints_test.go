@@ -0,0 +1,168 @@
+package managedcluster
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSecretEndpointPublisher(t *testing.T) {
+	cases := []struct {
+		name            string
+		hubObjects      []runtime.Object
+		metadata        map[string]string
+		expectedUpdated bool
+		expectedData    map[string]string
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:     "no cluster endpoints secret",
+			metadata: map[string]string{"agent-identifiers": "host=cluster1"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name:            "publish tunnel endpoints",
+			hubObjects:      []runtime.Object{newClusterEndpointsSecret("https://api.cluster1.example.com:6443", "ca")},
+			metadata:        map[string]string{"agent-identifiers": "host=cluster1"},
+			expectedUpdated: true,
+			expectedData: map[string]string{
+				helpers.ClusterEndpointsURLsKey:                               "https://api.cluster1.example.com:6443",
+				helpers.ClusterEndpointsCABundleKey:                           "ca",
+				helpers.ClusterEndpointsTunnelKeyPrefix + "agent-identifiers": "host=cluster1",
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "update")
+			},
+		},
+		{
+			name: "replace the stale tunnel endpoints",
+			hubObjects: []runtime.Object{newTunnelEndpointsSecret(map[string]string{
+				"agent-identifiers": "host=cluster1",
+				"proxy-port":        "8090",
+			})},
+			metadata:        map[string]string{"agent-identifiers": "host=cluster2"},
+			expectedUpdated: true,
+			expectedData: map[string]string{
+				helpers.ClusterEndpointsURLsKey:                               "",
+				helpers.ClusterEndpointsCABundleKey:                           "",
+				helpers.ClusterEndpointsTunnelKeyPrefix + "agent-identifiers": "host=cluster2",
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "update")
+			},
+		},
+		{
+			name:       "tunnel endpoints are not changed",
+			hubObjects: []runtime.Object{newTunnelEndpointsSecret(map[string]string{"agent-identifiers": "host=cluster1"})},
+			metadata:   map[string]string{"agent-identifiers": "host=cluster1"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			hubKubeClient := kubefake.NewSimpleClientset(c.hubObjects...)
+			publisher, err := NewEndpointPublisher(EndpointPublisherSecret, testinghelpers.TestManagedClusterName,
+				hubKubeClient.CoreV1(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			updated, err := publisher.Publish(context.TODO(), c.metadata)
+			testinghelpers.AssertError(t, err, "")
+			if updated != c.expectedUpdated {
+				t.Errorf("expected updated %v, but got %v", c.expectedUpdated, updated)
+			}
+
+			actions := hubKubeClient.Actions()
+			c.validateActions(t, actions)
+			if !c.expectedUpdated {
+				return
+			}
+			secret := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+			data := map[string]string{}
+			for key, value := range secret.Data {
+				data[key] = string(value)
+			}
+			if !reflect.DeepEqual(data, c.expectedData) {
+				t.Errorf("expected data %v, but got %v", c.expectedData, data)
+			}
+		})
+	}
+}
+
+func TestClaimEndpointPublisher(t *testing.T) {
+	cluster := testinghelpers.NewJoinedManagedCluster()
+	cluster.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{
+		{Name: "id.k8s.io", Value: "cluster1"},
+		{Name: TunnelEndpointClaimPrefix + "proxy-port", Value: "8090"},
+	}
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	publisher, err := NewEndpointPublisher(EndpointPublisherClaims, testinghelpers.TestManagedClusterName, nil, clusterClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := publisher.Publish(context.TODO(), map[string]string{"agent-identifiers": "host=cluster1"})
+	testinghelpers.AssertError(t, err, "")
+	if !updated {
+		t.Errorf("expected the tunnel endpoint claims are updated")
+	}
+
+	actions := clusterClient.Actions()
+	testinghelpers.AssertActions(t, actions, "get", "patch")
+	patched := &clusterv1.ManagedCluster{}
+	testinghelpers.UnmarshalPatch(t, actions[1], patched)
+	expectedClaims := []clusterv1.ManagedClusterClaim{
+		{Name: "id.k8s.io", Value: "cluster1"},
+		{Name: TunnelEndpointClaimPrefix + "agent-identifiers", Value: "host=cluster1"},
+	}
+	if !reflect.DeepEqual(patched.Status.ClusterClaims, expectedClaims) {
+		t.Errorf("expected claims %v, but got %v", expectedClaims, patched.Status.ClusterClaims)
+	}
+}
+
+func TestValidateTunnelEndpointMetadata(t *testing.T) {
+	cases := []struct {
+		name        string
+		metadata    map[string]string
+		expectedErr string
+	}{
+		{
+			name:     "valid metadata",
+			metadata: map[string]string{"agent-identifiers": "host=cluster1", "proxy.port": "8090"},
+		},
+		{
+			name:        "invalid metadata name",
+			metadata:    map[string]string{"agent/identifiers": "host=cluster1"},
+			expectedErr: "tunnel endpoint metadata name \"agent/identifiers\" is invalid: a valid config key must consist of alphanumeric characters, '-', '_' or '.' (e.g. 'key.name',  or 'KEY_NAME',  or 'key-name', regex used for validation is '[-._a-zA-Z0-9]+')",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testinghelpers.AssertError(t, ValidateTunnelEndpointMetadata(c.metadata), c.expectedErr)
+		})
+	}
+}
+
+func newTunnelEndpointsSecret(metadata map[string]string) *corev1.Secret {
+	secret := newClusterEndpointsSecret("", "")
+	for name, value := range metadata {
+		secret.Data[helpers.ClusterEndpointsTunnelKeyPrefix+name] = []byte(value)
+	}
+	return secret
+}
@@ -0,0 +1,177 @@
+package managedcluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/health"
+	"open-cluster-management.io/registration/pkg/helpers"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+)
+
+const (
+	// UpgradePolicyNone, UpgradePolicyMajor, UpgradePolicyMinor and UpgradePolicyPatch are the policies of the
+	// agent upgrades, the upgrade is required once the version of the agent is behind the desired version by a
+	// major, minor or patch release, it is never required with the none policy.
+	UpgradePolicyNone  = "none"
+	UpgradePolicyMajor = "major"
+	UpgradePolicyMinor = "minor"
+	UpgradePolicyPatch = "patch"
+)
+
+// UpgradePolicies are the supported policies of the agent upgrades
+var UpgradePolicies = []string{UpgradePolicyNone, UpgradePolicyMajor, UpgradePolicyMinor, UpgradePolicyPatch}
+
+// managedClusterVersionController publishes the version of the agent with the annotation of the managed cluster,
+// and compares it with the desired version published on the hub, the UpgradeRequired condition is set once the
+// agent is behind the desired version beyond the upgrade policy. The condition is removed if there is no desired
+// version, so the clusters which are not managed by a rollout are left as they are.
+type managedClusterVersionController struct {
+	clusterName      string
+	agentVersion     string
+	upgradePolicy    string
+	hubClusterClient clientset.Interface
+	hubClusterLister clusterv1listers.ManagedClusterLister
+}
+
+// NewManagedClusterVersionController creates a new managed cluster version controller on the managed cluster.
+func NewManagedClusterVersionController(
+	clusterName, agentVersion, upgradePolicy string,
+	hubClusterClient clientset.Interface,
+	hubManagedClusterInformer clusterv1informer.ManagedClusterInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &managedClusterVersionController{
+		clusterName:      clusterName,
+		agentVersion:     agentVersion,
+		upgradePolicy:    upgradePolicy,
+		hubClusterClient: hubClusterClient,
+		hubClusterLister: hubManagedClusterInformer.Lister(),
+	}
+
+	return factory.New().
+		WithInformers(hubManagedClusterInformer.Informer()).
+		WithSync(health.DefaultRegistry.TrackSync("ManagedClusterVersionController", c.sync)).
+		ResyncEvery(10*time.Minute).
+		ToController("ManagedClusterVersionController", recorder)
+}
+
+func (c *managedClusterVersionController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedCluster, err := c.hubClusterLister.Get(c.clusterName)
+	if err != nil {
+		return fmt.Errorf("unable to get managed cluster with name %q from hub: %w", c.clusterName, err)
+	}
+
+	// the agent version is unknown in the development builds
+	if len(c.agentVersion) > 0 && managedCluster.Annotations[helpers.ManagedClusterAgentVersionAnnotation] != c.agentVersion {
+		// only the version annotation is patched, the other fields of the cluster are owned by the hub
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{helpers.ManagedClusterAgentVersionAnnotation: c.agentVersion},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := c.hubClusterClient.ClusterV1().ManagedClusters().Patch(
+			ctx, c.clusterName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("unable to update the agent version of managed cluster %q: %w", c.clusterName, err)
+		}
+		// the condition is updated once the updated cluster is synced
+		return nil
+	}
+
+	var updateFunc helpers.UpdateManagedClusterStatusFunc
+	desiredVersion := managedCluster.Annotations[helpers.ManagedClusterDesiredAgentVersionAnnotation]
+	switch {
+	case len(desiredVersion) > 0:
+		updateFunc = helpers.UpdateManagedClusterConditionFn(upgradeRequiredCondition(c.agentVersion, desiredVersion, c.upgradePolicy))
+	case meta.FindStatusCondition(managedCluster.Status.Conditions, helpers.ManagedClusterConditionUpgradeRequired) != nil:
+		updateFunc = removeConditionFn(helpers.ManagedClusterConditionUpgradeRequired)
+	default:
+		return nil
+	}
+	newStatus, updated, err := helpers.UpdateManagedClusterStatus(ctx, c.hubClusterClient, c.clusterName, updateFunc)
+	if err != nil {
+		return fmt.Errorf("unable to update status of managed cluster %q: %w", c.clusterName, err)
+	}
+	if updated && meta.IsStatusConditionTrue(newStatus.Conditions, helpers.ManagedClusterConditionUpgradeRequired) &&
+		!meta.IsStatusConditionTrue(managedCluster.Status.Conditions, helpers.ManagedClusterConditionUpgradeRequired) {
+		syncCtx.Recorder().Warningf("ManagedClusterUpgradeRequired", "The agent of managed cluster %q is required to upgrade to %s",
+			c.clusterName, desiredVersion)
+	}
+	return nil
+}
+
+// upgradeRequiredCondition compares the agent version with the desired version by the upgrade policy
+func upgradeRequiredCondition(agentVersion, desiredVersion, upgradePolicy string) metav1.Condition {
+	desired, err := utilversion.ParseGeneric(desiredVersion)
+	if err != nil {
+		return metav1.Condition{
+			Type:    helpers.ManagedClusterConditionUpgradeRequired,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "DesiredVersionInvalid",
+			Message: fmt.Sprintf("The desired agent version is invalid: %v", err),
+		}
+	}
+	agent, err := utilversion.ParseGeneric(agentVersion)
+	if err != nil {
+		return metav1.Condition{
+			Type:    helpers.ManagedClusterConditionUpgradeRequired,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "AgentVersionUnknown",
+			Message: fmt.Sprintf("The agent version %q is unknown", agentVersion),
+		}
+	}
+
+	if upgradeRequired(agent, desired, upgradePolicy) {
+		return metav1.Condition{
+			Type:   helpers.ManagedClusterConditionUpgradeRequired,
+			Status: metav1.ConditionTrue,
+			Reason: "AgentVersionOutdated",
+			Message: fmt.Sprintf("The agent version %s is behind the desired version %s beyond the %s upgrade policy",
+				agentVersion, desiredVersion, upgradePolicy),
+		}
+	}
+	return metav1.Condition{
+		Type:    helpers.ManagedClusterConditionUpgradeRequired,
+		Status:  metav1.ConditionFalse,
+		Reason:  "AgentVersionUpToDate",
+		Message: fmt.Sprintf("The agent version %s is within the %s upgrade policy of the desired version %s", agentVersion, upgradePolicy, desiredVersion),
+	}
+}
+
+// upgradeRequired returns true if the agent is behind the desired version by a release of the policy or above
+func upgradeRequired(agent, desired *utilversion.Version, upgradePolicy string) bool {
+	if !agent.LessThan(desired) {
+		return false
+	}
+	switch upgradePolicy {
+	case UpgradePolicyMajor:
+		return agent.Major() < desired.Major()
+	case UpgradePolicyMinor:
+		return agent.Major() < desired.Major() || (agent.Major() == desired.Major() && agent.Minor() < desired.Minor())
+	case UpgradePolicyPatch:
+		return true
+	}
+	return false
+}
+
+func removeConditionFn(conditionType string) helpers.UpdateManagedClusterStatusFunc {
+	return func(oldStatus *clusterv1.ManagedClusterStatus) error {
+		meta.RemoveStatusCondition(&oldStatus.Conditions, conditionType)
+		return nil
+	}
+}
@@ -0,0 +1,163 @@
+package managedcluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestSyncAgentVersion(t *testing.T) {
+	cases := []struct {
+		name            string
+		agentVersion    string
+		cluster         *clusterv1.ManagedCluster
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:         "publish agent version",
+			agentVersion: "v0.11.0",
+			cluster:      testinghelpers.NewJoinedManagedCluster(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "patch")
+				cluster := &clusterv1.ManagedCluster{}
+				testinghelpers.UnmarshalPatch(t, actions[0], cluster)
+				if version := cluster.Annotations[helpers.ManagedClusterAgentVersionAnnotation]; version != "v0.11.0" {
+					t.Errorf("expected agent version v0.11.0, but got %q", version)
+				}
+			},
+		},
+		{
+			name:            "unknown agent version",
+			cluster:         testinghelpers.NewJoinedManagedCluster(),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:            "no desired version",
+			agentVersion:    "v0.11.0",
+			cluster:         newVersionedManagedCluster("v0.11.0", ""),
+			validateActions: testinghelpers.AssertNoActions,
+		},
+		{
+			name:         "upgrade required",
+			agentVersion: "v0.10.2",
+			cluster:      newVersionedManagedCluster("v0.10.2", "v0.11.0"),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertUpgradeRequiredCondition(t, actions, metav1.ConditionTrue, "AgentVersionOutdated")
+			},
+		},
+		{
+			name:         "upgrade not required",
+			agentVersion: "v0.11.0",
+			cluster:      newVersionedManagedCluster("v0.11.0", "v0.11.3"),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertUpgradeRequiredCondition(t, actions, metav1.ConditionFalse, "AgentVersionUpToDate")
+			},
+		},
+		{
+			name:         "invalid desired version",
+			agentVersion: "v0.11.0",
+			cluster:      newVersionedManagedCluster("v0.11.0", "latest"),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				assertUpgradeRequiredCondition(t, actions, metav1.ConditionUnknown, "DesiredVersionInvalid")
+			},
+		},
+		{
+			name:         "desired version removed",
+			agentVersion: "v0.11.0",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := newVersionedManagedCluster("v0.11.0", "")
+				meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+					Type:   helpers.ManagedClusterConditionUpgradeRequired,
+					Status: metav1.ConditionTrue,
+					Reason: "AgentVersionOutdated",
+				})
+				return cluster
+			}(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "patch")
+				cluster := &clusterv1.ManagedCluster{}
+				testinghelpers.UnmarshalPatch(t, actions[1], cluster)
+				if meta.FindStatusCondition(cluster.Status.Conditions, helpers.ManagedClusterConditionUpgradeRequired) != nil {
+					t.Errorf("expected the condition is removed, but got %v", cluster.Status.Conditions)
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := &managedClusterVersionController{
+				clusterName:      testinghelpers.TestManagedClusterName,
+				agentVersion:     c.agentVersion,
+				upgradePolicy:    UpgradePolicyMinor,
+				hubClusterClient: clusterClient,
+				hubClusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+			}
+			if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "")); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func TestUpgradeRequiredCondition(t *testing.T) {
+	cases := []struct {
+		agentVersion   string
+		desiredVersion string
+		upgradePolicy  string
+		expectedStatus metav1.ConditionStatus
+	}{
+		{agentVersion: "v0.11.0", desiredVersion: "v1.0.0", upgradePolicy: UpgradePolicyMajor, expectedStatus: metav1.ConditionTrue},
+		{agentVersion: "v1.0.0", desiredVersion: "v1.2.0", upgradePolicy: UpgradePolicyMajor, expectedStatus: metav1.ConditionFalse},
+		{agentVersion: "v0.10.2", desiredVersion: "v0.11.0", upgradePolicy: UpgradePolicyMinor, expectedStatus: metav1.ConditionTrue},
+		{agentVersion: "v0.11.0", desiredVersion: "v0.11.1", upgradePolicy: UpgradePolicyMinor, expectedStatus: metav1.ConditionFalse},
+		{agentVersion: "v0.11.0", desiredVersion: "v0.11.1", upgradePolicy: UpgradePolicyPatch, expectedStatus: metav1.ConditionTrue},
+		{agentVersion: "v0.12.0", desiredVersion: "v0.11.1", upgradePolicy: UpgradePolicyPatch, expectedStatus: metav1.ConditionFalse},
+		{agentVersion: "v0.10.0", desiredVersion: "v1.0.0", upgradePolicy: UpgradePolicyNone, expectedStatus: metav1.ConditionFalse},
+		{agentVersion: "v0.11.0-12-gabcdef", desiredVersion: "v0.12.0", upgradePolicy: UpgradePolicyMinor, expectedStatus: metav1.ConditionTrue},
+		{agentVersion: "", desiredVersion: "v0.12.0", upgradePolicy: UpgradePolicyMinor, expectedStatus: metav1.ConditionUnknown},
+	}
+	for _, c := range cases {
+		cond := upgradeRequiredCondition(c.agentVersion, c.desiredVersion, c.upgradePolicy)
+		if cond.Status != c.expectedStatus {
+			t.Errorf("expected %s for agent version %q, desired version %q and %s policy, but got %s",
+				c.expectedStatus, c.agentVersion, c.desiredVersion, c.upgradePolicy, cond.Status)
+		}
+	}
+}
+
+func newVersionedManagedCluster(agentVersion, desiredVersion string) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewJoinedManagedCluster()
+	cluster.Annotations = map[string]string{helpers.ManagedClusterAgentVersionAnnotation: agentVersion}
+	if len(desiredVersion) > 0 {
+		cluster.Annotations[helpers.ManagedClusterDesiredAgentVersionAnnotation] = desiredVersion
+	}
+	return cluster
+}
+
+func assertUpgradeRequiredCondition(t *testing.T, actions []clienttesting.Action, status metav1.ConditionStatus, reason string) {
+	testinghelpers.AssertActions(t, actions, "get", "patch")
+	cluster := &clusterv1.ManagedCluster{}
+	testinghelpers.UnmarshalPatch(t, actions[1], cluster)
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, helpers.ManagedClusterConditionUpgradeRequired)
+	if cond == nil || cond.Status != status || cond.Reason != reason {
+		t.Errorf("expected the condition %s with reason %s, but got %v", status, reason, cond)
+	}
+}
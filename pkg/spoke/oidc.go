@@ -0,0 +1,86 @@
+package spoke
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"open-cluster-management.io/registration/pkg/features"
+	"open-cluster-management.io/registration/pkg/transport"
+)
+
+// oidcTokenClaims are the claims of the token identifying the agent on the hub
+type oidcTokenClaims struct {
+	Issuer  string `json:"iss"`
+	Subject string `json:"sub"`
+}
+
+// validateOIDCOptions verifies the options of the oidc registration driver
+func (o *SpokeAgentOptions) validateOIDCOptions() error {
+	if !features.DefaultSpokeMutableFeatureGate.Enabled(features.OIDCRegistrationDriver) {
+		return fmt.Errorf("registration driver %q requires the OIDCRegistrationDriver feature", o.RegistrationDriver)
+	}
+	if o.HubTokenFile == "" {
+		return errors.New("hub token file is required by the oidc registration driver")
+	}
+	// the mqtt transport authenticates to the broker with the hub client certificate, which is not issued
+	if o.RegistrationTransport == transport.TransportMQTT {
+		return errors.New("registration transport \"mqtt\" is not supported by the oidc registration driver")
+	}
+	return nil
+}
+
+// buildOIDCKubeconfig builds the hub kubeconfig which authenticates to the hub with the token in the hub token file.
+// The token is a service account token projected with the audience of the hub, it is rotated by the kubelet and
+// reloaded by the hub clients, so there is no long-lived credential of the agent.
+func (o *SpokeAgentOptions) buildOIDCKubeconfig(bootstrapClientConfig *rest.Config) clientcmdapi.Config {
+	return clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{"default-cluster": {
+			Server:                   bootstrapClientConfig.Host,
+			InsecureSkipTLSVerify:    false,
+			CertificateAuthorityData: bootstrapClientConfig.CAData,
+		}},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{"default-auth": {
+			TokenFile: o.HubTokenFile,
+		}},
+		Contexts: map[string]*clientcmdapi.Context{"default-context": {
+			Cluster:   "default-cluster",
+			AuthInfo:  "default-auth",
+			Namespace: "configuration",
+		}},
+		CurrentContext: "default-context",
+	}
+}
+
+// loadOIDCTokenClaims returns the issuer and subject claims of the token in the file. The token is not verified,
+// the claims are only logged for the hub admins, who annotate the cluster with them.
+func loadOIDCTokenClaims(tokenFile string) (*oidcTokenClaims, error) {
+	data, err := ioutil.ReadFile(path.Clean(tokenFile))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the hub token file %q: %w", tokenFile, err)
+	}
+
+	parts := strings.Split(strings.TrimSpace(string(data)), ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("the token in the hub token file %q is not a JWT", tokenFile)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the token in the hub token file %q: %w", tokenFile, err)
+	}
+	claims := &oidcTokenClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, fmt.Errorf("unable to decode the token in the hub token file %q: %w", tokenFile, err)
+	}
+	if claims.Issuer == "" || claims.Subject == "" {
+		return nil, fmt.Errorf("the token in the hub token file %q has no iss or sub claim", tokenFile)
+	}
+	return claims, nil
+}
@@ -0,0 +1,150 @@
+package spoke
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/rest"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/features"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestValidateOIDCOptions(t *testing.T) {
+	cases := []struct {
+		name        string
+		options     *SpokeAgentOptions
+		enabled     bool
+		expectedErr string
+	}{
+		{
+			name: "without feature",
+			options: &SpokeAgentOptions{
+				RegistrationDriver: clientcert.RegistrationDriverOIDC,
+				HubTokenFile:       "/var/run/secrets/hub/token",
+			},
+			expectedErr: "registration driver \"oidc\" requires the OIDCRegistrationDriver feature",
+		},
+		{
+			name: "valid options",
+			options: &SpokeAgentOptions{
+				RegistrationDriver: clientcert.RegistrationDriverOIDC,
+				HubTokenFile:       "/var/run/secrets/hub/token",
+			},
+			enabled: true,
+		},
+		{
+			name: "no hub token file",
+			options: &SpokeAgentOptions{
+				RegistrationDriver: clientcert.RegistrationDriverOIDC,
+			},
+			enabled:     true,
+			expectedErr: "hub token file is required by the oidc registration driver",
+		},
+		{
+			name: "mqtt transport",
+			options: &SpokeAgentOptions{
+				RegistrationDriver:    clientcert.RegistrationDriverOIDC,
+				HubTokenFile:          "/var/run/secrets/hub/token",
+				RegistrationTransport: "mqtt",
+			},
+			enabled:     true,
+			expectedErr: "registration transport \"mqtt\" is not supported by the oidc registration driver",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := features.DefaultSpokeMutableFeatureGate.Set(
+				fmt.Sprintf("%s=%t", string(features.OIDCRegistrationDriver), c.enabled)); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				if err := features.DefaultSpokeMutableFeatureGate.Set(
+					fmt.Sprintf("%s=false", string(features.OIDCRegistrationDriver))); err != nil {
+					t.Fatal(err)
+				}
+			}()
+			testinghelpers.AssertError(t, c.options.validateRegistrationDriverOptions(), c.expectedErr)
+		})
+	}
+}
+
+func TestBuildOIDCKubeconfig(t *testing.T) {
+	options := &SpokeAgentOptions{HubTokenFile: "/var/run/secrets/hub/token"}
+	kubeconfig := options.buildOIDCKubeconfig(&rest.Config{
+		Host:            "https://hub.example.com",
+		TLSClientConfig: rest.TLSClientConfig{CAData: []byte("ca")},
+	})
+
+	cluster := kubeconfig.Clusters["default-cluster"]
+	if cluster.Server != "https://hub.example.com" || string(cluster.CertificateAuthorityData) != "ca" {
+		t.Errorf("unexpected cluster %v", cluster)
+	}
+	if tokenFile := kubeconfig.AuthInfos["default-auth"].TokenFile; tokenFile != "/var/run/secrets/hub/token" {
+		t.Errorf("expected the hub token file, but got %q", tokenFile)
+	}
+}
+
+func TestOIDCIdentityAnnotations(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "testoidcidentityannotations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	cases := []struct {
+		name                string
+		token               string
+		expectedAnnotations map[string]string
+		expectedErr         string
+	}{
+		{
+			name:  "token with claims",
+			token: newTestJWT(`{"iss":"https://issuer.cluster1.example.com","sub":"system:serviceaccount:agent:klusterlet"}`),
+			expectedAnnotations: map[string]string{
+				helpers.ManagedClusterOIDCIssuerAnnotation:  "https://issuer.cluster1.example.com",
+				helpers.ManagedClusterOIDCSubjectAnnotation: "system:serviceaccount:agent:klusterlet",
+			},
+		},
+		{
+			name:        "token without subject",
+			token:       newTestJWT(`{"iss":"https://issuer.cluster1.example.com"}`),
+			expectedErr: fmt.Sprintf("the token in the hub token file %q has no iss or sub claim", path.Join(tempDir, "token")),
+		},
+		{
+			name:        "not a jwt",
+			token:       "token",
+			expectedErr: fmt.Sprintf("the token in the hub token file %q is not a JWT", path.Join(tempDir, "token")),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testinghelpers.WriteFile(path.Join(tempDir, "token"), []byte(c.token))
+			options := &SpokeAgentOptions{
+				RegistrationDriver: clientcert.RegistrationDriverOIDC,
+				HubTokenFile:       path.Join(tempDir, "token"),
+			}
+
+			annotations, err := options.identityAnnotations()
+			testinghelpers.AssertError(t, err, c.expectedErr)
+			if !reflect.DeepEqual(annotations, c.expectedAnnotations) {
+				t.Errorf("expected annotations %v, but got %v", c.expectedAnnotations, annotations)
+			}
+		})
+	}
+}
+
+func newTestJWT(claims string) string {
+	return fmt.Sprintf("%s.%s.signature",
+		base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(claims)))
+}
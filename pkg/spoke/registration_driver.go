@@ -0,0 +1,163 @@
+package spoke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certificatesinformers "k8s.io/client-go/informers/certificates"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/features"
+	"open-cluster-management.io/registration/pkg/helpers"
+)
+
+// validateRegistrationDriverOptions verifies the options of the driver to request the client certificates
+func (o *SpokeAgentOptions) validateRegistrationDriverOptions() error {
+	switch o.RegistrationDriver {
+	case "", clientcert.RegistrationDriverCSR:
+		return nil
+	case clientcert.RegistrationDriverGRPC:
+		if !features.DefaultSpokeMutableFeatureGate.Enabled(features.GRPCRegistrationDriver) {
+			return fmt.Errorf("registration driver %q requires the GRPCRegistrationDriver feature", o.RegistrationDriver)
+		}
+		if o.RegistrationServiceAddress == "" {
+			return errors.New("registration service address is required by the grpc registration driver")
+		}
+		return nil
+	case clientcert.RegistrationDriverAWSIRSA:
+		return o.validateAWSIAMOptions()
+	case clientcert.RegistrationDriverOIDC:
+		return o.validateOIDCOptions()
+	default:
+		return fmt.Errorf("registration driver %q is invalid, it must be one of %v", o.RegistrationDriver, clientcert.RegistrationDrivers)
+	}
+}
+
+// newRegistrationDriver returns the driver to request the client certificates from the hub. The grpc driver
+// connects to the registration service with the tls config of the hub client config, the connection is closed once
+// the context is done.
+func (o *SpokeAgentOptions) newRegistrationDriver(
+	ctx context.Context,
+	hubClientConfig *rest.Config,
+	hubCSRInformer certificatesinformers.Interface,
+	hubKubeClient kubernetes.Interface) (clientcert.RegistrationDriver, error) {
+	if o.RegistrationDriver != clientcert.RegistrationDriverGRPC {
+		return clientcert.NewCSRDriver(hubCSRInformer, hubKubeClient)
+	}
+
+	tlsConfig, err := rest.TLSConfigFor(hubClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build the tls config of the registration service: %w", err)
+	}
+	return clientcert.NewGRPCDriver(ctx, o.RegistrationServiceAddress, tlsConfig)
+}
+
+// usesClientCertificates returns false if the agent authenticates to the hub with tokens instead of client
+// certificates, there is no client certificate of the cluster to request or rotate in that case.
+func (o *SpokeAgentOptions) usesClientCertificates() bool {
+	return o.RegistrationDriver != clientcert.RegistrationDriverAWSIRSA &&
+		o.RegistrationDriver != clientcert.RegistrationDriverOIDC
+}
+
+// identityAnnotations returns the identity annotations the hub admins set on the managed cluster, so the hub grants
+// the roles of the cluster to the identity the agent authenticates with if it does not use client certificates. The
+// agent cannot set them itself, they are only logged for the admins.
+func (o *SpokeAgentOptions) identityAnnotations() (map[string]string, error) {
+	switch o.RegistrationDriver {
+	case clientcert.RegistrationDriverAWSIRSA:
+		return map[string]string{helpers.ManagedClusterIAMRoleARNAnnotation: o.managedClusterIAMRoleARN()}, nil
+	case clientcert.RegistrationDriverOIDC:
+		claims, err := loadOIDCTokenClaims(o.HubTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{
+			helpers.ManagedClusterOIDCIssuerAnnotation:  claims.Issuer,
+			helpers.ManagedClusterOIDCSubjectAnnotation: claims.Subject,
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// buildTokenHubKubeconfig builds the hub kubeconfig of the drivers which do not use client certificates
+func (o *SpokeAgentOptions) buildTokenHubKubeconfig(bootstrapClientConfig *rest.Config) (clientcmdapi.Config, error) {
+	if o.RegistrationDriver == clientcert.RegistrationDriverAWSIRSA {
+		return o.buildAWSIAMKubeconfig(bootstrapClientConfig)
+	}
+	return o.buildOIDCKubeconfig(bootstrapClientConfig), nil
+}
+
+// saveHubKubeconfigSecret saves the hub kubeconfig with the cluster and agent names into the hub kubeconfig secret,
+// it is used by the drivers which do not use client certificates since there is no certificate to request.
+func (o *SpokeAgentOptions) saveHubKubeconfigSecret(
+	ctx context.Context, client corev1client.SecretsGetter, kubeconfigData []byte) error {
+	data := map[string][]byte{
+		clientcert.KubeconfigFile:  kubeconfigData,
+		clientcert.ClusterNameFile: []byte(o.ClusterName),
+		clientcert.AgentNameFile:   []byte(o.AgentName),
+	}
+
+	secret, err := client.Secrets(o.ComponentNamespace).Get(ctx, o.HubKubeconfigSecret, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = client.Secrets(o.ComponentNamespace).Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: o.ComponentNamespace,
+				Name:      o.HubKubeconfigSecret,
+			},
+			Data: data,
+		}, metav1.CreateOptions{})
+		return err
+	case err != nil:
+		return err
+	}
+
+	secret = secret.DeepCopy()
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for key, value := range data {
+		secret.Data[key] = value
+	}
+	_, err = client.Secrets(o.ComponentNamespace).Update(ctx, secret, metav1.UpdateOptions{})
+	return err
+}
+
+// hasValidTokenHubClientConfig returns true if the kubeconfig file exists and the cluster and agent name files
+// are of the current cluster/agent. The tokens are issued or refreshed on demand, they are not checked.
+func (o *SpokeAgentOptions) hasValidTokenHubClientConfig() (bool, error) {
+	kubeconfigPath := path.Join(o.HubKubeconfigDir, clientcert.KubeconfigFile)
+	if _, err := os.Stat(kubeconfigPath); os.IsNotExist(err) {
+		klog.V(4).Infof("Kubeconfig file %q not found", kubeconfigPath)
+		return false, nil
+	}
+
+	for file, expected := range map[string]string{
+		clientcert.ClusterNameFile: o.ClusterName,
+		clientcert.AgentNameFile:   o.AgentName,
+	} {
+		data, err := ioutil.ReadFile(path.Clean(path.Join(o.HubKubeconfigDir, file)))
+		if err != nil {
+			klog.V(4).Infof("Unable to load file %q", file)
+			return false, nil
+		}
+		if string(data) != expected {
+			klog.V(4).Infof("The hub kubeconfig is for %q instead of %q", string(data), expected)
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,113 @@
+package spoke
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSaveHubKubeconfigSecret(t *testing.T) {
+	options := &SpokeAgentOptions{
+		ClusterName:         "testcluster",
+		AgentName:           "testagent",
+		ComponentNamespace:  "open-cluster-management-agent",
+		HubKubeconfigSecret: "hub-kubeconfig-secret",
+	}
+	kubeClient := kubefake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "open-cluster-management-agent",
+			Name:      "hub-kubeconfig-secret",
+		},
+		Data: map[string][]byte{clientcert.TLSCertFile: []byte("cert")},
+	})
+
+	if err := options.saveHubKubeconfigSecret(context.TODO(), kubeClient.CoreV1(), []byte("kubeconfig")); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := kubeClient.CoreV1().Secrets("open-cluster-management-agent").Get(
+		context.TODO(), "hub-kubeconfig-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]byte{
+		clientcert.TLSCertFile:     []byte("cert"),
+		clientcert.KubeconfigFile:  []byte("kubeconfig"),
+		clientcert.ClusterNameFile: []byte("testcluster"),
+		clientcert.AgentNameFile:   []byte("testagent"),
+	}
+	if !reflect.DeepEqual(secret.Data, expected) {
+		t.Errorf("expected secret data %v, but got %v", expected, secret.Data)
+	}
+}
+
+func TestHasValidTokenHubClientConfig(t *testing.T) {
+	cases := []struct {
+		name        string
+		files       map[string][]byte
+		clusterName string
+		isValid     bool
+	}{
+		{
+			name:        "no kubeconfig",
+			clusterName: "cluster1",
+			isValid:     false,
+		},
+		{
+			name: "hub kubeconfig of another cluster",
+			files: map[string][]byte{
+				clientcert.KubeconfigFile:  testinghelpers.NewKubeconfig(nil, nil),
+				clientcert.ClusterNameFile: []byte("cluster2"),
+				clientcert.AgentNameFile:   []byte("agent1"),
+			},
+			clusterName: "cluster1",
+			isValid:     false,
+		},
+		{
+			name: "valid hub client config",
+			files: map[string][]byte{
+				clientcert.KubeconfigFile:  testinghelpers.NewKubeconfig(nil, nil),
+				clientcert.ClusterNameFile: []byte("cluster1"),
+				clientcert.AgentNameFile:   []byte("agent1"),
+			},
+			clusterName: "cluster1",
+			isValid:     true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tempDir, err := ioutil.TempDir("", "testvalidtokenhubclientconfig")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tempDir)
+			for file, data := range c.files {
+				testinghelpers.WriteFile(path.Join(tempDir, file), data)
+			}
+
+			options := &SpokeAgentOptions{
+				ClusterName:        c.clusterName,
+				AgentName:          "agent1",
+				HubKubeconfigDir:   tempDir,
+				RegistrationDriver: clientcert.RegistrationDriverOIDC,
+			}
+			valid, err := options.hasValidHubClientConfig()
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if c.isValid != valid {
+				t.Errorf("expect %t, but %t", c.isValid, valid)
+			}
+		})
+	}
+}
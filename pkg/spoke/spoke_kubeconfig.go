@@ -0,0 +1,66 @@
+package spoke
+
+import (
+	"context"
+	"io/ioutil"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/fileobserver"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+// spokeKubeconfigObserveInterval is the interval to check the changes of the spoke kubeconfig files
+var spokeKubeconfigObserveInterval = 10 * time.Second
+
+// watchSpokeKubeconfig observes the spoke kubeconfig file and the files referenced by it in Detached mode, the
+// spoke kubeconfig is usually mounted from a secret on the management cluster which is rotated by the hosting
+// platform. The returned context is cancelled once any of the files is changed, so the agent exits and is
+// restarted with the rotated kubeconfig.
+func (o *SpokeAgentOptions) watchSpokeKubeconfig(ctx context.Context, spokeClientConfig *rest.Config) (context.Context, error) {
+	if o.SpokeKubeconfig == "" {
+		return ctx, nil
+	}
+
+	observer, err := fileobserver.NewObserver(spokeKubeconfigObserveInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	files := spokeKubeconfigFiles(o.SpokeKubeconfig, spokeClientConfig)
+	startingContent := map[string][]byte{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			// the file may be created later, the observer reacts to its creation
+			klog.Warningf("Unable to read the initial content of %q: %v", file, err)
+			continue
+		}
+		startingContent[file] = data
+	}
+
+	watchCtx, terminate := context.WithCancel(ctx)
+	observer.AddReactor(func(filename string, action fileobserver.ActionType) error {
+		klog.Infof("Exiting because the spoke kubeconfig file %q is changed", filename)
+		terminate()
+		return nil
+	}, startingContent, files...)
+
+	go observer.Run(watchCtx.Done())
+	return watchCtx, nil
+}
+
+// spokeKubeconfigFiles returns the spoke kubeconfig file and the certificate, key and CA files referenced by it
+func spokeKubeconfigFiles(spokeKubeconfig string, spokeClientConfig *rest.Config) []string {
+	files := []string{spokeKubeconfig}
+	for _, file := range []string{
+		spokeClientConfig.TLSClientConfig.CertFile,
+		spokeClientConfig.TLSClientConfig.KeyFile,
+		spokeClientConfig.TLSClientConfig.CAFile,
+	} {
+		if len(file) > 0 {
+			files = append(files, file)
+		}
+	}
+	return files
+}
@@ -0,0 +1,89 @@
+package spoke
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestSpokeKubeconfigFiles(t *testing.T) {
+	cases := []struct {
+		name          string
+		config        *rest.Config
+		expectedFiles []string
+	}{
+		{
+			name:          "kubeconfig with embedded data",
+			config:        &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAData: []byte("ca")}},
+			expectedFiles: []string{"/spoke/config/kubeconfig"},
+		},
+		{
+			name: "kubeconfig with referenced files",
+			config: &rest.Config{TLSClientConfig: rest.TLSClientConfig{
+				CertFile: "/spoke/config/tls.crt",
+				KeyFile:  "/spoke/config/tls.key",
+				CAFile:   "/spoke/config/ca.crt",
+			}},
+			expectedFiles: []string{
+				"/spoke/config/kubeconfig",
+				"/spoke/config/tls.crt",
+				"/spoke/config/tls.key",
+				"/spoke/config/ca.crt",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			files := spokeKubeconfigFiles("/spoke/config/kubeconfig", c.config)
+			if !reflect.DeepEqual(files, c.expectedFiles) {
+				t.Errorf("expected %v, but got %v", c.expectedFiles, files)
+			}
+		})
+	}
+}
+
+func TestWatchSpokeKubeconfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spoke-kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kubeconfigFile := path.Join(dir, "kubeconfig")
+	if err := ioutil.WriteFile(kubeconfigFile, []byte("kubeconfig"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	interval := spokeKubeconfigObserveInterval
+	spokeKubeconfigObserveInterval = 100 * time.Millisecond
+	defer func() { spokeKubeconfigObserveInterval = interval }()
+
+	o := &SpokeAgentOptions{SpokeKubeconfig: kubeconfigFile}
+	ctx, err := o.watchSpokeKubeconfig(context.TODO(), &rest.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the agent keeps running if the kubeconfig is not changed
+	select {
+	case <-ctx.Done():
+		t.Fatalf("unexpected termination")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	if err := ioutil.WriteFile(kubeconfigFile, []byte("rotated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected the context to be cancelled after the kubeconfig is rotated")
+	}
+}
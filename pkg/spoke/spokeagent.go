@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"path"
+	"strings"
 	"time"
 
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
@@ -18,9 +20,14 @@ import (
 	clusterv1informers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	"open-cluster-management.io/registration/pkg/clientcert"
 	"open-cluster-management.io/registration/pkg/features"
+	"open-cluster-management.io/registration/pkg/health"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/logging"
 	"open-cluster-management.io/registration/pkg/spoke/addon"
 	"open-cluster-management.io/registration/pkg/spoke/managedcluster"
+	"open-cluster-management.io/registration/pkg/transport"
+	"open-cluster-management.io/registration/pkg/transport/mqtt"
+	"open-cluster-management.io/registration/pkg/version"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -28,10 +35,13 @@ import (
 
 	"github.com/spf13/pflag"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
@@ -39,6 +49,7 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 )
 
 const (
@@ -48,6 +59,16 @@ const (
 	defaultSpokeComponentNamespace = "open-cluster-management-agent"
 )
 
+const (
+	// DeployModeDefault and DeployModeDetached are the deploy modes of the agent, the agent runs on the managed
+	// cluster in Default mode, and on a management cluster with the spoke kubeconfig in Detached mode.
+	DeployModeDefault  = "Default"
+	DeployModeDetached = "Detached"
+)
+
+// DeployModes are the supported deploy modes of the agent
+var DeployModes = []string{DeployModeDefault, DeployModeDetached}
+
 // AddOnLeaseControllerSyncInterval is exposed so that integration tests can crank up the constroller sync speed.
 // TODO if we register the lease informer to the lease controller, we need to increase this time
 var AddOnLeaseControllerSyncInterval = 30 * time.Second
@@ -65,15 +86,149 @@ type SpokeAgentOptions struct {
 	MaxCustomClusterClaims      int
 	SpokeKubeconfig             string
 	ClientCertExpirationSeconds int32
+	BootstrapToken              string
+	HubAPIServer                string
+	HubCACertHashes             []string
+	BootstrapKubeconfigSecret   string
+	// SyncHubCABundle enables refreshing the CA of the hub kubeconfig with the hub CA bundle published by the hub
+	SyncHubCABundle bool
+	// ReloadHubKubeconfig enables rebuilding the hub clients and restarting the controllers talking to the hub
+	// in-process once the files in the HubKubeconfigDir are changed, instead of restarting the agent
+	ReloadHubKubeconfig bool
+	// PublishClusterEndpoints enables publishing the api server urls and CA bundle of the managed cluster to the hub,
+	// they are discovered from the cluster-info configmap unless the external server urls are specified
+	PublishClusterEndpoints bool
+	// TunnelEndpointMetadata is the metadata of the tunnels the hub components reach the managed cluster through,
+	// e.g. the identifiers of the konnectivity agent, it is published with the TunnelEndpointPublisher.
+	// EndpointPublisher is not a flag but set by the downstream distributions to substitute their own publisher.
+	TunnelEndpointMetadata     map[string]string
+	TunnelEndpointPublisher    string
+	EndpointPublisher          managedcluster.EndpointPublisher
+	ClientCertRenewalThreshold float64
+	ClientCertRenewalJitter    float64
+	MaxConcurrentAddOnCSRs     int
+	// CSRBackoffInitial, CSRBackoffMax, CSRBackoffMultiplier and CSRMaxAttempts throttle the csrs of the cluster
+	// which are not issued with client certificates, e.g. the csrs waiting for the manual approval expire
+	CSRBackoffInitial    time.Duration
+	CSRBackoffMax        time.Duration
+	CSRBackoffMultiplier float64
+	CSRMaxAttempts       int
+	ClientCertKeyType    string
+	ClientCertRSAKeySize int
+	ClientCertECDSACurve string
+	// ClientCertKeyProvider is the name of the provider generating and holding the private keys of the client
+	// certificates, it is one of the registered key providers of clientcert
+	ClientCertKeyProvider string
+	// ServingCertDNSNames and ServingCertIPAddresses are the subject alternative names of the serving certificate
+	// requested from the cluster serving signer of the hub for the mTLS callbacks to the cluster, the certificate is
+	// stored in the ServingCertSecret. It is not requested if no names are set.
+	ServingCertDNSNames    []string
+	ServingCertIPAddresses []net.IP
+	ServingCertSecret      string
+	// ClusterIdentityKeyFile is the file of the pre-shared key of the cluster, the csrs of the cluster are annotated
+	// with the identity proofs made with the key if it is set
+	ClusterIdentityKeyFile string
+	// RegistrationDriver is the driver to request the client certificates of the agent from the hub, the grpc
+	// driver talks to the registration service at the RegistrationServiceAddress
+	RegistrationDriver         string
+	RegistrationServiceAddress string
+	// HubClusterARN and ManagedClusterIAMRoleARN configure the awsirsa registration driver, the agent authenticates
+	// to the EKS hub of the HubClusterARN with the IAM role of the ManagedClusterIAMRoleARN
+	HubClusterARN            string
+	ManagedClusterIAMRoleARN string
+	// HubTokenFile is the file of the service account token projected with the audience of the hub, the agent
+	// authenticates to the hub with it by the oidc registration driver
+	HubTokenFile          string
+	RegistrationTransport string
+	MQTTBrokerURL         string
+	MQTTCAFile            string
+	AvailabilityProbes    []string
+	// HealthCheckStrategies are the methods to check the health of the kube-apiserver of the managed cluster, they
+	// are tried in order since the managed kubernetes providers restrict some endpoints
+	HealthCheckStrategies []string
+	// HealthCheckCAFile is the CA file to verify the servers of the url health check strategies
+	HealthCheckCAFile string
+	// ClusterResourceNodeSelector, ClusterResourceExcludedNodeTaints, ClusterResourceExcludeNotReadyNodes,
+	// ClusterResourceExcludeCordonedNodes, ClusterResourceRoleBreakdown, ClusterResourcePlatformBreakdown and
+	// ClusterResourceExtendedResources configure how the capacity and allocatable of the managed cluster are
+	// aggregated from the nodes
+	ClusterResourceNodeSelector         string
+	ClusterResourceExcludedNodeTaints   []string
+	ClusterResourceExcludeNotReadyNodes bool
+	ClusterResourceExcludeCordonedNodes bool
+	ClusterResourceRoleBreakdown        bool
+	ClusterResourcePlatformBreakdown    bool
+	ClusterResourceExtendedResources    []string
+	// ClusterResourceUpdateMinInterval and ClusterResourceUpdateThresholdPercent throttle the updates of the
+	// capacity and allocatable of the managed cluster caused by the node churn
+	ClusterResourceUpdateMinInterval      time.Duration
+	ClusterResourceUpdateThresholdPercent float64
+	// NodeSummaryClaims reports the counts of the nodes by the OS, architecture and kubelet minor version with the
+	// cluster claims, so the upgrades can be planned on the hub without listing the nodes of the managed clusters
+	NodeSummaryClaims bool
+	// ClusterLabels and ClusterAnnotations are set on the ManagedCluster when it is created by the agent, so the
+	// provisioning systems stamp the metadata, e.g. the environment or owner, on the cluster at join time
+	ClusterLabels      map[string]string
+	ClusterAnnotations map[string]string
+	// ReconciledClusterSpecFields are the spec fields of the ManagedCluster kept reconciled by the agent after the
+	// cluster is created, the client configs are built with the SpokeExternalServerURLs and the lease duration is
+	// the ClusterLeaseDurationSeconds
+	ReconciledClusterSpecFields []string
+	ClusterLeaseDurationSeconds int32
+	// AgentUpgradePolicy is the policy to require the upgrade of the agent once its version is behind the desired
+	// version published on the hub
+	AgentUpgradePolicy          string
+	HealthProbeBindAddress      string
+	HealthProbeFailureThreshold time.Duration
+	// SpokeKubeAPIQPS, SpokeKubeAPIBurst, HubKubeAPIQPS and HubKubeAPIBurst are the rate limits of the clients of
+	// the managed cluster and the hub, so the traffic to the hub and the managed cluster are tuned separately
+	SpokeKubeAPIQPS   float32
+	SpokeKubeAPIBurst int
+	HubKubeAPIQPS     float32
+	HubKubeAPIBurst   int
+	// LeaseClientQPS, LeaseClientBurst and LeaseRequestTimeout configure the hub client dedicated to the lease
+	// renewals, so the renewals are neither starved by the other controllers nor blocked by a slow hub
+	LeaseClientQPS      float32
+	LeaseClientBurst    int
+	LeaseRequestTimeout time.Duration
+	// FeatureGatesFile is the file holding the feature gates, it is usually mounted from a ConfigMap so the feature
+	// gates can be toggled without editing the deployment
+	FeatureGatesFile string
+	// DeployMode is the deploy mode of the agent, the spoke kubeconfig is only watched for the rotation in Detached
+	// mode
+	DeployMode string
+	// LoggingFormat is the format of the log lines, text or json. ControllerLogLevels maps the names of the
+	// controllers to the verbosities of their syncs overriding the -v flag.
+	LoggingFormat       string
+	ControllerLogLevels map[string]int
 }
 
 // NewSpokeAgentOptions returns a SpokeAgentOptions
 func NewSpokeAgentOptions() *SpokeAgentOptions {
 	return &SpokeAgentOptions{
-		HubKubeconfigSecret:      "hub-kubeconfig-secret",
-		HubKubeconfigDir:         "/spoke/hub-kubeconfig",
-		ClusterHealthCheckPeriod: 1 * time.Minute,
-		MaxCustomClusterClaims:   20,
+		HubKubeconfigSecret:         "hub-kubeconfig-secret",
+		ServingCertSecret:           "registration-serving-cert",
+		HubKubeconfigDir:            "/spoke/hub-kubeconfig",
+		ClusterHealthCheckPeriod:    1 * time.Minute,
+		MaxCustomClusterClaims:      20,
+		ClientCertRenewalThreshold:  clientcert.DefaultRenewalThreshold,
+		ClientCertRenewalJitter:     clientcert.DefaultRenewalJitter,
+		CSRBackoffMax:               clientcert.DefaultCSRBackoffMax,
+		CSRBackoffMultiplier:        clientcert.DefaultCSRBackoffMultiplier,
+		ClientCertKeyType:           clientcert.KeyTypeECDSA,
+		ClientCertRSAKeySize:        clientcert.DefaultRSAKeySize,
+		ClientCertECDSACurve:        clientcert.DefaultECDSACurve,
+		ClientCertKeyProvider:       clientcert.KeyProviderSoftware,
+		RegistrationDriver:          clientcert.RegistrationDriverCSR,
+		RegistrationTransport:       transport.TransportKube,
+		AgentUpgradePolicy:          managedcluster.UpgradePolicyMinor,
+		TunnelEndpointPublisher:     managedcluster.EndpointPublisherSecret,
+		HealthProbeFailureThreshold: 10 * time.Minute,
+		LeaseClientQPS:              5,
+		LeaseClientBurst:            5,
+		LeaseRequestTimeout:         10 * time.Second,
+		LoggingFormat:               logging.FormatText,
+		DeployMode:                  DeployModeDefault,
 	}
 }
 
@@ -89,12 +244,14 @@ func NewSpokeAgentOptions() *SpokeAgentOptions {
 //     runs. In Default mode, it is the managed cluster's kubeconfig; in Detached mode, it is
 //     the management cluster's kubeconfig.
 //   - The 'spoke' kubeconfig: used to communicate with the spoke/managed cluster which will
-//     be registered to the hub.
+//     be registered to the hub. In Detached mode, the agent restarts once it is rotated.
 //   - The 'bootstrap' kubeconfig: used to communicate with the hub in order to
 //     submit a CertificateSigningRequest, begin the join flow with the hub, and
 //     to write the 'hub' kubeconfig.
 //   - The 'hub' kubeconfig: used to communicate with the hub using a signed
-//     certificate from the hub.
+//     certificate from the hub. If it is reloaded in-process, the hub clients
+//     are rebuilt and the controllers talking to the hub are restarted once it
+//     or the client certificate is changed.
 //
 // RunSpokeAgent handles the following scenarios:
 //
@@ -108,6 +265,10 @@ func NewSpokeAgentOptions() *SpokeAgentOptions {
 // create a valid hub kubeconfig. Once the hub kubeconfig is valid, the
 // temporary controller is stopped and the main controllers are started.
 func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
+	if err := logging.Setup(o.LoggingFormat, o.ControllerLogLevels); err != nil {
+		return err
+	}
+
 	// create management kube client
 	managementKubeClient, err := kubernetes.NewForConfig(controllerContext.KubeConfig)
 	if err != nil {
@@ -120,6 +281,22 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 	if err != nil {
 		return err
 	}
+	spokeClientConfig = helpers.WithRateLimit(spokeClientConfig, o.SpokeKubeAPIQPS, o.SpokeKubeAPIBurst)
+
+	// the feature gates file is loaded before the leader election, the agent exits to be restarted once any feature
+	// gate in it is toggled
+	ctx, err = features.WatchFeatureGatesFile(ctx, features.DefaultSpokeMutableFeatureGate, o.FeatureGatesFile)
+	if err != nil {
+		return err
+	}
+
+	// in Detached mode, the agent exits once the spoke kubeconfig is rotated
+	if o.DeployMode == DeployModeDetached {
+		ctx, err = o.watchSpokeKubeconfig(ctx, spokeClientConfig)
+		if err != nil {
+			return err
+		}
+	}
 
 	spokeKubeClient, err := kubernetes.NewForConfig(spokeClientConfig)
 	if err != nil {
@@ -136,9 +313,14 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 	}
 
 	klog.Infof("Cluster name is %q and agent name is %q", o.ClusterName, o.AgentName)
+	// all of the log lines of the controllers carry the cluster name
+	ctx = klog.NewContext(ctx, logging.FromContext(ctx).WithValues(logging.ClusterKey, o.ClusterName))
 
-	// create shared informer factory for spoke cluster
-	spokeKubeInformerFactory := informers.NewSharedInformerFactory(spokeKubeClient, 10*time.Minute)
+	// the agent is not ready until the bootstrap completes
+	health.DefaultRegistry.AddReadinessGate(bootstrapHealthComponent)
+	if len(o.HealthProbeBindAddress) > 0 {
+		go o.serveHealthProbes(ctx)
+	}
 
 	// get spoke cluster CA bundle
 	spokeClusterCABundle, err := o.getSpokeClusterCABundle(spokeClientConfig)
@@ -149,11 +331,56 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 	// create a shared informer factory with specific namespace for the management cluster.
 	namespacedManagementKubeInformerFactory := informers.NewSharedInformerFactoryWithOptions(managementKubeClient, 10*time.Minute, informers.WithNamespace(o.ComponentNamespace))
 
+	// record the bootstrap phases in a ConfigMap in the agent namespace
+	bootstrapStatusRecorder := managedcluster.NewBootstrapStatusRecorder(managementKubeClient.CoreV1(), o.ComponentNamespace)
+
+	// the key provider is shared by the client certificates of the cluster and the addons
+	keyProvider, err := o.clientCertKeyProvider()
+	if err != nil {
+		return err
+	}
+
+	// load the pre-shared key of the cluster to prove the cluster identity in the csrs
+	var identityKey []byte
+	if len(o.ClusterIdentityKeyFile) > 0 {
+		identityKey, err = ioutil.ReadFile(path.Clean(o.ClusterIdentityKeyFile))
+		if err != nil {
+			return fmt.Errorf("unable to read the cluster identity key file %q: %w", o.ClusterIdentityKeyFile, err)
+		}
+	}
+
+	spokeClusterClient, err := clusterv1client.NewForConfig(spokeClientConfig)
+	if err != nil {
+		return err
+	}
+
+	// annotate the csrs with the id.k8s.io claim of the cluster, so the hub refuses to approve the csrs of another
+	// cluster registering with the same name
+	var clusterID string
+	claim, err := spokeClusterClient.ClusterV1alpha1().ClusterClaims().Get(ctx, clientcert.ClusterIDClaimName, metav1.GetOptions{})
+	if err != nil {
+		klog.V(4).Infof("Unable to get the cluster claim %q: %v", clientcert.ClusterIDClaimName, err)
+	} else {
+		clusterID = claim.Spec.Value
+	}
+
 	// load bootstrap client config and create bootstrap clients
-	bootstrapClientConfig, err := clientcmd.BuildConfigFromFlags("", o.BootstrapKubeconfig)
+	bootstrapClientConfig, err := o.bootstrapClientConfig(ctx)
 	if err != nil {
-		return fmt.Errorf("unable to load bootstrap kubeconfig from file %q: %w", o.BootstrapKubeconfig, err)
+		bootstrapStatusRecorder.Record(ctx, metav1.Condition{
+			Type:    managedcluster.BootstrapKubeconfigValidCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "BootstrapKubeconfigInvalid",
+			Message: err.Error(),
+		})
+		return err
 	}
+	bootstrapStatusRecorder.Record(ctx, metav1.Condition{
+		Type:    managedcluster.BootstrapKubeconfigValidCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "BootstrapKubeconfigLoaded",
+		Message: "The bootstrap kubeconfig is loaded",
+	})
 	bootstrapKubeClient, err := kubernetes.NewForConfig(bootstrapClientConfig)
 	if err != nil {
 		return err
@@ -163,10 +390,21 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 		return err
 	}
 
+	// the agent which does not use client certificates is identified by the annotations set by the hub admin
+	identityAnnotations, err := o.identityAnnotations()
+	if err != nil {
+		return err
+	}
+	if len(identityAnnotations) > 0 {
+		klog.Infof("The managed cluster %q must be annotated with %v by the hub admin to grant its roles to the agent",
+			o.ClusterName, identityAnnotations)
+	}
+
 	// start a SpokeClusterCreatingController to make sure there is a spoke cluster on hub cluster
 	spokeClusterCreatingController := managedcluster.NewManagedClusterCreatingController(
 		o.ClusterName, o.SpokeExternalServerURLs,
 		spokeClusterCABundle,
+		o.ClusterLabels, o.ClusterAnnotations,
 		bootstrapClusterClient,
 		controllerContext.EventRecorder,
 	)
@@ -193,7 +431,26 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 	// exists a valid client config for hub or not, the controller will be started and then stopped immediately
 	// in scenario #2 and #3, which results in an error message in log: 'Observed a panic: timeout waiting for
 	// informer cache'
-	if !ok {
+	if !ok && !o.usesClientCertificates() {
+		// there is no client certificate to request with the awsirsa and oidc drivers, the hub kubeconfig
+		// authenticating with the tokens is saved directly
+		kubeconfig, err := o.buildTokenHubKubeconfig(bootstrapClientConfig)
+		if err != nil {
+			return err
+		}
+		kubeconfigData, err := clientcmd.Write(kubeconfig)
+		if err != nil {
+			return err
+		}
+		if err := o.saveHubKubeconfigSecret(ctx, managementKubeClient.CoreV1(), kubeconfigData); err != nil {
+			return fmt.Errorf("unable to save the hub kubeconfig secret: %w", err)
+		}
+
+		klog.Info("Waiting for hub client config to be ready")
+		if err := wait.PollImmediateInfinite(1*time.Second, o.hasValidHubClientConfig); err != nil {
+			return err
+		}
+	} else if !ok {
 		// create a ClientCertForHubController for spoke agent bootstrap
 		// the bootstrap informers are supposed to be terminated after completing the bootstrap process.
 		bootstrapInformerFactory := informers.NewSharedInformerFactory(bootstrapKubeClient, 10*time.Minute)
@@ -206,8 +463,12 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 			return err
 		}
 
-		csrControl, err := clientcert.NewCSRControl(bootstrapInformerFactory.Certificates(), bootstrapKubeClient)
+		bootstrapCtx, stopBootstrap := context.WithCancel(ctx)
+
+		registrationDriver, err := o.newRegistrationDriver(
+			bootstrapCtx, bootstrapClientConfig, bootstrapInformerFactory.Certificates(), bootstrapKubeClient)
 		if err != nil {
+			stopBootstrap()
 			return err
 		}
 
@@ -217,16 +478,20 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 			kubeconfigData,
 			// store the secret in the cluster where the agent pod runs
 			bootstrapNamespacedManagementKubeInformerFactory.Core().V1().Secrets(),
-			csrControl,
+			registrationDriver,
 			o.ClientCertExpirationSeconds,
+			identityKey,
+			clusterID,
+			o.ClientCertRenewalThreshold, o.ClientCertRenewalJitter,
+			keyProvider,
+			o.csrBackoffOption(),
 			managementKubeClient,
 			managedcluster.GenerateBootstrapStatusUpdater(),
+			nil,
 			controllerContext.EventRecorder,
 			controllerName,
 		)
 
-		bootstrapCtx, stopBootstrap := context.WithCancel(ctx)
-
 		go bootstrapInformerFactory.Start(bootstrapCtx.Done())
 		go bootstrapNamespacedManagementKubeInformerFactory.Start(bootstrapCtx.Done())
 
@@ -234,7 +499,10 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 
 		// wait for the hub client config is ready.
 		klog.Info("Waiting for hub client config and managed cluster to be ready")
-		if err := wait.PollImmediateInfinite(1*time.Second, o.hasValidHubClientConfig); err != nil {
+		if err := wait.PollImmediateInfinite(1*time.Second, func() (bool, error) {
+			bootstrapStatusRecorder.RecordCSRStatus(ctx, registrationDriver.Informer().GetIndexer(), o.ClusterName)
+			return o.hasValidHubClientConfig()
+		}); err != nil {
 			// TODO need run the bootstrap CSR forever to re-establish the client-cert if it is ever lost.
 			stopBootstrap()
 			return err
@@ -242,13 +510,95 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 
 		// stop the clientCertForHubController for bootstrap once the hub client config is ready
 		stopBootstrap()
+		health.DefaultRegistry.Remove(controllerName)
+	}
+	health.DefaultRegistry.Record(bootstrapHealthComponent, nil)
+	bootstrapStatusRecorder.Record(ctx, metav1.Condition{
+		Type:    managedcluster.HubKubeconfigReadyCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "HubKubeconfigReady",
+		Message: "The hub kubeconfig is valid",
+	})
+
+	agentCtx := &spokeAgentContext{
+		controllerContext:       controllerContext,
+		managementKubeClient:    managementKubeClient,
+		spokeKubeClient:         spokeKubeClient,
+		spokeClusterClient:      spokeClusterClient,
+		spokeClusterCABundle:    spokeClusterCABundle,
+		identityKey:             identityKey,
+		keyProvider:             keyProvider,
+		clusterID:               clusterID,
+		bootstrapStatusRecorder: bootstrapStatusRecorder,
 	}
+	ctx, agentCtx.stopAgent = context.WithCancel(ctx)
+	defer agentCtx.stopAgent()
+
+	for {
+		// the hub context is cancelled once the hub kubeconfig is reloaded, the hub clients are rebuilt and the
+		// controllers talking to the hub are restarted with the reloaded hub kubeconfig then
+		hubCtx, err := o.watchHubKubeconfig(ctx)
+		if err != nil {
+			return err
+		}
+		if err := o.runHubControllers(hubCtx, agentCtx); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// the files of the hub kubeconfig secret are not dumped at once, wait until they are consistent
+		klog.Info("Waiting for the reloaded hub kubeconfig to be valid")
+		if err := wait.PollImmediateUntil(1*time.Second, o.hasValidHubClientConfig, ctx.Done()); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		controllerContext.EventRecorder.Event("HubKubeconfigReloaded", "The hub kubeconfig is reloaded.")
+	}
+}
+
+// spokeAgentContext holds the clients and the data of the agent which are not changed with the hub kubeconfig,
+// they are shared by the runs of the controllers talking to the hub
+type spokeAgentContext struct {
+	controllerContext       *controllercmd.ControllerContext
+	managementKubeClient    kubernetes.Interface
+	spokeKubeClient         kubernetes.Interface
+	spokeClusterClient      clusterv1client.Interface
+	spokeClusterCABundle    []byte
+	identityKey             []byte
+	keyProvider             clientcert.KeyProvider
+	clusterID               string
+	bootstrapStatusRecorder *managedcluster.BootstrapStatusRecorder
+	// stopAgent stops the agent, so it is restarted
+	stopAgent context.CancelFunc
+}
+
+// runHubControllers builds the hub clients with the hub kubeconfig in the hub kubeconfig dir, and runs the
+// controllers talking to the hub until the context is cancelled.
+func (o *SpokeAgentOptions) runHubControllers(ctx context.Context, agentCtx *spokeAgentContext) error {
+	controllerContext := agentCtx.controllerContext
+	managementKubeClient := agentCtx.managementKubeClient
+	spokeKubeClient := agentCtx.spokeKubeClient
+	spokeClusterCABundle := agentCtx.spokeClusterCABundle
+	identityKey := agentCtx.identityKey
+	keyProvider := agentCtx.keyProvider
+	clusterID := agentCtx.clusterID
+
+	// the informer factories are created for each run, so the event handlers of the stopped controllers are
+	// not left on the informers
+	spokeKubeInformerFactory := informers.NewSharedInformerFactory(spokeKubeClient, 10*time.Minute)
+	namespacedManagementKubeInformerFactory := informers.NewSharedInformerFactoryWithOptions(
+		managementKubeClient, 10*time.Minute, informers.WithNamespace(o.ComponentNamespace))
 
 	// create hub clients and shared informer factories from hub kube config
 	hubClientConfig, err := clientcmd.BuildConfigFromFlags("", path.Join(o.HubKubeconfigDir, clientcert.KubeconfigFile))
 	if err != nil {
 		return err
 	}
+	hubClientConfig = helpers.WithRateLimit(hubClientConfig, o.HubKubeAPIQPS, o.HubKubeAPIBurst)
 
 	hubKubeClient, err := kubernetes.NewForConfig(hubClientConfig)
 	if err != nil {
@@ -292,26 +642,51 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 		return err
 	}
 
-	csrControl, err := clientcert.NewCSRControl(hubKubeInformerFactory.Certificates(), hubKubeClient)
+	registrationDriver, err := o.newRegistrationDriver(ctx, hubClientConfig, hubKubeInformerFactory.Certificates(), hubKubeClient)
 	if err != nil {
 		return err
 	}
 
-	// create another ClientCertForHubController for client certificate rotation
-	controllerName := fmt.Sprintf("ClientCertController@cluster:%s", o.ClusterName)
-	clientCertForHubController := managedcluster.NewClientCertForHubController(
-		o.ClusterName, o.AgentName, o.ComponentNamespace, o.HubKubeconfigSecret,
-		kubeconfigData,
-		namespacedManagementKubeInformerFactory.Core().V1().Secrets(),
-		csrControl,
-		o.ClientCertExpirationSeconds,
-		managementKubeClient,
-		managedcluster.GenerateStatusUpdater(hubClusterClient, o.ClusterName),
-		controllerContext.EventRecorder,
-		controllerName,
-	)
-	if err != nil {
-		return err
+	// create another ClientCertForHubController for client certificate rotation, there is no client certificate to
+	// rotate with the awsirsa and oidc drivers
+	var clientCertForHubController factory.Controller
+	if o.usesClientCertificates() {
+		controllerName := fmt.Sprintf("ClientCertController@cluster:%s", o.ClusterName)
+		clientCertForHubController = managedcluster.NewClientCertForHubController(
+			o.ClusterName, o.AgentName, o.ComponentNamespace, o.HubKubeconfigSecret,
+			kubeconfigData,
+			namespacedManagementKubeInformerFactory.Core().V1().Secrets(),
+			registrationDriver,
+			o.ClientCertExpirationSeconds,
+			identityKey,
+			clusterID,
+			o.ClientCertRenewalThreshold, o.ClientCertRenewalJitter,
+			keyProvider,
+			o.csrBackoffOption(),
+			managementKubeClient,
+			managedcluster.GenerateStatusUpdater(hubClusterClient, o.ClusterName),
+			managedcluster.GenerateExpirationReporter(hubClusterClient, o.ClusterName),
+			controllerContext.EventRecorder,
+			controllerName,
+		)
+	}
+
+	// the serving certificate is requested with the client certificate of the agent, the hub approves it for the
+	// names in its allow list
+	var servingCertController factory.Controller
+	if o.requestsServingCert() {
+		servingCertController = managedcluster.NewServingCertController(
+			o.ClusterName, o.AgentName, o.ComponentNamespace, o.ServingCertSecret,
+			namespacedManagementKubeInformerFactory.Core().V1().Secrets(),
+			registrationDriver,
+			o.ServingCertDNSNames, o.ServingCertIPAddresses,
+			o.ClientCertRenewalThreshold, o.ClientCertRenewalJitter,
+			keyProvider,
+			o.csrBackoffOption(),
+			managementKubeClient,
+			controllerContext.EventRecorder,
+			fmt.Sprintf("ServingCertController@cluster:%s", o.ClusterName),
+		)
 	}
 
 	// create ManagedClusterJoiningController to reconcile instances of ManagedCluster on the managed cluster
@@ -322,29 +697,94 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 		controllerContext.EventRecorder,
 	)
 
+	// create ManagedClusterVersionController to publish the agent version and report whether the agent is required
+	// to upgrade
+	managedClusterVersionController := managedcluster.NewManagedClusterVersionController(
+		o.ClusterName,
+		version.Get().GitVersion,
+		o.AgentUpgradePolicy,
+		hubClusterClient,
+		hubClusterInformerFactory.Cluster().V1().ManagedClusters(),
+		controllerContext.EventRecorder,
+	)
+
+	var managedClusterSpecController factory.Controller
+	if len(o.ReconciledClusterSpecFields) > 0 {
+		managedClusterSpecController = managedcluster.NewManagedClusterSpecController(
+			o.ClusterName,
+			o.ReconciledClusterSpecFields,
+			o.SpokeExternalServerURLs,
+			spokeClusterCABundle,
+			o.ClusterLeaseDurationSeconds,
+			hubClusterClient,
+			hubClusterInformerFactory.Cluster().V1().ManagedClusters(),
+			controllerContext.EventRecorder,
+		)
+	}
+
+	// the heartbeats and status are reported with the hub kube-apiserver if there is no publisher
+	var publisher transport.Publisher
+	var mqttClient *mqtt.Client
+	if o.RegistrationTransport == transport.TransportMQTT {
+		mqttClient, err = o.newMQTTClient()
+		if err != nil {
+			return err
+		}
+		publisher = mqttClient
+	}
+
+	// the lease is renewed with a dedicated client, so the renewals have their own rate limit and fail fast on a slow
+	// hub instead of waiting behind the requests of the other controllers
+	hubLeaseClient, err := kubernetes.NewForConfig(o.leaseClientConfig(hubClientConfig))
+	if err != nil {
+		return err
+	}
+
 	// create ManagedClusterLeaseController to keep the spoke cluster heartbeat
 	managedClusterLeaseController := managedcluster.NewManagedClusterLeaseController(
 		o.ClusterName,
-		hubKubeClient,
+		o.AgentName,
+		hubLeaseClient,
 		hubClusterInformerFactory.Cluster().V1().ManagedClusters(),
+		publisher,
+		clock.RealClock{},
 		controllerContext.EventRecorder,
 	)
 
+	availabilityProbes, err := o.availabilityProbes()
+	if err != nil {
+		return err
+	}
+	healthCheckStrategies, err := o.healthCheckStrategies()
+	if err != nil {
+		return err
+	}
+	healthCheckHTTPClient, err := managedcluster.NewHealthCheckHTTPClient(o.HealthCheckCAFile)
+	if err != nil {
+		return err
+	}
+	clusterResourceOptions, err := o.clusterResourceOptions()
+	if err != nil {
+		return err
+	}
+
 	// create NewManagedClusterStatusController to update the spoke cluster status
 	managedClusterHealthCheckController := managedcluster.NewManagedClusterStatusController(
 		o.ClusterName,
+		o.AgentName,
 		hubClusterClient,
 		hubClusterInformerFactory.Cluster().V1().ManagedClusters(),
 		spokeKubeClient.Discovery(),
 		spokeKubeInformerFactory.Core().V1().Nodes(),
+		clusterResourceOptions,
+		availabilityProbes,
+		healthCheckStrategies,
+		healthCheckHTTPClient,
+		publisher,
 		o.ClusterHealthCheckPeriod,
 		controllerContext.EventRecorder,
 	)
-	spokeClusterClient, err := clusterv1client.NewForConfig(spokeClientConfig)
-	if err != nil {
-		return err
-	}
-	spokeClusterInformerFactory := clusterv1informers.NewSharedInformerFactory(spokeClusterClient, 10*time.Minute)
+	spokeClusterInformerFactory := clusterv1informers.NewSharedInformerFactory(agentCtx.spokeClusterClient, 10*time.Minute)
 
 	var managedClusterClaimController factory.Controller
 	if features.DefaultSpokeMutableFeatureGate.Enabled(ocmfeature.ClusterClaim) {
@@ -370,18 +810,101 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 			managementKubeClient.CoordinationV1(),
 			spokeKubeClient.CoordinationV1(),
 			AddOnLeaseControllerSyncInterval, //TODO: this interval time should be allowed to change from outside
+			clock.RealClock{},
 			controllerContext.EventRecorder,
 		)
 
 		addOnRegistrationController = addon.NewAddOnRegistrationController(
 			o.ClusterName,
 			o.AgentName,
+			o.ComponentNamespace,
 			kubeconfigData,
 			addOnClient,
 			managementKubeClient,
 			spokeKubeClient,
-			csrControl,
+			registrationDriver,
 			addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns(),
+			o.ClientCertRenewalThreshold, o.ClientCertRenewalJitter,
+			o.MaxConcurrentAddOnCSRs,
+			keyProvider,
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var bootstrapKubeconfigController factory.Controller
+	var hubSecretInformerFactory informers.SharedInformerFactory
+	if o.BootstrapKubeconfigSecret != "" {
+		// create a secret informer factory in the cluster namespace with name field selector because we just
+		// need to handle the bootstrap kubeconfig published by hub
+		hubSecretInformerFactory = informers.NewSharedInformerFactoryWithOptions(
+			hubKubeClient,
+			10*time.Minute,
+			informers.WithNamespace(o.ClusterName),
+			informers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.FieldSelector = fields.OneTermEqualSelector("metadata.name", helpers.BootstrapHubKubeconfigSecretName).String()
+			}),
+		)
+		bootstrapKubeconfigController = managedcluster.NewBootstrapKubeconfigController(
+			o.ClusterName, o.ComponentNamespace, o.BootstrapKubeconfigSecret,
+			hubSecretInformerFactory.Core().V1().Secrets(),
+			managementKubeClient.CoreV1(),
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var clusterEndpointsController factory.Controller
+	if o.PublishClusterEndpoints {
+		clusterEndpointsController = managedcluster.NewClusterEndpointsController(
+			o.ClusterName, o.SpokeExternalServerURLs,
+			spokeClusterCABundle,
+			spokeKubeClient.CoreV1(),
+			hubKubeClient.CoreV1(),
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var tunnelEndpointsController factory.Controller
+	if len(o.TunnelEndpointMetadata) > 0 {
+		publisher := o.EndpointPublisher
+		if publisher == nil {
+			publisher, err = managedcluster.NewEndpointPublisher(o.TunnelEndpointPublisher, o.ClusterName,
+				hubKubeClient.CoreV1(), hubClusterClient)
+			if err != nil {
+				return err
+			}
+		}
+		tunnelEndpointsController = managedcluster.NewTunnelEndpointsController(
+			o.ClusterName,
+			o.TunnelEndpointMetadata,
+			publisher,
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var hubCABundleController factory.Controller
+	var hubConfigMapInformerFactory informers.SharedInformerFactory
+	if o.SyncHubCABundle {
+		// the agent exits once the CA of the hub kubeconfig is updated, so it is restarted with the hub clients
+		// trusting the rotated CA, unless the hub clients are rebuilt once the hub kubeconfig is reloaded
+		hubConfigMapInformerFactory = informers.NewSharedInformerFactoryWithOptions(
+			hubKubeClient,
+			10*time.Minute,
+			informers.WithNamespace(o.ClusterName),
+			informers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.FieldSelector = fields.OneTermEqualSelector("metadata.name", helpers.HubCABundleConfigMapName).String()
+			}),
+		)
+		hubCABundleController = managedcluster.NewHubCABundleController(
+			o.ClusterName, o.ComponentNamespace, o.HubKubeconfigSecret,
+			hubConfigMapInformerFactory.Core().V1().ConfigMaps(),
+			managementKubeClient.CoreV1(),
+			func() {
+				if o.ReloadHubKubeconfig {
+					return
+				}
+				klog.Infof("Exiting because the CA bundle of the hub kubeconfig is updated")
+				agentCtx.stopAgent()
+			},
 			controllerContext.EventRecorder,
 		)
 	}
@@ -393,8 +916,20 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 	go spokeClusterInformerFactory.Start(ctx.Done())
 	go addOnInformerFactory.Start(ctx.Done())
 
-	go clientCertForHubController.Run(ctx, 1)
+	if mqttClient != nil {
+		go mqttClient.Run(ctx)
+	}
+	if clientCertForHubController != nil {
+		go clientCertForHubController.Run(ctx, 1)
+	}
+	if servingCertController != nil {
+		go servingCertController.Run(ctx, 1)
+	}
 	go managedClusterJoiningController.Run(ctx, 1)
+	go managedClusterVersionController.Run(ctx, 1)
+	if managedClusterSpecController != nil {
+		go managedClusterSpecController.Run(ctx, 1)
+	}
 	go managedClusterLeaseController.Run(ctx, 1)
 	go managedClusterHealthCheckController.Run(ctx, 1)
 	if features.DefaultSpokeMutableFeatureGate.Enabled(ocmfeature.ClusterClaim) {
@@ -404,6 +939,41 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 		go addOnLeaseController.Run(ctx, 1)
 		go addOnRegistrationController.Run(ctx, 1)
 	}
+	if bootstrapKubeconfigController != nil {
+		go hubSecretInformerFactory.Start(ctx.Done())
+		go bootstrapKubeconfigController.Run(ctx, 1)
+	}
+	if clusterEndpointsController != nil {
+		go clusterEndpointsController.Run(ctx, 1)
+	}
+	if tunnelEndpointsController != nil {
+		go tunnelEndpointsController.Run(ctx, 1)
+	}
+	if hubCABundleController != nil {
+		go hubConfigMapInformerFactory.Start(ctx.Done())
+		go hubCABundleController.Run(ctx, 1)
+	}
+
+	// record the last bootstrap phase once the managed cluster is joined
+	go func() {
+		clusterLister := hubClusterInformerFactory.Cluster().V1().ManagedClusters().Lister()
+		err := wait.PollImmediateUntil(1*time.Second, func() (bool, error) {
+			cluster, err := clusterLister.Get(o.ClusterName)
+			if err != nil {
+				return false, nil
+			}
+			return meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionJoined), nil
+		}, ctx.Done())
+		if err != nil {
+			return
+		}
+		agentCtx.bootstrapStatusRecorder.Record(ctx, metav1.Condition{
+			Type:    managedcluster.ClusterJoinedCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ManagedClusterJoined",
+			Message: fmt.Sprintf("The managed cluster %q is joined to the hub", o.ClusterName),
+		})
+	}()
 
 	<-ctx.Done()
 	return nil
@@ -412,6 +982,10 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 // AddFlags registers flags for Agent
 func (o *SpokeAgentOptions) AddFlags(fs *pflag.FlagSet) {
 	features.DefaultSpokeMutableFeatureGate.AddFlag(fs)
+	fs.StringVar(&o.FeatureGatesFile, "feature-gates-file", o.FeatureGatesFile,
+		"The file holding a map of the feature gates to true or false, it takes precedence over --feature-gates. "+
+			"The feature gates are not reloaded in-process, the agent exits to be restarted once any feature gate in the "+
+			"file is toggled.")
 	fs.StringVar(&o.ClusterName, "cluster-name", o.ClusterName,
 		"If non-empty, will use as cluster name instead of generated random name.")
 	fs.StringVar(&o.BootstrapKubeconfig, "bootstrap-kubeconfig", o.BootstrapKubeconfig,
@@ -421,21 +995,212 @@ func (o *SpokeAgentOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.HubKubeconfigDir, "hub-kubeconfig-dir", o.HubKubeconfigDir,
 		"The mount path of hub-kubeconfig-secret in the container.")
 	fs.StringVar(&o.SpokeKubeconfig, "spoke-kubeconfig", o.SpokeKubeconfig,
-		"The path of the kubeconfig file for managed/spoke cluster. If this is not set, will use '--kubeconfig' to build client to connect to the managed cluster.")
+		"The path of the kubeconfig file for managed/spoke cluster. If this is not set, will use '--kubeconfig' to build client to connect to the managed cluster. "+
+			"In Detached mode, the agent exits once the kubeconfig or the files referenced by it are changed, so it is restarted with the rotated kubeconfig.")
+	fs.StringVar(&o.DeployMode, "deploy-mode", o.DeployMode,
+		"The deploy mode of the agent, it must be one of Default and Detached. In Detached mode, the agent runs on a "+
+			"management cluster and --spoke-kubeconfig is required.")
 	fs.StringArrayVar(&o.SpokeExternalServerURLs, "spoke-external-server-urls", o.SpokeExternalServerURLs,
 		"A list of reachable spoke cluster api server URLs for hub cluster.")
+	fs.StringToStringVar(&o.ClusterLabels, "cluster-labels", o.ClusterLabels,
+		"The labels set on the managed cluster when it is created by the agent, e.g. environment=production. "+
+			"The hub webhook may restrict them to the allowed prefixes.")
+	fs.StringToStringVar(&o.ClusterAnnotations, "cluster-annotations", o.ClusterAnnotations,
+		"The annotations set on the managed cluster when it is created by the agent, e.g. example.com/owner=team-a. "+
+			"The hub webhook may restrict them to the allowed prefixes.")
+	fs.StringSliceVar(&o.ReconciledClusterSpecFields, "reconciled-cluster-spec-fields", o.ReconciledClusterSpecFields,
+		"The spec fields of the managed cluster kept reconciled by the agent with server-side apply after the cluster is "+
+			"created, they must be of managedClusterClientConfigs and leaseDurationSeconds. The other fields, e.g. "+
+			"hubAcceptsClient and taints, are owned by the hub and never overwritten. If it is not set, the spec is only "+
+			"set when the cluster is created. The managedClusterClientConfigs are not reconciled if the cluster endpoints "+
+			"are published.")
+	fs.Int32Var(&o.ClusterLeaseDurationSeconds, "cluster-lease-duration-seconds", o.ClusterLeaseDurationSeconds,
+		"The lease duration seconds of the managed cluster, it is required if leaseDurationSeconds is reconciled.")
 	fs.DurationVar(&o.ClusterHealthCheckPeriod, "cluster-healthcheck-period", o.ClusterHealthCheckPeriod,
 		"The period to check managed cluster kube-apiserver health")
 	fs.IntVar(&o.MaxCustomClusterClaims, "max-custom-cluster-claims", o.MaxCustomClusterClaims,
 		"The max number of custom cluster claims to expose.")
 	fs.Int32Var(&o.ClientCertExpirationSeconds, "client-cert-expiration-seconds", o.ClientCertExpirationSeconds,
 		"The requested duration in seconds of validity of the issued client certificate. If this is not set, the value of --cluster-signing-duration command-line flag of the kube-controller-manager will be used.")
+	fs.Float64Var(&o.ClientCertRenewalThreshold, "client-cert-renewal-threshold", o.ClientCertRenewalThreshold,
+		"The fraction of the client certificate lifetime remaining when the certificate rotation starts, it must be in the range of (0, 1).")
+	fs.Float64Var(&o.ClientCertRenewalJitter, "client-cert-renewal-jitter", o.ClientCertRenewalJitter,
+		"The max factor of the renewal threshold which is randomly added to the threshold, so that the client certificates issued at the same time are not rotated simultaneously.")
+	fs.StringVar(&o.ClientCertKeyType, "client-cert-key-type", o.ClientCertKeyType,
+		"The type of the private keys of the client certificates for the cluster and addons, it must be one of ecdsa and rsa.")
+	fs.IntVar(&o.ClientCertRSAKeySize, "client-cert-rsa-key-size", o.ClientCertRSAKeySize,
+		"The size of the RSA private keys of the client certificates, it must be one of 2048, 3072 and 4096.")
+	fs.StringVar(&o.ClientCertECDSACurve, "client-cert-ecdsa-curve", o.ClientCertECDSACurve,
+		"The curve of the ECDSA private keys of the client certificates, it must be one of P256, P384 and P521.")
+	fs.StringVar(&o.ClientCertKeyProvider, "client-cert-key-provider", o.ClientCertKeyProvider,
+		"The provider generating and holding the private keys of the client certificates for the cluster and addons, it "+
+			"must be one of "+strings.Join(clientcert.KeyProviders(), ", ")+". The software provider generates the keys "+
+			"with the key type options and stores them in the client certificate secrets.")
+	fs.StringSliceVar(&o.ServingCertDNSNames, "serving-cert-dns-names", o.ServingCertDNSNames,
+		"The DNS names requested as the subject alternative names of the serving certificate of the agent, which is signed "+
+			"by the "+helpers.ClusterServingSignerName+" signer of the hub for the mTLS callbacks to the cluster. They "+
+			"must be allowed by the hub, otherwise the csrs are not approved. It requires the csr registration driver.")
+	fs.IPSliceVar(&o.ServingCertIPAddresses, "serving-cert-ip-addresses", o.ServingCertIPAddresses,
+		"The IPv4 or IPv6 addresses requested as the subject alternative names of the serving certificate of the agent. "+
+			"They must be allowed by the hub, otherwise the csrs are not approved.")
+	fs.StringVar(&o.ServingCertSecret, "serving-cert-secret", o.ServingCertSecret,
+		"The name of the secret in the agent namespace the serving certificate of the agent is stored in.")
+	fs.StringVar(&o.ClusterIdentityKeyFile, "cluster-identity-key-file", o.ClusterIdentityKeyFile,
+		"The file of the pre-shared key of the cluster. If it is set, the csrs of the cluster are annotated with the proofs "+
+			"of the cluster identity made with the key, which are verified by the hub before approving the csrs.")
+	fs.IntVar(&o.MaxConcurrentAddOnCSRs, "max-concurrent-addon-csrs", o.MaxConcurrentAddOnCSRs,
+		"The max number of pending csrs the agent creates for all addons at the same time. If it is not set, the number is not limited.")
+	fs.DurationVar(&o.CSRBackoffInitial, "csr-backoff-initial", o.CSRBackoffInitial,
+		"The interval before creating another csr of the cluster once a csr is created without a client certificate issued, e.g. "+
+			"the csr is denied or expires before the manual approval. The interval grows exponentially with the following csrs. "+
+			"If it is not set, the csrs are created without any interval.")
+	fs.DurationVar(&o.CSRBackoffMax, "csr-backoff-max", o.CSRBackoffMax,
+		"The max interval between the csrs of the cluster.")
+	fs.Float64Var(&o.CSRBackoffMultiplier, "csr-backoff-multiplier", o.CSRBackoffMultiplier,
+		"The factor by which the interval between the csrs of the cluster is multiplied after each csr, it must not be less than 1.")
+	fs.IntVar(&o.CSRMaxAttempts, "csr-max-attempts", o.CSRMaxAttempts,
+		"The number of the csrs of the cluster created without a client certificate issued after which the agent is reported "+
+			"as degraded by the health probes. If it is not set, the agent is not degraded.")
+	fs.StringVar(&o.BootstrapToken, "bootstrap-token", o.BootstrapToken,
+		"The bootstrap token to bootstrap the agent instead of the bootstrap kubeconfig, it requires --hub-apiserver and --hub-ca-cert-hash.")
+	fs.StringVar(&o.HubAPIServer, "hub-apiserver", o.HubAPIServer,
+		"The URL of the hub kube-apiserver to bootstrap the agent with the bootstrap token.")
+	fs.StringSliceVar(&o.HubCACertHashes, "hub-ca-cert-hash", o.HubCACertHashes,
+		"The hashes of the hub CA certs to verify the hub when bootstrapping with the bootstrap token, in the format of sha256:<hex>.")
+	fs.StringVar(&o.BootstrapKubeconfigSecret, "bootstrap-kubeconfig-secret", o.BootstrapKubeconfigSecret,
+		"The name of secret in component namespace storing the bootstrap kubeconfig. If it is set, the secret will be updated with the bootstrap kubeconfig published by hub.")
+	fs.BoolVar(&o.SyncHubCABundle, "sync-hub-ca-bundle", o.SyncHubCABundle,
+		"If true, the CA of the hub kubeconfig is updated with the hub CA bundle published by hub, and the agent restarts to trust the rotated CA.")
+	fs.BoolVar(&o.ReloadHubKubeconfig, "reload-hub-kubeconfig", o.ReloadHubKubeconfig,
+		"If true, the hub clients and the controllers talking to hub are rebuilt in-process once the hub kubeconfig or the client certificate files are changed.")
+	fs.BoolVar(&o.PublishClusterEndpoints, "publish-cluster-endpoints", o.PublishClusterEndpoints,
+		"If true, the api server urls and CA bundle of the managed cluster are published to hub. The urls are discovered from the "+
+			"configmap kube-public/cluster-info unless the spoke external server urls are specified.")
+	fs.StringToStringVar(&o.TunnelEndpointMetadata, "tunnel-endpoint-metadata", o.TunnelEndpointMetadata,
+		"The metadata of the tunnels the hub components reach the managed cluster through, e.g. "+
+			"konnectivity-agent-identifiers=host=cluster1. It is published to hub with the tunnel endpoint publisher.")
+	fs.StringVar(&o.TunnelEndpointPublisher, "tunnel-endpoint-publisher", o.TunnelEndpointPublisher,
+		"The publisher of the tunnel endpoint metadata, it must be one of secret and claims. The secret publisher sets the keys "+
+			helpers.ClusterEndpointsTunnelKeyPrefix+"<name> of the secret "+helpers.ClusterEndpointsSecretName+" created by hub in "+
+			"the cluster namespace, and the claims publisher reports the cluster claims "+managedcluster.TunnelEndpointClaimPrefix+
+			"<name> of the managed cluster.")
+	fs.StringVar(&o.RegistrationDriver, "registration-driver", o.RegistrationDriver,
+		"The driver to request the client certificates of the agent from the hub, it must be one of csr, grpc, awsirsa and oidc. "+
+			"The csr driver creates the certificate signing requests on the hub kube-apiserver. The grpc driver requires the "+
+			"GRPCRegistrationDriver feature and --registration-service-address. The awsirsa driver authenticates to an EKS hub "+
+			"with the IAM role of the agent instead of a client certificate, it requires the AWSIAMRegistrationDriver feature "+
+			"and --hub-cluster-arn. The oidc driver authenticates to the hub with a projected service account token instead of "+
+			"a client certificate, it requires the OIDCRegistrationDriver feature and --hub-token-file.")
+	fs.StringVar(&o.RegistrationServiceAddress, "registration-service-address", o.RegistrationServiceAddress,
+		"The address of the hub registration service of the grpc registration driver, e.g. registration.example.com:8443.")
+	fs.StringVar(&o.HubClusterARN, "hub-cluster-arn", o.HubClusterARN,
+		"The ARN of the EKS hub cluster of the awsirsa registration driver, e.g. arn:aws:eks:us-west-2:123456789012:cluster/hub.")
+	fs.StringVar(&o.ManagedClusterIAMRoleARN, "managed-cluster-iam-role-arn", o.ManagedClusterIAMRoleARN,
+		"The ARN of the IAM role the agent authenticates to the EKS hub with by the awsirsa registration driver. If it is "+
+			"not set, the role of the service account of the agent in the "+awsRoleARNEnv+" environment variable is used.")
+	fs.StringVar(&o.HubTokenFile, "hub-token-file", o.HubTokenFile,
+		"The file of the service account token projected with the audience of the hub, the agent authenticates to the hub "+
+			"with it by the oidc registration driver. The issuer of the token must be trusted by the hub kube-apiserver.")
+	fs.StringVar(&o.RegistrationTransport, "registration-transport", o.RegistrationTransport,
+		"The transport to report the heartbeats and status of the managed cluster, it must be one of kube and mqtt. The mqtt transport requires the MQTTTransport feature.")
+	fs.StringVar(&o.MQTTBrokerURL, "mqtt-broker-url", o.MQTTBrokerURL,
+		"The URL of the MQTT broker for the mqtt registration transport, e.g. tls://broker:8883. The agent authenticates to the broker with its hub client certificate.")
+	fs.StringVar(&o.MQTTCAFile, "mqtt-ca-file", o.MQTTCAFile,
+		"The CA file to verify the MQTT broker. If it is not set, the system CAs are used.")
+	fs.StringArrayVar(&o.AvailabilityProbes, "availability-probe", o.AvailabilityProbes,
+		"The probe of a critical API of the managed cluster in the format of <name>=<type>:<target>, the result is reported with "+
+			"the condition "+helpers.ManagedClusterConditionProbePrefix+"<name> on the managed cluster. The type is one of readyz "+
+			"(e.g. etcd=readyz:etcd), path (e.g. livez=path:/livez), apigroup (e.g. metrics=apigroup:metrics.k8s.io/v1beta1) "+
+			"and resource (e.g. works=resource:work.open-cluster-management.io/v1/appliedmanifestworks).")
+	fs.StringArrayVar(&o.HealthCheckStrategies, "health-check-strategy", o.HealthCheckStrategies,
+		"The strategy to check the health of the kube-apiserver of the managed cluster in the format of "+
+			"<type>[:<target>][,timeout=<duration>]. The type is one of livez, readyz, healthz, list (e.g. list:v1/namespaces "+
+			"lists one namespace) and url (e.g. url:https://lb.example.com/healthz, requested without credentials). The "+
+			"strategies are tried in order, the next one is tried if a strategy is not found or forbidden. If it is not "+
+			"set, livez is checked with the fallback to healthz.")
+	fs.StringVar(&o.HealthCheckCAFile, "health-check-ca-file", o.HealthCheckCAFile,
+		"The CA file to verify the servers of the url health check strategies. If it is not set, the system CAs are used.")
+	fs.StringVar(&o.ClusterResourceNodeSelector, "cluster-resource-node-selector", o.ClusterResourceNodeSelector,
+		"The label selector of the nodes whose capacity and allocatable are aggregated into the managed cluster status, "+
+			"e.g. '!node-role.kubernetes.io/control-plane'. If it is not set, all of the nodes are aggregated.")
+	fs.StringVar(&o.ClusterResourceNodeSelector, "capacity-node-selector", o.ClusterResourceNodeSelector,
+		"The alias of --cluster-resource-node-selector, e.g. 'node-pool.example.com/multicluster=true' to count a "+
+			"dedicated worker pool only.")
+	fs.StringSliceVar(&o.ClusterResourceExcludedNodeTaints, "cluster-resource-excluded-node-taints", o.ClusterResourceExcludedNodeTaints,
+		"The keys of the taints, the nodes with any of them are not aggregated into the managed cluster status.")
+	fs.BoolVar(&o.ClusterResourceExcludeNotReadyNodes, "cluster-resource-exclude-not-ready-nodes", o.ClusterResourceExcludeNotReadyNodes,
+		"Exclude the nodes which are not ready from the capacity and allocatable of the managed cluster.")
+	fs.BoolVar(&o.ClusterResourceExcludeCordonedNodes, "cluster-resource-exclude-cordoned-nodes", o.ClusterResourceExcludeCordonedNodes,
+		"Exclude the cordoned nodes from the capacity of the managed cluster, they are always excluded from the allocatable.")
+	fs.BoolVar(&o.ClusterResourceRoleBreakdown, "cluster-resource-role-breakdown", o.ClusterResourceRoleBreakdown,
+		"Add the capacity and allocatable of the nodes of each role as the extended resources "+
+			helpers.NodeRoleResourcePrefix+"<role>.<resource>, e.g. "+helpers.NodeRoleResourcePrefix+"worker.cpu.")
+	fs.BoolVar(&o.ClusterResourcePlatformBreakdown, "cluster-resource-platform-breakdown", o.ClusterResourcePlatformBreakdown,
+		"Add the capacity and allocatable of the nodes of each architecture and OS as the extended resources "+
+			helpers.NodeArchResourcePrefix+"<arch>.<resource> and "+helpers.NodeOSResourcePrefix+"<os>.<resource>, "+
+			"e.g. "+helpers.NodeArchResourcePrefix+"arm64.cpu and "+helpers.NodeOSResourcePrefix+"windows.memory.")
+	fs.StringSliceVar(&o.ClusterResourceExtendedResources, "cluster-resource-extended-resources", o.ClusterResourceExtendedResources,
+		"The allow-list of the extended resources of the nodes, e.g. GPUs, aggregated into the capacity and allocatable of the "+
+			"managed cluster. The items are the resource names or wildcard patterns, e.g. nvidia.com/gpu or amd.com/*. If it is "+
+			"not set, all of the extended resources are aggregated. The resources without a domain, e.g. cpu, memory and "+
+			"ephemeral-storage, are always aggregated.")
+	fs.DurationVar(&o.ClusterResourceUpdateMinInterval, "cluster-resource-update-min-interval", o.ClusterResourceUpdateMinInterval,
+		"The min interval between the updates of the capacity and allocatable of the managed cluster, the changes in the "+
+			"interval are delayed to its end. If it is not set, the changes are updated immediately.")
+	fs.Float64Var(&o.ClusterResourceUpdateThresholdPercent, "cluster-resource-update-threshold-percent", o.ClusterResourceUpdateThresholdPercent,
+		"The percentage of the change of a resource of the managed cluster, the change larger than it is updated immediately "+
+			"even in the min update interval. If it is not set, all of the changes in the interval are delayed.")
+	fs.BoolVar(&o.NodeSummaryClaims, "node-summary-claims", o.NodeSummaryClaims,
+		"Report the counts of the nodes by the OS, architecture and kubelet minor version with the cluster claims "+
+			managedcluster.NodeSummaryClaimPrefix+"os.<os>, arch.<arch> and kubelet.<minor version>, e.g. "+
+			managedcluster.NodeSummaryClaimPrefix+"kubelet.v1.26, in the managed cluster status.")
+	fs.StringVar(&o.AgentUpgradePolicy, "agent-upgrade-policy", o.AgentUpgradePolicy,
+		"The policy to require the upgrade of the agent once its version is behind the desired version in the annotation "+
+			helpers.ManagedClusterDesiredAgentVersionAnnotation+" of the managed cluster, it must be one of none, major, minor "+
+			"and patch. The condition "+helpers.ManagedClusterConditionUpgradeRequired+" of the managed cluster is true once "+
+			"the agent is behind by a release of the policy or above.")
+	fs.StringVar(&o.HealthProbeBindAddress, "health-probe-bind-address", o.HealthProbeBindAddress,
+		"The address the /healthz and /readyz endpoints bind to, e.g. :8000. The endpoints report the last sync of each "+
+			"controller of the agent. If it is not set, the endpoints are not served.")
+	fs.DurationVar(&o.HealthProbeFailureThreshold, "health-probe-failure-threshold", o.HealthProbeFailureThreshold,
+		"The duration a controller keeps failing before it is reported as stuck by the /healthz endpoint. The failures "+
+			"caused by the connectivity to the hub or the managed cluster are only reported by the /readyz endpoint.")
+	fs.Float32Var(&o.SpokeKubeAPIQPS, "spoke-kube-api-qps", o.SpokeKubeAPIQPS,
+		"The QPS of the clients of the managed cluster. If it is 0, the QPS of the spoke kubeconfig is used.")
+	fs.IntVar(&o.SpokeKubeAPIBurst, "spoke-kube-api-burst", o.SpokeKubeAPIBurst,
+		"The burst of the clients of the managed cluster. If it is 0, the burst of the spoke kubeconfig is used.")
+	fs.Float32Var(&o.HubKubeAPIQPS, "hub-kube-api-qps", o.HubKubeAPIQPS,
+		"The QPS of the clients of the hub. If it is 0, the QPS of the hub kubeconfig is used.")
+	fs.IntVar(&o.HubKubeAPIBurst, "hub-kube-api-burst", o.HubKubeAPIBurst,
+		"The burst of the clients of the hub. If it is 0, the burst of the hub kubeconfig is used.")
+	fs.Float32Var(&o.LeaseClientQPS, "lease-client-qps", o.LeaseClientQPS,
+		"The QPS of the hub client dedicated to the lease renewals of the managed cluster. If it is 0, the QPS of "+
+			"the hub kubeconfig is used.")
+	fs.IntVar(&o.LeaseClientBurst, "lease-client-burst", o.LeaseClientBurst,
+		"The burst of the hub client dedicated to the lease renewals of the managed cluster. If it is 0, the burst "+
+			"of the hub kubeconfig is used.")
+	fs.DurationVar(&o.LeaseRequestTimeout, "lease-request-timeout", o.LeaseRequestTimeout,
+		"The timeout of each request of the lease renewals of the managed cluster to the hub. If it is not set, the "+
+			"timeout of the hub kubeconfig is used.")
+	fs.StringVar(&o.LoggingFormat, "logging-format", o.LoggingFormat,
+		"The format of the log lines, text or json. The log lines of the controller syncs carry the controller, "+
+			"queueKey, cluster and addon fields in both formats.")
+	fs.StringToIntVar(&o.ControllerLogLevels, "controller-log-levels", o.ControllerLogLevels,
+		"The verbosities of the syncs of the controllers overriding -v, e.g. ManagedClusterLeaseController=4,"+
+			"ClusterClaimController=0. The log lines out of the controller syncs are still filtered by -v.")
 }
 
 // Validate verifies the inputs.
 func (o *SpokeAgentOptions) Validate() error {
-	if o.BootstrapKubeconfig == "" {
-		return errors.New("bootstrap-kubeconfig is required")
+	switch {
+	case o.BootstrapKubeconfig == "" && o.BootstrapToken == "":
+		return errors.New("bootstrap-kubeconfig or bootstrap-token is required")
+	case o.BootstrapKubeconfig != "" && o.BootstrapToken != "":
+		return errors.New("bootstrap-kubeconfig and bootstrap-token cannot be specified at the same time")
+	case o.BootstrapToken != "":
+		if err := o.validateBootstrapTokenOptions(); err != nil {
+			return err
+		}
 	}
 
 	if o.ClusterName == "" {
@@ -455,6 +1220,39 @@ func (o *SpokeAgentOptions) Validate() error {
 		}
 	}
 
+	for key, value := range o.ClusterLabels {
+		if errMsgs := validation.IsQualifiedName(key); len(errMsgs) > 0 {
+			return fmt.Errorf("cluster label key %q is invalid: %s", key, strings.Join(errMsgs, ", "))
+		}
+		if errMsgs := validation.IsValidLabelValue(value); len(errMsgs) > 0 {
+			return fmt.Errorf("value %q of cluster label %q is invalid: %s", value, key, strings.Join(errMsgs, ", "))
+		}
+	}
+	for key := range o.ClusterAnnotations {
+		if errMsgs := validation.IsQualifiedName(key); len(errMsgs) > 0 {
+			return fmt.Errorf("cluster annotation key %q is invalid: %s", key, strings.Join(errMsgs, ", "))
+		}
+	}
+
+	for _, field := range o.ReconciledClusterSpecFields {
+		switch field {
+		case managedcluster.ClusterSpecFieldClientConfigs:
+			if len(o.SpokeExternalServerURLs) == 0 {
+				return errors.New("spoke external server urls are required if the managed cluster client configs are reconciled")
+			}
+			if o.PublishClusterEndpoints {
+				return errors.New("the managed cluster client configs are either reconciled by the agent or copied by the hub " +
+					"from the published cluster endpoints, but not both")
+			}
+		case managedcluster.ClusterSpecFieldLeaseDuration:
+			if o.ClusterLeaseDurationSeconds <= 0 {
+				return errors.New("cluster lease duration seconds must greater than zero if the lease duration is reconciled")
+			}
+		default:
+			return fmt.Errorf("cluster spec field %q is invalid, it must be one of %v", field, managedcluster.ClusterSpecFields)
+		}
+	}
+
 	if o.ClusterHealthCheckPeriod <= 0 {
 		return errors.New("cluster healthcheck period must greater than zero")
 	}
@@ -463,9 +1261,206 @@ func (o *SpokeAgentOptions) Validate() error {
 		return errors.New("client certificate expiration seconds must greater or qual to 600")
 	}
 
+	if o.ClientCertRenewalThreshold <= 0 || o.ClientCertRenewalThreshold >= 1 {
+		return errors.New("client certificate renewal threshold must be in the range of (0, 1)")
+	}
+
+	if o.ClientCertRenewalJitter <= 0 || o.ClientCertRenewalThreshold*(1+o.ClientCertRenewalJitter) >= 1 {
+		return errors.New("client certificate renewal jitter must be greater than zero and the renewal threshold with the jitter must be less than 1")
+	}
+
+	if o.MaxConcurrentAddOnCSRs < 0 {
+		return errors.New("max concurrent addon csrs must not be negative")
+	}
+
+	if len(o.HealthProbeBindAddress) > 0 && o.HealthProbeFailureThreshold <= 0 {
+		return errors.New("health probe failure threshold must greater than zero")
+	}
+
+	if o.SpokeKubeAPIQPS < 0 || o.SpokeKubeAPIBurst < 0 || o.HubKubeAPIQPS < 0 || o.HubKubeAPIBurst < 0 {
+		return errors.New("kube api qps and burst must not be negative")
+	}
+
+	if o.LeaseClientQPS < 0 || o.LeaseClientBurst < 0 || o.LeaseRequestTimeout < 0 {
+		return errors.New("lease client qps, burst and request timeout must not be negative")
+	}
+
+	if err := o.clientCertPrivateKeyOption().Validate(); err != nil {
+		return err
+	}
+	if _, err := o.clientCertKeyProvider(); err != nil {
+		return err
+	}
+
+	if err := o.csrBackoffOption().Validate(); err != nil {
+		return err
+	}
+
+	if err := o.validateTransportOptions(); err != nil {
+		return err
+	}
+
+	if err := o.validateRegistrationDriverOptions(); err != nil {
+		return err
+	}
+
+	if o.requestsServingCert() {
+		if o.RegistrationDriver != "" && o.RegistrationDriver != clientcert.RegistrationDriverCSR {
+			return errors.New("the serving certificate is only requested with the csr registration driver")
+		}
+		if o.ServingCertSecret == "" {
+			return errors.New("serving cert secret is required if the serving certificate is requested")
+		}
+	}
+
+	if _, err := o.availabilityProbes(); err != nil {
+		return err
+	}
+
+	if _, err := o.healthCheckStrategies(); err != nil {
+		return err
+	}
+
+	if err := managedcluster.ValidateTunnelEndpointMetadata(o.TunnelEndpointMetadata); err != nil {
+		return err
+	}
+
+	switch o.TunnelEndpointPublisher {
+	case "", managedcluster.EndpointPublisherSecret, managedcluster.EndpointPublisherClaims:
+	default:
+		return fmt.Errorf("tunnel endpoint publisher %q is invalid, it must be one of %v",
+			o.TunnelEndpointPublisher, managedcluster.EndpointPublishers)
+	}
+
+	switch o.DeployMode {
+	case "", DeployModeDefault:
+	case DeployModeDetached:
+		if o.SpokeKubeconfig == "" {
+			return errors.New("spoke-kubeconfig is required in Detached mode")
+		}
+	default:
+		return fmt.Errorf("deploy mode %q is invalid, it must be one of %v", o.DeployMode, DeployModes)
+	}
+
+	switch o.AgentUpgradePolicy {
+	case "", managedcluster.UpgradePolicyNone, managedcluster.UpgradePolicyMajor, managedcluster.UpgradePolicyMinor,
+		managedcluster.UpgradePolicyPatch:
+	default:
+		return fmt.Errorf("agent upgrade policy %q is invalid, it must be one of %v", o.AgentUpgradePolicy, managedcluster.UpgradePolicies)
+	}
+
+	if _, err := o.clusterResourceOptions(); err != nil {
+		return err
+	}
+
+	if err := logging.Validate(o.LoggingFormat, o.ControllerLogLevels); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// leaseClientConfig returns a copy of the hub client config with the rate limit and timeout of the lease renewals
+func (o *SpokeAgentOptions) leaseClientConfig(hubClientConfig *rest.Config) *rest.Config {
+	config := helpers.WithRateLimit(hubClientConfig, o.LeaseClientQPS, o.LeaseClientBurst)
+	if o.LeaseRequestTimeout > 0 {
+		config.Timeout = o.LeaseRequestTimeout
+	}
+	return config
+}
+
+// availabilityProbes parses the availability probes of the managed cluster
+func (o *SpokeAgentOptions) availabilityProbes() ([]managedcluster.AvailabilityProbe, error) {
+	probes := []managedcluster.AvailabilityProbe{}
+	names := map[string]bool{}
+	for _, value := range o.AvailabilityProbes {
+		probe, err := managedcluster.ParseAvailabilityProbe(value)
+		if err != nil {
+			return nil, err
+		}
+		if names[probe.Name] {
+			return nil, fmt.Errorf("availability probe %q is duplicated", probe.Name)
+		}
+		names[probe.Name] = true
+		probes = append(probes, probe)
+	}
+	return probes, nil
+}
+
+// healthCheckStrategies parses the health check strategies of the kube-apiserver of the managed cluster
+func (o *SpokeAgentOptions) healthCheckStrategies() ([]managedcluster.HealthCheckStrategy, error) {
+	strategies := []managedcluster.HealthCheckStrategy{}
+	for _, value := range o.HealthCheckStrategies {
+		strategy, err := managedcluster.ParseHealthCheckStrategy(value)
+		if err != nil {
+			return nil, err
+		}
+		strategies = append(strategies, strategy)
+	}
+	return strategies, nil
+}
+
+// clusterResourceOptions returns the options to aggregate the capacity and allocatable of the managed cluster
+func (o *SpokeAgentOptions) clusterResourceOptions() (managedcluster.ClusterResourceOptions, error) {
+	options := managedcluster.ClusterResourceOptions{
+		ExcludedNodeTaints:     o.ClusterResourceExcludedNodeTaints,
+		ExcludeNotReadyNodes:   o.ClusterResourceExcludeNotReadyNodes,
+		ExcludeCordonedNodes:   o.ClusterResourceExcludeCordonedNodes,
+		RoleBreakdown:          o.ClusterResourceRoleBreakdown,
+		PlatformBreakdown:      o.ClusterResourcePlatformBreakdown,
+		ExtendedResources:      o.ClusterResourceExtendedResources,
+		MinUpdateInterval:      o.ClusterResourceUpdateMinInterval,
+		UpdateThresholdPercent: o.ClusterResourceUpdateThresholdPercent,
+		NodeSummary:            o.NodeSummaryClaims,
+	}
+	if o.ClusterResourceUpdateMinInterval < 0 {
+		return options, fmt.Errorf("cluster resource update min interval must not be negative")
+	}
+	if o.ClusterResourceUpdateThresholdPercent < 0 {
+		return options, fmt.Errorf("cluster resource update threshold percent must not be negative")
+	}
+	if err := managedcluster.ValidateExtendedResourcePatterns(o.ClusterResourceExtendedResources); err != nil {
+		return options, err
+	}
+	if len(o.ClusterResourceNodeSelector) > 0 {
+		selector, err := labels.Parse(o.ClusterResourceNodeSelector)
+		if err != nil {
+			return options, fmt.Errorf("cluster resource node selector %q is invalid: %w", o.ClusterResourceNodeSelector, err)
+		}
+		options.NodeSelector = selector
+	}
+	return options, nil
+}
+
+// clientCertPrivateKeyOption returns the option to generate the private keys of the client certificates
+func (o *SpokeAgentOptions) clientCertPrivateKeyOption() clientcert.PrivateKeyOption {
+	return clientcert.PrivateKeyOption{
+		Type:       o.ClientCertKeyType,
+		RSAKeySize: o.ClientCertRSAKeySize,
+		ECDSACurve: o.ClientCertECDSACurve,
+	}
+}
+
+// clientCertKeyProvider returns the provider generating and holding the private keys of the client certificates
+func (o *SpokeAgentOptions) clientCertKeyProvider() (clientcert.KeyProvider, error) {
+	return clientcert.NewKeyProvider(o.ClientCertKeyProvider, o.clientCertPrivateKeyOption())
+}
+
+// requestsServingCert returns true if the serving certificate of the agent is requested with any subject
+// alternative name
+func (o *SpokeAgentOptions) requestsServingCert() bool {
+	return len(o.ServingCertDNSNames) > 0 || len(o.ServingCertIPAddresses) > 0
+}
+
+func (o *SpokeAgentOptions) csrBackoffOption() clientcert.CSRBackoffOption {
+	return clientcert.CSRBackoffOption{
+		Initial:     o.CSRBackoffInitial,
+		Max:         o.CSRBackoffMax,
+		Multiplier:  o.CSRBackoffMultiplier,
+		MaxAttempts: o.CSRMaxAttempts,
+	}
+}
+
 // Complete fills in missing values.
 func (o *SpokeAgentOptions) Complete(coreV1Client corev1client.CoreV1Interface, ctx context.Context, recorder events.Recorder) error {
 	// get component namespace of spoke agent
@@ -510,6 +1505,10 @@ func generateAgentName() string {
 // completes. Changing the name of the cluster will make the existing hub kubeconfig invalid,
 // because certificate in TLSCertFile is issued to a specific cluster/agent.
 func (o *SpokeAgentOptions) hasValidHubClientConfig() (bool, error) {
+	if !o.usesClientCertificates() {
+		return o.hasValidTokenHubClientConfig()
+	}
+
 	kubeconfigPath := path.Join(o.HubKubeconfigDir, clientcert.KubeconfigFile)
 	if _, err := os.Stat(kubeconfigPath); os.IsNotExist(err) {
 		klog.V(4).Infof("Kubeconfig file %q not found", kubeconfigPath)
@@ -612,8 +1611,9 @@ func (o *SpokeAgentOptions) getOrGenerateClusterAgentNames() (string, string) {
 }
 
 // getSpokeClusterCABundle returns the spoke cluster Kubernetes client CA data when SpokeExternalServerURLs is specified
+// or the cluster endpoints are published
 func (o *SpokeAgentOptions) getSpokeClusterCABundle(kubeConfig *rest.Config) ([]byte, error) {
-	if len(o.SpokeExternalServerURLs) == 0 {
+	if len(o.SpokeExternalServerURLs) == 0 && !o.PublishClusterEndpoints {
 		return nil, nil
 	}
 	if kubeConfig.CAData != nil {
@@ -149,7 +149,7 @@ func TestValidate(t *testing.T) {
 		{
 			name:        "no bootstrap kubeconfig",
 			options:     &SpokeAgentOptions{},
-			expectedErr: "bootstrap-kubeconfig is required",
+			expectedErr: "bootstrap-kubeconfig or bootstrap-token is required",
 		},
 		{
 			name:        "no cluster name",
@@ -211,9 +211,380 @@ func TestValidate(t *testing.T) {
 				ClusterName:                 "testcluster",
 				AgentName:                   "testagent",
 				ClientCertExpirationSeconds: 600,
+				ClientCertRenewalThreshold:  0.2,
+				ClientCertRenewalJitter:     0.25,
 			},
 			expectedErr: "",
 		},
+		{
+			name: "invalid client cert renewal threshold",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 1,
+				ClientCertRenewalJitter:    0.25,
+			},
+			expectedErr: "client certificate renewal threshold must be in the range of (0, 1)",
+		},
+		{
+			name: "invalid client cert renewal jitter",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.6,
+				ClientCertRenewalJitter:    0.8,
+			},
+			expectedErr: "client certificate renewal jitter must be greater than zero and the renewal threshold with the jitter must be less than 1",
+		},
+		{
+			name: "invalid max concurrent addon csrs",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				MaxConcurrentAddOnCSRs:     -1,
+			},
+			expectedErr: "max concurrent addon csrs must not be negative",
+		},
+		{
+			name: "invalid csr backoff multiplier",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				CSRBackoffInitial:          1 * time.Minute,
+				CSRBackoffMultiplier:       0.5,
+			},
+			expectedErr: "csr backoff multiplier 0.5 must not be less than 1",
+		},
+		{
+			name: "invalid client cert key type",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				ClientCertKeyType:          "dsa",
+			},
+			expectedErr: "unsupported key type \"dsa\", it must be one of [ecdsa rsa]",
+		},
+		{
+			name: "invalid client cert key provider",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				ClientCertKeyProvider:      "tpm",
+			},
+			expectedErr: "unsupported key provider \"tpm\", it must be one of [software]",
+		},
+		{
+			name: "invalid registration transport",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				RegistrationTransport:      "grpc",
+			},
+			expectedErr: "registration transport \"grpc\" is invalid, it must be one of [kube mqtt]",
+		},
+		{
+			name: "mqtt registration transport without feature",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				RegistrationTransport:      "mqtt",
+				MQTTBrokerURL:              "tls://broker:8883",
+			},
+			expectedErr: "registration transport \"mqtt\" requires the MQTTTransport feature",
+		},
+		{
+			name: "invalid registration driver",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				RegistrationDriver:         "mqtt",
+			},
+			expectedErr: "registration driver \"mqtt\" is invalid, it must be one of [csr grpc awsirsa oidc]",
+		},
+		{
+			name: "serving cert without secret",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				ServingCertDNSNames:        []string{"testcluster.example.com"},
+			},
+			expectedErr: "serving cert secret is required if the serving certificate is requested",
+		},
+		{
+			name: "grpc registration driver without feature",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				RegistrationDriver:         "grpc",
+				RegistrationServiceAddress: "registration.example.com:8443",
+			},
+			expectedErr: "registration driver \"grpc\" requires the GRPCRegistrationDriver feature",
+		},
+		{
+			name: "awsirsa registration driver without feature",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				RegistrationDriver:         "awsirsa",
+				HubClusterARN:              "arn:aws:eks:us-west-2:123456789012:cluster/hub",
+			},
+			expectedErr: "registration driver \"awsirsa\" requires the AWSIAMRegistrationDriver feature",
+		},
+		{
+			name: "duplicated availability probes",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				AvailabilityProbes:         []string{"etcd=readyz:etcd", "etcd=path:/readyz/etcd"},
+			},
+			expectedErr: "availability probe \"etcd\" is duplicated",
+		},
+		{
+			name: "invalid health check strategy",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				HealthCheckStrategies:      []string{"readyz", "ping"},
+			},
+			expectedErr: "the type of health check strategy \"ping\" must be one of [livez readyz healthz list url]",
+		},
+		{
+			name: "invalid agent upgrade policy",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				AgentUpgradePolicy:         "build",
+			},
+			expectedErr: "agent upgrade policy \"build\" is invalid, it must be one of [none major minor patch]",
+		},
+		{
+			name: "invalid deploy mode",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				DeployMode:                 "Hosted",
+			},
+			expectedErr: "deploy mode \"Hosted\" is invalid, it must be one of [Default Detached]",
+		},
+		{
+			name: "no spoke kubeconfig in detached mode",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				DeployMode:                 DeployModeDetached,
+			},
+			expectedErr: "spoke-kubeconfig is required in Detached mode",
+		},
+		{
+			name: "invalid cluster label",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				ClusterLabels:              map[string]string{"environment": "production/eu"},
+			},
+			expectedErr: "value \"production/eu\" of cluster label \"environment\" is invalid: a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')",
+		},
+		{
+			name: "invalid reconciled cluster spec field",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:         "/spoke/bootstrap/kubeconfig",
+				ClusterName:                 "testcluster",
+				AgentName:                   "testagent",
+				ClusterHealthCheckPeriod:    1 * time.Minute,
+				ClientCertRenewalThreshold:  0.2,
+				ClientCertRenewalJitter:     0.25,
+				ReconciledClusterSpecFields: []string{"hubAcceptsClient"},
+			},
+			expectedErr: "cluster spec field \"hubAcceptsClient\" is invalid, it must be one of [managedClusterClientConfigs leaseDurationSeconds]",
+		},
+		{
+			name: "reconciled client configs with the published cluster endpoints",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:         "/spoke/bootstrap/kubeconfig",
+				ClusterName:                 "testcluster",
+				AgentName:                   "testagent",
+				ClusterHealthCheckPeriod:    1 * time.Minute,
+				ClientCertRenewalThreshold:  0.2,
+				ClientCertRenewalJitter:     0.25,
+				SpokeExternalServerURLs:     []string{"https://api.testcluster.example.com:6443"},
+				PublishClusterEndpoints:     true,
+				ReconciledClusterSpecFields: []string{"managedClusterClientConfigs"},
+			},
+			expectedErr: "the managed cluster client configs are either reconciled by the agent or copied by the hub from the published cluster endpoints, but not both",
+		},
+		{
+			name: "reconciled lease duration without cluster lease duration seconds",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:         "/spoke/bootstrap/kubeconfig",
+				ClusterName:                 "testcluster",
+				AgentName:                   "testagent",
+				ClusterHealthCheckPeriod:    1 * time.Minute,
+				ClientCertRenewalThreshold:  0.2,
+				ClientCertRenewalJitter:     0.25,
+				ReconciledClusterSpecFields: []string{"leaseDurationSeconds"},
+			},
+			expectedErr: "cluster lease duration seconds must greater than zero if the lease duration is reconciled",
+		},
+		{
+			name: "invalid tunnel endpoint publisher",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				TunnelEndpointPublisher:    "configmap",
+			},
+			expectedErr: "tunnel endpoint publisher \"configmap\" is invalid, it must be one of [secret claims]",
+		},
+		{
+			name: "invalid cluster resource node selector",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:         "/spoke/bootstrap/kubeconfig",
+				ClusterName:                 "testcluster",
+				AgentName:                   "testagent",
+				ClusterHealthCheckPeriod:    1 * time.Minute,
+				ClientCertRenewalThreshold:  0.2,
+				ClientCertRenewalJitter:     0.25,
+				ClusterResourceNodeSelector: "role in (worker",
+			},
+			expectedErr: "cluster resource node selector \"role in (worker\" is invalid: unable to parse requirement: found '', expected: ',' or ')'",
+		},
+		{
+			name: "negative cluster resource update threshold percent",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:                   "/spoke/bootstrap/kubeconfig",
+				ClusterName:                           "testcluster",
+				AgentName:                             "testagent",
+				ClusterHealthCheckPeriod:              1 * time.Minute,
+				ClientCertRenewalThreshold:            0.2,
+				ClientCertRenewalJitter:               0.25,
+				ClusterResourceUpdateMinInterval:      5 * time.Minute,
+				ClusterResourceUpdateThresholdPercent: -10,
+			},
+			expectedErr: "cluster resource update threshold percent must not be negative",
+		},
+		{
+			name: "invalid health probe failure threshold",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				HealthProbeBindAddress:     ":8000",
+			},
+			expectedErr: "health probe failure threshold must greater than zero",
+		},
+		{
+			name: "negative hub kube api qps",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				HubKubeAPIQPS:              -1,
+			},
+			expectedErr: "kube api qps and burst must not be negative",
+		},
+		{
+			name: "negative lease request timeout",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				LeaseRequestTimeout:        -1 * time.Second,
+			},
+			expectedErr: "lease client qps, burst and request timeout must not be negative",
+		},
+		{
+			name: "invalid controller log level",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:        "/spoke/bootstrap/kubeconfig",
+				ClusterName:                "testcluster",
+				AgentName:                  "testagent",
+				ClusterHealthCheckPeriod:   1 * time.Minute,
+				ClientCertRenewalThreshold: 0.2,
+				ClientCertRenewalJitter:    0.25,
+				ControllerLogLevels:        map[string]int{"ClusterClaimController": -1},
+			},
+			expectedErr: "log level -1 of controller \"ClusterClaimController\" must not be negative",
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -223,6 +594,26 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestLeaseClientConfig(t *testing.T) {
+	hubClientConfig := &rest.Config{Host: "https://hub:6443", QPS: 50, Burst: 100}
+
+	o := NewSpokeAgentOptions()
+	config := o.leaseClientConfig(hubClientConfig)
+	if config.Host != hubClientConfig.Host || config.QPS != 5 || config.Burst != 5 || config.Timeout != 10*time.Second {
+		t.Errorf("unexpected lease client config %s qps=%v burst=%d timeout=%v", config.Host, config.QPS, config.Burst, config.Timeout)
+	}
+	if hubClientConfig.QPS != 50 || hubClientConfig.Burst != 100 || hubClientConfig.Timeout != 0 {
+		t.Errorf("expected the hub client config is not changed")
+	}
+
+	o = &SpokeAgentOptions{}
+	config = o.leaseClientConfig(hubClientConfig)
+	if config.QPS != 50 || config.Burst != 100 || config.Timeout != 0 {
+		t.Errorf("expected the lease client config inherits the hub client config, but got qps=%v burst=%d timeout=%v",
+			config.QPS, config.Burst, config.Timeout)
+	}
+}
+
 func TestHasValidHubClientConfig(t *testing.T) {
 	tempDir, err := ioutil.TempDir("", "testvalidhubclientconfig")
 	if err != nil {
@@ -0,0 +1,64 @@
+package spoke
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"path"
+
+	certutil "k8s.io/client-go/util/cert"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/features"
+	"open-cluster-management.io/registration/pkg/transport"
+	"open-cluster-management.io/registration/pkg/transport/mqtt"
+)
+
+// validateTransportOptions verifies the options of the transport to report the heartbeats and status
+func (o *SpokeAgentOptions) validateTransportOptions() error {
+	switch o.RegistrationTransport {
+	case "", transport.TransportKube:
+		return nil
+	case transport.TransportMQTT:
+		if !features.DefaultSpokeMutableFeatureGate.Enabled(features.MQTTTransport) {
+			return fmt.Errorf("registration transport %q requires the MQTTTransport feature", o.RegistrationTransport)
+		}
+		if o.MQTTBrokerURL == "" {
+			return errors.New("mqtt broker url is required by the mqtt registration transport")
+		}
+		return nil
+	default:
+		return fmt.Errorf("registration transport %q is invalid, it must be one of %v", o.RegistrationTransport, transport.Transports)
+	}
+}
+
+// newMQTTClient returns an MQTT client which authenticates to the broker with the hub client certificate of the
+// agent. The certificate is loaded from the hub kubeconfig dir on each connection, so the rotated one is used
+// once the client reconnects.
+func (o *SpokeAgentOptions) newMQTTClient() (*mqtt.Client, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(
+				path.Join(o.HubKubeconfigDir, clientcert.TLSCertFile),
+				path.Join(o.HubKubeconfigDir, clientcert.TLSKeyFile))
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		},
+	}
+	if o.MQTTCAFile != "" {
+		pool, err := certutil.NewPool(o.MQTTCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load mqtt ca file %q: %w", o.MQTTCAFile, err)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return mqtt.NewClient(mqtt.Options{
+		BrokerURL: o.MQTTBrokerURL,
+		ClientID:  fmt.Sprintf("%s-%s", o.ClusterName, o.AgentName),
+		TLSConfig: tlsConfig,
+	})
+}
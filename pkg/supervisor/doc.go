@@ -0,0 +1,3 @@
+// package supervisor isolates the panics in the syncs of the controllers, so a panic in one controller, e.g. on a
+// malformed object, does not bring down the whole process, and restarts the panicked controllers with backoff.
+package supervisor
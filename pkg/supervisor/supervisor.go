@@ -0,0 +1,145 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// initialRestartBackoff is the backoff to restart a controller after its first panic, it is doubled on each of
+	// the following panics up to maxRestartBackoff
+	initialRestartBackoff = 1 * time.Second
+	maxRestartBackoff     = 5 * time.Minute
+	// restartBackoffResetPeriod is how long a controller runs without a panic before its backoff is reset
+	restartBackoffResetPeriod = 10 * time.Minute
+)
+
+// controllerRestarts is the number of the restarts of the controllers after their panics
+var controllerRestarts = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Name:           "controller_restarts_total",
+		Help:           "The number of the restarts of the controllers after their syncs panicked, partitioned by controller.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"controller"},
+)
+
+func init() {
+	legacyregistry.MustRegister(controllerRestarts)
+}
+
+// DefaultSupervisor is the supervisor of the controllers of the process
+var DefaultSupervisor = NewSupervisor(clock.RealClock{})
+
+// Sync wraps the sync function of a controller with the DefaultSupervisor
+func Sync(controllerName string, sync factory.SyncFunc) factory.SyncFunc {
+	return DefaultSupervisor.Sync(controllerName, sync)
+}
+
+// controllerState is the restart state of a controller
+type controllerState struct {
+	// backoff is the backoff of the last restart
+	backoff time.Duration
+	// lastPanicTime is the time of the last panic, and restartTime is the time the controller is restarted at
+	lastPanicTime time.Time
+	restartTime   time.Time
+}
+
+// Supervisor recovers the panics in the syncs of the controllers. The sync which panicked returns an error, so its
+// queue key is requeued with the rate limit of the controller, and the controller is restarted with backoff: its
+// syncs are held until the backoff expires, then it resumes with the keys queued meanwhile. Holding the syncs
+// rather than rebuilding the controller keeps the keys queued, which a rebuilt controller would lose.
+type Supervisor struct {
+	lock        sync.Mutex
+	clock       clock.Clock
+	controllers map[string]*controllerState
+}
+
+// NewSupervisor returns a Supervisor which schedules the restarts of the controllers with the clock
+func NewSupervisor(clock clock.Clock) *Supervisor {
+	return &Supervisor{
+		clock:       clock,
+		controllers: map[string]*controllerState{},
+	}
+}
+
+// Sync returns a sync func which recovers the panics of the sync func of the controller and holds the syncs until
+// the controller is restarted. The logger of the panics is the one in the context of the syncs.
+func (s *Supervisor) Sync(controllerName string, sync factory.SyncFunc) factory.SyncFunc {
+	return func(ctx context.Context, syncCtx factory.SyncContext) (err error) {
+		if err := s.waitForRestart(ctx, controllerName); err != nil {
+			return err
+		}
+
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			backoff := s.restart(controllerName)
+			klog.FromContext(ctx).Error(nil, "Controller panicked, restarting it with backoff",
+				"panic", r, "backoff", backoff, "stacktrace", string(debug.Stack()))
+			err = fmt.Errorf("controller %s panicked: %v", controllerName, r)
+		}()
+		return sync(ctx, syncCtx)
+	}
+}
+
+// restart schedules the restart of the controller after a panic, and returns the backoff of the restart
+func (s *Supervisor) restart(controllerName string) time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.clock.Now()
+	state, ok := s.controllers[controllerName]
+	if !ok {
+		state = &controllerState{}
+		s.controllers[controllerName] = state
+	}
+
+	switch {
+	case state.backoff == 0, now.Sub(state.lastPanicTime) > restartBackoffResetPeriod:
+		state.backoff = initialRestartBackoff
+	case state.backoff*2 > maxRestartBackoff:
+		state.backoff = maxRestartBackoff
+	default:
+		state.backoff *= 2
+	}
+	state.lastPanicTime = now
+	state.restartTime = now.Add(state.backoff)
+	controllerRestarts.WithLabelValues(controllerName).Inc()
+	return state.backoff
+}
+
+// waitForRestart blocks until the controller is restarted, it returns an error if the context is done meanwhile
+func (s *Supervisor) waitForRestart(ctx context.Context, controllerName string) error {
+	s.lock.Lock()
+	var wait time.Duration
+	if state, ok := s.controllers[controllerName]; ok {
+		wait = state.restartTime.Sub(s.clock.Now())
+	}
+	s.lock.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := s.clock.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		klog.FromContext(ctx).Info("Controller restarted after the panic")
+		return nil
+	}
+}
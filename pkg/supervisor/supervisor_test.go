@@ -0,0 +1,120 @@
+package supervisor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"k8s.io/component-base/metrics/testutil"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func restarts(t *testing.T, controllerName string) float64 {
+	value, err := testutil.GetCounterMetricValue(controllerRestarts.WithLabelValues(controllerName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestSync(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	supervisor := NewSupervisor(fakeClock)
+
+	syncs := 0
+	panicking := true
+	sync := supervisor.Sync("TestSync", func(ctx context.Context, syncCtx factory.SyncContext) error {
+		syncs++
+		if panicking {
+			panic("malformed object")
+		}
+		return nil
+	})
+	syncCtx := testinghelpers.NewFakeSyncContext(t, "key")
+
+	before := restarts(t, "TestSync")
+	if err := sync(context.TODO(), syncCtx); err == nil {
+		t.Errorf("expected the panic is returned as an error, but got nil")
+	}
+	if actual := restarts(t, "TestSync") - before; actual != 1 {
+		t.Errorf("expected 1 restart, but got %v", actual)
+	}
+
+	// the sync is held until the controller is restarted
+	panicking = false
+	done := make(chan error)
+	go func() {
+		done <- sync(context.TODO(), syncCtx)
+	}()
+	if err := waitForWaiters(fakeClock); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+		t.Fatalf("expected the sync is held until the controller is restarted")
+	default:
+	}
+	fakeClock.Step(initialRestartBackoff)
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if syncs != 2 {
+		t.Errorf("expected 2 syncs, but got %d", syncs)
+	}
+
+	// the held sync returns once the context is done
+	panicking = true
+	if err := sync(context.TODO(), syncCtx); err == nil {
+		t.Errorf("expected the panic is returned as an error, but got nil")
+	}
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if err := sync(ctx, syncCtx); err != context.Canceled {
+		t.Errorf("expected the context error, but got %v", err)
+	}
+	if syncs != 3 {
+		t.Errorf("expected 3 syncs, but got %d", syncs)
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	supervisor := NewSupervisor(fakeClock)
+
+	expected := []time.Duration{
+		1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second,
+		64 * time.Second, 128 * time.Second, 256 * time.Second, maxRestartBackoff, maxRestartBackoff,
+	}
+	for i, backoff := range expected {
+		if actual := supervisor.restart("TestRestartBackoff"); actual != backoff {
+			t.Errorf("expected backoff %v of restart %d, but got %v", backoff, i, actual)
+		}
+		fakeClock.Step(backoff)
+	}
+
+	// the backoff is reset once the controller runs without a panic for a while
+	fakeClock.Step(restartBackoffResetPeriod + time.Second)
+	if actual := supervisor.restart("TestRestartBackoff"); actual != initialRestartBackoff {
+		t.Errorf("expected the backoff is reset to %v, but got %v", initialRestartBackoff, actual)
+	}
+	// the backoffs of the controllers are independent
+	if actual := supervisor.restart("AnotherController"); actual != initialRestartBackoff {
+		t.Errorf("expected backoff %v, but got %v", initialRestartBackoff, actual)
+	}
+}
+
+func waitForWaiters(fakeClock *clocktesting.FakeClock) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for !fakeClock.HasWaiters() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return nil
+}
@@ -0,0 +1,2 @@
+// package tracing contains the helpers to trace the syncs of the controllers with OpenTelemetry
+package tracing
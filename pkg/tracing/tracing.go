@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"open-cluster-management.io/registration/pkg/logging"
+)
+
+// TracerName is the name of the tracer of the registration controllers
+const TracerName = "open-cluster-management.io/registration"
+
+const (
+	// ControllerKey is the attribute of the name of the controller
+	ControllerKey = attribute.Key("controller")
+	// QueueKeyKey is the attribute of the queue key handled by a sync
+	QueueKeyKey = attribute.Key("queue.key")
+	// ClusterNameKey is the attribute of the name of the managed cluster handled by a sync
+	ClusterNameKey = attribute.Key("cluster.name")
+	// ObjectAgeKey is the attribute of the seconds since the creation of the object handled by a sync, e.g. the
+	// CSR or the managed cluster, it shows how long a cluster takes from the CSR creation to being available
+	ObjectAgeKey = attribute.Key("object.age_seconds")
+)
+
+// TraceSync wraps the sync function of a controller with a span named after the controller. The span is in the
+// context of the sync function, so the sync function can annotate it with SetCluster. The context carries the
+// logger of the controller as well.
+func TraceSync(controllerName string, sync factory.SyncFunc) factory.SyncFunc {
+	sync = logging.SyncWithLogger(controllerName, sync)
+	return func(ctx context.Context, syncCtx factory.SyncContext) error {
+		ctx, span := otel.Tracer(TracerName).Start(ctx, controllerName+".sync", trace.WithAttributes(
+			ControllerKey.String(controllerName),
+			QueueKeyKey.String(syncCtx.QueueKey()),
+		))
+		defer span.End()
+
+		err := sync(ctx, syncCtx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+}
+
+// SetCluster annotates the span in the context with the name of the managed cluster and the age of the object
+// handled by the sync. It does nothing if the context has no span.
+func SetCluster(ctx context.Context, clusterName string, creationTimestamp metav1.Time) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	attrs := []attribute.KeyValue{ClusterNameKey.String(clusterName)}
+	if !creationTimestamp.IsZero() {
+		attrs = append(attrs, ObjectAgeKey.Float64(time.Since(creationTimestamp.Time).Seconds()))
+	}
+	span.SetAttributes(attrs...)
+}
+
+// AddEvent adds an event to the span in the context, e.g. the approval of a CSR or the acceptance of a cluster
+func AddEvent(ctx context.Context, name string, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).AddEvent(name, trace.WithAttributes(attrs...))
+}
+
+// Setup installs the global tracer provider which exports the spans to the OTLP gRPC endpoint, the exporter can
+// be further configured with the OTEL_EXPORTER_OTLP_* environment variables, e.g. OTEL_EXPORTER_OTLP_INSECURE.
+// The returned function flushes the pending spans and shuts down the tracer provider.
+func Setup(ctx context.Context, serviceName, endpoint string, samplingRatio float64) (func(context.Context) error, error) {
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplingRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
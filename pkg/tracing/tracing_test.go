@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+type fakeExporter struct {
+	sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (e *fakeExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.Lock()
+	defer e.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *fakeExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func TestTraceSync(t *testing.T) {
+	exporter := &fakeExporter{}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	cases := []struct {
+		name           string
+		syncErr        error
+		expectedStatus codes.Code
+	}{
+		{
+			name:           "sync succeeded",
+			expectedStatus: codes.Unset,
+		},
+		{
+			name:           "sync failed",
+			syncErr:        errors.New("failed"),
+			expectedStatus: codes.Error,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			exporter.spans = nil
+			sync := TraceSync("TestController", func(ctx context.Context, syncCtx factory.SyncContext) error {
+				SetCluster(ctx, "cluster1", metav1.NewTime(time.Now().Add(-time.Minute)))
+				AddEvent(ctx, "Synced")
+				return c.syncErr
+			})
+
+			err := sync(context.Background(), testinghelpers.NewFakeSyncContext(t, "cluster1"))
+			if err != c.syncErr {
+				t.Errorf("expected error %v, but got %v", c.syncErr, err)
+			}
+
+			if len(exporter.spans) != 1 {
+				t.Fatalf("expected 1 span, but got %d", len(exporter.spans))
+			}
+			span := exporter.spans[0]
+			if span.Name() != "TestController.sync" {
+				t.Errorf("unexpected span name %q", span.Name())
+			}
+			if span.Status().Code != c.expectedStatus {
+				t.Errorf("expected status %v, but got %v", c.expectedStatus, span.Status().Code)
+			}
+			attrs := map[string]interface{}{}
+			for _, attr := range span.Attributes() {
+				attrs[string(attr.Key)] = attr.Value.AsInterface()
+			}
+			if attrs[string(ClusterNameKey)] != "cluster1" || attrs[string(QueueKeyKey)] != "cluster1" {
+				t.Errorf("unexpected attributes %v", attrs)
+			}
+			if age, ok := attrs[string(ObjectAgeKey)].(float64); !ok || age < 60 {
+				t.Errorf("unexpected object age %v", attrs[string(ObjectAgeKey)])
+			}
+			// the error of a failed sync is recorded as an event too
+			if len(span.Events()) == 0 || span.Events()[0].Name != "Synced" {
+				t.Errorf("unexpected events %v", span.Events())
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"open-cluster-management.io/registration/pkg/transport"
+)
+
+const (
+	defaultKeepAlive      = 30 * time.Second
+	defaultReconnectDelay = 5 * time.Second
+	defaultMaxPacketSize  = 1024 * 1024
+	writeTimeout          = 10 * time.Second
+)
+
+// Options are the options to connect to an MQTT broker
+type Options struct {
+	// BrokerURL is the url of the broker, the scheme is tcp, or tls/ssl for the connections with TLS
+	BrokerURL string
+	ClientID  string
+	Username  string
+	Password  string
+	// TLSConfig is used if the scheme of the broker url is tls or ssl
+	TLSConfig *tls.Config
+	// KeepAlive is the interval to ping the broker, it defaults to 30 seconds
+	KeepAlive time.Duration
+	// MaxPacketSize is the max size of the packets published and received, it defaults to 1MiB. The connection is
+	// closed once a larger packet is received from the broker.
+	MaxPacketSize int
+}
+
+type subscription struct {
+	topicFilter string
+	handler     transport.MessageHandler
+}
+
+// Client is a minimal MQTT 3.1.1 client which publishes and subscribes with QoS 1. It reconnects to the broker
+// and subscribes to the topics again once the connection is lost. The sessions are not persisted, so the messages
+// which are not acknowledged when the connection is lost are dropped, the publishers are expected to publish the
+// messages periodically.
+type Client struct {
+	options Options
+	address string
+	useTLS  bool
+
+	lock          sync.Mutex
+	conn          net.Conn
+	subscriptions []subscription
+	packetID      uint16
+	// pendingAcks are the publishes waiting for the acknowledgements of the broker by the packet identifiers
+	pendingAcks map[uint16]chan error
+}
+
+// NewClient returns a Client with the options, the client connects to the broker once it is run.
+func NewClient(options Options) (*Client, error) {
+	brokerURL, err := url.Parse(options.BrokerURL)
+	if err != nil {
+		return nil, fmt.Errorf("mqtt broker url %q is invalid: %w", options.BrokerURL, err)
+	}
+
+	c := &Client{options: options, address: brokerURL.Host, pendingAcks: map[uint16]chan error{}}
+	switch brokerURL.Scheme {
+	case "tcp":
+	case "tls", "ssl":
+		c.useTLS = true
+	default:
+		return nil, fmt.Errorf("the scheme of mqtt broker url %q must be tcp, tls or ssl", options.BrokerURL)
+	}
+	if len(brokerURL.Port()) == 0 {
+		return nil, fmt.Errorf("the port of mqtt broker url %q is required", options.BrokerURL)
+	}
+	if c.options.KeepAlive <= 0 {
+		c.options.KeepAlive = defaultKeepAlive
+	}
+	if c.options.MaxPacketSize <= 0 {
+		c.options.MaxPacketSize = defaultMaxPacketSize
+	}
+	return c, nil
+}
+
+// Subscribe subscribes to the topics matching the topic filter, the handler is called in the receiving goroutine
+// of the client, so it should not block.
+func (c *Client) Subscribe(topicFilter string, handler transport.MessageHandler) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.subscriptions = append(c.subscriptions, subscription{topicFilter: topicFilter, handler: handler})
+	if c.conn != nil {
+		if err := c.writeLocked(subscribePacket(c.nextPacketIDLocked(), topicFilter)); err != nil {
+			klog.Errorf("failed to subscribe to mqtt topic %q: %v", topicFilter, err)
+		}
+	}
+}
+
+// Publish publishes the payload to the topic with QoS 1, and waits until the broker acknowledges it
+func (c *Client) Publish(ctx context.Context, topic string, payload []byte) error {
+	c.lock.Lock()
+	if c.conn == nil {
+		c.lock.Unlock()
+		return fmt.Errorf("mqtt broker %s is not connected", c.address)
+	}
+	packetID := c.nextPacketIDLocked()
+	p := publishPacket(topic, packetID, payload)
+	if len(p.body) > c.options.MaxPacketSize {
+		c.lock.Unlock()
+		return fmt.Errorf("the message of %d bytes to mqtt topic %q exceeds the max packet size of %d bytes",
+			len(payload), topic, c.options.MaxPacketSize)
+	}
+	ack := make(chan error, 1)
+	c.pendingAcks[packetID] = ack
+	if err := c.writeLocked(p); err != nil {
+		delete(c.pendingAcks, packetID)
+		c.lock.Unlock()
+		return err
+	}
+	c.lock.Unlock()
+
+	defer func() {
+		c.lock.Lock()
+		delete(c.pendingAcks, packetID)
+		c.lock.Unlock()
+	}()
+	select {
+	case err := <-ack:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(writeTimeout):
+		return fmt.Errorf("the message to mqtt topic %q is not acknowledged by broker %s", topic, c.address)
+	}
+}
+
+// Run connects to the broker and receives the messages of the subscriptions until the context is done
+func (c *Client) Run(ctx context.Context) {
+	for {
+		if err := c.serve(ctx); err != nil {
+			klog.Errorf("mqtt connection to %s is lost: %v", c.address, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(defaultReconnectDelay):
+		}
+	}
+}
+
+// serve connects to the broker, and dispatches the received messages until the connection is lost or the
+// context is done.
+func (c *Client) serve(ctx context.Context) error {
+	conn, reader, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	klog.Infof("Connected to mqtt broker %s", c.address)
+
+	c.lock.Lock()
+	c.conn = conn
+	filters := []string{}
+	for _, s := range c.subscriptions {
+		filters = append(filters, s.topicFilter)
+	}
+	if len(filters) > 0 {
+		if err := c.writeLocked(subscribePacket(c.nextPacketIDLocked(), filters...)); err != nil {
+			c.lock.Unlock()
+			c.disconnect(false)
+			return err
+		}
+	}
+	c.lock.Unlock()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(c.options.KeepAlive / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				c.disconnect(true)
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				c.lock.Lock()
+				if err := c.writeLocked(packet{packetType: packetPingReq}); err != nil {
+					klog.Errorf("failed to ping mqtt broker %s: %v", c.address, err)
+				}
+				c.lock.Unlock()
+			}
+		}
+	}()
+
+	for {
+		// the broker responds to the pings, so the connection is lost if nothing is received in the keep alive
+		if err := conn.SetReadDeadline(time.Now().Add(c.options.KeepAlive * 3 / 2)); err != nil {
+			c.disconnect(false)
+			return err
+		}
+		p, err := readPacket(reader, c.options.MaxPacketSize)
+		if err != nil {
+			c.disconnect(false)
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		switch p.packetType {
+		case packetPublish:
+			topic, packetID, payload, err := parsePublish(p)
+			if err != nil {
+				klog.Errorf("failed to parse the message from mqtt broker %s: %v", c.address, err)
+				continue
+			}
+			c.dispatch(topic, payload)
+			if packetID != 0 {
+				c.lock.Lock()
+				if err := c.writeLocked(pubAckPacket(packetID)); err != nil {
+					klog.Errorf("failed to acknowledge the message from mqtt broker %s: %v", c.address, err)
+				}
+				c.lock.Unlock()
+			}
+		case packetPubAck:
+			packetID, err := parsePubAck(p)
+			if err != nil {
+				klog.Errorf("failed to parse the acknowledgement from mqtt broker %s: %v", c.address, err)
+				continue
+			}
+			c.lock.Lock()
+			if ack, ok := c.pendingAcks[packetID]; ok {
+				ack <- nil
+				delete(c.pendingAcks, packetID)
+			}
+			c.lock.Unlock()
+		case packetSubAck:
+			codes, err := parseSubAck(p)
+			if err != nil {
+				klog.Errorf("failed to parse the subscription acknowledgement from mqtt broker %s: %v", c.address, err)
+				continue
+			}
+			for _, code := range codes {
+				if code == 0x80 {
+					klog.Errorf("mqtt broker %s rejected the subscription", c.address)
+				}
+			}
+		}
+	}
+}
+
+// connect dials the broker, and waits for the acknowledgement of the connection
+func (c *Client) connect(ctx context.Context) (net.Conn, *bufio.Reader, error) {
+	dialer := &net.Dialer{Timeout: writeTimeout}
+	var conn net.Conn
+	var err error
+	if c.useTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: c.options.TLSConfig}).DialContext(ctx, "tcp", c.address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", c.address)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keepAliveSeconds := uint16(c.options.KeepAlive / time.Second)
+	if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if err := writePacket(conn, connectPacket(c.options.ClientID, c.options.Username, c.options.Password, keepAliveSeconds)); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	if err := conn.SetReadDeadline(time.Now().Add(writeTimeout)); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	ack, err := readPacket(reader, c.options.MaxPacketSize)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if ack.packetType != packetConnAck || len(ack.body) != 2 {
+		conn.Close()
+		return nil, nil, errors.New("unexpected response to the connect packet")
+	}
+	if ack.body[1] != 0 {
+		conn.Close()
+		return nil, nil, fmt.Errorf("the connection is refused with return code %d", ack.body[1])
+	}
+	return conn, reader, nil
+}
+
+// disconnect closes the current connection, and sends the disconnect packet first if graceful is true. The pending
+// publishes fail because they are not acknowledged.
+func (c *Client) disconnect(graceful bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.conn == nil {
+		return
+	}
+	if graceful {
+		_ = c.writeLocked(packet{packetType: packetDisconnect})
+	}
+	c.conn.Close()
+	c.conn = nil
+	for packetID, ack := range c.pendingAcks {
+		ack <- fmt.Errorf("mqtt connection to %s is lost before the message is acknowledged", c.address)
+		delete(c.pendingAcks, packetID)
+	}
+}
+
+func (c *Client) dispatch(topic string, payload []byte) {
+	c.lock.Lock()
+	handlers := []transport.MessageHandler{}
+	for _, s := range c.subscriptions {
+		if topicMatches(s.topicFilter, topic) {
+			handlers = append(handlers, s.handler)
+		}
+	}
+	c.lock.Unlock()
+
+	for _, handler := range handlers {
+		handler(topic, payload)
+	}
+}
+
+func (c *Client) writeLocked(p packet) error {
+	if c.conn == nil {
+		return fmt.Errorf("mqtt broker %s is not connected", c.address)
+	}
+	if err := c.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	return writePacket(c.conn, p)
+}
+
+func (c *Client) nextPacketIDLocked() uint16 {
+	c.packetID++
+	if c.packetID == 0 {
+		c.packetID = 1
+	}
+	return c.packetID
+}
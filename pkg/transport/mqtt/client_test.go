@@ -0,0 +1,196 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// testBroker is a minimal broker which forwards the published messages to the subscribers on the same server
+type testBroker struct {
+	listener net.Listener
+	lock     sync.Mutex
+	conns    map[net.Conn][]string
+	// acks is the number of the acknowledgements of the forwarded messages
+	acks int
+}
+
+func newTestBroker(t *testing.T) *testBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b := &testBroker{listener: listener, conns: map[net.Conn][]string{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *testBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		p, err := readPacket(reader, maxRemainingLength)
+		if err != nil {
+			return
+		}
+		switch p.packetType {
+		case packetConnect:
+			b.write(conn, packet{packetType: packetConnAck, body: []byte{0, 0}})
+		case packetSubscribe:
+			filters := []string{}
+			rest := p.body[2:]
+			for len(rest) > 0 {
+				filter, r, err := readString(rest)
+				if err != nil {
+					return
+				}
+				filters = append(filters, filter)
+				rest = r[1:]
+			}
+			b.lock.Lock()
+			b.conns[conn] = append(b.conns[conn], filters...)
+			b.lock.Unlock()
+			b.write(conn, packet{packetType: packetSubAck, body: append(p.body[:2:2], make([]byte, len(filters))...)})
+		case packetPublish:
+			topic, packetID, payload, err := parsePublish(p)
+			if err != nil {
+				return
+			}
+			b.lock.Lock()
+			for subscriber, filters := range b.conns {
+				for _, filter := range filters {
+					if topicMatches(filter, topic) {
+						_ = writePacket(subscriber, publishPacket(topic, 1, payload))
+						break
+					}
+				}
+			}
+			b.lock.Unlock()
+			if packetID != 0 {
+				b.write(conn, pubAckPacket(packetID))
+			}
+		case packetPubAck:
+			b.lock.Lock()
+			b.acks++
+			b.lock.Unlock()
+		case packetPingReq:
+			b.write(conn, packet{packetType: packetPingResp})
+		case packetDisconnect:
+			return
+		}
+	}
+}
+
+func (b *testBroker) write(conn net.Conn, p packet) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	_ = writePacket(conn, p)
+}
+
+func (b *testBroker) url() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func TestNewClient(t *testing.T) {
+	cases := []struct {
+		name        string
+		brokerURL   string
+		expectedErr bool
+	}{
+		{name: "tcp", brokerURL: "tcp://broker:1883"},
+		{name: "tls", brokerURL: "tls://broker:8883"},
+		{name: "invalid scheme", brokerURL: "http://broker:1883", expectedErr: true},
+		{name: "no port", brokerURL: "tcp://broker", expectedErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := NewClient(Options{BrokerURL: c.brokerURL})
+			if c.expectedErr != (err != nil) {
+				t.Errorf("expected error %v, but got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestPublishAndSubscribe(t *testing.T) {
+	broker := newTestBroker(t)
+	defer broker.listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subscriber, err := NewClient(Options{BrokerURL: broker.url(), ClientID: "subscriber"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	received := make(chan string, 1)
+	subscriber.Subscribe("clusters/+/status", func(topic string, payload []byte) {
+		received <- topic + ":" + string(payload)
+	})
+	go subscriber.Run(ctx)
+
+	publisher, err := NewClient(Options{BrokerURL: broker.url(), ClientID: "publisher", MaxPacketSize: 1024})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := publisher.Publish(ctx, "clusters/cluster1/status", []byte("ok")); err == nil {
+		t.Errorf("expected error before the publisher is connected")
+	}
+	go publisher.Run(ctx)
+
+	// wait until the subscription is acknowledged, the messages published before it are dropped
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		broker.lock.Lock()
+		defer broker.lock.Unlock()
+		for _, filters := range broker.conns {
+			if len(filters) > 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	}); err != nil {
+		t.Fatalf("the subscriber is not subscribed: %v", err)
+	}
+
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return publisher.Publish(ctx, "clusters/cluster1/status", []byte("ok")) == nil, nil
+	}); err != nil {
+		t.Fatalf("the publisher is not connected: %v", err)
+	}
+
+	select {
+	case message := <-received:
+		if message != "clusters/cluster1/status:ok" {
+			t.Errorf("unexpected message %q", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("the message is not received")
+	}
+
+	// the subscriber acknowledges the message of QoS 1
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		broker.lock.Lock()
+		defer broker.lock.Unlock()
+		return broker.acks == 1, nil
+	}); err != nil {
+		t.Errorf("the message is not acknowledged by the subscriber: %v", err)
+	}
+
+	if err := publisher.Publish(ctx, "clusters/cluster1/status", []byte(strings.Repeat("x", 2048))); err == nil {
+		t.Errorf("expected error for the message exceeding the max packet size")
+	}
+}
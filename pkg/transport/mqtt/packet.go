@@ -0,0 +1,207 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// the control packet types of MQTT 3.1.1, only the packets of QoS 0 and 1 are supported
+const (
+	packetConnect      byte = 1
+	packetConnAck      byte = 2
+	packetPublish      byte = 3
+	packetPubAck       byte = 4
+	packetSubscribe    byte = 8
+	packetSubAck       byte = 9
+	packetPingReq      byte = 12
+	packetPingResp     byte = 13
+	packetDisconnect   byte = 14
+	protocolLevel311   byte = 4
+	maxRemainingLength      = 268435455
+)
+
+// packet is an MQTT control packet
+type packet struct {
+	packetType byte
+	flags      byte
+	body       []byte
+}
+
+// writePacket encodes the packet with the fixed header
+func writePacket(w io.Writer, p packet) error {
+	if len(p.body) > maxRemainingLength {
+		return fmt.Errorf("the packet is too large: %d bytes", len(p.body))
+	}
+
+	header := []byte{p.packetType<<4 | p.flags&0x0f}
+	length := len(p.body)
+	for {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		header = append(header, b)
+		if length == 0 {
+			break
+		}
+	}
+
+	if _, err := w.Write(append(header, p.body...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readPacket decodes a packet with the fixed header, the packets with a body larger than the max size are rejected
+// before they are read.
+func readPacket(r *bufio.Reader, maxSize int) (packet, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return packet{}, errors.New("malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return packet{}, err
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+
+	if length > maxSize {
+		return packet{}, fmt.Errorf("the packet of %d bytes exceeds the max size of %d bytes", length, maxSize)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+	return packet{packetType: first >> 4, flags: first & 0x0f, body: body}, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errors.New("malformed string")
+	}
+	length := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+length {
+		return "", nil, errors.New("malformed string")
+	}
+	return string(b[2 : 2+length]), b[2+length:], nil
+}
+
+func connectPacket(clientID, username, password string, keepAliveSeconds uint16) packet {
+	flags := byte(0x02) // clean session
+	if len(username) > 0 {
+		flags |= 0x80
+	}
+	if len(password) > 0 {
+		flags |= 0x40
+	}
+
+	body := appendString(nil, "MQTT")
+	body = append(body, protocolLevel311, flags)
+	body = binary.BigEndian.AppendUint16(body, keepAliveSeconds)
+	body = appendString(body, clientID)
+	if len(username) > 0 {
+		body = appendString(body, username)
+	}
+	if len(password) > 0 {
+		body = appendString(body, password)
+	}
+	return packet{packetType: packetConnect, body: body}
+}
+
+// publishPacket returns a publish packet of QoS 1 with the packet identifier, or QoS 0 if the identifier is 0
+func publishPacket(topic string, packetID uint16, payload []byte) packet {
+	body := appendString(nil, topic)
+	if packetID == 0 {
+		return packet{packetType: packetPublish, body: append(body, payload...)}
+	}
+	body = binary.BigEndian.AppendUint16(body, packetID)
+	return packet{packetType: packetPublish, flags: 0x02, body: append(body, payload...)}
+}
+
+// parsePublish returns the topic, packet identifier and payload of a publish packet, the identifier is 0 for QoS 0.
+// The packets of QoS 2 are rejected, the client subscribes with QoS 1 and is not able to complete the QoS 2 flow.
+func parsePublish(p packet) (string, uint16, []byte, error) {
+	qos := (p.flags >> 1) & 0x03
+	if qos > 1 {
+		return "", 0, nil, fmt.Errorf("publish packet of QoS %d is not supported", qos)
+	}
+	topic, rest, err := readString(p.body)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if qos == 1 {
+		if len(rest) < 2 {
+			return "", 0, nil, errors.New("malformed publish packet")
+		}
+		return topic, binary.BigEndian.Uint16(rest), rest[2:], nil
+	}
+	return topic, 0, rest, nil
+}
+
+func pubAckPacket(packetID uint16) packet {
+	return packet{packetType: packetPubAck, body: binary.BigEndian.AppendUint16(nil, packetID)}
+}
+
+// parsePubAck returns the packet identifier of a puback packet
+func parsePubAck(p packet) (uint16, error) {
+	if len(p.body) != 2 {
+		return 0, errors.New("malformed puback packet")
+	}
+	return binary.BigEndian.Uint16(p.body), nil
+}
+
+// parseSubAck returns the return codes of a suback packet
+func parseSubAck(p packet) ([]byte, error) {
+	if len(p.body) < 2 {
+		return nil, errors.New("malformed suback packet")
+	}
+	return p.body[2:], nil
+}
+
+func subscribePacket(packetID uint16, topicFilters ...string) packet {
+	body := binary.BigEndian.AppendUint16(nil, packetID)
+	for _, filter := range topicFilters {
+		body = appendString(body, filter)
+		body = append(body, 1) // QoS 1
+	}
+	return packet{packetType: packetSubscribe, flags: 0x02, body: body}
+}
+
+// topicMatches returns true if the topic matches the topic filter with the wildcards + and #
+func topicMatches(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
@@ -0,0 +1,143 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPacketEncoding(t *testing.T) {
+	cases := []struct {
+		name   string
+		packet packet
+	}{
+		{
+			name:   "no body",
+			packet: packet{packetType: packetPingReq},
+		},
+		{
+			name:   "short body",
+			packet: publishPacket("a/b", 0, []byte("hello")),
+		},
+		{
+			name:   "qos 1",
+			packet: publishPacket("a/b", 1, []byte("hello")),
+		},
+		{
+			name:   "multi bytes remaining length",
+			packet: publishPacket("a/b", 0, []byte(strings.Repeat("x", 20000))),
+		},
+		{
+			name:   "flags",
+			packet: subscribePacket(1, "a/+", "b/#"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := writePacket(buf, c.packet); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			decoded, err := readPacket(bufio.NewReader(buf), maxRemainingLength)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decoded.packetType != c.packet.packetType || decoded.flags != c.packet.flags ||
+				!bytes.Equal(decoded.body, c.packet.body) {
+				t.Errorf("expected packet %v, but got %v", c.packet, decoded)
+			}
+		})
+	}
+}
+
+func TestReadPacketExceedsMaxSize(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := writePacket(buf, publishPacket("a/b", 0, []byte(strings.Repeat("x", 2000)))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := readPacket(bufio.NewReader(buf), 1024); err == nil {
+		t.Errorf("expected error, but got nil")
+	}
+}
+
+func TestParsePublish(t *testing.T) {
+	topic, packetID, payload, err := parsePublish(publishPacket("a/b", 0, []byte("hello")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if topic != "a/b" || packetID != 0 || string(payload) != "hello" {
+		t.Errorf("unexpected topic %q, packet id %d and payload %q", topic, packetID, string(payload))
+	}
+
+	// the packet identifier follows the topic for QoS 1
+	topic, packetID, payload, err = parsePublish(publishPacket("a/b", 258, []byte("hi")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if topic != "a/b" || packetID != 258 || string(payload) != "hi" {
+		t.Errorf("unexpected topic %q, packet id %d and payload %q", topic, packetID, string(payload))
+	}
+
+	if _, _, _, err := parsePublish(packet{packetType: packetPublish, body: []byte{0, 5, 'a'}}); err == nil {
+		t.Errorf("expected error, but got nil")
+	}
+
+	// the packets of QoS 2 are not supported
+	qos2 := publishPacket("a/b", 258, []byte("hi"))
+	qos2.flags = 0x04
+	if _, _, _, err := parsePublish(qos2); err == nil {
+		t.Errorf("expected error, but got nil")
+	}
+}
+
+func TestParseSubAck(t *testing.T) {
+	codes, err := parseSubAck(packet{packetType: packetSubAck, body: []byte{1, 2, 1, 0x80}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes) != 2 || codes[0] != 1 || codes[1] != 0x80 {
+		t.Errorf("unexpected return codes %v", codes)
+	}
+
+	if _, err := parseSubAck(packet{packetType: packetSubAck, body: []byte{1}}); err == nil {
+		t.Errorf("expected error, but got nil")
+	}
+}
+
+func TestParsePubAck(t *testing.T) {
+	packetID, err := parsePubAck(pubAckPacket(258))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if packetID != 258 {
+		t.Errorf("unexpected packet id %d", packetID)
+	}
+
+	if _, err := parsePubAck(packet{packetType: packetPubAck, body: []byte{1}}); err == nil {
+		t.Errorf("expected error, but got nil")
+	}
+}
+
+func TestTopicMatches(t *testing.T) {
+	cases := []struct {
+		filter  string
+		topic   string
+		matches bool
+	}{
+		{filter: "a/b", topic: "a/b", matches: true},
+		{filter: "a/b", topic: "a/c", matches: false},
+		{filter: "a/+/c", topic: "a/b/c", matches: true},
+		{filter: "a/+/c", topic: "a/b/d", matches: false},
+		{filter: "a/+", topic: "a/b/c", matches: false},
+		{filter: "a/#", topic: "a/b/c", matches: true},
+		{filter: "a/#", topic: "a", matches: true},
+		{filter: "#", topic: "a/b", matches: true},
+		{filter: "a/b/c", topic: "a/b", matches: false},
+	}
+	for _, c := range cases {
+		if actual := topicMatches(c.filter, c.topic); actual != c.matches {
+			t.Errorf("expected %v for filter %q and topic %q, but got %v", c.matches, c.filter, c.topic, actual)
+		}
+	}
+}
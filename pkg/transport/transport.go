@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/hub/user"
+)
+
+const (
+	// TransportKube reports the heartbeats and status of a managed cluster with the hub kube-apiserver
+	TransportKube = "kube"
+	// TransportMQTT reports the heartbeats and status of a managed cluster to an MQTT broker, and the hub
+	// consumes them from the broker
+	TransportMQTT = "mqtt"
+
+	// the topics of an agent are under its user name, which is the common name of its hub client certificate, e.g.
+	// open-cluster-management/agents/system:open-cluster-management:cluster1:agent1/status. The broker must
+	// authenticate the agents with their hub client certificates and only allow them to publish to the topics of
+	// their own user names, e.g. with use_identity_as_username and the acl pattern
+	// "pattern write open-cluster-management/agents/%u/#" of mosquitto.
+	topicPrefix     = "open-cluster-management/agents/"
+	heartbeatSuffix = "/heartbeat"
+	statusSuffix    = "/status"
+
+	// HeartbeatTopicFilter matches the heartbeat topics of all of the agents
+	HeartbeatTopicFilter = topicPrefix + "+" + heartbeatSuffix
+	// StatusTopicFilter matches the status topics of all of the agents
+	StatusTopicFilter = topicPrefix + "+" + statusSuffix
+)
+
+// Transports are the supported transports
+var Transports = []string{TransportKube, TransportMQTT}
+
+// Heartbeat is the message to renew the lease of a managed cluster
+type Heartbeat struct {
+	ClusterName string      `json:"clusterName"`
+	RenewTime   metav1.Time `json:"renewTime"`
+}
+
+// StatusUpdate is the message to update the status of a managed cluster. The conditions are reported in the status,
+// and the resources and version are only reported once they are collected from the managed cluster.
+type StatusUpdate struct {
+	ClusterName string                         `json:"clusterName"`
+	Status      clusterv1.ManagedClusterStatus `json:"status"`
+	Resources   *Resources                     `json:"resources,omitempty"`
+}
+
+// Resources are the capacity, allocatable and version collected from the managed cluster
+type Resources struct {
+	Capacity    clusterv1.ResourceList          `json:"capacity,omitempty"`
+	Allocatable clusterv1.ResourceList          `json:"allocatable,omitempty"`
+	Version     clusterv1.ManagedClusterVersion `json:"version,omitempty"`
+}
+
+// Publisher publishes the messages to the topics
+type Publisher interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+}
+
+// MessageHandler handles a message received from a topic
+type MessageHandler func(topic string, payload []byte)
+
+// Subscriber subscribes to the topics matching the topic filter
+type Subscriber interface {
+	Subscribe(topicFilter string, handler MessageHandler)
+}
+
+// HeartbeatTopic returns the topic of the heartbeats published by the agent of the managed cluster
+func HeartbeatTopic(clusterName, agentName string) string {
+	return topicPrefix + agentUserName(clusterName, agentName) + heartbeatSuffix
+}
+
+// StatusTopic returns the topic of the status updates published by the agent of the managed cluster
+func StatusTopic(clusterName, agentName string) string {
+	return topicPrefix + agentUserName(clusterName, agentName) + statusSuffix
+}
+
+// ClusterNameFromTopic returns the managed cluster name of the agent user name in a heartbeat or status topic. The
+// broker only allows the agents to publish to the topics of their own user names, so the cluster is authenticated.
+func ClusterNameFromTopic(topic string) string {
+	if !strings.HasPrefix(topic, topicPrefix) {
+		return ""
+	}
+	levels := strings.Split(strings.TrimPrefix(topic, topicPrefix), "/")
+	if len(levels) != 2 || !strings.HasPrefix(levels[0], user.SubjectPrefix) {
+		return ""
+	}
+	names := strings.Split(strings.TrimPrefix(levels[0], user.SubjectPrefix), ":")
+	if len(names) != 2 || len(names[0]) == 0 || len(names[1]) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// agentUserName returns the user name of the agent, which is the common name of its hub client certificate
+func agentUserName(clusterName, agentName string) string {
+	return fmt.Sprintf("%s%s:%s", user.SubjectPrefix, clusterName, agentName)
+}
+
+// PublishJSON publishes the object encoded in json to the topic
+func PublishJSON(ctx context.Context, publisher Publisher, topic string, obj interface{}) error {
+	payload, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return publisher.Publish(ctx, topic, payload)
+}
@@ -0,0 +1,24 @@
+package transport
+
+import "testing"
+
+func TestClusterNameFromTopic(t *testing.T) {
+	cases := []struct {
+		topic               string
+		expectedClusterName string
+	}{
+		{topic: HeartbeatTopic("cluster1", "agent1"), expectedClusterName: "cluster1"},
+		{topic: StatusTopic("cluster1", "agent1"), expectedClusterName: "cluster1"},
+		{topic: "open-cluster-management/agents/system:open-cluster-management:cluster1:agent1", expectedClusterName: ""},
+		{topic: "open-cluster-management/agents/system:open-cluster-management:cluster1:agent1/a/b", expectedClusterName: ""},
+		{topic: "open-cluster-management/agents/system:open-cluster-management:cluster1/status", expectedClusterName: ""},
+		{topic: "open-cluster-management/agents/system:open-cluster-management::agent1/status", expectedClusterName: ""},
+		{topic: "open-cluster-management/agents/cluster1/status", expectedClusterName: ""},
+		{topic: "open-cluster-management/clusters/cluster1/status", expectedClusterName: ""},
+	}
+	for _, c := range cases {
+		if actual := ClusterNameFromTopic(c.topic); actual != c.expectedClusterName {
+			t.Errorf("expected cluster name %q of topic %q, but got %q", c.expectedClusterName, c.topic, actual)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package certificates
+
+import (
+	"context"
+	"fmt"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/hub/csr"
+)
+
+var _ webhook.CustomValidator = &CSRWebhook{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *CSRWebhook) ValidateCreate(_ context.Context, obj runtime.Object) error {
+	request, ok := obj.(*certificatesv1.CertificateSigningRequest)
+	if !ok {
+		return apierrors.NewBadRequest("Request csr obj format is not right")
+	}
+
+	// only the serving certificates of the agents are validated
+	if request.Spec.SignerName != helpers.ClusterServingSignerName {
+		return nil
+	}
+
+	usages := []string{}
+	for _, usage := range request.Spec.Usages {
+		usages = append(usages, string(usage))
+	}
+	if !csr.ServingUsagesAllowed(usages) {
+		return apierrors.NewBadRequest(fmt.Sprintf("The key usages %v are not allowed by signer %q.",
+			usages, helpers.ClusterServingSignerName))
+	}
+	if allowed, reason := r.SANAllowList.Allowed(request.Spec.Request); !allowed {
+		return apierrors.NewBadRequest(reason)
+	}
+	return nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *CSRWebhook) ValidateUpdate(_ context.Context, _, _ runtime.Object) error {
+	// the spec of the csrs is immutable
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *CSRWebhook) ValidateDelete(_ context.Context, _ runtime.Object) error {
+	return nil
+}
@@ -0,0 +1,89 @@
+package certificates
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+	"open-cluster-management.io/registration/pkg/hub/csr"
+)
+
+func TestValidateCreate(t *testing.T) {
+	cases := []struct {
+		name          string
+		signerName    string
+		usages        []certificatesv1.KeyUsage
+		dnsNames      []string
+		ipAddresses   []net.IP
+		expectedError bool
+	}{
+		{
+			name:        "csr of other signers",
+			signerName:  certificatesv1.KubeAPIServerClientSignerName,
+			usages:      clientcert.ClientCertUsages,
+			dnsNames:    []string{"cluster1.other.com"},
+			ipAddresses: []net.IP{net.ParseIP("192.168.0.1")},
+		},
+		{
+			name:        "allowed serving csr",
+			signerName:  helpers.ClusterServingSignerName,
+			usages:      clientcert.ServingCertUsages,
+			dnsNames:    []string{"cluster1.example.com"},
+			ipAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+		},
+		{
+			name:          "client auth usage",
+			signerName:    helpers.ClusterServingSignerName,
+			usages:        clientcert.ClientCertUsages,
+			dnsNames:      []string{"cluster1.example.com"},
+			expectedError: true,
+		},
+		{
+			name:          "dns name not allowed",
+			signerName:    helpers.ClusterServingSignerName,
+			usages:        clientcert.ServingCertUsages,
+			dnsNames:      []string{"cluster1.other.com"},
+			expectedError: true,
+		},
+		{
+			name:          "ip address not allowed",
+			signerName:    helpers.ClusterServingSignerName,
+			usages:        clientcert.ServingCertUsages,
+			ipAddresses:   []net.IP{net.ParseIP("192.168.0.1")},
+			expectedError: true,
+		},
+	}
+
+	allowList, err := csr.NewSANAllowList([]string{"*.example.com"}, []string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &CSRWebhook{SANAllowList: allowList}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			request := testinghelpers.NewCSR(testinghelpers.CSRHolder{
+				Name:         "cluster1-serving-abcde",
+				SignerName:   c.signerName,
+				CN:           "system:open-cluster-management:cluster1:agent1",
+				Orgs:         []string{"system:open-cluster-management:cluster1"},
+				ReqBlockType: "CERTIFICATE REQUEST",
+				DNSNames:     c.dnsNames,
+				IPAddresses:  c.ipAddresses,
+			})
+			request.Spec.Usages = c.usages
+
+			err := w.ValidateCreate(context.Background(), request)
+			if c.expectedError && err == nil {
+				t.Errorf("expected error, but got nil")
+			}
+			if !c.expectedError && err != nil {
+				t.Errorf("expected no error, but got %v", err)
+			}
+		})
+	}
+}
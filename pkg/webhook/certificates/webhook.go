@@ -0,0 +1,27 @@
+package certificates
+
+import (
+	certificatesv1 "k8s.io/api/certificates/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"open-cluster-management.io/registration/pkg/hub/csr"
+)
+
+// CSRWebhook validates the CertificateSigningRequests of the serving certificates of the agents, so the requests
+// for the subject alternative names out of the allow list are rejected before anyone approves or signs them
+type CSRWebhook struct {
+	// SANAllowList is the allow list of the subject alternative names in the serving certificates of the agents, no
+	// name is allowed if it is nil
+	SANAllowList *csr.SANAllowList
+}
+
+func (r *CSRWebhook) Init(mgr ctrl.Manager) error {
+	return r.SetupWebhookWithManager(mgr)
+}
+
+func (r *CSRWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		WithValidator(r).
+		For(&certificatesv1.CertificateSigningRequest{}).
+		Complete()
+}
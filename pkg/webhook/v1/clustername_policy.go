@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ClusterNamePolicy is the naming convention of the ManagedClusters enforced at admission, on top of the format
+// of the namespace names which is always required. It is only enforced on creating the ManagedClusters, so the
+// existing ManagedClusters are not blocked once the policy is changed.
+type ClusterNamePolicy struct {
+	// allowedPatterns are the regular expressions the names must match entirely, any name is allowed if it is empty
+	allowedPatterns []*regexp.Regexp
+	// maxLength is the max length of the names, it is not limited beyond the format of the namespace names if it
+	// is zero
+	maxLength int
+	// reservedPrefixes are the prefixes the names are not allowed to start with, e.g. local-cluster
+	reservedPrefixes []string
+}
+
+// NewClusterNamePolicy returns a ClusterNamePolicy with the allowed patterns, the max length and the reserved
+// prefixes, or an error if a pattern is not a valid regular expression or the max length is out of range.
+func NewClusterNamePolicy(allowedPatterns []string, maxLength int, reservedPrefixes []string) (*ClusterNamePolicy, error) {
+	if maxLength < 0 || maxLength > validation.DNS1123LabelMaxLength {
+		return nil, fmt.Errorf("the max length of the cluster names must be between 0 and %d, but got %d",
+			validation.DNS1123LabelMaxLength, maxLength)
+	}
+
+	policy := &ClusterNamePolicy{
+		maxLength:        maxLength,
+		reservedPrefixes: reservedPrefixes,
+	}
+	for _, pattern := range allowedPatterns {
+		// anchor the pattern so a name is allowed only if it matches the pattern entirely
+		re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", pattern))
+		if err != nil {
+			return nil, fmt.Errorf("the cluster name pattern %q is invalid: %v", pattern, err)
+		}
+		policy.allowedPatterns = append(policy.allowedPatterns, re)
+	}
+	return policy, nil
+}
+
+// Validate returns the reasons why the name is not allowed by the policy, it is empty if the name is allowed.
+func (p *ClusterNamePolicy) Validate(name string) []string {
+	if p == nil {
+		return nil
+	}
+
+	errMsgs := []string{}
+	if p.maxLength > 0 && len(name) > p.maxLength {
+		errMsgs = append(errMsgs, fmt.Sprintf("must be no more than %d characters", p.maxLength))
+	}
+	for _, prefix := range p.reservedPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			errMsgs = append(errMsgs, fmt.Sprintf("must not start with the reserved prefix %q", prefix))
+		}
+	}
+	if len(p.allowedPatterns) > 0 && !p.matchesAny(name) {
+		patterns := []string{}
+		for _, re := range p.allowedPatterns {
+			patterns = append(patterns, re.String())
+		}
+		errMsgs = append(errMsgs, fmt.Sprintf("must match one of the patterns %s", strings.Join(patterns, ", ")))
+	}
+	return errMsgs
+}
+
+func (p *ClusterNamePolicy) matchesAny(name string) bool {
+	for _, re := range p.allowedPatterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
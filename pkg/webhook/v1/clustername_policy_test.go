@@ -0,0 +1,124 @@
+package v1
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newClusterNamePolicy(t *testing.T, allowedPatterns []string, maxLength int, reservedPrefixes []string) *ClusterNamePolicy {
+	policy, err := NewClusterNamePolicy(allowedPatterns, maxLength, reservedPrefixes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return policy
+}
+
+func TestNewClusterNamePolicy(t *testing.T) {
+	cases := []struct {
+		name            string
+		allowedPatterns []string
+		maxLength       int
+		expectedErr     bool
+	}{
+		{
+			name: "empty policy",
+		},
+		{
+			name:            "valid policy",
+			allowedPatterns: []string{"prod-[a-z0-9-]+", "dev-.*"},
+			maxLength:       20,
+		},
+		{
+			name:            "invalid pattern",
+			allowedPatterns: []string{"prod-[a-z"},
+			expectedErr:     true,
+		},
+		{
+			name:        "negative max length",
+			maxLength:   -1,
+			expectedErr: true,
+		},
+		{
+			name:        "max length beyond the namespace names",
+			maxLength:   64,
+			expectedErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := NewClusterNamePolicy(c.allowedPatterns, c.maxLength, nil)
+			if c.expectedErr && err == nil {
+				t.Errorf("expected error, but got nil")
+			}
+			if !c.expectedErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestClusterNamePolicyValidate(t *testing.T) {
+	policy := newClusterNamePolicy(t, []string{"prod-[a-z0-9-]+", "dev-[a-z0-9-]+"}, 12, []string{"local-cluster", "prod-reserved"})
+	cases := []struct {
+		name            string
+		policy          *ClusterNamePolicy
+		clusterName     string
+		expectedErrMsgs []string
+	}{
+		{
+			name:        "nil policy",
+			clusterName: "local-cluster",
+		},
+		{
+			name:        "empty policy",
+			policy:      newClusterNamePolicy(t, nil, 0, nil),
+			clusterName: "local-cluster",
+		},
+		{
+			name:        "allowed name",
+			policy:      policy,
+			clusterName: "prod-east-1",
+		},
+		{
+			name:        "name matching a pattern partially",
+			policy:      policy,
+			clusterName: "my-prod-east",
+			expectedErrMsgs: []string{
+				"must match one of the patterns ^(?:prod-[a-z0-9-]+)$, ^(?:dev-[a-z0-9-]+)$",
+			},
+		},
+		{
+			name:            "too long name",
+			policy:          policy,
+			clusterName:     "prod-east-long",
+			expectedErrMsgs: []string{"must be no more than 12 characters"},
+		},
+		{
+			name:        "reserved prefix",
+			policy:      policy,
+			clusterName: "local-cluster",
+			expectedErrMsgs: []string{
+				"must be no more than 12 characters",
+				"must not start with the reserved prefix \"local-cluster\"",
+				"must match one of the patterns ^(?:prod-[a-z0-9-]+)$, ^(?:dev-[a-z0-9-]+)$",
+			},
+		},
+		{
+			name:            "reserved prefix matching a pattern",
+			policy:          policy,
+			clusterName:     "prod-reserved",
+			expectedErrMsgs: []string{"must be no more than 12 characters", "must not start with the reserved prefix \"prod-reserved\""},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			errMsgs := c.policy.Validate(c.clusterName)
+			if len(errMsgs) == 0 && len(c.expectedErrMsgs) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(errMsgs, c.expectedErrMsgs) {
+				t.Errorf("expected %v, but got %v", c.expectedErrMsgs, errMsgs)
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package v1
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -14,7 +13,7 @@ import (
 	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 	ocmfeature "open-cluster-management.io/api/feature"
 	"open-cluster-management.io/registration/pkg/features"
-	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/helpers/taints"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -31,22 +30,13 @@ func (r *ManagedClusterWebhook) Default(ctx context.Context, obj runtime.Object)
 		return apierrors.NewBadRequest(err.Error())
 	}
 
-	var oldManagedCluster *clusterv1.ManagedCluster
-	if len(req.OldObject.Raw) > 0 {
-		cluster := &clusterv1.ManagedCluster{}
-		if err := json.Unmarshal(req.OldObject.Raw, cluster); err != nil {
-			return apierrors.NewBadRequest(err.Error())
-		}
-		oldManagedCluster = cluster
-	}
-
 	managedCluster, ok := obj.(*clusterv1.ManagedCluster)
 	if !ok {
 		return apierrors.NewBadRequest("Request cluster obj format is not right")
 	}
 
 	//Generate taints
-	err = r.processTaints(managedCluster, oldManagedCluster)
+	err = r.processTaints(managedCluster, req)
 	if err != nil {
 		return err
 	}
@@ -59,49 +49,28 @@ func (r *ManagedClusterWebhook) Default(ctx context.Context, obj runtime.Object)
 	return nil
 }
 
-// processTaints set cluster taints
-func (r *ManagedClusterWebhook) processTaints(managedCluster, oldManagedCluster *clusterv1.ManagedCluster) error {
+// processTaints sets the TimeAdded of the cluster taints, the request is denied if the client modifies it. The old
+// cluster is only decoded if the cluster has taints, since most of the updates, e.g. the ones of the agents, do not
+// touch the taints.
+func (r *ManagedClusterWebhook) processTaints(managedCluster *clusterv1.ManagedCluster, req admission.Request) error {
 	if len(managedCluster.Spec.Taints) == 0 {
 		return nil
 	}
-	now := metav1.NewTime(nowFunc())
-	var invalidTaints []string
-	for index, taint := range managedCluster.Spec.Taints {
-		originalTaint := helpers.FindTaintByKey(oldManagedCluster, taint.Key)
-		switch {
-		case oldManagedCluster == nil:
-			// handle CREATE operation.
-			// The request will not be denied if it has taints with timeAdded specified,
-			// while the specified values will be ignored.
-			managedCluster.Spec.Taints[index].TimeAdded = now
-		case originalTaint == nil:
-			// handle UPDATE operation.
-			// new taint
-			// The request will be denied if it has any taint with timeAdded specified.
-			if !taint.TimeAdded.IsZero() {
-				invalidTaints = append(invalidTaints, taint.Key)
-				continue
-			}
-			managedCluster.Spec.Taints[index].TimeAdded = now
-		case originalTaint.Value == taint.Value && originalTaint.Effect == taint.Effect:
-			// handle UPDATE operation.
-			// no change
-			// The request will be denied if it has any taint with different timeAdded specified.
-			if !originalTaint.TimeAdded.Equal(&taint.TimeAdded) {
-				invalidTaints = append(invalidTaints, taint.Key)
-			}
-		default:
-			// handle UPDATE operation.
-			// taint's value/effect has changed
-			// The request will be denied if it has any taint with timeAdded specified.
-			if !taint.TimeAdded.IsZero() {
-				invalidTaints = append(invalidTaints, taint.Key)
-				continue
-			}
-			managedCluster.Spec.Taints[index].TimeAdded = now
+
+	var oldManagedCluster *clusterv1.ManagedCluster
+	if len(req.OldObject.Raw) > 0 {
+		oldManagedCluster = &clusterv1.ManagedCluster{}
+		if err := decoder.DecodeRaw(req.OldObject, oldManagedCluster); err != nil {
+			return apierrors.NewBadRequest(err.Error())
 		}
 	}
 
+	var originalTaints []clusterv1.Taint
+	if oldManagedCluster != nil {
+		originalTaints = oldManagedCluster.Spec.Taints
+	}
+	invalidTaints := taints.SetTimeAdded(managedCluster.Spec.Taints, originalTaints, oldManagedCluster == nil,
+		metav1.NewTime(nowFunc()))
 	if len(invalidTaints) == 0 {
 		return nil
 	}
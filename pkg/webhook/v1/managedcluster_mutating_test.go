@@ -519,3 +519,44 @@ func newTime(time time.Time, offset time.Duration) metav1.Time {
 	mt := metav1.NewTime(time.Add(offset))
 	return mt
 }
+
+func TestProcessTaintsPreserveTimeAdded(t *testing.T) {
+	// the old cluster is serialized in the request, which keeps the time in seconds
+	now := time.Now().Truncate(time.Second)
+	oldCluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "set-1",
+		},
+		Spec: clusterv1.ManagedClusterSpec{
+			Taints: []clusterv1.Taint{
+				{
+					Key:       "a",
+					Value:     "b",
+					Effect:    clusterv1.TaintEffectNoSelect,
+					TimeAdded: newTime(now, -10*time.Second),
+				},
+			},
+		},
+	}
+	cluster := oldCluster.DeepCopy()
+	cluster.Spec.Taints[0].TimeAdded = metav1.Time{}
+
+	oldClusterBytes, err := json.Marshal(oldCluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			OldObject: apiruntime.RawExtension{Raw: oldClusterBytes},
+		},
+	}
+
+	w := ManagedClusterWebhook{}
+	if err := w.processTaints(cluster, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cluster.Spec.Taints[0].TimeAdded.Equal(&oldCluster.Spec.Taints[0].TimeAdded) {
+		t.Errorf("expected timeAdded %v is preserved, but got %v",
+			oldCluster.Spec.Taints[0].TimeAdded, cluster.Spec.Taints[0].TimeAdded)
+	}
+}
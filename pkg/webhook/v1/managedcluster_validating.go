@@ -1,8 +1,11 @@
 package v1
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"net/url"
+	"sort"
 	"strings"
 
 	operatorhelpers "github.com/openshift/library-go/pkg/operator/v1helpers"
@@ -12,10 +15,14 @@ import (
 	apimachineryvalidation "k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	certutil "k8s.io/client-go/util/cert"
 	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 
 	v1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/hub/acceptancereview"
 
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -37,15 +44,27 @@ func (r *ManagedClusterWebhook) ValidateCreate(ctx context.Context, obj runtime.
 	}
 
 	//Validate if Spec.ManagedClusterClientConfigs is Valid HTTPS URL
-	err = r.validateManagedClusterObj(*managedCluster)
+	err = r.validateManagedClusterObj(*managedCluster, nil, nil)
 	if err != nil {
 		return err
 	}
 
+	// check whether the cluster name follows the naming convention
+	if errMsgs := r.NamePolicy.Validate(managedCluster.Name); len(errMsgs) > 0 {
+		return apierrors.NewBadRequest(fmt.Sprintf("metadata.name %q is not allowed: %s",
+			managedCluster.Name, strings.Join(errMsgs, ", ")))
+	}
+
+	// check whether the request user has been allowed to set the labels and annotations at registration
+	if err := r.allowSetClusterMetadata(req.UserInfo, managedCluster.Name, nil, managedCluster); err != nil {
+		return err
+	}
+
 	// the HubAcceptsClient field is changed, we need to:
 	// 1. check whether cluster namespace is terminating.
 	// 2. check the request user whether has been allowed to change the HubAcceptsClient field with
 	// SubjectAccessReview api.
+	// 3. check whether the cluster is approved by a reviewer if the acceptance review is required.
 	if managedCluster.Spec.HubAcceptsClient {
 		if err := r.validateAcceptByClusterNamespace(managedCluster.Name); err != nil {
 			return err
@@ -53,6 +72,24 @@ func (r *ManagedClusterWebhook) ValidateCreate(ctx context.Context, obj runtime.
 		if err := r.allowUpdateAcceptField(managedCluster.Name, req.UserInfo); err != nil {
 			return err
 		}
+		if err := r.allowAcceptWithReview(req.UserInfo, managedCluster); err != nil {
+			return err
+		}
+	}
+
+	// check whether the request user has been allowed to set addon feature labels
+	if err := r.allowSetAddOnFeatureLabels(req.UserInfo, managedCluster.Name, nil, managedCluster.Labels); err != nil {
+		return err
+	}
+
+	// check whether the request user has been allowed to review the acceptance of the cluster
+	if err := r.allowSetAcceptanceReview(req.UserInfo, managedCluster.Name, nil, managedCluster.Annotations); err != nil {
+		return err
+	}
+
+	// check whether the request user has been allowed to set the identity of the agent
+	if err := r.allowSetIdentityAnnotations(req.UserInfo, managedCluster.Name, nil, managedCluster.Annotations); err != nil {
+		return err
 	}
 
 	// check whether the request user has been allowed to set clusterset label
@@ -80,7 +117,8 @@ func (r *ManagedClusterWebhook) ValidateUpdate(ctx context.Context, oldObj, newO
 	}
 
 	//Validate if Spec.ManagedClusterClientConfigs is Valid HTTPS URL
-	err = r.validateManagedClusterObj(*managedCluster)
+	err = r.validateManagedClusterObj(*managedCluster, oldManagedCluster.Spec.Taints,
+		oldManagedCluster.Spec.ManagedClusterClientConfigs)
 	if err != nil {
 		return err
 	}
@@ -89,6 +127,7 @@ func (r *ManagedClusterWebhook) ValidateUpdate(ctx context.Context, oldObj, newO
 	// 1. check whether cluster namespace is terminating.
 	// 2. check the request user whether has been allowed to change the HubAcceptsClient field with
 	// SubjectAccessReview api.
+	// 3. check whether the cluster is approved by a reviewer if the acceptance review is required.
 	if managedCluster.Spec.HubAcceptsClient != oldManagedCluster.Spec.HubAcceptsClient {
 		if managedCluster.Spec.HubAcceptsClient {
 			if err := r.validateAcceptByClusterNamespace(managedCluster.Name); err != nil {
@@ -97,9 +136,35 @@ func (r *ManagedClusterWebhook) ValidateUpdate(ctx context.Context, oldObj, newO
 			if err := r.allowUpdateAcceptField(managedCluster.Name, req.UserInfo); err != nil {
 				return err
 			}
+			if err := r.allowAcceptWithReview(req.UserInfo, managedCluster); err != nil {
+				return err
+			}
 		}
 	}
 
+	// check whether the request user has been allowed to change the labels and annotations
+	if err := r.allowSetClusterMetadata(req.UserInfo, managedCluster.Name, oldManagedCluster, managedCluster); err != nil {
+		return err
+	}
+
+	// check whether the request user has been allowed to change addon feature labels
+	if err := r.allowSetAddOnFeatureLabels(
+		req.UserInfo, managedCluster.Name, oldManagedCluster.Labels, managedCluster.Labels); err != nil {
+		return err
+	}
+
+	// check whether the request user has been allowed to review the acceptance of the cluster
+	if err := r.allowSetAcceptanceReview(
+		req.UserInfo, managedCluster.Name, oldManagedCluster.Annotations, managedCluster.Annotations); err != nil {
+		return err
+	}
+
+	// check whether the request user has been allowed to change the identity of the agent
+	if err := r.allowSetIdentityAnnotations(
+		req.UserInfo, managedCluster.Name, oldManagedCluster.Annotations, managedCluster.Annotations); err != nil {
+		return err
+	}
+
 	// check whether the request user has been allowed to set clusterset label
 	var originalClusterSetName, currentClusterSetName string
 	if len(oldManagedCluster.Labels) > 0 {
@@ -117,54 +182,112 @@ func (r *ManagedClusterWebhook) ValidateDelete(_ context.Context, obj runtime.Ob
 	return nil
 }
 
-// validateManagedClusterObj validates the fileds of ManagedCluster object
-func (r *ManagedClusterWebhook) validateManagedClusterObj(cluster v1.ManagedCluster) error {
+// validateManagedClusterObj validates the fileds of ManagedCluster object, the taints in the old taints and the
+// client configs in the old client configs are not validated again
+func (r *ManagedClusterWebhook) validateManagedClusterObj(cluster v1.ManagedCluster, oldTaints []v1.Taint,
+	oldClientConfigs []v1.ClientConfig) error {
 	errs := []error{}
 	// The cluster name must be the same format of namespace name.
 	if errMsgs := apimachineryvalidation.ValidateNamespaceName(cluster.Name, false); len(errMsgs) > 0 {
 		errs = append(errs, fmt.Errorf("metadata.name format is not correct: %s", strings.Join(errMsgs, ",")))
 	}
-	// there are no spoke client configs, finish the validation process
-	if len(cluster.Spec.ManagedClusterClientConfigs) == 0 {
-		return nil
-	}
+	// validate the taints
+	errs = append(errs, validateTaints(cluster.Spec.Taints, oldTaints)...)
 
-	// validate the url in spoke client configs
-	for _, clientConfig := range cluster.Spec.ManagedClusterClientConfigs {
-		if !helpers.IsValidHTTPSURL(clientConfig.URL) {
-			errs = append(errs, fmt.Errorf("url %q is invalid in client configs", clientConfig.URL))
-		}
-	}
+	// validate the url and the ca bundle in spoke client configs
+	errs = append(errs, validateClientConfigs(cluster.Spec.ManagedClusterClientConfigs, oldClientConfigs)...)
 	if len(errs) != 0 {
 		return apierrors.NewBadRequest(operatorhelpers.NewMultiLineAggregate(errs).Error())
 	}
 	return nil
 }
 
-// allowUpdateHubAcceptsClientField using SubjectAccessReview API to check whether a request user has been authorized to update
-// HubAcceptsClient field
-func (r *ManagedClusterWebhook) allowUpdateAcceptField(clusterName string, userInfo authenticationv1.UserInfo) error {
-	extra := make(map[string]authorizationv1.ExtraValue)
-	for k, v := range userInfo.Extra {
-		extra[k] = authorizationv1.ExtraValue(v)
+// validateTaints validates the key, value and effect of the taints, and rejects the taints with duplicated keys. The
+// taints which are not new or changed from the old ones are skipped, so the clusters with the taints added before the
+// validation are still able to be updated.
+func validateTaints(taints, oldTaints []v1.Taint) []error {
+	errs := []error{}
+	keys := sets.NewString()
+	for _, taint := range taints {
+		if helpers.FindTaint(oldTaints, taint) != nil {
+			keys.Insert(taint.Key)
+			continue
+		}
+		if errMsgs := validation.IsQualifiedName(taint.Key); len(errMsgs) > 0 {
+			errs = append(errs, fmt.Errorf("taint key %q is invalid: %s", taint.Key, strings.Join(errMsgs, ",")))
+		}
+		if errMsgs := validation.IsValidLabelValue(taint.Value); len(errMsgs) > 0 {
+			errs = append(errs, fmt.Errorf("value %q of taint %q is invalid: %s", taint.Value, taint.Key, strings.Join(errMsgs, ",")))
+		}
+		switch taint.Effect {
+		case v1.TaintEffectNoSelect, v1.TaintEffectPreferNoSelect, v1.TaintEffectNoSelectIfNew:
+		default:
+			errs = append(errs, fmt.Errorf("effect %q of taint %q is invalid, it must be one of %s, %s or %s",
+				taint.Effect, taint.Key, v1.TaintEffectNoSelect, v1.TaintEffectPreferNoSelect, v1.TaintEffectNoSelectIfNew))
+		}
+		if keys.Has(taint.Key) {
+			errs = append(errs, fmt.Errorf("taint key %q is duplicated", taint.Key))
+		}
+		keys.Insert(taint.Key)
 	}
+	return errs
+}
 
-	sar := &authorizationv1.SubjectAccessReview{
-		Spec: authorizationv1.SubjectAccessReviewSpec{
-			User:   userInfo.Username,
-			UID:    userInfo.UID,
-			Groups: userInfo.Groups,
-			Extra:  extra,
-			ResourceAttributes: &authorizationv1.ResourceAttributes{
-				Group:       "register.open-cluster-management.io",
-				Resource:    "managedclusters",
-				Verb:        "update",
-				Subresource: "accept",
-				Name:        clusterName,
-			},
-		},
+// validateClientConfigs validates that the url of each client config is an absolute https url and its ca bundle,
+// if it is set, is a PEM encoded certificate bundle, since the malformed client configs break the consumers of the
+// cluster endpoints silently. The client configs which are not new or changed from the old ones are skipped, so the
+// clusters created before the validation are still able to be updated.
+func validateClientConfigs(clientConfigs, oldClientConfigs []v1.ClientConfig) []error {
+	errs := []error{}
+	for i, clientConfig := range clientConfigs {
+		if containsClientConfig(oldClientConfigs, clientConfig) {
+			continue
+		}
+		field := fmt.Sprintf("spec.managedClusterClientConfigs[%d]", i)
+		if err := validateHTTPSURL(clientConfig.URL); err != nil {
+			errs = append(errs, fmt.Errorf("%s.url %q is invalid: %v", field, clientConfig.URL, err))
+		}
+		if len(clientConfig.CABundle) == 0 {
+			continue
+		}
+		if _, err := certutil.ParseCertsPEM(clientConfig.CABundle); err != nil {
+			errs = append(errs, fmt.Errorf("%s.caBundle is invalid, it must be PEM encoded certificates: %v", field, err))
+		}
 	}
-	sar, err := r.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), sar, metav1.CreateOptions{})
+	return errs
+}
+
+func containsClientConfig(clientConfigs []v1.ClientConfig, clientConfig v1.ClientConfig) bool {
+	for _, c := range clientConfigs {
+		if c.URL == clientConfig.URL && bytes.Equal(c.CABundle, clientConfig.CABundle) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateHTTPSURL returns an error with the reason if the url is not an absolute https url
+func validateHTTPSURL(rawURL string) error {
+	if len(rawURL) == 0 {
+		return fmt.Errorf("it must not be empty")
+	}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if parsedURL.Scheme != "https" {
+		return fmt.Errorf("it must be an https url, e.g. https://api.example.com:6443")
+	}
+	if len(parsedURL.Host) == 0 {
+		return fmt.Errorf("it must be an absolute url with a host, e.g. https://api.example.com:6443")
+	}
+	return nil
+}
+
+// allowUpdateHubAcceptsClientField using SubjectAccessReview API to check whether a request user has been authorized to update
+// HubAcceptsClient field.
+func (r *ManagedClusterWebhook) allowUpdateAcceptField(clusterName string, userInfo authenticationv1.UserInfo) error {
+	allowed, err := r.reviewAcceptField(clusterName, userInfo)
 	if err != nil {
 		return apierrors.NewForbidden(
 			v1.Resource("managedclusters/accept"),
@@ -173,7 +296,7 @@ func (r *ManagedClusterWebhook) allowUpdateAcceptField(clusterName string, userI
 		)
 	}
 
-	if !sar.Status.Allowed {
+	if !allowed {
 		return apierrors.NewForbidden(
 			v1.Resource("managedclusters/accept"),
 			clusterName,
@@ -184,6 +307,133 @@ func (r *ManagedClusterWebhook) allowUpdateAcceptField(clusterName string, userI
 	return nil
 }
 
+// reviewAcceptField returns true if the request user is allowed to update the HubAcceptsClient field, the review
+// results are cached for a short period.
+func (r *ManagedClusterWebhook) reviewAcceptField(clusterName string, userInfo authenticationv1.UserInfo) (bool, error) {
+	return r.acceptReviewCache.allowed(reviewKey(userInfo, clusterName), func() (bool, error) {
+		extra := make(map[string]authorizationv1.ExtraValue)
+		for k, v := range userInfo.Extra {
+			extra[k] = authorizationv1.ExtraValue(v)
+		}
+
+		sar := &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   userInfo.Username,
+				UID:    userInfo.UID,
+				Groups: userInfo.Groups,
+				Extra:  extra,
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Group:       "register.open-cluster-management.io",
+					Resource:    "managedclusters",
+					Verb:        "update",
+					Subresource: "accept",
+					Name:        clusterName,
+				},
+			},
+		}
+		sar, err := r.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), sar, metav1.CreateOptions{})
+		if err != nil {
+			return false, err
+		}
+		return sar.Status.Allowed, nil
+	})
+}
+
+// allowSetClusterMetadata checks whether the labels and annotations of a ManagedCluster added, changed or removed by a
+// request user are allowed. The users who are not allowed to accept the cluster, e.g. the agents, are only allowed to
+// change the labels and annotations with the MetadataAllowedPrefixes, besides the clusterset label, which is validated
+// on its own, and the annotations the agents report. It is not checked if no prefix is allowed.
+func (r *ManagedClusterWebhook) allowSetClusterMetadata(
+	userInfo authenticationv1.UserInfo, clusterName string, original, cluster *v1.ManagedCluster) error {
+	if len(r.MetadataAllowedPrefixes) == 0 {
+		return nil
+	}
+
+	var originalLabels, originalAnnotations map[string]string
+	if original != nil {
+		originalLabels, originalAnnotations = original.Labels, original.Annotations
+	}
+	disallowed := []string{}
+	for _, key := range changedKeys(originalLabels, cluster.Labels) {
+		if key != clusterv1beta2.ClusterSetLabel && !hasAnyPrefix(key, r.MetadataAllowedPrefixes) {
+			disallowed = append(disallowed, fmt.Sprintf("label %q", key))
+		}
+	}
+	for _, key := range changedKeys(originalAnnotations, cluster.Annotations) {
+		if !sets.New(helpers.ManagedClusterAgentAnnotations...).Has(key) && !hasAnyPrefix(key, r.MetadataAllowedPrefixes) {
+			disallowed = append(disallowed, fmt.Sprintf("annotation %q", key))
+		}
+	}
+	if len(disallowed) == 0 {
+		return nil
+	}
+
+	allowed, err := r.reviewAcceptField(clusterName, userInfo)
+	if err != nil {
+		return apierrors.NewForbidden(v1.Resource("managedclusters"), clusterName, err)
+	}
+	if !allowed {
+		sort.Strings(disallowed)
+		return apierrors.NewForbidden(v1.Resource("managedclusters"), clusterName,
+			fmt.Errorf("user %q cannot set the %s, only the prefixes %v are allowed",
+				userInfo.Username, strings.Join(disallowed, ", "), r.MetadataAllowedPrefixes))
+	}
+	return nil
+}
+
+// changedKeys returns the keys added, changed or removed in the new map compared with the original one
+func changedKeys(original, new map[string]string) []string {
+	keys := []string{}
+	for key, value := range new {
+		if originalValue, ok := original[key]; !ok || originalValue != value {
+			keys = append(keys, key)
+		}
+	}
+	for key := range original {
+		if _, ok := new[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// allowSetIdentityAnnotations checks whether the identity annotations of a ManagedCluster are added, changed or
+// removed by a user who is allowed to accept the cluster. The hub grants the roles of the cluster to the identity in
+// the annotations, so the agents, e.g. with their bootstrap identities, must not set it for themselves.
+func (r *ManagedClusterWebhook) allowSetIdentityAnnotations(
+	userInfo authenticationv1.UserInfo, clusterName string, originalAnnotations, newAnnotations map[string]string) error {
+	changed := []string{}
+	for _, key := range helpers.ManagedClusterIdentityAnnotations {
+		value, ok := newAnnotations[key]
+		if originalValue, originalOK := originalAnnotations[key]; ok != originalOK || value != originalValue {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	allowed, err := r.reviewAcceptField(clusterName, userInfo)
+	if err != nil {
+		return apierrors.NewForbidden(v1.Resource("managedclusters"), clusterName, err)
+	}
+	if !allowed {
+		return apierrors.NewForbidden(v1.Resource("managedclusters"), clusterName,
+			fmt.Errorf("user %q cannot set the identity annotations %s, only the users allowed to accept the cluster can",
+				userInfo.Username, strings.Join(changed, ", ")))
+	}
+	return nil
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // validateClusterNamespace checks the cluster namespace, if the namespace is terminating, reject the accept request.
 func (r *ManagedClusterWebhook) validateAcceptByClusterNamespace(clusterName string) error {
 	clusterNamespace, err := r.kubeClient.CoreV1().Namespaces().Get(context.TODO(), clusterName, metav1.GetOptions{})
@@ -272,3 +522,169 @@ func (r *ManagedClusterWebhook) allowUpdateClusterSet(userInfo authenticationv1.
 
 	return nil
 }
+
+// allowSetAddOnFeatureLabels checks whether a request user has been authorized to add/change/remove the addon
+// feature labels of a ManagedCluster. A user is authorized to set the feature label of an addon only if the user
+// is allowed to update the status of the ManagedClusterAddOn in the cluster namespace.
+func (r *ManagedClusterWebhook) allowSetAddOnFeatureLabels(
+	userInfo authenticationv1.UserInfo, clusterName string, originalLabels, newLabels map[string]string) error {
+	addOnNames := sets.NewString()
+	for key, value := range newLabels {
+		if !strings.HasPrefix(key, helpers.AddOnFeatureLabelPrefix) {
+			continue
+		}
+		if originalValue, ok := originalLabels[key]; !ok || originalValue != value {
+			addOnNames.Insert(strings.TrimPrefix(key, helpers.AddOnFeatureLabelPrefix))
+		}
+	}
+	for key := range originalLabels {
+		if !strings.HasPrefix(key, helpers.AddOnFeatureLabelPrefix) {
+			continue
+		}
+		if _, ok := newLabels[key]; !ok {
+			addOnNames.Insert(strings.TrimPrefix(key, helpers.AddOnFeatureLabelPrefix))
+		}
+	}
+
+	for _, addOnName := range addOnNames.List() {
+		if err := r.allowUpdateAddOnStatus(userInfo, clusterName, addOnName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allowUpdateAddOnStatus checks whether a request user has been authorized to update the status of a
+// ManagedClusterAddOn
+func (r *ManagedClusterWebhook) allowUpdateAddOnStatus(userInfo authenticationv1.UserInfo, clusterName, addOnName string) error {
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for k, v := range userInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   userInfo.Username,
+			UID:    userInfo.UID,
+			Groups: userInfo.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:       "addon.open-cluster-management.io",
+				Resource:    "managedclusteraddons",
+				Subresource: "status",
+				Namespace:   clusterName,
+				Name:        addOnName,
+				Verb:        "update",
+			},
+		},
+	}
+	sar, err := r.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), sar, metav1.CreateOptions{})
+	if err != nil {
+		return apierrors.NewForbidden(
+			v1.Resource("managedclusters"),
+			clusterName,
+			err,
+		)
+	}
+
+	if !sar.Status.Allowed {
+		return apierrors.NewForbidden(
+			v1.Resource("managedclusters"),
+			clusterName,
+			fmt.Errorf("user %q cannot set the feature label of addon %q", userInfo.Username, addOnName),
+		)
+	}
+
+	return nil
+}
+
+// allowSetAcceptanceReview checks whether a request user has been authorized to set the acceptance review
+// annotation of a ManagedCluster, it requires the approve verb on the managedclusters/accept subresource.
+func (r *ManagedClusterWebhook) allowSetAcceptanceReview(
+	userInfo authenticationv1.UserInfo, clusterName string, originalAnnotations, newAnnotations map[string]string) error {
+	review, ok := newAnnotations[acceptancereview.AcceptanceReviewAnnotation]
+	if originalReview, originalOK := originalAnnotations[acceptancereview.AcceptanceReviewAnnotation]; ok == originalOK && review == originalReview {
+		return nil
+	}
+	if ok && review != acceptancereview.AcceptanceReviewApproved && review != acceptancereview.AcceptanceReviewDenied {
+		return apierrors.NewBadRequest(fmt.Sprintf("annotation %s must be either %s or %s",
+			acceptancereview.AcceptanceReviewAnnotation, acceptancereview.AcceptanceReviewApproved, acceptancereview.AcceptanceReviewDenied))
+	}
+
+	allowed, err := r.reviewAcceptance(clusterName, userInfo)
+	if err != nil {
+		return apierrors.NewForbidden(
+			v1.Resource("managedclusters/accept"),
+			clusterName,
+			err,
+		)
+	}
+
+	if !allowed {
+		return apierrors.NewForbidden(
+			v1.Resource("managedclusters/accept"),
+			clusterName,
+			fmt.Errorf("user %q cannot review the acceptance of the cluster", userInfo.Username),
+		)
+	}
+
+	return nil
+}
+
+// allowAcceptWithReview checks whether a ManagedCluster is approved by a reviewer before it is accepted if the
+// acceptance review is required. The reviewers, who have the approve verb on the managedclusters/accept
+// subresource, are allowed to accept the clusters directly.
+func (r *ManagedClusterWebhook) allowAcceptWithReview(userInfo authenticationv1.UserInfo, cluster *v1.ManagedCluster) error {
+	if !r.RequireAcceptanceReview || cluster.Annotations[acceptancereview.AcceptanceReviewAnnotation] == acceptancereview.AcceptanceReviewApproved {
+		return nil
+	}
+
+	allowed, err := r.reviewAcceptance(cluster.Name, userInfo)
+	if err != nil {
+		return apierrors.NewForbidden(
+			v1.Resource("managedclusters/accept"),
+			cluster.Name,
+			err,
+		)
+	}
+
+	if !allowed {
+		return apierrors.NewForbidden(
+			v1.Resource("managedclusters/accept"),
+			cluster.Name,
+			fmt.Errorf("the cluster must be approved with the annotation %s=%s before it is accepted",
+				acceptancereview.AcceptanceReviewAnnotation, acceptancereview.AcceptanceReviewApproved),
+		)
+	}
+
+	return nil
+}
+
+// reviewAcceptance returns true if the request user is allowed to review the acceptance of the cluster
+func (r *ManagedClusterWebhook) reviewAcceptance(clusterName string, userInfo authenticationv1.UserInfo) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for k, v := range userInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   userInfo.Username,
+			UID:    userInfo.UID,
+			Groups: userInfo.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:       "register.open-cluster-management.io",
+				Resource:    "managedclusters",
+				Verb:        "approve",
+				Subresource: "accept",
+				Name:        clusterName,
+			},
+		},
+	}
+	sar, err := r.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), sar, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return sar.Status.Allowed, nil
+}
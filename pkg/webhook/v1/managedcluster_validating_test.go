@@ -2,7 +2,9 @@ package v1
 
 import (
 	"context"
+	"reflect"
 	"testing"
+	"time"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -15,6 +17,9 @@ import (
 	clienttesting "k8s.io/client-go/testing"
 	v1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/api/cluster/v1beta1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+	"open-cluster-management.io/registration/pkg/hub/acceptancereview"
 
 	corev1 "k8s.io/api/core/v1"
 )
@@ -28,6 +33,8 @@ func TestValidateCreate(t *testing.T) {
 		allowUpdateAcceptField bool
 		allowClusterset        bool
 		allowUpdateClusterSets map[string]bool
+		allowedPrefixes        []string
+		namePolicy             *ClusterNamePolicy
 	}{
 		{
 			name:          "Empty spec cluster",
@@ -168,6 +175,153 @@ func TestValidateCreate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "validate create cluster with valid taints",
+			expectedError: false,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+				},
+				Spec: v1.ManagedClusterSpec{
+					Taints: []v1.Taint{
+						{Key: "example.com/gpu", Value: "true", Effect: v1.TaintEffectNoSelect},
+						{Key: "maintenance", Effect: v1.TaintEffectPreferNoSelect},
+						{Key: "upgrade", Effect: v1.TaintEffectNoSelectIfNew},
+					},
+				},
+			},
+		},
+		{
+			name:          "validate create cluster with invalid taint key",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+				},
+				Spec: v1.ManagedClusterSpec{
+					Taints: []v1.Taint{
+						{Key: "invalid key!", Effect: v1.TaintEffectNoSelect},
+					},
+				},
+			},
+		},
+		{
+			name:          "validate create cluster with invalid taint value",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+				},
+				Spec: v1.ManagedClusterSpec{
+					Taints: []v1.Taint{
+						{Key: "gpu", Value: "-invalid-", Effect: v1.TaintEffectNoSelect},
+					},
+				},
+			},
+		},
+		{
+			name:          "validate create cluster with invalid taint effect",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+				},
+				Spec: v1.ManagedClusterSpec{
+					Taints: []v1.Taint{
+						{Key: "gpu", Effect: "NoSchedule"},
+					},
+				},
+			},
+		},
+		{
+			name:          "validate create cluster with duplicated taint keys",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+				},
+				Spec: v1.ManagedClusterSpec{
+					Taints: []v1.Taint{
+						{Key: "gpu", Effect: v1.TaintEffectNoSelect},
+						{Key: "gpu", Effect: v1.TaintEffectPreferNoSelect},
+					},
+				},
+			},
+		},
+		{
+			name:          "validate setting addon feature label without permission",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+					Labels: map[string]string{
+						"feature.open-cluster-management.io/addon-foo": "available",
+					},
+				},
+			},
+		},
+		{
+			name:            "validate setting metadata with allowed prefixes",
+			expectedError:   false,
+			allowedPrefixes: []string{"example.com/"},
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "set-1",
+					Labels:      map[string]string{"example.com/environment": "production"},
+					Annotations: map[string]string{"example.com/owner": "team-a", "agent.open-cluster-management.io/version": "v0.11.0"},
+				},
+			},
+		},
+		{
+			name:            "validate setting metadata beyond allowed prefixes without permission",
+			expectedError:   true,
+			allowedPrefixes: []string{"example.com/"},
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "set-1",
+					Labels: map[string]string{"environment": "production"},
+				},
+			},
+		},
+		{
+			name:                   "validate setting metadata beyond allowed prefixes with permission",
+			expectedError:          false,
+			allowUpdateAcceptField: true,
+			allowedPrefixes:        []string{"example.com/"},
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "set-1",
+					Annotations: map[string]string{"owner": "team-a"},
+				},
+			},
+		},
+		{
+			name:          "validate setting identity annotations without permission",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+					Annotations: map[string]string{
+						helpers.ManagedClusterOIDCIssuerAnnotation:  "https://issuer.example.com",
+						helpers.ManagedClusterOIDCSubjectAnnotation: "admin",
+					},
+				},
+			},
+		},
+		{
+			name:                   "validate setting identity annotations with permission",
+			expectedError:          false,
+			allowUpdateAcceptField: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+					Annotations: map[string]string{
+						helpers.ManagedClusterOIDCIssuerAnnotation:  "https://issuer.example.com",
+						helpers.ManagedClusterOIDCSubjectAnnotation: "agent",
+					},
+				},
+			},
+		},
 		{
 			name:          "validate cluster name",
 			expectedError: true,
@@ -182,6 +336,26 @@ func TestValidateCreate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "validate cluster name allowed by the name policy",
+			expectedError: false,
+			namePolicy:    newClusterNamePolicy(t, []string{"prod-[a-z0-9-]+"}, 0, []string{"local-cluster"}),
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "prod-east-1",
+				},
+			},
+		},
+		{
+			name:          "validate cluster name not allowed by the name policy",
+			expectedError: true,
+			namePolicy:    newClusterNamePolicy(t, []string{"prod-[a-z0-9-]+"}, 0, []string{"local-cluster"}),
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "dev-east-1",
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -208,7 +382,9 @@ func TestValidateCreate(t *testing.T) {
 				},
 			)
 			w := ManagedClusterWebhook{
-				kubeClient: kubeClient,
+				MetadataAllowedPrefixes: c.allowedPrefixes,
+				NamePolicy:              c.namePolicy,
+				kubeClient:              kubeClient,
 			}
 			req := admission.Request{
 				AdmissionRequest: admissionv1.AdmissionRequest{
@@ -238,6 +414,159 @@ func TestValidateCreate(t *testing.T) {
 	}
 }
 
+func TestValidateClientConfigs(t *testing.T) {
+	caBundle := testinghelpers.NewTestCert("test-ca", 1*time.Hour).Cert
+	cases := []struct {
+		name             string
+		clientConfigs    []v1.ClientConfig
+		oldClientConfigs []v1.ClientConfig
+		expectedErrors   []string
+	}{
+		{
+			name: "valid client configs",
+			clientConfigs: []v1.ClientConfig{
+				{URL: "https://127.0.0.1:8001"},
+				{URL: "https://api.example.com:6443", CABundle: caBundle},
+			},
+		},
+		{
+			name:          "empty url",
+			clientConfigs: []v1.ClientConfig{{URL: ""}},
+			expectedErrors: []string{
+				`spec.managedClusterClientConfigs[0].url "" is invalid: it must not be empty`,
+			},
+		},
+		{
+			name:          "http url",
+			clientConfigs: []v1.ClientConfig{{URL: "https://127.0.0.1:8001"}, {URL: "http://127.0.0.1:8001"}},
+			expectedErrors: []string{
+				`spec.managedClusterClientConfigs[1].url "http://127.0.0.1:8001" is invalid: it must be an https url, e.g. https://api.example.com:6443`,
+			},
+		},
+		{
+			name:          "url without host",
+			clientConfigs: []v1.ClientConfig{{URL: "https:///api"}},
+			expectedErrors: []string{
+				`spec.managedClusterClientConfigs[0].url "https:///api" is invalid: it must be an absolute url with a host, e.g. https://api.example.com:6443`,
+			},
+		},
+		{
+			name:          "malformed url",
+			clientConfigs: []v1.ClientConfig{{URL: "https://api.example.com:port"}},
+			expectedErrors: []string{
+				`spec.managedClusterClientConfigs[0].url "https://api.example.com:port" is invalid: parse "https://api.example.com:port": invalid port ":port" after host`,
+			},
+		},
+		{
+			name:          "invalid ca bundle",
+			clientConfigs: []v1.ClientConfig{{URL: "https://127.0.0.1:8001", CABundle: []byte("invalid")}},
+			expectedErrors: []string{
+				"spec.managedClusterClientConfigs[0].caBundle is invalid, it must be PEM encoded certificates: " +
+					"data does not contain any valid RSA or ECDSA certificates",
+			},
+		},
+		{
+			name:             "unchanged invalid client config",
+			clientConfigs:    []v1.ClientConfig{{URL: "http://127.0.0.1:8001"}, {URL: "https://api.example.com:6443"}},
+			oldClientConfigs: []v1.ClientConfig{{URL: "http://127.0.0.1:8001"}},
+		},
+		{
+			name:             "changed invalid client config",
+			clientConfigs:    []v1.ClientConfig{{URL: "http://127.0.0.1:8001", CABundle: []byte("invalid")}},
+			oldClientConfigs: []v1.ClientConfig{{URL: "http://127.0.0.1:8001"}},
+			expectedErrors: []string{
+				`spec.managedClusterClientConfigs[0].url "http://127.0.0.1:8001" is invalid: it must be an https url, e.g. https://api.example.com:6443`,
+				"spec.managedClusterClientConfigs[0].caBundle is invalid, it must be PEM encoded certificates: " +
+					"data does not contain any valid RSA or ECDSA certificates",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			errs := validateClientConfigs(c.clientConfigs, c.oldClientConfigs)
+			actualErrors := []string{}
+			for _, err := range errs {
+				actualErrors = append(actualErrors, err.Error())
+			}
+			if len(actualErrors) == 0 && len(c.expectedErrors) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(actualErrors, c.expectedErrors) {
+				t.Errorf("expected %v, but got %v", c.expectedErrors, actualErrors)
+			}
+		})
+	}
+}
+
+func TestValidateTaints(t *testing.T) {
+	cases := []struct {
+		name           string
+		taints         []v1.Taint
+		oldTaints      []v1.Taint
+		expectedErrors []string
+	}{
+		{
+			name: "valid taints",
+			taints: []v1.Taint{
+				{Key: "example.com/gpu", Value: "true", Effect: v1.TaintEffectNoSelect},
+				{Key: "maintenance", Effect: v1.TaintEffectPreferNoSelect},
+			},
+		},
+		{
+			name:   "invalid taint value",
+			taints: []v1.Taint{{Key: "gpu", Value: "-invalid-", Effect: v1.TaintEffectNoSelect}},
+			expectedErrors: []string{
+				`value "-invalid-" of taint "gpu" is invalid: a valid label must be an empty string or consist of ` +
+					`alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character ` +
+					`(e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`,
+			},
+		},
+		{
+			name: "unchanged invalid taint",
+			taints: []v1.Taint{
+				{Key: "gpu", Value: "-invalid-", Effect: v1.TaintEffectNoSelect},
+				{Key: "maintenance", Effect: v1.TaintEffectPreferNoSelect},
+			},
+			oldTaints: []v1.Taint{{Key: "gpu", Value: "-invalid-", Effect: v1.TaintEffectNoSelect}},
+		},
+		{
+			name:      "changed invalid taint",
+			taints:    []v1.Taint{{Key: "gpu", Value: "-invalid-", Effect: "Unknown"}},
+			oldTaints: []v1.Taint{{Key: "gpu", Value: "-invalid-", Effect: v1.TaintEffectNoSelect}},
+			expectedErrors: []string{
+				`value "-invalid-" of taint "gpu" is invalid: a valid label must be an empty string or consist of ` +
+					`alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character ` +
+					`(e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`,
+				`effect "Unknown" of taint "gpu" is invalid, it must be one of NoSelect, PreferNoSelect or NoSelectIfNew`,
+			},
+		},
+		{
+			name: "taint with the key of an unchanged taint",
+			taints: []v1.Taint{
+				{Key: "gpu", Effect: v1.TaintEffectNoSelect},
+				{Key: "gpu", Effect: v1.TaintEffectPreferNoSelect},
+			},
+			oldTaints:      []v1.Taint{{Key: "gpu", Effect: v1.TaintEffectNoSelect}},
+			expectedErrors: []string{`taint key "gpu" is duplicated`},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			errs := validateTaints(c.taints, c.oldTaints)
+			actualErrors := []string{}
+			for _, err := range errs {
+				actualErrors = append(actualErrors, err.Error())
+			}
+			if len(actualErrors) == 0 && len(c.expectedErrors) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(actualErrors, c.expectedErrors) {
+				t.Errorf("expected %v, but got %v", c.expectedErrors, actualErrors)
+			}
+		})
+	}
+}
+
 func TestValidateUpdate(t *testing.T) {
 	cases := []struct {
 		name                   string
@@ -248,7 +577,81 @@ func TestValidateUpdate(t *testing.T) {
 		allowUpdateAcceptField bool
 		allowClusterset        bool
 		allowUpdateClusterSets map[string]bool
+		allowUpdateAddOns      map[string]bool
+		allowReview            bool
+		allowedPrefixes        []string
+		// requireAcceptanceReview requires the clusters to be approved before they are accepted
+		requireAcceptanceReview bool
 	}{
+		{
+			name:                    "validate accepting a ManagedCluster which is not reviewed",
+			expectedError:           true,
+			allowUpdateAcceptField:  true,
+			requireAcceptanceReview: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+				},
+				Spec: v1.ManagedClusterSpec{
+					HubAcceptsClient: true,
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+				},
+				Spec: v1.ManagedClusterSpec{
+					HubAcceptsClient: false,
+				},
+			},
+		},
+		{
+			name:                    "validate accepting a ManagedCluster which is approved",
+			expectedError:           false,
+			allowUpdateAcceptField:  true,
+			requireAcceptanceReview: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "set-1",
+					Annotations: map[string]string{acceptancereview.AcceptanceReviewAnnotation: acceptancereview.AcceptanceReviewApproved},
+				},
+				Spec: v1.ManagedClusterSpec{
+					HubAcceptsClient: true,
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "set-1",
+					Annotations: map[string]string{acceptancereview.AcceptanceReviewAnnotation: acceptancereview.AcceptanceReviewApproved},
+				},
+				Spec: v1.ManagedClusterSpec{
+					HubAcceptsClient: false,
+				},
+			},
+		},
+		{
+			name:                    "validate accepting a ManagedCluster by a reviewer",
+			expectedError:           false,
+			allowUpdateAcceptField:  true,
+			allowReview:             true,
+			requireAcceptanceReview: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+				},
+				Spec: v1.ManagedClusterSpec{
+					HubAcceptsClient: true,
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set-1",
+				},
+				Spec: v1.ManagedClusterSpec{
+					HubAcceptsClient: false,
+				},
+			},
+		},
 		{
 			name:                   "validate update an accepted ManagedCluster without permission",
 			expectedError:          true,
@@ -550,6 +953,300 @@ func TestValidateUpdate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "validate adding addon feature label without permission",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+					Labels: map[string]string{
+						"feature.open-cluster-management.io/addon-foo": "available",
+					},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+				},
+			},
+		},
+		{
+			name:          "validate adding addon feature label with permission",
+			expectedError: false,
+			allowUpdateAddOns: map[string]bool{
+				"foo": true,
+			},
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+					Labels: map[string]string{
+						"feature.open-cluster-management.io/addon-foo": "available",
+					},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+				},
+			},
+		},
+		{
+			name:          "validate changing addon feature label without permission",
+			expectedError: true,
+			allowUpdateAddOns: map[string]bool{
+				"bar": true,
+			},
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+					Labels: map[string]string{
+						"feature.open-cluster-management.io/addon-foo": "unhealthy",
+					},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+					Labels: map[string]string{
+						"feature.open-cluster-management.io/addon-foo": "available",
+					},
+				},
+			},
+		},
+		{
+			name:          "validate removing addon feature label without permission",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+					Labels: map[string]string{
+						"feature.open-cluster-management.io/addon-foo": "available",
+					},
+				},
+			},
+		},
+		{
+			name:          "validate approving a ManagedCluster without permission",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "set",
+					Annotations: map[string]string{"cluster.open-cluster-management.io/acceptance-review": "approved"},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+				},
+			},
+		},
+		{
+			name:          "validate approving a ManagedCluster with permission",
+			expectedError: false,
+			allowReview:   true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "set",
+					Annotations: map[string]string{"cluster.open-cluster-management.io/acceptance-review": "approved"},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+				},
+			},
+		},
+		{
+			name:          "validate an invalid acceptance review",
+			expectedError: true,
+			allowReview:   true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "set",
+					Annotations: map[string]string{"cluster.open-cluster-management.io/acceptance-review": "yes"},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+				},
+			},
+		},
+		{
+			name:          "validate keeping acceptance review without permission",
+			expectedError: false,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "set",
+					Labels:      map[string]string{"env": "prod"},
+					Annotations: map[string]string{"cluster.open-cluster-management.io/acceptance-review": "denied"},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "set",
+					Annotations: map[string]string{"cluster.open-cluster-management.io/acceptance-review": "denied"},
+				},
+			},
+		},
+		{
+			name:          "validate keeping addon feature label without permission",
+			expectedError: false,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+					Labels: map[string]string{
+						"feature.open-cluster-management.io/addon-foo": "available",
+						"env": "prod",
+					},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+					Labels: map[string]string{
+						"feature.open-cluster-management.io/addon-foo": "available",
+						"env": "dev",
+					},
+				},
+			},
+		},
+		{
+			name:          "validate changing identity annotation without permission",
+			expectedError: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+					Annotations: map[string]string{
+						helpers.ManagedClusterOIDCIssuerAnnotation:  "https://issuer.example.com",
+						helpers.ManagedClusterOIDCSubjectAnnotation: "attacker",
+					},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+					Annotations: map[string]string{
+						helpers.ManagedClusterOIDCIssuerAnnotation:  "https://issuer.example.com",
+						helpers.ManagedClusterOIDCSubjectAnnotation: "agent",
+					},
+				},
+			},
+		},
+		{
+			name:                   "validate changing identity annotation with permission",
+			expectedError:          false,
+			allowUpdateAcceptField: true,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+					Annotations: map[string]string{
+						helpers.ManagedClusterOIDCSubjectAnnotation: "agent",
+					},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+				},
+			},
+		},
+		{
+			name:          "validate keeping identity annotation without permission",
+			expectedError: false,
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+					Annotations: map[string]string{
+						helpers.ManagedClusterOIDCSubjectAnnotation: "agent",
+					},
+					Labels: map[string]string{"env": "prod"},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+					Annotations: map[string]string{
+						helpers.ManagedClusterOIDCSubjectAnnotation: "agent",
+					},
+				},
+			},
+		},
+		{
+			name:            "validate changing metadata with allowed prefixes",
+			expectedError:   false,
+			allowedPrefixes: []string{"example.com/"},
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "set",
+					Labels: map[string]string{"example.com/environment": "production", "env": "prod"},
+					Annotations: map[string]string{
+						helpers.ManagedClusterAgentVersionAnnotation: "v0.11.0",
+					},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "set",
+					Labels: map[string]string{"env": "prod"},
+				},
+			},
+		},
+		{
+			name:            "validate changing metadata beyond allowed prefixes without permission",
+			expectedError:   true,
+			allowedPrefixes: []string{"example.com/"},
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "set",
+					Labels: map[string]string{"env": "test"},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "set",
+					Labels: map[string]string{"env": "prod"},
+				},
+			},
+		},
+		{
+			name:            "validate removing metadata beyond allowed prefixes without permission",
+			expectedError:   true,
+			allowedPrefixes: []string{"example.com/"},
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "set",
+					Annotations: map[string]string{"agent.open-cluster-management.io/desired-version": "v0.12.0"},
+				},
+			},
+		},
+		{
+			name:                   "validate changing metadata beyond allowed prefixes with permission",
+			expectedError:          false,
+			allowUpdateAcceptField: true,
+			allowedPrefixes:        []string{"example.com/"},
+			cluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "set",
+					Annotations: map[string]string{"owner": "team-a"},
+				},
+			},
+			oldCluster: &v1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "set",
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -564,8 +1261,13 @@ func TestValidateUpdate(t *testing.T) {
 					switch sar.Spec.ResourceAttributes.Resource {
 					case "managedclusters":
 						allowed = c.allowUpdateAcceptField
+						if sar.Spec.ResourceAttributes.Verb == "approve" {
+							allowed = c.allowReview
+						}
 					case "managedclustersets":
 						allowed = c.allowUpdateClusterSets[sar.Spec.ResourceAttributes.Name]
+					case "managedclusteraddons":
+						allowed = c.allowUpdateAddOns[sar.Spec.ResourceAttributes.Name]
 					}
 
 					return true, &authorizationv1.SubjectAccessReview{
@@ -576,7 +1278,9 @@ func TestValidateUpdate(t *testing.T) {
 				},
 			)
 			w := ManagedClusterWebhook{
-				kubeClient: kubeClient,
+				MetadataAllowedPrefixes: c.allowedPrefixes,
+				RequireAcceptanceReview: c.requireAcceptanceReview,
+				kubeClient:              kubeClient,
 			}
 			req := admission.Request{
 				AdmissionRequest: admissionv1.AdmissionRequest{
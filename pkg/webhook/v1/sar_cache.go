@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/utils/clock"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// defaultSARCacheSize is the max number of the review results kept in the cache
+	defaultSARCacheSize = 4096
+	// defaultSARCacheTTL is how long a review result is cached. It is kept short, so that the changes of the RBAC
+	// rules take effect soon.
+	defaultSARCacheTTL = 10 * time.Second
+)
+
+var sarCacheRequests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "managedcluster_webhook_sar_cache_requests_total",
+		Help: "Number of the SubjectAccessReview cache lookups of the ManagedCluster webhook, partitioned by review and result (hit or miss).",
+	},
+	[]string{"review", "result"},
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(sarCacheRequests)
+}
+
+// subjectAccessReviewCache caches the results of SubjectAccessReviews for a short period, and coalesces the
+// concurrent reviews with the same key into one request, so that the automation which updates ManagedClusters
+// frequently does not overload the authorization API.
+type subjectAccessReviewCache struct {
+	name  string
+	ttl   time.Duration
+	cache *utilcache.LRUExpireCache
+	group singleflight.Group
+}
+
+func newSubjectAccessReviewCache(name string, size int, ttl time.Duration, clock clock.PassiveClock) *subjectAccessReviewCache {
+	return &subjectAccessReviewCache{
+		name:  name,
+		ttl:   ttl,
+		cache: utilcache.NewLRUExpireCacheWithClock(size, clock),
+	}
+}
+
+// allowed returns the cached review result of the key. If the result is not cached or expired, the review func is
+// called to get the result, the concurrent calls with the same key share one review. An error of the review is not
+// cached. A nil cache always calls the review func.
+func (c *subjectAccessReviewCache) allowed(key string, review func() (bool, error)) (bool, error) {
+	if c == nil {
+		return review()
+	}
+
+	if allowed, ok := c.cache.Get(key); ok {
+		sarCacheRequests.WithLabelValues(c.name, "hit").Inc()
+		return allowed.(bool), nil
+	}
+	sarCacheRequests.WithLabelValues(c.name, "miss").Inc()
+
+	allowed, err, _ := c.group.Do(key, func() (interface{}, error) {
+		allowed, err := review()
+		if err != nil {
+			return false, err
+		}
+		c.cache.Add(key, allowed, c.ttl)
+		return allowed, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return allowed.(bool), nil
+}
+
+// reviewKey returns the cache key of a review for the user on the object. The groups of the user are part of the
+// key, since the RBAC rules may be bound to the groups.
+func reviewKey(userInfo authenticationv1.UserInfo, name string) string {
+	groups := append([]string{}, userInfo.Groups...)
+	sort.Strings(groups)
+	return fmt.Sprintf("%s/%s/%s/%s", userInfo.Username, userInfo.UID, strings.Join(groups, ","), name)
+}
@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestSubjectAccessReviewCache(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	cache := newSubjectAccessReviewCache("test", 10, 10*time.Second, fakeClock)
+
+	reviews := 0
+	review := func(allowed bool, err error) func() (bool, error) {
+		return func() (bool, error) {
+			reviews++
+			return allowed, err
+		}
+	}
+
+	hits := testutil.ToFloat64(sarCacheRequests.WithLabelValues("test", "hit"))
+	misses := testutil.ToFloat64(sarCacheRequests.WithLabelValues("test", "miss"))
+
+	// the error is not cached
+	if _, err := cache.allowed("key", review(false, fmt.Errorf("failed"))); err == nil {
+		t.Errorf("expected error, but got nil")
+	}
+	// the result is cached
+	if allowed, _ := cache.allowed("key", review(true, nil)); !allowed {
+		t.Errorf("expected allowed, but got denied")
+	}
+	if allowed, _ := cache.allowed("key", review(false, nil)); !allowed {
+		t.Errorf("expected cached result allowed, but got denied")
+	}
+	if reviews != 2 {
+		t.Errorf("expected 2 reviews, but got %d", reviews)
+	}
+
+	// the result is expired
+	fakeClock.Step(11 * time.Second)
+	if allowed, _ := cache.allowed("key", review(false, nil)); allowed {
+		t.Errorf("expected denied, but got allowed")
+	}
+	if reviews != 3 {
+		t.Errorf("expected 3 reviews, but got %d", reviews)
+	}
+
+	if actual := testutil.ToFloat64(sarCacheRequests.WithLabelValues("test", "hit")) - hits; actual != 1 {
+		t.Errorf("expected 1 cache hit, but got %v", actual)
+	}
+	if actual := testutil.ToFloat64(sarCacheRequests.WithLabelValues("test", "miss")) - misses; actual != 3 {
+		t.Errorf("expected 3 cache misses, but got %v", actual)
+	}
+}
+
+func TestSubjectAccessReviewCacheCoalesce(t *testing.T) {
+	cache := newSubjectAccessReviewCache("test", 10, 10*time.Second, clocktesting.NewFakeClock(time.Now()))
+
+	var reviews int32
+	release := make(chan struct{})
+	review := func() (bool, error) {
+		atomic.AddInt32(&reviews, 1)
+		<-release
+		return true, nil
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if allowed, err := cache.allowed("key", review); !allowed || err != nil {
+				t.Errorf("expected allowed, but got %v, %v", allowed, err)
+			}
+		}()
+	}
+	// wait for the first review to start
+	for atomic.LoadInt32(&reviews) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if actual := atomic.LoadInt32(&reviews); actual != 1 {
+		t.Errorf("expected 1 review, but got %d", actual)
+	}
+}
+
+func TestReviewKey(t *testing.T) {
+	user1 := authenticationv1.UserInfo{Username: "user", Groups: []string{"b", "a"}}
+	user2 := authenticationv1.UserInfo{Username: "user", Groups: []string{"a", "b"}}
+	user3 := authenticationv1.UserInfo{Username: "user", Groups: []string{"a"}}
+
+	if reviewKey(user1, "cluster1") != reviewKey(user2, "cluster1") {
+		t.Errorf("expected the same key regardless of the group order")
+	}
+	if reviewKey(user1, "cluster1") == reviewKey(user3, "cluster1") {
+		t.Errorf("expected different keys for different groups")
+	}
+	if reviewKey(user1, "cluster1") == reviewKey(user1, "cluster2") {
+		t.Errorf("expected different keys for different clusters")
+	}
+
+	var nilCache *subjectAccessReviewCache
+	if allowed, _ := nilCache.allowed("key", func() (bool, error) { return true, nil }); !allowed {
+		t.Errorf("expected nil cache calls the review")
+	}
+}
@@ -1,13 +1,44 @@
 package v1
 
 import (
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/clock"
 	v1 "open-cluster-management.io/api/cluster/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+var (
+	scheme = runtime.NewScheme()
+	// decoder decodes the raw objects of the admission requests which are not decoded by the webhook framework,
+	// e.g. the old objects on the mutations, with the codecs of the scheme
+	decoder *admission.Decoder
+)
+
+func init() {
+	utilruntime.Must(v1.Install(scheme))
+
+	var err error
+	decoder, err = admission.NewDecoder(scheme)
+	utilruntime.Must(err)
+}
+
 type ManagedClusterWebhook struct {
+	// MetadataAllowedPrefixes are the prefixes of the labels and annotations the agents are allowed to set or change on
+	// their ManagedClusters, the metadata are not restricted if it is empty
+	MetadataAllowedPrefixes []string
+	// NamePolicy is the naming convention enforced on creating the ManagedClusters, only the format of the
+	// namespace names is required if it is nil
+	NamePolicy *ClusterNamePolicy
+	// RequireAcceptanceReview requires the ManagedClusters to be approved with the acceptance review annotation
+	// before they are accepted, unless they are accepted by the reviewers
+	RequireAcceptanceReview bool
+
 	kubeClient kubernetes.Interface
+	// acceptReviewCache caches the results of the reviews on updating the HubAcceptsClient field
+	acceptReviewCache *subjectAccessReviewCache
 }
 
 func (r *ManagedClusterWebhook) Init(mgr ctrl.Manager) error {
@@ -15,6 +46,7 @@ func (r *ManagedClusterWebhook) Init(mgr ctrl.Manager) error {
 	if err != nil {
 		return err
 	}
+	r.acceptReviewCache = newSubjectAccessReviewCache("accept", defaultSARCacheSize, defaultSARCacheTTL, clock.RealClock{})
 	r.kubeClient, err = kubernetes.NewForConfig(mgr.GetConfig())
 	return err
 }
@@ -0,0 +1,176 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	ocmfeature "open-cluster-management.io/api/feature"
+	"open-cluster-management.io/registration/pkg/features"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// newBenchmarkCluster returns a ManagedCluster with the metadata, spec and status of a typical joined cluster
+func newBenchmarkCluster(withTaints bool) *clusterv1.ManagedCluster {
+	cluster := &clusterv1.ManagedCluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "ManagedCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "cluster1",
+			ResourceVersion: "100",
+			Labels: map[string]string{
+				clusterv1beta2.ClusterSetLabel: defaultClusterSetName,
+				"cloud":                        "Amazon",
+				"vendor":                       "OpenShift",
+			},
+			Annotations: map[string]string{
+				"agent.open-cluster-management.io/identity": "cluster1-agent",
+			},
+		},
+		Spec: clusterv1.ManagedClusterSpec{
+			HubAcceptsClient:     true,
+			LeaseDurationSeconds: 60,
+			ManagedClusterClientConfigs: []clusterv1.ClientConfig{
+				{URL: "https://api.cluster1.example.com:6443"},
+			},
+		},
+	}
+	for i := 0; i < 20; i++ {
+		cluster.Status.ClusterClaims = append(cluster.Status.ClusterClaims, clusterv1.ManagedClusterClaim{
+			Name:  fmt.Sprintf("claim%d.open-cluster-management.io", i),
+			Value: fmt.Sprintf("value%d", i),
+		})
+	}
+	if withTaints {
+		cluster.Spec.Taints = []clusterv1.Taint{
+			{
+				Key:       clusterv1.ManagedClusterTaintUnavailable,
+				Effect:    clusterv1.TaintEffectNoSelect,
+				TimeAdded: metav1.NewTime(time.Now().Add(-10 * time.Minute).Truncate(time.Second)),
+			},
+		}
+	}
+	return cluster
+}
+
+func newAdmissionRequest(b *testing.B, operation admissionv1.Operation, obj, oldObj *clusterv1.ManagedCluster) admission.Request {
+	req := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:       "uid",
+			Operation: operation,
+			Kind: metav1.GroupVersionKind{
+				Group:   clusterv1.GroupName,
+				Version: "v1",
+				Kind:    "ManagedCluster",
+			},
+			Resource: metav1.GroupVersionResource{
+				Group:    clusterv1.GroupName,
+				Version:  "v1",
+				Resource: "managedclusters",
+			},
+			Name: obj.Name,
+		},
+	}
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		b.Fatal(err)
+	}
+	req.Object = apiruntime.RawExtension{Raw: raw}
+	if oldObj != nil {
+		raw, err := json.Marshal(oldObj)
+		if err != nil {
+			b.Fatal(err)
+		}
+		req.OldObject = apiruntime.RawExtension{Raw: raw}
+	}
+	return req
+}
+
+func newAdmissionWebhook(b *testing.B, wh *admission.Webhook) *admission.Webhook {
+	if err := wh.InjectScheme(scheme); err != nil {
+		b.Fatal(err)
+	}
+	return wh
+}
+
+// BenchmarkManagedClusterMutatingWebhook measures the admission requests of the ManagedClusters going through the
+// mutating webhook, including the decoding of the objects and the generation of the patches.
+func BenchmarkManagedClusterMutatingWebhook(b *testing.B) {
+	runtime.Must(features.DefaultHubMutableFeatureGate.Add(ocmfeature.DefaultHubRegistrationFeatureGates))
+	wh := newAdmissionWebhook(b, admission.WithCustomDefaulter(&clusterv1.ManagedCluster{}, &ManagedClusterWebhook{}))
+
+	cases := []struct {
+		name string
+		req  admission.Request
+	}{
+		{
+			name: "create",
+			req:  newAdmissionRequest(b, admissionv1.Create, newBenchmarkCluster(false), nil),
+		},
+		{
+			name: "update without taints",
+			req:  newAdmissionRequest(b, admissionv1.Update, newBenchmarkCluster(false), newBenchmarkCluster(false)),
+		},
+		{
+			name: "update with taints",
+			req:  newAdmissionRequest(b, admissionv1.Update, newBenchmarkCluster(true), newBenchmarkCluster(true)),
+		},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if resp := wh.Handle(context.Background(), c.req); !resp.Allowed {
+					b.Fatalf("expected the request is allowed, but got %v", resp.Result)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkManagedClusterValidatingWebhook measures the admission requests of the ManagedClusters going through the
+// validating webhook, the requests do not need the subject access reviews.
+func BenchmarkManagedClusterValidatingWebhook(b *testing.B) {
+	wh := newAdmissionWebhook(b, admission.WithCustomValidator(&clusterv1.ManagedCluster{}, &ManagedClusterWebhook{
+		kubeClient: kubefake.NewSimpleClientset(),
+	}))
+
+	createdCluster := newBenchmarkCluster(false)
+	createdCluster.Spec.HubAcceptsClient = false
+	delete(createdCluster.Labels, clusterv1beta2.ClusterSetLabel)
+	cases := []struct {
+		name string
+		req  admission.Request
+	}{
+		{
+			name: "create",
+			req:  newAdmissionRequest(b, admissionv1.Create, createdCluster, nil),
+		},
+		{
+			name: "update",
+			req:  newAdmissionRequest(b, admissionv1.Update, newBenchmarkCluster(true), newBenchmarkCluster(true)),
+		},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if resp := wh.Handle(context.Background(), c.req); !resp.Allowed {
+					b.Fatalf("expected the request is allowed, but got %v", resp.Result)
+				}
+			}
+		})
+	}
+}
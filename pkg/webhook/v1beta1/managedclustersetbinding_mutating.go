@@ -0,0 +1,66 @@
+package v1beta1
+
+import (
+	"context"
+	"encoding/json"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"open-cluster-management.io/api/cluster/v1beta1"
+	"open-cluster-management.io/registration/pkg/helpers"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var _ webhook.CustomDefaulter = &ManagedClusterSetBindingWebhook{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (b *ManagedClusterSetBindingWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	binding, ok := obj.(*v1beta1.ManagedClusterSetBinding)
+	if !ok {
+		return apierrors.NewBadRequest("Request clustersetbinding obj format is not right")
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	return SetBinder(binding, req)
+}
+
+// SetBinder records the username and groups of the user who creates the binding in the binder annotation. The
+// annotation of the existing binding is kept on the updates, so the binder cannot be changed by anyone else.
+func SetBinder(binding metav1.Object, req admission.Request) error {
+	binder := ""
+	if len(req.OldObject.Raw) > 0 {
+		oldBinding := &metav1.PartialObjectMetadata{}
+		if err := decoder.DecodeRaw(req.OldObject, oldBinding); err != nil {
+			return apierrors.NewBadRequest(err.Error())
+		}
+		binder = oldBinding.Annotations[helpers.ClusterSetBindingBinderAnnotation]
+	} else {
+		data, err := json.Marshal(authenticationv1.UserInfo{
+			Username: req.UserInfo.Username,
+			Groups:   req.UserInfo.Groups,
+		})
+		if err != nil {
+			return apierrors.NewBadRequest(err.Error())
+		}
+		binder = string(data)
+	}
+
+	annotations := binding.GetAnnotations()
+	if len(binder) == 0 {
+		delete(annotations, helpers.ClusterSetBindingBinderAnnotation)
+		return nil
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[helpers.ClusterSetBindingBinderAnnotation] = binder
+	binding.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,91 @@
+package v1beta1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"open-cluster-management.io/api/cluster/v1beta1"
+	"open-cluster-management.io/registration/pkg/helpers"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestDefault(t *testing.T) {
+	cases := []struct {
+		name           string
+		oldBinder      *string
+		binder         string
+		expectedBinder string
+	}{
+		{
+			name:           "record the binder on creating",
+			binder:         "forged",
+			expectedBinder: `{"username":"user1","groups":["group1"]}`,
+		},
+		{
+			name:           "keep the binder on updating",
+			oldBinder:      stringPtr(`{"username":"user2"}`),
+			binder:         "forged",
+			expectedBinder: `{"username":"user2"}`,
+		},
+		{
+			name:      "binding without binder",
+			oldBinder: stringPtr(""),
+			binder:    "forged",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			binding := &v1beta1.ManagedClusterSetBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "ns-1",
+					Name:        "set-1",
+					Annotations: map[string]string{helpers.ClusterSetBindingBinderAnnotation: c.binder},
+				},
+				Spec: v1beta1.ManagedClusterSetBindingSpec{ClusterSet: "set-1"},
+			}
+
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UserInfo: authenticationv1.UserInfo{
+						Username: "user1",
+						UID:      "uid1",
+						Groups:   []string{"group1"},
+					},
+				},
+			}
+			if c.oldBinder != nil {
+				oldBinding := binding.DeepCopy()
+				oldBinding.Annotations = map[string]string{}
+				if len(*c.oldBinder) > 0 {
+					oldBinding.Annotations[helpers.ClusterSetBindingBinderAnnotation] = *c.oldBinder
+				}
+				data, err := json.Marshal(oldBinding)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.OldObject = runtime.RawExtension{Raw: data}
+			}
+
+			w := ManagedClusterSetBindingWebhook{}
+			if err := w.Default(admission.NewContextWithRequest(context.Background(), req), binding); err != nil {
+				t.Fatal(err)
+			}
+
+			binder, ok := binding.Annotations[helpers.ClusterSetBindingBinderAnnotation]
+			if binder != c.expectedBinder || ok != (len(c.expectedBinder) > 0) {
+				t.Errorf("expected binder %q, but got %q", c.expectedBinder, binder)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
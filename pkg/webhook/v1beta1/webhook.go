@@ -4,9 +4,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
 	"open-cluster-management.io/api/cluster/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 var (
@@ -15,6 +17,21 @@ var (
 	Install = schemeBuilder.AddToScheme
 )
 
+var (
+	scheme = runtime.NewScheme()
+	// decoder decodes the raw objects of the admission requests which are not decoded by the webhook framework,
+	// e.g. the metadata of the old bindings on the mutations, with the codecs of the scheme
+	decoder *admission.Decoder
+)
+
+func init() {
+	utilruntime.Must(Install(scheme))
+
+	var err error
+	decoder, err = admission.NewDecoder(scheme)
+	utilruntime.Must(err)
+}
+
 // Adds the list of known types to api.Scheme.
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(v1beta1.GroupVersion,
@@ -56,6 +73,7 @@ func (b *ManagedClusterSetBindingWebhook) SetExternalKubeClientSet(client kubern
 func (b *ManagedClusterSetBindingWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		WithValidator(b).
+		WithDefaulter(b).
 		For(&v1beta1.ManagedClusterSetBinding{}).
 		Complete()
 }
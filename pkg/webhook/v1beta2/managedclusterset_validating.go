@@ -0,0 +1,98 @@
+package v1beta2
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"open-cluster-management.io/api/cluster/v1beta2"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var _ webhook.CustomValidator = &ManagedClusterSetWebhook{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type. The clustersets of
+// v1beta1 are converted to v1beta2 and validated by the same webhook.
+func (w *ManagedClusterSetWebhook) ValidateCreate(_ context.Context, obj runtime.Object) error {
+	clusterSet, ok := obj.(*ManagedClusterSet)
+	if !ok {
+		return apierrors.NewBadRequest("Request clusterset obj format is not right")
+	}
+	return validateClusterSelector(clusterSet.Spec.ClusterSelector)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (w *ManagedClusterSetWebhook) ValidateUpdate(_ context.Context, _, newObj runtime.Object) error {
+	clusterSet, ok := newObj.(*ManagedClusterSet)
+	if !ok {
+		return apierrors.NewBadRequest("Request clusterset obj format is not right")
+	}
+	return validateClusterSelector(clusterSet.Spec.ClusterSelector)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type. The deletion of a
+// clusterset which still has member clusters or bindings is denied if the deletion protection is enabled, since the
+// placements of the bound namespaces lose the clusters of the clusterset once it is deleted.
+func (w *ManagedClusterSetWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	if !w.DenyDeletionInUse {
+		return nil
+	}
+	clusterSet, ok := obj.(*ManagedClusterSet)
+	if !ok {
+		return apierrors.NewBadRequest("Request clusterset obj format is not right")
+	}
+
+	selector, err := v1beta2.BuildClusterSelector(&clusterSet.ManagedClusterSet)
+	if err != nil {
+		// the members of a clusterset with an invalid selector cannot be evaluated, so it is not protected
+		return nil
+	}
+	clusters, err := w.clusterClient.ClusterV1().ManagedClusters().List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+		Limit:         1,
+	})
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	if len(clusters.Items) > 0 {
+		return apierrors.NewForbidden(v1beta2.Resource("managedclustersets"), clusterSet.Name,
+			fmt.Errorf("the ManagedClusterSet still has member clusters, e.g. %q", clusters.Items[0].Name))
+	}
+
+	bindings, err := w.clusterClient.ClusterV1beta2().ManagedClusterSetBindings(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	for _, binding := range bindings.Items {
+		if binding.Spec.ClusterSet == clusterSet.Name {
+			return apierrors.NewForbidden(v1beta2.Resource("managedclustersets"), clusterSet.Name,
+				fmt.Errorf("the ManagedClusterSet is still bound in namespace %q", binding.Namespace))
+		}
+	}
+	return nil
+}
+
+// validateClusterSelector verifies the label selector is set and valid only with the LabelSelector selector type
+func validateClusterSelector(selector v1beta2.ManagedClusterSelector) error {
+	switch selector.SelectorType {
+	case "", v1beta2.ExclusiveClusterSetLabel:
+		if selector.LabelSelector != nil {
+			return apierrors.NewBadRequest(fmt.Sprintf(
+				"The labelSelector must not be set with the selector type %q", v1beta2.ExclusiveClusterSetLabel))
+		}
+	case v1beta2.LabelSelector:
+		if selector.LabelSelector == nil {
+			return apierrors.NewBadRequest(fmt.Sprintf(
+				"The labelSelector is required by the selector type %q", v1beta2.LabelSelector))
+		}
+		if _, err := metav1.LabelSelectorAsSelector(selector.LabelSelector); err != nil {
+			return apierrors.NewBadRequest(fmt.Sprintf("The labelSelector is invalid: %v", err))
+		}
+	default:
+		return apierrors.NewBadRequest(fmt.Sprintf("The selector type %q is not supported", selector.SelectorType))
+	}
+	return nil
+}
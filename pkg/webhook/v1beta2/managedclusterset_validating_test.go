@@ -0,0 +1,152 @@
+package v1beta2
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/api/cluster/v1beta2"
+)
+
+func TestValidateClusterSet(t *testing.T) {
+	cases := []struct {
+		name          string
+		selector      v1beta2.ManagedClusterSelector
+		expectedError bool
+	}{
+		{
+			name: "empty selector",
+		},
+		{
+			name:     "exclusive clusterset label",
+			selector: v1beta2.ManagedClusterSelector{SelectorType: v1beta2.ExclusiveClusterSetLabel},
+		},
+		{
+			name: "exclusive clusterset label with label selector",
+			selector: v1beta2.ManagedClusterSelector{
+				SelectorType:  v1beta2.ExclusiveClusterSetLabel,
+				LabelSelector: &metav1.LabelSelector{},
+			},
+			expectedError: true,
+		},
+		{
+			name: "label selector",
+			selector: v1beta2.ManagedClusterSelector{
+				SelectorType: v1beta2.LabelSelector,
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"vendor": "OpenShift"},
+				},
+			},
+		},
+		{
+			name:          "label selector without label selector",
+			selector:      v1beta2.ManagedClusterSelector{SelectorType: v1beta2.LabelSelector},
+			expectedError: true,
+		},
+		{
+			name: "invalid label selector",
+			selector: v1beta2.ManagedClusterSelector{
+				SelectorType: v1beta2.LabelSelector,
+				LabelSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "vendor", Operator: "Unknown"},
+					},
+				},
+			},
+			expectedError: true,
+		},
+		{
+			name:          "unsupported selector type",
+			selector:      v1beta2.ManagedClusterSelector{SelectorType: "SingleClusterLabel"},
+			expectedError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterSet := &ManagedClusterSet{
+				v1beta2.ManagedClusterSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "mcs1"},
+					Spec:       v1beta2.ManagedClusterSetSpec{ClusterSelector: c.selector},
+				},
+			}
+
+			w := &ManagedClusterSetWebhook{}
+			createErr := w.ValidateCreate(context.Background(), clusterSet)
+			updateErr := w.ValidateUpdate(context.Background(), clusterSet, clusterSet)
+			if (createErr != nil) != c.expectedError || (updateErr != nil) != c.expectedError {
+				t.Errorf("expected error %v, but got %v and %v", c.expectedError, createErr, updateErr)
+			}
+		})
+	}
+}
+
+func TestValidateClusterSetDelete(t *testing.T) {
+	cases := []struct {
+		name              string
+		denyDeletionInUse bool
+		objects           []runtime.Object
+		expectedError     bool
+	}{
+		{
+			name: "deletion protection is disabled",
+			objects: []runtime.Object{
+				newManagedCluster("cluster1", map[string]string{v1beta2.ClusterSetLabel: "mcs1"}),
+			},
+		},
+		{
+			name:              "clusterset is not in use",
+			denyDeletionInUse: true,
+			objects: []runtime.Object{
+				newManagedCluster("cluster1", map[string]string{v1beta2.ClusterSetLabel: "mcs2"}),
+				newManagedClusterSetBinding("ns1", "mcs2"),
+			},
+		},
+		{
+			name:              "clusterset has members",
+			denyDeletionInUse: true,
+			objects: []runtime.Object{
+				newManagedCluster("cluster1", map[string]string{v1beta2.ClusterSetLabel: "mcs1"}),
+			},
+			expectedError: true,
+		},
+		{
+			name:              "clusterset has bindings",
+			denyDeletionInUse: true,
+			objects:           []runtime.Object{newManagedClusterSetBinding("ns1", "mcs1")},
+			expectedError:     true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := &ManagedClusterSetWebhook{DenyDeletionInUse: c.denyDeletionInUse}
+			w.SetExternalClusterClientSet(clusterfake.NewSimpleClientset(c.objects...))
+
+			clusterSet := &ManagedClusterSet{
+				v1beta2.ManagedClusterSet{ObjectMeta: metav1.ObjectMeta{Name: "mcs1"}},
+			}
+			err := w.ValidateDelete(context.Background(), clusterSet)
+			if (err != nil) != c.expectedError {
+				t.Errorf("expected error %v, but got %v", c.expectedError, err)
+			}
+		})
+	}
+}
+
+func newManagedCluster(name string, labels map[string]string) *clusterv1.ManagedCluster {
+	return &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+	}
+}
+
+func newManagedClusterSetBinding(namespace, clusterSet string) *v1beta2.ManagedClusterSetBinding {
+	return &v1beta2.ManagedClusterSetBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: clusterSet},
+		Spec:       v1beta2.ManagedClusterSetBindingSpec{ClusterSet: clusterSet},
+	}
+}
@@ -0,0 +1,29 @@
+package v1beta2
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"open-cluster-management.io/api/cluster/v1beta2"
+	internalv1beta1 "open-cluster-management.io/registration/pkg/webhook/v1beta1"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var _ webhook.CustomDefaulter = &ManagedClusterSetBindingWebhook{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (b *ManagedClusterSetBindingWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	binding, ok := obj.(*v1beta2.ManagedClusterSetBinding)
+	if !ok {
+		return apierrors.NewBadRequest("Request clustersetbinding obj format is not right")
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	return internalv1beta1.SetBinder(binding, req)
+}
@@ -4,6 +4,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
+	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	"open-cluster-management.io/api/cluster/v1beta2"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
@@ -32,12 +33,40 @@ type ManagedClusterSetBindingWebhook struct {
 	kubeClient kubernetes.Interface
 }
 
+// ManagedClusterSetWebhook validates the ManagedClusterSets. The deletion of a clusterset which still has member
+// clusters or bindings is denied if DenyDeletionInUse is true.
+type ManagedClusterSetWebhook struct {
+	DenyDeletionInUse bool
+	clusterClient     clusterclientset.Interface
+}
+
 func (src *ManagedClusterSet) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(src).
 		Complete()
 }
 
+func (w *ManagedClusterSetWebhook) Init(mgr ctrl.Manager) error {
+	err := w.SetupWebhookWithManager(mgr)
+	if err != nil {
+		return err
+	}
+	w.clusterClient, err = clusterclientset.NewForConfig(mgr.GetConfig())
+	return err
+}
+
+// SetExternalClusterClientSet is function to enable the webhook injecting to kube admssion
+func (w *ManagedClusterSetWebhook) SetExternalClusterClientSet(client clusterclientset.Interface) {
+	w.clusterClient = client
+}
+
+func (w *ManagedClusterSetWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&ManagedClusterSet{}).
+		WithValidator(w).
+		Complete()
+}
+
 func (b *ManagedClusterSetBindingWebhook) Init(mgr ctrl.Manager) error {
 	err := b.SetupWebhookWithManager(mgr)
 	if err != nil {
@@ -55,6 +84,7 @@ func (b *ManagedClusterSetBindingWebhook) SetExternalKubeClientSet(client kubern
 func (b *ManagedClusterSetBindingWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		WithValidator(b).
+		WithDefaulter(b).
 		For(&v1beta2.ManagedClusterSetBinding{}).
 		Complete()
 }
@@ -0,0 +1,68 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+var _ = ginkgo.Describe("Network partition", func() {
+	var (
+		suffix      string
+		clusterName string
+		u           *utilClients
+	)
+
+	ginkgo.BeforeEach(func() {
+		suffix = rand.String(6)
+		clusterName = fmt.Sprintf("loopback-e2e-%v", suffix)
+		u = &utilClients{
+			hubClient:         hubClient,
+			hubDynamicClient:  hubDynamicClient,
+			hubAddOnClient:    hubAddOnClient,
+			clusterClient:     clusterClient,
+			registrationImage: registrationImage,
+		}
+
+		ginkgo.By(fmt.Sprintf("Creating managed cluster %q", clusterName))
+		_, err := u.createManagedCluster(clusterName, suffix)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	})
+
+	ginkgo.AfterEach(func() {
+		ginkgo.By(fmt.Sprintf("Cleaning managed cluster %q", clusterName))
+		err := u.cleanupManagedCluster(clusterName, suffix)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("Should set the cluster to unknown once the hub is unreachable and recover once it is healed", func() {
+		availableStatus := func() (metav1.ConditionStatus, error) {
+			cluster, err := clusterClient.ClusterV1().ManagedClusters().Get(context.TODO(), clusterName, metav1.GetOptions{})
+			if err != nil {
+				return "", err
+			}
+			cond := meta.FindStatusCondition(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable)
+			if cond == nil {
+				return "", nil
+			}
+			return cond.Status, nil
+		}
+
+		// the lease duration of the loopback cluster is 5 seconds, so its grace period is 25 seconds
+		ginkgo.By(fmt.Sprintf("Partitioning the agent of managed cluster %q from the hub", clusterName))
+		gomega.Expect(u.partitionSpokeAgent(suffix)).ToNot(gomega.HaveOccurred())
+		gomega.Eventually(availableStatus, 2*eventuallyTimeout, eventuallyInterval).Should(gomega.Equal(metav1.ConditionUnknown))
+
+		ginkgo.By(fmt.Sprintf("Healing the partition of the agent of managed cluster %q", clusterName))
+		gomega.Expect(u.healSpokeAgent(suffix)).ToNot(gomega.HaveOccurred())
+		gomega.Eventually(availableStatus, 3*eventuallyTimeout, eventuallyInterval).Should(gomega.Equal(metav1.ConditionTrue))
+	})
+})
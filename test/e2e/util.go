@@ -285,6 +285,30 @@ func (u *utilClients) createManagedCluster(clusterName, suffix string) (*cluster
 	return managedCluster, err
 }
 
+// partitionSpokeAgent simulates the network partition between the agent of the loopback cluster and the hub by
+// scaling the agent down, so the hub stops receiving the lease renewals and the status updates of the cluster
+func (u *utilClients) partitionSpokeAgent(suffix string) error {
+	return u.scaleSpokeAgent(suffix, 0)
+}
+
+// healSpokeAgent heals the network partition of the agent of the loopback cluster by scaling the agent up
+func (u *utilClients) healSpokeAgent(suffix string) error {
+	return u.scaleSpokeAgent(suffix, 1)
+}
+
+func (u *utilClients) scaleSpokeAgent(suffix string, replicas int32) error {
+	deployments := u.hubClient.AppsV1().Deployments(fmt.Sprintf("loopback-spoke-%v", suffix))
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		deployment, err := deployments.Get(context.TODO(), "spoke-agent", metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		deployment.Spec.Replicas = &replicas
+		_, err = deployments.Update(context.TODO(), deployment, metav1.UpdateOptions{})
+		return err
+	})
+}
+
 func (u *utilClients) createManagedClusterAddOn(managedCluster *clusterv1.ManagedCluster, addOnName string) (*addonv1alpha1.ManagedClusterAddOn, error) {
 	addOn := &addonv1alpha1.ManagedClusterAddOn{
 		ObjectMeta: metav1.ObjectMeta{
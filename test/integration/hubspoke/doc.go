@@ -0,0 +1,6 @@
+// Package hubspoke provides integration tests for the registration between a hub and a managed cluster which are
+// backed by separate kube-apiservers. The hub controllers and the registration agent run in-process against them,
+// so the requests of the agent reach the hub only through the bootstrap and hub kubeconfigs, the test cases include
+// - managed cluster bootstrap, csr approval, acceptance, joining and availability
+// - managed cluster lease renewal on the hub
+package hubspoke
@@ -0,0 +1,154 @@
+package hubspoke_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+
+	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/clientcert"
+	"open-cluster-management.io/registration/pkg/hub"
+	"open-cluster-management.io/registration/pkg/spoke/managedcluster"
+	"open-cluster-management.io/registration/test/integration/util"
+
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const (
+	eventuallyTimeout  = 30 // seconds
+	eventuallyInterval = 1  // seconds
+)
+
+// the hub and the managed cluster are backed by separate kube-apiservers, the hub one authenticates the agent with
+// the certificates signed by the test authn
+var hubEnv *envtest.Environment
+var spokeEnv *envtest.Environment
+
+var hubCfg *rest.Config
+var spokeCfg *rest.Config
+var bootstrapKubeConfigFile string
+
+var authn *util.TestAuthn
+
+var hubKubeClient kubernetes.Interface
+var hubClusterClient clusterclientset.Interface
+var spokeKubeClient kubernetes.Interface
+
+var agentNamespace string
+
+var ctx context.Context
+var cancel context.CancelFunc
+
+func TestHubSpoke(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Hub Spoke Integration Suite")
+}
+
+var _ = ginkgo.BeforeSuite(func() {
+	logf.SetLogger(zap.New(zap.WriteTo(ginkgo.GinkgoWriter), zap.UseDevMode(true)))
+
+	ginkgo.By("bootstrapping the hub and spoke test environments")
+
+	var err error
+
+	ctx, cancel = context.WithCancel(context.TODO())
+
+	// crank up the sync speed
+	transport.CertCallbackRefreshDuration = 5 * time.Second
+	clientcert.ControllerResyncInterval = 5 * time.Second
+	managedcluster.CreatingControllerSyncInterval = 1 * time.Second
+	hub.ResyncInterval = 5 * time.Second
+
+	// start the hub kube-apiserver with the hub CRDs
+	authn = util.DefaultTestAuthn
+	hubAPIServer := &envtest.APIServer{}
+	hubAPIServer.SecureServing.Authn = authn
+	hubEnv = &envtest.Environment{
+		ControlPlane: envtest.ControlPlane{
+			APIServer: hubAPIServer,
+		},
+		ErrorIfCRDPathMissing: true,
+		CRDDirectoryPaths:     []string{filepath.Join(".", "deploy", "hub")},
+	}
+	hubCfg, err = hubEnv.Start()
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	gomega.Expect(hubCfg).ToNot(gomega.BeNil())
+
+	// start the spoke kube-apiserver with the spoke CRDs
+	spokeEnv = &envtest.Environment{
+		ErrorIfCRDPathMissing: true,
+		CRDDirectoryPaths:     []string{filepath.Join(".", "deploy", "spoke")},
+	}
+	spokeCfg, err = spokeEnv.Start()
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	gomega.Expect(spokeCfg).ToNot(gomega.BeNil())
+
+	err = clusterv1.AddToScheme(scheme.Scheme)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// the bootstrap kubeconfig of the agent points to the hub kube-apiserver
+	hubSecurePort := hubEnv.ControlPlane.APIServer.SecureServing.Port
+	gomega.Expect(len(hubSecurePort)).ToNot(gomega.BeZero())
+	hubServerCertFile := fmt.Sprintf("%s/apiserver.crt", hubEnv.ControlPlane.APIServer.CertDir)
+
+	bootstrapKubeConfigFile = path.Join(util.TestDir, "hubspoke", "bootstrap", "kubeconfig")
+	err = authn.CreateBootstrapKubeConfigWithCertAge(bootstrapKubeConfigFile, hubServerCertFile, hubSecurePort, 24*time.Hour)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// prepare clients
+	hubKubeClient, err = kubernetes.NewForConfig(hubCfg)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+	hubClusterClient, err = clusterclientset.NewForConfig(hubCfg)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+	spokeKubeClient, err = kubernetes.NewForConfig(spokeCfg)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+	// the agent namespace is only on the managed cluster
+	agentNamespace = "open-cluster-management-agent"
+	err = util.PrepareSpokeAgentNamespace(spokeKubeClient, agentNamespace)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+	// start the hub controllers against the hub kube-apiserver
+	go func() {
+		m := hub.NewHubManagerOptions()
+		err := m.RunControllerManager(ctx, &controllercmd.ControllerContext{
+			KubeConfig:    hubCfg,
+			EventRecorder: util.NewIntegrationTestEventRecorder("hub"),
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	}()
+})
+
+var _ = ginkgo.AfterSuite(func() {
+	ginkgo.By("tearing down the hub and spoke test environments")
+
+	cancel()
+
+	err := spokeEnv.Stop()
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+	err = hubEnv.Stop()
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+	err = os.RemoveAll(util.TestDir)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+})
@@ -0,0 +1,126 @@
+package hubspoke_test
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+
+	certificates "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/spoke"
+	"open-cluster-management.io/registration/test/integration/util"
+)
+
+var _ = ginkgo.Describe("Registration between a hub and a managed cluster", func() {
+	ginkgo.It("managed cluster should be bootstrapped, accepted, joined and available", func() {
+		var err error
+
+		managedClusterName := "hubspoke-managedcluster"
+		hubKubeconfigSecret := "hubspoke-hub-kubeconfig-secret"
+		hubKubeconfigDir := path.Join(util.TestDir, "hubspoke", "hub-kubeconfig")
+
+		// run registration agent against the spoke kube-apiserver, it reaches the hub with the bootstrap kubeconfig
+		agentOptions := spoke.SpokeAgentOptions{
+			ClusterName:              managedClusterName,
+			BootstrapKubeconfig:      bootstrapKubeConfigFile,
+			HubKubeconfigSecret:      hubKubeconfigSecret,
+			HubKubeconfigDir:         hubKubeconfigDir,
+			ClusterHealthCheckPeriod: 1 * time.Minute,
+		}
+		stop := util.RunAgent("hubspoke", agentOptions, spokeCfg)
+		defer stop()
+
+		ginkgo.By("bootstrap")
+		// the managed cluster and the registration csr should be created on the hub after bootstrap
+		gomega.Eventually(func() error {
+			_, err := util.GetManagedCluster(hubClusterClient, managedClusterName)
+			return err
+		}, eventuallyTimeout, eventuallyInterval).ShouldNot(gomega.HaveOccurred())
+
+		ginkgo.By("csr")
+		// the registration csr requests a kube-apiserver client certificate of the hub
+		var csr *certificates.CertificateSigningRequest
+		gomega.Eventually(func() error {
+			csr, err = util.FindUnapprovedSpokeCSR(hubKubeClient, managedClusterName)
+			return err
+		}, eventuallyTimeout, eventuallyInterval).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(csr.Spec.SignerName).To(gomega.Equal(certificates.KubeAPIServerClientSignerName))
+
+		ginkgo.By("accept")
+		// simulate hub cluster admin to accept the managed cluster and approve the csr
+		err = util.AcceptManagedClusterWithLeaseDuration(hubClusterClient, managedClusterName, util.TestLeaseDurationSeconds)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		err = authn.ApproveSpokeClusterCSR(hubKubeClient, managedClusterName, time.Hour*24)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		assertManagedClusterCondition(managedClusterName, clusterv1.ManagedClusterConditionHubAccepted)
+
+		// the hub kubeconfig secret is filled on the managed cluster only
+		gomega.Eventually(func() error {
+			_, err := util.GetFilledHubKubeConfigSecret(spokeKubeClient, agentNamespace, hubKubeconfigSecret)
+			return err
+		}, eventuallyTimeout, eventuallyInterval).ShouldNot(gomega.HaveOccurred())
+		_, err = hubKubeClient.CoreV1().Secrets(agentNamespace).Get(context.TODO(), hubKubeconfigSecret, metav1.GetOptions{})
+		gomega.Expect(errors.IsNotFound(err)).To(gomega.BeTrue())
+
+		ginkgo.By("joined")
+		assertManagedClusterCondition(managedClusterName, clusterv1.ManagedClusterConditionJoined)
+
+		ginkgo.By("available")
+		assertManagedClusterCondition(managedClusterName, clusterv1.ManagedClusterConditionAvailable)
+
+		// the agent keeps renewing the lease on the hub with the hub kubeconfig
+		var renewTime time.Time
+		gomega.Eventually(func() error {
+			renewTime, err = getManagedClusterLeaseRenewTime(managedClusterName)
+			return err
+		}, eventuallyTimeout, eventuallyInterval).ShouldNot(gomega.HaveOccurred())
+		gomega.Eventually(func() error {
+			lastRenewTime, err := getManagedClusterLeaseRenewTime(managedClusterName)
+			if err != nil {
+				return err
+			}
+			if !lastRenewTime.After(renewTime) {
+				return fmt.Errorf("lease is not renewed since %v", renewTime)
+			}
+			return nil
+		}, eventuallyTimeout, eventuallyInterval).ShouldNot(gomega.HaveOccurred())
+	})
+})
+
+// assertManagedClusterCondition asserts the managed cluster has the condition true on the hub eventually
+func assertManagedClusterCondition(managedClusterName, conditionType string) {
+	gomega.Eventually(func() error {
+		managedCluster, err := util.GetManagedCluster(hubClusterClient, managedClusterName)
+		if err != nil {
+			return err
+		}
+		if !meta.IsStatusConditionTrue(managedCluster.Status.Conditions, conditionType) {
+			return fmt.Errorf("condition %s of cluster %q is not true: %v",
+				conditionType, managedClusterName, managedCluster.Status.Conditions)
+		}
+		return nil
+	}, eventuallyTimeout, eventuallyInterval).ShouldNot(gomega.HaveOccurred())
+}
+
+// getManagedClusterLeaseRenewTime returns the renew time of the managed cluster lease on the hub
+func getManagedClusterLeaseRenewTime(managedClusterName string) (time.Time, error) {
+	lease, err := hubKubeClient.CoordinationV1().Leases(managedClusterName).Get(
+		context.TODO(), "managed-cluster-lease", metav1.GetOptions{})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if lease.Spec.RenewTime == nil {
+		return time.Time{}, fmt.Errorf("lease of cluster %q is not renewed", managedClusterName)
+	}
+	return lease.Spec.RenewTime.Time, nil
+}